@@ -0,0 +1,80 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/luxfi/crypto/address"
+	"github.com/luxfi/ids"
+)
+
+// Chain aliases used as the prefix of formatted addresses
+const (
+	XChainAlias = "X"
+	PChainAlias = "P"
+	CChainAlias = "C"
+)
+
+// Bech32 HRPs of the Lux networks
+const (
+	MainnetHRP = "lux"
+	TestnetHRP = "test"
+	LocalHRP   = "local"
+)
+
+var (
+	ErrUnexpectedChainAlias = errors.New("unexpected chain alias")
+	ErrUnexpectedHRP        = errors.New("unexpected HRP")
+)
+
+// FormatAddress renders [addr] as <chainAlias>-<bech32 address>, for example
+// X-lux1...
+func FormatAddress(chainAlias, hrp string, addr ids.ShortID) (string, error) {
+	return address.Format(chainAlias, hrp, addr.Bytes())
+}
+
+// FormatAddresses renders each of [addrs] as with FormatAddress
+func FormatAddresses(chainAlias, hrp string, addrs []ids.ShortID) ([]string, error) {
+	addrStrs := make([]string, len(addrs))
+	for i, addr := range addrs {
+		addrStr, err := FormatAddress(chainAlias, hrp, addr)
+		if err != nil {
+			return nil, err
+		}
+		addrStrs[i] = addrStr
+	}
+	return addrStrs, nil
+}
+
+// ParseAddress parses an address formatted by FormatAddress, returning its
+// chain alias, HRP and address
+func ParseAddress(addrStr string) (string, string, ids.ShortID, error) {
+	chainAlias, hrp, addrBytes, err := address.Parse(addrStr)
+	if err != nil {
+		return "", "", ids.ShortEmpty, err
+	}
+	addr, err := ids.ToShortID(addrBytes)
+	if err != nil {
+		return "", "", ids.ShortEmpty, err
+	}
+	return chainAlias, hrp, addr, nil
+}
+
+// ParseChainAddress parses an address formatted by FormatAddress and checks
+// that it has the expected [chainAlias] and [hrp]
+func ParseChainAddress(addrStr, chainAlias, hrp string) (ids.ShortID, error) {
+	gotAlias, gotHRP, addr, err := ParseAddress(addrStr)
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	if gotAlias != chainAlias {
+		return ids.ShortEmpty, fmt.Errorf("%w: expected %q but got %q", ErrUnexpectedChainAlias, chainAlias, gotAlias)
+	}
+	if gotHRP != hrp {
+		return ids.ShortEmpty, fmt.Errorf("%w: expected %q but got %q", ErrUnexpectedHRP, hrp, gotHRP)
+	}
+	return addr, nil
+}
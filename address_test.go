@@ -0,0 +1,70 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+)
+
+func TestFormatParseAddress(t *testing.T) {
+	require := require.New(t)
+
+	addr := ids.GenerateTestShortID()
+	addrStr, err := FormatAddress(XChainAlias, MainnetHRP, addr)
+	require.NoError(err)
+	require.True(strings.HasPrefix(addrStr, "X-lux1"))
+
+	chainAlias, hrp, parsed, err := ParseAddress(addrStr)
+	require.NoError(err)
+	require.Equal(XChainAlias, chainAlias)
+	require.Equal(MainnetHRP, hrp)
+	require.Equal(addr, parsed)
+
+	parsed, err = ParseChainAddress(addrStr, XChainAlias, MainnetHRP)
+	require.NoError(err)
+	require.Equal(addr, parsed)
+
+	_, err = ParseChainAddress(addrStr, PChainAlias, MainnetHRP)
+	require.ErrorIs(err, ErrUnexpectedChainAlias)
+
+	_, err = ParseChainAddress(addrStr, XChainAlias, TestnetHRP)
+	require.ErrorIs(err, ErrUnexpectedHRP)
+
+	_, _, _, err = ParseAddress(strings.TrimPrefix(addrStr, "X-"))
+	require.Error(err)
+
+	addrStrs, err := FormatAddresses(PChainAlias, TestnetHRP, []ids.ShortID{addr, ids.ShortEmpty})
+	require.NoError(err)
+	require.Len(addrStrs, 2)
+	require.True(strings.HasPrefix(addrStrs[0], "P-test1"))
+}
+
+func FuzzParseAddress(f *testing.F) {
+	for _, hrp := range []string{MainnetHRP, TestnetHRP} {
+		addrStr, err := FormatAddress(XChainAlias, hrp, ids.GenerateTestShortID())
+		require.NoError(f, err)
+		f.Add(addrStr)
+	}
+	f.Add("X-")
+	f.Fuzz(func(t *testing.T, addrStr string) {
+		chainAlias, hrp, addr, err := ParseAddress(addrStr)
+		if err != nil {
+			return
+		}
+
+		// Parsed addresses format to an address that parses back
+		formatted, err := FormatAddress(chainAlias, hrp, addr)
+		require.NoError(t, err)
+		gotAlias, gotHRP, got, err := ParseAddress(formatted)
+		require.NoError(t, err)
+		require.Equal(t, chainAlias, gotAlias)
+		require.Equal(t, hrp, gotHRP)
+		require.Equal(t, addr, got)
+	})
+}
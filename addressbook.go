@@ -0,0 +1,259 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/luxfi/ids"
+)
+
+// addressBookMode keeps address books private to their owner
+const addressBookMode = 0o600
+
+var (
+	ErrUnknownAlias = errors.New("unknown address alias")
+	ErrInvalidAlias = errors.New("invalid address alias")
+)
+
+// AddressBookEntry names an address, which need not be held by any keychain
+type AddressBookEntry struct {
+	Alias   string      `json:"alias"`
+	Address ids.ShortID `json:"address"`
+	Note    string      `json:"note,omitempty"`
+}
+
+// AddressBook maps human aliases, such as "treasury-hot-1", to addresses. An
+// address may have several aliases. Aliases are matched exactly, and cannot
+// contain whitespace or be parsable as an address, so that resolving a string
+// is never ambiguous.
+type AddressBook struct {
+	// path is the file the book is persisted to, or empty if it is only held
+	// in memory
+	path string
+
+	lock    sync.RWMutex
+	entries map[string]AddressBookEntry
+}
+
+// NewAddressBook creates an address book held in memory, initialized with
+// [entries]
+func NewAddressBook(entries ...AddressBookEntry) (*AddressBook, error) {
+	b := &AddressBook{
+		entries: make(map[string]AddressBookEntry, len(entries)),
+	}
+	for _, entry := range entries {
+		if err := validateAlias(entry.Alias); err != nil {
+			return nil, err
+		}
+		b.entries[entry.Alias] = entry
+	}
+	return b, nil
+}
+
+// OpenAddressBook loads the address book persisted at [path], a JSON list of
+// entries. If the file does not exist the book starts empty, and the file is
+// created on the first change. Every change is written to the file before it
+// takes effect.
+func OpenAddressBook(path string) (*AddressBook, error) {
+	var entries []AddressBookEntry
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("invalid address book %s: %w", path, err)
+		}
+	}
+
+	b, err := NewAddressBook(entries...)
+	if err != nil {
+		return nil, err
+	}
+	b.path = path
+	return b, nil
+}
+
+// Set records [entry], replacing the entry of its alias
+func (b *AddressBook) Set(entry AddressBookEntry) error {
+	if err := validateAlias(entry.Alias); err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	previous, existed := b.entries[entry.Alias]
+	b.entries[entry.Alias] = entry
+	if err := b.save(); err != nil {
+		if existed {
+			b.entries[entry.Alias] = previous
+		} else {
+			delete(b.entries, entry.Alias)
+		}
+		return err
+	}
+	return nil
+}
+
+// Remove removes the entry of [alias]
+func (b *AddressBook) Remove(alias string) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	entry, ok := b.entries[alias]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownAlias, alias)
+	}
+	delete(b.entries, alias)
+	if err := b.save(); err != nil {
+		b.entries[alias] = entry
+		return err
+	}
+	return nil
+}
+
+// Entry returns the entry of [alias]
+func (b *AddressBook) Entry(alias string) (AddressBookEntry, bool) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	entry, ok := b.entries[alias]
+	return entry, ok
+}
+
+// Lookup returns the address of [alias]
+func (b *AddressBook) Lookup(alias string) (ids.ShortID, bool) {
+	entry, ok := b.Entry(alias)
+	return entry.Address, ok
+}
+
+// Aliases returns the aliases of [addr], sorted
+func (b *AddressBook) Aliases(addr ids.ShortID) []string {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	var aliases []string
+	for alias, entry := range b.entries {
+		if entry.Address == addr {
+			aliases = append(aliases, alias)
+		}
+	}
+	slices.Sort(aliases)
+	return aliases
+}
+
+// Entries returns every entry of the book, sorted by alias
+func (b *AddressBook) Entries() []AddressBookEntry {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.sortedEntries()
+}
+
+// Resolve returns the address named by [name], which is either an alias of
+// the book or an address, formatted as by FormatAddress or as an
+// ids.ShortID. The chain alias and HRP of a formatted address are not
+// checked.
+func (b *AddressBook) Resolve(name string) (ids.ShortID, error) {
+	if addr, ok := b.Lookup(name); ok {
+		return addr, nil
+	}
+	if addr, ok := parseAnyAddress(name); ok {
+		return addr, nil
+	}
+	return ids.ShortEmpty, fmt.Errorf("%w: %q", ErrUnknownAlias, name)
+}
+
+// GetByAlias returns the signer of [kc] for the address [book] resolves
+// [name] to, such as GetByAlias(kc, book, "treasury-hot-1")
+func GetByAlias(kc Keychain, book *AddressBook, name string) (Signer, error) {
+	addr, err := book.Resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := kc.Get(addr)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s resolves to %s", ErrUnknownAddress, name, addr)
+	}
+	return signer, nil
+}
+
+// sortedEntries assumes the lock is held
+func (b *AddressBook) sortedEntries() []AddressBookEntry {
+	entries := make([]AddressBookEntry, 0, len(b.entries))
+	for _, entry := range b.entries {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(x, y AddressBookEntry) int {
+		return strings.Compare(x.Alias, y.Alias)
+	})
+	return entries
+}
+
+// save writes the book to its file, if it has one. The lock must be held.
+func (b *AddressBook) save() error {
+	if b.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(b.sortedEntries(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that the book is never left
+	// partially written
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), "."+filepath.Base(b.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(addressBookMode); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), b.path)
+}
+
+func validateAlias(alias string) error {
+	if alias == "" {
+		return fmt.Errorf("%w: empty alias", ErrInvalidAlias)
+	}
+	if strings.ContainsFunc(alias, unicode.IsSpace) {
+		return fmt.Errorf("%w: %q contains whitespace", ErrInvalidAlias, alias)
+	}
+	if _, ok := parseAnyAddress(alias); ok {
+		return fmt.Errorf("%w: %q is an address", ErrInvalidAlias, alias)
+	}
+	return nil
+}
+
+// parseAnyAddress parses [s] as an address formatted by FormatAddress or as
+// an ids.ShortID
+func parseAnyAddress(s string) (ids.ShortID, bool) {
+	if _, _, addr, err := ParseAddress(s); err == nil {
+		return addr, true
+	}
+	addr, err := ids.ShortFromString(s)
+	return addr, err == nil
+}
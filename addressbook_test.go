@@ -0,0 +1,169 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressBook(t *testing.T) {
+	require := require.New(t)
+
+	hot := ids.GenerateTestShortID()
+	cold := ids.GenerateTestShortID()
+	book, err := NewAddressBook(
+		AddressBookEntry{Alias: "treasury-hot-1", Address: hot},
+		AddressBookEntry{Alias: "treasury-cold", Address: cold, Note: "vault"},
+	)
+	require.NoError(err)
+
+	addr, ok := book.Lookup("treasury-hot-1")
+	require.True(ok)
+	require.Equal(hot, addr)
+	_, ok = book.Lookup("Treasury-hot-1")
+	require.False(ok)
+
+	require.NoError(book.Set(AddressBookEntry{Alias: "ops", Address: hot}))
+	require.Equal([]string{"ops", "treasury-hot-1"}, book.Aliases(hot))
+	require.Equal([]AddressBookEntry{
+		{Alias: "ops", Address: hot},
+		{Alias: "treasury-cold", Address: cold, Note: "vault"},
+		{Alias: "treasury-hot-1", Address: hot},
+	}, book.Entries())
+
+	require.NoError(book.Remove("ops"))
+	require.ErrorIs(book.Remove("ops"), ErrUnknownAlias)
+	require.Equal([]string{"treasury-hot-1"}, book.Aliases(hot))
+	require.Empty(book.Aliases(ids.GenerateTestShortID()))
+}
+
+func TestAddressBookInvalidAlias(t *testing.T) {
+	addr := ids.GenerateTestShortID()
+	formatted, err := FormatAddress(XChainAlias, MainnetHRP, addr)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		alias string
+	}{
+		{
+			name:  "empty",
+			alias: "",
+		},
+		{
+			name:  "whitespace",
+			alias: "treasury hot",
+		},
+		{
+			name:  "formatted address",
+			alias: formatted,
+		},
+		{
+			name:  "short ID",
+			alias: addr.String(),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+
+			book, err := NewAddressBook()
+			require.NoError(err)
+			require.ErrorIs(book.Set(AddressBookEntry{Alias: test.alias, Address: addr}), ErrInvalidAlias)
+			require.Empty(book.Entries())
+
+			_, err = NewAddressBook(AddressBookEntry{Alias: test.alias, Address: addr})
+			require.ErrorIs(err, ErrInvalidAlias)
+		})
+	}
+}
+
+func TestAddressBookResolve(t *testing.T) {
+	require := require.New(t)
+
+	addr := ids.GenerateTestShortID()
+	book, err := NewAddressBook(AddressBookEntry{Alias: "treasury", Address: addr})
+	require.NoError(err)
+	formatted, err := FormatAddress(PChainAlias, TestnetHRP, addr)
+	require.NoError(err)
+
+	for _, name := range []string{"treasury", formatted, addr.String()} {
+		resolved, err := book.Resolve(name)
+		require.NoError(err)
+		require.Equal(addr, resolved)
+	}
+	_, err = book.Resolve("payroll")
+	require.ErrorIs(err, ErrUnknownAlias)
+}
+
+func TestGetByAlias(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("address-book"), 1)
+	require.NoError(err)
+	held := kc.Addresses().List()[0]
+	book, err := NewAddressBook(
+		AddressBookEntry{Alias: "treasury-hot-1", Address: held},
+		AddressBookEntry{Alias: "exchange", Address: ids.GenerateTestShortID()},
+	)
+	require.NoError(err)
+
+	signer, err := GetByAlias(kc, book, "treasury-hot-1")
+	require.NoError(err)
+	require.Equal(held, signer.Address())
+
+	_, err = GetByAlias(kc, book, "exchange")
+	require.ErrorIs(err, ErrUnknownAddress)
+	_, err = GetByAlias(kc, book, "payroll")
+	require.ErrorIs(err, ErrUnknownAlias)
+}
+
+func TestAddressBookPersistence(t *testing.T) {
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "addressbook.json")
+	book, err := OpenAddressBook(path)
+	require.NoError(err)
+	require.Empty(book.Entries())
+	_, err = os.Stat(path)
+	require.ErrorIs(err, os.ErrNotExist)
+
+	hot := ids.GenerateTestShortID()
+	cold := ids.GenerateTestShortID()
+	require.NoError(book.Set(AddressBookEntry{Alias: "treasury-hot-1", Address: hot}))
+	require.NoError(book.Set(AddressBookEntry{Alias: "treasury-cold", Address: cold, Note: "vault"}))
+	require.NoError(book.Remove("treasury-hot-1"))
+
+	reopened, err := OpenAddressBook(path)
+	require.NoError(err)
+	require.Equal(book.Entries(), reopened.Entries())
+	require.Equal([]AddressBookEntry{{Alias: "treasury-cold", Address: cold, Note: "vault"}}, reopened.Entries())
+
+	// Changes that cannot be persisted do not take effect
+	require.NoError(os.RemoveAll(filepath.Dir(path)))
+	require.Error(book.Set(AddressBookEntry{Alias: "ops", Address: hot}))
+	_, ok := book.Lookup("ops")
+	require.False(ok)
+	require.Error(book.Remove("treasury-cold"))
+	_, ok = book.Lookup("treasury-cold")
+	require.True(ok)
+}
+
+func TestOpenAddressBookInvalid(t *testing.T) {
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "addressbook.json")
+	require.NoError(os.WriteFile(path, []byte("{"), 0o600))
+	_, err := OpenAddressBook(path)
+	require.Error(err)
+
+	require.NoError(os.WriteFile(path, []byte(`[{"alias":"","address":"111111111111111111116DBWJs"}]`), 0o600))
+	_, err = OpenAddressBook(path)
+	require.ErrorIs(err, ErrInvalidAlias)
+}
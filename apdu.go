@@ -0,0 +1,127 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// maxAPDUDataLen is the maximum payload of a short APDU command
+const maxAPDUDataLen = 255
+
+// Status words returned by the Lux ledger app
+const (
+	swOK                = 0x9000
+	swUserRejected      = 0x6985
+	swUserRefused       = 0x5501 // review cancelled on a Stax or Flex
+	swWrongLength       = 0x6700
+	swInvalidData       = 0x6a80
+	swInsNotSupported   = 0x6d00
+	swClaNotSupported   = 0x6e00
+	swAppNotOpen        = 0x6e01
+	swWrongApp          = 0x6511
+	swDeviceLocked      = 0x5515
+	swSecurityCondition = 0x6982
+	swNotEnoughMemory   = 0x6a84
+	// swBlindSigningDisabled is returned for hashes while blind signing is
+	// disabled in the settings of the app
+	swBlindSigningDisabled = 0x6808
+)
+
+var (
+	ErrAPDUTooLarge      = errors.New("APDU payload exceeds the maximum length")
+	ErrInvalidResponse   = errors.New("invalid response from ledger device")
+	ErrUserRejected      = errors.New("request was rejected on the ledger device")
+	ErrDeviceLocked      = errors.New("ledger device is locked")
+	ErrAppNotOpen        = errors.New("lux app is not open on the ledger device")
+	ErrRequestRejected   = errors.New("ledger device rejected the request as invalid")
+	ErrSecurityCondition = errors.New("ledger device security condition not satisfied")
+	ErrPayloadTooLarge   = errors.New("payload exceeds the ledger device limit")
+)
+
+// LimitError reports a payload that exceeds a size limit of the ledger
+// device. It matches ErrPayloadTooLarge.
+type LimitError struct {
+	Size  int
+	Limit int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s: %d > %d bytes", ErrPayloadTooLarge, e.Size, e.Limit)
+}
+
+func (*LimitError) Unwrap() error {
+	return ErrPayloadTooLarge
+}
+
+// StatusError reports an unrecognized status word returned by a ledger device
+type StatusError struct {
+	StatusWord uint16
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ledger device returned status word 0x%04x", e.StatusWord)
+}
+
+// apduCommand is a short ISO 7816-4 command APDU
+type apduCommand struct {
+	cla  byte
+	ins  byte
+	p1   byte
+	p2   byte
+	data []byte
+}
+
+// encode returns CLA || INS || P1 || P2 || Lc || data
+func (c *apduCommand) encode() ([]byte, error) {
+	if len(c.data) > maxAPDUDataLen {
+		return nil, fmt.Errorf("%w: %d > %d", ErrAPDUTooLarge, len(c.data), maxAPDUDataLen)
+	}
+	apdu := make([]byte, 5, 5+len(c.data))
+	apdu[0] = c.cla
+	apdu[1] = c.ins
+	apdu[2] = c.p1
+	apdu[3] = c.p2
+	apdu[4] = byte(len(c.data))
+	return append(apdu, c.data...), nil
+}
+
+// parseAPDUResponse splits a response APDU into its data and checks the
+// trailing status word
+func parseAPDUResponse(resp []byte) ([]byte, error) {
+	if len(resp) < 2 {
+		return nil, ErrInvalidResponse
+	}
+	data := resp[:len(resp)-2]
+	sw := binary.BigEndian.Uint16(resp[len(resp)-2:])
+	if err := statusError(sw); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func statusError(sw uint16) error {
+	switch sw {
+	case swOK:
+		return nil
+	case swUserRejected, swUserRefused:
+		return ErrUserRejected
+	case swDeviceLocked:
+		return ErrDeviceLocked
+	case swInsNotSupported, swClaNotSupported, swAppNotOpen, swWrongApp:
+		return fmt.Errorf("%w (0x%04x)", ErrAppNotOpen, sw)
+	case swWrongLength, swInvalidData:
+		return fmt.Errorf("%w (0x%04x)", ErrRequestRejected, sw)
+	case swSecurityCondition:
+		return ErrSecurityCondition
+	case swBlindSigningDisabled:
+		return fmt.Errorf("%w in the settings of the ledger app", ErrBlindSigningDisabled)
+	case swNotEnoughMemory:
+		return fmt.Errorf("%w (0x%04x)", ErrPayloadTooLarge, sw)
+	default:
+		return &StatusError{StatusWord: sw}
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+const (
+	// minApprovals is the two-person rule
+	minApprovals = 2
+	// defaultApprovalTimeout bounds how long a signing request waits for
+	// its approvals
+	defaultApprovalTimeout = 10 * time.Minute
+)
+
+var (
+	ErrApprovalDenied        = errors.New("signing request was not approved")
+	ErrNotEnoughApprovers    = errors.New("fewer approvers than required approvals")
+	ErrEmptyApproverIdentity = errors.New("approver returned an empty identity")
+	ErrDuplicateApproval     = errors.New("identity already approved the request")
+)
+
+// ApprovalRequest is a signing operation awaiting approval
+type ApprovalRequest struct {
+	// ID identifies the request across approvers and the audit trail
+	ID      string
+	Address ids.ShortID
+	Op      SignOp
+	Payload []byte
+}
+
+// Approver is an approval backend, such as a chat bot prompting an operator,
+// a ticketing system or a second hardware token
+type Approver interface {
+	// Approve asks for the approval of [req] and returns the identity of the
+	// person who approved it. Returning an error denies the request. It must
+	// return once [ctx] is done.
+	Approve(ctx context.Context, req ApprovalRequest) (identity string, err error)
+}
+
+// ApproverFunc adapts a function to the Approver interface
+type ApproverFunc func(ctx context.Context, req ApprovalRequest) (string, error)
+
+func (f ApproverFunc) Approve(ctx context.Context, req ApprovalRequest) (string, error) {
+	return f(ctx, req)
+}
+
+// ApprovalRecord is an entry of the audit trail of DualApproval, written once
+// a request is decided
+type ApprovalRecord struct {
+	ID          string
+	Address     ids.ShortID
+	Op          SignOp
+	PayloadHash [sha256.Size]byte
+	// Approvers are the identities that approved the request, in the order
+	// they approved it
+	Approvers []string
+	Requested time.Time
+	Decided   time.Time
+	// Err is nil if the request was approved and signed, and otherwise the
+	// reason it failed
+	Err error
+}
+
+// DualApprovalConfig configures DualApproval
+type DualApprovalConfig struct {
+	// Approvers are asked for approval of every signing request, concurrently
+	Approvers []Approver
+	// Required is the number of distinct approver identities a request
+	// needs, at least and by default 2
+	Required int
+	// Timeout bounds how long a request waits for its approvals, 10 minutes
+	// if zero
+	Timeout time.Duration
+	// Audit, if set, is called with the record of every request once it is
+	// decided
+	Audit func(ApprovalRecord)
+}
+
+// DualApproval returns a middleware enforcing the two-person rule: signing
+// operations only run once approved by [config].Required distinct approver
+// identities. Approvals with an identity that already approved the request
+// do not count, so one person holding two approval backends cannot approve
+// alone. Requests that can no longer collect enough approvals, because
+// approvers denied them or the timeout expired, fail with
+// ErrApprovalDenied without reaching the signer.
+func DualApproval(config DualApprovalConfig) (Middleware, error) {
+	if config.Required < minApprovals {
+		config.Required = minApprovals
+	}
+	if len(config.Approvers) < config.Required {
+		return nil, ErrNotEnoughApprovers
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultApprovalTimeout
+	}
+	return Intercept(func(signer Signer, op SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		record := ApprovalRecord{
+			ID:          uuid.NewString(),
+			Address:     signer.Address(),
+			Op:          op,
+			PayloadHash: sha256.Sum256(payload),
+			Requested:   time.Now(),
+		}
+		record.Approvers, record.Err = config.approve(ApprovalRequest{
+			ID:      record.ID,
+			Address: record.Address,
+			Op:      op,
+			Payload: slices.Clone(payload),
+		})
+
+		var sig []byte
+		if record.Err == nil {
+			sig, record.Err = next(payload)
+		}
+		record.Decided = time.Now()
+		if config.Audit != nil {
+			config.Audit(record)
+		}
+		return sig, record.Err
+	}), nil
+}
+
+type approval struct {
+	identity string
+	err      error
+}
+
+// approve asks every approver for approval of [req] and returns the
+// identities that approved it once there are enough of them
+func (c *DualApprovalConfig) approve(req ApprovalRequest) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	results := make(chan approval, len(c.Approvers))
+	for _, approver := range c.Approvers {
+		go func() {
+			identity, err := approver.Approve(ctx, req)
+			if err == nil && identity == "" {
+				err = ErrEmptyApproverIdentity
+			}
+			results <- approval{
+				identity: identity,
+				err:      err,
+			}
+		}()
+	}
+
+	var (
+		approvers []string
+		approved  = make(set.Set[string], c.Required)
+		errs      []error
+	)
+	for pending := len(c.Approvers); pending > 0; pending-- {
+		result := <-results
+		switch {
+		case result.err != nil:
+			errs = append(errs, result.err)
+		case approved.Contains(result.identity):
+			errs = append(errs, fmt.Errorf("%w: %s", ErrDuplicateApproval, result.identity))
+		default:
+			approved.Add(result.identity)
+			approvers = append(approvers, result.identity)
+		}
+		if len(approvers) >= c.Required {
+			return approvers, nil
+		}
+		if len(approvers)+pending-1 < c.Required {
+			break
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		errs = append(errs, err)
+	}
+	return approvers, fmt.Errorf("%w: %d of %d approvals: %w", ErrApprovalDenied, len(approvers), c.Required, errors.Join(errs...))
+}
@@ -0,0 +1,194 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// approveAs returns an approver approving every request as [identity]
+func approveAs(identity string) Approver {
+	return ApproverFunc(func(context.Context, ApprovalRequest) (string, error) {
+		return identity, nil
+	})
+}
+
+// auditLog collects the records of an audit trail
+type auditLog struct {
+	lock    sync.Mutex
+	records []ApprovalRecord
+}
+
+func (a *auditLog) add(record ApprovalRecord) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.records = append(a.records, record)
+}
+
+func TestDualApproval(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("approval"), 1)
+	require.NoError(err)
+	key := kc.Keys()[0]
+
+	var (
+		log  auditLog
+		seen []ApprovalRequest
+		lock sync.Mutex
+	)
+	recording := ApproverFunc(func(_ context.Context, req ApprovalRequest) (string, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		seen = append(seen, req)
+		return "alice", nil
+	})
+	mw, err := DualApproval(DualApprovalConfig{
+		Approvers: []Approver{recording, approveAs("bob")},
+		Audit:     log.add,
+	})
+	require.NoError(err)
+	signer, ok := Wrap(kc, mw).Get(key.Address())
+	require.True(ok)
+
+	msg := []byte("treasury transfer")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.True(key.PublicKey().Verify(msg, sig))
+
+	require.Len(seen, 1)
+	require.Equal(key.Address(), seen[0].Address)
+	require.Equal(OpSign, seen[0].Op)
+	require.Equal(msg, seen[0].Payload)
+
+	require.Len(log.records, 1)
+	record := log.records[0]
+	require.Equal(seen[0].ID, record.ID)
+	require.Equal(sha256.Sum256(msg), record.PayloadHash)
+	require.ElementsMatch([]string{"alice", "bob"}, record.Approvers)
+	require.NoError(record.Err)
+	require.False(record.Decided.Before(record.Requested))
+}
+
+func TestDualApprovalDenied(t *testing.T) {
+	kc, err := NewTestKeychain([]byte("approval"), 1)
+	require.NoError(t, err)
+	decline := ApproverFunc(func(context.Context, ApprovalRequest) (string, error) {
+		return "", errDeclined
+	})
+
+	tests := []struct {
+		name      string
+		approvers []Approver
+		expected  error
+	}{
+		{
+			name:      "declined",
+			approvers: []Approver{approveAs("alice"), decline},
+			expected:  errDeclined,
+		},
+		{
+			name:      "same identity",
+			approvers: []Approver{approveAs("alice"), approveAs("alice")},
+			expected:  ErrDuplicateApproval,
+		},
+		{
+			name:      "empty identity",
+			approvers: []Approver{approveAs("alice"), approveAs("")},
+			expected:  ErrEmptyApproverIdentity,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+
+			var calls int
+			count := Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+				calls++
+				return next(payload)
+			})
+			var log auditLog
+			mw, err := DualApproval(DualApprovalConfig{
+				Approvers: test.approvers,
+				Audit:     log.add,
+			})
+			require.NoError(err)
+			signer, ok := Wrap(kc, mw, count).Get(kc.Keys()[0].Address())
+			require.True(ok)
+
+			_, err = signer.SignHash(make([]byte, 32))
+			require.ErrorIs(err, ErrApprovalDenied)
+			require.ErrorIs(err, test.expected)
+			require.Zero(calls)
+			require.Len(log.records, 1)
+			require.ErrorIs(log.records[0].Err, ErrApprovalDenied)
+		})
+	}
+}
+
+func TestDualApprovalQuorum(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("approval"), 1)
+	require.NoError(err)
+	decline := ApproverFunc(func(context.Context, ApprovalRequest) (string, error) {
+		return "", errDeclined
+	})
+
+	// Two of three approvers suffice, and the request does not wait for the
+	// third
+	var canceled sync.WaitGroup
+	canceled.Add(1)
+	slow := ApproverFunc(func(ctx context.Context, _ ApprovalRequest) (string, error) {
+		defer canceled.Done()
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	mw, err := DualApproval(DualApprovalConfig{
+		Approvers: []Approver{approveAs("alice"), slow, approveAs("carol")},
+	})
+	require.NoError(err)
+	signer, ok := Wrap(kc, mw).Get(kc.Keys()[0].Address())
+	require.True(ok)
+	_, err = signer.Sign([]byte("quorum"))
+	require.NoError(err)
+	canceled.Wait()
+
+	// Requests time out waiting for approvals
+	mw, err = DualApproval(DualApprovalConfig{
+		Approvers: []Approver{approveAs("alice"), slow, decline},
+		Required:  2,
+		Timeout:   10 * time.Millisecond,
+	})
+	require.NoError(err)
+	canceled.Add(1)
+	signer, ok = Wrap(kc, mw).Get(kc.Keys()[0].Address())
+	require.True(ok)
+	_, err = signer.Sign([]byte("quorum"))
+	require.ErrorIs(err, ErrApprovalDenied)
+	require.ErrorIs(err, context.DeadlineExceeded)
+	canceled.Wait()
+}
+
+func TestDualApprovalConfig(t *testing.T) {
+	require := require.New(t)
+
+	_, err := DualApproval(DualApprovalConfig{
+		Approvers: []Approver{approveAs("alice")},
+		Required:  1,
+	})
+	require.ErrorIs(err, ErrNotEnoughApprovers)
+
+	_, err = DualApproval(DualApprovalConfig{
+		Approvers: []Approver{approveAs("alice"), approveAs("bob")},
+		Required:  3,
+	})
+	require.ErrorIs(err, ErrNotEnoughApprovers)
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+// SignResult is the outcome of an asynchronous signing request
+type SignResult struct {
+	Signature []byte
+	Err       error
+}
+
+// SignAsync signs [msg] with [signer] in the background. The returned
+// channel receives exactly one result and is then closed, so that callers,
+// such as a UI prompting the user to confirm on a device, can keep working
+// while a slow backend completes:
+//
+//	results := keychain.SignAsync(signer, unsignedTx)
+//	showConfirmOnDevice()
+//	result := <-results
+func SignAsync(signer Signer, msg []byte) <-chan SignResult {
+	return async(func() ([]byte, error) {
+		return signer.Sign(msg)
+	})
+}
+
+// SignHashAsync signs [hash] with [signer] in the background, as SignAsync
+func SignHashAsync(signer Signer, hash []byte) <-chan SignResult {
+	return async(func() ([]byte, error) {
+		return signer.SignHash(hash)
+	})
+}
+
+func async(sign func() ([]byte, error)) <-chan SignResult {
+	results := make(chan SignResult, 1)
+	go func() {
+		defer close(results)
+
+		sig, err := sign()
+		results <- SignResult{
+			Signature: sig,
+			Err:       err,
+		}
+	}()
+	return results
+}
@@ -0,0 +1,39 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAsync(t *testing.T) {
+	require := require.New(t)
+
+	key, err := DeterministicKey([]byte("async"), 0)
+	require.NoError(err)
+	kc := NewSoftwareKeychain(key)
+	signer, ok := kc.Get(key.Address())
+	require.True(ok)
+
+	msg := []byte("payload")
+	results := SignAsync(signer, msg)
+	result := <-results
+	require.NoError(result.Err)
+	require.True(key.PublicKey().Verify(msg, result.Signature))
+	_, ok = <-results
+	require.False(ok)
+
+	hash := make([]byte, 32)
+	result = <-SignHashAsync(signer, hash)
+	require.NoError(result.Err)
+	require.True(key.PublicKey().VerifyHash(hash, result.Signature))
+
+	// Errors are delivered through the channel
+	require.NoError(kc.Retire(key.Address()))
+	result = <-SignAsync(signer, msg)
+	require.ErrorIs(result.Err, ErrKeyRetired)
+	require.Nil(result.Signature)
+}
@@ -0,0 +1,180 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/luxfi/crypto/secp256k1"
+)
+
+const (
+	insAttest = 0x07
+
+	attestationChallengeLen = 32
+	attestationResponseLen  = ledgerPublicKeyLen + 2*secp256k1.SignatureLen
+
+	// attestationCertDomain and attestationDomain separate the messages
+	// signed by the issuer and the device from any other signature
+	attestationCertDomain = "lux-ledger-attestation-certificate"
+	attestationDomain     = "lux-ledger-attestation"
+)
+
+var (
+	ErrAttestationUnsupported = errors.New("ledger does not support attestation")
+	ErrNotGenuine             = errors.New("ledger device failed the genuineness check")
+	ErrInvalidChallenge       = errors.New("attestation challenge must be 32 bytes")
+
+	_ AttestationLedger = (*LedgerDevice)(nil)
+	_ AttestationLedger = (*timeoutLedger)(nil)
+	_ AttestationLedger = (*previewLedger)(nil)
+	_ AttestationLedger = (*retryLedger)(nil)
+	_ AttestedKeychain  = (*ledgerKeychain)(nil)
+)
+
+// AttestationLedger is implemented by ledgers that can prove that they are
+// genuine devices
+type AttestationLedger interface {
+	Ledger
+	// Attest has the device sign [challenge] with its attestation key
+	Attest(challenge []byte) (*Attestation, error)
+}
+
+// AttestedKeychain is implemented by keychains whose device may have been
+// checked for genuineness during construction
+type AttestedKeychain interface {
+	Keychain
+	// Attestation returns the verified attestation of the device, or nil if
+	// the device was not attested
+	Attestation() *Attestation
+}
+
+// Attestation is the response of a device to a genuineness challenge. The
+// device holds an attestation key, provisioned at manufacturing time and
+// certified by an issuer, with which it signs the challenge.
+type Attestation struct {
+	Challenge []byte
+	// DevicePublicKey is the compressed public key of the device attestation
+	// key
+	DevicePublicKey []byte
+	// Certificate is the issuer's signature of the device public key
+	Certificate []byte
+	// Signature is the device's signature of the challenge
+	Signature []byte
+}
+
+// Verify checks that the device public key is certified by one of the
+// compressed public keys of [issuers] and that the device signed the
+// challenge. ErrNotGenuine is returned otherwise.
+func (a *Attestation) Verify(issuers [][]byte) error {
+	certHash := attestationHash(attestationCertDomain, a.DevicePublicKey)
+	issuer, err := secp256k1.RecoverPublicKeyFromHash(certHash, a.Certificate)
+	if err != nil {
+		return errors.Join(ErrNotGenuine, err)
+	}
+	certified := false
+	for _, trusted := range issuers {
+		if bytes.Equal(issuer.Bytes(), trusted) {
+			certified = true
+			break
+		}
+	}
+	if !certified {
+		return ErrNotGenuine
+	}
+
+	device, err := secp256k1.RecoverPublicKeyFromHash(attestationHash(attestationDomain, a.Challenge), a.Signature)
+	if err != nil {
+		return errors.Join(ErrNotGenuine, err)
+	}
+	if !bytes.Equal(device.Bytes(), a.DevicePublicKey) {
+		return ErrNotGenuine
+	}
+	return nil
+}
+
+// Attest sends [challenge] to the device, which returns
+//
+//	device pubkey (33) || certificate (65) || signature (65)
+func (l *LedgerDevice) Attest(challenge []byte) (*Attestation, error) {
+	if len(challenge) != attestationChallengeLen {
+		return nil, ErrInvalidChallenge
+	}
+	resp, err := l.exchange(&apduCommand{
+		cla:  ledgerCLA,
+		ins:  insAttest,
+		data: challenge,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) != attestationResponseLen {
+		return nil, ErrInvalidResponse
+	}
+	return &Attestation{
+		Challenge:       bytes.Clone(challenge),
+		DevicePublicKey: resp[:ledgerPublicKeyLen],
+		Certificate:     resp[ledgerPublicKeyLen : ledgerPublicKeyLen+secp256k1.SignatureLen],
+		Signature:       resp[ledgerPublicKeyLen+secp256k1.SignatureLen:],
+	}, nil
+}
+
+// AttestLedger challenges [ledger] with a random nonce and verifies its
+// response against [issuers]
+func AttestLedger(ledger Ledger, issuers [][]byte) (*Attestation, error) {
+	challenge := make([]byte, attestationChallengeLen)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	attestation, err := attest(ledger, challenge)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(attestation.Challenge, challenge) {
+		return nil, ErrNotGenuine
+	}
+	if err := attestation.Verify(issuers); err != nil {
+		return nil, err
+	}
+	return attestation, nil
+}
+
+func (l *ledgerKeychain) Attestation() *Attestation {
+	return l.attestation
+}
+
+func (t *timeoutLedger) Attest(challenge []byte) (*Attestation, error) {
+	return withTimeout(t.timeouts.Derive, t.cancel, func() (*Attestation, error) {
+		return attest(t.ledger, challenge)
+	})
+}
+
+func (p *previewLedger) Attest(challenge []byte) (*Attestation, error) {
+	return attest(p.ledger, challenge)
+}
+
+func (r *retryLedger) Attest(challenge []byte) (*Attestation, error) {
+	return RetryOperation(context.Background(), r.policy, func() (*Attestation, error) {
+		return attest(r.ledger, challenge)
+	})
+}
+
+func attest(ledger Ledger, challenge []byte) (*Attestation, error) {
+	attester, ok := ledger.(AttestationLedger)
+	if !ok {
+		return nil, ErrAttestationUnsupported
+	}
+	return attester.Attest(challenge)
+}
+
+func attestationHash(domain string, data []byte) []byte {
+	h := sha256.New()
+	_, _ = h.Write([]byte(domain))
+	_, _ = h.Write(data)
+	return h.Sum(nil)
+}
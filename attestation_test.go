@@ -0,0 +1,121 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+)
+
+// newAttestedApp returns a fake app certified by a new issuer key, and the
+// issuer's public key
+func newAttestedApp(t *testing.T) (*fakeLuxApp, []byte) {
+	t.Helper()
+
+	issuer, err := secp256k1.NewPrivateKey()
+	require.NoError(t, err)
+	app := newFakeLuxApp()
+	app.issuer = issuer
+	return app, issuer.PublicKey().Bytes()
+}
+
+func TestAttestLedger(t *testing.T) {
+	require := require.New(t)
+
+	app, issuer := newAttestedApp(t)
+	device := NewLedgerDevice(app)
+
+	attestation, err := AttestLedger(device, [][]byte{issuer})
+	require.NoError(err)
+	require.Len(attestation.Challenge, attestationChallengeLen)
+	require.Len(attestation.DevicePublicKey, ledgerPublicKeyLen)
+
+	// A device certified by an untrusted issuer is not genuine
+	other, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	_, err = AttestLedger(device, [][]byte{other.PublicKey().Bytes()})
+	require.ErrorIs(err, ErrNotGenuine)
+	_, err = AttestLedger(device, nil)
+	require.ErrorIs(err, ErrNotGenuine)
+
+	// Wrappers forward the attestation to the device
+	wrapped := NewTimeoutLedger(NewPreviewLedger(device, PreviewConfig{}), Timeouts{})
+	_, err = AttestLedger(wrapped, [][]byte{issuer})
+	require.NoError(err)
+}
+
+func TestAttestationVerify(t *testing.T) {
+	require := require.New(t)
+
+	app, issuer := newAttestedApp(t)
+	device := NewLedgerDevice(app)
+
+	challenge := make([]byte, attestationChallengeLen)
+	attestation, err := device.Attest(challenge)
+	require.NoError(err)
+	require.NoError(attestation.Verify([][]byte{issuer}))
+
+	// A response replayed for another challenge is not genuine
+	replayed := *attestation
+	replayed.Challenge = make([]byte, attestationChallengeLen)
+	replayed.Challenge[0] = 1
+	require.ErrorIs(replayed.Verify([][]byte{issuer}), ErrNotGenuine)
+
+	// A device key that was not certified is not genuine
+	forged, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	sig, err := forged.SignHash(attestationHash(attestationDomain, challenge))
+	require.NoError(err)
+	tampered := *attestation
+	tampered.DevicePublicKey = forged.PublicKey().Bytes()
+	tampered.Signature = sig
+	require.ErrorIs(tampered.Verify([][]byte{issuer}), ErrNotGenuine)
+
+	tampered = *attestation
+	tampered.Certificate = make([]byte, secp256k1.SignatureLen)
+	require.ErrorIs(tampered.Verify([][]byte{issuer}), ErrNotGenuine)
+
+	_, err = device.Attest(challenge[:16])
+	require.ErrorIs(err, ErrInvalidChallenge)
+}
+
+func TestAttestationUnsupported(t *testing.T) {
+	require := require.New(t)
+
+	_, err := AttestLedger(newMockLedger(), nil)
+	require.ErrorIs(err, ErrAttestationUnsupported)
+
+	// Apps without an attestation key do not support the instruction
+	_, err = AttestLedger(NewLedgerDevice(newFakeLuxApp()), nil)
+	require.ErrorIs(err, ErrAppNotOpen)
+}
+
+func TestLedgerKeychainWithAttestation(t *testing.T) {
+	require := require.New(t)
+
+	app, issuer := newAttestedApp(t)
+	device := NewLedgerDevice(app)
+
+	kc, err := NewLedgerKeychain(device, []uint32{0}, WithAttestation(issuer))
+	require.NoError(err)
+	attested, ok := kc.(AttestedKeychain)
+	require.True(ok)
+	require.NotNil(attested.Attestation())
+	require.NoError(attested.Attestation().Verify([][]byte{issuer}))
+
+	// Counterfeit devices are refused before any address is derived
+	counterfeit := newFakeLuxApp()
+	counterfeit.issuer, err = secp256k1.NewPrivateKey()
+	require.NoError(err)
+	_, err = NewLedgerKeychain(NewLedgerDevice(counterfeit), []uint32{0}, WithAttestation(issuer))
+	require.ErrorIs(err, ErrNotGenuine)
+	require.Equal(1, counterfeit.exchanges)
+
+	// Without the option the device is not attested
+	kc, err = NewLedgerKeychain(device, []uint32{0})
+	require.NoError(err)
+	require.Nil(kc.(AttestedKeychain).Attestation())
+}
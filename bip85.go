@@ -0,0 +1,153 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+
+	"github.com/tyler-smith/go-bip39"
+
+	"github.com/luxfi/crypto/secp256k1"
+)
+
+// BIP85 purpose and applications
+const (
+	bip85Purpose  = 83696968
+	bip85AppBIP39 = 39
+	bip85AppHex   = 128169
+	bip85AppWIF   = 2
+
+	// bip85English is the BIP85 code of the English BIP39 wordlist
+	bip85English = 0
+
+	minBIP85HexLen = 16
+	maxBIP85HexLen = 64
+)
+
+// bip85EntropyKey is the HMAC key deriving BIP85 entropy from a child key
+var bip85EntropyKey = []byte("bip-entropy-from-k")
+
+// bip85MnemonicEntropyLens are the entropy lengths of BIP39 mnemonics, by
+// word count
+var bip85MnemonicEntropyLens = map[int]int{12: 16, 15: 20, 18: 24, 21: 28, 24: 32}
+
+var (
+	ErrUnhardenedPath       = errors.New("BIP85 derivation path must be fully hardened")
+	ErrInvalidEntropyLength = errors.New("BIP85 entropy must be between 16 and 64 bytes")
+)
+
+// DeriveEntropy returns the 64 bytes of BIP85 entropy at [path] of the root
+// BIP32 [seed], such as the seed of a mnemonic. Every element of [path] must
+// be hardened. The entropy of distinct paths is independent, and reveals
+// nothing about the root seed.
+func DeriveEntropy(seed []byte, path DerivationPath) ([]byte, error) {
+	root, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	return bip85Entropy(root, path)
+}
+
+// DeriveChildMnemonic returns the English BIP39 mnemonic of [words] words
+// derived with the BIP85 application 39' at [index] from the root BIP32
+// [seed]. Separate applications or environments can be given their own
+// mnemonic, with its own keys, that is recovered from the root mnemonic alone.
+func DeriveChildMnemonic(seed []byte, words int, index uint32) (string, error) {
+	root, err := newMasterKey(seed)
+	if err != nil {
+		return "", err
+	}
+	return bip85Mnemonic(root, words, index)
+}
+
+// DeriveChildEntropy returns [length] bytes of entropy derived with the
+// BIP85 application 128169' at [index] from the root BIP32 [seed], such as
+// the seed of another key derivation scheme
+func DeriveChildEntropy(seed []byte, length int, index uint32) ([]byte, error) {
+	root, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	return bip85Hex(root, length, index)
+}
+
+// DeriveChildKey returns the secp256k1 key derived with the BIP85 application
+// 2' at [index] from the root BIP32 [seed], the key BIP85 encodes as WIF
+func DeriveChildKey(seed []byte, index uint32) (*secp256k1.PrivateKey, error) {
+	root, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	return bip85Key(root, index)
+}
+
+func bip85Mnemonic(root *extendedKey, words int, index uint32) (string, error) {
+	entropyLen, ok := bip85MnemonicEntropyLens[words]
+	if !ok {
+		return "", ErrInvalidMnemonicWordCount
+	}
+	entropy, err := bip85Entropy(root, bip85Path(bip85AppBIP39, bip85English, uint32(words), index))
+	if err != nil {
+		return "", err
+	}
+	defer clear(entropy)
+	return bip39.NewMnemonic(entropy[:entropyLen])
+}
+
+func bip85Hex(root *extendedKey, length int, index uint32) ([]byte, error) {
+	if length < minBIP85HexLen || length > maxBIP85HexLen {
+		return nil, ErrInvalidEntropyLength
+	}
+	entropy, err := bip85Entropy(root, bip85Path(bip85AppHex, uint32(length), index))
+	if err != nil {
+		return nil, err
+	}
+	child := make([]byte, length)
+	copy(child, entropy)
+	clear(entropy)
+	return child, nil
+}
+
+func bip85Key(root *extendedKey, index uint32) (*secp256k1.PrivateKey, error) {
+	entropy, err := bip85Entropy(root, bip85Path(bip85AppWIF, index))
+	if err != nil {
+		return nil, err
+	}
+	defer clear(entropy)
+	return secp256k1.ToPrivateKey(entropy[:secp256k1.PrivateKeyLen])
+}
+
+// bip85Path returns the hardened path m/83696968'/[elements]'...
+func bip85Path(elements ...uint32) DerivationPath {
+	path := make(DerivationPath, 0, len(elements)+1)
+	path = append(path, bip85Purpose|HardenedOffset)
+	for _, element := range elements {
+		path = append(path, element|HardenedOffset)
+	}
+	return path
+}
+
+func bip85Entropy(root *extendedKey, path DerivationPath) ([]byte, error) {
+	k := root
+	for _, index := range path {
+		if index < HardenedOffset {
+			return nil, fmt.Errorf("%w: %s", ErrUnhardenedPath, path)
+		}
+		var err error
+		k, err = k.child(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if k != root {
+		defer clear(k.key)
+	}
+
+	mac := hmac.New(sha512.New, bip85EntropyKey)
+	_, _ = mac.Write(k.key)
+	return mac.Sum(nil), nil
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// bip85TestRoot is the root key of the BIP85 test vectors,
+// xprv9s21ZrQH143K2LBWUUQRFXhucrQqBpKdRRxNVq2zBqsx8HVqFk2uYo8kmbaLLHRdqtQpUm98uKfu3vca1LqdGhUtyoFnCNkfmXRyPXLjbKb
+func bip85TestRoot(t *testing.T) *extendedKey {
+	t.Helper()
+
+	key, err := hex.DecodeString("3f15e5d852dc2e9ba5e9fe189a8dd2e1547badef5b563bbe6579fc6807d80ed9")
+	require.NoError(t, err)
+	chainCode, err := hex.DecodeString("1b67969d1ec69bdfeeae43213da8460ba34b92d0788c8f7bfcfa44906e8a589c")
+	require.NoError(t, err)
+	return &extendedKey{
+		key:       key,
+		chainCode: chainCode,
+	}
+}
+
+func TestBIP85Entropy(t *testing.T) {
+	require := require.New(t)
+
+	path, err := ParseDerivationPath("m/83696968'/0'/0'")
+	require.NoError(err)
+	entropy, err := bip85Entropy(bip85TestRoot(t), path)
+	require.NoError(err)
+	require.Equal("efecfbccffea313214232d29e71563d941229afb4338c21f9517c41aaa0d16f00b83d2a09ef747e7a64e8e2bd5a14869e693da66ce94ac2da570ab7ee48618f7", hex.EncodeToString(entropy))
+
+	_, err = bip85Entropy(bip85TestRoot(t), DerivationPath{bip85Purpose | HardenedOffset, 0})
+	require.ErrorIs(err, ErrUnhardenedPath)
+}
+
+func TestBIP85Mnemonic(t *testing.T) {
+	tests := []struct {
+		words    int
+		expected string
+	}{
+		{
+			words:    12,
+			expected: "girl mad pet galaxy egg matter matrix prison refuse sense ordinary nose",
+		},
+		{
+			words:    24,
+			expected: "puppy ocean match cereal symbol another shed magic wrap hammer bulb intact gadget divorce twin tonight reason outdoor destroy simple truth cigar social volcano",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			require := require.New(t)
+
+			mnemonic, err := bip85Mnemonic(bip85TestRoot(t), test.words, 0)
+			require.NoError(err)
+			require.Equal(test.expected, mnemonic)
+		})
+	}
+
+	_, err := bip85Mnemonic(bip85TestRoot(t), 13, 0)
+	require.ErrorIs(t, err, ErrInvalidMnemonicWordCount)
+}
+
+func TestBIP85Hex(t *testing.T) {
+	require := require.New(t)
+
+	entropy, err := bip85Hex(bip85TestRoot(t), 64, 0)
+	require.NoError(err)
+	require.Equal("492db4698cf3b73a5a24998aa3e9d7fa96275d85724a91e71aa2d645442f878555d078fd1f1f67e368976f04137b1f7a0d19232136ca50c44614af72b5582a5c", hex.EncodeToString(entropy))
+
+	_, err = bip85Hex(bip85TestRoot(t), 15, 0)
+	require.ErrorIs(err, ErrInvalidEntropyLength)
+	_, err = bip85Hex(bip85TestRoot(t), 65, 0)
+	require.ErrorIs(err, ErrInvalidEntropyLength)
+}
+
+func TestBIP85Key(t *testing.T) {
+	require := require.New(t)
+
+	// The key of the WIF Kzyv4uF39d4Jrw2W7UryTHwZr1zQVNk4dAFyqE6BuMrMh1Za7uhp
+	key, err := bip85Key(bip85TestRoot(t), 0)
+	require.NoError(err)
+	require.Equal("7040bb53104f27367f317558e78a994ada7296c6fde36a364e5baf206e502bb1", hex.EncodeToString(key.Bytes()))
+}
+
+func TestDeriveChildMnemonic(t *testing.T) {
+	require := require.New(t)
+
+	seed, err := MnemonicSeed(testMnemonic, "")
+	require.NoError(err)
+
+	staging, err := DeriveChildMnemonic(seed, 24, 0)
+	require.NoError(err)
+	require.NoError(ValidateMnemonic(staging))
+	again, err := DeriveChildMnemonic(seed, 24, 0)
+	require.NoError(err)
+	require.Equal(staging, again)
+
+	production, err := DeriveChildMnemonic(seed, 24, 1)
+	require.NoError(err)
+	require.NotEqual(staging, production)
+
+	// Child mnemonics hold keys unrelated to the root ones
+	root, err := NewMnemonicKeychain(testMnemonic, "", []uint32{0})
+	require.NoError(err)
+	child, err := NewMnemonicKeychain(staging, "", []uint32{0})
+	require.NoError(err)
+	require.False(root.Addresses().Overlaps(child.Addresses()))
+
+	_, err = DeriveChildMnemonic(make([]byte, 15), 12, 0)
+	require.ErrorIs(err, ErrInvalidSeed)
+}
+
+func TestDeriveChildEntropyAndKey(t *testing.T) {
+	require := require.New(t)
+
+	seed, err := MnemonicSeed(testMnemonic, "")
+	require.NoError(err)
+
+	entropy, err := DeriveChildEntropy(seed, 32, 0)
+	require.NoError(err)
+	require.Len(entropy, 32)
+	path, err := ParseDerivationPath("m/83696968'/128169'/32'/0'")
+	require.NoError(err)
+	full, err := DeriveEntropy(seed, path)
+	require.NoError(err)
+	require.Equal(full[:32], entropy)
+
+	key, err := DeriveChildKey(seed, 0)
+	require.NoError(err)
+	other, err := DeriveChildKey(seed, 1)
+	require.NoError(err)
+	require.NotEqual(key.Address(), other.Address())
+}
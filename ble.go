@@ -0,0 +1,207 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// bleTagMTU tags the frames negotiating the frame size
+	bleTagMTU = 0x08
+	// defaultBLEFrameLen is the frame size of the default ATT MTU of 23
+	// bytes, used until the device reports its own
+	defaultBLEFrameLen = 20
+	// minBLEFrameLen is the smallest frame that fits the first frame header
+	minBLEFrameLen = 6
+)
+
+// BLE pairing states
+const (
+	BLEUnpaired BLEPairingState = iota
+	BLEPairing
+	BLEPaired
+)
+
+var (
+	ErrBLENotPaired = errors.New("ledger device is not paired over bluetooth")
+
+	_ ModelTransport = (*bleTransport)(nil)
+)
+
+// BLEPairingState is the bonding state of the host with a ledger device
+type BLEPairingState uint8
+
+func (s BLEPairingState) String() string {
+	switch s {
+	case BLEUnpaired:
+		return "unpaired"
+	case BLEPairing:
+		return "pairing"
+	case BLEPaired:
+		return "paired"
+	default:
+		return "unknown"
+	}
+}
+
+// BLEPairingError reports that the device cannot be used until the user
+// completes pairing, confirming the pairing code on the device. It matches
+// ErrBLENotPaired.
+type BLEPairingError struct {
+	State BLEPairingState
+}
+
+func (e *BLEPairingError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrBLENotPaired, e.State)
+}
+
+func (*BLEPairingError) Unwrap() error {
+	return ErrBLENotPaired
+}
+
+// LedgerBLEService holds the GATT UUIDs of the APDU service of a ledger
+// device. Commands are written to Write and responses are notified on
+// Notify.
+type LedgerBLEService struct {
+	Service string
+	Notify  string
+	Write   string
+}
+
+// ledgerBLEServices are the APDU services of the bluetooth models
+var ledgerBLEServices = map[LedgerModel]string{
+	LedgerNanoX: "13d63400-2c97-0004",
+	LedgerStax:  "13d63400-2c97-6004",
+	LedgerFlex:  "13d63400-2c97-3004",
+}
+
+// BLEService returns the GATT service a BLE library connects to for [model],
+// and false if the model has no bluetooth
+func BLEService(model LedgerModel) (LedgerBLEService, bool) {
+	prefix, ok := ledgerBLEServices[model]
+	if !ok {
+		return LedgerBLEService{}, false
+	}
+	uuid := func(characteristic string) string {
+		return strings.Join([]string{prefix, characteristic, "4c6564676572"}, "-")
+	}
+	return LedgerBLEService{
+		Service: uuid("0000"),
+		Notify:  uuid("0001"),
+		Write:   uuid("0002"),
+	}, true
+}
+
+// BLEConnection is a connection to the APDU service of a ledger device, as
+// established by a BLE library
+type BLEConnection interface {
+	// PairingState returns the bonding state with the device
+	PairingState() BLEPairingState
+	// Write writes [frame] to the write characteristic
+	Write(frame []byte) error
+	// Read returns the next frame notified on the notify characteristic
+	Read() ([]byte, error)
+	// Close disconnects from the device
+	Close() error
+}
+
+// bleTransport exchanges APDUs with a ledger device over bluetooth. The
+// framing is that of USB HID without the channel: tag (1) || sequence number
+// (2) || data, where the data of the first frame starts with the 2 byte
+// length of the APDU. Frames are not padded, and are as large as the frame
+// size negotiated with the device.
+type bleTransport struct {
+	lock     sync.Mutex
+	conn     BLEConnection
+	model    LedgerModel
+	frameLen int
+}
+
+// NewBLETransport speaks the ledger bluetooth framing over [conn], so that apps
+// without USB access, such as on tablets, can build a ledger keychain. The
+// device must be paired; otherwise a *BLEPairingError reports the pairing
+// state so the UI can ask the user to confirm the pairing code on the device.
+// The frame size is negotiated with the device before the transport is
+// returned.
+func NewBLETransport(conn BLEConnection, model LedgerModel) (Transport, error) {
+	if state := conn.PairingState(); state != BLEPaired {
+		return nil, &BLEPairingError{State: state}
+	}
+	b := &bleTransport{
+		conn:     conn,
+		model:    model,
+		frameLen: defaultBLEFrameLen,
+	}
+	if err := b.negotiateMTU(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// negotiateMTU asks the device for its frame size:
+//
+//	0x08 || 0x0000 || 0x0000 -> 0x08 || 0x0000 || 0x0001 || size
+func (b *bleTransport) negotiateMTU() error {
+	if err := b.write([]byte{bleTagMTU, 0, 0, 0, 0}); err != nil {
+		return err
+	}
+	for {
+		frame, err := b.read()
+		if err != nil {
+			return err
+		}
+		if len(frame) == 0 || frame[0] != bleTagMTU {
+			continue
+		}
+		if len(frame) < 6 || int(frame[5]) < minBLEFrameLen {
+			return ErrInvalidResponse
+		}
+		b.frameLen = int(frame[5])
+		return nil
+	}
+}
+
+func (b *bleTransport) Exchange(apdu []byte) ([]byte, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for _, frame := range ledgerFrames(nil, apdu, b.frameLen) {
+		if err := b.write(frame); err != nil {
+			return nil, err
+		}
+	}
+	return readLedgerFrames(nil, b.read)
+}
+
+func (b *bleTransport) Close() error {
+	return b.conn.Close()
+}
+
+func (b *bleTransport) Model() LedgerModel {
+	return b.model
+}
+
+// write and read report a connection that lost its bond as unpaired
+func (b *bleTransport) write(frame []byte) error {
+	return b.pairingError(b.conn.Write(frame))
+}
+
+func (b *bleTransport) read() ([]byte, error) {
+	frame, err := b.conn.Read()
+	return frame, b.pairingError(err)
+}
+
+func (b *bleTransport) pairingError(err error) error {
+	if err == nil || errors.Is(err, ErrBLENotPaired) {
+		return err
+	}
+	if state := b.conn.PairingState(); state != BLEPaired {
+		return errors.Join(&BLEPairingError{State: state}, err)
+	}
+	return err
+}
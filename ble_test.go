@@ -0,0 +1,149 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errDisconnected = errors.New("disconnected")
+
+// fakeBLEConnection serves a fake Lux app through the ledger bluetooth
+// framing
+type fakeBLEConnection struct {
+	app      *fakeLuxApp
+	state    BLEPairingState
+	frameLen int
+	pending  []byte
+	frames   [][]byte
+	written  [][]byte
+	closed   bool
+}
+
+func newFakeBLEConnection() *fakeBLEConnection {
+	return &fakeBLEConnection{
+		app:      newFakeLuxApp(),
+		state:    BLEPaired,
+		frameLen: 128,
+	}
+}
+
+func (c *fakeBLEConnection) PairingState() BLEPairingState {
+	return c.state
+}
+
+func (c *fakeBLEConnection) Write(frame []byte) error {
+	if c.state != BLEPaired {
+		return errDisconnected
+	}
+	c.written = append(c.written, frame)
+	switch {
+	case len(frame) == 5 && frame[0] == bleTagMTU:
+		c.frames = append(c.frames, []byte{bleTagMTU, 0, 0, 0, 1, byte(c.frameLen)})
+		return nil
+	case len(frame) > c.frameLen || len(frame) < 3 || frame[0] != ledgerTagAPDU:
+		return errMalformedAPDU
+	}
+	c.pending = append(c.pending, frame[3:]...)
+	n := int(binary.BigEndian.Uint16(c.pending))
+	if len(c.pending) < 2+n {
+		return nil
+	}
+
+	resp, err := c.app.Exchange(c.pending[2 : 2+n])
+	c.pending = nil
+	if err != nil {
+		return err
+	}
+	c.frames = append(c.frames, ledgerFrames(nil, resp, c.frameLen)...)
+	return nil
+}
+
+func (c *fakeBLEConnection) Read() ([]byte, error) {
+	if c.state != BLEPaired || len(c.frames) == 0 {
+		return nil, errDisconnected
+	}
+	frame := c.frames[0]
+	c.frames = c.frames[1:]
+	return frame, nil
+}
+
+func (c *fakeBLEConnection) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestBLETransport(t *testing.T) {
+	require := require.New(t)
+
+	conn := newFakeBLEConnection()
+	conn.frameLen = 23
+	transport, err := NewBLETransport(conn, LedgerNanoX)
+	require.NoError(err)
+
+	device := NewLedgerDevice(transport)
+	require.Equal(LedgerNanoX, device.Model())
+	key, err := DeterministicKey(conn.app.seed, 5)
+	require.NoError(err)
+
+	tx := make([]byte, 300)
+	sigs, err := device.SignTransaction(tx, []uint32{5})
+	require.NoError(err)
+	require.True(key.PublicKey().Verify(tx, sigs[0]))
+	for _, frame := range conn.written {
+		require.LessOrEqual(len(frame), 23)
+	}
+
+	require.NoError(device.Disconnect())
+	require.True(conn.closed)
+}
+
+func TestBLETransportPairing(t *testing.T) {
+	require := require.New(t)
+
+	conn := newFakeBLEConnection()
+	conn.state = BLEPairing
+	_, err := NewBLETransport(conn, LedgerNanoX)
+	require.ErrorIs(err, ErrBLENotPaired)
+	var pairingErr *BLEPairingError
+	require.ErrorAs(err, &pairingErr)
+	require.Equal(BLEPairing, pairingErr.State)
+
+	// Losing the bond is reported as a pairing error
+	conn.state = BLEPaired
+	transport, err := NewBLETransport(conn, LedgerNanoX)
+	require.NoError(err)
+	conn.state = BLEUnpaired
+	_, err = NewLedgerDevice(transport).Version()
+	require.ErrorIs(err, ErrBLENotPaired)
+	require.ErrorIs(err, errDisconnected)
+	require.ErrorAs(err, &pairingErr)
+	require.Equal(BLEUnpaired, pairingErr.State)
+
+	conn.state = BLEPaired
+	conn.frameLen = 3
+	_, err = NewBLETransport(conn, LedgerNanoX)
+	require.ErrorIs(err, ErrInvalidResponse)
+}
+
+func TestBLEService(t *testing.T) {
+	require := require.New(t)
+
+	service, ok := BLEService(LedgerNanoX)
+	require.True(ok)
+	require.Equal(LedgerBLEService{
+		Service: "13d63400-2c97-0004-0000-4c6564676572",
+		Notify:  "13d63400-2c97-0004-0001-4c6564676572",
+		Write:   "13d63400-2c97-0004-0002-4c6564676572",
+	}, service)
+
+	_, ok = BLEService(LedgerStax)
+	require.True(ok)
+	_, ok = BLEService(LedgerNanoSPlus)
+	require.False(ok)
+}
@@ -0,0 +1,358 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"slices"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+const (
+	// BundleVersion is the version of the bundles written by Export
+	BundleVersion uint16 = bundleVersionV2
+
+	bundleVersionV1 = 1
+	bundleVersionV2 = 2
+
+	bundleSaltLen = 32
+	// bundlePrefixLen is the length of magic || version
+	bundlePrefixLen = len(bundleMagic) + 2
+	// bundleKDFLen is the length of the scrypt parameters of a v2 header
+	bundleKDFLen = 3
+	// maxBundleScryptMemory bounds the scrypt memory, 128 * N * r bytes, an
+	// imported bundle can demand
+	maxBundleScryptMemory = 1 << 30
+)
+
+var (
+	ErrInvalidBundle            = errors.New("invalid keychain bundle")
+	ErrUnsupportedBundleVersion = errors.New("unsupported keychain bundle version")
+	ErrBundleDecryption         = errors.New("incorrect password or corrupted keychain bundle")
+	ErrUnsupportedKeychain      = errors.New("keychain type cannot be exported")
+	ErrLedgerMismatch           = errors.New("ledger addresses do not match the bundle")
+	ErrInvalidKDFParams         = errors.New("invalid scrypt parameters")
+
+	bundleMagic = [4]byte{'L', 'K', 'C', 'B'}
+
+	// ScryptStandard suits hot wallets unlocked frequently: deriving the key
+	// takes 256 MiB and well under a second on a server
+	ScryptStandard = ScryptParams{N: 1 << 18, R: 8, P: 1}
+	// ScryptHardened suits cold storage: deriving the key takes 1 GiB, the
+	// most Import accepts, and several seconds
+	ScryptHardened = ScryptParams{N: 1 << 20, R: 8, P: 1}
+
+	// bundleScrypt are the scrypt cost parameters used to derive the
+	// encryption key of exported bundles from the password
+	bundleScrypt = ScryptStandard
+	// bundleScryptV1 are the scrypt cost parameters of v1 bundles, which do
+	// not record them
+	bundleScryptV1 = ScryptParams{N: 1 << 18, R: 8, P: 1}
+)
+
+// ScryptParams are the cost parameters of the scrypt key derivation that
+// turns a password into the encryption key of a bundle. N is the CPU and
+// memory cost, a power of two, R the block size and P the parallelization;
+// deriving a key takes 128 * N * R bytes of memory. Bundles record the
+// parameters they were encrypted with, so Import needs no configuration.
+type ScryptParams struct {
+	N, R, P int
+}
+
+// Validate checks that [p] can be recorded in a bundle header and that
+// Import will accept the memory it requires
+func (p ScryptParams) Validate() error {
+	if p.N <= 1 || p.N&(p.N-1) != 0 || p.R <= 0 || p.R > 255 || p.P <= 0 || p.P > 255 {
+		return ErrInvalidKDFParams
+	}
+	// Divided rather than multiplied, as 128 * N * R overflows for the large
+	// N of a crafted header
+	if p.N > maxBundleScryptMemory/128/p.R {
+		return fmt.Errorf("%w: scrypt needs more than %d bytes", ErrInvalidKDFParams, maxBundleScryptMemory)
+	}
+	return nil
+}
+
+// LedgerAccount records the address index of a ledger-derived address
+type LedgerAccount struct {
+	Address ids.ShortID `json:"address"`
+	Index   uint32      `json:"index"`
+}
+
+// Bundle captures the contents of one or more keychains so that they can be
+// moved between machines.
+//
+// On the wire a bundle is encoded as:
+//
+//	magic (4) || version (2) || log2(N) (1) || r (1) || p (1) || salt (32) || nonce (24) || ciphertext
+//
+// where the ciphertext is the JSON encoded bundle sealed with
+// XChaCha20-Poly1305 under a key derived from the password with the scrypt
+// parameters N, r and p. The header is authenticated as additional data.
+//
+// Bundles of version 1 do not record the scrypt parameters, which are fixed
+// to N = 2^18, r = 8 and p = 1. Import reads both versions, and
+// MigrateBundle rewrites a bundle in the latest version.
+type Bundle struct {
+	// Keys are the software private keys of the keychain
+	Keys []*secp256k1.PrivateKey `json:"keys,omitempty"`
+	// Retired are the addresses of the retired keys
+	Retired []ids.ShortID `json:"retired,omitempty"`
+	// Validity are the validity windows of the keys
+	Validity []KeyValidity `json:"validity,omitempty"`
+	// Ledger maps ledger-derived addresses to their address indices
+	Ledger []LedgerAccount `json:"ledger,omitempty"`
+	// StakingKeys are validator BLS staking keys
+	StakingKeys []*StakingKey `json:"stakingKeys,omitempty"`
+	// Accounts is the metadata of the accounts of metadata keychains
+	Accounts []AccountMetadata `json:"accounts,omitempty"`
+	// Metadata is free-form wallet metadata
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// NewBundle captures the contents of [keychains]. Only software and ledger
+// keychains, optionally wrapped with account metadata, can be captured.
+func NewBundle(keychains ...Keychain) (*Bundle, error) {
+	b := &Bundle{}
+	for _, kc := range keychains {
+		if err := b.capture(kc); err != nil {
+			return nil, err
+		}
+	}
+	slices.SortFunc(b.Retired, ids.ShortID.Compare)
+	slices.SortFunc(b.Validity, func(x, y KeyValidity) int {
+		return x.Address.Compare(y.Address)
+	})
+	slices.SortFunc(b.Accounts, func(x, y AccountMetadata) int {
+		return x.Address.Compare(y.Address)
+	})
+	slices.SortFunc(b.Ledger, func(x, y LedgerAccount) int {
+		return cmp.Compare(x.Index, y.Index)
+	})
+	return b, nil
+}
+
+func (b *Bundle) capture(kc Keychain) error {
+	switch kc := kc.(type) {
+	case *SoftwareKeychain:
+		b.Keys = append(b.Keys, kc.Keys()...)
+		b.Retired = append(b.Retired, kc.RetiredAddresses().List()...)
+		b.Validity = append(b.Validity, kc.validities()...)
+	case *ledgerKeychain:
+		for addr, idx := range kc.addrToIdx {
+			b.Ledger = append(b.Ledger, LedgerAccount{
+				Address: addr,
+				Index:   idx,
+			})
+		}
+	case *metadataKeychain:
+		b.Accounts = append(b.Accounts, kc.records()...)
+		return b.capture(kc.Keychain)
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedKeychain, kc)
+	}
+	return nil
+}
+
+// SoftwareKeychain returns a software keychain holding the bundle's keys
+func (b *Bundle) SoftwareKeychain() *SoftwareKeychain {
+	kc := NewSoftwareKeychain(b.Keys...)
+	for _, addr := range b.Retired {
+		_ = kc.Retire(addr)
+	}
+	for _, v := range b.Validity {
+		_ = kc.SetValidity(v.Address, v.Validity)
+	}
+	return kc
+}
+
+// MetadataKeychain wraps [kc] with the bundle's account metadata
+func (b *Bundle) MetadataKeychain(kc Keychain) MetadataKeychain {
+	return NewMetadataKeychain(kc, b.Accounts...)
+}
+
+// LedgerKeychain derives the bundle's ledger accounts from [ledger] and
+// returns the resulting keychain. ErrLedgerMismatch is returned if [ledger]
+// does not derive the addresses recorded in the bundle, for example because
+// a different device was connected.
+func (b *Bundle) LedgerKeychain(ledger Ledger, opts ...Option) (Keychain, error) {
+	indices := make([]uint32, len(b.Ledger))
+	expected := make(set.Set[ids.ShortID], len(b.Ledger))
+	for i, account := range b.Ledger {
+		indices[i] = account.Index
+		expected.Add(account.Address)
+	}
+
+	kc, err := NewLedgerKeychain(ledger, indices, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if !kc.Addresses().Equals(expected) {
+		return nil, ErrLedgerMismatch
+	}
+	return kc, nil
+}
+
+// Export encrypts the bundle with [pass] and writes it to [w] in the latest
+// version of the format, deriving the key with ScryptStandard
+func (b *Bundle) Export(w io.Writer, pass []byte) error {
+	return b.export(w, pass, bundleScrypt)
+}
+
+// ExportWithParams exports the bundle as Export, deriving the encryption key
+// from [pass] with the scrypt cost parameters [params], such as
+// ScryptHardened for cold storage
+func (b *Bundle) ExportWithParams(w io.Writer, pass []byte, params ScryptParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	return b.export(w, pass, params)
+}
+
+func (b *Bundle) export(w io.Writer, pass []byte, params ScryptParams) error {
+	plaintext, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, bundlePrefixLen+bundleKDFLen+bundleSaltLen+chacha20poly1305.NonceSizeX)
+	copy(header, bundleMagic[:])
+	binary.BigEndian.PutUint16(header[len(bundleMagic):], BundleVersion)
+	header[bundlePrefixLen] = byte(bits.Len(uint(params.N)) - 1)
+	header[bundlePrefixLen+1] = byte(params.R)
+	header[bundlePrefixLen+2] = byte(params.P)
+	saltAndNonce := header[bundlePrefixLen+bundleKDFLen:]
+	if _, err := rand.Read(saltAndNonce); err != nil {
+		return err
+	}
+	salt := saltAndNonce[:bundleSaltLen]
+	nonce := saltAndNonce[bundleSaltLen:]
+
+	aead, err := newBundleAEAD(pass, salt, params)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(aead.Seal(header, nonce, plaintext, header))
+	return err
+}
+
+// Import reads a bundle of any supported version from [r] and decrypts it
+// with [pass]
+func Import(r io.Reader, pass []byte) (*Bundle, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	version, err := ReadBundleVersion(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		params ScryptParams
+		kdfLen int
+	)
+	switch version {
+	case bundleVersionV1:
+		params = bundleScryptV1
+	case bundleVersionV2:
+		kdfLen = bundleKDFLen
+		if len(data) < bundlePrefixLen+kdfLen {
+			return nil, ErrInvalidBundle
+		}
+		params, err = parseBundleScrypt(data[bundlePrefixLen : bundlePrefixLen+kdfLen])
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedBundleVersion, version)
+	}
+
+	headerLen := bundlePrefixLen + kdfLen + bundleSaltLen + chacha20poly1305.NonceSizeX
+	if len(data) < headerLen {
+		return nil, ErrInvalidBundle
+	}
+	header := data[:headerLen]
+	salt := header[bundlePrefixLen+kdfLen : bundlePrefixLen+kdfLen+bundleSaltLen]
+	nonce := header[bundlePrefixLen+kdfLen+bundleSaltLen:]
+
+	aead, err := newBundleAEAD(pass, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, data[headerLen:], header)
+	if err != nil {
+		return nil, ErrBundleDecryption
+	}
+
+	b := &Bundle{}
+	if err := json.Unmarshal(plaintext, b); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidBundle, err)
+	}
+	return b, nil
+}
+
+// ReadBundleVersion returns the format version of the encoded bundle [data]
+// without decrypting it
+func ReadBundleVersion(data []byte) (uint16, error) {
+	if len(data) < bundlePrefixLen || !bytes.Equal(data[:len(bundleMagic)], bundleMagic[:]) {
+		return 0, ErrInvalidBundle
+	}
+	return binary.BigEndian.Uint16(data[len(bundleMagic):]), nil
+}
+
+// MigrateBundle reads a bundle of any supported version from [r] and writes
+// it to [w] in the latest version, encrypted with the same password
+func MigrateBundle(r io.Reader, w io.Writer, pass []byte) error {
+	b, err := Import(r, pass)
+	if err != nil {
+		return err
+	}
+	defer b.wipe()
+	return b.Export(w, pass)
+}
+
+// wipe clears the private keys of the bundle
+func (b *Bundle) wipe() {
+	for _, key := range b.Keys {
+		WipeKey(key)
+	}
+}
+
+func parseBundleScrypt(kdf []byte) (ScryptParams, error) {
+	logN, r, p := kdf[0], int(kdf[1]), int(kdf[2])
+	// Larger N need more memory than Import accepts, and overflow the int of
+	// 32 bit platforms
+	if logN == 0 || logN >= 30 {
+		return ScryptParams{}, ErrInvalidBundle
+	}
+	params := ScryptParams{N: 1 << logN, R: r, P: p}
+	if params.Validate() != nil {
+		return ScryptParams{}, ErrInvalidBundle
+	}
+	return params, nil
+}
+
+func newBundleAEAD(pass, salt []byte, params ScryptParams) (cipher.AEAD, error) {
+	key, err := scrypt.Key(pass, salt, params.N, params.R, params.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(key)
+}
@@ -0,0 +1,258 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math/bits"
+	"testing"
+
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/math/set"
+)
+
+// lightBundleScrypt lowers the bundle KDF cost for the duration of the test
+func lightBundleScrypt(t testing.TB) {
+	t.Helper()
+
+	params, paramsV1 := bundleScrypt, bundleScryptV1
+	bundleScrypt = ScryptParams{N: 1 << 10, R: 8, P: 1}
+	bundleScryptV1 = ScryptParams{N: 1 << 10, R: 8, P: 1}
+	t.Cleanup(func() {
+		bundleScrypt, bundleScryptV1 = params, paramsV1
+	})
+}
+
+func TestBundleExportImport(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	soft := NewSoftwareKeychain()
+	for range 3 {
+		_, err := soft.New()
+		require.NoError(err)
+	}
+	retired := soft.Keys()[0].Address()
+	_, err := soft.Rotate(retired)
+	require.NoError(err)
+
+	ledger := newMockLedger()
+	lkc, err := NewLedgerKeychain(ledger, []uint32{4, 1, 7})
+	require.NoError(err)
+
+	b, err := NewBundle(soft, lkc)
+	require.NoError(err)
+	b.Metadata = map[string]string{"name": "treasury"}
+	require.Equal([]uint32{1, 4, 7}, []uint32{b.Ledger[0].Index, b.Ledger[1].Index, b.Ledger[2].Index})
+
+	var buf bytes.Buffer
+	pass := []byte("correct horse battery staple")
+	require.NoError(b.Export(&buf, pass))
+	encoded := buf.Bytes()
+
+	// Wrong password
+	_, err = Import(bytes.NewReader(encoded), []byte("wrong"))
+	require.ErrorIs(err, ErrBundleDecryption)
+
+	// Tampered header
+	tampered := bytes.Clone(encoded)
+	tampered[len(bundleMagic)+2] ^= 1
+	_, err = Import(bytes.NewReader(tampered), pass)
+	require.ErrorIs(err, ErrBundleDecryption)
+
+	imported, err := Import(bytes.NewReader(encoded), pass)
+	require.NoError(err)
+	require.Equal(b.Metadata, imported.Metadata)
+	require.Equal(b.Ledger, imported.Ledger)
+
+	restored := imported.SoftwareKeychain()
+	require.True(restored.Addresses().Equals(soft.Addresses()))
+	require.True(restored.RetiredAddresses().Equals(set.Of(retired)))
+
+	restoredLedger, err := imported.LedgerKeychain(ledger)
+	require.NoError(err)
+	require.True(restoredLedger.Addresses().Equals(lkc.Addresses()))
+
+	// A different device derives different addresses
+	other := newMockLedger()
+	other.addresses[1] = [20]byte{0xff}
+	_, err = imported.LedgerKeychain(other)
+	require.ErrorIs(err, ErrLedgerMismatch)
+}
+
+func TestImportInvalidBundle(t *testing.T) {
+	require := require.New(t)
+
+	_, err := Import(bytes.NewReader([]byte("not a bundle")), nil)
+	require.ErrorIs(err, ErrInvalidBundle)
+
+	header := make([]byte, bundlePrefixLen)
+	copy(header, bundleMagic[:])
+	header[len(bundleMagic)+1] = byte(BundleVersion + 1)
+	_, err = Import(bytes.NewReader(header), nil)
+	require.ErrorIs(err, ErrUnsupportedBundleVersion)
+}
+
+func TestNewBundleUnsupportedKeychain(t *testing.T) {
+	require := require.New(t)
+
+	lazy, err := NewLazyLedgerKeychain(newMockLedger(), []uint32{0})
+	require.NoError(err)
+	_, err = NewBundle(lazy)
+	require.ErrorIs(err, ErrUnsupportedKeychain)
+}
+
+// exportV1 encodes [b] in the v1 format, which does not record the KDF
+// parameters
+func exportV1(t *testing.T, b *Bundle, pass []byte) []byte {
+	t.Helper()
+
+	plaintext, err := json.Marshal(b)
+	require.NoError(t, err)
+	header := make([]byte, bundlePrefixLen+bundleSaltLen+chacha20poly1305.NonceSizeX)
+	copy(header, bundleMagic[:])
+	binary.BigEndian.PutUint16(header[len(bundleMagic):], bundleVersionV1)
+	_, err = rand.Read(header[bundlePrefixLen:])
+	require.NoError(t, err)
+
+	aead, err := newBundleAEAD(pass, header[bundlePrefixLen:bundlePrefixLen+bundleSaltLen], bundleScryptV1)
+	require.NoError(t, err)
+	return aead.Seal(header, header[bundlePrefixLen+bundleSaltLen:], plaintext, header)
+}
+
+func TestMigrateBundle(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	soft := NewSoftwareKeychain()
+	_, err := soft.New()
+	require.NoError(err)
+	b, err := NewBundle(soft)
+	require.NoError(err)
+	b.Metadata = map[string]string{"name": "cold backup"}
+
+	pass := []byte("correct horse battery staple")
+	v1 := exportV1(t, b, pass)
+	version, err := ReadBundleVersion(v1)
+	require.NoError(err)
+	require.Equal(uint16(bundleVersionV1), version)
+
+	// v1 bundles remain readable
+	imported, err := Import(bytes.NewReader(v1), pass)
+	require.NoError(err)
+	require.Equal(b.Metadata, imported.Metadata)
+
+	var migrated bytes.Buffer
+	require.NoError(MigrateBundle(bytes.NewReader(v1), &migrated, pass))
+	version, err = ReadBundleVersion(migrated.Bytes())
+	require.NoError(err)
+	require.Equal(BundleVersion, version)
+
+	imported, err = Import(bytes.NewReader(migrated.Bytes()), pass)
+	require.NoError(err)
+	require.Equal(b.Metadata, imported.Metadata)
+	require.True(imported.SoftwareKeychain().Addresses().Equals(soft.Addresses()))
+
+	require.ErrorIs(MigrateBundle(bytes.NewReader(v1), io.Discard, []byte("wrong")), ErrBundleDecryption)
+}
+
+func TestBundleRecordsKDFParameters(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	var buf bytes.Buffer
+	pass := []byte("pass")
+	require.NoError((&Bundle{}).Export(&buf, pass))
+
+	// Bundles remain readable after the export cost changes
+	bundleScrypt = ScryptParams{N: 1 << 11, R: 8, P: 1}
+	_, err := Import(bytes.NewReader(buf.Bytes()), pass)
+	require.NoError(err)
+
+	// Bundles demanding excessive KDF memory are rejected before deriving
+	tampered := bytes.Clone(buf.Bytes())
+	tampered[bundlePrefixLen] = 30
+	_, err = Import(bytes.NewReader(tampered), pass)
+	require.ErrorIs(err, ErrInvalidBundle)
+}
+
+func TestBundleExportWithParams(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	b := &Bundle{Keys: []*secp256k1.PrivateKey{key}}
+
+	var buf bytes.Buffer
+	pass := []byte("pass")
+	params := ScryptParams{N: 1 << 12, R: 4, P: 2}
+	require.NoError(b.ExportWithParams(&buf, pass, params))
+	require.Equal([]byte{12, 4, 2}, buf.Bytes()[bundlePrefixLen:bundlePrefixLen+bundleKDFLen])
+
+	imported, err := Import(bytes.NewReader(buf.Bytes()), pass)
+	require.NoError(err)
+	require.Equal(key.Bytes(), imported.Keys[0].Bytes())
+
+	for _, params := range []ScryptParams{
+		{},
+		{N: 1000, R: 8, P: 1},
+		{N: 1 << 10, R: 256, P: 1},
+		{N: 1 << 10, R: 8, P: 0},
+		{N: 1 << 21, R: 8, P: 1},
+		// 128 * N * R overflows
+		{N: 1 << (bits.UintSize - 4), R: 8, P: 1},
+	} {
+		require.ErrorIs(b.ExportWithParams(&buf, pass, params), ErrInvalidKDFParams)
+	}
+	require.NoError(ScryptStandard.Validate())
+	require.NoError(ScryptHardened.Validate())
+}
+
+func FuzzImportBundle(f *testing.F) {
+	lightBundleScrypt(f)
+
+	kc, err := NewTestKeychain([]byte("bundle fuzz"), 2)
+	require.NoError(f, err)
+	b, err := NewBundle(kc)
+	require.NoError(f, err)
+	pass := []byte("pass")
+	var buf bytes.Buffer
+	require.NoError(f, b.Export(&buf, pass))
+	f.Add(buf.Bytes(), pass)
+	f.Add(buf.Bytes()[:bundlePrefixLen+bundleKDFLen], pass)
+	f.Add([]byte("not a bundle"), []byte(nil))
+
+	f.Fuzz(func(t *testing.T, data, pass []byte) {
+		// Headers demanding expensive key derivations are not worth fuzzing
+		if version, err := ReadBundleVersion(data); err == nil && version == bundleVersionV2 && len(data) >= bundlePrefixLen+bundleKDFLen {
+			params, err := parseBundleScrypt(data[bundlePrefixLen : bundlePrefixLen+bundleKDFLen])
+			if err == nil && params.N*params.R*params.P > 1<<16 {
+				t.Skip()
+			}
+		}
+
+		imported, err := Import(bytes.NewReader(data), pass)
+		if err != nil {
+			return
+		}
+		defer imported.wipe()
+
+		// Decrypted bundles export to a bundle that imports back
+		var buf bytes.Buffer
+		require.NoError(t, imported.Export(&buf, pass))
+		reimported, err := Import(&buf, pass)
+		require.NoError(t, err)
+		defer reimported.wipe()
+		require.Len(t, reimported.Keys, len(imported.Keys))
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"slices"
+	"sync"
+
+	"github.com/luxfi/ids"
+)
+
+var _ Signer = (*cachedSigner)(nil)
+
+// Cache returns a middleware that remembers the last [size] signatures
+// produced by the signers it wraps, keyed by address, operation and payload,
+// so re-signing an identical payload returns the cached signature instead of
+// another round trip to the device or KMS. Identical requests made while the
+// first is in flight wait for its result. Failed operations are not cached.
+// If [size] is not positive, signers are returned unwrapped.
+//
+// Signers of the software and ledger keychains produce deterministic
+// signatures, so a cached signature is the one a retry would obtain.
+func Cache(size int) Middleware {
+	if size <= 0 {
+		return func(next Signer) Signer {
+			return next
+		}
+	}
+	cache := &signatureCache{
+		size:     size,
+		entries:  make(map[cacheKey]*list.Element, size),
+		order:    list.New(),
+		inflight: make(map[cacheKey]*cacheCall),
+	}
+	return func(next Signer) Signer {
+		return &cachedSigner{
+			signer: next,
+			cache:  cache,
+		}
+	}
+}
+
+// NewCachingKeychain wraps [keychain] so that its signers share a cache of
+// [size] signatures, as by Cache
+func NewCachingKeychain(keychain Keychain, size int) Keychain {
+	return Wrap(keychain, Cache(size))
+}
+
+type cacheKey struct {
+	addr    ids.ShortID
+	scheme  SchemeID
+	op      SignOp
+	payload [sha256.Size]byte
+}
+
+type cacheEntry struct {
+	key cacheKey
+	sig []byte
+}
+
+// cacheCall is a signing operation in flight, whose result is shared with
+// identical requests made before it completes
+type cacheCall struct {
+	done chan struct{}
+	sig  []byte
+	err  error
+}
+
+// signatureCache is a least recently used cache of signatures
+type signatureCache struct {
+	lock     sync.Mutex
+	size     int
+	entries  map[cacheKey]*list.Element
+	order    *list.List
+	inflight map[cacheKey]*cacheCall
+}
+
+// sign returns the cached signature of [key], or runs [op] to produce it
+func (c *signatureCache) sign(key cacheKey, op func() ([]byte, error)) ([]byte, error) {
+	c.lock.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		sig := slices.Clone(elem.Value.(*cacheEntry).sig)
+		c.lock.Unlock()
+		return sig, nil
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.lock.Unlock()
+		<-call.done
+		return slices.Clone(call.sig), call.err
+	}
+	call := &cacheCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.lock.Unlock()
+
+	call.sig, call.err = op()
+
+	c.lock.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		c.add(key, slices.Clone(call.sig))
+	}
+	c.lock.Unlock()
+	close(call.done)
+	return slices.Clone(call.sig), call.err
+}
+
+// add caches [sig] under [key], evicting the least recently used signature
+// once the cache is full. The lock must be held.
+func (c *signatureCache) add(key cacheKey, sig []byte) {
+	c.entries[key] = c.order.PushFront(&cacheEntry{
+		key: key,
+		sig: sig,
+	})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+type cachedSigner struct {
+	signer Signer
+	cache  *signatureCache
+}
+
+func (c *cachedSigner) SignHash(hash []byte) ([]byte, error) {
+	return c.cache.sign(c.key(OpSignHash, hash), func() ([]byte, error) {
+		return c.signer.SignHash(hash)
+	})
+}
+
+func (c *cachedSigner) Sign(msg []byte) ([]byte, error) {
+	return c.cache.sign(c.key(OpSign, msg), func() ([]byte, error) {
+		return c.signer.Sign(msg)
+	})
+}
+
+func (c *cachedSigner) Address() ids.ShortID {
+	return c.signer.Address()
+}
+
+func (c *cachedSigner) Unwrap() Signer {
+	return c.signer
+}
+
+func (c *cachedSigner) key(op SignOp, payload []byte) cacheKey {
+	return cacheKey{
+		addr:    c.signer.Address(),
+		scheme:  SchemeOf(c.signer),
+		op:      op,
+		payload: sha256.Sum256(payload),
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countCalls returns a middleware counting the operations reaching the signer
+func countCalls(calls *atomic.Int32) Middleware {
+	return Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		calls.Add(1)
+		return next(payload)
+	})
+}
+
+func TestCache(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("cache"), 2)
+	require.NoError(err)
+	keys := kc.Keys()
+
+	var calls atomic.Int32
+	cached := Wrap(kc, Cache(2), countCalls(&calls))
+	first, ok := cached.Get(keys[0].Address())
+	require.True(ok)
+
+	msg := []byte("withdrawal 42")
+	sig, err := first.Sign(msg)
+	require.NoError(err)
+	require.True(keys[0].PublicKey().Verify(msg, sig))
+
+	// Signers returned by separate lookups share the cache
+	again, ok := cached.Get(keys[0].Address())
+	require.True(ok)
+	cachedSig, err := again.Sign(msg)
+	require.NoError(err)
+	require.Equal(sig, cachedSig)
+	require.Equal(int32(1), calls.Load())
+
+	// Cached signatures are copies
+	cachedSig[0] ^= 1
+	cachedSig, err = first.Sign(msg)
+	require.NoError(err)
+	require.Equal(sig, cachedSig)
+
+	// The key, the operation and the payload all distinguish entries
+	second, ok := cached.Get(keys[1].Address())
+	require.True(ok)
+	_, err = second.Sign(msg)
+	require.NoError(err)
+	require.Equal(int32(2), calls.Load())
+
+	hash := make([]byte, 32)
+	_, err = first.SignHash(hash)
+	require.NoError(err)
+	require.Equal(int32(3), calls.Load())
+
+	// The least recently used signature was evicted
+	_, err = second.Sign(msg)
+	require.NoError(err)
+	require.Equal(int32(3), calls.Load())
+	_, err = first.Sign(msg)
+	require.NoError(err)
+	require.Equal(int32(4), calls.Load())
+}
+
+func TestCacheSkipsFailures(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("cache"), 1)
+	require.NoError(err)
+
+	var calls atomic.Int32
+	signer, ok := Wrap(kc, Cache(8), countCalls(&calls)).Get(kc.Keys()[0].Address())
+	require.True(ok)
+
+	for range 2 {
+		_, err = signer.SignHash([]byte("short"))
+		require.Error(err)
+	}
+	require.Equal(int32(2), calls.Load())
+}
+
+func TestCacheCoalescesInflight(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("cache"), 1)
+	require.NoError(err)
+
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow := Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+		}
+		<-release
+		return next(payload)
+	})
+	signer, ok := NewCachingKeychain(Wrap(kc, slow), 8).Get(kc.Keys()[0].Address())
+	require.True(ok)
+
+	msg := []byte("retry storm")
+	sigs := make([][]byte, 4)
+	var wg sync.WaitGroup
+	for i := range sigs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sigs[i], _ = signer.Sign(msg)
+		}()
+	}
+	<-started
+	// Give the other requests time to find the one in flight
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(int32(1), calls.Load())
+	for _, sig := range sigs {
+		require.Equal(sigs[0], sig)
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("cache"), 1)
+	require.NoError(err)
+	signer, ok := kc.Get(kc.Keys()[0].Address())
+	require.True(ok)
+
+	require.Equal(signer, Cache(0)(signer))
+}
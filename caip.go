@@ -0,0 +1,209 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/luxfi/crypto/address"
+	"github.com/luxfi/crypto/common"
+	"github.com/luxfi/ids"
+)
+
+// CAIP-2 namespaces
+const (
+	LuxNamespace    = "lux"
+	EIP155Namespace = "eip155"
+)
+
+var (
+	ErrInvalidChainID   = errors.New("invalid CAIP-2 chain ID")
+	ErrInvalidAccountID = errors.New("invalid CAIP-10 account ID")
+	ErrUnexpectedChain  = errors.New("account ID is not on the expected chain")
+
+	caipNamespace = regexp.MustCompile(`^[-a-z0-9]{3,8}$`)
+	caipReference = regexp.MustCompile(`^[-_a-zA-Z0-9]{1,32}$`)
+	caipAddress   = regexp.MustCompile(`^[-.%a-zA-Z0-9]{1,128}$`)
+)
+
+// ChainID is a CAIP-2 blockchain identifier, such as lux:X or eip155:96369
+type ChainID struct {
+	Namespace string
+	Reference string
+}
+
+// LuxChainID returns the chain ID of the Lux chain [chainAlias], such as
+// lux:X for XChainAlias
+func LuxChainID(chainAlias string) ChainID {
+	return ChainID{
+		Namespace: LuxNamespace,
+		Reference: chainAlias,
+	}
+}
+
+// EVMChainID returns the chain ID eip155:[chainID] of an EVM chain, such as
+// the C-chain
+func EVMChainID(chainID uint64) ChainID {
+	return ChainID{
+		Namespace: EIP155Namespace,
+		Reference: strconv.FormatUint(chainID, 10),
+	}
+}
+
+// ParseChainID parses a chain ID of the form <namespace>:<reference>
+func ParseChainID(s string) (ChainID, error) {
+	namespace, reference, ok := strings.Cut(s, ":")
+	c := ChainID{
+		Namespace: namespace,
+		Reference: reference,
+	}
+	if !ok || !c.valid() {
+		return ChainID{}, fmt.Errorf("%w: %q", ErrInvalidChainID, s)
+	}
+	return c, nil
+}
+
+func (c ChainID) valid() bool {
+	return caipNamespace.MatchString(c.Namespace) && caipReference.MatchString(c.Reference)
+}
+
+func (c ChainID) String() string {
+	return c.Namespace + ":" + c.Reference
+}
+
+func (c ChainID) MarshalText() ([]byte, error) {
+	if !c.valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidChainID, c.String())
+	}
+	return []byte(c.String()), nil
+}
+
+func (c *ChainID) UnmarshalText(text []byte) error {
+	parsed, err := ParseChainID(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// AccountID is a CAIP-10 account identifier, such as lux:X:lux1... or
+// eip155:96369:0x..., by which multi-chain wallet backends and WalletConnect
+// sessions reference accounts
+type AccountID struct {
+	Chain   ChainID
+	Address string
+}
+
+// LuxAccountID returns the account ID lux:<chainAlias>:<bech32 address> of
+// [addr], formatted with [hrp]
+func LuxAccountID(chainAlias, hrp string, addr ids.ShortID) (AccountID, error) {
+	bech32, err := address.FormatBech32(hrp, addr.Bytes())
+	if err != nil {
+		return AccountID{}, err
+	}
+	return AccountID{
+		Chain:   LuxChainID(chainAlias),
+		Address: bech32,
+	}, nil
+}
+
+// EVMAccountID returns the account ID eip155:<chainID>:<checksummed address>
+// of [addr]
+func EVMAccountID(chainID uint64, addr common.Address) AccountID {
+	return AccountID{
+		Chain:   EVMChainID(chainID),
+		Address: addr.Hex(),
+	}
+}
+
+// LuxAccountIDs returns the account IDs of the addresses of [kc] on the Lux
+// chain [chainAlias], sorted by address
+func LuxAccountIDs(kc Keychain, chainAlias, hrp string) ([]AccountID, error) {
+	addrs := kc.Addresses().List()
+	slices.SortFunc(addrs, ids.ShortID.Compare)
+
+	accounts := make([]AccountID, len(addrs))
+	for i, addr := range addrs {
+		var err error
+		accounts[i], err = LuxAccountID(chainAlias, hrp, addr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return accounts, nil
+}
+
+// ParseAccountID parses an account ID of the form
+// <namespace>:<reference>:<address>
+func ParseAccountID(s string) (AccountID, error) {
+	i := strings.LastIndexByte(s, ':')
+	if i < 0 {
+		return AccountID{}, fmt.Errorf("%w: %q", ErrInvalidAccountID, s)
+	}
+	chain, err := ParseChainID(s[:i])
+	if err != nil {
+		return AccountID{}, fmt.Errorf("%w: %w", ErrInvalidAccountID, err)
+	}
+	a := AccountID{
+		Chain:   chain,
+		Address: s[i+1:],
+	}
+	if !caipAddress.MatchString(a.Address) {
+		return AccountID{}, fmt.Errorf("%w: %q", ErrInvalidAccountID, s)
+	}
+	return a, nil
+}
+
+func (a AccountID) String() string {
+	return a.Chain.String() + ":" + a.Address
+}
+
+func (a AccountID) MarshalText() ([]byte, error) {
+	if !a.Chain.valid() || !caipAddress.MatchString(a.Address) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAccountID, a.String())
+	}
+	return []byte(a.String()), nil
+}
+
+func (a *AccountID) UnmarshalText(text []byte) error {
+	parsed, err := ParseAccountID(string(text))
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// LuxAddress returns the address of a Lux account ID on the chain
+// [chainAlias] of the network [hrp]
+func (a AccountID) LuxAddress(chainAlias, hrp string) (ids.ShortID, error) {
+	if a.Chain != LuxChainID(chainAlias) {
+		return ids.ShortEmpty, fmt.Errorf("%w: expected %s but got %s", ErrUnexpectedChain, LuxChainID(chainAlias), a.Chain)
+	}
+	gotHRP, addrBytes, err := address.ParseBech32(a.Address)
+	if err != nil {
+		return ids.ShortEmpty, fmt.Errorf("%w: %w", ErrInvalidAccountID, err)
+	}
+	if gotHRP != hrp {
+		return ids.ShortEmpty, fmt.Errorf("%w: expected %q but got %q", ErrUnexpectedHRP, hrp, gotHRP)
+	}
+	return ids.ToShortID(addrBytes)
+}
+
+// EVMAddress returns the address of an EVM account ID on the chain [chainID]
+func (a AccountID) EVMAddress(chainID uint64) (common.Address, error) {
+	if a.Chain != EVMChainID(chainID) {
+		return common.Address{}, fmt.Errorf("%w: expected %s but got %s", ErrUnexpectedChain, EVMChainID(chainID), a.Chain)
+	}
+	if !common.IsHexAddress(a.Address) {
+		return common.Address{}, fmt.Errorf("%w: %q is not an EVM address", ErrInvalidAccountID, a.Address)
+	}
+	return common.HexToAddress(a.Address), nil
+}
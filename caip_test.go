@@ -0,0 +1,159 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/luxfi/crypto/common"
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLuxAccountID(t *testing.T) {
+	require := require.New(t)
+
+	addr := ids.GenerateTestShortID()
+	account, err := LuxAccountID(XChainAlias, MainnetHRP, addr)
+	require.NoError(err)
+	formatted, err := FormatAddress(XChainAlias, MainnetHRP, addr)
+	require.NoError(err)
+	require.Equal("lux:X:"+strings.TrimPrefix(formatted, "X-"), account.String())
+
+	parsed, err := ParseAccountID(account.String())
+	require.NoError(err)
+	require.Equal(account, parsed)
+	got, err := parsed.LuxAddress(XChainAlias, MainnetHRP)
+	require.NoError(err)
+	require.Equal(addr, got)
+
+	_, err = parsed.LuxAddress(PChainAlias, MainnetHRP)
+	require.ErrorIs(err, ErrUnexpectedChain)
+	_, err = parsed.LuxAddress(XChainAlias, TestnetHRP)
+	require.ErrorIs(err, ErrUnexpectedHRP)
+}
+
+func TestEVMAccountID(t *testing.T) {
+	require := require.New(t)
+
+	addr := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	account := EVMAccountID(96369, addr)
+	require.Equal("eip155:96369:0x9858EfFD232B4033E47d90003D41EC34EcaEda94", account.String())
+
+	parsed, err := ParseAccountID(account.String())
+	require.NoError(err)
+	got, err := parsed.EVMAddress(96369)
+	require.NoError(err)
+	require.Equal(addr, got)
+
+	_, err = parsed.EVMAddress(1)
+	require.ErrorIs(err, ErrUnexpectedChain)
+	_, err = parsed.LuxAddress(CChainAlias, MainnetHRP)
+	require.ErrorIs(err, ErrUnexpectedChain)
+}
+
+func TestParseAccountIDErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          string
+		expectedErr error
+	}{
+		{
+			name:        "no separator",
+			id:          "lux",
+			expectedErr: ErrInvalidAccountID,
+		},
+		{
+			name:        "missing address",
+			id:          "lux:X",
+			expectedErr: ErrInvalidAccountID,
+		},
+		{
+			name:        "empty address",
+			id:          "lux:X:",
+			expectedErr: ErrInvalidAccountID,
+		},
+		{
+			name:        "short namespace",
+			id:          "lx:X:lux1abc",
+			expectedErr: ErrInvalidChainID,
+		},
+		{
+			name:        "uppercase namespace",
+			id:          "LUX:X:lux1abc",
+			expectedErr: ErrInvalidChainID,
+		},
+		{
+			name:        "invalid address character",
+			id:          "lux:X:lux1/abc",
+			expectedErr: ErrInvalidAccountID,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseAccountID(test.id)
+			require.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
+
+func TestParseChainID(t *testing.T) {
+	require := require.New(t)
+
+	chain, err := ParseChainID("lux:P")
+	require.NoError(err)
+	require.Equal(LuxChainID(PChainAlias), chain)
+
+	_, err = ParseChainID("lux")
+	require.ErrorIs(err, ErrInvalidChainID)
+	_, err = ParseChainID("lux:" + strings.Repeat("a", 33))
+	require.ErrorIs(err, ErrInvalidChainID)
+}
+
+func TestAccountIDJSON(t *testing.T) {
+	require := require.New(t)
+
+	account := EVMAccountID(1, common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"))
+	data, err := json.Marshal(struct {
+		Account AccountID `json:"account"`
+		Chain   ChainID   `json:"chain"`
+	}{account, account.Chain})
+	require.NoError(err)
+	require.JSONEq(`{"account":"eip155:1:0x9858EfFD232B4033E47d90003D41EC34EcaEda94","chain":"eip155:1"}`, string(data))
+
+	var decoded struct {
+		Account AccountID `json:"account"`
+		Chain   ChainID   `json:"chain"`
+	}
+	require.NoError(json.Unmarshal(data, &decoded))
+	require.Equal(account, decoded.Account)
+	require.Equal(account.Chain, decoded.Chain)
+
+	_, err = json.Marshal(AccountID{})
+	require.ErrorIs(err, ErrInvalidAccountID)
+}
+
+func TestLuxAccountIDs(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("caip"), 3)
+	require.NoError(err)
+	accounts, err := LuxAccountIDs(kc, PChainAlias, TestnetHRP)
+	require.NoError(err)
+	require.Len(accounts, 3)
+
+	addrs := kc.Addresses()
+	for i, account := range accounts {
+		addr, err := account.LuxAddress(PChainAlias, TestnetHRP)
+		require.NoError(err)
+		require.True(addrs.Contains(addr))
+		if i > 0 {
+			prev, err := accounts[i-1].LuxAddress(PChainAlias, TestnetHRP)
+			require.NoError(err)
+			require.Negative(prev.Compare(addr))
+		}
+	}
+}
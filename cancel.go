@@ -0,0 +1,215 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/luxfi/ids"
+)
+
+var (
+	ErrOperationCanceled = errors.New("operation was canceled")
+
+	_ Canceler = (*LedgerDevice)(nil)
+	_ Canceler = (*ledgerSigner)(nil)
+	_ Canceler = (*timeoutLedger)(nil)
+	_ Canceler = (*retryLedger)(nil)
+	_ Canceler = (*timeoutSigner)(nil)
+)
+
+// SignContext signs [msg] with [signer], returning early once [ctx] is done.
+// When [ctx] is done first, the in-flight operation is aborted if [signer]
+// implements Canceler, so that a hardware device is not left waiting for a
+// confirmation the user abandoned. The middlewares wrapping [signer] that use
+// a context get [ctx], such as TOTP for a code set with WithTOTPCode and
+// Tracing for the parent of its spans.
+func SignContext(ctx context.Context, signer Signer, msg []byte) ([]byte, error) {
+	return withContext(ctx, cancelFunc(signer), func() ([]byte, error) {
+		defer bindContext(ctx, signer, OpSign, msg)()
+		return signer.Sign(msg)
+	})
+}
+
+// SignHashContext signs [hash] with [signer], as SignContext
+func SignHashContext(ctx context.Context, signer Signer, hash []byte) ([]byte, error) {
+	return withContext(ctx, cancelFunc(signer), func() ([]byte, error) {
+		defer bindContext(ctx, signer, OpSignHash, hash)()
+		return signer.SignHash(hash)
+	})
+}
+
+// Cancel aborts the in-flight exchange with the device, which then fails
+// with ErrOperationCanceled. If the transport implements Canceler the
+// exchange is aborted through it; otherwise the transport is closed and the
+// device must be reconnected. Cancel does nothing if no exchange is in
+// flight.
+func (l *LedgerDevice) Cancel() error {
+	// The lock is held while aborting, so that the exchange cannot complete
+	// and another start in between
+	l.cancelLock.Lock()
+	defer l.cancelLock.Unlock()
+
+	if !l.inFlight {
+		return nil
+	}
+	l.canceled = true
+	if c, ok := l.transport.(Canceler); ok {
+		return c.Cancel()
+	}
+	return l.transport.Close()
+}
+
+// Cancel aborts the in-flight request of the signer if its ledger implements
+// Canceler
+func (l *ledgerSigner) Cancel() error {
+	if c, ok := l.ledger.(Canceler); ok {
+		return c.Cancel()
+	}
+	return nil
+}
+
+func (t *timeoutLedger) Cancel() error {
+	return t.cancel()
+}
+
+func (t *timeoutSigner) Cancel() error {
+	return t.cancel()
+}
+
+func (r *retryLedger) Cancel() error {
+	if c, ok := r.ledger.(Canceler); ok {
+		return c.Cancel()
+	}
+	return nil
+}
+
+// contextBinder is implemented by signers whose operations use the context
+// they are signed with by SignContext and SignHashContext
+type contextBinder interface {
+	// bindContext makes [ctx] the context of the operation [op] of [payload]
+	// until the returned function is called
+	bindContext(ctx context.Context, op SignOp, payload []byte) func()
+}
+
+// bindContext binds [ctx] to the operation [op] of [payload] of the signers
+// wrapping [signer] that implement contextBinder, and returns a function
+// unbinding it
+func bindContext(ctx context.Context, signer Signer, op SignOp, payload []byte) func() {
+	var unbinds []func()
+	for s := signer; s != nil; {
+		if b, ok := s.(contextBinder); ok {
+			unbinds = append(unbinds, b.bindContext(ctx, op, payload))
+		}
+		u, ok := s.(Unwrapper)
+		if !ok {
+			break
+		}
+		s = u.Unwrap()
+	}
+	return func() {
+		for _, unbind := range unbinds {
+			unbind()
+		}
+	}
+}
+
+// boundContexts are the contexts bound to the operations of signers. The
+// zero value is ready to use.
+type boundContexts struct {
+	lock     sync.Mutex
+	contexts map[ids.ID][]*boundContext
+}
+
+// boundContext is a context bound to an operation, compared by identity so
+// that unbinding removes its own binding
+type boundContext struct {
+	ctx context.Context
+}
+
+// operationID identifies the operation [op] of [payload] by signer [addr]
+func operationID(addr ids.ShortID, op SignOp, payload []byte) ids.ID {
+	h := sha256.New()
+	_, _ = h.Write(addr[:])
+	_, _ = h.Write([]byte(op))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(payload)
+	return ids.ID(h.Sum(nil))
+}
+
+func (b *boundContexts) bind(ctx context.Context, addr ids.ShortID, op SignOp, payload []byte) func() {
+	id := operationID(addr, op, payload)
+	bound := &boundContext{ctx: ctx}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.contexts == nil {
+		b.contexts = make(map[ids.ID][]*boundContext)
+	}
+	b.contexts[id] = append(b.contexts[id], bound)
+	return func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+
+		contexts := b.contexts[id]
+		if i := slices.Index(contexts, bound); i >= 0 {
+			contexts = slices.Delete(contexts, i, i+1)
+		}
+		if len(contexts) > 0 {
+			b.contexts[id] = contexts
+		} else {
+			delete(b.contexts, id)
+		}
+	}
+}
+
+// get returns the context bound to [op] of [payload] by signer [addr], or
+// the background context if there is none. If several are bound to the same
+// operation, the first bound is returned.
+func (b *boundContexts) get(addr ids.ShortID, op SignOp, payload []byte) context.Context {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if contexts := b.contexts[operationID(addr, op, payload)]; len(contexts) > 0 {
+		return contexts[0].ctx
+	}
+	return context.Background()
+}
+
+func cancelFunc(signer Signer) func() error {
+	return func() error {
+		if c, ok := signer.(Canceler); ok {
+			return c.Cancel()
+		}
+		return nil
+	}
+}
+
+// withContext runs [op] and returns its result, or an error wrapping
+// ErrOperationCanceled and the context error if [ctx] is done first. In that
+// case [cancel] is invoked so the underlying operation can unwind.
+func withContext[T any](ctx context.Context, cancel func() error, op func() (T, error)) (T, error) {
+	done := make(chan result[T], 1)
+	go func() {
+		value, err := op()
+		done <- result[T]{value: value, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		err := fmt.Errorf("%w: %w", ErrOperationCanceled, context.Cause(ctx))
+		if cancelErr := cancel(); cancelErr != nil {
+			return zero, errors.Join(err, cancelErr)
+		}
+		return zero, err
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+)
+
+var errTransportAborted = errors.New("transport aborted")
+
+// awaitingTransport forwards to an app but blocks signing requests, as a
+// device waiting for the user to confirm, until it is aborted
+type awaitingTransport struct {
+	app     *fakeLuxApp
+	waiting chan struct{}
+
+	abortOnce sync.Once
+	aborted   chan struct{}
+	closed    bool
+}
+
+func newAwaitingTransport() *awaitingTransport {
+	return &awaitingTransport{
+		app:     newFakeLuxApp(),
+		waiting: make(chan struct{}, 1),
+		aborted: make(chan struct{}),
+	}
+}
+
+func (a *awaitingTransport) Exchange(apdu []byte) ([]byte, error) {
+	if apdu[1] != insSignTransaction {
+		return a.app.Exchange(apdu)
+	}
+	a.waiting <- struct{}{}
+	<-a.aborted
+	return nil, errTransportAborted
+}
+
+func (a *awaitingTransport) abort() {
+	a.abortOnce.Do(func() {
+		close(a.aborted)
+	})
+}
+
+func (a *awaitingTransport) Close() error {
+	a.closed = true
+	a.abort()
+	return nil
+}
+
+// cancelingTransport aborts exchanges without being closed
+type cancelingTransport struct {
+	*awaitingTransport
+}
+
+func (c cancelingTransport) Cancel() error {
+	c.abort()
+	return nil
+}
+
+func TestSignContextCancelsLedger(t *testing.T) {
+	tests := []struct {
+		name           string
+		cancelable     bool
+		expectedClosed bool
+	}{
+		{
+			name:           "transport canceler",
+			cancelable:     true,
+			expectedClosed: false,
+		},
+		{
+			name:           "close transport",
+			cancelable:     false,
+			expectedClosed: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+
+			awaiting := newAwaitingTransport()
+			var transport Transport = awaiting
+			if test.cancelable {
+				transport = cancelingTransport{awaitingTransport: awaiting}
+			}
+			device := NewLedgerDevice(transport)
+			require.NoError(device.Cancel())
+
+			kc, err := NewLedgerKeychain(device, []uint32{0})
+			require.NoError(err)
+			signer, ok := kc.Get(kc.Addresses().List()[0])
+			require.True(ok)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				<-awaiting.waiting
+				cancel()
+			}()
+			_, err = SignContext(ctx, signer, []byte("tx"))
+			require.ErrorIs(err, ErrOperationCanceled)
+			require.ErrorIs(err, context.Canceled)
+			require.Equal(test.expectedClosed, awaiting.closed)
+		})
+	}
+}
+
+func TestLedgerDeviceCancel(t *testing.T) {
+	require := require.New(t)
+
+	awaiting := newAwaitingTransport()
+	device := NewLedgerDevice(cancelingTransport{awaitingTransport: awaiting})
+
+	results := make(chan error, 1)
+	go func() {
+		_, err := device.Sign([]byte("tx"), 0)
+		results <- err
+	}()
+	<-awaiting.waiting
+	require.NoError(device.Cancel())
+	require.ErrorIs(<-results, ErrOperationCanceled)
+
+	// The device remains usable
+	_, err := device.Version()
+	require.NoError(err)
+}
+
+// completingTransport forwards to an app, running onResponse once the app
+// responded and before the exchange returns
+type completingTransport struct {
+	app        *fakeLuxApp
+	onResponse func()
+	closes     int
+}
+
+func (c *completingTransport) Exchange(apdu []byte) ([]byte, error) {
+	resp, err := c.app.Exchange(apdu)
+	if c.onResponse != nil {
+		c.onResponse()
+	}
+	return resp, err
+}
+
+func (c *completingTransport) Close() error {
+	c.closes++
+	return nil
+}
+
+func TestLedgerDeviceCancelAsExchangeCompletes(t *testing.T) {
+	require := require.New(t)
+
+	transport := &completingTransport{app: newFakeLuxApp()}
+	device := NewLedgerDevice(transport)
+
+	// A cancel as the device responds aborts that exchange only
+	transport.onResponse = func() {
+		transport.onResponse = nil
+		require.NoError(device.Cancel())
+	}
+	_, err := device.Sign([]byte("tx"), 0)
+	require.ErrorIs(err, ErrOperationCanceled)
+	require.Equal(1, transport.closes)
+	_, err = device.Sign([]byte("tx"), 0)
+	require.NoError(err)
+
+	// A cancel once the exchange completed does nothing
+	require.NoError(device.Cancel())
+	require.Equal(1, transport.closes)
+	_, err = device.Version()
+	require.NoError(err)
+}
+
+func TestLedgerDeviceCancelConcurrently(t *testing.T) {
+	require := require.New(t)
+
+	awaiting := newAwaitingTransport()
+	device := NewLedgerDevice(cancelingTransport{awaitingTransport: awaiting})
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = device.Cancel()
+			}
+		}
+	}()
+	for range 100 {
+		_, err := device.Version()
+		if err != nil {
+			require.ErrorIs(err, ErrOperationCanceled)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	// No cancel outlives the exchange it was aimed at
+	_, err := device.Version()
+	require.NoError(err)
+}
+
+func TestBoundContexts(t *testing.T) {
+	require := require.New(t)
+
+	type key struct{}
+	var contexts boundContexts
+	addr, other := ids.GenerateTestShortID(), ids.GenerateTestShortID()
+	first := context.WithValue(context.Background(), key{}, "first")
+	second := context.WithValue(context.Background(), key{}, "second")
+	unbindFirst := contexts.bind(first, addr, OpSign, []byte("tx"))
+	unbindSecond := contexts.bind(second, addr, OpSign, []byte("tx"))
+
+	// Contexts are bound to the operations of a single signer
+	require.Equal(first, contexts.get(addr, OpSign, []byte("tx")))
+	require.Equal(context.Background(), contexts.get(other, OpSign, []byte("tx")))
+	require.Equal(context.Background(), contexts.get(addr, OpSignHash, []byte("tx")))
+
+	// Unbinding removes the context of the caller, not the first one
+	unbindSecond()
+	require.Equal(first, contexts.get(addr, OpSign, []byte("tx")))
+	unbindFirst()
+	require.Equal(context.Background(), contexts.get(addr, OpSign, []byte("tx")))
+	require.Empty(contexts.contexts)
+}
+
+func TestSignHashContext(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("cancel"), 1)
+	require.NoError(err)
+	signer, ok := kc.Get(kc.Keys()[0].Address())
+	require.True(ok)
+
+	sig, err := SignHashContext(context.Background(), signer, make([]byte, 32))
+	require.NoError(err)
+	require.NotEmpty(sig)
+}
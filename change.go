@@ -0,0 +1,54 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrInvalidChangeIndicesLength = errors.New("too many change indices")
+
+	_ ChangeLedger = (*timeoutLedger)(nil)
+	_ ChangeLedger = (*previewLedger)(nil)
+	_ ChangeLedger = (*retryLedger)(nil)
+)
+
+// ChangeLedger is implemented by ledgers that can be told which outputs of a
+// transaction return change to addresses of the device, so that the device
+// does not warn about them as sends to unknown addresses
+type ChangeLedger interface {
+	Ledger
+	SignTransactionWithChange(rawUnsignedHash []byte, addressIndices, changeIndices []uint32) ([][]byte, error)
+}
+
+// SignTransactionWithChange signs [rawUnsignedHash] with [addressIndices],
+// passing [changeIndices] to [ledger] if it implements ChangeLedger. Other
+// ledgers sign the transaction without the change information.
+func SignTransactionWithChange(ledger Ledger, rawUnsignedHash []byte, addressIndices, changeIndices []uint32) ([][]byte, error) {
+	if c, ok := ledger.(ChangeLedger); ok && len(changeIndices) > 0 {
+		return c.SignTransactionWithChange(rawUnsignedHash, addressIndices, changeIndices)
+	}
+	return ledger.SignTransaction(rawUnsignedHash, addressIndices)
+}
+
+func (t *timeoutLedger) SignTransactionWithChange(rawUnsignedHash []byte, addressIndices, changeIndices []uint32) ([][]byte, error) {
+	return withTimeout(t.timeouts.Sign, t.cancel, func() ([][]byte, error) {
+		return SignTransactionWithChange(t.ledger, rawUnsignedHash, addressIndices, changeIndices)
+	})
+}
+
+func (p *previewLedger) SignTransactionWithChange(rawUnsignedHash []byte, addressIndices, changeIndices []uint32) ([][]byte, error) {
+	if err := p.preview(rawUnsignedHash, addressIndices); err != nil {
+		return nil, err
+	}
+	return SignTransactionWithChange(p.ledger, rawUnsignedHash, addressIndices, changeIndices)
+}
+
+func (r *retryLedger) SignTransactionWithChange(rawUnsignedHash []byte, addressIndices, changeIndices []uint32) ([][]byte, error) {
+	return RetryOperation(context.Background(), r.policy, func() ([][]byte, error) {
+		return SignTransactionWithChange(r.ledger, rawUnsignedHash, addressIndices, changeIndices)
+	})
+}
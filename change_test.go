@@ -0,0 +1,69 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerDeviceSignTransactionWithChange(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	device := NewLedgerDevice(app)
+
+	tx := []byte("unsigned transaction with change")
+	sigs, err := device.SignTransactionWithChange(tx, []uint32{0, 2}, []uint32{5})
+	require.NoError(err)
+	require.Len(sigs, 2)
+	require.Equal([][]uint32{AddressPath(5)}, app.change)
+
+	for i, idx := range []uint32{0, 2} {
+		addr, err := device.Address("", idx)
+		require.NoError(err)
+		pubKey, err := secp256k1.RecoverPublicKey(tx, sigs[i])
+		require.NoError(err)
+		require.Equal(addr, pubKey.Address())
+	}
+
+	// Signing without change does not send any change paths
+	_, err = device.SignTransaction(tx, []uint32{0})
+	require.NoError(err)
+	require.Nil(app.change)
+
+	_, err = device.SignTransactionWithChange(tx, nil, []uint32{5})
+	require.ErrorIs(err, ErrInvalidIndicesLength)
+	_, err = device.SignTransactionWithChange(tx, []uint32{0}, make([]uint32, maxAPDUDataLen+1))
+	require.ErrorIs(err, ErrInvalidChangeIndicesLength)
+	_, err = device.SignTransactionWithChange(make([]byte, MaxLedgerTransactionLen+1), []uint32{0}, []uint32{5})
+	require.ErrorIs(err, ErrPayloadTooLarge)
+}
+
+func TestSignTransactionWithChangeWrappers(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	ledger := NewTimeoutLedger(NewPreviewLedger(NewLedgerDevice(app), PreviewConfig{
+		Decode: decodeTestTx,
+	}), Timeouts{})
+
+	_, err := SignTransactionWithChange(ledger, []byte("transfer"), []uint32{0}, []uint32{1, 2})
+	require.NoError(err)
+	require.Equal([][]uint32{AddressPath(1), AddressPath(2)}, app.change)
+
+	_, err = SignTransactionWithChange(ledger, []byte("drain"), []uint32{0}, []uint32{1})
+	require.ErrorIs(err, ErrTransactionRejected)
+}
+
+func TestSignTransactionWithChangeFallback(t *testing.T) {
+	require := require.New(t)
+
+	// Ledgers that do not support change paths sign without them
+	sigs, err := SignTransactionWithChange(newMockLedger(), []byte("tx"), []uint32{0, 1}, []uint32{2})
+	require.NoError(err)
+	require.Len(sigs, 2)
+}
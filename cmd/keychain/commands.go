@@ -0,0 +1,452 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/keychain"
+	"github.com/luxfi/math/set"
+)
+
+// passphraseEnv holds the passphrase of the mnemonic, if any
+const passphraseEnv = "KEYCHAIN_PASSPHRASE"
+
+var (
+	errNoBackend        = errors.New("exactly one of -key-file, -mnemonic-file or -speculos is required")
+	errNoPayload        = errors.New("exactly one of -hash or -file is required")
+	errInvalidHash      = errors.New("hash must be 32 bytes of hex")
+	errInvalidSignature = errors.New("signature does not match the address")
+	errNoSocket         = errors.New("-socket is required")
+)
+
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (e *env) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+// readSecret reads the trimmed contents of [path], or of stdin if [path] is
+// "-"
+func (e *env) readSecret(path string) ([]byte, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if path == "-" {
+		b, err = io.ReadAll(e.stdin)
+	} else {
+		b, err = os.ReadFile(path)
+	}
+	return bytes.TrimSpace(b), err
+}
+
+func (e *env) generate(args []string) error {
+	fs := e.flagSet("generate")
+	words := fs.Int("words", 0, "generate a mnemonic of this many words instead of a private key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *words == 0 {
+		key, err := secp256k1.NewPrivateKey()
+		if err != nil {
+			return err
+		}
+		defer keychain.WipeKey(key)
+		fmt.Fprintln(e.stdout, key)
+		return e.printAddress("", key.Address())
+	}
+
+	mnemonic, err := keychain.NewMnemonic(*words)
+	if err != nil {
+		return err
+	}
+	kc, err := keychain.NewMnemonicKeychain(mnemonic, os.Getenv(passphraseEnv), []uint32{0})
+	if err != nil {
+		return err
+	}
+	defer kc.Destroy()
+
+	fmt.Fprintln(e.stdout, mnemonic)
+	return e.printAddress(keychain.AddressPath(0).String(), kc.Addresses().List()[0])
+}
+
+func (e *env) printAddress(path string, addr ids.ShortID) error {
+	formatted, err := keychain.FormatAddress(keychain.XChainAlias, keychain.MainnetHRP, addr)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		fmt.Fprintln(e.stdout, formatted)
+	} else {
+		fmt.Fprintf(e.stdout, "%s\t%s\n", path, formatted)
+	}
+	return nil
+}
+
+// backend selects the keychain a command operates on
+type backend struct {
+	keyFile      string
+	mnemonicFile string
+	speculos     string
+	coinType     uint
+}
+
+func (b *backend) register(fs *flag.FlagSet) {
+	fs.StringVar(&b.keyFile, "key-file", "", "file holding a PrivateKey-... private key")
+	fs.StringVar(&b.mnemonicFile, "mnemonic-file", "", "file holding a BIP39 mnemonic")
+	fs.StringVar(&b.speculos, "speculos", "", "address of the APDU server of a Speculos emulator running the Lux app")
+	fs.UintVar(&b.coinType, "coin-type", keychain.LuxCoinType, "BIP44 coin type of the mnemonic paths, such as 60 for the accounts of Ethereum wallets")
+}
+
+// account is an address of a backend together with its address index, if
+// derived
+type account struct {
+	index  uint32
+	path   string
+	signer keychain.Signer
+}
+
+// accounts returns the accounts of [indices], in order. A private key backend
+// has a single account regardless of [indices]. The returned function
+// releases the backend.
+func (b *backend) accounts(e *env, indices []uint32) ([]account, func(), error) {
+	var set int
+	for _, s := range []string{b.keyFile, b.mnemonicFile, b.speculos} {
+		if s != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, nil, errNoBackend
+	}
+
+	switch {
+	case b.keyFile != "":
+		secret, err := e.readSecret(b.keyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer clear(secret)
+		key := &secp256k1.PrivateKey{}
+		if err := key.UnmarshalText(secret); err != nil {
+			return nil, nil, err
+		}
+		addr := key.Address()
+		kc := keychain.NewSoftwareKeychain(key)
+		keychain.WipeKey(key)
+		signer, _ := kc.Get(addr)
+		return []account{{signer: signer}}, func() { _ = kc.Destroy() }, nil
+
+	case b.mnemonicFile != "":
+		mnemonic, err := e.readSecret(b.mnemonicFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer clear(mnemonic)
+		seed, err := keychain.MnemonicSeed(string(mnemonic), os.Getenv(passphraseEnv))
+		if err != nil {
+			return nil, nil, err
+		}
+		defer clear(seed)
+
+		kc := keychain.NewSoftwareKeychain()
+		accounts := make([]account, len(indices))
+		for i, idx := range indices {
+			path := keychain.BIP44Path(keychain.BIP44Purpose, uint32(b.coinType), idx)
+			key, err := keychain.DeriveKey(seed, path)
+			if err != nil {
+				_ = kc.Destroy()
+				return nil, nil, err
+			}
+			addr := key.Address()
+			kc.Add(key)
+			keychain.WipeKey(key)
+			signer, _ := kc.Get(addr)
+			accounts[i] = account{
+				index:  idx,
+				path:   path.String(),
+				signer: signer,
+			}
+		}
+		return accounts, func() { _ = kc.Destroy() }, nil
+
+	default:
+		transport, err := keychain.DialSpeculos(b.speculos, 5*time.Second)
+		if err != nil {
+			return nil, nil, err
+		}
+		device := keychain.NewLedgerDevice(transport)
+		// Passing -hash to sign is the explicit request to blind sign; the
+		// app still refuses it unless enabled in its settings
+		kc, err := keychain.NewLedgerKeychain(device, indices, keychain.WithBlindSigning(), keychain.WithCoinType(uint32(b.coinType)))
+		if err != nil {
+			_ = device.Disconnect()
+			return nil, nil, err
+		}
+		addrs, err := device.GetAddresses(indices)
+		if err != nil {
+			_ = device.Disconnect()
+			return nil, nil, err
+		}
+		accounts := make([]account, len(indices))
+		for i, idx := range indices {
+			signer, _ := kc.Get(addrs[i])
+			accounts[i] = account{
+				index:  idx,
+				path:   keychain.AddressPath(idx).String(),
+				signer: signer,
+			}
+		}
+		return accounts, func() { _ = device.Disconnect() }, nil
+	}
+}
+
+func (e *env) addresses(args []string) error {
+	fs := e.flagSet("addresses")
+	var b backend
+	b.register(fs)
+	start := fs.Uint("start", 0, "first address index")
+	n := fs.Uint("n", 5, "number of addresses")
+	chain := fs.String("chain", keychain.XChainAlias, "chain alias of the addresses")
+	hrp := fs.String("hrp", keychain.MainnetHRP, "HRP of the addresses")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *n == 0 {
+		return keychain.ErrInvalidNumAddrsToDerive
+	}
+
+	indices := make([]uint32, *n)
+	for i := range indices {
+		indices[i] = uint32(*start) + uint32(i)
+	}
+	accounts, release, err := b.accounts(e, indices)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	for _, account := range accounts {
+		formatted, err := keychain.FormatAddress(*chain, *hrp, account.signer.Address())
+		if err != nil {
+			return err
+		}
+		if account.path == "" {
+			fmt.Fprintln(e.stdout, formatted)
+			continue
+		}
+		fmt.Fprintf(e.stdout, "%d\t%s\t%s\n", account.index, account.path, formatted)
+	}
+	return nil
+}
+
+// payload selects the data signed or verified by a command
+type payload struct {
+	hash string
+	file string
+}
+
+func (p *payload) register(fs *flag.FlagSet) {
+	fs.StringVar(&p.hash, "hash", "", "hex encoded 32 byte hash")
+	fs.StringVar(&p.file, "file", "", "file whose contents are signed, after hashing with SHA-256")
+}
+
+// read returns the payload and whether it is a hash
+func (p *payload) read() ([]byte, bool, error) {
+	switch {
+	case p.hash != "" && p.file == "":
+		hash, err := hex.DecodeString(strings.TrimPrefix(p.hash, "0x"))
+		if err != nil || len(hash) != 32 {
+			return nil, false, errInvalidHash
+		}
+		return hash, true, nil
+	case p.file != "" && p.hash == "":
+		msg, err := os.ReadFile(p.file)
+		return msg, false, err
+	default:
+		return nil, false, errNoPayload
+	}
+}
+
+func (e *env) sign(args []string) error {
+	fs := e.flagSet("sign")
+	var (
+		b backend
+		p payload
+	)
+	b.register(fs)
+	p.register(fs)
+	index := fs.Uint("index", 0, "address index of the signing key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	data, isHash, err := p.read()
+	if err != nil {
+		return err
+	}
+	accounts, release, err := b.accounts(e, []uint32{uint32(*index)})
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	signer := accounts[0].signer
+	var sig []byte
+	if isHash {
+		sig, err = signer.SignHash(data)
+	} else {
+		sig, err = signer.Sign(data)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(e.stdout, hex.EncodeToString(sig))
+	return nil
+}
+
+func (e *env) verify(args []string) error {
+	fs := e.flagSet("verify")
+	var p payload
+	p.register(fs)
+	addrStr := fs.String("address", "", "address of the signer, such as X-lux1...")
+	sigStr := fs.String("sig", "", "hex encoded 65 byte signature")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	addr, err := parseAddress(*addrStr)
+	if err != nil {
+		return err
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(*sigStr, "0x"))
+	if err != nil {
+		return err
+	}
+	data, isHash, err := p.read()
+	if err != nil {
+		return err
+	}
+
+	var pubKey *secp256k1.PublicKey
+	if isHash {
+		pubKey, err = secp256k1.RecoverPublicKeyFromHash(data, sig)
+	} else {
+		pubKey, err = secp256k1.RecoverPublicKey(data, sig)
+	}
+	if err != nil {
+		return err
+	}
+	if pubKey.Address() != addr {
+		return errInvalidSignature
+	}
+	fmt.Fprintln(e.stdout, "signature is valid")
+	return nil
+}
+
+// parseAddress accepts chain addresses, such as X-lux1..., and bare cb58
+// encoded addresses
+func parseAddress(s string) (ids.ShortID, error) {
+	if strings.Contains(s, "-") {
+		_, _, addr, err := keychain.ParseAddress(s)
+		return addr, err
+	}
+	return ids.ShortFromString(s)
+}
+
+// accountKeychain is a keychain of the accounts of a backend
+type accountKeychain map[ids.ShortID]keychain.Signer
+
+func (a accountKeychain) Get(addr ids.ShortID) (keychain.Signer, bool) {
+	signer, ok := a[addr]
+	return signer, ok
+}
+
+func (a accountKeychain) Addresses() set.Set[ids.ShortID] {
+	addrs := make(set.Set[ids.ShortID], len(a))
+	for addr := range a {
+		addrs.Add(addr)
+	}
+	return addrs
+}
+
+func (e *env) serve(args []string) error {
+	fs := e.flagSet("serve")
+	var b backend
+	b.register(fs)
+	socket := fs.String("socket", "", "path of the Unix socket to listen on")
+	start := fs.Uint("start", 0, "first address index")
+	n := fs.Uint("n", 5, "number of addresses")
+	var uids []uint32
+	fs.Func("allow-uid", "user ID allowed to connect, may be repeated (default: the current user)", func(s string) error {
+		uid, err := strconv.ParseUint(s, 10, 32)
+		uids = append(uids, uint32(uid))
+		return err
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *socket == "" {
+		return errNoSocket
+	}
+	if *n == 0 {
+		return keychain.ErrInvalidNumAddrsToDerive
+	}
+
+	indices := make([]uint32, *n)
+	for i := range indices {
+		indices[i] = uint32(*start) + uint32(i)
+	}
+	accounts, release, err := b.accounts(e, indices)
+	if err != nil {
+		return err
+	}
+	defer release()
+	kc := make(accountKeychain, len(accounts))
+	for _, account := range accounts {
+		kc[account.signer.Address()] = account.signer
+	}
+
+	l, err := keychain.ListenSocket(*socket)
+	if err != nil {
+		return err
+	}
+	server := keychain.NewSignerServer(kc, keychain.SignerServerConfig{
+		Authorize: keychain.AllowPeerUIDs(uids...),
+	})
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	fmt.Fprintf(e.stderr, "serving %d addresses on %s\n", len(kc), *socket)
+	if err := server.Serve(l); !errors.Is(err, keychain.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Command keychain generates keys, lists the addresses of mnemonics and ledger
+// devices, and signs and verifies payloads with the backends of the keychain
+// package.
+//
+// Usage:
+//
+//	keychain generate [-words n]
+//	keychain addresses (-key-file f | -mnemonic-file f | -speculos addr) [-start i] [-n n]
+//	keychain sign (-key-file f | -mnemonic-file f | -speculos addr) [-index i] (-hash hex | -file f)
+//	keychain verify -address addr -sig hex (-hash hex | -file f)
+//	keychain serve -socket path (-key-file f | -mnemonic-file f | -speculos addr) [-start i] [-n n] [-allow-uid uid]...
+//
+// The serve command holds the keys of a backend and serves signing requests
+// on a Unix socket until interrupted, so that local processes can sign with
+// keychain.NewRemoteKeychain without holding key material themselves. Only
+// processes of the current user, or of the users given with -allow-uid, are
+// served.
+//
+// Secrets are read from files rather than flags so that they do not end up in
+// shell histories; a file of "-" is read from stdin. The mnemonic passphrase,
+// if any, is read from the KEYCHAIN_PASSPHRASE environment variable.
+//
+// Mnemonics are derived at Lux paths unless -coin-type is given, such as
+// -coin-type 60 to recover the accounts of an Ethereum wallet.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var errUnknownCommand = errors.New("unknown command")
+
+const usage = `usage: keychain <command> [flags]
+
+commands:
+  generate   generate a private key or mnemonic
+  addresses  list the addresses of a key, mnemonic or ledger
+  sign       sign a hash or file
+  verify     verify a signature
+  serve      serve signing requests on a Unix socket
+
+Run "keychain <command> -h" for the flags of a command.
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "keychain:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUnknownCommand
+	}
+
+	env := &env{
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+	switch args[0] {
+	case "generate":
+		return env.generate(args[1:])
+	case "addresses":
+		return env.addresses(args[1:])
+	case "sign":
+		return env.sign(args[1:])
+	case "verify":
+		return env.verify(args[1:])
+	case "serve":
+		return env.serve(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("%w: %s", errUnknownCommand, args[0])
+	}
+}
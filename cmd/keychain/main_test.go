@@ -0,0 +1,171 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/keychain"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// runCommand runs the CLI with [args] and returns its output
+func runCommand(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	err := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), err
+}
+
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestGenerate(t *testing.T) {
+	require := require.New(t)
+
+	out, err := runCommand(t, "", "generate")
+	require.NoError(err)
+	lines := strings.Fields(out)
+	require.Len(lines, 2)
+	key := &secp256k1.PrivateKey{}
+	require.NoError(key.UnmarshalText([]byte(lines[0])))
+	addr, err := keychain.FormatAddress(keychain.XChainAlias, keychain.MainnetHRP, key.Address())
+	require.NoError(err)
+	require.Equal(addr, lines[1])
+
+	out, err = runCommand(t, "", "generate", "-words", "24")
+	require.NoError(err)
+	mnemonic, _, ok := strings.Cut(out, "\n")
+	require.True(ok)
+	require.NoError(keychain.ValidateMnemonic(mnemonic))
+	require.Len(strings.Fields(mnemonic), 24)
+
+	_, err = runCommand(t, "", "generate", "-words", "13")
+	require.ErrorIs(err, keychain.ErrInvalidMnemonicWordCount)
+}
+
+func TestAddresses(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := keychain.NewMnemonicKeychain(testMnemonic, "", []uint32{2, 3})
+	require.NoError(err)
+
+	// The mnemonic is read from stdin
+	out, err := runCommand(t, testMnemonic, "addresses", "-mnemonic-file", "-", "-start", "2", "-n", "2", "-chain", "P", "-hrp", "test")
+	require.NoError(err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(lines, 2)
+	for i, line := range lines {
+		fields := strings.Split(line, "\t")
+		require.Len(fields, 3)
+		require.Equal(keychain.AddressPath(uint32(2+i)).String(), fields[1])
+		_, addr, err := parseChainAddress(fields[2])
+		require.NoError(err)
+		require.True(kc.Addresses().Contains(addr))
+	}
+}
+
+func TestAddressesCoinType(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := keychain.NewMnemonicKeychain(testMnemonic, "", []uint32{0}, keychain.WithCoinType(keychain.EthereumCoinType))
+	require.NoError(err)
+
+	out, err := runCommand(t, testMnemonic, "addresses", "-mnemonic-file", "-", "-n", "1", "-coin-type", "60")
+	require.NoError(err)
+	fields := strings.Split(strings.TrimSpace(out), "\t")
+	require.Len(fields, 3)
+	require.Equal("m/44'/60'/0'/0/0", fields[1])
+	_, addr, err := parseChainAddress(fields[2])
+	require.NoError(err)
+	require.True(kc.Addresses().Contains(addr))
+}
+
+func TestSignVerify(t *testing.T) {
+	require := require.New(t)
+
+	mnemonicFile := writeFile(t, "mnemonic", testMnemonic+"\n")
+	msgFile := writeFile(t, "msg", "runbook payload")
+
+	out, err := runCommand(t, "", "addresses", "-mnemonic-file", mnemonicFile, "-start", "1", "-n", "1")
+	require.NoError(err)
+	addr := strings.Split(strings.TrimSpace(out), "\t")[2]
+
+	sig, err := runCommand(t, "", "sign", "-mnemonic-file", mnemonicFile, "-index", "1", "-file", msgFile)
+	require.NoError(err)
+	sig = strings.TrimSpace(sig)
+
+	out, err = runCommand(t, "", "verify", "-address", addr, "-sig", sig, "-file", msgFile)
+	require.NoError(err)
+	require.Equal("signature is valid\n", out)
+
+	// A signature by another index does not verify
+	other, err := runCommand(t, "", "sign", "-mnemonic-file", mnemonicFile, "-index", "2", "-file", msgFile)
+	require.NoError(err)
+	_, err = runCommand(t, "", "verify", "-address", addr, "-sig", strings.TrimSpace(other), "-file", msgFile)
+	require.ErrorIs(err, errInvalidSignature)
+}
+
+func TestSignHashWithKeyFile(t *testing.T) {
+	require := require.New(t)
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	keyFile := writeFile(t, "key", key.String())
+	hash := strings.Repeat("ab", 32)
+
+	sig, err := runCommand(t, "", "sign", "-key-file", keyFile, "-hash", hash)
+	require.NoError(err)
+
+	out, err := runCommand(t, "", "verify", "-address", key.Address().String(), "-sig", strings.TrimSpace(sig), "-hash", "0x"+hash)
+	require.NoError(err)
+	require.Equal("signature is valid\n", out)
+
+	out, err = runCommand(t, "", "addresses", "-key-file", keyFile)
+	require.NoError(err)
+	_, addr, err := parseChainAddress(strings.TrimSpace(out))
+	require.NoError(err)
+	require.Equal(key.Address(), addr)
+}
+
+func TestCommandErrors(t *testing.T) {
+	require := require.New(t)
+
+	_, err := runCommand(t, "")
+	require.ErrorIs(err, errUnknownCommand)
+	_, err = runCommand(t, "", "unknown")
+	require.ErrorIs(err, errUnknownCommand)
+
+	_, err = runCommand(t, "", "addresses")
+	require.ErrorIs(err, errNoBackend)
+	_, err = runCommand(t, testMnemonic, "addresses", "-mnemonic-file", "-", "-key-file", "key")
+	require.ErrorIs(err, errNoBackend)
+
+	_, err = runCommand(t, testMnemonic, "sign", "-mnemonic-file", "-")
+	require.ErrorIs(err, errNoPayload)
+	_, err = runCommand(t, testMnemonic, "sign", "-mnemonic-file", "-", "-hash", "abcd")
+	require.ErrorIs(err, errInvalidHash)
+
+	_, err = runCommand(t, "not a mnemonic", "addresses", "-mnemonic-file", "-")
+	require.ErrorIs(err, keychain.ErrInvalidMnemonic)
+}
+
+func parseChainAddress(s string) (string, ids.ShortID, error) {
+	alias, _, addr, err := keychain.ParseAddress(s)
+	return alias, addr, err
+}
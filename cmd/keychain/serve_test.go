@@ -0,0 +1,66 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build linux || darwin || freebsd
+
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/keychain"
+)
+
+func TestServe(t *testing.T) {
+	require := require.New(t)
+
+	dir, err := os.MkdirTemp("", "kc")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "keychain.sock")
+	mnemonic := writeFile(t, "mnemonic", testMnemonic)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := runCommand(t, "", "serve", "-socket", socket, "-mnemonic-file", mnemonic, "-n", "2")
+		done <- err
+	}()
+
+	var conn net.Conn
+	require.Eventually(func() bool {
+		conn, err = net.Dial("unix", socket)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	defer conn.Close()
+
+	remote, err := keychain.NewRemoteKeychain(conn)
+	require.NoError(err)
+	kc, err := keychain.NewMnemonicKeychain(testMnemonic, "", []uint32{0, 1})
+	require.NoError(err)
+	defer kc.Destroy()
+	require.Equal(kc.Addresses(), remote.Addresses())
+
+	addr := kc.Addresses().List()[0]
+	signer, ok := remote.Get(addr)
+	require.True(ok)
+	expected, _ := kc.Get(addr)
+	hash := make([]byte, 32)
+	sig, err := signer.SignHash(hash)
+	require.NoError(err)
+	expectedSig, err := expected.SignHash(hash)
+	require.NoError(err)
+	require.Equal(expectedSig, sig)
+
+	require.NoError(syscall.Kill(os.Getpid(), syscall.SIGINT))
+	require.NoError(<-done)
+
+	_, err = runCommand(t, "", "serve", "-mnemonic-file", mnemonic)
+	require.ErrorIs(err, errNoSocket)
+}
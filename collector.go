@@ -0,0 +1,279 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var (
+	ErrNotAnOwner        = errors.New("address is not an owner of the transaction")
+	ErrInvalidThreshold  = errors.New("threshold must be between 1 and the number of owners")
+	ErrDuplicateOwner    = errors.New("owner is listed more than once")
+	ErrWrongSigner       = errors.New("signature is not by the expected owner")
+	ErrThresholdNotMet   = errors.New("not enough owners have signed")
+	ErrCollectorMismatch = errors.New("signature collectors are for different transactions")
+)
+
+// SignatureCollector accumulates the signatures of the owners of a multisig
+// transaction, which may be collected over several sessions and machines: its
+// state is serialized with MarshalJSON and resumed with UnmarshalJSON. Every
+// signature is verified against its owner before it is accepted, so that a
+// completed collector always holds valid credentials.
+type SignatureCollector struct {
+	lock       sync.Mutex
+	unsignedTx []byte
+	owners     []ids.ShortID
+	threshold  int
+	sigs       map[ids.ShortID][]byte
+}
+
+// NewSignatureCollector creates a collector for the signatures of
+// [unsignedTx] by [threshold] of [owners], whose order is the order of the
+// returned signatures
+func NewSignatureCollector(unsignedTx []byte, owners []ids.ShortID, threshold int) (*SignatureCollector, error) {
+	if err := checkOwners(owners, threshold); err != nil {
+		return nil, err
+	}
+	return &SignatureCollector{
+		unsignedTx: slices.Clone(unsignedTx),
+		owners:     slices.Clone(owners),
+		threshold:  threshold,
+		sigs:       make(map[ids.ShortID][]byte, threshold),
+	}, nil
+}
+
+// checkOwners returns an error if [threshold] of [owners] is not a valid
+// multisig quorum
+func checkOwners(owners []ids.ShortID, threshold int) error {
+	if threshold < 1 || threshold > len(owners) {
+		return ErrInvalidThreshold
+	}
+	seen := make(set.Set[ids.ShortID], len(owners))
+	for _, owner := range owners {
+		if seen.Contains(owner) {
+			return fmt.Errorf("%w: %s", ErrDuplicateOwner, owner)
+		}
+		seen.Add(owner)
+	}
+	return nil
+}
+
+// UnsignedTx returns the transaction the owners sign
+func (c *SignatureCollector) UnsignedTx() []byte {
+	return slices.Clone(c.unsignedTx)
+}
+
+// Add records the signature [sig] of the transaction by [owner], as returned
+// by Sign of the owner's signer. Adding a signature for an owner that
+// already signed replaces it.
+func (c *SignatureCollector) Add(owner ids.ShortID, sig []byte) error {
+	if !slices.Contains(c.owners, owner) {
+		return fmt.Errorf("%w: %s", ErrNotAnOwner, owner)
+	}
+	if err := c.verify(owner, sig); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.sigs[owner] = slices.Clone(sig)
+	return nil
+}
+
+// Sign adds the signatures of the owners held by [kc] that have not signed
+// yet, and returns the owners it signed for. Owners that fail to sign are
+// reported in the returned error without preventing the others from signing.
+func (c *SignatureCollector) Sign(kc Keychain) ([]ids.ShortID, error) {
+	var (
+		signed []ids.ShortID
+		errs   []error
+	)
+	for _, owner := range c.Missing() {
+		signer, ok := kc.Get(owner)
+		if !ok {
+			continue
+		}
+		sig, err := signer.Sign(c.unsignedTx)
+		if err == nil {
+			err = c.Add(owner, sig)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", owner, err))
+			continue
+		}
+		signed = append(signed, owner)
+	}
+	return signed, errors.Join(errs...)
+}
+
+// Signed returns the owners that signed, in owner order
+func (c *SignatureCollector) Signed() []ids.ShortID {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return slices.DeleteFunc(slices.Clone(c.owners), func(owner ids.ShortID) bool {
+		_, ok := c.sigs[owner]
+		return !ok
+	})
+}
+
+// Missing returns the owners that have not signed, in owner order
+func (c *SignatureCollector) Missing() []ids.ShortID {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return slices.DeleteFunc(slices.Clone(c.owners), func(owner ids.ShortID) bool {
+		_, ok := c.sigs[owner]
+		return ok
+	})
+}
+
+// Remaining returns the number of signatures still needed to reach the
+// threshold
+func (c *SignatureCollector) Remaining() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return max(c.threshold-len(c.sigs), 0)
+}
+
+// Complete reports whether the threshold of signatures is reached
+func (c *SignatureCollector) Complete() bool {
+	return c.Remaining() == 0
+}
+
+// Signatures returns the signatures of the first threshold owners that
+// signed, in owner order, or ErrThresholdNotMet if too few owners signed
+func (c *SignatureCollector) Signatures() ([]AddressSignature, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.sigs) < c.threshold {
+		return nil, fmt.Errorf("%w: %d of %d signatures", ErrThresholdNotMet, len(c.sigs), c.threshold)
+	}
+	sigs := make([]AddressSignature, 0, c.threshold)
+	for _, owner := range c.owners {
+		sig, ok := c.sigs[owner]
+		if !ok {
+			continue
+		}
+		sigs = append(sigs, AddressSignature{
+			Address:   owner,
+			Signature: slices.Clone(sig),
+		})
+		if len(sigs) == c.threshold {
+			break
+		}
+	}
+	return sigs, nil
+}
+
+// Merge adds the signatures collected by [other] for the same transaction
+// and owners, such as a copy returned by a co-signer
+func (c *SignatureCollector) Merge(other *SignatureCollector) error {
+	if !bytes.Equal(c.unsignedTx, other.unsignedTx) || !slices.Equal(c.owners, other.owners) || c.threshold != other.threshold {
+		return ErrCollectorMismatch
+	}
+	for _, sig := range other.collected() {
+		if err := c.Add(sig.Owner, sig.Signature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collected returns every collected signature, in owner order
+func (c *SignatureCollector) collected() []collectedSignature {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var sigs []collectedSignature
+	for _, owner := range c.owners {
+		if sig, ok := c.sigs[owner]; ok {
+			sigs = append(sigs, collectedSignature{
+				Owner:     owner,
+				Signature: slices.Clone(sig),
+			})
+		}
+	}
+	return sigs
+}
+
+// verify checks that [sig] is the recoverable signature of the transaction by
+// [owner]
+func (c *SignatureCollector) verify(owner ids.ShortID, sig []byte) error {
+	if len(sig) != secp256k1.SignatureLen {
+		return ErrInvalidSignatureLen
+	}
+	pubKey, err := secp256k1.RecoverPublicKey(c.unsignedTx, sig)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSignatureInvalid, err)
+	}
+	if signer := pubKey.Address(); signer != owner {
+		return fmt.Errorf("%w: expected %s but got %s", ErrWrongSigner, owner, signer)
+	}
+	return nil
+}
+
+// collectorState is the serialized state of a SignatureCollector
+type collectorState struct {
+	UnsignedTx []byte               `json:"unsignedTx"`
+	Owners     []ids.ShortID        `json:"owners"`
+	Threshold  int                  `json:"threshold"`
+	Signatures []collectedSignature `json:"signatures,omitempty"`
+}
+
+type collectedSignature struct {
+	Owner     ids.ShortID `json:"owner"`
+	Signature []byte      `json:"signature"`
+}
+
+// MarshalJSON serializes the transaction, owners, threshold and collected
+// signatures so that the collection can be resumed later
+func (c *SignatureCollector) MarshalJSON() ([]byte, error) {
+	return json.Marshal(collectorState{
+		UnsignedTx: c.unsignedTx,
+		Owners:     c.owners,
+		Threshold:  c.threshold,
+		Signatures: c.collected(),
+	})
+}
+
+// UnmarshalJSON resumes the collection serialized by MarshalJSON, verifying
+// every signature again
+func (c *SignatureCollector) UnmarshalJSON(data []byte) error {
+	var state collectorState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	resumed, err := NewSignatureCollector(state.UnsignedTx, state.Owners, state.Threshold)
+	if err != nil {
+		return err
+	}
+	for _, sig := range state.Signatures {
+		if err := resumed.Add(sig.Owner, sig.Signature); err != nil {
+			return err
+		}
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.unsignedTx = resumed.unsignedTx
+	c.owners = resumed.owners
+	c.threshold = resumed.threshold
+	c.sigs = resumed.sigs
+	return nil
+}
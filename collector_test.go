@@ -0,0 +1,142 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatureCollector(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("collector"), 3)
+	require.NoError(err)
+	owners := kc.Addresses().List()
+	slices.SortFunc(owners, ids.ShortID.Compare)
+	tx := []byte("multisig tx")
+
+	collector, err := NewSignatureCollector(tx, owners, 2)
+	require.NoError(err)
+	require.Equal(owners, collector.Missing())
+	require.Equal(2, collector.Remaining())
+	_, err = collector.Signatures()
+	require.ErrorIs(err, ErrThresholdNotMet)
+
+	// The first co-signer only holds the last owner
+	signed, err := collector.Sign(Filter(kc, set.Of(owners[2])))
+	require.NoError(err)
+	require.Equal([]ids.ShortID{owners[2]}, signed)
+	require.Equal(owners[:2], collector.Missing())
+	require.False(collector.Complete())
+
+	// The in-progress state is handed to the next co-signer
+	data, err := json.Marshal(collector)
+	require.NoError(err)
+	var resumed SignatureCollector
+	require.NoError(json.Unmarshal(data, &resumed))
+	require.Equal(tx, resumed.UnsignedTx())
+	require.Equal([]ids.ShortID{owners[2]}, resumed.Signed())
+
+	signer, ok := kc.Get(owners[0])
+	require.True(ok)
+	sig, err := signer.Sign(tx)
+	require.NoError(err)
+	require.NoError(resumed.Add(owners[0], sig))
+	require.True(resumed.Complete())
+	require.Equal([]ids.ShortID{owners[1]}, resumed.Missing())
+
+	sigs, err := resumed.Signatures()
+	require.NoError(err)
+	require.Equal([]AddressSignature{
+		{Address: owners[0], Signature: sig},
+		{Address: owners[2], Signature: sigs[1].Signature},
+	}, sigs)
+
+	// The original collector picks up the co-signer's signatures
+	require.NoError(collector.Merge(&resumed))
+	require.True(collector.Complete())
+
+	other, err := NewSignatureCollector([]byte("other tx"), owners, 2)
+	require.NoError(err)
+	require.ErrorIs(collector.Merge(other), ErrCollectorMismatch)
+}
+
+func TestSignatureCollectorRejectsInvalidSignatures(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("collector"), 2)
+	require.NoError(err)
+	owners := kc.Addresses().List()
+	tx := []byte("multisig tx")
+
+	collector, err := NewSignatureCollector(tx, owners[:1], 1)
+	require.NoError(err)
+
+	signer, ok := kc.Get(owners[1])
+	require.True(ok)
+	sig, err := signer.Sign(tx)
+	require.NoError(err)
+	require.ErrorIs(collector.Add(owners[1], sig), ErrNotAnOwner)
+	require.ErrorIs(collector.Add(owners[0], sig), ErrWrongSigner)
+	require.ErrorIs(collector.Add(owners[0], sig[1:]), ErrInvalidSignatureLen)
+
+	signer, ok = kc.Get(owners[0])
+	require.True(ok)
+	sig, err = signer.Sign([]byte("other tx"))
+	require.NoError(err)
+	require.ErrorIs(collector.Add(owners[0], sig), ErrWrongSigner)
+	require.Empty(collector.Signed())
+
+	// Tampered state is rejected when resumed
+	data, err := json.Marshal(collectorState{
+		UnsignedTx: tx,
+		Owners:     owners[:1],
+		Threshold:  1,
+		Signatures: []collectedSignature{{Owner: owners[0], Signature: sig}},
+	})
+	require.NoError(err)
+	var resumed SignatureCollector
+	require.ErrorIs(json.Unmarshal(data, &resumed), ErrWrongSigner)
+}
+
+func TestNewSignatureCollectorErrors(t *testing.T) {
+	owner := ids.GenerateTestShortID()
+	tests := []struct {
+		name        string
+		owners      []ids.ShortID
+		threshold   int
+		expectedErr error
+	}{
+		{
+			name:        "zero threshold",
+			owners:      []ids.ShortID{owner},
+			threshold:   0,
+			expectedErr: ErrInvalidThreshold,
+		},
+		{
+			name:        "threshold above owners",
+			owners:      []ids.ShortID{owner},
+			threshold:   2,
+			expectedErr: ErrInvalidThreshold,
+		},
+		{
+			name:        "duplicate owner",
+			owners:      []ids.ShortID{owner, owner},
+			threshold:   1,
+			expectedErr: ErrDuplicateOwner,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewSignatureCollector([]byte("tx"), test.owners, test.threshold)
+			require.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+}
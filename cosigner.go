@@ -0,0 +1,440 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// Methods of the cosigner protocol
+const (
+	cosignerMethodPropose   = "propose"
+	cosignerMethodProposals = "proposals"
+	cosignerMethodProposal  = "proposal"
+	cosignerMethodSign      = "partialSign"
+	cosignerMethodAggregate = "aggregate"
+)
+
+// defaultCosignTTL is how long a cosigner server keeps its proposals
+const defaultCosignTTL = 24 * time.Hour
+
+var ErrUnknownProposal = errors.New("unknown cosign proposal")
+
+// CosignProposal is a multisig transaction proposed to its co-signers through
+// a CosignerServer
+type CosignProposal struct {
+	// ID, Created and Signed are set by the server
+	ID         string        `json:"id"`
+	UnsignedTx []byte        `json:"unsignedTx"`
+	Owners     []ids.ShortID `json:"owners"`
+	Threshold  int           `json:"threshold"`
+	// Description tells the co-signers what the transaction is for
+	Description string `json:"description,omitempty"`
+	// Proposer identifies who proposed the transaction, as claimed by the
+	// proposer
+	Proposer string    `json:"proposer,omitempty"`
+	Created  time.Time `json:"created"`
+	// Signed are the owners that signed, in owner order
+	Signed []ids.ShortID `json:"signed,omitempty"`
+}
+
+// Complete reports whether the threshold of signatures is reached
+func (p CosignProposal) Complete() bool {
+	return len(p.Signed) >= p.Threshold
+}
+
+type cosignIDParams struct {
+	ID string `json:"id"`
+}
+
+type cosignSignParams struct {
+	ID         string               `json:"id"`
+	Signatures []collectedSignature `json:"signatures"`
+}
+
+// CosignerServerConfig configures a CosignerServer
+type CosignerServerConfig struct {
+	// Authorize is called with every accepted connection before any request
+	// is read from it, as by SignerServerConfig.Authorize, such as to only
+	// serve the client certificates of the co-signers
+	Authorize func(net.Conn) error
+	// TTL is how long proposals are kept once proposed, 24h by default
+	TTL time.Duration
+	// OnUpdate, if set, is called when a transaction is proposed and when it
+	// is signed, such as to notify the co-signers. It may be called
+	// concurrently.
+	OnUpdate func(CosignProposal)
+}
+
+// CosignerServer coordinates the signing of multisig transactions by
+// co-signers that do not share their keys, such as teammates in different
+// locations, through the cosigner protocol:
+//
+//  1. A client proposes a transaction, its owners and its threshold.
+//  2. Co-signers list the proposals and review the ones they own.
+//  3. Each co-signer signs with its own keychain and submits its partial
+//     signatures, which the server verifies against their owners.
+//  4. Once the threshold is reached, any client aggregates the signatures
+//     into the credentials of the transaction.
+//
+// The server never holds key material. Proposals are kept in memory until
+// their TTL passes. The protocol is that of SignerServer, with the methods
+// of CosignerClient.
+type CosignerServer struct {
+	authorize func(net.Conn) error
+	ttl       time.Duration
+	onUpdate  func(CosignProposal)
+	now       func() time.Time
+	server    connServer
+
+	lock      sync.Mutex
+	proposals map[string]*cosignEntry
+}
+
+// cosignEntry is a proposal and the signatures collected for it
+type cosignEntry struct {
+	proposal  CosignProposal
+	collector *SignatureCollector
+}
+
+// snapshot returns a copy of the proposal with its current signers
+func (e *cosignEntry) snapshot() CosignProposal {
+	p := e.proposal
+	p.UnsignedTx = slices.Clone(p.UnsignedTx)
+	p.Owners = slices.Clone(p.Owners)
+	p.Signed = e.collector.Signed()
+	return p
+}
+
+// NewCosignerServer creates a cosigner server without proposals
+func NewCosignerServer(config CosignerServerConfig) *CosignerServer {
+	if config.TTL <= 0 {
+		config.TTL = defaultCosignTTL
+	}
+	return &CosignerServer{
+		authorize: config.Authorize,
+		ttl:       config.TTL,
+		onUpdate:  config.OnUpdate,
+		now:       time.Now,
+		proposals: make(map[string]*cosignEntry),
+	}
+}
+
+// Serve accepts connections on [l] until the server is closed, and then
+// returns ErrServerClosed. The listener is closed when Serve returns.
+func (s *CosignerServer) Serve(l net.Listener) error {
+	return s.server.serve(l, s.serveConn)
+}
+
+// Close stops the listeners and closes the open connections, waiting for
+// requests in progress to return. The proposals are discarded.
+func (s *CosignerServer) Close() error {
+	s.server.close()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	clear(s.proposals)
+	return nil
+}
+
+func (s *CosignerServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	enc := json.NewEncoder(conn)
+	if s.authorize != nil {
+		if err := s.authorize(conn); err != nil {
+			_ = enc.Encode(remoteResponse{Error: newRemoteError(err)})
+			return
+		}
+	}
+
+	dec := json.NewDecoder(conn)
+	for {
+		var req remoteRequest
+		if err := dec.Decode(&req); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				_ = enc.Encode(remoteResponse{Error: newRemoteError(ErrInvalidRemoteRequest)})
+			}
+			return
+		}
+		resp := remoteResponse{ID: req.ID}
+		result, err := s.handle(req)
+		if err == nil {
+			resp.Result, err = json.Marshal(result)
+		}
+		if err != nil {
+			resp.Error = newRemoteError(err)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+func (s *CosignerServer) handle(req remoteRequest) (any, error) {
+	switch req.Method {
+	case cosignerMethodPropose:
+		var params CosignProposal
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, ErrInvalidRemoteRequest
+		}
+		return s.propose(params)
+	case cosignerMethodProposals:
+		return s.list(), nil
+	case cosignerMethodProposal:
+		var params cosignIDParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, ErrInvalidRemoteRequest
+		}
+		entry, err := s.entry(params.ID)
+		if err != nil {
+			return nil, err
+		}
+		return entry.snapshot(), nil
+	case cosignerMethodSign:
+		var params cosignSignParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, ErrInvalidRemoteRequest
+		}
+		return s.sign(params)
+	case cosignerMethodAggregate:
+		var params cosignIDParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, ErrInvalidRemoteRequest
+		}
+		entry, err := s.entry(params.ID)
+		if err != nil {
+			return nil, err
+		}
+		return entry.collector.Signatures()
+	default:
+		return nil, ErrUnknownRemoteMethod
+	}
+}
+
+func (s *CosignerServer) propose(p CosignProposal) (CosignProposal, error) {
+	collector, err := NewSignatureCollector(p.UnsignedTx, p.Owners, p.Threshold)
+	if err != nil {
+		return CosignProposal{}, err
+	}
+	p.ID = uuid.NewString()
+	p.Signed = nil
+
+	s.lock.Lock()
+	s.pruneLocked()
+	p.Created = s.now()
+	entry := &cosignEntry{
+		proposal:  p,
+		collector: collector,
+	}
+	s.proposals[p.ID] = entry
+	s.lock.Unlock()
+
+	proposal := entry.snapshot()
+	s.update(proposal)
+	return proposal, nil
+}
+
+// sign adds the partial signatures of [params] to their proposal. The
+// signatures preceding one that fails to verify are kept.
+func (s *CosignerServer) sign(params cosignSignParams) (CosignProposal, error) {
+	entry, err := s.entry(params.ID)
+	if err != nil {
+		return CosignProposal{}, err
+	}
+	for i, sig := range params.Signatures {
+		if err = entry.collector.Add(sig.Owner, sig.Signature); err != nil {
+			params.Signatures = params.Signatures[:i]
+			break
+		}
+	}
+	proposal := entry.snapshot()
+	if len(params.Signatures) > 0 {
+		s.update(proposal)
+	}
+	return proposal, err
+}
+
+func (s *CosignerServer) update(p CosignProposal) {
+	if s.onUpdate != nil {
+		s.onUpdate(p)
+	}
+}
+
+// list returns the proposals, oldest first
+func (s *CosignerServer) list() []CosignProposal {
+	s.lock.Lock()
+	s.pruneLocked()
+	entries := make([]*cosignEntry, 0, len(s.proposals))
+	for _, entry := range s.proposals {
+		entries = append(entries, entry)
+	}
+	s.lock.Unlock()
+
+	proposals := make([]CosignProposal, len(entries))
+	for i, entry := range entries {
+		proposals[i] = entry.snapshot()
+	}
+	slices.SortFunc(proposals, func(a, b CosignProposal) int {
+		return cmp.Or(a.Created.Compare(b.Created), cmp.Compare(a.ID, b.ID))
+	})
+	return proposals
+}
+
+func (s *CosignerServer) entry(id string) (*cosignEntry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pruneLocked()
+	entry, ok := s.proposals[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProposal, id)
+	}
+	return entry, nil
+}
+
+// pruneLocked removes the proposals older than the TTL
+func (s *CosignerServer) pruneLocked() {
+	now := s.now()
+	for id, entry := range s.proposals {
+		if now.Sub(entry.proposal.Created) >= s.ttl {
+			delete(s.proposals, id)
+		}
+	}
+}
+
+// CosignerClient is a client of a CosignerServer
+type CosignerClient struct {
+	pool *remotePool
+}
+
+// NewCosignerClient creates a client of the CosignerServer at the other end
+// of [conn]. [conn] is closed by Close or once a request fails to reach the
+// server.
+func NewCosignerClient(conn net.Conn) *CosignerClient {
+	pool := newRemotePool(nil, 1, newOptions(nil))
+	_ = pool.add(conn)
+	return &CosignerClient{pool: pool}
+}
+
+// DialCosigner creates a client of the CosignerServer reached with [dial],
+// which redials the connections that fail as DialRemoteKeychain does. The
+// WithPoolSize and WithIdleTimeout options apply.
+func DialCosigner(dial func() (net.Conn, error), opts ...Option) (*CosignerClient, error) {
+	o := newOptions(opts)
+	c := &CosignerClient{pool: newRemotePool(dial, o.poolSize, o)}
+	if err := c.pool.warmUp(); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// Propose proposes the transaction of [proposal] to its co-signers and
+// returns the proposal as recorded by the server, with its ID
+func (c *CosignerClient) Propose(ctx context.Context, proposal CosignProposal) (CosignProposal, error) {
+	var proposed CosignProposal
+	err := c.pool.call(ctx, cosignerMethodPropose, proposal, &proposed)
+	return proposed, err
+}
+
+// Proposals returns the proposals of the server, oldest first
+func (c *CosignerClient) Proposals(ctx context.Context) ([]CosignProposal, error) {
+	var proposals []CosignProposal
+	err := c.pool.call(ctx, cosignerMethodProposals, nil, &proposals)
+	return proposals, err
+}
+
+// Proposal returns the proposal [id], or ErrUnknownProposal
+func (c *CosignerClient) Proposal(ctx context.Context, id string) (CosignProposal, error) {
+	var proposal CosignProposal
+	err := c.pool.call(ctx, cosignerMethodProposal, cosignIDParams{ID: id}, &proposal)
+	return proposal, err
+}
+
+// Sign signs the proposal [id] with the owners held by [kc] that have not
+// signed it yet, once [review] accepts it, and submits the signatures to the
+// server. The keys never leave [kc]. [review] is given the proposal before
+// anything is signed, such as to display its transaction, and rejects it by
+// returning an error; a nil [review] accepts every proposal.
+//
+// The returned owners are those whose signatures the server accepted.
+// Owners that fail to sign are reported in the returned error without
+// preventing the others from signing.
+func (c *CosignerClient) Sign(ctx context.Context, id string, kc Keychain, review func(CosignProposal) error) ([]ids.ShortID, error) {
+	proposal, err := c.Proposal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if review != nil {
+		if err := review(proposal); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		signed = set.Of(proposal.Signed...)
+		params = cosignSignParams{ID: id}
+		errs   []error
+	)
+	for _, owner := range proposal.Owners {
+		if signed.Contains(owner) {
+			continue
+		}
+		signer, ok := kc.Get(owner)
+		if !ok {
+			continue
+		}
+		sig, err := SignContext(ctx, signer, proposal.UnsignedTx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", owner, err))
+			continue
+		}
+		params.Signatures = append(params.Signatures, collectedSignature{
+			Owner:     owner,
+			Signature: sig,
+		})
+	}
+	if len(params.Signatures) == 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var updated CosignProposal
+	if err := c.pool.call(ctx, cosignerMethodSign, params, &updated); err != nil {
+		return nil, errors.Join(append(errs, err)...)
+	}
+	owners := make([]ids.ShortID, len(params.Signatures))
+	for i, sig := range params.Signatures {
+		owners[i] = sig.Owner
+	}
+	return owners, errors.Join(errs...)
+}
+
+// Aggregate returns the signatures of the first threshold owners that signed
+// the proposal [id], in owner order, or ErrThresholdNotMet if too few did
+func (c *CosignerClient) Aggregate(ctx context.Context, id string) ([]AddressSignature, error) {
+	var sigs []AddressSignature
+	err := c.pool.call(ctx, cosignerMethodAggregate, cosignIDParams{ID: id}, &sigs)
+	return sigs, err
+}
+
+// Close closes the connections to the server
+func (c *CosignerClient) Close() error {
+	return c.pool.close()
+}
@@ -0,0 +1,203 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+// serveCosigner serves a cosigner server on a loopback listener and returns a
+// function dialing it
+func serveCosigner(t *testing.T, config CosignerServerConfig) (*CosignerServer, func() (net.Conn, error)) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := NewCosignerServer(config)
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(l)
+	}()
+	t.Cleanup(func() {
+		require.NoError(t, server.Close())
+		require.ErrorIs(t, <-done, ErrServerClosed)
+	})
+	return server, func() (net.Conn, error) {
+		return net.Dial("tcp", l.Addr().String())
+	}
+}
+
+// newCosigner returns a client of the server reached with [dial]
+func newCosigner(t *testing.T, dial func() (net.Conn, error)) *CosignerClient {
+	t.Helper()
+
+	c, err := DialCosigner(dial)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+// cosignerKeychains returns the keychains of three co-signers, each holding
+// one key, and the addresses of their keys
+func cosignerKeychains(t *testing.T) ([]Keychain, []ids.ShortID) {
+	t.Helper()
+
+	var (
+		keychains []Keychain
+		owners    []ids.ShortID
+	)
+	for _, seed := range []string{"alice", "bob", "carol"} {
+		kc, err := NewTestKeychain([]byte(seed), 1)
+		require.NoError(t, err)
+		keychains = append(keychains, kc)
+		owners = append(owners, kc.Keys()[0].Address())
+	}
+	return keychains, owners
+}
+
+func TestCosigner(t *testing.T) {
+	require := require.New(t)
+
+	var (
+		lock    sync.Mutex
+		updates int
+	)
+	_, dial := serveCosigner(t, CosignerServerConfig{
+		OnUpdate: func(CosignProposal) {
+			lock.Lock()
+			defer lock.Unlock()
+			updates++
+		},
+	})
+	countUpdates := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return updates
+	}
+	keychains, owners := cosignerKeychains(t)
+	tx := []byte("unsigned multisig tx")
+
+	proposed, err := newCosigner(t, dial).Propose(context.Background(), CosignProposal{
+		UnsignedTx:  tx,
+		Owners:      owners,
+		Threshold:   2,
+		Description: "treasury payout",
+		Proposer:    "alice",
+	})
+	require.NoError(err)
+	require.NotEmpty(proposed.ID)
+	require.Empty(proposed.Signed)
+	require.Equal(1, countUpdates())
+
+	// Each co-signer reviews and signs on its own connection
+	alice := newCosigner(t, dial)
+	proposals, err := alice.Proposals(context.Background())
+	require.NoError(err)
+	require.Len(proposals, 1)
+	require.Equal("treasury payout", proposals[0].Description)
+
+	var reviewed CosignProposal
+	signed, err := alice.Sign(context.Background(), proposed.ID, keychains[0], func(p CosignProposal) error {
+		reviewed = p
+		return nil
+	})
+	require.NoError(err)
+	require.Equal([]ids.ShortID{owners[0]}, signed)
+	require.Equal(tx, reviewed.UnsignedTx)
+	require.Equal(2, countUpdates())
+
+	_, err = alice.Aggregate(context.Background(), proposed.ID)
+	require.ErrorIs(err, ErrThresholdNotMet)
+
+	// A co-signer that rejects the proposal signs nothing
+	carol := newCosigner(t, dial)
+	errRejected := errors.New("amount too large")
+	signed, err = carol.Sign(context.Background(), proposed.ID, keychains[2], func(CosignProposal) error {
+		return errRejected
+	})
+	require.ErrorIs(err, errRejected)
+	require.Empty(signed)
+
+	bob := newCosigner(t, dial)
+	signed, err = bob.Sign(context.Background(), proposed.ID, keychains[1], nil)
+	require.NoError(err)
+	require.Equal([]ids.ShortID{owners[1]}, signed)
+
+	proposal, err := carol.Proposal(context.Background(), proposed.ID)
+	require.NoError(err)
+	require.True(proposal.Complete())
+	require.Equal(owners[:2], proposal.Signed)
+
+	sigs, err := carol.Aggregate(context.Background(), proposed.ID)
+	require.NoError(err)
+	require.Len(sigs, 2)
+	for i, sig := range sigs {
+		require.Equal(owners[i], sig.Address)
+		pubKey, err := secp256k1.RecoverPublicKey(tx, sig.Signature)
+		require.NoError(err)
+		require.Equal(owners[i], pubKey.Address())
+	}
+}
+
+func TestCosignerErrors(t *testing.T) {
+	require := require.New(t)
+
+	server, dial := serveCosigner(t, CosignerServerConfig{TTL: time.Hour})
+	keychains, owners := cosignerKeychains(t)
+	client := newCosigner(t, dial)
+	ctx := context.Background()
+
+	_, err := client.Propose(ctx, CosignProposal{
+		UnsignedTx: []byte("tx"),
+		Owners:     owners,
+		Threshold:  4,
+	})
+	require.ErrorIs(err, ErrInvalidThreshold)
+
+	_, err = client.Proposal(ctx, "missing")
+	require.ErrorIs(err, ErrUnknownProposal)
+
+	proposed, err := client.Propose(ctx, CosignProposal{
+		UnsignedTx: []byte("tx"),
+		Owners:     owners[:2],
+		Threshold:  1,
+	})
+	require.NoError(err)
+
+	// Keychains without an owner of the proposal sign nothing
+	signed, err := client.Sign(ctx, proposed.ID, keychains[2], nil)
+	require.NoError(err)
+	require.Empty(signed)
+
+	// Signatures are verified against their owner
+	carol, _ := keychains[2].Get(owners[2])
+	sig, err := carol.Sign([]byte("tx"))
+	require.NoError(err)
+	err = client.pool.call(ctx, cosignerMethodSign, cosignSignParams{
+		ID:         proposed.ID,
+		Signatures: []collectedSignature{{Owner: owners[0], Signature: sig}},
+	}, &CosignProposal{})
+	require.ErrorIs(err, ErrWrongSigner)
+
+	// Proposals expire after their TTL
+	server.lock.Lock()
+	server.now = func() time.Time {
+		return time.Now().Add(time.Hour)
+	}
+	server.lock.Unlock()
+	_, err = client.Aggregate(ctx, proposed.ID)
+	require.ErrorIs(err, ErrUnknownProposal)
+	proposals, err := client.Proposals(ctx)
+	require.NoError(err)
+	require.Empty(proposals)
+}
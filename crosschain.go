@@ -0,0 +1,117 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/luxfi/crypto/common"
+	"github.com/luxfi/crypto/hash"
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var (
+	ErrPublicKeyUnavailable = errors.New("signer does not expose its public key")
+	ErrPublicKeyMismatch    = errors.New("public key does not match the signer address")
+
+	_ CrossChainKeychain = (*crossChainKeychain)(nil)
+)
+
+// CrossChainKeychain is a keychain whose keys are addressable both by their
+// X/P-chain address and by their C-chain (EVM) address
+type CrossChainKeychain interface {
+	Keychain
+	// GetEVM returns the signer of the key whose EVM address is [addr]
+	GetEVM(addr common.Address) (Signer, bool)
+	// EVMAddresses returns the EVM addresses of every key
+	EVMAddresses() set.Set[common.Address]
+	// EVMAddress returns the EVM address of the key of the X/P-chain [addr]
+	EVMAddress(addr ids.ShortID) (common.Address, bool)
+	// ShortAddress returns the X/P-chain address of the key of the EVM [addr]
+	ShortAddress(addr common.Address) (ids.ShortID, bool)
+}
+
+type crossChainKeychain struct {
+	Keychain
+	shortToEVM map[ids.ShortID]common.Address
+	evmToShort map[common.Address]ids.ShortID
+	evmAddrs   set.Set[common.Address]
+}
+
+// NewCrossChainKeychain indexes the keys of [kc] by their EVM address. Every
+// signer of [kc] must expose its public key, as reported by PublicKeyOf,
+// since the EVM address is derived from the public key. The addresses of
+// [kc] are read once, so keys added to [kc] afterwards are not visible
+// through the EVM lookups.
+func NewCrossChainKeychain(kc Keychain) (CrossChainKeychain, error) {
+	addrs := kc.Addresses()
+	c := &crossChainKeychain{
+		Keychain:   kc,
+		shortToEVM: make(map[ids.ShortID]common.Address, addrs.Len()),
+		evmToShort: make(map[common.Address]ids.ShortID, addrs.Len()),
+		evmAddrs:   set.NewSet[common.Address](addrs.Len()),
+	}
+	for addr := range addrs {
+		signer, ok := kc.Get(addr)
+		if !ok {
+			continue
+		}
+		pubKey, ok := PublicKeyOf(signer)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPublicKeyUnavailable, addr)
+		}
+		if publicKeyAddress(pubKey) != addr {
+			return nil, fmt.Errorf("%w: %s", ErrPublicKeyMismatch, addr)
+		}
+		evmAddr, err := EVMAddress(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		c.shortToEVM[addr] = evmAddr
+		c.evmToShort[evmAddr] = addr
+		c.evmAddrs.Add(evmAddr)
+	}
+	return c, nil
+}
+
+func (c *crossChainKeychain) GetEVM(addr common.Address) (Signer, bool) {
+	shortAddr, ok := c.evmToShort[addr]
+	if !ok {
+		return nil, false
+	}
+	return c.Get(shortAddr)
+}
+
+func (c *crossChainKeychain) EVMAddresses() set.Set[common.Address] {
+	return c.evmAddrs
+}
+
+func (c *crossChainKeychain) EVMAddress(addr ids.ShortID) (common.Address, bool) {
+	evmAddr, ok := c.shortToEVM[addr]
+	return evmAddr, ok
+}
+
+func (c *crossChainKeychain) ShortAddress(addr common.Address) (ids.ShortID, bool) {
+	shortAddr, ok := c.evmToShort[addr]
+	return shortAddr, ok
+}
+
+// EVMAddress returns the C-chain address of the compressed secp256k1 public
+// key [pubKey]: the last 20 bytes of the keccak256 hash of the uncompressed
+// point
+func EVMAddress(pubKey []byte) (common.Address, error) {
+	pk, err := secp256k1.ToPublicKey(pubKey)
+	if err != nil {
+		return common.Address{}, err
+	}
+	point := pk.ToECDSA()
+	digest := hash.ComputeKeccak256(
+		secp256k1.PaddedBigBytes(point.X, 32),
+		secp256k1.PaddedBigBytes(point.Y, 32),
+	)
+	return common.BytesToAddress(digest[12:]), nil
+}
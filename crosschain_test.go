@@ -0,0 +1,77 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/common"
+	"github.com/luxfi/crypto/secp256k1"
+)
+
+func TestEVMAddress(t *testing.T) {
+	require := require.New(t)
+
+	keyBytes := make([]byte, secp256k1.PrivateKeyLen)
+	keyBytes[len(keyBytes)-1] = 1
+	key, err := secp256k1.ToPrivateKey(keyBytes)
+	require.NoError(err)
+
+	evmAddr, err := EVMAddress(key.PublicKey().Bytes())
+	require.NoError(err)
+	require.Equal(common.HexToAddress("0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"), evmAddr)
+
+	_, err = EVMAddress([]byte{1, 2, 3})
+	require.Error(err)
+}
+
+func TestCrossChainKeychain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("cross-chain"), 2)
+	require.NoError(err)
+	keys := kc.Keys()
+
+	c, err := NewCrossChainKeychain(kc)
+	require.NoError(err)
+	require.Equal(2, c.EVMAddresses().Len())
+
+	for _, key := range keys {
+		evmAddr, err := EVMAddress(key.PublicKey().Bytes())
+		require.NoError(err)
+		require.True(c.EVMAddresses().Contains(evmAddr))
+
+		got, ok := c.EVMAddress(key.Address())
+		require.True(ok)
+		require.Equal(evmAddr, got)
+
+		shortAddr, ok := c.ShortAddress(evmAddr)
+		require.True(ok)
+		require.Equal(key.Address(), shortAddr)
+
+		signer, ok := c.GetEVM(evmAddr)
+		require.True(ok)
+		require.Equal(key.Address(), signer.Address())
+
+		signer, ok = c.Get(key.Address())
+		require.True(ok)
+		require.Equal(key.Address(), signer.Address())
+	}
+
+	_, ok := c.GetEVM(common.Address{})
+	require.False(ok)
+}
+
+func TestCrossChainKeychainPublicKeyUnavailable(t *testing.T) {
+	require := require.New(t)
+
+	ledger := newMockLedger()
+	kc, err := NewLedgerKeychain(ledger, []uint32{1})
+	require.NoError(err)
+
+	_, err = NewCrossChainKeychain(kc)
+	require.ErrorIs(err, ErrPublicKeyUnavailable)
+}
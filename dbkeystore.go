@@ -0,0 +1,550 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"slices"
+	"sync"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// dbKeystoreVersion is the version of the schema of a DBKeystore
+const dbKeystoreVersion = "1"
+
+// Rows of the configuration table of a DBKeystore
+const (
+	dbConfigVersion = "version"
+	dbConfigKDF     = "kdf"
+	dbConfigSalt    = "salt"
+	dbConfigCheck   = "check"
+)
+
+var (
+	ErrKeystoreDecryption         = errors.New("incorrect password or corrupted keystore")
+	ErrUnsupportedKeystoreVersion = errors.New("unsupported keystore database version")
+	ErrTxDone                     = errors.New("keystore transaction is done")
+
+	_ Keychain = (*DBKeystore)(nil)
+
+	// dbKeystoreSchema creates the tables of a DBKeystore
+	dbKeystoreSchema = []string{
+		`CREATE TABLE IF NOT EXISTS keychain_config (name TEXT PRIMARY KEY, value BLOB NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS keychain_keys (address TEXT PRIMARY KEY, sealed BLOB NOT NULL)`,
+		`CREATE TABLE IF NOT EXISTS keychain_metadata (address TEXT PRIMARY KEY, metadata BLOB NOT NULL)`,
+	}
+
+	// dbCheckData is sealed into the configuration so that a wrong password
+	// is detected before any key is decrypted
+	dbCheckData = []byte("lux-keychain-db:check")
+	// dbKeyData prefixes the address a key is sealed with, so that the key
+	// of one row cannot be swapped into another
+	dbKeyData = []byte("lux-keychain-db:key:")
+)
+
+// DBKeystore keeps a software keychain and the metadata of its accounts in an
+// embedded database, such as SQLite, so that services managing many keys
+// update them atomically rather than through key files. Keys are sealed
+// with a key derived from the keystore password once, rather than with a key
+// derivation per key as key files are, so that opening a keystore of
+// hundreds of keys takes a single scrypt derivation. Every key and metadata
+// is loaded in memory when the keystore is opened, so signing does not query
+// the database.
+//
+// The database is accessed through database/sql in the SQL dialect of
+// SQLite; the caller registers the driver, such as modernc.org/sqlite, and
+// owns the *sql.DB. A database must be used by one DBKeystore at a time.
+type DBKeystore struct {
+	db       *sql.DB
+	aead     cipher.AEAD
+	keychain *SoftwareKeychain
+
+	lock     sync.RWMutex
+	metadata map[ids.ShortID]AccountMetadata
+	closed   bool
+}
+
+// OpenDBKeystore opens the keystore stored in [db], creating its tables if
+// needed, and decrypts its keys with [pass]. The keys of a new keystore are
+// encrypted with the scrypt parameters of WithScrypt, or ScryptStandard;
+// existing keystores keep the parameters they were created with. A wrong
+// password fails with ErrKeystoreDecryption.
+func OpenDBKeystore(ctx context.Context, db *sql.DB, pass []byte, opts ...Option) (*DBKeystore, error) {
+	o := newOptions(opts)
+	params := bundleScrypt
+	if o.scrypt != nil {
+		if err := o.scrypt.Validate(); err != nil {
+			return nil, err
+		}
+		params = *o.scrypt
+	}
+
+	aead, err := initDBKeystore(ctx, db, pass, params)
+	if err != nil {
+		return nil, err
+	}
+	ks := &DBKeystore{
+		db:       db,
+		aead:     aead,
+		keychain: NewSoftwareKeychain(),
+		metadata: make(map[ids.ShortID]AccountMetadata),
+	}
+	if err := ks.load(ctx); err != nil {
+		_ = ks.keychain.Destroy()
+		return nil, err
+	}
+	return ks, nil
+}
+
+// initDBKeystore creates the tables and configuration of a new keystore, and
+// returns the cipher of the keys derived from [pass]
+func initDBKeystore(ctx context.Context, db *sql.DB, pass []byte, params ScryptParams) (cipher.AEAD, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, stmt := range dbKeystoreSchema {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return nil, err
+		}
+	}
+	config, err := readDBConfig(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var aead cipher.AEAD
+	if len(config) == 0 {
+		aead, err = newDBKeystore(ctx, tx, pass, params)
+	} else {
+		aead, err = openDBKeystore(config, pass)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return aead, tx.Commit()
+}
+
+// newDBKeystore writes the configuration of a new keystore
+func newDBKeystore(ctx context.Context, tx *sql.Tx, pass []byte, params ScryptParams) (cipher.AEAD, error) {
+	salt := make([]byte, bundleSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := newBundleAEAD(pass, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	check, err := seal(aead, dbCheckData, dbCheckData)
+	if err != nil {
+		return nil, err
+	}
+
+	config := []struct {
+		name  string
+		value []byte
+	}{
+		{dbConfigVersion, []byte(dbKeystoreVersion)},
+		{dbConfigKDF, []byte{byte(bits.Len(uint(params.N)) - 1), byte(params.R), byte(params.P)}},
+		{dbConfigSalt, salt},
+		{dbConfigCheck, check},
+	}
+	for _, row := range config {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO keychain_config (name, value) VALUES (?, ?)`, row.name, row.value); err != nil {
+			return nil, err
+		}
+	}
+	return aead, nil
+}
+
+// openDBKeystore derives the cipher of an existing keystore from [pass] and
+// checks it against the keystore
+func openDBKeystore(config map[string][]byte, pass []byte) (cipher.AEAD, error) {
+	if version := string(config[dbConfigVersion]); version != dbKeystoreVersion {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedKeystoreVersion, version)
+	}
+	kdf := config[dbConfigKDF]
+	if len(kdf) != bundleKDFLen {
+		return nil, ErrKeystoreDecryption
+	}
+	params, err := parseBundleScrypt(kdf)
+	if err != nil {
+		return nil, ErrKeystoreDecryption
+	}
+	salt := config[dbConfigSalt]
+	if len(salt) != bundleSaltLen {
+		return nil, ErrKeystoreDecryption
+	}
+	aead, err := newBundleAEAD(pass, salt, params)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := unseal(aead, config[dbConfigCheck], dbCheckData); err != nil {
+		return nil, err
+	}
+	return aead, nil
+}
+
+func readDBConfig(ctx context.Context, tx *sql.Tx) (map[string][]byte, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT name, value FROM keychain_config`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	config := make(map[string][]byte)
+	for rows.Next() {
+		var (
+			name  string
+			value []byte
+		)
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		config[name] = value
+	}
+	return config, rows.Err()
+}
+
+// seal encrypts [plaintext], authenticating [data], into nonce || ciphertext
+func seal(aead cipher.AEAD, plaintext, data []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, data), nil
+}
+
+// unseal decrypts [sealed], as returned by seal
+func unseal(aead cipher.AEAD, sealed, data []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrKeystoreDecryption
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], data)
+	if err != nil {
+		return nil, ErrKeystoreDecryption
+	}
+	return plaintext, nil
+}
+
+func dbKeyAssociatedData(addr ids.ShortID) []byte {
+	return append(slices.Clip(dbKeyData), addr[:]...)
+}
+
+// load decrypts the keys and reads the metadata of the keystore
+func (ks *DBKeystore) load(ctx context.Context) error {
+	rows, err := ks.db.QueryContext(ctx, `SELECT address, sealed FROM keychain_keys`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			addrStr string
+			sealed  []byte
+		)
+		if err := rows.Scan(&addrStr, &sealed); err != nil {
+			return err
+		}
+		addr, err := ids.ShortFromString(addrStr)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrKeystoreDecryption, err)
+		}
+		secret, err := unseal(ks.aead, sealed, dbKeyAssociatedData(addr))
+		if err != nil {
+			return fmt.Errorf("%w: key of %s", err, addr)
+		}
+		key, err := secp256k1.ToPrivateKey(secret)
+		if err != nil || key.Address() != addr {
+			clear(secret)
+			return fmt.Errorf("%w: key of %s", ErrKeystoreDecryption, addr)
+		}
+		ks.keychain.Add(key)
+		WipeKey(key)
+		clear(secret)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	mdRows, err := ks.db.QueryContext(ctx, `SELECT address, metadata FROM keychain_metadata`)
+	if err != nil {
+		return err
+	}
+	defer mdRows.Close()
+
+	for mdRows.Next() {
+		var (
+			addrStr string
+			encoded []byte
+		)
+		if err := mdRows.Scan(&addrStr, &encoded); err != nil {
+			return err
+		}
+		addr, err := ids.ShortFromString(addrStr)
+		if err != nil {
+			return err
+		}
+		var md AccountMetadata
+		if err := json.Unmarshal(encoded, &md); err != nil {
+			return err
+		}
+		md.Address = addr
+		ks.metadata[addr] = md
+	}
+	return mdRows.Err()
+}
+
+// Get returns the signer of a key of the keystore
+func (ks *DBKeystore) Get(addr ids.ShortID) (Signer, bool) {
+	return ks.keychain.Get(addr)
+}
+
+// Addresses returns the addresses of the keys of the keystore
+func (ks *DBKeystore) Addresses() set.Set[ids.ShortID] {
+	return ks.keychain.Addresses()
+}
+
+// Subscribe returns a channel receiving the keys added to and removed from
+// the keystore. Subscriptions are closed when the keystore is closed.
+func (ks *DBKeystore) Subscribe() <-chan Event {
+	return ks.keychain.Subscribe()
+}
+
+func (ks *DBKeystore) Unsubscribe(ch <-chan Event) {
+	ks.keychain.Unsubscribe(ch)
+}
+
+// Metadata returns the metadata of [addr], or false if none is recorded
+func (ks *DBKeystore) Metadata(addr ids.ShortID) (AccountMetadata, bool) {
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+
+	md, ok := ks.metadata[addr]
+	return cloneMetadata(md), ok
+}
+
+// Accounts returns the metadata of every address of the keystore, ordered by
+// address. Addresses without recorded metadata only have their Address set.
+func (ks *DBKeystore) Accounts() []AccountMetadata {
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+
+	addrs := ks.keychain.Addresses().List()
+	slices.SortFunc(addrs, ids.ShortID.Compare)
+	accounts := make([]AccountMetadata, len(addrs))
+	for i, addr := range addrs {
+		md, ok := ks.metadata[addr]
+		if !ok {
+			md.Address = addr
+		}
+		accounts[i] = cloneMetadata(md)
+	}
+	return accounts
+}
+
+// Store adds [keys] to the keystore, in a single transaction
+func (ks *DBKeystore) Store(ctx context.Context, keys ...*secp256k1.PrivateKey) error {
+	return ks.Update(ctx, func(tx *DBKeystoreTx) error {
+		for _, key := range keys {
+			if err := tx.Store(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes the keys of [addrs], and their metadata, from the keystore,
+// in a single transaction
+func (ks *DBKeystore) Delete(ctx context.Context, addrs ...ids.ShortID) error {
+	return ks.Update(ctx, func(tx *DBKeystoreTx) error {
+		for _, addr := range addrs {
+			if err := tx.Delete(addr); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Update runs [f] in a database transaction, committed if [f] returns nil
+// and rolled back otherwise. The changes made by [f] are applied to the keys
+// and metadata in memory once committed, so that a failed update changes
+// neither the database nor the keystore. Updates are serialized.
+func (ks *DBKeystore) Update(ctx context.Context, f func(tx *DBKeystoreTx) error) error {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	if ks.closed {
+		return ErrKeystoreClosed
+	}
+	sqlTx, err := ks.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	tx := &DBKeystoreTx{
+		ctx:      ctx,
+		ks:       ks,
+		tx:       sqlTx,
+		added:    make(map[ids.ShortID]*secp256k1.PrivateKey),
+		removed:  make(set.Set[ids.ShortID]),
+		metadata: make(map[ids.ShortID]*AccountMetadata),
+	}
+	err = f(tx)
+	tx.done = true
+	if err != nil {
+		return errors.Join(err, sqlTx.Rollback())
+	}
+	if err := sqlTx.Commit(); err != nil {
+		return err
+	}
+
+	for addr := range tx.removed {
+		ks.keychain.Remove(addr)
+	}
+	for _, key := range tx.added {
+		ks.keychain.Add(key)
+	}
+	for addr, md := range tx.metadata {
+		if md == nil {
+			delete(ks.metadata, addr)
+		} else {
+			ks.metadata[addr] = *md
+		}
+	}
+	return nil
+}
+
+// Close wipes the keys of the keystore. The database is left open.
+func (ks *DBKeystore) Close() error {
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	if ks.closed {
+		return nil
+	}
+	ks.closed = true
+	clear(ks.metadata)
+	err := ks.keychain.Destroy()
+	ks.keychain.events.close()
+	return err
+}
+
+// DBKeystoreTx is a transaction of DBKeystore.Update. It must not be used once
+// the function it was given to returns.
+type DBKeystoreTx struct {
+	ctx context.Context
+	ks  *DBKeystore
+	tx  *sql.Tx
+
+	added    map[ids.ShortID]*secp256k1.PrivateKey
+	removed  set.Set[ids.ShortID]
+	metadata map[ids.ShortID]*AccountMetadata
+	done     bool
+}
+
+// has reports whether the keystore holds [addr] once the transaction commits
+func (t *DBKeystoreTx) has(addr ids.ShortID) bool {
+	if _, ok := t.added[addr]; ok {
+		return true
+	}
+	return !t.removed.Contains(addr) && t.ks.keychain.Addresses().Contains(addr)
+}
+
+// Store adds [key] to the keystore. Storing a key the keystore holds does
+// nothing.
+func (t *DBKeystoreTx) Store(key *secp256k1.PrivateKey) error {
+	if t.done {
+		return ErrTxDone
+	}
+	addr := key.Address()
+	sealed, err := seal(t.ks.aead, key.Bytes(), dbKeyAssociatedData(addr))
+	if err != nil {
+		return err
+	}
+	if _, err := t.tx.ExecContext(t.ctx,
+		`INSERT INTO keychain_keys (address, sealed) VALUES (?, ?) ON CONFLICT (address) DO UPDATE SET sealed = excluded.sealed`,
+		addr.String(), sealed,
+	); err != nil {
+		return err
+	}
+	t.added[addr] = key
+	t.removed.Remove(addr)
+	return nil
+}
+
+// Delete removes the key of [addr], and its metadata, from the keystore. It
+// fails with ErrUnknownAddress if the keystore does not hold [addr].
+func (t *DBKeystoreTx) Delete(addr ids.ShortID) error {
+	if t.done {
+		return ErrTxDone
+	}
+	if !t.has(addr) {
+		return fmt.Errorf("%w: %s", ErrUnknownAddress, addr)
+	}
+	for _, stmt := range []string{
+		`DELETE FROM keychain_keys WHERE address = ?`,
+		`DELETE FROM keychain_metadata WHERE address = ?`,
+	} {
+		if _, err := t.tx.ExecContext(t.ctx, stmt, addr.String()); err != nil {
+			return err
+		}
+	}
+	delete(t.added, addr)
+	t.removed.Add(addr)
+	t.metadata[addr] = nil
+	return nil
+}
+
+// SetMetadata records the metadata of the address it describes, which must
+// be held by the keystore once the transaction commits
+func (t *DBKeystoreTx) SetMetadata(md AccountMetadata) error {
+	if t.done {
+		return ErrTxDone
+	}
+	if !t.has(md.Address) {
+		return fmt.Errorf("%w: %s", ErrUnknownAddress, md.Address)
+	}
+	md = cloneMetadata(md)
+	encoded, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+	if _, err := t.tx.ExecContext(t.ctx,
+		`INSERT INTO keychain_metadata (address, metadata) VALUES (?, ?) ON CONFLICT (address) DO UPDATE SET metadata = excluded.metadata`,
+		md.Address.String(), encoded,
+	); err != nil {
+		return err
+	}
+	t.metadata[md.Address] = &md
+	return nil
+}
+
+// DeleteMetadata removes the metadata of [addr]
+func (t *DBKeystoreTx) DeleteMetadata(addr ids.ShortID) error {
+	if t.done {
+		return ErrTxDone
+	}
+	if _, err := t.tx.ExecContext(t.ctx, `DELETE FROM keychain_metadata WHERE address = ?`, addr.String()); err != nil {
+		return err
+	}
+	t.metadata[addr] = nil
+	return nil
+}
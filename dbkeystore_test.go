@@ -0,0 +1,186 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build !js
+
+package keychain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+
+	_ "modernc.org/sqlite"
+)
+
+// openTestDB returns a new SQLite database, closed when the test ends
+func openTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "keys.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+// testDBKeys returns [n] deterministic keys
+func testDBKeys(t *testing.T, n int) []*secp256k1.PrivateKey {
+	kc, err := NewTestKeychain([]byte("db"), n)
+	require.NoError(t, err)
+	return kc.Keys()
+}
+
+func TestDBKeystore(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	ctx := context.Background()
+	db := openTestDB(t)
+	pass := []byte("correct horse")
+	ks, err := OpenDBKeystore(ctx, db, pass)
+	require.NoError(err)
+	require.Zero(ks.Addresses().Len())
+
+	keys := testDBKeys(t, 3)
+	require.NoError(ks.Store(ctx, keys...))
+	require.Equal(3, ks.Addresses().Len())
+	require.NoError(ks.Update(ctx, func(tx *DBKeystoreTx) error {
+		return tx.SetMetadata(AccountMetadata{
+			Address: keys[0].Address(),
+			Label:   "treasury",
+			Tags:    []string{"cold"},
+		})
+	}))
+
+	signer, ok := ks.Get(keys[1].Address())
+	require.True(ok)
+	msg := []byte("tx")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.NoError(ks.Close())
+	_, ok = ks.Get(keys[1].Address())
+	require.False(ok)
+	require.ErrorIs(ks.Store(ctx, keys[0]), ErrKeystoreClosed)
+
+	// Keys and metadata persist across opens
+	ks, err = OpenDBKeystore(ctx, db, pass)
+	require.NoError(err)
+	require.Equal(3, ks.Addresses().Len())
+	signer, ok = ks.Get(keys[1].Address())
+	require.True(ok)
+	reSig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.Equal(sig, reSig)
+
+	md, ok := ks.Metadata(keys[0].Address())
+	require.True(ok)
+	require.Equal("treasury", md.Label)
+	require.Equal([]string{"cold"}, md.Tags)
+	accounts := ks.Accounts()
+	require.Len(accounts, 3)
+	for i := 1; i < len(accounts); i++ {
+		require.Negative(accounts[i-1].Address.Compare(accounts[i].Address))
+	}
+
+	require.NoError(ks.Delete(ctx, keys[0].Address()))
+	_, ok = ks.Metadata(keys[0].Address())
+	require.False(ok)
+	require.ErrorIs(ks.Delete(ctx, keys[0].Address()), ErrUnknownAddress)
+	require.NoError(ks.Close())
+
+	ks, err = OpenDBKeystore(ctx, db, pass)
+	require.NoError(err)
+	require.Equal(2, ks.Addresses().Len())
+	require.False(ks.Addresses().Contains(keys[0].Address()))
+	_, ok = ks.Metadata(keys[0].Address())
+	require.False(ok)
+	require.NoError(ks.Close())
+}
+
+func TestDBKeystoreWrongPassword(t *testing.T) {
+	require := require.New(t)
+
+	ctx := context.Background()
+	db := openTestDB(t)
+	ks, err := OpenDBKeystore(ctx, db, []byte("pass"), WithScrypt(ScryptParams{N: 1 << 10, R: 8, P: 1}))
+	require.NoError(err)
+	require.NoError(ks.Store(ctx, testDBKeys(t, 1)...))
+	require.NoError(ks.Close())
+
+	_, err = OpenDBKeystore(ctx, db, []byte("wrong"))
+	require.ErrorIs(err, ErrKeystoreDecryption)
+
+	_, err = db.Exec(`UPDATE keychain_config SET value = ? WHERE name = ?`, []byte("2"), dbConfigVersion)
+	require.NoError(err)
+	_, err = OpenDBKeystore(ctx, db, []byte("pass"))
+	require.ErrorIs(err, ErrUnsupportedKeystoreVersion)
+}
+
+func TestDBKeystoreUpdateRollback(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	ctx := context.Background()
+	db := openTestDB(t)
+	pass := []byte("pass")
+	ks, err := OpenDBKeystore(ctx, db, pass)
+	require.NoError(err)
+	keys := testDBKeys(t, 2)
+	require.NoError(ks.Store(ctx, keys[0]))
+
+	// A failed update changes neither the keystore nor the database
+	errAbort := errors.New("abort")
+	var leaked *DBKeystoreTx
+	err = ks.Update(ctx, func(tx *DBKeystoreTx) error {
+		leaked = tx
+		require.NoError(tx.Store(keys[1]))
+		require.NoError(tx.SetMetadata(AccountMetadata{Address: keys[1].Address(), Label: "new"}))
+		require.NoError(tx.Delete(keys[0].Address()))
+		return errAbort
+	})
+	require.ErrorIs(err, errAbort)
+	require.ErrorIs(leaked.Store(keys[1]), ErrTxDone)
+	require.True(ks.Addresses().Contains(keys[0].Address()))
+	require.False(ks.Addresses().Contains(keys[1].Address()))
+	_, ok := ks.Metadata(keys[1].Address())
+	require.False(ok)
+
+	// Metadata is only recorded for held addresses
+	err = ks.Update(ctx, func(tx *DBKeystoreTx) error {
+		return tx.SetMetadata(AccountMetadata{Address: keys[1].Address()})
+	})
+	require.ErrorIs(err, ErrUnknownAddress)
+	require.NoError(ks.Close())
+
+	ks, err = OpenDBKeystore(ctx, db, pass)
+	require.NoError(err)
+	require.Equal(1, ks.Addresses().Len())
+	require.True(ks.Addresses().Contains(keys[0].Address()))
+	require.NoError(ks.Close())
+}
+
+func TestDBKeystoreTamperedKey(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	ctx := context.Background()
+	db := openTestDB(t)
+	ks, err := OpenDBKeystore(ctx, db, []byte("pass"))
+	require.NoError(err)
+	keys := testDBKeys(t, 2)
+	require.NoError(ks.Store(ctx, keys...))
+	require.NoError(ks.Close())
+
+	// A sealed key moved to the row of another address fails to decrypt
+	var sealed []byte
+	require.NoError(db.QueryRow(`SELECT sealed FROM keychain_keys WHERE address = ?`, keys[0].Address().String()).Scan(&sealed))
+	_, err = db.Exec(`UPDATE keychain_keys SET sealed = ? WHERE address = ?`, sealed, keys[1].Address().String())
+	require.NoError(err)
+	_, err = OpenDBKeystore(ctx, db, []byte("pass"))
+	require.ErrorIs(err, ErrKeystoreDecryption)
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"sync"
+
+	"github.com/luxfi/ids"
+)
+
+// WithBatchSize derives the addresses of a ledger keychain with GetAddresses
+// and GetPublicKeys requests of at most [n] indices, so that keychains of
+// thousands of indices, such as exchange deposit addresses, are not derived
+// by a single request that times out on hardware or remote backends.
+// Timeouts apply to each request. Batches are requested by the workers of
+// WithConcurrency, and reported to WithProgress as each is derived. Without
+// it, addresses are derived by a single request, or by one request per index
+// with WithConcurrency or WithProgress.
+func WithBatchSize(n int) Option {
+	return func(o *options) {
+		o.batchSize = max(n, 0)
+	}
+}
+
+// deriveAddresses returns the addresses of [indices], in order, requested in
+// batches of [batchSize] indices by a bounded pool of workers. Without a
+// batch size, a single GetAddresses request is issued with a concurrency of
+// 1, unless [progress] is set, which reports each derived address; otherwise
+// each index is requested separately.
+func deriveAddresses(ledger Ledger, indices []uint32, concurrency, batchSize int, progress func(Progress)) ([]ids.ShortID, error) {
+	if batchSize <= 0 {
+		batchSize = len(indices)
+		if concurrency > 1 || progress != nil {
+			batchSize = 1
+		}
+	}
+	if batchSize >= len(indices) && progress == nil {
+		return getAddresses(ledger, indices)
+	}
+
+	// reported counts the addresses reported to progress
+	var (
+		progressLock sync.Mutex
+		reported     int
+	)
+	report := func(batch []uint32) {
+		if progress == nil {
+			return
+		}
+		progressLock.Lock()
+		defer progressLock.Unlock()
+
+		reported += len(batch)
+		progress(Progress{
+			Stage: ProgressDeriving,
+			Index: batch[len(batch)-1],
+			Step:  reported,
+			Steps: len(indices),
+		})
+	}
+
+	var (
+		addresses = make([]ids.ShortID, len(indices))
+		jobs      = make(chan int)
+		wg        sync.WaitGroup
+
+		errOnce  sync.Once
+		firstErr error
+		failed   = make(chan struct{})
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			close(failed)
+		})
+	}
+
+	batches := (len(indices) + batchSize - 1) / batchSize
+	for range min(max(concurrency, 1), batches) {
+		wg.Go(func() {
+			for start := range jobs {
+				batch := indices[start:min(start+batchSize, len(indices))]
+				derived, err := getAddresses(ledger, batch)
+				if err != nil {
+					fail(err)
+					continue
+				}
+				copy(addresses[start:], derived)
+				report(batch)
+			}
+		})
+	}
+
+dispatch:
+	for start := 0; start < len(indices); start += batchSize {
+		select {
+		case jobs <- start:
+		case <-failed:
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return addresses, nil
+}
+
+// getAddresses requests the addresses of [indices] with a single
+// GetAddresses request
+func getAddresses(ledger Ledger, indices []uint32) ([]ids.ShortID, error) {
+	addresses, err := ledger.GetAddresses(indices)
+	if err != nil {
+		return nil, err
+	}
+	if len(addresses) != len(indices) {
+		return nil, ErrInvalidNumAddrsDerived
+	}
+	return addresses, nil
+}
@@ -0,0 +1,188 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+var errDerive = errors.New("derive failed")
+
+// concurrentLedger records the peak number of in-flight GetAddresses calls
+type concurrentLedger struct {
+	*mockLedger
+	inFlight atomic.Int32
+	peak     atomic.Int32
+	failIdx  uint32
+	fail     bool
+}
+
+func (c *concurrentLedger) GetAddresses(addressIndices []uint32) ([]ids.ShortID, error) {
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+
+	for _, idx := range addressIndices {
+		if c.fail && idx == c.failIdx {
+			return nil, errDerive
+		}
+	}
+	return c.mockLedger.GetAddresses(addressIndices)
+}
+
+func TestDeriveAddressesConcurrently(t *testing.T) {
+	require := require.New(t)
+
+	ledger := &concurrentLedger{mockLedger: newMockLedger()}
+	indices := make([]uint32, 64)
+	for i := range indices {
+		indices[i] = uint32(i)
+	}
+
+	addrs, err := deriveAddresses(ledger, indices, 4, 0, nil)
+	require.NoError(err)
+	require.Len(addrs, len(indices))
+	for i, addr := range addrs {
+		expected, err := ledger.Address("", indices[i])
+		require.NoError(err)
+		require.Equal(expected, addr)
+	}
+	require.LessOrEqual(ledger.peak.Load(), int32(4))
+	require.Greater(ledger.peak.Load(), int32(1))
+}
+
+func TestDeriveAddressesConcurrentError(t *testing.T) {
+	require := require.New(t)
+
+	ledger := &concurrentLedger{
+		mockLedger: newMockLedger(),
+		failIdx:    7,
+		fail:       true,
+	}
+	indices := make([]uint32, 32)
+	for i := range indices {
+		indices[i] = uint32(i)
+	}
+
+	_, err := deriveAddresses(ledger, indices, 8, 0, nil)
+	require.ErrorIs(err, errDerive)
+}
+
+func TestNewLedgerKeychainWithConcurrency(t *testing.T) {
+	require := require.New(t)
+
+	ledger := &concurrentLedger{mockLedger: newMockLedger()}
+	kc, err := NewLedgerKeychain(ledger, []uint32{0, 1, 2, 3, 4}, WithConcurrency(3))
+	require.NoError(err)
+	require.Equal(5, kc.Addresses().Len())
+
+	addr, err := ledger.Address("", 3)
+	require.NoError(err)
+	signer, ok := kc.Get(addr)
+	require.True(ok)
+	require.Equal(addr, signer.Address())
+}
+
+// batchLedger records the number of indices of each request
+type batchLedger struct {
+	*mockLedger
+	lock                   sync.Mutex
+	addrBatches, pkBatches []int
+}
+
+func (b *batchLedger) GetAddresses(addressIndices []uint32) ([]ids.ShortID, error) {
+	b.lock.Lock()
+	b.addrBatches = append(b.addrBatches, len(addressIndices))
+	b.lock.Unlock()
+	return b.mockLedger.GetAddresses(addressIndices)
+}
+
+func (b *batchLedger) GetPublicKeys(addressIndices []uint32) ([][]byte, error) {
+	b.lock.Lock()
+	b.pkBatches = append(b.pkBatches, len(addressIndices))
+	b.lock.Unlock()
+	pubKeys := make([][]byte, len(addressIndices))
+	for i, idx := range addressIndices {
+		pubKeys[i] = binary.BigEndian.AppendUint32(nil, idx)
+	}
+	return pubKeys, nil
+}
+
+func TestDeriveAddressesInBatches(t *testing.T) {
+	require := require.New(t)
+
+	ledger := &batchLedger{mockLedger: newMockLedger()}
+	indices := make([]uint32, 10)
+	for i := range indices {
+		indices[i] = uint32(100 + i)
+	}
+
+	var events []Progress
+	addrs, err := deriveAddresses(ledger, indices, 1, 4, func(p Progress) {
+		events = append(events, p)
+	})
+	require.NoError(err)
+	require.Equal([]int{4, 4, 2}, ledger.addrBatches)
+	for i, addr := range addrs {
+		expected, err := ledger.Address("", indices[i])
+		require.NoError(err)
+		require.Equal(expected, addr)
+	}
+	require.Equal([]Progress{
+		{Stage: ProgressDeriving, Index: 103, Step: 4, Steps: 10},
+		{Stage: ProgressDeriving, Index: 107, Step: 8, Steps: 10},
+		{Stage: ProgressDeriving, Index: 109, Step: 10, Steps: 10},
+	}, events)
+
+	// Batches are requested concurrently
+	concurrent := &concurrentLedger{mockLedger: newMockLedger()}
+	batched, err := deriveAddresses(concurrent, indices, 2, 3, nil)
+	require.NoError(err)
+	require.Equal(addrs, batched)
+	require.LessOrEqual(concurrent.peak.Load(), int32(2))
+}
+
+func TestNewLedgerKeychainWithBatchSize(t *testing.T) {
+	require := require.New(t)
+
+	indices := make([]uint32, 7)
+	for i := range indices {
+		indices[i] = uint32(i)
+	}
+	ledger := &batchLedger{mockLedger: newMockLedger()}
+	kc, err := NewLedgerKeychain(ledger, indices, WithBatchSize(3))
+	require.NoError(err)
+	require.Equal(7, kc.Addresses().Len())
+	require.Equal([]int{3, 3, 1}, ledger.addrBatches)
+	require.Equal([]int{3, 3, 1}, ledger.pkBatches)
+
+	addr, err := ledger.Address("", 6)
+	require.NoError(err)
+	signer, ok := kc.Get(addr)
+	require.True(ok)
+	require.Equal([]byte{0, 0, 0, 6}, signer.(PublicKeySigner).PublicKey())
+
+	// Lazy keychains derive their pending addresses in batches too
+	ledger = &batchLedger{mockLedger: newMockLedger()}
+	lazy, err := NewLazyLedgerKeychain(ledger, indices, WithBatchSize(4))
+	require.NoError(err)
+	_, err = lazy.SignerAt(0)
+	require.NoError(err)
+	require.Equal(7, lazy.Addresses().Len())
+	require.Equal([]int{1, 4, 2}, ledger.addrBatches)
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// BOLOS commands, served by the operating system of the device whichever
+// app is open
+const (
+	bolosCLA            = 0xb0
+	insGetAppAndVersion = 0x01
+
+	// appInfoFormat is the format of the GetAppAndVersion response
+	appInfoFormat = 0x01
+)
+
+var (
+	ErrDeviceEnumerationUnsupported = errors.New("hardware wallet enumeration is not supported on this platform")
+	ErrDeviceNotFound               = errors.New("hardware wallet not found")
+)
+
+// LedgerApp is the app open on a ledger device. The name is "BOLOS" while
+// the dashboard is shown.
+type LedgerApp struct {
+	Name    string
+	Version string
+}
+
+// DeviceInfo describes a connected hardware wallet
+type DeviceInfo struct {
+	// Path identifies the device for OpenLedgerDevice
+	Path   string
+	Model  LedgerModel
+	Serial string
+	// App is the app open on the device. It is unset if the device is
+	// locked or could not be queried.
+	App LedgerApp
+	// Locked is set if the device is waiting for its PIN
+	Locked bool
+	// Err records why the device could not be queried, such as missing
+	// permissions on its device node
+	Err error
+}
+
+// App returns the app open on the device:
+//
+//	GetAppAndVersion CLA 0xb0 INS 0x01: -> 0x01 || len(name) || name || len(version) || version || len(flags) || flags
+func (l *LedgerDevice) App() (LedgerApp, error) {
+	resp, err := l.exchange(&apduCommand{
+		cla: bolosCLA,
+		ins: insGetAppAndVersion,
+	})
+	if err != nil {
+		return LedgerApp{}, err
+	}
+	if len(resp) < 2 || resp[0] != appInfoFormat {
+		return LedgerApp{}, ErrInvalidResponse
+	}
+	name, rest, ok := cutLengthPrefixed(resp[1:])
+	if !ok {
+		return LedgerApp{}, ErrInvalidResponse
+	}
+	version, _, ok := cutLengthPrefixed(rest)
+	if !ok {
+		return LedgerApp{}, ErrInvalidResponse
+	}
+	return LedgerApp{
+		Name:    string(name),
+		Version: string(version),
+	}, nil
+}
+
+// ListDevices reports the ledger devices connected over USB, so wallet UIs can
+// present a picker before constructing a keychain. Each device is opened to
+// query its app and locked state; devices that cannot be opened are still
+// listed, with Err set. Enumeration is supported on Linux, through hidraw,
+// and fails with ErrDeviceEnumerationUnsupported elsewhere.
+func ListDevices() ([]DeviceInfo, error) {
+	devices, err := enumerateHID()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]DeviceInfo, len(devices))
+	for i, dev := range devices {
+		infos[i] = DeviceInfo{
+			Path:   dev.path,
+			Model:  dev.model,
+			Serial: dev.serial,
+		}
+		infos[i].App, infos[i].Locked, infos[i].Err = queryDevice(dev)
+	}
+	return infos, nil
+}
+
+// OpenLedgerDevice opens the ledger device at [path], as reported by
+// ListDevices
+func OpenLedgerDevice(path string) (*LedgerDevice, error) {
+	devices, err := enumerateHID()
+	if err != nil {
+		return nil, err
+	}
+	for _, dev := range devices {
+		if dev.path != path {
+			continue
+		}
+		f, err := openHID(path)
+		if err != nil {
+			return nil, err
+		}
+		return NewLedgerDevice(NewHIDTransport(f, dev.model)), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, path)
+}
+
+// hidDevice is the APDU interface of a ledger device found by enumerateHID
+type hidDevice struct {
+	path   string
+	model  LedgerModel
+	serial string
+}
+
+func queryDevice(dev hidDevice) (LedgerApp, bool, error) {
+	f, err := openHID(dev.path)
+	if err != nil {
+		return LedgerApp{}, false, err
+	}
+	device := NewLedgerDevice(NewHIDTransport(f, dev.model))
+	defer device.Disconnect()
+
+	app, err := device.App()
+	if errors.Is(err, ErrDeviceLocked) {
+		return LedgerApp{}, true, nil
+	}
+	return app, false, err
+}
+
+// cutLengthPrefixed splits a 1 byte length prefixed field from [data]
+func cutLengthPrefixed(data []byte) ([]byte, []byte, bool) {
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return nil, nil, false
+	}
+	n := 1 + int(data[0])
+	return data[1:n], data[n:], true
+}
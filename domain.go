@@ -0,0 +1,182 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/luxfi/ids"
+)
+
+// Signing domains of the protocols of Lux
+const (
+	DomainTx      Domain = "tx"
+	DomainMessage Domain = "message"
+	DomainWarp    Domain = "warp"
+	DomainStaking Domain = "staking"
+)
+
+// domainPrefix starts the preimage of every domain hash, separating them from
+// the hashes of other constructions
+const domainPrefix = "lux-domain:"
+
+var (
+	ErrInvalidDomain    = errors.New("invalid signing domain")
+	ErrDomainRequired   = errors.New("hash must be signed with a declared domain")
+	ErrDomainNotAllowed = errors.New("signing domain is not allowed")
+
+	domainPattern = regexp.MustCompile(`^[a-z0-9][-.a-z0-9]{0,63}$`)
+
+	_ Unwrapper = (*domainSigner)(nil)
+)
+
+// Domain is a tag separating the hashes signed by one protocol from those of
+// the others, so that a signature obtained for one, such as a signed message,
+// cannot be replayed in another, such as a transaction. Besides the built in
+// domains, applications may declare their own, such as "myapp.login". Domains
+// are made of lowercase letters, digits, dots and dashes.
+type Domain string
+
+// Valid reports whether [d] is a well formed domain
+func (d Domain) Valid() bool {
+	return domainPattern.MatchString(string(d))
+}
+
+// DomainHash returns the hash signed for [payload] in [domain]:
+//
+//	SHA-256("lux-domain:" || domain || 0x00 || payload)
+func DomainHash(domain Domain, payload []byte) ([]byte, error) {
+	if !domain.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDomain, domain)
+	}
+	h := sha256.New()
+	_, _ = h.Write([]byte(domainPrefix))
+	_, _ = h.Write([]byte(domain))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(payload)
+	return h.Sum(nil), nil
+}
+
+// SignDomain signs the DomainHash of [payload] in [domain] with [signer]. The
+// hash is declared to the RequireDomain middlewares wrapping the signer, so
+// that they let it through.
+func SignDomain(signer Signer, domain Domain, payload []byte) ([]byte, error) {
+	hash, err := DomainHash(domain, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var gates []*domainGate
+	for s := signer; s != nil; {
+		if d, ok := s.(*domainSigner); ok {
+			gates = append(gates, d.gate)
+		}
+		u, ok := s.(Unwrapper)
+		if !ok {
+			break
+		}
+		s = u.Unwrap()
+	}
+	for i, gate := range gates {
+		if err := gate.declare(domain, hash); err != nil {
+			for _, declared := range gates[:i] {
+				declared.release(hash)
+			}
+			return nil, err
+		}
+	}
+	defer func() {
+		for _, gate := range gates {
+			gate.release(hash)
+		}
+	}()
+	return signer.SignHash(hash)
+}
+
+// RequireDomain returns a middleware rejecting with ErrDomainRequired the
+// SignHash calls of hashes that were not computed by SignDomain, and with
+// ErrDomainNotAllowed those of domains not in [domains]. If [domains] is
+// empty, every domain is allowed. Sign hashes the message as a transaction
+// and is not affected.
+func RequireDomain(domains ...Domain) Middleware {
+	gate := &domainGate{
+		allowed:  slices.Clone(domains),
+		declared: make(map[ids.ID]int),
+	}
+	return func(next Signer) Signer {
+		return &domainSigner{
+			next: next,
+			gate: gate,
+		}
+	}
+}
+
+// domainGate counts the hashes being signed by SignDomain
+type domainGate struct {
+	allowed []Domain
+
+	lock     sync.Mutex
+	declared map[ids.ID]int
+}
+
+func (g *domainGate) declare(domain Domain, hash []byte) error {
+	if len(g.allowed) > 0 && !slices.Contains(g.allowed, domain) {
+		return fmt.Errorf("%w: %s", ErrDomainNotAllowed, domain)
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.declared[ids.ID(hash)]++
+	return nil
+}
+
+func (g *domainGate) release(hash []byte) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	key := ids.ID(hash)
+	if g.declared[key]--; g.declared[key] <= 0 {
+		delete(g.declared, key)
+	}
+}
+
+func (g *domainGate) isDeclared(hash []byte) bool {
+	if len(hash) != len(ids.ID{}) {
+		return false
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	return g.declared[ids.ID(hash)] > 0
+}
+
+// domainSigner rejects the hashes not declared to its gate
+type domainSigner struct {
+	next Signer
+	gate *domainGate
+}
+
+func (d *domainSigner) SignHash(hash []byte) ([]byte, error) {
+	if !d.gate.isDeclared(hash) {
+		return nil, ErrDomainRequired
+	}
+	return d.next.SignHash(hash)
+}
+
+func (d *domainSigner) Sign(msg []byte) ([]byte, error) {
+	return d.next.Sign(msg)
+}
+
+func (d *domainSigner) Address() ids.ShortID {
+	return d.next.Address()
+}
+
+func (d *domainSigner) Unwrap() Signer {
+	return d.next
+}
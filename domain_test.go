@@ -0,0 +1,87 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDomainHash(t *testing.T) {
+	require := require.New(t)
+
+	payload := []byte("payload")
+	txHash, err := DomainHash(DomainTx, payload)
+	require.NoError(err)
+	expected := sha256.Sum256([]byte("lux-domain:tx\x00payload"))
+	require.Equal(expected[:], txHash)
+
+	// The same payload hashes differently in every domain
+	msgHash, err := DomainHash(DomainMessage, payload)
+	require.NoError(err)
+	require.NotEqual(txHash, msgHash)
+
+	for _, domain := range []Domain{"", "Tx", "tx\x00", "-tx", "my app"} {
+		_, err := DomainHash(domain, payload)
+		require.ErrorIs(err, ErrInvalidDomain, "domain %q", domain)
+	}
+	require.True(Domain("myapp.login-v2").Valid())
+}
+
+func TestRequireDomain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("domain"), 1)
+	require.NoError(err)
+	addr, _ := kc.Addresses().Peek()
+	wrapped := Wrap(kc, Intercept(noopInterceptor), RequireDomain(DomainMessage, DomainWarp))
+	signer, ok := wrapped.Get(addr)
+	require.True(ok)
+
+	payload := []byte("hello")
+	sig, err := SignDomain(signer, DomainMessage, payload)
+	require.NoError(err)
+	hash, err := DomainHash(DomainMessage, payload)
+	require.NoError(err)
+	pubKey, err := secp256k1.RecoverPublicKeyFromHash(hash, sig)
+	require.NoError(err)
+	require.Equal(addr, pubKey.Address())
+
+	// Raw hashes, even of a declared domain, are rejected once the signing
+	// completed
+	_, err = signer.SignHash(hash)
+	require.ErrorIs(err, ErrDomainRequired)
+	_, err = SignDomain(signer, DomainStaking, payload)
+	require.ErrorIs(err, ErrDomainNotAllowed)
+
+	// Transactions are signed with Sign, which the middleware lets through
+	_, err = signer.Sign([]byte("tx"))
+	require.NoError(err)
+}
+
+func TestRequireAnyDomain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("domain"), 1)
+	require.NoError(err)
+	addr, _ := kc.Addresses().Peek()
+	signer, _ := Wrap(kc, RequireDomain()).Get(addr)
+
+	_, err = SignDomain(signer, "myapp.login", []byte("nonce"))
+	require.NoError(err)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrDomainRequired)
+
+	// Signers without the middleware sign domain hashes as any other hash
+	plain, _ := kc.Get(addr)
+	_, err = SignDomain(plain, DomainTx, []byte("tx"))
+	require.NoError(err)
+}
+
+func noopInterceptor(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+	return next(payload)
+}
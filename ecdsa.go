@@ -0,0 +1,84 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+
+	"github.com/luxfi/crypto/hash"
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+)
+
+var (
+	ErrInvalidSignatureEncoding = errors.New("invalid signature encoding")
+	ErrSignatureRecovery        = errors.New("signature does not recover the signer's address")
+)
+
+// parseDERSignature parses an ASN.1 DER encoded ECDSA signature, as returned
+// by most hardware tokens and KMS services
+func parseDERSignature(der []byte) (*big.Int, *big.Int, error) {
+	var (
+		r, s  = new(big.Int), new(big.Int)
+		input = cryptobyte.String(der)
+		inner cryptobyte.String
+	)
+	if !input.ReadASN1(&inner, asn1.SEQUENCE) ||
+		!input.Empty() ||
+		!inner.ReadASN1Integer(r) ||
+		!inner.ReadASN1Integer(s) ||
+		!inner.Empty() ||
+		r.Sign() <= 0 || s.Sign() <= 0 {
+		return nil, nil, ErrInvalidSignatureEncoding
+	}
+	return r, s, nil
+}
+
+// compactSignature returns the 64 byte r || s encoding of a signature over
+// [curve], with s normalized to the lower half of the curve order
+func compactSignature(curve elliptic.Curve, r, s *big.Int) []byte {
+	n := curve.Params().N
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s = new(big.Int).Sub(n, s)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return sig
+}
+
+// recoverableSignature appends to a compact secp256k1 signature the recovery
+// id that recovers [addr] from [hash], producing the 65 byte [r || s || v]
+// signature format used by Lux credentials
+func recoverableSignature(hash, compact []byte, addr ids.ShortID) ([]byte, error) {
+	sig := make([]byte, secp256k1.SignatureLen)
+	copy(sig, compact)
+	for v := range byte(2) {
+		sig[64] = v
+		pubKey, err := secp256k1.RecoverPublicKeyFromHash(hash, sig)
+		if err == nil && pubKey.Address() == addr {
+			return sig, nil
+		}
+	}
+	return nil, ErrSignatureRecovery
+}
+
+// compressPublicKey returns the 33 byte SEC1 compressed encoding of a point
+func compressPublicKey(x, y *big.Int) []byte {
+	compressed := make([]byte, 33)
+	compressed[0] = 0x02 | byte(y.Bit(0))
+	x.FillBytes(compressed[1:])
+	return compressed
+}
+
+// publicKeyAddress returns the Lux address of a compressed public key
+func publicKeyAddress(compressed []byte) ids.ShortID {
+	addr, _ := ids.ToShortID(hash.PubkeyBytesToAddress(compressed))
+	return addr
+}
@@ -0,0 +1,46 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+
+	"github.com/stretchr/testify/require"
+)
+
+func FuzzParseDERSignature(f *testing.F) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(f, err)
+	hash := sha256.Sum256([]byte("der"))
+	der, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	require.NoError(f, err)
+	f.Add(der)
+	f.Add([]byte{0x30, 0x06, 0x02, 0x01, 0x01, 0x02, 0x01, 0x01})
+	f.Add([]byte{0x30, 0x00})
+
+	f.Fuzz(func(t *testing.T, der []byte) {
+		r, s, err := parseDERSignature(der)
+		if err != nil {
+			require.ErrorIs(t, err, ErrInvalidSignatureEncoding)
+			return
+		}
+		require.Positive(t, r.Sign())
+		require.Positive(t, s.Sign())
+
+		// DER is canonical: only the encoding of r and s is accepted
+		var b cryptobyte.Builder
+		b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1BigInt(r)
+			b.AddASN1BigInt(s)
+		})
+		require.Equal(t, der, b.BytesOrPanic())
+	})
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var (
+	ErrNotEd25519Key = errors.New("key is not an ed25519 key")
+
+	_ PublicKeySigner = (*ed25519Signer)(nil)
+	_ SchemeSigner    = (*ed25519Signer)(nil)
+)
+
+// Ed25519Address returns the address of an ed25519 public key under
+// SchemeEd25519: the hash of its raw encoding, derived as for secp256k1 keys
+func Ed25519Address(pub ed25519.PublicKey) ids.ShortID {
+	return publicKeyAddress(pub)
+}
+
+// ed25519Signer signs with an ed25519 key behind a crypto.Signer
+type ed25519Signer struct {
+	signer crypto.Signer
+	pubKey ed25519.PublicKey
+	addr   ids.ShortID
+}
+
+// NewEd25519Signer returns a signer of the ed25519 key of [signer], which may
+// be an ed25519.PrivateKey or the signer of an HSM or KMS. Sign signs the
+// message itself, as ed25519 hashes internally, and SignHash signs the given
+// hash as a message. Signatures verify under SchemeEd25519.
+func NewEd25519Signer(signer crypto.Signer) (Signer, error) {
+	pub, ok := signer.Public().(ed25519.PublicKey)
+	if !ok || len(pub) != ed25519.PublicKeySize {
+		return nil, ErrNotEd25519Key
+	}
+	return &ed25519Signer{
+		signer: signer,
+		pubKey: pub,
+		addr:   Ed25519Address(pub),
+	}, nil
+}
+
+func (e *ed25519Signer) SignHash(hash []byte) ([]byte, error) {
+	if len(hash) != sha256.Size {
+		return nil, ErrInvalidHashLen
+	}
+	return e.Sign(hash)
+}
+
+func (e *ed25519Signer) Sign(msg []byte) ([]byte, error) {
+	return e.signer.Sign(rand.Reader, msg, crypto.Hash(0))
+}
+
+func (e *ed25519Signer) Address() ids.ShortID {
+	return e.addr
+}
+
+func (e *ed25519Signer) PublicKey() []byte {
+	return e.pubKey
+}
+
+func (*ed25519Signer) Scheme() SchemeID {
+	return SchemeEd25519
+}
+
+// ed25519Keychain is a keychain of ed25519 signers
+type ed25519Keychain struct {
+	addrs   set.Set[ids.ShortID]
+	signers map[ids.ShortID]Signer
+}
+
+// NewEd25519Keychain creates a keychain of the ed25519 keys of [signers].
+// Keys held by several signers are only added once.
+func NewEd25519Keychain(signers ...crypto.Signer) (Keychain, error) {
+	kc := &ed25519Keychain{
+		addrs:   make(set.Set[ids.ShortID]),
+		signers: make(map[ids.ShortID]Signer),
+	}
+	for _, s := range signers {
+		signer, err := NewEd25519Signer(s)
+		if err != nil {
+			return nil, err
+		}
+		addr := signer.Address()
+		if kc.addrs.Contains(addr) {
+			continue
+		}
+		kc.addrs.Add(addr)
+		kc.signers[addr] = signer
+	}
+	return kc, nil
+}
+
+func (e *ed25519Keychain) Get(addr ids.ShortID) (Signer, bool) {
+	signer, ok := e.signers[addr]
+	return signer, ok
+}
+
+func (e *ed25519Keychain) Addresses() set.Set[ids.ShortID] {
+	return e.addrs
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEd25519Keychain(t *testing.T) {
+	require := require.New(t)
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+	_, other, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+	kc, err := NewEd25519Keychain(key, other, key)
+	require.NoError(err)
+	require.Equal(2, kc.Addresses().Len())
+
+	signer, ok := kc.Get(Ed25519Address(pub))
+	require.True(ok)
+	require.Equal(SchemeEd25519, SchemeOf(signer))
+
+	msg := []byte("validator payload")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.True(ed25519.Verify(pub, msg, sig))
+	require.NoError(VerifySignature(signer, msg, sig))
+
+	hash := sha256.Sum256(msg)
+	sig, err = signer.SignHash(hash[:])
+	require.NoError(err)
+	require.True(ed25519.Verify(pub, hash[:], sig))
+
+	_, err = signer.SignHash(msg)
+	require.ErrorIs(err, ErrInvalidHashLen)
+}
+
+func TestEd25519SignerRejectsOtherKeys(t *testing.T) {
+	_, err := NewEd25519Keychain(newP256Key(t))
+	require.ErrorIs(t, err, ErrNotEd25519Key)
+}
@@ -0,0 +1,186 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"sync"
+
+	"github.com/luxfi/ids"
+)
+
+var (
+	_ ObservableKeychain = (*SoftwareKeychain)(nil)
+	_ ObservableKeychain = (*Keystore)(nil)
+)
+
+// EventType is the kind of change reported by an Event
+type EventType uint8
+
+const (
+	// EventAdded reports an address that was added to, or reactivated in,
+	// the keychain
+	EventAdded EventType = iota + 1
+	// EventRemoved reports an address that was removed from the keychain
+	EventRemoved
+	// EventRotated reports a key that was replaced. Address is the new
+	// address and Previous the retired one.
+	EventRotated
+	// EventRetired reports a key that was retired without replacement
+	EventRetired
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventAdded:
+		return "added"
+	case EventRemoved:
+		return "removed"
+	case EventRotated:
+		return "rotated"
+	case EventRetired:
+		return "retired"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a change to the addresses of a keychain
+type Event struct {
+	Type     EventType
+	Address  ids.ShortID
+	Previous ids.ShortID
+}
+
+// ObservableKeychain is implemented by mutable keychains that report changes
+// to their addresses
+type ObservableKeychain interface {
+	Keychain
+	// Subscribe returns a channel receiving every change made after the
+	// call, in order. Events are queued for slow subscribers rather than
+	// dropped or blocking the keychain.
+	Subscribe() <-chan Event
+	// Unsubscribe stops the delivery of events to [ch] and closes it
+	Unsubscribe(ch <-chan Event)
+}
+
+// eventFeed fans events out to subscribers. The zero value is ready to use.
+type eventFeed struct {
+	lock   sync.Mutex
+	closed bool
+	subs   map[<-chan Event]*subscription
+}
+
+// subscription queues events for a single subscriber
+type subscription struct {
+	out  chan Event
+	wake chan struct{}
+	done chan struct{}
+
+	lock   sync.Mutex
+	queue  []Event
+	closed bool
+}
+
+func (f *eventFeed) subscribe() <-chan Event {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	s := &subscription{
+		out:  make(chan Event),
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	if f.closed {
+		close(s.out)
+		return s.out
+	}
+	if f.subs == nil {
+		f.subs = make(map[<-chan Event]*subscription)
+	}
+	f.subs[s.out] = s
+	go s.run()
+	return s.out
+}
+
+func (f *eventFeed) unsubscribe(ch <-chan Event) {
+	f.lock.Lock()
+	s, ok := f.subs[ch]
+	delete(f.subs, ch)
+	f.lock.Unlock()
+
+	if ok {
+		close(s.done)
+	}
+}
+
+// publish queues [events] for every subscriber without blocking
+func (f *eventFeed) publish(events ...Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, s := range f.subs {
+		s.push(events, false)
+	}
+}
+
+// close closes every subscription once its queued events are delivered.
+// Later subscriptions are closed immediately.
+func (f *eventFeed) close() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.closed = true
+	for ch, s := range f.subs {
+		s.push(nil, true)
+		delete(f.subs, ch)
+	}
+}
+
+func (s *subscription) push(events []Event, closed bool) {
+	s.lock.Lock()
+	s.queue = append(s.queue, events...)
+	s.closed = s.closed || closed
+	s.lock.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers queued events until the subscription is closed or
+// unsubscribed
+func (s *subscription) run() {
+	defer close(s.out)
+
+	for {
+		s.lock.Lock()
+		if len(s.queue) == 0 {
+			closed := s.closed
+			s.lock.Unlock()
+			if closed {
+				return
+			}
+			select {
+			case <-s.wake:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		event := s.queue[0]
+		s.queue = s.queue[1:]
+		s.lock.Unlock()
+
+		select {
+		case s.out <- event:
+		case <-s.done:
+			return
+		}
+	}
+}
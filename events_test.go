@@ -0,0 +1,114 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveEvent(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+
+	select {
+	case event, ok := <-ch:
+		require.True(t, ok, "subscription closed")
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "timed out waiting for event")
+		return Event{}
+	}
+}
+
+func TestSoftwareKeychainEvents(t *testing.T) {
+	require := require.New(t)
+
+	kc := NewSoftwareKeychain()
+	events := kc.Subscribe()
+	defer kc.Unsubscribe(events)
+
+	key, err := kc.New()
+	require.NoError(err)
+	addr := key.Address()
+	WipeKey(key)
+
+	// Adding an active key again is not a change
+	kc.Add(key)
+
+	newAddr, err := kc.Rotate(addr)
+	require.NoError(err)
+	require.NoError(kc.Retire(newAddr))
+	require.True(kc.Remove(addr))
+
+	require.Equal(Event{Type: EventAdded, Address: addr}, receiveEvent(t, events))
+	require.Equal(Event{Type: EventRotated, Address: newAddr, Previous: addr}, receiveEvent(t, events))
+	require.Equal(Event{Type: EventRetired, Address: newAddr}, receiveEvent(t, events))
+	require.Equal(Event{Type: EventRemoved, Address: addr}, receiveEvent(t, events))
+
+	require.NoError(kc.Destroy())
+	require.Equal(Event{Type: EventRemoved, Address: newAddr}, receiveEvent(t, events))
+}
+
+func TestSoftwareKeychainEventsQueued(t *testing.T) {
+	require := require.New(t)
+
+	kc := NewSoftwareKeychain()
+	events := kc.Subscribe()
+
+	// Mutations do not wait for the subscriber
+	const n = 100
+	keys := make([]*secp256k1.PrivateKey, n)
+	for i := range keys {
+		key, err := kc.New()
+		require.NoError(err)
+		keys[i] = key
+	}
+	for _, key := range keys {
+		require.Equal(Event{Type: EventAdded, Address: key.Address()}, receiveEvent(t, events))
+	}
+
+	kc.Unsubscribe(events)
+	_, ok := <-events
+	require.False(ok)
+
+	// Unsubscribing twice is a no-op
+	kc.Unsubscribe(events)
+}
+
+func TestKeystoreEvents(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	ks, err := NewKeystore(t.TempDir(), []byte("password"))
+	require.NoError(err)
+	events := ks.Subscribe()
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	_, err = ks.Store(key)
+	require.NoError(err)
+	require.Equal(Event{Type: EventAdded, Address: key.Address()}, receiveEvent(t, events))
+
+	// Closing delivers the pending events before closing the subscription
+	require.NoError(ks.Close())
+	require.Equal(Event{Type: EventRemoved, Address: key.Address()}, receiveEvent(t, events))
+	_, ok := <-events
+	require.False(ok)
+
+	_, ok = <-ks.Subscribe()
+	require.False(ok)
+}
+
+func TestEventTypeString(t *testing.T) {
+	require := require.New(t)
+
+	require.Equal("added", EventAdded.String())
+	require.Equal("removed", EventRemoved.String())
+	require.Equal("rotated", EventRotated.String())
+	require.Equal("retired", EventRetired.String())
+	require.Equal("unknown", EventType(0).String())
+}
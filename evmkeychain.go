@@ -0,0 +1,49 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"github.com/luxfi/crypto/common"
+	"github.com/luxfi/math/set"
+)
+
+var _ EVMKeychain = (*evmKeychain)(nil)
+
+// EVMKeychain is a keychain keyed by 20 byte EVM addresses, the last 20 bytes
+// of the keccak256 hash of the public key, as used by the C-chain
+type EVMKeychain interface {
+	// Get returns the signer of the key whose EVM address is [addr]
+	Get(addr common.Address) (Signer, bool)
+	// Addresses returns the EVM address of every key
+	Addresses() set.Set[common.Address]
+}
+
+// evmKeychain looks up the keys of a CrossChainKeychain by EVM address
+type evmKeychain struct {
+	kc CrossChainKeychain
+}
+
+// NewEVMKeychain returns a view of [kc] keyed by EVM address, so that C-chain
+// tooling can look up signers without converting through ids.ShortID. If [kc]
+// is not a CrossChainKeychain it is indexed with NewCrossChainKeychain, which
+// requires every signer to expose its public key.
+func NewEVMKeychain(kc Keychain) (EVMKeychain, error) {
+	ckc, ok := kc.(CrossChainKeychain)
+	if !ok {
+		var err error
+		ckc, err = NewCrossChainKeychain(kc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &evmKeychain{kc: ckc}, nil
+}
+
+func (e *evmKeychain) Get(addr common.Address) (Signer, bool) {
+	return e.kc.GetEVM(addr)
+}
+
+func (e *evmKeychain) Addresses() set.Set[common.Address] {
+	return e.kc.EVMAddresses()
+}
@@ -0,0 +1,48 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/common"
+)
+
+func TestEVMKeychain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("evm"), 2)
+	require.NoError(err)
+
+	evm, err := NewEVMKeychain(kc)
+	require.NoError(err)
+	require.Equal(2, evm.Addresses().Len())
+
+	for _, key := range kc.Keys() {
+		evmAddr, err := EVMAddress(key.PublicKey().Bytes())
+		require.NoError(err)
+		require.True(evm.Addresses().Contains(evmAddr))
+
+		signer, ok := evm.Get(evmAddr)
+		require.True(ok)
+		require.Equal(key.Address(), signer.Address())
+	}
+
+	_, ok := evm.Get(common.Address{})
+	require.False(ok)
+
+	// An indexed keychain is not indexed again
+	c, err := NewCrossChainKeychain(kc)
+	require.NoError(err)
+	evm, err = NewEVMKeychain(c)
+	require.NoError(err)
+	require.Equal(c.EVMAddresses(), evm.Addresses())
+
+	ledgerKC, err := NewLedgerKeychain(newMockLedger(), []uint32{1})
+	require.NoError(err)
+	_, err = NewEVMKeychain(ledgerKC)
+	require.ErrorIs(err, ErrPublicKeyUnavailable)
+}
@@ -0,0 +1,183 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/luxfi/crypto/common"
+	"github.com/luxfi/crypto/hash"
+	"github.com/luxfi/geth"
+	"github.com/luxfi/geth/accounts"
+	gethcommon "github.com/luxfi/geth/common"
+	"github.com/luxfi/geth/core/types"
+	"github.com/luxfi/geth/event"
+)
+
+// EVMWalletScheme is the URL scheme of the wallets returned by NewEVMWallet
+const EVMWalletScheme = "keychain"
+
+var (
+	_ accounts.Wallet  = (*evmWallet)(nil)
+	_ accounts.Backend = (*evmBackend)(nil)
+)
+
+// evmWallet exposes the keys of a keychain as go-ethereum accounts
+type evmWallet struct {
+	url      accounts.URL
+	kc       EVMKeychain
+	accounts []accounts.Account
+}
+
+// NewEVMWallet returns a go-ethereum wallet signing with the keys of [kc], so
+// that EVM tooling built on accounts.Wallet can use ledger, KMS or software
+// keys of this package. [name] is the path of the wallet URL, which has the
+// EVMWalletScheme scheme. Every signer of [kc] must be a secp256k1 signer
+// exposing its public key, as required by NewEVMKeychain.
+//
+// EVM transactions and messages are signed by their Keccak-256 hash, which
+// a ledger cannot display, so every signature of the wallet is a SignHash.
+// Ledger and hardware wallet keychains must be created WithBlindSigning, and
+// the Lux app must have blind signing enabled in its settings, or signing
+// fails with ErrBlindSigningDisabled.
+//
+// The wallet has no passphrase and cannot derive new keys: Open and Close do
+// nothing, passphrases are ignored and Derive returns
+// accounts.ErrNotSupported.
+func NewEVMWallet(kc Keychain, name string) (accounts.Wallet, error) {
+	evm, err := NewEVMKeychain(kc)
+	if err != nil {
+		return nil, err
+	}
+	w := &evmWallet{
+		url: accounts.URL{Scheme: EVMWalletScheme, Path: name},
+		kc:  evm,
+	}
+	for addr := range evm.Addresses() {
+		w.accounts = append(w.accounts, accounts.Account{
+			Address: gethcommon.Address(addr),
+			URL:     w.url,
+		})
+	}
+	slices.SortFunc(w.accounts, func(a, b accounts.Account) int {
+		return bytes.Compare(a.Address[:], b.Address[:])
+	})
+	return w, nil
+}
+
+func (w *evmWallet) URL() accounts.URL {
+	return w.url
+}
+
+func (*evmWallet) Status() (string, error) {
+	return "ok", nil
+}
+
+func (*evmWallet) Open(string) error {
+	return nil
+}
+
+func (*evmWallet) Close() error {
+	return nil
+}
+
+func (w *evmWallet) Accounts() []accounts.Account {
+	return slices.Clone(w.accounts)
+}
+
+func (w *evmWallet) Contains(account accounts.Account) bool {
+	_, ok := w.signer(account)
+	return ok
+}
+
+func (*evmWallet) Derive(accounts.DerivationPath, bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+func (*evmWallet) SelfDerive([]accounts.DerivationPath, ethereum.ChainStateReader) {}
+
+// SignData signs the keccak256 hash of [data], whatever its mime type, as
+// the go-ethereum keystore does
+func (w *evmWallet) SignData(account accounts.Account, _ string, data []byte) ([]byte, error) {
+	return w.signHash(account, hash.ComputeKeccak256(data))
+}
+
+func (w *evmWallet) SignDataWithPassphrase(account accounts.Account, _, mimeType string, data []byte) ([]byte, error) {
+	return w.SignData(account, mimeType, data)
+}
+
+// SignText signs the EIP-191 personal message hash of [text]. As with the
+// go-ethereum keystore, the recovery id of the signature is 0 or 1.
+func (w *evmWallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	return w.signHash(account, accounts.TextHash(text))
+}
+
+func (w *evmWallet) SignTextWithPassphrase(account accounts.Account, _ string, text []byte) ([]byte, error) {
+	return w.SignText(account, text)
+}
+
+// SignTx signs [tx] for [chainID] with the latest signer of the chain
+func (w *evmWallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.LatestSignerForChainID(chainID)
+	txHash := signer.Hash(tx)
+	sig, err := w.signHash(account, txHash[:])
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(signer, sig)
+}
+
+func (w *evmWallet) SignTxWithPassphrase(account accounts.Account, _ string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return w.SignTx(account, tx, chainID)
+}
+
+// signHash returns the 65 byte [r || s || v] signature of [hash] by the key
+// of [account]
+func (w *evmWallet) signHash(account accounts.Account, hash []byte) ([]byte, error) {
+	signer, ok := w.signer(account)
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	sig, err := signer.SignHash(hash)
+	if errors.Is(err, ErrBlindSigningDisabled) {
+		return nil, fmt.Errorf("%w: EVM wallets sign hashes, which ledger keychains only sign WithBlindSigning and with blind signing enabled in the Lux app", err)
+	}
+	return sig, err
+}
+
+// signer returns the signer of [account], which must not be bound to the URL
+// of another wallet
+func (w *evmWallet) signer(account accounts.Account) (Signer, bool) {
+	if account.URL != (accounts.URL{}) && account.URL != w.url {
+		return nil, false
+	}
+	return w.kc.Get(common.Address(account.Address))
+}
+
+// evmBackend is a fixed set of wallets
+type evmBackend struct {
+	wallets []accounts.Wallet
+	feed    event.Feed
+}
+
+// NewEVMBackend returns a go-ethereum backend serving [wallets], which can be
+// registered with an accounts.Manager. The set of wallets is fixed, so
+// subscribers never receive wallet events.
+func NewEVMBackend(wallets ...accounts.Wallet) accounts.Backend {
+	return &evmBackend{
+		wallets: slices.Clone(wallets),
+	}
+}
+
+func (b *evmBackend) Wallets() []accounts.Wallet {
+	return slices.Clone(b.wallets)
+}
+
+func (b *evmBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return b.feed.Subscribe(sink)
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/geth/accounts"
+	gethcommon "github.com/luxfi/geth/common"
+	"github.com/luxfi/geth/core/types"
+)
+
+func TestEVMWallet(t *testing.T) {
+	require := require.New(t)
+
+	kc := NewSoftwareKeychain()
+	key, err := kc.New()
+	require.NoError(err)
+	_, err = kc.New()
+	require.NoError(err)
+
+	wallet, err := NewEVMWallet(kc, "hot")
+	require.NoError(err)
+	require.Equal("keychain://hot", wallet.URL().String())
+	require.NoError(wallet.Open(""))
+	require.Len(wallet.Accounts(), 2)
+
+	evmAddr, err := EVMAddress(key.PublicKey().Bytes())
+	require.NoError(err)
+	account := accounts.Account{Address: gethcommon.Address(evmAddr)}
+	require.True(wallet.Contains(account))
+	require.False(wallet.Contains(accounts.Account{
+		Address: account.Address,
+		URL:     accounts.URL{Scheme: EVMWalletScheme, Path: "cold"},
+	}))
+
+	chainID := big.NewInt(96369)
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(25),
+		Gas:       21000,
+		To:        &gethcommon.Address{1},
+		Value:     big.NewInt(1000),
+	})
+	signed, err := wallet.SignTx(account, tx, chainID)
+	require.NoError(err)
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	require.NoError(err)
+	require.Equal(account.Address, sender)
+
+	text := []byte("sign in to the bridge")
+	sig, err := wallet.SignTextWithPassphrase(account, "ignored", text)
+	require.NoError(err)
+	pubKey, err := secp256k1.RecoverPublicKeyFromHash(accounts.TextHash(text), sig)
+	require.NoError(err)
+	require.Equal(key.Address(), pubKey.Address())
+
+	unknown := accounts.Account{Address: gethcommon.Address{2}}
+	_, err = wallet.SignTx(unknown, tx, chainID)
+	require.ErrorIs(err, accounts.ErrUnknownAccount)
+	_, err = wallet.SignData(unknown, accounts.MimetypeTextPlain, text)
+	require.ErrorIs(err, accounts.ErrUnknownAccount)
+	_, err = wallet.Derive(accounts.DefaultBaseDerivationPath, false)
+	require.ErrorIs(err, accounts.ErrNotSupported)
+}
+
+func TestEVMWalletLedger(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	device := NewLedgerDevice(app)
+	key, err := app.key(AddressPath(0))
+	require.NoError(err)
+	evmAddr, err := EVMAddress(key.PublicKey().Bytes())
+	require.NoError(err)
+	account := accounts.Account{Address: gethcommon.Address(evmAddr)}
+	text := []byte("sign in to the bridge")
+
+	// Ledger keychains refuse the hashes signed by the wallet by default
+	kc, err := NewLedgerKeychain(device, []uint32{0})
+	require.NoError(err)
+	wallet, err := NewEVMWallet(kc, "ledger")
+	require.NoError(err)
+	require.True(wallet.Contains(account))
+	_, err = wallet.SignText(account, text)
+	require.ErrorIs(err, ErrBlindSigningDisabled)
+
+	kc, err = NewLedgerKeychain(device, []uint32{0}, WithBlindSigning())
+	require.NoError(err)
+	wallet, err = NewEVMWallet(kc, "ledger")
+	require.NoError(err)
+	sig, err := wallet.SignText(account, text)
+	require.NoError(err)
+	pubKey, err := secp256k1.RecoverPublicKeyFromHash(accounts.TextHash(text), sig)
+	require.NoError(err)
+	require.Equal(key.Address(), pubKey.Address())
+
+	chainID := big.NewInt(96369)
+	signed, err := wallet.SignTx(account, types.NewTx(&types.LegacyTx{
+		Nonce:    1,
+		GasPrice: big.NewInt(25),
+		Gas:      21000,
+		To:       &gethcommon.Address{1},
+	}), chainID)
+	require.NoError(err)
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	require.NoError(err)
+	require.Equal(account.Address, sender)
+}
+
+func TestEVMBackend(t *testing.T) {
+	require := require.New(t)
+
+	kc := NewSoftwareKeychain()
+	key, err := kc.New()
+	require.NoError(err)
+
+	wallet, err := NewEVMWallet(kc, "hot")
+	require.NoError(err)
+	manager := accounts.NewManager(nil, NewEVMBackend(wallet))
+	defer manager.Close()
+
+	evmAddr, err := EVMAddress(key.PublicKey().Bytes())
+	require.NoError(err)
+	found, err := manager.Find(accounts.Account{Address: gethcommon.Address(evmAddr)})
+	require.NoError(err)
+	require.Equal(wallet.URL(), found.URL())
+	require.Equal([]gethcommon.Address{gethcommon.Address(evmAddr)}, manager.Accounts())
+}
+
+func TestEVMWalletPublicKeyUnavailable(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewLedgerKeychain(newMockLedger(), []uint32{1})
+	require.NoError(err)
+
+	_, err = NewEVMWallet(kc, "ledger")
+	require.ErrorIs(err, ErrPublicKeyUnavailable)
+}
@@ -0,0 +1,141 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/luxfi/ids"
+)
+
+// File names of the staking files read by a node from its staking directory
+const (
+	StakingKeyFileName       = "staker.key"
+	StakingCertFileName      = "staker.crt"
+	StakingSignerKeyFileName = "signer.key"
+)
+
+var ErrInvalidStakingCert = errors.New("invalid staking certificate")
+
+// stakingCertValidity is how long the generated staking certificates are
+// valid for. Nodes identify themselves with the certificate for as long as
+// they validate, so it does not expire in practice.
+const stakingCertValidity = 100 * 365 * 24 * time.Hour
+
+// ExportPrivateKey returns the private key of [addr] in the PrivateKey-<cb58>
+// format of Lux wallets and node configs. Addresses not held by the keychain
+// fail with ErrUnknownAddress.
+func (kc *SoftwareKeychain) ExportPrivateKey(addr ids.ShortID) (string, error) {
+	key, ok := kc.GetKey(addr)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownAddress, addr)
+	}
+	defer WipeKey(key)
+	return key.String(), nil
+}
+
+// NodeStakingFiles are the contents of the staking files of a node: the TLS
+// key and certificate its node ID is derived from, and its BLS signer key
+type NodeStakingFiles struct {
+	// Key is the PEM encoded PKCS #8 TLS private key of staker.key
+	Key []byte
+	// Cert is the PEM encoded TLS certificate of staker.crt
+	Cert []byte
+	// SignerKey is the BLS secret key of signer.key
+	SignerKey []byte
+}
+
+// NewNodeStakingFiles returns the staking files of a node whose BLS key is
+// [key], such as one of the StakingKeys of a Bundle, together with a newly
+// generated ECDSA P-256 TLS identity
+func NewNodeStakingFiles(key *StakingKey) (*NodeStakingFiles, error) {
+	tlsKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(tlsKey)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(keyDER)
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(0),
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(stakingCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, tlsKey.Public(), tlsKey)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeStakingFiles{
+		Key:       pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+		Cert:      pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		SignerKey: key.Bytes(),
+	}, nil
+}
+
+// NodeID returns the node ID derived from the staking certificate
+func (f *NodeStakingFiles) NodeID() (ids.NodeID, error) {
+	block, _ := pem.Decode(f.Cert)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return ids.EmptyNodeID, ErrInvalidStakingCert
+	}
+	return ids.NodeIDFromCert(&ids.Certificate{Raw: block.Bytes}), nil
+}
+
+// Write writes the staking files into [dir], such as the staking directory of
+// a node, readable only by the current user. Existing files are not
+// overwritten, so that the identity of a node is not replaced by mistake.
+func (f *NodeStakingFiles) Write(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	for _, file := range []struct {
+		name     string
+		contents []byte
+	}{
+		{StakingKeyFileName, f.Key},
+		{StakingCertFileName, f.Cert},
+		{StakingSignerKeyFileName, f.SignerKey},
+	} {
+		if err := writeNewFile(filepath.Join(dir, file.name), file.contents); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeNewFile writes [contents] to the file [path], readable only by the
+// current user, failing if it exists
+func writeNewFile(path string, contents []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(contents)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Wipe zeroes the private keys of the staking files
+func (f *NodeStakingFiles) Wipe() {
+	clear(f.Key)
+	clear(f.SignerKey)
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+)
+
+func TestExportPrivateKey(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("export"), 1)
+	require.NoError(err)
+	key := kc.Keys()[0]
+
+	exported, err := kc.ExportPrivateKey(key.Address())
+	require.NoError(err)
+	require.True(strings.HasPrefix(exported, secp256k1.PrivateKeyPrefix))
+
+	// Exported keys are imported back by wallets, and by ImportPrivateKey
+	imported, format, err := ImportPrivateKey(exported)
+	require.NoError(err)
+	require.Equal(KeyFormatCB58, format)
+	require.Equal(key.Bytes(), imported.Bytes())
+
+	// Exporting does not wipe the key held by the keychain
+	signer, _ := kc.Get(key.Address())
+	_, err = signer.Sign([]byte("after export"))
+	require.NoError(err)
+
+	_, err = kc.ExportPrivateKey(ids.GenerateTestShortID())
+	require.ErrorIs(err, ErrUnknownAddress)
+}
+
+func TestNodeStakingFiles(t *testing.T) {
+	require := require.New(t)
+
+	key, err := NewStakingKey()
+	require.NoError(err)
+	files, err := NewNodeStakingFiles(key)
+	require.NoError(err)
+
+	// The TLS identity loads as a node loads it
+	cert, err := tls.X509KeyPair(files.Cert, files.Key)
+	require.NoError(err)
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(err)
+	nodeID, err := files.NodeID()
+	require.NoError(err)
+	require.Equal(ids.NodeIDFromCert(&ids.Certificate{Raw: parsed.Raw}), nodeID)
+
+	dir := filepath.Join(t.TempDir(), "staking")
+	require.NoError(files.Write(dir))
+	signerKey, err := os.ReadFile(filepath.Join(dir, StakingSignerKeyFileName))
+	require.NoError(err)
+	restored, err := StakingKeyFromBytes(signerKey)
+	require.NoError(err)
+	require.Equal(key.PublicKeyBytes(), restored.PublicKeyBytes())
+	for _, name := range []string{StakingKeyFileName, StakingCertFileName, StakingSignerKeyFileName} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(err)
+		require.Equal(os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	// The identity of a node is not overwritten
+	require.ErrorIs(files.Write(dir), os.ErrExist)
+
+	_, err = (&NodeStakingFiles{Cert: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY"})}).NodeID()
+	require.ErrorIs(err, ErrInvalidStakingCert)
+
+	files.Wipe()
+	require.Equal(make([]byte, len(files.SignerKey)), files.SignerKey)
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var (
+	_ Keychain  = (*fallbackKeychain)(nil)
+	_ Signer    = (*fallbackSigner)(nil)
+	_ Unwrapper = (*fallbackSigner)(nil)
+)
+
+// IsDisconnected reports whether [err] is the failure of a backend that could
+// not be reached, such as an unplugged ledger device or a remote signer that
+// lost its backend: ErrDeviceDisconnected and ErrNotConnected
+func IsDisconnected(err error) bool {
+	return errors.Is(err, ErrDeviceDisconnected) || errors.Is(err, ErrNotConnected)
+}
+
+// FallbackPolicy configures when a fallback keychain signs with its secondary
+// keychain. Zero fields take their defaults.
+type FallbackPolicy struct {
+	// ShouldFallback reports whether a failure of the primary keychain is
+	// retried with the secondary one, IsDisconnected by default. Decisions
+	// such as ErrUserRejected should not fall back, so that a rejected
+	// request is not signed by another backend.
+	ShouldFallback func(error) bool
+	// Cooldown is how long the primary keychain is skipped after it failed
+	// with a fallback error, so that requests do not wait on a backend that
+	// just went down. If zero, every request tries the primary first.
+	Cooldown time.Duration
+	// OnFallback, if set, is called when a request of [addr] that failed
+	// with [err] on the primary keychain is sent to the secondary one
+	OnFallback func(addr ids.ShortID, err error)
+}
+
+// fallbackKeychain signs with its primary keychain, falling back to its
+// secondary one when the primary fails as its policy allows
+type fallbackKeychain struct {
+	primary, secondary Keychain
+	policy             FallbackPolicy
+	now                func() time.Time
+
+	lock sync.Mutex
+	// skipUntil is the end of the cooldown of the primary keychain
+	skipUntil time.Time
+}
+
+// NewFallbackKeychain returns a keychain signing with [primary], such as a
+// local ledger device, and transparently signing with [secondary], such as a
+// remote signer, when [primary] fails with an error [policy] falls back on.
+// Addresses owned by only one of the keychains are signed for by it alone.
+func NewFallbackKeychain(primary, secondary Keychain, policy FallbackPolicy) Keychain {
+	if policy.ShouldFallback == nil {
+		policy.ShouldFallback = IsDisconnected
+	}
+	return &fallbackKeychain{
+		primary:   primary,
+		secondary: secondary,
+		policy:    policy,
+		now:       time.Now,
+	}
+}
+
+func (f *fallbackKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	primary, inPrimary := f.primary.Get(addr)
+	secondary, inSecondary := f.secondary.Get(addr)
+	switch {
+	case !inPrimary:
+		return secondary, inSecondary
+	case !inSecondary:
+		return primary, true
+	default:
+		return &fallbackSigner{
+			keychain:  f,
+			primary:   primary,
+			secondary: secondary,
+		}, true
+	}
+}
+
+func (f *fallbackKeychain) Addresses() set.Set[ids.ShortID] {
+	return f.primary.Addresses().Union(f.secondary.Addresses())
+}
+
+// skipPrimary reports whether the primary keychain is cooling down
+func (f *fallbackKeychain) skipPrimary() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.now().Before(f.skipUntil)
+}
+
+func (f *fallbackKeychain) primaryFailed() {
+	if f.policy.Cooldown <= 0 {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.skipUntil = f.now().Add(f.policy.Cooldown)
+}
+
+type fallbackSigner struct {
+	keychain           *fallbackKeychain
+	primary, secondary Signer
+}
+
+func (f *fallbackSigner) SignHash(hash []byte) ([]byte, error) {
+	return f.sign(func(signer Signer) ([]byte, error) {
+		return signer.SignHash(hash)
+	})
+}
+
+func (f *fallbackSigner) Sign(msg []byte) ([]byte, error) {
+	return f.sign(func(signer Signer) ([]byte, error) {
+		return signer.Sign(msg)
+	})
+}
+
+// sign runs [op] with the primary signer, unless it is cooling down, and
+// with the secondary one if it fails with a fallback error. If both fail,
+// both errors are returned.
+func (f *fallbackSigner) sign(op func(Signer) ([]byte, error)) ([]byte, error) {
+	kc := f.keychain
+	if kc.skipPrimary() {
+		return op(f.secondary)
+	}
+
+	sig, err := op(f.primary)
+	if err == nil || !kc.policy.ShouldFallback(err) {
+		return sig, err
+	}
+	kc.primaryFailed()
+	if kc.policy.OnFallback != nil {
+		kc.policy.OnFallback(f.Address(), err)
+	}
+	sig, secondaryErr := op(f.secondary)
+	if secondaryErr != nil {
+		return nil, errors.Join(err, secondaryErr)
+	}
+	return sig, nil
+}
+
+func (f *fallbackSigner) Address() ids.ShortID {
+	return f.primary.Address()
+}
+
+// Unwrap returns the primary signer
+func (f *fallbackSigner) Unwrap() Signer {
+	return f.primary
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+// newUnpluggableLedger returns a ledger keychain of address index 0 of [app]
+// whose device fails every exchange while [unplugged] is set
+func newUnpluggableLedger(t *testing.T, app *fakeLuxApp, unplugged *atomic.Bool) Keychain {
+	device := NewLedgerDevice(TransportFunc(func(apdu []byte) ([]byte, error) {
+		if unplugged.Load() {
+			return nil, io.EOF
+		}
+		return app.Exchange(apdu)
+	}))
+	kc, err := NewLedgerKeychain(device, []uint32{0}, WithBlindSigning())
+	require.NoError(t, err)
+	return kc
+}
+
+func TestFallbackKeychain(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	var unplugged atomic.Bool
+	primary := newUnpluggableLedger(t, app, &unplugged)
+	key, err := DeterministicKey(app.seed, 0)
+	require.NoError(err)
+	other, err := DeterministicKey([]byte("fallback"), 0)
+	require.NoError(err)
+	secondary := NewSoftwareKeychain(key, other)
+
+	var fallbacks []error
+	kc := NewFallbackKeychain(primary, secondary, FallbackPolicy{
+		OnFallback: func(addr ids.ShortID, err error) {
+			require.Equal(key.Address(), addr)
+			fallbacks = append(fallbacks, err)
+		},
+	})
+	require.Equal(2, kc.Addresses().Len())
+
+	signer, ok := kc.Get(key.Address())
+	require.True(ok)
+	require.True(IsHardwareSigner(signer))
+
+	tx := []byte("unsigned tx")
+	_, err = signer.Sign(tx)
+	require.NoError(err)
+	signed := app.exchanges
+	require.Empty(fallbacks)
+
+	unplugged.Store(true)
+	sig, err := signer.Sign(tx)
+	require.NoError(err)
+	pubKey, err := secp256k1.RecoverPublicKey(tx, sig)
+	require.NoError(err)
+	require.Equal(key.Address(), pubKey.Address())
+	require.Len(fallbacks, 1)
+	require.ErrorIs(fallbacks[0], ErrDeviceDisconnected)
+	require.ErrorIs(fallbacks[0], io.EOF)
+	require.NoError(CheckHealth(context.Background(), kc))
+
+	// Without a cooldown, the primary is tried again once it is back
+	unplugged.Store(false)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	require.Greater(app.exchanges, signed)
+	require.Len(fallbacks, 1)
+
+	// Addresses of a single keychain are signed for by it alone
+	signer, ok = kc.Get(other.Address())
+	require.True(ok)
+	require.False(IsHardwareSigner(signer))
+}
+
+func TestFallbackKeychainPolicy(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	var unplugged atomic.Bool
+	primary := newUnpluggableLedger(t, app, &unplugged)
+	key, err := DeterministicKey(app.seed, 0)
+	require.NoError(err)
+
+	errRejected := errors.New("rejected")
+	sign := Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		if unplugged.Load() {
+			return nil, errRejected
+		}
+		return next(payload)
+	})
+	kc := NewFallbackKeychain(Wrap(primary, sign), NewSoftwareKeychain(key), FallbackPolicy{})
+	signer, _ := kc.Get(key.Address())
+
+	// Errors the policy does not fall back on are returned
+	unplugged.Store(true)
+	_, err = signer.Sign([]byte("tx"))
+	require.ErrorIs(err, errRejected)
+
+	// Once the primary fell back, it is skipped until the cooldown ends
+	now := time.Unix(0, 0)
+	kc = NewFallbackKeychain(primary, NewSoftwareKeychain(key), FallbackPolicy{
+		Cooldown: time.Minute,
+	})
+	kc.(*fallbackKeychain).now = func() time.Time {
+		return now
+	}
+	signer, _ = kc.Get(key.Address())
+	_, err = signer.Sign([]byte("tx"))
+	require.NoError(err)
+	unplugged.Store(false)
+	exchanges := app.exchanges
+	_, err = signer.Sign([]byte("tx"))
+	require.NoError(err)
+	require.Equal(exchanges, app.exchanges)
+
+	now = now.Add(time.Minute)
+	_, err = signer.Sign([]byte("tx"))
+	require.NoError(err)
+	require.Greater(app.exchanges, exchanges)
+}
+
+func TestFallbackKeychainBothFail(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	var unplugged atomic.Bool
+	primary := newUnpluggableLedger(t, app, &unplugged)
+	secondary := newUnpluggableLedger(t, app, &unplugged)
+	kc := NewFallbackKeychain(primary, secondary, FallbackPolicy{})
+	addr, _ := kc.Addresses().Peek()
+	signer, _ := kc.Get(addr)
+
+	unplugged.Store(true)
+	_, err := signer.Sign([]byte("tx"))
+	require.ErrorIs(err, ErrDeviceDisconnected)
+	require.Error(CheckHealth(context.Background(), kc))
+	require.False(IsConnected(kc))
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var _ RangeKeychain = (*filteredKeychain)(nil)
+
+// filteredKeychain exposes the subset of a keychain's addresses that is
+// part of an allowed set
+type filteredKeychain struct {
+	kc    Keychain
+	addrs set.Set[ids.ShortID]
+}
+
+// Filter returns a view of [kc] restricted to [addrs], for example to hand
+// lower layers a keychain that only holds the owners of a transaction's
+// inputs. Addresses of [addrs] that [kc] does not hold are not exposed, and
+// changes to [kc] are reflected in the view.
+func Filter(kc Keychain, addrs set.Set[ids.ShortID]) Keychain {
+	return &filteredKeychain{
+		kc:    kc,
+		addrs: set.Of(addrs.List()...),
+	}
+}
+
+func (f *filteredKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	if !f.addrs.Contains(addr) {
+		return nil, false
+	}
+	return f.kc.Get(addr)
+}
+
+func (f *filteredKeychain) Addresses() set.Set[ids.ShortID] {
+	return f.addrs.Intersection(f.kc.Addresses())
+}
+
+// Range yields the signers of Addresses, so that the retired keys that [kc]
+// returns from Get but does not list are not yielded
+func (f *filteredKeychain) Range(fn func(ids.ShortID, Signer) bool) {
+	for addr := range f.Addresses() {
+		signer, ok := f.kc.Get(addr)
+		if !ok {
+			continue
+		}
+		if !fn(addr, signer) {
+			return
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+func TestFilter(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("filter"), 3)
+	require.NoError(err)
+	keys := kc.Keys()
+	addr0, addr1, addr2 := keys[0].Address(), keys[1].Address(), keys[2].Address()
+	unknown := ids.GenerateTestShortID()
+
+	allowed := set.Of(addr0, addr1, unknown)
+	filtered := Filter(kc, allowed)
+	require.True(filtered.Addresses().Equals(set.Of(addr0, addr1)))
+
+	signer, ok := filtered.Get(addr0)
+	require.True(ok)
+	require.Equal(addr0, signer.Address())
+	_, ok = filtered.Get(addr2)
+	require.False(ok)
+	_, ok = filtered.Get(unknown)
+	require.False(ok)
+
+	seen := set.NewSet[ids.ShortID](0)
+	for addr := range Iter(filtered) {
+		seen.Add(addr)
+	}
+	require.True(seen.Equals(set.Of(addr0, addr1)))
+
+	// The allowed set is copied
+	allowed.Add(addr2)
+	_, ok = filtered.Get(addr2)
+	require.False(ok)
+
+	// Retired keys are not ranged over, as they are not listed
+	require.NoError(kc.Retire(addr0))
+	seen.Clear()
+	for addr := range Iter(filtered) {
+		seen.Add(addr)
+	}
+	require.True(seen.Equals(filtered.Addresses()))
+	require.False(seen.Contains(addr0))
+	kc.Add(keys[0])
+
+	// Changes to the underlying keychain are reflected
+	require.True(kc.Remove(addr1))
+	require.True(filtered.Addresses().Equals(set.Of(addr0)))
+	_, ok = filtered.Get(addr1)
+	require.False(ok)
+}
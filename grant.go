@@ -0,0 +1,217 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/luxfi/ids"
+)
+
+// grantTokenLen is the number of random bytes of the tokens of grants
+const grantTokenLen = 32
+
+var (
+	ErrUnknownGrant         = errors.New("unknown or already redeemed signing grant")
+	ErrGrantExpired         = errors.New("signing grant expired")
+	ErrGrantPayloadMismatch = errors.New("payload does not match the signing grant")
+	ErrInvalidGrantTTL      = errors.New("grant ttl should be greater than 0")
+	ErrUnknownSignOp        = errors.New("unknown signing operation")
+)
+
+// SigningGrant is the authorization to obtain a single signature of a payload,
+// minted by GrantIssuer.Grant
+type SigningGrant struct {
+	Address ids.ShortID
+	Op      SignOp
+	// PayloadHash is the SHA-256 hash of the payload the grant signs
+	PayloadHash [sha256.Size]byte
+	Expires     time.Time
+}
+
+// signingGrant is an outstanding grant
+type signingGrant struct {
+	SigningGrant
+	// redeeming is set while the grant is being signed, so that concurrent
+	// redemptions do not sign it twice
+	redeeming bool
+}
+
+// GrantIssuer mints signing grants over the signers of a keychain. The token
+// of a grant is given to a service, such as a delegated broadcaster, which
+// redeems it with Redeem, or RedeemGrant through a SignerServer, for the
+// signature of the payload it was granted, and of no other, without access to
+// the keychain. Grants are held in memory, so that they do not survive the
+// process that minted them.
+type GrantIssuer struct {
+	keychain Keychain
+	now      func() time.Time
+
+	lock sync.Mutex
+	// grants are the outstanding grants, by the hash of their token
+	grants map[[sha256.Size]byte]*signingGrant
+}
+
+// NewGrantIssuer returns an issuer of grants signing with the signers of
+// [keychain]
+func NewGrantIssuer(keychain Keychain) *GrantIssuer {
+	return &GrantIssuer{
+		keychain: keychain,
+		now:      time.Now,
+		grants:   make(map[[sha256.Size]byte]*signingGrant),
+	}
+}
+
+// Grant mints a grant to sign [payload] with [addr] by [op] once within
+// [ttl], and returns its token together with the grant. The payload of
+// OpSignHash grants is the hash to sign.
+func (g *GrantIssuer) Grant(addr ids.ShortID, op SignOp, payload []byte, ttl time.Duration) (string, SigningGrant, error) {
+	if ttl <= 0 {
+		return "", SigningGrant{}, ErrInvalidGrantTTL
+	}
+	if op != OpSign && op != OpSignHash {
+		return "", SigningGrant{}, fmt.Errorf("%w: %q", ErrUnknownSignOp, op)
+	}
+	if _, ok := g.keychain.Get(addr); !ok {
+		return "", SigningGrant{}, ErrUnknownAddress
+	}
+	b := make([]byte, grantTokenLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", SigningGrant{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	now := g.now()
+	g.prune(now)
+	grant := SigningGrant{
+		Address:     addr,
+		Op:          op,
+		PayloadHash: sha256.Sum256(payload),
+		Expires:     now.Add(ttl),
+	}
+	g.grants[sha256.Sum256([]byte(token))] = &signingGrant{SigningGrant: grant}
+	return token, grant, nil
+}
+
+// Redeem returns the signature of [payload] granted by [token], after which
+// the grant cannot be redeemed again. Payloads other than the granted one
+// fail with ErrGrantPayloadMismatch, and grants that were redeemed, revoked
+// or are being redeemed with ErrUnknownGrant. If signing fails, the grant can
+// be redeemed again until it expires.
+func (g *GrantIssuer) Redeem(token string, payload []byte) ([]byte, error) {
+	key := sha256.Sum256([]byte(token))
+	grant, err := g.reserve(key, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := g.sign(grant, payload)
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if err != nil {
+		g.grants[key].redeeming = false
+		return nil, err
+	}
+	delete(g.grants, key)
+	return sig, nil
+}
+
+// reserve marks the grant of [key] as being redeemed for [payload]
+func (g *GrantIssuer) reserve(key [sha256.Size]byte, payload []byte) (SigningGrant, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	grant, ok := g.grants[key]
+	switch {
+	case !ok || grant.redeeming:
+		return SigningGrant{}, ErrUnknownGrant
+	case !g.now().Before(grant.Expires):
+		delete(g.grants, key)
+		return SigningGrant{}, ErrGrantExpired
+	case sha256.Sum256(payload) != grant.PayloadHash:
+		return SigningGrant{}, ErrGrantPayloadMismatch
+	}
+	grant.redeeming = true
+	return grant.SigningGrant, nil
+}
+
+func (g *GrantIssuer) sign(grant SigningGrant, payload []byte) ([]byte, error) {
+	signer, ok := g.keychain.Get(grant.Address)
+	if !ok {
+		return nil, ErrUnknownAddress
+	}
+	if grant.Op == OpSignHash {
+		return signer.SignHash(payload)
+	}
+	return signer.Sign(payload)
+}
+
+// Revoke revokes the grant of [token], reporting whether it was outstanding.
+// A grant being redeemed is not revoked.
+func (g *GrantIssuer) Revoke(token string) bool {
+	key := sha256.Sum256([]byte(token))
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	grant, ok := g.grants[key]
+	if !ok || grant.redeeming {
+		return false
+	}
+	delete(g.grants, key)
+	return true
+}
+
+// prune removes the grants that expired by [now]. Assumes the lock is held.
+func (g *GrantIssuer) prune(now time.Time) {
+	for key, grant := range g.grants {
+		if !grant.redeeming && !now.Before(grant.Expires) {
+			delete(g.grants, key)
+		}
+	}
+}
+
+type remoteGrantParams struct {
+	Token   string `json:"token"`
+	Payload []byte `json:"payload"`
+}
+
+// RedeemGrant redeems the grant of [token] for the signature of [payload]
+// with the SignerServer at the other end of [conn], configured with
+// SignerServerConfig.Grants. No API key is needed, as the token authorizes
+// the signature. [conn] is not closed, but servers requiring API keys close
+// it once the grant is redeemed.
+func RedeemGrant(conn net.Conn, token string, payload []byte) ([]byte, error) {
+	var sig []byte
+	err := newRemoteConn(conn).roundTrip(remoteMethodRedeemGrant, remoteGrantParams{
+		Token:   token,
+		Payload: payload,
+	}, &sig)
+	return sig, err
+}
+
+// redeemGrant serves a RedeemGrant request
+func (s *SignerServer) redeemGrant(req remoteRequest) ([]byte, error) {
+	if s.grants == nil {
+		return nil, ErrUnknownRemoteMethod
+	}
+	var params remoteGrantParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, ErrInvalidRemoteRequest
+	}
+	return s.grants.Redeem(params.Token, params.Payload)
+}
@@ -0,0 +1,158 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/sha256"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+)
+
+func TestGrantIssuer(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("grant"), 1)
+	require.NoError(err)
+	addr := kc.Keys()[0].Address()
+	signer, _ := kc.Get(addr)
+	issuer := NewGrantIssuer(kc)
+
+	tx := []byte("withdrawal")
+	token, grant, err := issuer.Grant(addr, OpSign, tx, time.Minute)
+	require.NoError(err)
+	require.Equal(addr, grant.Address)
+	require.Equal(sha256.Sum256(tx), grant.PayloadHash)
+
+	// Only the granted payload is signed
+	_, err = issuer.Redeem(token, []byte("another withdrawal"))
+	require.ErrorIs(err, ErrGrantPayloadMismatch)
+	_, err = issuer.Redeem("unknown", tx)
+	require.ErrorIs(err, ErrUnknownGrant)
+
+	sig, err := issuer.Redeem(token, tx)
+	require.NoError(err)
+	require.NoError(VerifySignature(signer, tx, sig))
+
+	// Grants are redeemed once
+	_, err = issuer.Redeem(token, tx)
+	require.ErrorIs(err, ErrUnknownGrant)
+
+	hash := sha256.Sum256(tx)
+	token, _, err = issuer.Grant(addr, OpSignHash, hash[:], time.Minute)
+	require.NoError(err)
+	sig, err = issuer.Redeem(token, hash[:])
+	require.NoError(err)
+	expected, err := signer.SignHash(hash[:])
+	require.NoError(err)
+	require.Equal(expected, sig)
+}
+
+func TestGrantIssuerExpiry(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("grant expiry"), 1)
+	require.NoError(err)
+	addr := kc.Keys()[0].Address()
+	issuer := NewGrantIssuer(kc)
+	now := time.Now()
+	issuer.now = func() time.Time {
+		return now
+	}
+
+	tx := []byte("withdrawal")
+	expiring, _, err := issuer.Grant(addr, OpSign, tx, time.Minute)
+	require.NoError(err)
+	revoked, _, err := issuer.Grant(addr, OpSign, tx, time.Hour)
+	require.NoError(err)
+	require.True(issuer.Revoke(revoked))
+	require.False(issuer.Revoke(revoked))
+	_, err = issuer.Redeem(revoked, tx)
+	require.ErrorIs(err, ErrUnknownGrant)
+
+	now = now.Add(time.Minute)
+	_, err = issuer.Redeem(expiring, tx)
+	require.ErrorIs(err, ErrGrantExpired)
+	_, err = issuer.Redeem(expiring, tx)
+	require.ErrorIs(err, ErrUnknownGrant)
+
+	_, _, err = issuer.Grant(addr, OpSign, tx, 0)
+	require.ErrorIs(err, ErrInvalidGrantTTL)
+	_, _, err = issuer.Grant(addr, "export", tx, time.Minute)
+	require.ErrorIs(err, ErrUnknownSignOp)
+	_, _, err = issuer.Grant(ids.GenerateTestShortID(), OpSign, tx, time.Minute)
+	require.ErrorIs(err, ErrUnknownAddress)
+}
+
+func TestGrantIssuerSigningFailure(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("grant failure"), 1)
+	require.NoError(err)
+	addr := kc.Keys()[0].Address()
+	errSign := errors.New("device unplugged")
+	fail := true
+	issuer := NewGrantIssuer(Wrap(kc, Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		if fail {
+			return nil, errSign
+		}
+		return next(payload)
+	})))
+
+	// A grant that failed to sign can be redeemed again
+	tx := []byte("withdrawal")
+	token, _, err := issuer.Grant(addr, OpSign, tx, time.Minute)
+	require.NoError(err)
+	_, err = issuer.Redeem(token, tx)
+	require.ErrorIs(err, errSign)
+	fail = false
+	_, err = issuer.Redeem(token, tx)
+	require.NoError(err)
+}
+
+func TestRedeemGrant(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("remote grant"), 1)
+	require.NoError(err)
+	addr := kc.Keys()[0].Address()
+	signer, _ := kc.Get(addr)
+	_, keyHash, err := NewAPIKey()
+	require.NoError(err)
+	issuer := NewGrantIssuer(kc)
+	_, serverAddr := listenKeychain(t, kc, SignerServerConfig{
+		APIKeys: []APIKey{{Name: "operator", Hash: keyHash}},
+		Grants:  issuer,
+	})
+
+	tx := []byte("withdrawal")
+	token, _, err := issuer.Grant(addr, OpSign, tx, time.Minute)
+	require.NoError(err)
+
+	// The holder of the grant signs its payload without an API key
+	conn, err := net.Dial("tcp", serverAddr)
+	require.NoError(err)
+	defer conn.Close()
+	sig, err := RedeemGrant(conn, token, tx)
+	require.NoError(err)
+	require.NoError(VerifySignature(signer, tx, sig))
+
+	conn, err = net.Dial("tcp", serverAddr)
+	require.NoError(err)
+	defer conn.Close()
+	_, err = RedeemGrant(conn, token, tx)
+	require.ErrorIs(err, ErrUnknownGrant)
+
+	// The grant gives no other access to the keychain
+	conn, err = net.Dial("tcp", serverAddr)
+	require.NoError(err)
+	defer conn.Close()
+	_, err = NewRemoteKeychain(conn)
+	require.ErrorIs(err, ErrUnauthenticated)
+}
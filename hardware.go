@@ -0,0 +1,256 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// LedgerVendor is the vendor reported by the hardware wallets of ledgers
+const LedgerVendor = "Ledger"
+
+var (
+	ErrInvalidPathsLength  = errors.New("number of paths should be greater than 0")
+	ErrInvalidNumAddresses = errors.New("incorrect number of addresses")
+
+	_ HardwareWallet   = (*ledgerHardwareWallet)(nil)
+	_ AddressDisplayer = (*hardwareSigner)(nil)
+)
+
+// HardwareWalletInfo describes a hardware wallet
+type HardwareWalletInfo struct {
+	// Vendor is the maker of the device, such as LedgerVendor
+	Vendor string
+	// Model is the product name of the device, such as Nano X, or unknown
+	Model string
+	// Version is the version of the firmware or app signing for Lux, if
+	// the device reports it
+	Version string
+}
+
+// HardwareWallet is a device holding keys at BIP32 derivation paths, whatever
+// its vendor. Ledgers are adapted with NewLedgerHardwareWallet; other
+// vendors, such as Trezor, Lattice or Keystone, implement it directly and
+// are used with NewHardwareWalletKeychain.
+type HardwareWallet interface {
+	Info() (HardwareWalletInfo, error)
+	// Addresses returns the addresses of [paths] without user interaction
+	Addresses(paths []DerivationPath) ([]ids.ShortID, error)
+	// DisplayAddress shows the address of [path], formatted with [hrp], on
+	// the device for the user to confirm, and returns it
+	DisplayAddress(hrp string, path DerivationPath) (ids.ShortID, error)
+	// SignHash signs the 32 byte [hash] with the key of [path]
+	SignHash(hash []byte, path DerivationPath) ([]byte, error)
+	// SignTransaction signs the unsigned transaction [unsignedTx] with the
+	// keys of [paths], returning the signatures in the order of the paths
+	SignTransaction(unsignedTx []byte, paths []DerivationPath) ([][]byte, error)
+	Close() error
+}
+
+// ledgerHardwareWallet adapts a Ledger, which signs with the Lux address
+// indices of AddressPath, to HardwareWallet
+type ledgerHardwareWallet struct {
+	ledger Ledger
+}
+
+// NewLedgerHardwareWallet returns [ledger] as a HardwareWallet. Only Lux
+// address paths, as returned by AddressPath, are supported; other paths fail
+// with ErrUnsupportedDerivationPath.
+func NewLedgerHardwareWallet(ledger Ledger) HardwareWallet {
+	return &ledgerHardwareWallet{ledger: ledger}
+}
+
+func (l *ledgerHardwareWallet) Info() (HardwareWalletInfo, error) {
+	info := HardwareWalletInfo{
+		Vendor: LedgerVendor,
+		Model:  LedgerModelOf(l.ledger).String(),
+	}
+	version, err := LedgerVersionOf(l.ledger)
+	switch {
+	case err == nil:
+		info.Version = version.String()
+	case !errors.Is(err, ErrLedgerVersionUnavailable):
+		return HardwareWalletInfo{}, err
+	}
+	return info, nil
+}
+
+func (l *ledgerHardwareWallet) Addresses(paths []DerivationPath) ([]ids.ShortID, error) {
+	indices, err := addressIndices(paths)
+	if err != nil {
+		return nil, err
+	}
+	return l.ledger.GetAddresses(indices)
+}
+
+func (l *ledgerHardwareWallet) DisplayAddress(hrp string, path DerivationPath) (ids.ShortID, error) {
+	idx, err := path.AddressIndex()
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	return l.ledger.Address(cmp.Or(hrp, MainnetHRP), idx)
+}
+
+func (l *ledgerHardwareWallet) SignHash(hash []byte, path DerivationPath) ([]byte, error) {
+	idx, err := path.AddressIndex()
+	if err != nil {
+		return nil, err
+	}
+	return l.ledger.SignHash(hash, idx)
+}
+
+func (l *ledgerHardwareWallet) SignTransaction(unsignedTx []byte, paths []DerivationPath) ([][]byte, error) {
+	indices, err := addressIndices(paths)
+	if err != nil {
+		return nil, err
+	}
+	return l.ledger.SignTransaction(unsignedTx, indices)
+}
+
+func (l *ledgerHardwareWallet) Close() error {
+	return l.ledger.Disconnect()
+}
+
+// addressIndices returns the address indices of the Lux address paths [paths]
+func addressIndices(paths []DerivationPath) ([]uint32, error) {
+	indices := make([]uint32, len(paths))
+	for i, path := range paths {
+		var err error
+		indices[i], err = path.AddressIndex()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return indices, nil
+}
+
+// hardwareKeychain is a keychain of the keys of a hardware wallet at a finite
+// set of derivation paths
+type hardwareKeychain struct {
+	wallet     HardwareWallet
+	addrs      set.Set[ids.ShortID]
+	addrToPath map[ids.ShortID]DerivationPath
+	hrp        string
+	queue      *deviceQueue
+	logger     signLogger
+	// blindSigning permits SignHash
+	blindSigning bool
+}
+
+// hardwareSigner signs with the key of one derivation path of a hardware
+// wallet
+type hardwareSigner struct {
+	wallet HardwareWallet
+	queue  *deviceQueue
+	hrp    string
+	path   DerivationPath
+	addr   ids.ShortID
+	logger signLogger
+	// blindSigning permits SignHash
+	blindSigning bool
+}
+
+// NewHardwareWalletKeychain creates a keychain of the keys of [wallet] at
+// [paths]. The signers of the keychain wait for each other, as the signers
+// of a ledger keychain do, and are displayed with the HRP of WithHRP.
+func NewHardwareWalletKeychain(wallet HardwareWallet, paths []DerivationPath, opts ...Option) (Keychain, error) {
+	if len(paths) == 0 {
+		return nil, ErrInvalidPathsLength
+	}
+	o := newOptions(opts)
+	addresses, err := wallet.Addresses(paths)
+	if err == nil && len(addresses) != len(paths) {
+		err = fmt.Errorf("%w: expected %d but got %d", ErrInvalidNumAddresses, len(paths), len(addresses))
+	}
+	if err != nil {
+		logWarn(o.logger, "hardware wallet address derivation failed", "paths", len(paths), "error", err)
+		return nil, err
+	}
+
+	kc := &hardwareKeychain{
+		wallet:       wallet,
+		addrs:        set.NewSet[ids.ShortID](len(paths)),
+		addrToPath:   make(map[ids.ShortID]DerivationPath, len(paths)),
+		hrp:          o.hrp,
+		queue:        newDeviceQueue(o.onWait),
+		logger:       newSignLogger(o),
+		blindSigning: o.blindSigning,
+	}
+	for i, addr := range addresses {
+		kc.addrs.Add(addr)
+		kc.addrToPath[addr] = paths[i]
+		logDebug(o.logger, "derived hardware wallet address", "path", paths[i], "address", addr)
+	}
+	logDebug(o.logger, "created hardware wallet keychain", "addresses", kc.addrs.Len(), "hrp", o.hrp)
+	return kc, nil
+}
+
+func (h *hardwareKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	path, ok := h.addrToPath[addr]
+	if !ok {
+		return nil, false
+	}
+	return &hardwareSigner{
+		wallet:       h.wallet,
+		queue:        h.queue,
+		hrp:          h.hrp,
+		path:         path,
+		addr:         addr,
+		logger:       h.logger,
+		blindSigning: h.blindSigning,
+	}, true
+}
+
+func (h *hardwareKeychain) Addresses() set.Set[ids.ShortID] {
+	return h.addrs
+}
+
+// SignHash fails with ErrBlindSigningDisabled unless the keychain was
+// created with WithBlindSigning
+func (h *hardwareSigner) SignHash(hash []byte) ([]byte, error) {
+	if !h.blindSigning {
+		return nil, ErrBlindSigningDisabled
+	}
+	defer h.queue.acquire(h.addr, OpSignHash)()
+	return h.logger.sign(h.addr, OpSignHash, hash, func() ([]byte, error) {
+		return h.wallet.SignHash(hash, h.path)
+	})
+}
+
+// Sign signs [msg] as a transaction with the key of the signer
+func (h *hardwareSigner) Sign(msg []byte) ([]byte, error) {
+	defer h.queue.acquire(h.addr, OpSign)()
+	return h.logger.sign(h.addr, OpSign, msg, func() ([]byte, error) {
+		sigs, err := h.wallet.SignTransaction(msg, []DerivationPath{h.path})
+		if err != nil {
+			return nil, err
+		}
+		if len(sigs) != 1 {
+			return nil, ErrInvalidNumSignatures
+		}
+		return sigs[0], nil
+	})
+}
+
+func (h *hardwareSigner) Address() ids.ShortID {
+	return h.addr
+}
+
+// DisplayAddress shows the signer's address, formatted with the keychain's
+// HRP, on the device for the user to confirm
+func (h *hardwareSigner) DisplayAddress() error {
+	addr, err := h.wallet.DisplayAddress(cmp.Or(h.hrp, MainnetHRP), h.path)
+	if err != nil {
+		return err
+	}
+	if addr != h.addr {
+		return ErrAddressMismatch
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHardwareWallet is a HardwareWallet of another vendor, deriving a key
+// for any path
+type fakeHardwareWallet struct {
+	seed      []byte
+	displayed []string
+}
+
+func (*fakeHardwareWallet) Info() (HardwareWalletInfo, error) {
+	return HardwareWalletInfo{
+		Vendor:  "Trezor",
+		Model:   "Safe 3",
+		Version: "2.8.1",
+	}, nil
+}
+
+func (f *fakeHardwareWallet) key(path DerivationPath) (*secp256k1.PrivateKey, error) {
+	return DeterministicKey(append([]byte(path.String()), f.seed...), 0)
+}
+
+func (f *fakeHardwareWallet) Addresses(paths []DerivationPath) ([]ids.ShortID, error) {
+	addrs := make([]ids.ShortID, len(paths))
+	for i, path := range paths {
+		key, err := f.key(path)
+		if err != nil {
+			return nil, err
+		}
+		addrs[i] = key.Address()
+	}
+	return addrs, nil
+}
+
+func (f *fakeHardwareWallet) DisplayAddress(hrp string, path DerivationPath) (ids.ShortID, error) {
+	f.displayed = append(f.displayed, hrp+" "+path.String())
+	key, err := f.key(path)
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	return key.Address(), nil
+}
+
+func (f *fakeHardwareWallet) SignHash(hash []byte, path DerivationPath) ([]byte, error) {
+	key, err := f.key(path)
+	if err != nil {
+		return nil, err
+	}
+	return key.SignHash(hash)
+}
+
+func (f *fakeHardwareWallet) SignTransaction(unsignedTx []byte, paths []DerivationPath) ([][]byte, error) {
+	sigs := make([][]byte, len(paths))
+	for i, path := range paths {
+		key, err := f.key(path)
+		if err != nil {
+			return nil, err
+		}
+		sigs[i], err = key.Sign(unsignedTx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sigs, nil
+}
+
+func (*fakeHardwareWallet) Close() error {
+	return nil
+}
+
+func TestLedgerHardwareWallet(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	wallet := NewLedgerHardwareWallet(NewLedgerDevice(app))
+
+	info, err := wallet.Info()
+	require.NoError(err)
+	require.Equal(HardwareWalletInfo{
+		Vendor:  LedgerVendor,
+		Model:   LedgerModelUnknown.String(),
+		Version: "1.2.3",
+	}, info)
+
+	key, err := DeterministicKey(app.seed, 3)
+	require.NoError(err)
+	addrs, err := wallet.Addresses([]DerivationPath{AddressPath(3)})
+	require.NoError(err)
+	require.Equal([]ids.ShortID{key.Address()}, addrs)
+
+	addr, err := wallet.DisplayAddress(TestnetHRP, AddressPath(3))
+	require.NoError(err)
+	require.Equal(key.Address(), addr)
+	require.Len(app.displayed, 1)
+
+	tx := []byte("unsigned tx")
+	sigs, err := wallet.SignTransaction(tx, []DerivationPath{AddressPath(3)})
+	require.NoError(err)
+	pubKey, err := secp256k1.RecoverPublicKey(tx, sigs[0])
+	require.NoError(err)
+	require.Equal(key.Address(), pubKey.Address())
+
+	// Ledgers only sign for Lux address paths
+	otherPath, err := ParseDerivationPath("m/44'/60'/0'/0/0")
+	require.NoError(err)
+	_, err = wallet.Addresses([]DerivationPath{otherPath})
+	require.ErrorIs(err, ErrUnsupportedDerivationPath)
+	_, err = wallet.SignHash(make([]byte, 32), otherPath)
+	require.ErrorIs(err, ErrUnsupportedDerivationPath)
+
+	require.NoError(wallet.Close())
+	require.True(app.closed)
+}
+
+func TestHardwareWalletKeychain(t *testing.T) {
+	require := require.New(t)
+
+	wallet := &fakeHardwareWallet{seed: []byte("hardware")}
+	evmPath, err := ParseDerivationPath("m/44'/60'/0'/0/0")
+	require.NoError(err)
+	paths := []DerivationPath{AddressPath(0), evmPath}
+
+	_, err = NewHardwareWalletKeychain(wallet, nil)
+	require.ErrorIs(err, ErrInvalidPathsLength)
+
+	kc, err := NewHardwareWalletKeychain(wallet, paths, WithHRP(TestnetHRP), WithBlindSigning())
+	require.NoError(err)
+	addrs, err := wallet.Addresses(paths)
+	require.NoError(err)
+	require.Equal(2, kc.Addresses().Len())
+
+	for i, addr := range addrs {
+		signer, ok := kc.Get(addr)
+		require.True(ok)
+		require.Equal(addr, signer.Address())
+
+		tx := []byte("unsigned tx")
+		sig, err := signer.Sign(tx)
+		require.NoError(err)
+		pubKey, err := secp256k1.RecoverPublicKey(tx, sig)
+		require.NoError(err)
+		require.Equal(addr, pubKey.Address())
+
+		hash := make([]byte, 32)
+		sig, err = signer.SignHash(hash)
+		require.NoError(err)
+		pubKey, err = secp256k1.RecoverPublicKeyFromHash(hash, sig)
+		require.NoError(err)
+		require.Equal(addr, pubKey.Address())
+
+		require.NoError(signer.(AddressDisplayer).DisplayAddress())
+		require.Equal(TestnetHRP+" "+paths[i].String(), wallet.displayed[i])
+	}
+
+	_, ok := kc.Get(ids.GenerateTestShortID())
+	require.False(ok)
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/luxfi/crypto/secp256k1"
+)
+
+// bip32MasterKey is the HMAC key deriving the BIP32 master key from a seed
+var bip32MasterKey = []byte("Bitcoin seed")
+
+var (
+	ErrInvalidSeed     = errors.New("invalid HD seed")
+	ErrInvalidChildKey = errors.New("derived HD key is invalid")
+)
+
+// extendedKey is a BIP32 extended private key
+type extendedKey struct {
+	key       []byte
+	chainCode []byte
+}
+
+// DeriveKey derives the secp256k1 key at [path] from the BIP32 [seed], such as
+// the seed of a mnemonic. The key at AddressPath(i) of the seed of a Lux
+// wallet mnemonic is the key of its address index i.
+func DeriveKey(seed []byte, path DerivationPath) (*secp256k1.PrivateKey, error) {
+	k, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range path {
+		k, err = k.child(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return secp256k1.ToPrivateKey(k.key)
+}
+
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	// BIP32 seeds are between 128 and 512 bits
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, ErrInvalidSeed
+	}
+	mac := hmac.New(sha512.New, bip32MasterKey)
+	_, _ = mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	k := &extendedKey{
+		key:       sum[:32],
+		chainCode: sum[32:],
+	}
+	if !validScalar(new(big.Int).SetBytes(k.key)) {
+		return nil, ErrInvalidSeed
+	}
+	return k, nil
+}
+
+// child derives the private child key at [index]. Indices of
+// HardenedOffset and above derive hardened children.
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	data := make([]byte, 0, 37)
+	if index >= HardenedOffset {
+		data = append(data, 0)
+		data = append(data, k.key...)
+	} else {
+		key, err := secp256k1.ToPrivateKey(k.key)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, key.PublicKey().Bytes()...)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	_, _ = mac.Write(data)
+	sum := mac.Sum(nil)
+
+	n := secp256k1.S256().Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, ErrInvalidChildKey
+	}
+	childKey := il.Add(il, new(big.Int).SetBytes(k.key))
+	childKey.Mod(childKey, n)
+	if childKey.Sign() == 0 {
+		return nil, ErrInvalidChildKey
+	}
+	return &extendedKey{
+		key:       childKey.FillBytes(make([]byte, 32)),
+		chainCode: sum[32:],
+	}, nil
+}
+
+// validScalar returns true if [k] is a valid secp256k1 private key
+func validScalar(k *big.Int) bool {
+	return k.Sign() > 0 && k.Cmp(secp256k1.S256().Params().N) < 0
+}
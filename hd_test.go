@@ -0,0 +1,49 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/luxfi/crypto/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveKey(t *testing.T) {
+	require := require.New(t)
+
+	seed, err := MnemonicSeed(testMnemonic, "")
+	require.NoError(err)
+
+	// The first Ethereum account of the mnemonic, which exercises hardened
+	// and non-hardened derivation
+	path, err := ParseDerivationPath("m/44'/60'/0'/0/0")
+	require.NoError(err)
+	key, err := DeriveKey(seed, path)
+	require.NoError(err)
+	addr, err := EVMAddress(key.PublicKey().Bytes())
+	require.NoError(err)
+	require.Equal(common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94"), addr)
+}
+
+func TestDeriveKeyBIP32Vector(t *testing.T) {
+	require := require.New(t)
+
+	// BIP32 test vector 1, chain m/0'/1/2'/2/1000000000
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(err)
+	key, err := DeriveKey(seed, DerivationPath{HardenedOffset, 1, HardenedOffset + 2, 2, 1000000000})
+	require.NoError(err)
+	require.Equal("471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8", hex.EncodeToString(key.Bytes()))
+}
+
+func TestDeriveKeyInvalidSeed(t *testing.T) {
+	require := require.New(t)
+
+	_, err := DeriveKey(make([]byte, 15), AddressPath(0))
+	require.ErrorIs(err, ErrInvalidSeed)
+	_, err = DeriveKey(make([]byte, 65), AddressPath(0))
+	require.ErrorIs(err, ErrInvalidSeed)
+}
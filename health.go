@@ -0,0 +1,183 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrNotConnected = errors.New("signing backend is not connected")
+
+	_ HealthChecker = (*LedgerDevice)(nil)
+	_ HealthChecker = (*ledgerKeychain)(nil)
+	_ HealthChecker = (*lazyLedgerKeychain)(nil)
+	_ HealthChecker = (*timeoutLedger)(nil)
+	_ HealthChecker = (*previewLedger)(nil)
+	_ HealthChecker = (*retryLedger)(nil)
+	_ HealthChecker = (*middlewareKeychain)(nil)
+	_ HealthChecker = (*filteredKeychain)(nil)
+	_ HealthChecker = (*remoteKeychain)(nil)
+	_ HealthChecker = (*fallbackKeychain)(nil)
+)
+
+// HealthChecker is implemented by keychains and ledgers whose backend can
+// become unavailable, such as a ledger device that is unplugged or a remote
+// signer that is unreachable, so that monitoring can detect it before a user
+// transaction fails
+type HealthChecker interface {
+	// HealthCheck queries the backend and returns why it cannot sign, or nil
+	// if it responds. It returns once [ctx] is done.
+	HealthCheck(ctx context.Context) error
+	// IsConnected reports whether the backend responded to its last
+	// exchange, without contacting it
+	IsConnected() bool
+}
+
+// CheckHealth runs the health check of [backend], a keychain or ledger.
+// Backends that do not implement HealthChecker, such as software keys, are
+// always healthy.
+func CheckHealth(ctx context.Context, backend any) error {
+	if h, ok := backend.(HealthChecker); ok {
+		return h.HealthCheck(ctx)
+	}
+	return nil
+}
+
+// IsConnected reports whether [backend], a keychain or ledger, responded to
+// its last exchange. Backends that do not implement HealthChecker are always
+// connected.
+func IsConnected(backend any) bool {
+	if h, ok := backend.(HealthChecker); ok {
+		return h.IsConnected()
+	}
+	return true
+}
+
+// HealthCheck queries the version of the Lux app, which fails if the device
+// is unplugged, locked or showing another app. A device busy with another
+// exchange, such as a transaction awaiting confirmation, is not interrupted
+// and is reported healthy if it is connected. A device that does not answer
+// before [ctx] is done is reported disconnected until it does.
+func (l *LedgerDevice) HealthCheck(ctx context.Context) error {
+	if !l.exchangeLock.TryLock() {
+		if !l.IsConnected() {
+			return ErrNotConnected
+		}
+		return nil
+	}
+	disconnect := func() error {
+		l.connected.Store(false)
+		return nil
+	}
+	_, err := withContext(ctx, disconnect, func() (LedgerVersion, error) {
+		defer l.exchangeLock.Unlock()
+		return l.versionLocked()
+	})
+	return err
+}
+
+// IsConnected reports whether the transport completed the last exchange with
+// the device, whatever the device answered
+func (l *LedgerDevice) IsConnected() bool {
+	return l.connected.Load()
+}
+
+func (l *ledgerKeychain) HealthCheck(ctx context.Context) error {
+	return CheckHealth(ctx, l.ledger)
+}
+
+func (l *ledgerKeychain) IsConnected() bool {
+	return IsConnected(l.ledger)
+}
+
+func (l *lazyLedgerKeychain) HealthCheck(ctx context.Context) error {
+	return CheckHealth(ctx, l.ledger)
+}
+
+func (l *lazyLedgerKeychain) IsConnected() bool {
+	return IsConnected(l.ledger)
+}
+
+func (t *timeoutLedger) HealthCheck(ctx context.Context) error {
+	return CheckHealth(ctx, t.ledger)
+}
+
+func (t *timeoutLedger) IsConnected() bool {
+	return IsConnected(t.ledger)
+}
+
+func (p *previewLedger) HealthCheck(ctx context.Context) error {
+	return CheckHealth(ctx, p.ledger)
+}
+
+func (p *previewLedger) IsConnected() bool {
+	return IsConnected(p.ledger)
+}
+
+// HealthCheck is not retried, so that monitoring sees transient failures
+func (r *retryLedger) HealthCheck(ctx context.Context) error {
+	return CheckHealth(ctx, r.ledger)
+}
+
+func (r *retryLedger) IsConnected() bool {
+	return IsConnected(r.ledger)
+}
+
+func (m *middlewareKeychain) HealthCheck(ctx context.Context) error {
+	return CheckHealth(ctx, m.keychain)
+}
+
+func (m *middlewareKeychain) IsConnected() bool {
+	return IsConnected(m.keychain)
+}
+
+func (f *filteredKeychain) HealthCheck(ctx context.Context) error {
+	return CheckHealth(ctx, f.kc)
+}
+
+func (f *filteredKeychain) IsConnected() bool {
+	return IsConnected(f.kc)
+}
+
+// HealthCheck reports the keychain healthy as long as one of its keychains
+// is, as it can still sign. If both fail, both errors are returned.
+func (f *fallbackKeychain) HealthCheck(ctx context.Context) error {
+	primaryErr := CheckHealth(ctx, f.primary)
+	if primaryErr == nil {
+		return nil
+	}
+	if err := CheckHealth(ctx, f.secondary); err != nil {
+		return errors.Join(primaryErr, err)
+	}
+	return nil
+}
+
+func (f *fallbackKeychain) IsConnected() bool {
+	return IsConnected(f.primary) || IsConnected(f.secondary)
+}
+
+// HealthCheck runs the health check of the keychain served by the remote
+// signer, which also verifies that the server is reachable and accepts the
+// keychain's credentials. A connection that failed is redialed if the
+// keychain was created by DialRemoteKeychain. Keychains of several endpoints
+// check each of them, and are healthy as long as one of them is.
+func (r *remoteKeychain) HealthCheck(ctx context.Context) error {
+	_, err := withContext(ctx, noCancel, func() (bool, error) {
+		return true, r.endpoints.healthCheck(ctx)
+	})
+	return err
+}
+
+// IsConnected reports whether the keychain reached the server, or one of its
+// servers, the last time it dialed or sent a request to it. Connections
+// closed by WithIdleTimeout do not disconnect the keychain.
+func (r *remoteKeychain) IsConnected() bool {
+	return r.endpoints.connected()
+}
+
+func noCancel() error {
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luxfi/math/set"
+	"github.com/stretchr/testify/require"
+)
+
+var errUnplugged = errors.New("device unplugged")
+
+// pluggableTransport forwards to an app while plugged
+type pluggableTransport struct {
+	app     *fakeLuxApp
+	plugged atomic.Bool
+}
+
+func newPluggableTransport() *pluggableTransport {
+	p := &pluggableTransport{app: newFakeLuxApp()}
+	p.plugged.Store(true)
+	return p
+}
+
+func (p *pluggableTransport) Exchange(apdu []byte) ([]byte, error) {
+	if !p.plugged.Load() {
+		return nil, errUnplugged
+	}
+	return p.app.Exchange(apdu)
+}
+
+func (p *pluggableTransport) setLocked(locked bool) {
+	p.app.lock.Lock()
+	defer p.app.lock.Unlock()
+
+	p.app.locked = locked
+}
+
+func (p *pluggableTransport) Close() error {
+	return p.app.Close()
+}
+
+func TestLedgerDeviceHealthCheck(t *testing.T) {
+	require := require.New(t)
+
+	transport := newPluggableTransport()
+	device := NewLedgerDevice(transport)
+	ctx := context.Background()
+
+	require.True(device.IsConnected())
+	require.NoError(device.HealthCheck(ctx))
+
+	transport.plugged.Store(false)
+	require.ErrorIs(device.HealthCheck(ctx), errUnplugged)
+	require.False(device.IsConnected())
+
+	// A device that answers is connected, even if it cannot sign
+	transport.plugged.Store(true)
+	transport.setLocked(true)
+	require.ErrorIs(device.HealthCheck(ctx), ErrDeviceLocked)
+	require.True(device.IsConnected())
+
+	transport.setLocked(false)
+	require.NoError(device.HealthCheck(ctx))
+
+	require.NoError(device.Disconnect())
+	require.False(device.IsConnected())
+}
+
+func TestLedgerDeviceHealthCheckBusy(t *testing.T) {
+	require := require.New(t)
+
+	awaiting := newAwaitingTransport()
+	device := NewLedgerDevice(cancelingTransport{awaitingTransport: awaiting})
+
+	signed := make(chan error, 1)
+	go func() {
+		_, err := device.SignTransaction([]byte("tx"), []uint32{0})
+		signed <- err
+	}()
+	<-awaiting.waiting
+
+	// The pending confirmation is not interrupted
+	require.NoError(device.HealthCheck(context.Background()))
+	select {
+	case err := <-signed:
+		require.FailNow("signing was interrupted", err)
+	default:
+	}
+
+	require.NoError(device.Cancel())
+	require.ErrorIs(<-signed, ErrOperationCanceled)
+}
+
+func TestLedgerDeviceHealthCheckUnresponsive(t *testing.T) {
+	require := require.New(t)
+
+	release := make(chan struct{})
+	device := NewLedgerDevice(TransportFunc(func([]byte) ([]byte, error) {
+		<-release
+		return nil, errUnplugged
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := device.HealthCheck(ctx)
+	require.ErrorIs(err, ErrOperationCanceled)
+	require.ErrorIs(err, context.DeadlineExceeded)
+	require.False(device.IsConnected())
+
+	// The device is reported disconnected while the probe is pending
+	require.ErrorIs(device.HealthCheck(context.Background()), ErrNotConnected)
+	close(release)
+}
+
+func TestCheckHealthForwarding(t *testing.T) {
+	require := require.New(t)
+
+	transport := newPluggableTransport()
+	ledger := NewRetryLedger(NewPreviewLedger(NewTimeoutLedger(NewLedgerDevice(transport), Timeouts{}), PreviewConfig{}), RetryPolicy{})
+	ledgerKC, err := NewLedgerKeychain(ledger, []uint32{0})
+	require.NoError(err)
+	lazyKC, err := NewLazyLedgerKeychain(ledger, []uint32{0})
+	require.NoError(err)
+	kc := Filter(Wrap(ledgerKC, Timeout(time.Minute)), set.Of(ledgerKC.Addresses().List()...))
+
+	ctx := context.Background()
+	for _, backend := range []any{ledger, ledgerKC, lazyKC, kc} {
+		require.NoError(CheckHealth(ctx, backend))
+		require.True(IsConnected(backend))
+	}
+
+	transport.plugged.Store(false)
+	for _, backend := range []any{ledger, ledgerKC, lazyKC, kc} {
+		require.ErrorIs(CheckHealth(ctx, backend), errUnplugged)
+		require.False(IsConnected(backend))
+	}
+}
+
+func TestCheckHealthSoftwareKeychain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("health"), 1)
+	require.NoError(err)
+	require.NoError(CheckHealth(context.Background(), kc))
+	require.True(IsConnected(kc))
+}
+
+func TestRemoteKeychainHealthCheck(t *testing.T) {
+	require := require.New(t)
+
+	transport := newPluggableTransport()
+	ledgerKC, err := NewLedgerKeychain(NewLedgerDevice(transport), []uint32{0})
+	require.NoError(err)
+	_, conn := serveKeychain(t, ledgerKC, SignerServerConfig{})
+
+	kc, err := NewRemoteKeychain(conn)
+	require.NoError(err)
+	ctx := context.Background()
+	require.True(kc.(HealthChecker).IsConnected())
+	require.NoError(CheckHealth(ctx, kc))
+
+	transport.setLocked(true)
+	require.ErrorIs(CheckHealth(ctx, kc), ErrDeviceLocked)
+	require.True(IsConnected(kc))
+
+	require.NoError(conn.Close())
+	require.Error(CheckHealth(ctx, kc))
+	require.False(IsConnected(kc))
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+const (
+	// hidPacketLen is the length of the HID reports of ledger devices
+	hidPacketLen = 64
+	// hidChannel is the channel of APDU exchanges over HID
+	hidChannel = 0x0101
+	// ledgerTagAPDU tags the frames carrying an APDU
+	ledgerTagAPDU = 0x05
+)
+
+var _ ModelTransport = (*hidTransport)(nil)
+
+// hidTransport exchanges APDUs with a ledger device over USB HID. Each APDU
+// is prefixed with its 2 byte big endian length and split into 64 byte
+// reports of channel (2) || tag (1) || sequence number (2) || data, the last
+// one padded with zeros. Responses are framed the same way.
+type hidTransport struct {
+	lock  sync.Mutex
+	dev   io.ReadWriteCloser
+	model LedgerModel
+}
+
+// NewHIDTransport speaks the ledger HID framing over [dev], the APDU
+// interface of a [model] ledger device opened with a HID library. Reads of
+// [dev] return one 64 byte input report, and writes take one report
+// prefixed with its report number, always 0 for ledger devices.
+func NewHIDTransport(dev io.ReadWriteCloser, model LedgerModel) Transport {
+	return &hidTransport{
+		dev:   dev,
+		model: model,
+	}
+}
+
+func (h *hidTransport) Exchange(apdu []byte) ([]byte, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	header := binary.BigEndian.AppendUint16(nil, hidChannel)
+	for _, frame := range ledgerFrames(header, apdu, hidPacketLen) {
+		report := make([]byte, 1+hidPacketLen)
+		copy(report[1:], frame)
+		if _, err := h.dev.Write(report); err != nil {
+			return nil, err
+		}
+	}
+	return readLedgerFrames(header, func() ([]byte, error) {
+		report := make([]byte, hidPacketLen)
+		n, err := h.dev.Read(report)
+		return report[:n], err
+	})
+}
+
+func (h *hidTransport) Close() error {
+	return h.dev.Close()
+}
+
+func (h *hidTransport) Model() LedgerModel {
+	return h.model
+}
+
+// ledgerFrames splits [apdu], prefixed with its length, into frames of at
+// most [size] bytes of header || tag || sequence number || data
+func ledgerFrames(header, apdu []byte, size int) [][]byte {
+	data := binary.BigEndian.AppendUint16(nil, uint16(len(apdu)))
+	data = append(data, apdu...)
+
+	var frames [][]byte
+	for seq := uint16(0); len(data) > 0 || seq == 0; seq++ {
+		frame := append(bytes.Clone(header), ledgerTagAPDU)
+		frame = binary.BigEndian.AppendUint16(frame, seq)
+		n := min(len(data), size-len(frame))
+		frames = append(frames, append(frame, data[:n]...))
+		data = data[n:]
+	}
+	return frames
+}
+
+// readLedgerFrames reassembles the response read frame by frame with [read].
+// Frames with another header or tag are skipped, and sequence numbers must
+// follow each other from 0.
+func readLedgerFrames(header []byte, read func() ([]byte, error)) ([]byte, error) {
+	var (
+		resp     []byte
+		expected = -1
+		prefix   = len(header) + 3
+	)
+	for seq := uint16(0); expected < 0 || len(resp) < expected; {
+		frame, err := read()
+		if err != nil {
+			return nil, err
+		}
+		if len(frame) < prefix || !bytes.HasPrefix(frame, header) || frame[len(header)] != ledgerTagAPDU {
+			continue
+		}
+		if binary.BigEndian.Uint16(frame[len(header)+1:]) != seq {
+			return nil, ErrInvalidResponse
+		}
+		data := frame[prefix:]
+		if seq == 0 {
+			if len(data) < 2 {
+				return nil, ErrInvalidResponse
+			}
+			expected = int(binary.BigEndian.Uint16(data))
+			data = data[2:]
+		}
+		resp = append(resp, data...)
+		seq++
+	}
+	return resp[:expected], nil
+}
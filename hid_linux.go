@@ -0,0 +1,96 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// usbBus is the bus type of USB devices in HID_ID
+const usbBus = 0x0003
+
+var (
+	// hidrawSysDir lists the hidraw devices and hidrawDevDir holds their
+	// device nodes
+	hidrawSysDir = "/sys/class/hidraw"
+	hidrawDevDir = "/dev"
+
+	// openHID opens the device node of a hidraw device
+	openHID = func(path string) (io.ReadWriteCloser, error) {
+		return os.OpenFile(path, os.O_RDWR, 0)
+	}
+)
+
+// enumerateHID lists the hidraw nodes of the APDU interface of the connected
+// ledger devices, as described by the uevent of each hidraw device:
+//
+//	HID_ID=0003:00002C97:00004011
+//	HID_PHYS=usb-0000:00:14.0-1/input0
+//	HID_UNIQ=0001
+//
+// Other interfaces, such as the FIDO interface, are skipped.
+func enumerateHID() ([]hidDevice, error) {
+	entries, err := os.ReadDir(hidrawSysDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []hidDevice
+	for _, entry := range entries {
+		uevent, err := os.ReadFile(filepath.Join(hidrawSysDir, entry.Name(), "device", "uevent"))
+		if err != nil {
+			continue
+		}
+		vars := parseUevent(uevent)
+		bus, vendor, product, ok := parseHIDID(vars["HID_ID"])
+		if !ok || bus != usbBus || vendor != LedgerVendorID || !strings.HasSuffix(vars["HID_PHYS"], "/input0") {
+			continue
+		}
+		devices = append(devices, hidDevice{
+			path:   filepath.Join(hidrawDevDir, entry.Name()),
+			model:  LedgerModelFromProductID(product),
+			serial: vars["HID_UNIQ"],
+		})
+	}
+	return devices, nil
+}
+
+func parseUevent(uevent []byte) map[string]string {
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(uevent))
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok {
+			vars[key] = value
+		}
+	}
+	return vars
+}
+
+// parseHIDID parses bus:vendor:product, in hex
+func parseHIDID(id string) (uint16, uint16, uint16, bool) {
+	fields := strings.Split(id, ":")
+	if len(fields) != 3 {
+		return 0, 0, 0, false
+	}
+	var values [3]uint16
+	for i, field := range fields {
+		v, err := strconv.ParseUint(field, 16, 32)
+		if err != nil || v > 0xffff {
+			return 0, 0, 0, false
+		}
+		values[i] = uint16(v)
+	}
+	return values[0], values[1], values[2], true
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHIDRaw lays out hidraw devices with the given uevents under a temporary
+// sysfs and serves the nodes returned by [open]
+func fakeHIDRaw(t *testing.T, uevents map[string]string, open func(path string) (io.ReadWriteCloser, error)) {
+	t.Helper()
+
+	sysDir := t.TempDir()
+	for name, uevent := range uevents {
+		dir := filepath.Join(sysDir, name, "device")
+		require.NoError(t, os.MkdirAll(dir, 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "uevent"), []byte(uevent), 0o600))
+	}
+
+	sys, dev, openNode := hidrawSysDir, hidrawDevDir, openHID
+	hidrawSysDir, hidrawDevDir, openHID = sysDir, "/dev", open
+	t.Cleanup(func() {
+		hidrawSysDir, hidrawDevDir, openHID = sys, dev, openNode
+	})
+}
+
+func TestListDevices(t *testing.T) {
+	require := require.New(t)
+
+	locked := newFakeHIDDevice()
+	locked.app.locked = true
+	nodes := map[string]*fakeHIDDevice{
+		"/dev/hidraw0": newFakeHIDDevice(),
+		"/dev/hidraw3": locked,
+	}
+	fakeHIDRaw(t, map[string]string{
+		"hidraw0": "HID_ID=0003:00002C97:00006011\nHID_PHYS=usb-0000:00:14.0-1/input0\nHID_UNIQ=0001\n",
+		// The FIDO interface of the same device
+		"hidraw1": "HID_ID=0003:00002C97:00006011\nHID_PHYS=usb-0000:00:14.0-1/input1\n",
+		// Another vendor
+		"hidraw2": "HID_ID=0003:0000046D:0000C52B\nHID_PHYS=usb-0000:00:14.0-2/input0\n",
+		"hidraw3": "HID_ID=0003:00002C97:00004011\nHID_PHYS=usb-0000:00:14.0-3/input0\n",
+		"hidraw4": "HID_ID=0003:00002C97:00005011\nHID_PHYS=usb-0000:00:14.0-4/input0\n",
+	}, func(path string) (io.ReadWriteCloser, error) {
+		node, ok := nodes[path]
+		if !ok {
+			return nil, fs.ErrPermission
+		}
+		return node, nil
+	})
+
+	devices, err := ListDevices()
+	require.NoError(err)
+	require.Equal([]DeviceInfo{
+		{
+			Path:   "/dev/hidraw0",
+			Model:  LedgerStax,
+			Serial: "0001",
+			App:    LedgerApp{Name: "Lux", Version: "1.2.3"},
+		},
+		{
+			Path:   "/dev/hidraw3",
+			Model:  LedgerNanoX,
+			Locked: true,
+		},
+		{
+			Path:  "/dev/hidraw4",
+			Model: LedgerNanoSPlus,
+			Err:   fs.ErrPermission,
+		},
+	}, devices)
+	require.True(nodes["/dev/hidraw0"].closed)
+
+	device, err := OpenLedgerDevice("/dev/hidraw0")
+	require.NoError(err)
+	require.Equal(LedgerStax, device.Model())
+	_, err = device.Version()
+	require.NoError(err)
+
+	_, err = OpenLedgerDevice("/dev/hidraw1")
+	require.ErrorIs(err, ErrDeviceNotFound)
+}
+
+func TestListDevicesWithoutHIDRaw(t *testing.T) {
+	require := require.New(t)
+
+	sys := hidrawSysDir
+	hidrawSysDir = filepath.Join(t.TempDir(), "missing")
+	t.Cleanup(func() {
+		hidrawSysDir = sys
+	})
+
+	devices, err := ListDevices()
+	require.NoError(err)
+	require.Empty(devices)
+}
@@ -0,0 +1,18 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build !linux
+
+package keychain
+
+import "io"
+
+// enumerateHID is not supported; USB devices must be opened with a HID
+// library and NewHIDTransport
+func enumerateHID() ([]hidDevice, error) {
+	return nil, ErrDeviceEnumerationUnsupported
+}
+
+func openHID(string) (io.ReadWriteCloser, error) {
+	return nil, ErrDeviceEnumerationUnsupported
+}
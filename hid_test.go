@@ -0,0 +1,150 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errNoReport = errors.New("no input report")
+
+// fakeHIDDevice serves a fake Lux app through the ledger HID framing
+type fakeHIDDevice struct {
+	app     *fakeLuxApp
+	pending []byte
+	reports [][]byte
+	// noise is sent before each response on another channel
+	noise  bool
+	closed bool
+}
+
+func newFakeHIDDevice() *fakeHIDDevice {
+	return &fakeHIDDevice{app: newFakeLuxApp()}
+}
+
+func (d *fakeHIDDevice) Write(report []byte) (int, error) {
+	if len(report) != 1+hidPacketLen || report[0] != 0 {
+		return 0, errMalformedAPDU
+	}
+	frame := report[1:]
+	if binary.BigEndian.Uint16(frame) != hidChannel || frame[2] != ledgerTagAPDU {
+		return 0, errMalformedAPDU
+	}
+	d.pending = append(d.pending, frame[5:]...)
+	n := int(binary.BigEndian.Uint16(d.pending))
+	if len(d.pending) < 2+n {
+		return len(report), nil
+	}
+
+	resp, err := d.app.Exchange(d.pending[2 : 2+n])
+	d.pending = nil
+	if err != nil {
+		return 0, err
+	}
+	if d.noise {
+		d.reports = append(d.reports, make([]byte, hidPacketLen))
+	}
+	header := binary.BigEndian.AppendUint16(nil, hidChannel)
+	for _, frame := range ledgerFrames(header, resp, hidPacketLen) {
+		report := make([]byte, hidPacketLen)
+		copy(report, frame)
+		d.reports = append(d.reports, report)
+	}
+	return len(report), nil
+}
+
+func (d *fakeHIDDevice) Read(report []byte) (int, error) {
+	if len(d.reports) == 0 {
+		return 0, errNoReport
+	}
+	n := copy(report, d.reports[0])
+	d.reports = d.reports[1:]
+	return n, nil
+}
+
+func (d *fakeHIDDevice) Close() error {
+	d.closed = true
+	return nil
+}
+
+func TestHIDTransport(t *testing.T) {
+	require := require.New(t)
+
+	dev := newFakeHIDDevice()
+	dev.noise = true
+	transport := NewHIDTransport(dev, LedgerNanoX)
+	device := NewLedgerDevice(transport)
+	require.Equal(LedgerNanoX, device.Model())
+
+	version, err := device.Version()
+	require.NoError(err)
+	require.Equal(LedgerVersion{Major: 1, Minor: 2, Patch: 3}, version)
+
+	// Commands and responses spanning several reports
+	key, err := DeterministicKey(dev.app.seed, 2)
+	require.NoError(err)
+	addrs, err := device.GetAddresses([]uint32{2})
+	require.NoError(err)
+	require.Equal(key.Address(), addrs[0])
+
+	tx := make([]byte, 600)
+	sigs, err := device.SignTransaction(tx, []uint32{2})
+	require.NoError(err)
+	require.True(key.PublicKey().Verify(tx, sigs[0]))
+
+	require.NoError(device.Disconnect())
+	require.True(dev.closed)
+}
+
+func TestLedgerFrames(t *testing.T) {
+	require := require.New(t)
+
+	header := binary.BigEndian.AppendUint16(nil, hidChannel)
+	apdu := make([]byte, 200)
+	for i := range apdu {
+		apdu[i] = byte(i)
+	}
+	frames := ledgerFrames(header, apdu, hidPacketLen)
+	require.Len(frames, 4)
+	for _, frame := range frames {
+		require.LessOrEqual(len(frame), hidPacketLen)
+	}
+
+	next := func(frames [][]byte) func() ([]byte, error) {
+		return func() ([]byte, error) {
+			if len(frames) == 0 {
+				return nil, errNoReport
+			}
+			frame := frames[0]
+			frames = frames[1:]
+			return frame, nil
+		}
+	}
+	resp, err := readLedgerFrames(header, next(frames))
+	require.NoError(err)
+	require.Equal(apdu, resp)
+
+	_, err = readLedgerFrames(header, next(frames[1:]))
+	require.ErrorIs(err, ErrInvalidResponse)
+	_, err = readLedgerFrames(header, next(frames[:2]))
+	require.ErrorIs(err, errNoReport)
+}
+
+func TestLedgerDeviceApp(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	device := NewLedgerDevice(app)
+	info, err := device.App()
+	require.NoError(err)
+	require.Equal(LedgerApp{Name: "Lux", Version: "1.2.3"}, info)
+
+	app.locked = true
+	_, err = device.App()
+	require.ErrorIs(err, ErrDeviceLocked)
+}
@@ -0,0 +1,120 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/base58"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+)
+
+// Version bytes of WIF encoded private keys
+const (
+	wifMainnetVersion = 0x80
+	wifTestnetVersion = 0xef
+	// wifCompressed follows the key of WIF encodings of keys whose public
+	// key is compressed
+	wifCompressed = 0x01
+
+	// maxEncodedKeyLen bounds the length of the keys ImportPrivateKey
+	// decodes, well above that of every supported encoding, as decoding
+	// base58 takes quadratic time
+	maxEncodedKeyLen = 128
+)
+
+var ErrInvalidPrivateKeyEncoding = errors.New("invalid private key encoding")
+
+// KeyFormat is an encoding of a private key recognized by ImportPrivateKey
+type KeyFormat string
+
+const (
+	// KeyFormatCB58 is the PrivateKey-<cb58> encoding of Lux wallets
+	KeyFormatCB58 KeyFormat = "cb58"
+	// KeyFormatWIF is the Wallet Import Format of Bitcoin wallets
+	KeyFormatWIF KeyFormat = "wif"
+	// KeyFormatHex is the hex encoding of the 32 byte key, optionally 0x
+	// prefixed, as exported by Ethereum wallets
+	KeyFormatHex KeyFormat = "hex"
+)
+
+// ImportPrivateKey parses a private key exported by another wallet, detecting
+// its encoding among KeyFormatCB58, KeyFormatWIF and KeyFormatHex.
+// Surrounding whitespace is ignored. WIF keys of the Bitcoin mainnet and
+// testnet are accepted whether or not they mark a compressed public key, as
+// Lux addresses are always derived from the compressed public key. Keys that
+// cannot be decoded, or that are not valid secp256k1 keys, fail with
+// ErrInvalidPrivateKeyEncoding.
+func ImportPrivateKey(encoded string) (*secp256k1.PrivateKey, KeyFormat, error) {
+	encoded = strings.TrimSpace(encoded)
+	if len(encoded) > maxEncodedKeyLen {
+		return nil, "", fmt.Errorf("%w: longer than %d characters", ErrInvalidPrivateKeyEncoding, maxEncodedKeyLen)
+	}
+	if strings.HasPrefix(encoded, secp256k1.PrivateKeyPrefix) {
+		key := &secp256k1.PrivateKey{}
+		if err := key.UnmarshalText([]byte(encoded)); err != nil {
+			return nil, "", fmt.Errorf("%w: %w", ErrInvalidPrivateKeyEncoding, err)
+		}
+		return key, KeyFormatCB58, nil
+	}
+
+	if b, ok := decodeHexKey(encoded); ok {
+		defer clear(b)
+		key, err := secp256k1.ToPrivateKey(b)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %w", ErrInvalidPrivateKeyEncoding, err)
+		}
+		return key, KeyFormatHex, nil
+	}
+
+	b, version, err := base58.CheckDecode(encoded)
+	if err != nil {
+		return nil, "", ErrInvalidPrivateKeyEncoding
+	}
+	defer clear(b)
+	if version != wifMainnetVersion && version != wifTestnetVersion {
+		return nil, "", fmt.Errorf("%w: WIF version 0x%02x", ErrInvalidPrivateKeyEncoding, version)
+	}
+	switch {
+	case len(b) == secp256k1.PrivateKeyLen:
+	case len(b) == secp256k1.PrivateKeyLen+1 && b[secp256k1.PrivateKeyLen] == wifCompressed:
+		b = b[:secp256k1.PrivateKeyLen]
+	default:
+		return nil, "", ErrInvalidPrivateKeyEncoding
+	}
+	key, err := secp256k1.ToPrivateKey(b)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidPrivateKeyEncoding, err)
+	}
+	return key, KeyFormatWIF, nil
+}
+
+// Import adds the private key [encoded], in any format accepted by
+// ImportPrivateKey, to the keychain and returns its address
+func (kc *SoftwareKeychain) Import(encoded string) (ids.ShortID, error) {
+	key, _, err := ImportPrivateKey(encoded)
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	defer WipeKey(key)
+
+	kc.Add(key)
+	return key.Address(), nil
+}
+
+// decodeHexKey decodes the optionally 0x prefixed hex encoding of a 32 byte
+// key
+func decodeHexKey(s string) ([]byte, bool) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s) != 2*secp256k1.PrivateKeyLen {
+		return nil, false
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
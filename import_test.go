@@ -0,0 +1,98 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportPrivateKey(t *testing.T) {
+	require := require.New(t)
+
+	// The WIF test vectors of the Bitcoin wiki
+	const keyHex = "0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d"
+	want, _, err := ImportPrivateKey(keyHex)
+	require.NoError(err)
+	require.Equal(keyHex, hex.EncodeToString(want.Bytes()))
+
+	// Hex keys may be upper case and surrounded by whitespace
+	const paddedHex = "  0C28FCA386C7A227600B2FE50B7CAE11EC86D3BF1FBE471BE89827E19D72AA1D\n"
+	for encoded, format := range map[string]KeyFormat{
+		"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ":  KeyFormatWIF,
+		"KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617": KeyFormatWIF,
+		"0x" + keyHex: KeyFormatHex,
+		paddedHex:     KeyFormatHex,
+		want.String(): KeyFormatCB58,
+	} {
+		key, detected, err := ImportPrivateKey(encoded)
+		require.NoError(err, encoded)
+		require.Equal(format, detected, encoded)
+		require.Equal(want.Address(), key.Address(), encoded)
+	}
+
+	for _, encoded := range []string{
+		"",
+		"not a key",
+		// Checksum mismatch
+		"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTK",
+		// The order of the curve is not a valid key
+		"fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141",
+		"PrivateKey-abc",
+		// Too long to be worth decoding
+		"PrivateKey-" + strings.Repeat("z", 1<<16),
+	} {
+		_, _, err := ImportPrivateKey(encoded)
+		require.ErrorIs(err, ErrInvalidPrivateKeyEncoding, encoded)
+	}
+}
+
+func TestSoftwareKeychainImport(t *testing.T) {
+	require := require.New(t)
+
+	kc := NewSoftwareKeychain()
+	addr, err := kc.Import("KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617")
+	require.NoError(err)
+	require.True(kc.Addresses().Contains(addr))
+
+	signer, ok := kc.Get(addr)
+	require.True(ok)
+	msg := []byte("imported")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.NoError(VerifySignature(signer, msg, sig))
+
+	_, err = kc.Import("not a key")
+	require.ErrorIs(err, ErrInvalidPrivateKeyEncoding)
+	require.Equal(1, kc.Addresses().Len())
+}
+
+func FuzzImportPrivateKey(f *testing.F) {
+	for _, seed := range []string{
+		"0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d",
+		"5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ",
+		"KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617",
+		"PrivateKey-ewoqjP7PxY4yr3iLTpLisriqt94hdyDFNgchSxGGztUrTXtNN",
+		"",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, encoded string) {
+		key, format, err := ImportPrivateKey(encoded)
+		if err != nil {
+			require.ErrorIs(t, err, ErrInvalidPrivateKeyEncoding)
+			return
+		}
+		require.Contains(t, []KeyFormat{KeyFormatCB58, KeyFormatWIF, KeyFormatHex}, format)
+
+		// Every imported key exports to a key that imports back
+		reimported, format, err := ImportPrivateKey(key.String())
+		require.NoError(t, err)
+		require.Equal(t, KeyFormatCB58, format)
+		require.Equal(t, key.Bytes(), reimported.Bytes())
+	})
+}
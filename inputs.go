@@ -0,0 +1,113 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/luxfi/ids"
+)
+
+// InputSignature is a signature of a transaction placed in the credential of
+// one of its inputs
+type InputSignature struct {
+	// Input is the index of the input the credential belongs to
+	Input int
+	// SigIndex is the position of the signature in the credential
+	SigIndex int
+	AddressSignature
+}
+
+// SignInputs signs [unsignedTx] for the inputs of a transaction, where
+// [inputs][i] lists the addresses whose signatures the credential of input i
+// needs, in credential order. The returned credentials keep that layout, so
+// that each signature is found by its input and position rather than by its
+// offset in a flat list of signatures.
+//
+// Every distinct address is signed for once, however many inputs it owns, and
+// its signature is reused across them. If [kc] is a ledger keychain, all the
+// addresses are signed with a single SignTransaction, so that the user
+// confirms the transaction once on the device.
+func SignInputs(kc Keychain, unsignedTx []byte, inputs [][]ids.ShortID) ([][]InputSignature, error) {
+	var addrs []ids.ShortID
+	for _, owners := range inputs {
+		for _, addr := range owners {
+			if !slices.Contains(addrs, addr) {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+
+	sigs, err := signAddresses(kc, unsignedTx, addrs)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([][]InputSignature, len(inputs))
+	for i, owners := range inputs {
+		credentials[i] = make([]InputSignature, len(owners))
+		for j, addr := range owners {
+			credentials[i][j] = InputSignature{
+				Input:    i,
+				SigIndex: j,
+				AddressSignature: AddressSignature{
+					Address:   addr,
+					Signature: slices.Clone(sigs[addr]),
+				},
+			}
+		}
+	}
+	return credentials, nil
+}
+
+// signAddresses signs [unsignedTx] with the signer of each address of [addrs]
+func signAddresses(kc Keychain, unsignedTx []byte, addrs []ids.ShortID) (map[ids.ShortID][]byte, error) {
+	sigs := make(map[ids.ShortID][]byte, len(addrs))
+	if l, ok := kc.(*ledgerKeychain); ok {
+		return sigs, l.signAddresses(unsignedTx, addrs, sigs)
+	}
+	for _, addr := range addrs {
+		signer, ok := kc.Get(addr)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAddress, addr)
+		}
+		sig, err := signer.Sign(unsignedTx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", addr, err)
+		}
+		sigs[addr] = sig
+	}
+	return sigs, nil
+}
+
+// signAddresses signs [unsignedTx] with the address indices of [addrs] in one
+// SignTransaction, recording the signatures in [sigs]
+func (l *ledgerKeychain) signAddresses(unsignedTx []byte, addrs []ids.ShortID, sigs map[ids.ShortID][]byte) error {
+	if len(addrs) == 0 {
+		return nil
+	}
+	indices := make([]uint32, len(addrs))
+	for i, addr := range addrs {
+		idx, ok := l.addrToIdx[addr]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownAddress, addr)
+		}
+		indices[i] = idx
+	}
+
+	release := l.queue.acquire(addrs[0], OpSign)
+	signed, err := l.ledger.SignTransaction(unsignedTx, indices)
+	release()
+	if err != nil {
+		return err
+	}
+	if len(signed) != len(addrs) {
+		return fmt.Errorf("%w: expected %d but got %d", ErrInvalidNumSignatures, len(addrs), len(signed))
+	}
+	for i, addr := range addrs {
+		sigs[addr] = signed[i]
+	}
+	return nil
+}
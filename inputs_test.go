@@ -0,0 +1,99 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+// txCountingLedger counts the signing calls reaching a ledger
+type txCountingLedger struct {
+	Ledger
+	signs        int
+	transactions int
+}
+
+func (c *txCountingLedger) Sign(hash []byte, addressIndex uint32) ([]byte, error) {
+	c.signs++
+	return c.Ledger.Sign(hash, addressIndex)
+}
+
+func (c *txCountingLedger) SignTransaction(rawUnsignedHash []byte, addressIndices []uint32) ([][]byte, error) {
+	c.transactions++
+	return c.Ledger.SignTransaction(rawUnsignedHash, addressIndices)
+}
+
+func requireInputSignatures(t *testing.T, tx []byte, inputs [][]ids.ShortID, credentials [][]InputSignature) {
+	require := require.New(t)
+
+	require.Len(credentials, len(inputs))
+	for i, owners := range inputs {
+		require.Len(credentials[i], len(owners))
+		for j, addr := range owners {
+			sig := credentials[i][j]
+			require.Equal(i, sig.Input)
+			require.Equal(j, sig.SigIndex)
+			require.Equal(addr, sig.Address)
+			pubKey, err := secp256k1.RecoverPublicKey(tx, sig.Signature)
+			require.NoError(err)
+			require.Equal(addr, pubKey.Address())
+		}
+	}
+}
+
+func TestSignInputs(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("inputs"), 3)
+	require.NoError(err)
+	addrs := kc.Addresses().List()
+	tx := []byte("unsigned tx")
+
+	// The first address owns every input
+	inputs := [][]ids.ShortID{
+		{addrs[0], addrs[1]},
+		{addrs[0]},
+		{addrs[2], addrs[0]},
+		{},
+	}
+	credentials, err := SignInputs(kc, tx, inputs)
+	require.NoError(err)
+	requireInputSignatures(t, tx, inputs, credentials)
+	require.Equal(credentials[0][0].Signature, credentials[2][1].Signature)
+	require.Empty(credentials[3])
+
+	_, err = SignInputs(kc, tx, [][]ids.ShortID{{ids.GenerateTestShortID()}})
+	require.ErrorIs(err, ErrUnknownAddress)
+}
+
+func TestSignInputsLedger(t *testing.T) {
+	require := require.New(t)
+
+	ledger := &txCountingLedger{Ledger: NewLedgerDevice(newFakeLuxApp())}
+	kc, err := NewLedgerKeychain(ledger, []uint32{0, 1, 2})
+	require.NoError(err)
+	addrs := kc.Addresses().List()
+	tx := []byte("unsigned tx")
+
+	inputs := [][]ids.ShortID{
+		{addrs[1]},
+		{addrs[1], addrs[2]},
+		{addrs[2]},
+	}
+	credentials, err := SignInputs(kc, tx, inputs)
+	require.NoError(err)
+	requireInputSignatures(t, tx, inputs, credentials)
+
+	// The device is asked to confirm the transaction once
+	require.Equal(1, ledger.transactions)
+	require.Zero(ledger.signs)
+
+	_, err = SignInputs(kc, tx, [][]ids.ShortID{{ids.GenerateTestShortID()}})
+	require.ErrorIs(err, ErrUnknownAddress)
+	require.Equal(1, ledger.transactions)
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"iter"
+
+	"github.com/luxfi/ids"
+)
+
+var (
+	_ RangeKeychain = (*ledgerKeychain)(nil)
+	_ RangeKeychain = (*SoftwareKeychain)(nil)
+	_ RangeKeychain = (*metadataKeychain)(nil)
+)
+
+// RangeKeychain is implemented by keychains that can walk their signers
+// directly, without first copying their address set
+type RangeKeychain interface {
+	Keychain
+	// Range calls [f] with each address and its signer until [f] returns
+	// false. The order is unspecified.
+	Range(f func(addr ids.ShortID, signer Signer) bool)
+}
+
+// Range calls [f] with each address of [kc] and its signer until [f] returns
+// false. Keychains that do not implement RangeKeychain are walked through
+// Addresses and Get.
+func Range(kc Keychain, f func(addr ids.ShortID, signer Signer) bool) {
+	if rkc, ok := kc.(RangeKeychain); ok {
+		rkc.Range(f)
+		return
+	}
+	for addr := range kc.Addresses() {
+		signer, ok := kc.Get(addr)
+		if !ok {
+			continue
+		}
+		if !f(addr, signer) {
+			return
+		}
+	}
+}
+
+// Iter returns an iterator over the addresses of [kc] and their signers
+//
+//	for addr, signer := range keychain.Iter(kc) {
+//		...
+//	}
+func Iter(kc Keychain) iter.Seq2[ids.ShortID, Signer] {
+	return func(yield func(ids.ShortID, Signer) bool) {
+		Range(kc, yield)
+	}
+}
+
+func (l *ledgerKeychain) Range(f func(ids.ShortID, Signer) bool) {
+	for addr := range l.addrToIdx {
+		signer, _ := l.Get(addr)
+		if !f(addr, signer) {
+			return
+		}
+	}
+}
+
+// Range walks the signers of the active keys. The keychain is not locked
+// while [f] runs, so [f] may modify it.
+func (kc *SoftwareKeychain) Range(f func(ids.ShortID, Signer) bool) {
+	kc.lock.RLock()
+	signers := make([]*softwareSigner, 0, kc.addrs.Len())
+	for addr := range kc.addrs {
+		signers = append(signers, &softwareSigner{
+			keychain: kc,
+			key:      kc.keys[addr],
+			addr:     addr,
+		})
+	}
+	kc.lock.RUnlock()
+
+	for _, signer := range signers {
+		if !f(signer.addr, signer) {
+			return
+		}
+	}
+}
+
+func (m *metadataKeychain) Range(f func(ids.ShortID, Signer) bool) {
+	Range(m.Keychain, f)
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// getOnlyKeychain hides the RangeKeychain implementation of a keychain
+type getOnlyKeychain struct {
+	Keychain
+}
+
+func TestRange(t *testing.T) {
+	soft, err := NewTestKeychain([]byte("range"), 4)
+	require.NoError(t, err)
+	retired := soft.Keys()[0].Address()
+	require.NoError(t, soft.Retire(retired))
+
+	lkc, err := NewLedgerKeychain(newMockLedger(), []uint32{1, 2, 3})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		kc   Keychain
+	}{
+		{
+			name: "software",
+			kc:   soft,
+		},
+		{
+			name: "ledger",
+			kc:   lkc,
+		},
+		{
+			name: "metadata",
+			kc:   NewMetadataKeychain(soft),
+		},
+		{
+			name: "fallback",
+			kc:   getOnlyKeychain{Keychain: soft},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require := require.New(t)
+
+			seen := set.NewSet[ids.ShortID](0)
+			for addr, signer := range Iter(test.kc) {
+				require.Equal(addr, signer.Address())
+				seen.Add(addr)
+			}
+			require.True(seen.Equals(test.kc.Addresses()))
+
+			var calls int
+			Range(test.kc, func(ids.ShortID, Signer) bool {
+				calls++
+				return false
+			})
+			require.Equal(1, calls)
+		})
+	}
+}
+
+func TestSoftwareKeychainRangeModify(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("range"), 3)
+	require.NoError(err)
+
+	// The keychain may be modified while it is walked
+	for addr := range Iter(kc) {
+		require.True(kc.Remove(addr))
+	}
+	require.Zero(kc.Addresses().Len())
+}
@@ -6,6 +6,7 @@
 package keychain
 
 import (
+	"cmp"
 	"errors"
 
 	"github.com/luxfi/ids"
@@ -17,6 +18,9 @@ var (
 	ErrInvalidNumAddrsToDerive = errors.New("number of addresses to derive should be greater than 0")
 	ErrInvalidNumAddrsDerived  = errors.New("incorrect number of ledger derived addresses")
 	ErrInvalidNumSignatures    = errors.New("incorrect number of signatures")
+	ErrAddressMismatch         = errors.New("ledger displayed an unexpected address")
+
+	_ AddressDisplayer = (*ledgerSigner)(nil)
 )
 
 // Signer implements functions for a keychain to return its main address and
@@ -37,6 +41,14 @@ type Keychain interface {
 	Addresses() set.Set[ids.ShortID]
 }
 
+// AddressDisplayer is implemented by signers that can show their address on
+// the signing device, so that the user can check it against the address
+// shown by the host
+type AddressDisplayer interface {
+	Signer
+	DisplayAddress() error
+}
+
 // Ledger interface for hardware wallet support
 type Ledger interface {
 	Address(displayHRP string, addressIndex uint32) (ids.ShortID, error)
@@ -50,48 +62,100 @@ type Ledger interface {
 // ledgerKeychain is an abstraction of the underlying ledger hardware device,
 // to be able to get a signer from a finite set of derived signers
 type ledgerKeychain struct {
-	ledger    Ledger
-	addrs     set.Set[ids.ShortID]
-	addrToIdx map[ids.ShortID]uint32
+	ledger       Ledger
+	addrs        set.Set[ids.ShortID]
+	addrToIdx    map[ids.ShortID]uint32
+	addrToPubKey map[ids.ShortID][]byte
+	// hrp is the HRP the keychain's addresses are displayed with
+	hrp string
+	// queue serializes the device access of the keychain's signers
+	queue *deviceQueue
+	// attestation is the verified attestation of the device, if requested
+	attestation *Attestation
+	// logger logs the operations of the keychain's signers
+	logger signLogger
+	// progress, if set, reports the progress of the keychain's signers
+	progress func(Progress)
+	// blindSigning permits SignHash
+	blindSigning bool
 }
 
 // ledgerSigner is an abstraction of the underlying ledger hardware device,
 // capable of extracting its main address and signing a hash
 type ledgerSigner struct {
 	ledger Ledger
+	queue  *deviceQueue
+	hrp    string
 	idx    uint32
 	addr   ids.ShortID
+	pubKey []byte
+	logger signLogger
+	// progress, if set, reports the progress of the signer's operations
+	progress     func(Progress)
+	blindSigning bool
 }
 
 // NewLedgerKeychain creates a new ledger keychain
 // NewLedgerKeychainFromIndices is an alias for NewLedgerKeychain
 var NewLedgerKeychainFromIndices = NewLedgerKeychain
 
-func NewLedgerKeychain(ledger Ledger, indices []uint32) (Keychain, error) {
+func NewLedgerKeychain(ledger Ledger, indices []uint32, opts ...Option) (Keychain, error) {
 	if len(indices) == 0 {
 		return nil, ErrInvalidIndicesLength
 	}
 
-	addresses, err := ledger.GetAddresses(indices)
+	o := newOptions(opts)
+	if err := o.luxPaths(); err != nil {
+		return nil, err
+	}
+	var attestation *Attestation
+	if o.issuers != nil {
+		var err error
+		attestation, err = AttestLedger(ledger, o.issuers)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := o.checkLedgerApp(ledger); err != nil {
+		return nil, err
+	}
+
+	addresses, err := deriveAddresses(ledger, indices, o.concurrency, o.batchSize, o.onProgress)
 	if err != nil {
+		logWarn(o.logger, "ledger address derivation failed", "indices", len(indices), "error", err)
 		return nil, err
 	}
 
-	if len(addresses) != len(indices) {
-		return nil, ErrInvalidNumAddrsDerived
+	pubKeys, err := derivePublicKeys(ledger, indices, o.batchSize)
+	if err != nil {
+		logWarn(o.logger, "ledger public key derivation failed", "indices", len(indices), "error", err)
+		return nil, err
 	}
 
 	addrToIdx := make(map[ids.ShortID]uint32)
+	addrToPubKey := make(map[ids.ShortID][]byte)
 	addrs := make(set.Set[ids.ShortID])
 	for i, addr := range addresses {
 		addrToIdx[addr] = indices[i]
+		if pubKeys != nil {
+			addrToPubKey[addr] = pubKeys[i]
+		}
 		addrs.Add(addr)
+		logDebug(o.logger, "derived ledger address", "index", indices[i], "address", addr)
 	}
 
+	logDebug(o.logger, "created ledger keychain", "addresses", addrs.Len(), "hrp", o.hrp)
 	return &ledgerKeychain{
-		ledger:    ledger,
-		addrs:     addrs,
-		addrToIdx: addrToIdx,
+		ledger:       ledger,
+		addrs:        addrs,
+		addrToIdx:    addrToIdx,
+		addrToPubKey: addrToPubKey,
+		hrp:          o.hrp,
+		queue:        newDeviceQueue(o.onWait),
+		attestation:  attestation,
+		logger:       newSignLogger(o),
+		progress:     o.onProgress,
+		blindSigning: o.blindSigning,
 	}, nil
 }
 
@@ -101,9 +165,15 @@ func (l *ledgerKeychain) Get(addr ids.ShortID) (Signer, bool) {
 		return nil, false
 	}
 	return &ledgerSigner{
-		ledger: l.ledger,
-		idx:    idx,
-		addr:   addr,
+		ledger:       l.ledger,
+		queue:        l.queue,
+		hrp:          l.hrp,
+		idx:          idx,
+		addr:         addr,
+		pubKey:       l.addrToPubKey[addr],
+		logger:       l.logger,
+		progress:     l.progress,
+		blindSigning: l.blindSigning,
 	}, true
 }
 
@@ -111,14 +181,71 @@ func (l *ledgerKeychain) Addresses() set.Set[ids.ShortID] {
 	return l.addrs
 }
 
+// SignHash fails with ErrBlindSigningDisabled unless the keychain was
+// created with WithBlindSigning
 func (l *ledgerSigner) SignHash(hash []byte) ([]byte, error) {
-	return l.ledger.SignHash(hash, l.idx)
+	if !l.blindSigning {
+		return nil, ErrBlindSigningDisabled
+	}
+	defer l.acquire(OpSignHash)()
+	return l.logger.sign(l.addr, OpSignHash, hash, func() ([]byte, error) {
+		if l.progress != nil {
+			l.report(OpSignHash)(Progress{
+				Stage: ProgressConfirming,
+				Index: l.idx,
+			})
+		}
+		return l.ledger.SignHash(hash, l.idx)
+	})
 }
 
 func (l *ledgerSigner) Sign(hash []byte) ([]byte, error) {
-	return l.ledger.Sign(hash, l.idx)
+	defer l.acquire(OpSign)()
+	return l.logger.sign(l.addr, OpSign, hash, func() ([]byte, error) {
+		if l.progress != nil {
+			return SignWithProgress(l.ledger, hash, l.idx, l.report(OpSign))
+		}
+		return l.ledger.Sign(hash, l.idx)
+	})
+}
+
+// report returns the function reporting the progress of [op] of the signer
+func (l *ledgerSigner) report(op SignOp) func(Progress) {
+	return func(p Progress) {
+		p.Address = l.addr
+		p.Op = op
+		l.progress(p)
+	}
+}
+
+// acquire waits for the device to be available to the signer and returns the
+// function releasing it
+func (l *ledgerSigner) acquire(op SignOp) func() {
+	if l.queue == nil {
+		return func() {}
+	}
+	return l.queue.acquire(l.addr, op)
 }
 
 func (l *ledgerSigner) Address() ids.ShortID {
 	return l.addr
 }
+
+// PublicKey returns the public key cached during keychain construction, or nil
+// if the ledger does not export public keys
+func (l *ledgerSigner) PublicKey() []byte {
+	return l.pubKey
+}
+
+// DisplayAddress shows the signer's address, formatted with the keychain's
+// HRP, on the device for the user to confirm
+func (l *ledgerSigner) DisplayAddress() error {
+	addr, err := l.ledger.Address(cmp.Or(l.hrp, MainnetHRP), l.idx)
+	if err != nil {
+		return err
+	}
+	if addr != l.addr {
+		return ErrAddressMismatch
+	}
+	return nil
+}
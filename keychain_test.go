@@ -4,6 +4,7 @@
 package keychain
 
 import (
+	"sync"
 	"testing"
 
 	"github.com/luxfi/ids"
@@ -12,6 +13,7 @@ import (
 
 // mockLedger implements Ledger interface for testing
 type mockLedger struct {
+	lock      sync.Mutex
 	addresses map[uint32]ids.ShortID
 }
 
@@ -22,6 +24,9 @@ func newMockLedger() *mockLedger {
 }
 
 func (m *mockLedger) Address(_ string, addressIndex uint32) (ids.ShortID, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
 	if addr, ok := m.addresses[addressIndex]; ok {
 		return addr, nil
 	}
@@ -111,7 +116,7 @@ func TestLedgerSignerSign(t *testing.T) {
 	require := require.New(t)
 
 	ledger := newMockLedger()
-	kc, err := NewLedgerKeychain(ledger, []uint32{0})
+	kc, err := NewLedgerKeychain(ledger, []uint32{0}, WithBlindSigning())
 	require.NoError(err)
 
 	addr0, err := ledger.Address("", 0)
@@ -130,3 +135,89 @@ func TestLedgerSignerSign(t *testing.T) {
 	require.NoError(err)
 	require.Equal([]byte("mock-signature"), sig)
 }
+
+func TestLedgerSignerBlindSigning(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	device := NewLedgerDevice(app)
+	kc, err := NewLedgerKeychain(device, []uint32{0})
+	require.NoError(err)
+	addr, _ := kc.Addresses().Peek()
+	signer, _ := kc.Get(addr)
+
+	// Hashes are refused without contacting the device
+	exchanges := app.exchanges
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrBlindSigningDisabled)
+	require.Equal(exchanges, app.exchanges)
+	_, err = signer.Sign([]byte("tx"))
+	require.NoError(err)
+
+	kc, err = NewLedgerKeychain(device, []uint32{0}, WithBlindSigning())
+	require.NoError(err)
+	signer, _ = kc.Get(addr)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+
+	// Lazy and hardware wallet keychains refuse hashes too
+	lazy, err := NewLazyLedgerKeychain(device, []uint32{0})
+	require.NoError(err)
+	signer, _ = lazy.Get(addr)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrBlindSigningDisabled)
+
+	hw, err := NewHardwareWalletKeychain(NewLedgerHardwareWallet(device), []DerivationPath{AddressPath(0)})
+	require.NoError(err)
+	signer, _ = hw.Get(addr)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrBlindSigningDisabled)
+
+	// The app refuses hashes unless blind signing is enabled in its settings
+	app.noBlindSigning = true
+	signer, _ = kc.Get(addr)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrBlindSigningDisabled)
+}
+
+func TestLedgerKeychainWithHRP(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	device := NewLedgerDevice(app)
+
+	for _, test := range []struct {
+		opts []Option
+		want string
+	}{
+		{
+			want: MainnetHRP,
+		},
+		{
+			opts: []Option{WithHRP(TestnetHRP)},
+			want: TestnetHRP,
+		},
+	} {
+		kc, err := NewLedgerKeychain(device, []uint32{0}, test.opts...)
+		require.NoError(err)
+		for _, signer := range Iter(kc) {
+			require.NoError(signer.(AddressDisplayer).DisplayAddress())
+		}
+
+		lazy, err := NewLazyLedgerKeychain(device, []uint32{1}, test.opts...)
+		require.NoError(err)
+		signer, err := lazy.SignerAt(1)
+		require.NoError(err)
+		require.NoError(signer.(AddressDisplayer).DisplayAddress())
+	}
+	require.Equal([]string{MainnetHRP, MainnetHRP, TestnetHRP, TestnetHRP}, app.displayed)
+
+	// The HRP is part of the keychain state
+	kc, err := NewLedgerKeychain(device, []uint32{0}, WithHRP(LocalHRP))
+	require.NoError(err)
+	state, err := kc.(StatefulKeychain).MarshalState()
+	require.NoError(err)
+	restored, err := NewLedgerKeychainFromState(device, state)
+	require.NoError(err)
+	require.Equal(LocalHRP, restored.(*ledgerKeychain).hrp)
+}
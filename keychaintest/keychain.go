@@ -0,0 +1,135 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychaintest
+
+import (
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+
+	"github.com/luxfi/keychain"
+)
+
+// Signer and Keychain methods, as recorded in calls and used to inject errors
+const (
+	MethodGet       = "Get"
+	MethodAddresses = "Addresses"
+)
+
+var (
+	_ keychain.Keychain        = (*Keychain)(nil)
+	_ keychain.PublicKeySigner = (*Signer)(nil)
+)
+
+// Signer is a mock keychain.Signer. By default signatures are
+// Signature(payload, 0).
+type Signer struct {
+	recorder
+
+	// Addr is the address reported by the signer
+	Addr ids.ShortID
+	// PubKey is the public key reported by the signer
+	PubKey []byte
+	// SignFunc, if set, produces the signatures returned by SignHash and
+	// Sign
+	SignFunc func(payload []byte) ([]byte, error)
+}
+
+// NewSigner returns a mock signer for [addr]
+func NewSigner(addr ids.ShortID) *Signer {
+	return &Signer{Addr: addr}
+}
+
+func (s *Signer) SignHash(hash []byte) ([]byte, error) {
+	if err := s.record(MethodSignHash, hash); err != nil {
+		return nil, err
+	}
+	return s.sign(hash)
+}
+
+func (s *Signer) Sign(msg []byte) ([]byte, error) {
+	if err := s.record(MethodSign, msg); err != nil {
+		return nil, err
+	}
+	return s.sign(msg)
+}
+
+func (s *Signer) Address() ids.ShortID {
+	return s.Addr
+}
+
+func (s *Signer) PublicKey() []byte {
+	return s.PubKey
+}
+
+func (s *Signer) sign(payload []byte) ([]byte, error) {
+	if s.SignFunc != nil {
+		return s.SignFunc(payload)
+	}
+	return Signature(payload, 0), nil
+}
+
+// Keychain is a mock keychain.Keychain holding a fixed set of signers.
+// Injecting an error for MethodGet makes Get report every address as
+// missing.
+type Keychain struct {
+	recorder
+
+	signers map[ids.ShortID]keychain.Signer
+}
+
+// NewKeychain returns a mock keychain holding [signers]
+func NewKeychain(signers ...keychain.Signer) *Keychain {
+	k := &Keychain{
+		signers: make(map[ids.ShortID]keychain.Signer),
+	}
+	for _, signer := range signers {
+		k.Add(signer)
+	}
+	return k
+}
+
+// NewKeychainWithSigners returns a mock keychain holding [n] mock signers
+// with addresses LedgerAddress(0) through LedgerAddress(n-1)
+func NewKeychainWithSigners(n int) (*Keychain, []*Signer) {
+	signers := make([]*Signer, n)
+	k := NewKeychain()
+	for i := range signers {
+		signers[i] = NewSigner(LedgerAddress(uint32(i)))
+		k.Add(signers[i])
+	}
+	return k, signers
+}
+
+// Add [signer] to the keychain
+func (k *Keychain) Add(signer keychain.Signer) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	k.signers[signer.Address()] = signer
+}
+
+func (k *Keychain) Get(addr ids.ShortID) (keychain.Signer, bool) {
+	if err := k.record(MethodGet, addr); err != nil {
+		return nil, false
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	signer, ok := k.signers[addr]
+	return signer, ok
+}
+
+func (k *Keychain) Addresses() set.Set[ids.ShortID] {
+	_ = k.record(MethodAddresses)
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	addrs := make(set.Set[ids.ShortID], len(k.signers))
+	for addr := range k.signers {
+		addrs.Add(addr)
+	}
+	return addrs
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychaintest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeychain(t *testing.T) {
+	require := require.New(t)
+
+	kc, signers := NewKeychainWithSigners(3)
+	require.Len(signers, 3)
+	require.Equal(3, kc.Addresses().Len())
+
+	signer, ok := kc.Get(LedgerAddress(2))
+	require.True(ok)
+	require.Same(signers[2], signer)
+
+	sig, err := signer.Sign([]byte("msg"))
+	require.NoError(err)
+	require.Equal(Signature([]byte("msg"), 0), sig)
+	require.Equal(1, signers[2].CallCount(MethodSign))
+
+	signers[2].Fail(MethodSign, errTest)
+	_, err = signer.Sign([]byte("msg"))
+	require.ErrorIs(err, errTest)
+
+	kc.Fail(MethodGet, errTest)
+	_, ok = kc.Get(LedgerAddress(2))
+	require.False(ok)
+	require.Equal(2, kc.CallCount(MethodGet))
+	require.Equal(1, kc.CallCount(MethodAddresses))
+}
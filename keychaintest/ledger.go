@@ -0,0 +1,178 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychaintest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/luxfi/ids"
+
+	"github.com/luxfi/keychain"
+)
+
+// Ledger methods, as recorded in calls and used to inject errors
+const (
+	MethodAddress         = "Address"
+	MethodGetAddresses    = "GetAddresses"
+	MethodSignHash        = "SignHash"
+	MethodSign            = "Sign"
+	MethodSignTransaction = "SignTransaction"
+	MethodDisconnect      = "Disconnect"
+	MethodGetPublicKeys   = "GetPublicKeys"
+)
+
+var (
+	_ keychain.Ledger          = (*Ledger)(nil)
+	_ keychain.PublicKeyLedger = (*PublicKeyLedger)(nil)
+)
+
+// Ledger is a mock keychain.Ledger. By default the address of each index is
+// LedgerAddress(index) and signatures are Signature(payload, index).
+type Ledger struct {
+	recorder
+
+	// SignFunc, if set, produces the signatures returned by SignHash, Sign
+	// and SignTransaction
+	SignFunc func(payload []byte, addressIndex uint32) ([]byte, error)
+
+	addresses map[uint32]ids.ShortID
+}
+
+// NewLedger returns a mock ledger with the default behavior
+func NewLedger() *Ledger {
+	return &Ledger{
+		addresses: make(map[uint32]ids.ShortID),
+	}
+}
+
+// SetAddress overrides the address derived for [addressIndex]
+func (l *Ledger) SetAddress(addressIndex uint32, addr ids.ShortID) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.addresses[addressIndex] = addr
+}
+
+func (l *Ledger) Address(displayHRP string, addressIndex uint32) (ids.ShortID, error) {
+	if err := l.record(MethodAddress, displayHRP, addressIndex); err != nil {
+		return ids.ShortEmpty, err
+	}
+	return l.address(addressIndex), nil
+}
+
+func (l *Ledger) GetAddresses(addressIndices []uint32) ([]ids.ShortID, error) {
+	if err := l.record(MethodGetAddresses, addressIndices); err != nil {
+		return nil, err
+	}
+	addrs := make([]ids.ShortID, len(addressIndices))
+	for i, idx := range addressIndices {
+		addrs[i] = l.address(idx)
+	}
+	return addrs, nil
+}
+
+func (l *Ledger) SignHash(hash []byte, addressIndex uint32) ([]byte, error) {
+	if err := l.record(MethodSignHash, hash, addressIndex); err != nil {
+		return nil, err
+	}
+	return l.sign(hash, addressIndex)
+}
+
+func (l *Ledger) Sign(hash []byte, addressIndex uint32) ([]byte, error) {
+	if err := l.record(MethodSign, hash, addressIndex); err != nil {
+		return nil, err
+	}
+	return l.sign(hash, addressIndex)
+}
+
+func (l *Ledger) SignTransaction(rawUnsignedHash []byte, addressIndices []uint32) ([][]byte, error) {
+	if err := l.record(MethodSignTransaction, rawUnsignedHash, addressIndices); err != nil {
+		return nil, err
+	}
+	sigs := make([][]byte, len(addressIndices))
+	for i, idx := range addressIndices {
+		sig, err := l.sign(rawUnsignedHash, idx)
+		if err != nil {
+			return nil, err
+		}
+		sigs[i] = sig
+	}
+	return sigs, nil
+}
+
+func (l *Ledger) Disconnect() error {
+	return l.record(MethodDisconnect)
+}
+
+func (l *Ledger) address(addressIndex uint32) ids.ShortID {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if addr, ok := l.addresses[addressIndex]; ok {
+		return addr
+	}
+	return LedgerAddress(addressIndex)
+}
+
+func (l *Ledger) sign(payload []byte, addressIndex uint32) ([]byte, error) {
+	if l.SignFunc != nil {
+		return l.SignFunc(payload, addressIndex)
+	}
+	return Signature(payload, addressIndex), nil
+}
+
+// PublicKeyLedger is a mock keychain.PublicKeyLedger, which exports
+// LedgerPublicKey(index) as the public key of each index
+type PublicKeyLedger struct {
+	*Ledger
+}
+
+// NewPublicKeyLedger returns a mock ledger exporting public keys with the
+// default behavior
+func NewPublicKeyLedger() *PublicKeyLedger {
+	return &PublicKeyLedger{Ledger: NewLedger()}
+}
+
+func (l *PublicKeyLedger) GetPublicKeys(addressIndices []uint32) ([][]byte, error) {
+	if err := l.record(MethodGetPublicKeys, addressIndices); err != nil {
+		return nil, err
+	}
+	pubKeys := make([][]byte, len(addressIndices))
+	for i, idx := range addressIndices {
+		pubKeys[i] = LedgerPublicKey(idx)
+	}
+	return pubKeys, nil
+}
+
+// LedgerAddress returns the default address derived by a mock ledger for
+// [addressIndex]
+func LedgerAddress(addressIndex uint32) ids.ShortID {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], addressIndex)
+	digest := sha256.Sum256(append([]byte("keychaintest-address"), buf[:]...))
+
+	var addr ids.ShortID
+	copy(addr[:], digest[:])
+	return addr
+}
+
+// LedgerPublicKey returns the public key exported by a mock ledger for
+// [addressIndex]. It is not a valid curve point and does not derive to
+// LedgerAddress(addressIndex).
+func LedgerPublicKey(addressIndex uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], addressIndex)
+	digest := sha256.Sum256(append([]byte("keychaintest-public-key"), buf[:]...))
+	return append([]byte{0x02}, digest[:]...)
+}
+
+// Signature returns the default signature produced by the mocks over
+// [payload] for [addressIndex]
+func Signature(payload []byte, addressIndex uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], addressIndex)
+	digest := sha256.Sum256(append(buf[:], payload...))
+	return digest[:]
+}
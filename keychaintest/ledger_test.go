@@ -0,0 +1,103 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychaintest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/keychain"
+)
+
+var errTest = errors.New("test")
+
+func TestLedger(t *testing.T) {
+	require := require.New(t)
+
+	ledger := NewLedger()
+	kc, err := keychain.NewLedgerKeychain(ledger, []uint32{0, 1}, keychain.WithBlindSigning())
+	require.NoError(err)
+	require.True(kc.Addresses().Contains(LedgerAddress(0)))
+	require.True(kc.Addresses().Contains(LedgerAddress(1)))
+	require.NotEqual(LedgerAddress(0), LedgerAddress(1))
+
+	signer, ok := kc.Get(LedgerAddress(1))
+	require.True(ok)
+	sig, err := signer.SignHash([]byte("hash"))
+	require.NoError(err)
+	require.Equal(Signature([]byte("hash"), 1), sig)
+
+	require.Equal([]Call{
+		{Method: MethodGetAddresses, Args: []any{[]uint32{0, 1}}},
+		{Method: MethodSignHash, Args: []any{[]byte("hash"), uint32(1)}},
+	}, ledger.Calls())
+
+	// Error injection
+	ledger.Fail(MethodSignHash, errTest)
+	_, err = signer.SignHash([]byte("hash"))
+	require.ErrorIs(err, errTest)
+	ledger.Fail(MethodSignHash, nil)
+	_, err = signer.SignHash([]byte("hash"))
+	require.NoError(err)
+	require.Equal(3, ledger.CallCount(MethodSignHash))
+
+	ledger.Fail(MethodGetAddresses, errTest)
+	_, err = keychain.NewLedgerKeychain(ledger, []uint32{0})
+	require.ErrorIs(err, errTest)
+
+	ledger.Reset()
+	require.Empty(ledger.Calls())
+
+	// Address overrides and custom signatures
+	addr := ids.ShortID{1}
+	ledger.SetAddress(7, addr)
+	ledger.SignFunc = func([]byte, uint32) ([]byte, error) {
+		return []byte("custom"), nil
+	}
+	derived, err := ledger.Address("lux", 7)
+	require.NoError(err)
+	require.Equal(addr, derived)
+	sigs, err := ledger.SignTransaction([]byte("tx"), []uint32{7, 7})
+	require.NoError(err)
+	require.Equal([][]byte{[]byte("custom"), []byte("custom")}, sigs)
+}
+
+func TestPublicKeyLedgerWrappers(t *testing.T) {
+	require := require.New(t)
+
+	// The ledger wrappers forward the public keys of the ledger they wrap
+	ledger := NewPublicKeyLedger()
+	wrappers := map[string]keychain.Ledger{
+		"timeout": keychain.NewTimeoutLedger(ledger, keychain.Timeouts{Derive: time.Minute}),
+		"retry":   keychain.NewRetryLedger(ledger, keychain.RetryPolicy{}),
+		"preview": keychain.NewPreviewLedger(ledger, keychain.PreviewConfig{}),
+	}
+	for name, wrapped := range wrappers {
+		ledger.Reset()
+		kc, err := keychain.NewLedgerKeychain(wrapped, []uint32{0, 1})
+		require.NoError(err, name)
+		signer, ok := kc.Get(LedgerAddress(1))
+		require.True(ok, name)
+		pubKey, ok := keychain.PublicKeyOf(signer)
+		require.True(ok, name)
+		require.Equal(LedgerPublicKey(1), pubKey, name)
+		require.Equal(1, ledger.CallCount(MethodGetPublicKeys), name)
+
+		ledger.Fail(MethodGetPublicKeys, errTest)
+		_, err = keychain.NewLedgerKeychain(wrapped, []uint32{0})
+		require.ErrorIs(err, errTest, name)
+		ledger.Fail(MethodGetPublicKeys, nil)
+	}
+
+	// Ledgers that cannot export public keys are still wrapped
+	kc, err := keychain.NewLedgerKeychain(keychain.NewTimeoutLedger(NewLedger(), keychain.Timeouts{}), []uint32{0})
+	require.NoError(err)
+	signer, _ := kc.Get(LedgerAddress(0))
+	_, ok := keychain.PublicKeyOf(signer)
+	require.False(ok)
+}
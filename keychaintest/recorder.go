@@ -0,0 +1,82 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+// Package keychaintest provides configurable mock implementations of the
+// keychain interfaces for use in tests.
+package keychaintest
+
+import "sync"
+
+// Call is a call recorded by a mock
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// recorder records calls and holds errors injected per method
+type recorder struct {
+	lock  sync.Mutex
+	calls []Call
+	errs  map[string]error
+}
+
+// record appends a call and returns the error injected for [method]
+func (r *recorder) record(method string, args ...any) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.calls = append(r.calls, Call{
+		Method: method,
+		Args:   args,
+	})
+	return r.errs[method]
+}
+
+// Fail makes every subsequent call to [method] return [err]. Passing a nil
+// error clears the injected error.
+func (r *recorder) Fail(method string, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.errs == nil {
+		r.errs = make(map[string]error)
+	}
+	if err == nil {
+		delete(r.errs, method)
+		return
+	}
+	r.errs[method] = err
+}
+
+// Calls returns the recorded calls, in order
+func (r *recorder) Calls() []Call {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	calls := make([]Call, len(r.calls))
+	copy(calls, r.calls)
+	return calls
+}
+
+// CallCount returns the number of recorded calls to [method]
+func (r *recorder) CallCount(method string) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var count int
+	for _, call := range r.calls {
+		if call.Method == method {
+			count++
+		}
+	}
+	return count
+}
+
+// Reset clears the recorded calls and injected errors
+func (r *recorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.calls = nil
+	r.errs = nil
+}
@@ -0,0 +1,40 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychaintest
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/luxfi/keychain"
+)
+
+// SpeculosAddrEnv is the environment variable holding the address of the APDU
+// server of a Speculos emulator running the Lux app
+const SpeculosAddrEnv = "SPECULOS_APDU_ADDR"
+
+// SpeculosLedger connects to the Speculos emulator configured by
+// SpeculosAddrEnv and returns a ledger speaking to it. The test is skipped if
+// no emulator is configured, so that the same tests run locally without an
+// emulator and against one in CI. The connection is closed when the test
+// completes.
+func SpeculosLedger(tb testing.TB) *keychain.LedgerDevice {
+	tb.Helper()
+
+	addr := os.Getenv(SpeculosAddrEnv)
+	if addr == "" {
+		tb.Skipf("%s is not set", SpeculosAddrEnv)
+	}
+	transport, err := keychain.DialSpeculos(addr, 5*time.Second)
+	if err != nil {
+		tb.Fatalf("failed to connect to speculos at %s: %v", addr, err)
+	}
+
+	device := keychain.NewLedgerDevice(transport)
+	tb.Cleanup(func() {
+		_ = device.Disconnect()
+	})
+	return device
+}
@@ -0,0 +1,24 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychaintest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/keychain"
+)
+
+func TestSpeculosLedger(t *testing.T) {
+	require := require.New(t)
+
+	device := SpeculosLedger(t)
+	_, err := device.Version()
+	require.NoError(err)
+
+	kc, err := keychain.NewLedgerKeychain(device, []uint32{0, 1})
+	require.NoError(err)
+	require.Equal(2, kc.Addresses().Len())
+}
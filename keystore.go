@@ -0,0 +1,286 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// keyFileExt is the extension of key files written by a Keystore
+const keyFileExt = ".key"
+
+var (
+	ErrKeystoreClosed = errors.New("keystore is closed")
+
+	_ Keychain = (*Keystore)(nil)
+)
+
+// Keystore keeps a software keychain in sync with a directory of encrypted
+// key files. Each file is a Bundle encrypted with the keystore password; its
+// keys are loaded into the keychain, and removing the file removes them
+// again. Ethereum V3 JSON keystore files encrypted with the same password are
+// loaded too, so a geth keystore directory can be used as is. Hidden files
+// and files that are not regular are ignored.
+type Keystore struct {
+	dir      string
+	pass     []byte
+	scrypt   *ScryptParams
+	keychain *SoftwareKeychain
+
+	lock sync.Mutex
+	// files caches the decrypted contents of each loaded file so that
+	// unchanged files are not decrypted again on reload
+	files map[string]*keyFile
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	watchers  sync.WaitGroup
+}
+
+type keyFile struct {
+	modTime time.Time
+	size    int64
+	keys    []*secp256k1.PrivateKey
+}
+
+func (f *keyFile) wipe() {
+	for _, key := range f.keys {
+		WipeKey(key)
+	}
+}
+
+// NewKeystore loads the key files in [dir], decrypting them with [pass]
+func NewKeystore(dir string, pass []byte, opts ...Option) (*Keystore, error) {
+	o := newOptions(opts)
+	if o.scrypt != nil {
+		if err := o.scrypt.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	ks := &Keystore{
+		dir:      dir,
+		pass:     bytes.Clone(pass),
+		scrypt:   o.scrypt,
+		keychain: NewSoftwareKeychain(),
+		files:    make(map[string]*keyFile),
+		closing:  make(chan struct{}),
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return ks, ks.Reload()
+}
+
+// Get returns the signer of a loaded key
+func (ks *Keystore) Get(addr ids.ShortID) (Signer, bool) {
+	return ks.keychain.Get(addr)
+}
+
+// Addresses returns the addresses of the loaded keys
+func (ks *Keystore) Addresses() set.Set[ids.ShortID] {
+	return ks.keychain.Addresses()
+}
+
+// Subscribe returns a channel receiving the keys loaded and dropped by the
+// keystore. Subscriptions are closed when the keystore is closed.
+func (ks *Keystore) Subscribe() <-chan Event {
+	return ks.keychain.Subscribe()
+}
+
+func (ks *Keystore) Unsubscribe(ch <-chan Event) {
+	ks.keychain.Unsubscribe(ch)
+}
+
+// Store encrypts [key] into a new file in the keystore directory and loads
+// it. The path of the written file is returned.
+func (ks *Keystore) Store(key *secp256k1.PrivateKey) (string, error) {
+	var buf bytes.Buffer
+	b := &Bundle{Keys: []*secp256k1.PrivateKey{key}}
+	params := bundleScrypt
+	if ks.scrypt != nil {
+		params = *ks.scrypt
+	}
+	if err := b.export(&buf, ks.pass, params); err != nil {
+		return "", err
+	}
+
+	// Write to a hidden temporary file first so that a concurrent reload
+	// never observes a partially written key file.
+	name := key.Address().String() + keyFileExt
+	tmp, err := os.CreateTemp(ks.dir, "."+name+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	path := filepath.Join(ks.dir, name)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return path, ks.Reload()
+}
+
+// Reload rescans the keystore directory. Keys of new or modified files are
+// loaded and keys of removed files are dropped. Files that fail to load are
+// reported in the returned error, without preventing other files from
+// loading.
+func (ks *Keystore) Reload() error {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return err
+	}
+
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	select {
+	case <-ks.closing:
+		return ErrKeystoreClosed
+	default:
+	}
+
+	var (
+		errs []error
+		seen = make(set.Set[string], len(entries))
+		keys = make(map[ids.ShortID]*secp256k1.PrivateKey)
+	)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || !entry.Type().IsRegular() {
+			continue
+		}
+
+		path := filepath.Join(ks.dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			// The file was removed while scanning
+			continue
+		}
+		seen.Add(path)
+
+		file, ok := ks.files[path]
+		if !ok || !file.modTime.Equal(info.ModTime()) || file.size != info.Size() {
+			if ok {
+				file.wipe()
+			}
+			file, err = ks.load(path, info)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				delete(ks.files, path)
+				continue
+			}
+			ks.files[path] = file
+		}
+		for _, key := range file.keys {
+			keys[key.Address()] = key
+		}
+	}
+
+	for path, file := range ks.files {
+		if !seen.Contains(path) {
+			file.wipe()
+			delete(ks.files, path)
+		}
+	}
+
+	for addr := range ks.keychain.Addresses() {
+		if _, ok := keys[addr]; !ok {
+			ks.keychain.Remove(addr)
+		}
+	}
+	for _, key := range keys {
+		ks.keychain.Add(key)
+	}
+	return errors.Join(errs...)
+}
+
+func (ks *Keystore) load(path string, info os.FileInfo) (*keyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*secp256k1.PrivateKey
+	if isKeystoreV3(data) {
+		key, err := ImportKeystoreV3(data, ks.pass)
+		if err != nil {
+			return nil, err
+		}
+		keys = []*secp256k1.PrivateKey{key}
+	} else {
+		b, err := Import(bytes.NewReader(data), ks.pass)
+		if err != nil {
+			return nil, err
+		}
+		keys = b.Keys
+	}
+	return &keyFile{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		keys:    keys,
+	}, nil
+}
+
+// Watch reloads the keystore every [interval] until the keystore is closed.
+// Reload errors are passed to [onError], which may be nil.
+func (ks *Keystore) Watch(interval time.Duration, onError func(error)) error {
+	select {
+	case <-ks.closing:
+		return ErrKeystoreClosed
+	default:
+	}
+
+	ks.watchers.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ks.closing:
+				return
+			case <-ticker.C:
+				if err := ks.Reload(); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// Close stops any watchers of the keystore and wipes the loaded keys
+func (ks *Keystore) Close() error {
+	ks.closeOnce.Do(func() {
+		close(ks.closing)
+	})
+	ks.watchers.Wait()
+
+	ks.lock.Lock()
+	defer ks.lock.Unlock()
+
+	for _, file := range ks.files {
+		file.wipe()
+	}
+	clear(ks.files)
+	err := ks.keychain.Destroy()
+	ks.keychain.events.close()
+	return err
+}
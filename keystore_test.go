@@ -0,0 +1,115 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeystoreReload(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	dir := t.TempDir()
+	pass := []byte("pass")
+
+	ks, err := NewKeystore(dir, pass)
+	require.NoError(err)
+	require.Zero(ks.Addresses().Len())
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	path, err := ks.Store(key)
+	require.NoError(err)
+	require.True(ks.Addresses().Contains(key.Address()))
+
+	// A second keystore over the same directory picks up the file
+	other, err := NewKeystore(dir, pass)
+	require.NoError(err)
+	signer, ok := other.Get(key.Address())
+	require.True(ok)
+	require.Equal(key.Address(), signer.Address())
+
+	// Removing the file removes the key
+	require.NoError(os.Remove(path))
+	require.NoError(other.Reload())
+	require.Zero(other.Addresses().Len())
+
+	// Undecryptable files are reported but do not block other files
+	_, err = ks.Store(key)
+	require.NoError(err)
+	require.NoError(os.WriteFile(filepath.Join(dir, "garbage"+keyFileExt), []byte("garbage"), 0o600))
+	err = other.Reload()
+	require.ErrorIs(err, ErrInvalidBundle)
+	require.True(other.Addresses().Contains(key.Address()))
+
+	// Hidden files are ignored
+	require.NoError(os.WriteFile(filepath.Join(dir, ".tmp"), []byte("garbage"), 0o600))
+	require.NoError(os.Remove(filepath.Join(dir, "garbage"+keyFileExt)))
+	require.NoError(other.Reload())
+}
+
+func TestKeystoreScrypt(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	dir := t.TempDir()
+	pass := []byte("pass")
+
+	_, err := NewKeystore(dir, pass, WithScrypt(ScryptParams{N: 3, R: 8, P: 1}))
+	require.ErrorIs(err, ErrInvalidKDFParams)
+
+	ks, err := NewKeystore(dir, pass, WithScrypt(ScryptParams{N: 1 << 11, R: 8, P: 1}))
+	require.NoError(err)
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	path, err := ks.Store(key)
+	require.NoError(err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(err)
+	require.Equal(byte(11), data[bundlePrefixLen])
+
+	// Keystores with other parameters still read the file
+	other, err := NewKeystore(dir, pass)
+	require.NoError(err)
+	require.True(other.Addresses().Contains(key.Address()))
+}
+
+func TestKeystoreWatch(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	dir := t.TempDir()
+	pass := []byte("pass")
+
+	ks, err := NewKeystore(dir, pass)
+	require.NoError(err)
+	watched, err := NewKeystore(dir, pass)
+	require.NoError(err)
+	require.NoError(watched.Watch(5*time.Millisecond, nil))
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	path, err := ks.Store(key)
+	require.NoError(err)
+	require.Eventually(func() bool {
+		return watched.Addresses().Contains(key.Address())
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(os.Remove(path))
+	require.Eventually(func() bool {
+		return watched.Addresses().Len() == 0
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(watched.Close())
+	require.ErrorIs(watched.Watch(time.Millisecond, nil), ErrKeystoreClosed)
+	require.ErrorIs(watched.Reload(), ErrKeystoreClosed)
+}
@@ -0,0 +1,134 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/geth/accounts/keystore"
+	gethcommon "github.com/luxfi/geth/common"
+)
+
+// keystoreV3ScryptR is the scrypt block size of the V3 files written by
+// go-ethereum, which ExportKeystoreV3 is restricted to
+const keystoreV3ScryptR = 8
+
+const (
+	// keystoreV3Version is the version of the files ImportKeystoreV3 reads
+	keystoreV3Version = 3
+	// keystoreV3DKLen is the length of the derived key of V3 files, half of
+	// which is the AES key and half the MAC key
+	keystoreV3DKLen = 32
+	// maxKeystoreV3Iterations bounds the pbkdf2 iterations an imported file
+	// can demand, 16 times those of the files written by geth
+	maxKeystoreV3Iterations = 1 << 22
+)
+
+var ErrInvalidKeystoreV3 = errors.New("invalid V3 keystore file")
+
+// keystoreV3KDF is the part of a V3 keystore file describing its KDF
+type keystoreV3KDF struct {
+	Version int `json:"version"`
+	Crypto  struct {
+		KDF       string `json:"kdf"`
+		KDFParams struct {
+			Salt  *string `json:"salt"`
+			DKLen int     `json:"dklen"`
+			// N, R and P are the parameters of scrypt
+			N int `json:"n"`
+			R int `json:"r"`
+			P int `json:"p"`
+			// C and PRF are the parameters of pbkdf2
+			C   int     `json:"c"`
+			PRF *string `json:"prf"`
+		} `json:"kdfparams"`
+	} `json:"crypto"`
+}
+
+// check returns an error unless the KDF can be run with bounded resources.
+// The decryption of go-ethereum trusts the parameters of the file, and
+// panics or exhausts memory on crafted ones.
+func (k *keystoreV3KDF) check() error {
+	params := k.Crypto.KDFParams
+	switch {
+	case k.Version != keystoreV3Version:
+		return fmt.Errorf("%w: version %d", ErrInvalidKeystoreV3, k.Version)
+	case params.Salt == nil:
+		return fmt.Errorf("%w: missing salt", ErrInvalidKeystoreV3)
+	case params.DKLen != keystoreV3DKLen:
+		return fmt.Errorf("%w: derived key length %d", ErrInvalidKeystoreV3, params.DKLen)
+	}
+	switch k.Crypto.KDF {
+	case "scrypt":
+		if err := (ScryptParams{N: params.N, R: params.R, P: params.P}).Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidKeystoreV3, err)
+		}
+	case "pbkdf2":
+		if params.C <= 0 || params.C > maxKeystoreV3Iterations || params.PRF == nil {
+			return fmt.Errorf("%w: invalid pbkdf2 parameters", ErrInvalidKeystoreV3)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported KDF %q", ErrInvalidKeystoreV3, k.Crypto.KDF)
+	}
+	return nil
+}
+
+// ImportKeystoreV3 decrypts [data], an Ethereum V3 JSON keystore file as
+// written by geth, MetaMask and most EVM wallets, with [pass]. Both the scrypt
+// and pbkdf2 KDFs are supported; files demanding more scrypt memory than
+// bundles are allowed, or more pbkdf2 iterations than 4M, are rejected before
+// deriving the key. An incorrect password fails with ErrIncorrectPassword.
+func ImportKeystoreV3(data, pass []byte) (*secp256k1.PrivateKey, error) {
+	var kdf keystoreV3KDF
+	if err := json.Unmarshal(data, &kdf); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKeystoreV3, err)
+	}
+	if err := kdf.check(); err != nil {
+		return nil, err
+	}
+
+	key, err := keystore.DecryptKey(data, string(pass))
+	if errors.Is(err, keystore.ErrDecrypt) {
+		return nil, ErrIncorrectPassword
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKeystoreV3, err)
+	}
+	keyBytes := secp256k1.PaddedBigBytes(key.PrivateKey.D, secp256k1.PrivateKeyLen)
+	defer clear(keyBytes)
+	return secp256k1.ToPrivateKey(keyBytes)
+}
+
+// ExportKeystoreV3 encrypts [key] with [pass] into an Ethereum V3 JSON
+// keystore file that geth and MetaMask can import. The key is derived with
+// scrypt under [params], whose R must be 8 as in every file written by geth;
+// both ScryptStandard and ScryptHardened qualify.
+func ExportKeystoreV3(key *secp256k1.PrivateKey, pass []byte, params ScryptParams) ([]byte, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if params.R != keystoreV3ScryptR {
+		return nil, fmt.Errorf("%w: V3 keystores require r = %d", ErrInvalidKDFParams, keystoreV3ScryptR)
+	}
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+	return keystore.EncryptKey(&keystore.Key{
+		Id:         id,
+		Address:    gethcommon.Address(key.EVMAddress()),
+		PrivateKey: key.ToECDSA(),
+	}, string(pass), params.N, params.P)
+}
+
+// isKeystoreV3 reports whether [data] looks like a JSON keystore file rather
+// than a bundle
+func isKeystoreV3(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
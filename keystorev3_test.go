@@ -0,0 +1,128 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/secp256k1"
+)
+
+// keystoreV3Vector is the pbkdf2 test vector of the Web3 Secret Storage
+// definition, encrypted with "testpassword"
+const keystoreV3Vector = `{
+	"crypto": {
+		"cipher": "aes-128-ctr",
+		"cipherparams": {"iv": "6087dab2f9fdbbfaddc31a909735c1e6"},
+		"ciphertext": "5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46",
+		"kdf": "pbkdf2",
+		"kdfparams": {
+			"c": 262144,
+			"dklen": 32,
+			"prf": "hmac-sha256",
+			"salt": "ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"
+		},
+		"mac": "517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"
+	},
+	"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+	"version": 3
+}`
+
+var lightKeystoreV3Scrypt = ScryptParams{N: 1 << 10, R: 8, P: 1}
+
+func TestImportKeystoreV3(t *testing.T) {
+	require := require.New(t)
+
+	key, err := ImportKeystoreV3([]byte(keystoreV3Vector), []byte("testpassword"))
+	require.NoError(err)
+	require.Equal(decodeHex(t, "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"), key.Bytes())
+
+	_, err = ImportKeystoreV3([]byte(keystoreV3Vector), []byte("wrong"))
+	require.ErrorIs(err, ErrIncorrectPassword)
+
+	_, err = ImportKeystoreV3([]byte("{"), []byte("testpassword"))
+	require.ErrorIs(err, ErrInvalidKeystoreV3)
+
+	// Files demanding excessive resources, or that geth would fail to
+	// decrypt, are rejected before deriving
+	for _, kdf := range []string{
+		`"kdf":"scrypt","kdfparams":{"n":1073741824,"r":8,"p":1,"dklen":32,"salt":""}`,
+		`"kdf":"scrypt","kdfparams":{"n":1152921504606846976,"r":8,"p":1,"dklen":32,"salt":""}`,
+		`"kdf":"pbkdf2","kdfparams":{"c":2147483647,"dklen":32,"prf":"hmac-sha256","salt":""}`,
+		`"kdf":"pbkdf2","kdfparams":{"c":1,"dklen":16,"prf":"hmac-sha256","salt":""}`,
+		`"kdf":"pbkdf2","kdfparams":{"c":1,"dklen":32,"prf":"hmac-sha256"}`,
+		`"kdf":"pbkdf2","kdfparams":{"c":1,"dklen":32,"salt":""}`,
+		`"kdf":"argon2","kdfparams":{"dklen":32,"salt":""}`,
+	} {
+		data := []byte(`{"version":3,"crypto":{"cipher":"aes-128-ctr",` + kdf + `}}`)
+		_, err = ImportKeystoreV3(data, []byte("testpassword"))
+		require.ErrorIs(err, ErrInvalidKeystoreV3, kdf)
+	}
+}
+
+func TestExportKeystoreV3(t *testing.T) {
+	require := require.New(t)
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	pass := []byte("pass")
+
+	data, err := ExportKeystoreV3(key, pass, lightKeystoreV3Scrypt)
+	require.NoError(err)
+	imported, err := ImportKeystoreV3(data, pass)
+	require.NoError(err)
+	require.Equal(key.Bytes(), imported.Bytes())
+
+	_, err = ExportKeystoreV3(key, pass, ScryptParams{N: 1 << 10, R: 4, P: 1})
+	require.ErrorIs(err, ErrInvalidKDFParams)
+	_, err = ExportKeystoreV3(key, pass, ScryptParams{})
+	require.ErrorIs(err, ErrInvalidKDFParams)
+}
+
+func TestKeystoreLoadsKeystoreV3(t *testing.T) {
+	require := require.New(t)
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	pass := []byte("pass")
+	data, err := ExportKeystoreV3(key, pass, lightKeystoreV3Scrypt)
+	require.NoError(err)
+
+	dir := t.TempDir()
+	require.NoError(os.WriteFile(filepath.Join(dir, "UTC--2025-01-01T00-00-00.000000000Z--"+key.Address().Hex()), data, 0o600))
+
+	ks, err := NewKeystore(dir, pass)
+	require.NoError(err)
+	defer ks.Close()
+	require.True(ks.Addresses().Contains(key.Address()))
+}
+
+func FuzzImportKeystoreV3(f *testing.F) {
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(f, err)
+	pass := []byte("pass")
+	data, err := ExportKeystoreV3(key, pass, lightKeystoreV3Scrypt)
+	require.NoError(f, err)
+	f.Add(data, pass)
+	f.Add([]byte(`{"crypto":{"kdf":"scrypt","kdfparams":{"n":1024,"r":8,"p":1}}}`), pass)
+	f.Add([]byte(`{"crypto":{"kdf":"pbkdf2","kdfparams":{"c":1,"dklen":32,"prf":"hmac-sha256"}}}`), pass)
+
+	f.Fuzz(func(t *testing.T, data, pass []byte) {
+		key, err := ImportKeystoreV3(data, pass)
+		if err != nil {
+			return
+		}
+
+		// Decrypted keys export to a file that imports back
+		exported, err := ExportKeystoreV3(key, pass, lightKeystoreV3Scrypt)
+		require.NoError(t, err)
+		reimported, err := ImportKeystoreV3(exported, pass)
+		require.NoError(t, err)
+		require.Equal(t, key.Bytes(), reimported.Bytes())
+	})
+}
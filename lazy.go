@@ -0,0 +1,206 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/log"
+	"github.com/luxfi/math/set"
+)
+
+var ErrUnknownIndex = errors.New("address index is not part of the keychain")
+
+var _ LazyKeychain = (*lazyLedgerKeychain)(nil)
+
+// LazyKeychain is a Keychain whose addresses are derived on demand
+type LazyKeychain interface {
+	Keychain
+	// SignerAt returns the signer at [index], deriving only that index if it
+	// has not been derived yet
+	SignerAt(index uint32) (Signer, error)
+}
+
+// lazyLedgerKeychain defers address derivation until an index is actually
+// needed. Derived addresses are memoized, so each index is requested from the
+// ledger at most once.
+type lazyLedgerKeychain struct {
+	ledger   Ledger
+	queue    *deviceQueue
+	hrp      string
+	indices  []uint32
+	allowed  set.Set[uint32]
+	logger   log.Logger
+	progress func(Progress)
+	// blindSigning permits SignHash
+	blindSigning bool
+	// batchSize bounds the indices derived by a request of Addresses
+	batchSize int
+
+	lock sync.Mutex
+	// next is the position in indices of the first index that may not have
+	// been derived yet
+	next         int
+	addrs        set.Set[ids.ShortID]
+	addrToIdx    map[ids.ShortID]uint32
+	idxToAddr    map[uint32]ids.ShortID
+	addrToPubKey map[ids.ShortID][]byte
+}
+
+// NewLazyLedgerKeychain creates a ledger keychain over [indices] without
+// contacting the ledger. Addresses are derived when Get, Addresses or
+// SignerAt first needs them.
+//
+// Because Get cannot report errors, a derivation failure during Get is
+// treated as the address not being found; the failed indices are retried on
+// the next call. The WithConcurrency option does not apply, as derivation is
+// done one request at a time.
+func NewLazyLedgerKeychain(ledger Ledger, indices []uint32, opts ...Option) (LazyKeychain, error) {
+	if len(indices) == 0 {
+		return nil, ErrInvalidIndicesLength
+	}
+
+	o := newOptions(opts)
+	if err := o.luxPaths(); err != nil {
+		return nil, err
+	}
+	indicesCopy := make([]uint32, len(indices))
+	copy(indicesCopy, indices)
+	return &lazyLedgerKeychain{
+		ledger:       ledger,
+		queue:        newDeviceQueue(o.onWait),
+		hrp:          o.hrp,
+		indices:      indicesCopy,
+		allowed:      set.Of(indicesCopy...),
+		logger:       o.logger,
+		progress:     o.onProgress,
+		blindSigning: o.blindSigning,
+		batchSize:    o.batchSize,
+		addrs:        make(set.Set[ids.ShortID]),
+		addrToIdx:    make(map[ids.ShortID]uint32),
+		idxToAddr:    make(map[uint32]ids.ShortID),
+		addrToPubKey: make(map[ids.ShortID][]byte),
+	}, nil
+}
+
+// Get derives pending indices, in order, until [addr] is found or every
+// index has been derived
+func (l *lazyLedgerKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for {
+		if idx, ok := l.addrToIdx[addr]; ok {
+			return l.signer(idx, addr), true
+		}
+		idx, ok := l.nextPending()
+		if !ok {
+			return nil, false
+		}
+		if _, err := l.derive([]uint32{idx}); err != nil {
+			return nil, false
+		}
+	}
+}
+
+// Addresses derives every pending index in a single request, or in requests
+// of the batch size of WithBatchSize. If derivation fails, only the
+// addresses derived so far are returned. The returned set is a copy.
+func (l *lazyLedgerKeychain) Addresses() set.Set[ids.ShortID] {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	var pending []uint32
+	for _, idx := range l.indices[l.next:] {
+		if _, ok := l.idxToAddr[idx]; !ok {
+			pending = append(pending, idx)
+		}
+	}
+	batchSize := l.batchSize
+	if batchSize <= 0 {
+		batchSize = max(len(pending), 1)
+	}
+	for batch := range slices.Chunk(pending, batchSize) {
+		if _, err := l.derive(batch); err != nil {
+			return set.Of(l.addrs.List()...)
+		}
+	}
+	l.next = len(l.indices)
+	return set.Of(l.addrs.List()...)
+}
+
+func (l *lazyLedgerKeychain) SignerAt(index uint32) (Signer, error) {
+	if !l.allowed.Contains(index) {
+		return nil, ErrUnknownIndex
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if addr, ok := l.idxToAddr[index]; ok {
+		return l.signer(index, addr), nil
+	}
+	addrs, err := l.derive([]uint32{index})
+	if err != nil {
+		return nil, err
+	}
+	return l.signer(index, addrs[0]), nil
+}
+
+// nextPending advances past derived indices and returns the next index that
+// still needs to be derived. Assumes the lock is held.
+func (l *lazyLedgerKeychain) nextPending() (uint32, bool) {
+	for ; l.next < len(l.indices); l.next++ {
+		idx := l.indices[l.next]
+		if _, ok := l.idxToAddr[idx]; !ok {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+// derive requests the addresses of [indices] and memoizes them. Assumes the
+// lock is held.
+func (l *lazyLedgerKeychain) derive(indices []uint32) ([]ids.ShortID, error) {
+	addrs, err := l.ledger.GetAddresses(indices)
+	if err == nil && len(addrs) != len(indices) {
+		err = ErrInvalidNumAddrsDerived
+	}
+	if err != nil {
+		logWarn(l.logger, "ledger address derivation failed", "indices", len(indices), "error", err)
+		return nil, err
+	}
+	pubKeys, err := derivePublicKeys(l.ledger, indices, 0)
+	if err != nil {
+		logWarn(l.logger, "ledger public key derivation failed", "indices", len(indices), "error", err)
+		return nil, err
+	}
+	for i, addr := range addrs {
+		l.addrs.Add(addr)
+		l.addrToIdx[addr] = indices[i]
+		l.idxToAddr[indices[i]] = addr
+		if pubKeys != nil {
+			l.addrToPubKey[addr] = pubKeys[i]
+		}
+		logDebug(l.logger, "derived ledger address", "index", indices[i], "address", addr)
+	}
+	return addrs, nil
+}
+
+func (l *lazyLedgerKeychain) signer(idx uint32, addr ids.ShortID) Signer {
+	return &ledgerSigner{
+		ledger:       l.ledger,
+		queue:        l.queue,
+		hrp:          l.hrp,
+		idx:          idx,
+		addr:         addr,
+		pubKey:       l.addrToPubKey[addr],
+		logger:       signLogger{Logger: l.logger},
+		progress:     l.progress,
+		blindSigning: l.blindSigning,
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+// countingLedger records how many indices have been derived
+type countingLedger struct {
+	*mockLedger
+	derived []uint32
+}
+
+func (c *countingLedger) GetAddresses(addressIndices []uint32) ([]ids.ShortID, error) {
+	c.derived = append(c.derived, addressIndices...)
+	return c.mockLedger.GetAddresses(addressIndices)
+}
+
+func TestLazyLedgerKeychain(t *testing.T) {
+	require := require.New(t)
+
+	ledger := &countingLedger{mockLedger: newMockLedger()}
+
+	_, err := NewLazyLedgerKeychain(ledger, nil)
+	require.ErrorIs(err, ErrInvalidIndicesLength)
+
+	kc, err := NewLazyLedgerKeychain(ledger, []uint32{1, 2, 3, 4, 5})
+	require.NoError(err)
+
+	// Construction does not touch the ledger
+	require.Empty(ledger.derived)
+
+	// Get derives only until the address is found
+	addr2, err := ledger.Address("", 2)
+	require.NoError(err)
+	signer, ok := kc.Get(addr2)
+	require.True(ok)
+	require.Equal(addr2, signer.Address())
+	require.Equal([]uint32{1, 2}, ledger.derived)
+
+	// Memoized lookups do not derive again
+	_, ok = kc.Get(addr2)
+	require.True(ok)
+	require.Equal([]uint32{1, 2}, ledger.derived)
+
+	// SignerAt derives a single index out of order
+	signer, err = kc.SignerAt(5)
+	require.NoError(err)
+	require.Equal(byte(5), signer.Address()[0])
+	require.Equal([]uint32{1, 2, 5}, ledger.derived)
+
+	_, err = kc.SignerAt(6)
+	require.ErrorIs(err, ErrUnknownIndex)
+
+	// Addresses derives the remaining indices only
+	addrs := kc.Addresses()
+	require.Equal(5, addrs.Len())
+	require.Equal([]uint32{1, 2, 5, 3, 4}, ledger.derived)
+
+	// The addresses are a copy
+	addrs.Remove(addr2)
+	require.True(kc.Addresses().Contains(addr2))
+
+	// Unknown addresses are not found once everything is derived
+	var unknownAddr ids.ShortID
+	unknownAddr[0] = 99
+	_, ok = kc.Get(unknownAddr)
+	require.False(ok)
+	require.Len(ledger.derived, 5)
+}
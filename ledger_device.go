@@ -0,0 +1,431 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/luxfi/ids"
+)
+
+// Instructions of the Lux ledger app
+const (
+	ledgerCLA = 0x80
+
+	insGetVersion                = 0x00
+	insGetAddress                = 0x02
+	insSignHash                  = 0x04
+	insSignTransaction           = 0x05
+	insSignTransactionWithChange = 0x06
+
+	p1Silent  = 0x00
+	p1Display = 0x01
+
+	p1ChunkFirst = 0x00
+	p1ChunkNext  = 0x01
+	p2ChunkLast  = 0x00
+	p2ChunkMore  = 0x01
+)
+
+// MaxLedgerTransactionLen is the largest unsigned transaction the Lux app can
+// buffer for signing
+const MaxLedgerTransactionLen = 16 * 1024
+
+const (
+	ledgerPublicKeyLen = 33
+	ledgerSignatureLen = 65
+)
+
+var (
+	ErrInvalidHRP         = errors.New("invalid HRP")
+	ErrDeviceDisconnected = errors.New("ledger device is disconnected")
+
+	_ Ledger          = (*LedgerDevice)(nil)
+	_ PublicKeyLedger = (*LedgerDevice)(nil)
+	_ ChangeLedger    = (*LedgerDevice)(nil)
+)
+
+// LedgerVersion is the version of the Lux app running on a ledger device
+type LedgerVersion struct {
+	Major, Minor, Patch uint8
+}
+
+// LedgerDevice implements Ledger by speaking the APDU protocol of the Lux
+// ledger app over a Transport. Address index i is derived at the BIP44 path
+// m/44'/9000'/0'/0/i.
+//
+// The commands are:
+//
+//	GetVersion                INS 0x00: -> major || minor || patch
+//	GetAddress                INS 0x02: len(hrp) || hrp || path -> pubkey (33) || addr (20)
+//	SignHash                  INS 0x04: path || hash (32) -> signature (65)
+//	SignTransaction           INS 0x05: n || path_1 .. path_n || tx -> n signatures (65)
+//	SignTransactionWithChange INS 0x06: n || path_1 .. path_n || m || change_1 .. change_m || tx -> n signatures (65)
+//
+// where a path is encoded as its length followed by each big endian element.
+// GetAddress displays the address for confirmation when P1 is 0x01.
+// SignTransactionWithChange lists the paths of the outputs returning change to
+// the device, which the app then does not flag as sends to unknown addresses.
+//
+// SignTransaction and SignTransactionWithChange payloads larger than an APDU
+// are split into chunks. P1 is 0x00 for the first chunk and 0x01 for the
+// following ones, and P2 is 0x01 while more chunks follow and 0x00 for the
+// last one. The signatures are returned in response to the last chunk.
+type LedgerDevice struct {
+	// exchangeLock serializes exchanges with the device
+	exchangeLock sync.Mutex
+	transport    Transport
+	// cancelLock guards inFlight and canceled, so that Cancel only aborts the
+	// exchange it saw in flight. inFlight is set while waiting for the device
+	// to respond, and canceled is set by Cancel to report the aborted
+	// exchange.
+	cancelLock sync.Mutex
+	inFlight   bool
+	canceled   bool
+	// connected is cleared when the transport fails an exchange
+	connected atomic.Bool
+
+	lock sync.Mutex
+	// accounts caches the public key and address of each derived index, which
+	// are fixed for a given device
+	accounts map[uint32]ledgerAccount
+}
+
+type ledgerAccount struct {
+	pubKey []byte
+	addr   ids.ShortID
+}
+
+// NewLedgerDevice returns a ledger that communicates with the Lux app over
+// [transport]
+func NewLedgerDevice(transport Transport) *LedgerDevice {
+	l := &LedgerDevice{
+		transport: transport,
+		accounts:  make(map[uint32]ledgerAccount),
+	}
+	l.connected.Store(true)
+	return l
+}
+
+// Version returns the version of the Lux app
+func (l *LedgerDevice) Version() (LedgerVersion, error) {
+	l.exchangeLock.Lock()
+	defer l.exchangeLock.Unlock()
+
+	return l.versionLocked()
+}
+
+// versionLocked assumes the exchange lock is held
+func (l *LedgerDevice) versionLocked() (LedgerVersion, error) {
+	resp, err := l.exchangeLocked(&apduCommand{
+		cla: ledgerCLA,
+		ins: insGetVersion,
+	})
+	if err != nil {
+		return LedgerVersion{}, err
+	}
+	if len(resp) < 3 {
+		return LedgerVersion{}, ErrInvalidResponse
+	}
+	return LedgerVersion{
+		Major: resp[0],
+		Minor: resp[1],
+		Patch: resp[2],
+	}, nil
+}
+
+// Address returns the address of [addressIndex]. If [displayHRP] is not
+// empty, the address is displayed with that HRP on the device for the user to
+// confirm.
+func (l *LedgerDevice) Address(displayHRP string, addressIndex uint32) (ids.ShortID, error) {
+	if displayHRP == "" {
+		account, err := l.account(addressIndex)
+		return account.addr, err
+	}
+
+	account, err := l.deriveAccount(displayHRP, addressIndex)
+	return account.addr, err
+}
+
+func (l *LedgerDevice) GetAddresses(addressIndices []uint32) ([]ids.ShortID, error) {
+	addrs := make([]ids.ShortID, len(addressIndices))
+	for i, idx := range addressIndices {
+		account, err := l.account(idx)
+		if err != nil {
+			return nil, err
+		}
+		addrs[i] = account.addr
+	}
+	return addrs, nil
+}
+
+func (l *LedgerDevice) GetPublicKeys(addressIndices []uint32) ([][]byte, error) {
+	pubKeys := make([][]byte, len(addressIndices))
+	for i, idx := range addressIndices {
+		account, err := l.account(idx)
+		if err != nil {
+			return nil, err
+		}
+		pubKeys[i] = account.pubKey
+	}
+	return pubKeys, nil
+}
+
+// SignHash signs a 32 byte hash. The device cannot display what the hash
+// commits to.
+func (l *LedgerDevice) SignHash(hash []byte, addressIndex uint32) ([]byte, error) {
+	data := appendPath(nil, AddressPath(addressIndex))
+	data = append(data, hash...)
+	resp, err := l.exchange(&apduCommand{
+		cla:  ledgerCLA,
+		ins:  insSignHash,
+		data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) != ledgerSignatureLen {
+		return nil, ErrInvalidResponse
+	}
+	return resp, nil
+}
+
+// Sign signs the unsigned transaction bytes [msg], which the device parses
+// and displays for confirmation
+func (l *LedgerDevice) Sign(msg []byte, addressIndex uint32) ([]byte, error) {
+	sigs, err := l.SignTransaction(msg, []uint32{addressIndex})
+	if err != nil {
+		return nil, err
+	}
+	return sigs[0], nil
+}
+
+// SignWithProgress signs as Sign, reporting each chunk of [msg] sent to the
+// device, and the wait for the user's confirmation once the last one is, to
+// [progress]
+func (l *LedgerDevice) SignWithProgress(msg []byte, addressIndex uint32, progress func(Progress)) ([]byte, error) {
+	paths := appendPaths(nil, []uint32{addressIndex})
+	sigs, err := l.signTransaction(insSignTransaction, paths, msg, 1, func(p Progress) {
+		p.Index = addressIndex
+		progress(p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sigs[0], nil
+}
+
+// SignTransaction signs the unsigned transaction bytes [rawUnsignedHash] with
+// each of [addressIndices], returning the signatures in the same order.
+// Transactions larger than MaxLedgerTransactionLen fail with a *LimitError.
+func (l *LedgerDevice) SignTransaction(rawUnsignedHash []byte, addressIndices []uint32) ([][]byte, error) {
+	if len(addressIndices) == 0 || len(addressIndices) > maxAPDUDataLen {
+		return nil, ErrInvalidIndicesLength
+	}
+
+	data := appendPaths(nil, addressIndices)
+	return l.signTransaction(insSignTransaction, data, rawUnsignedHash, len(addressIndices), nil)
+}
+
+// SignTransactionWithChange signs like SignTransaction, additionally telling
+// the device that the outputs to [changeIndices] are change outputs it owns
+func (l *LedgerDevice) SignTransactionWithChange(rawUnsignedHash []byte, addressIndices, changeIndices []uint32) ([][]byte, error) {
+	if len(addressIndices) == 0 || len(addressIndices) > maxAPDUDataLen {
+		return nil, ErrInvalidIndicesLength
+	}
+	if len(changeIndices) > maxAPDUDataLen {
+		return nil, ErrInvalidChangeIndicesLength
+	}
+
+	data := appendPaths(nil, addressIndices)
+	data = appendPaths(data, changeIndices)
+	return l.signTransaction(insSignTransactionWithChange, data, rawUnsignedHash, len(addressIndices), nil)
+}
+
+// signTransaction sends [paths] followed by [tx] to [ins] and returns the [n]
+// signatures of the device. [progress] may be nil.
+func (l *LedgerDevice) signTransaction(ins byte, paths, tx []byte, n int, progress func(Progress)) ([][]byte, error) {
+	if len(tx) > MaxLedgerTransactionLen {
+		return nil, &LimitError{
+			Size:  len(tx),
+			Limit: MaxLedgerTransactionLen,
+		}
+	}
+
+	resp, err := l.exchangeChunks(ins, append(paths, tx...), progress)
+	if err != nil {
+		return nil, err
+	}
+	return splitSignatures(resp, n)
+}
+
+func (l *LedgerDevice) Disconnect() error {
+	l.connected.Store(false)
+	return l.transport.Close()
+}
+
+// account returns the cached account of [addressIndex], deriving it from the
+// device if needed
+func (l *LedgerDevice) account(addressIndex uint32) (ledgerAccount, error) {
+	l.lock.Lock()
+	account, ok := l.accounts[addressIndex]
+	l.lock.Unlock()
+	if ok {
+		return account, nil
+	}
+	return l.deriveAccount("", addressIndex)
+}
+
+func (l *LedgerDevice) deriveAccount(displayHRP string, addressIndex uint32) (ledgerAccount, error) {
+	if len(displayHRP) > maxAPDUDataLen {
+		return ledgerAccount{}, ErrInvalidHRP
+	}
+
+	p1 := byte(p1Silent)
+	if displayHRP != "" {
+		p1 = p1Display
+	}
+	data := append([]byte{byte(len(displayHRP))}, displayHRP...)
+	data = appendPath(data, AddressPath(addressIndex))
+	resp, err := l.exchange(&apduCommand{
+		cla:  ledgerCLA,
+		ins:  insGetAddress,
+		p1:   p1,
+		data: data,
+	})
+	if err != nil {
+		return ledgerAccount{}, err
+	}
+	if len(resp) != ledgerPublicKeyLen+ids.ShortIDLen {
+		return ledgerAccount{}, ErrInvalidResponse
+	}
+
+	addr, err := ids.ToShortID(resp[ledgerPublicKeyLen:])
+	if err != nil {
+		return ledgerAccount{}, err
+	}
+	account := ledgerAccount{
+		pubKey: resp[:ledgerPublicKeyLen],
+		addr:   addr,
+	}
+
+	l.lock.Lock()
+	l.accounts[addressIndex] = account
+	l.lock.Unlock()
+	return account, nil
+}
+
+// exchangeChunks sends [data] to [ins] in as many APDUs as needed and returns
+// the response to the last one. The device is held for the whole exchange so
+// that chunks of concurrent requests are not interleaved. If [progress] is
+// not nil, it is called before each chunk is sent, and before the device is
+// waited on for the response to the last one.
+func (l *LedgerDevice) exchangeChunks(ins byte, data []byte, progress func(Progress)) ([]byte, error) {
+	l.exchangeLock.Lock()
+	defer l.exchangeLock.Unlock()
+
+	chunks := max((len(data)+maxAPDUDataLen-1)/maxAPDUDataLen, 1)
+	p1 := byte(p1ChunkFirst)
+	for i := 1; ; i++ {
+		chunk := data[:min(len(data), maxAPDUDataLen)]
+		data = data[len(chunk):]
+		p2 := byte(p2ChunkLast)
+		if len(data) > 0 {
+			p2 = p2ChunkMore
+		}
+		if progress != nil {
+			progress(Progress{
+				Stage: ProgressSending,
+				Step:  i,
+				Steps: chunks,
+			})
+			if p2 == p2ChunkLast {
+				progress(Progress{Stage: ProgressConfirming})
+			}
+		}
+
+		resp, err := l.exchangeLocked(&apduCommand{
+			cla:  ledgerCLA,
+			ins:  ins,
+			p1:   p1,
+			p2:   p2,
+			data: chunk,
+		})
+		if err != nil || p2 == p2ChunkLast {
+			return resp, err
+		}
+		p1 = p1ChunkNext
+	}
+}
+
+func (l *LedgerDevice) exchange(cmd *apduCommand) ([]byte, error) {
+	l.exchangeLock.Lock()
+	defer l.exchangeLock.Unlock()
+
+	return l.exchangeLocked(cmd)
+}
+
+// exchangeLocked assumes the lock is held
+func (l *LedgerDevice) exchangeLocked(cmd *apduCommand) ([]byte, error) {
+	apdu, err := cmd.encode()
+	if err != nil {
+		return nil, err
+	}
+
+	l.cancelLock.Lock()
+	l.inFlight = true
+	l.canceled = false
+	l.cancelLock.Unlock()
+
+	resp, err := l.transport.Exchange(apdu)
+
+	l.cancelLock.Lock()
+	l.inFlight = false
+	canceled := l.canceled
+	l.canceled = false
+	l.cancelLock.Unlock()
+
+	l.connected.Store(err == nil)
+	if canceled {
+		return nil, ErrOperationCanceled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDeviceDisconnected, err)
+	}
+	return parseAPDUResponse(resp)
+}
+
+// appendPaths appends the number of [indices] followed by their address
+// paths to [data]
+func appendPaths(data []byte, indices []uint32) []byte {
+	data = append(data, byte(len(indices)))
+	for _, idx := range indices {
+		data = appendPath(data, AddressPath(idx))
+	}
+	return data
+}
+
+// appendPath appends the APDU encoding of a derivation path to [data]
+func appendPath(data []byte, path []uint32) []byte {
+	data = append(data, byte(len(path)))
+	for _, element := range path {
+		data = binary.BigEndian.AppendUint32(data, element)
+	}
+	return data
+}
+
+func splitSignatures(resp []byte, n int) ([][]byte, error) {
+	if len(resp) != n*ledgerSignatureLen {
+		return nil, ErrInvalidNumSignatures
+	}
+	sigs := make([][]byte, n)
+	for i := range sigs {
+		sigs[i] = resp[i*ledgerSignatureLen : (i+1)*ledgerSignatureLen]
+	}
+	return sigs, nil
+}
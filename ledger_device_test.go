@@ -0,0 +1,357 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+)
+
+var errMalformedAPDU = errors.New("malformed APDU")
+
+// fakeLuxApp emulates the Lux ledger app with deterministic software keys
+type fakeLuxApp struct {
+	lock      sync.Mutex
+	seed      []byte
+	reject    bool
+	exchanges int
+	displayed []string
+	closed    bool
+	// change records the change paths of the last transaction signed
+	change [][]uint32
+
+	// pending buffers the chunks of a transaction being signed
+	pending []byte
+	chunks  int
+	// memory bounds the transaction size the app accepts, if set
+	memory int
+	// issuer certifies the attestation key of the app, if set
+	issuer *secp256k1.PrivateKey
+	// locked makes the device answer every command as locked
+	locked bool
+	// noBlindSigning makes the app refuse hashes, as when blind signing is
+	// disabled in its settings
+	noBlindSigning bool
+	// version is the version the app reports, 1.2.3 if unset
+	version *LedgerVersion
+}
+
+func newFakeLuxApp() *fakeLuxApp {
+	return &fakeLuxApp{seed: []byte("fake-lux-app")}
+}
+
+func (f *fakeLuxApp) key(path []uint32) (*secp256k1.PrivateKey, error) {
+	return DeterministicKey(f.seed, path[len(path)-1])
+}
+
+func (f *fakeLuxApp) Exchange(apdu []byte) ([]byte, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.exchanges++
+	if len(apdu) < 5 || int(apdu[4]) != len(apdu)-5 {
+		return nil, errMalformedAPDU
+	}
+	if f.locked {
+		return binary.BigEndian.AppendUint16(nil, swDeviceLocked), nil
+	}
+	if apdu[0] == bolosCLA && apdu[1] == insGetAppAndVersion {
+		resp := []byte{appInfoFormat, 3, 'L', 'u', 'x', 5, '1', '.', '2', '.', '3', 1, 0}
+		return binary.BigEndian.AppendUint16(resp, swOK), nil
+	}
+	if apdu[0] != ledgerCLA {
+		return binary.BigEndian.AppendUint16(nil, swClaNotSupported), nil
+	}
+	resp, sw := f.handle(apdu[1], apdu[2], apdu[3], apdu[5:])
+	return binary.BigEndian.AppendUint16(resp, sw), nil
+}
+
+func (f *fakeLuxApp) handle(ins, p1, p2 byte, data []byte) ([]byte, uint16) {
+	switch ins {
+	case insGetVersion:
+		if f.version != nil {
+			return []byte{f.version.Major, f.version.Minor, f.version.Patch}, swOK
+		}
+		return []byte{1, 2, 3}, swOK
+	case insGetAddress:
+		if len(data) < 1 || len(data) < 1+int(data[0]) {
+			return nil, swWrongLength
+		}
+		hrp := string(data[1 : 1+data[0]])
+		path, _, ok := decodePath(data[1+data[0]:])
+		if !ok {
+			return nil, swInvalidData
+		}
+		if p1 == p1Display {
+			if f.reject {
+				return nil, swUserRejected
+			}
+			f.displayed = append(f.displayed, hrp)
+		}
+		key, err := f.key(path)
+		if err != nil {
+			return nil, swInvalidData
+		}
+		return append(key.PublicKey().Bytes(), key.Address().Bytes()...), swOK
+	case insSignHash:
+		path, hash, ok := decodePath(data)
+		if !ok || len(hash) != 32 {
+			return nil, swInvalidData
+		}
+		if f.noBlindSigning {
+			return nil, swBlindSigningDisabled
+		}
+		if f.reject {
+			return nil, swUserRejected
+		}
+		key, err := f.key(path)
+		if err != nil {
+			return nil, swInvalidData
+		}
+		sig, err := key.SignHash(hash)
+		if err != nil {
+			return nil, swInvalidData
+		}
+		return sig, swOK
+	case insSignTransaction, insSignTransactionWithChange:
+		switch p1 {
+		case p1ChunkFirst:
+			f.pending = nil
+			f.chunks = 0
+		case p1ChunkNext:
+			if f.chunks == 0 {
+				return nil, swInvalidData
+			}
+		default:
+			return nil, swInvalidData
+		}
+		f.pending = append(f.pending, data...)
+		f.chunks++
+		if f.memory > 0 && len(f.pending) > f.memory {
+			f.chunks = 0
+			return nil, swNotEnoughMemory
+		}
+		if p2 == p2ChunkMore {
+			return nil, swOK
+		}
+		data, f.chunks = f.pending, 0
+
+		if len(data) < 1 {
+			return nil, swWrongLength
+		}
+		n := int(data[0])
+		rest := data[1:]
+		paths := make([][]uint32, n)
+		for i := range paths {
+			var ok bool
+			paths[i], rest, ok = decodePath(rest)
+			if !ok {
+				return nil, swInvalidData
+			}
+		}
+		f.change = nil
+		if ins == insSignTransactionWithChange {
+			if len(rest) < 1 {
+				return nil, swWrongLength
+			}
+			f.change = make([][]uint32, rest[0])
+			rest = rest[1:]
+			for i := range f.change {
+				var ok bool
+				f.change[i], rest, ok = decodePath(rest)
+				if !ok {
+					return nil, swInvalidData
+				}
+			}
+		}
+		if f.reject {
+			return nil, swUserRejected
+		}
+		var sigs []byte
+		for _, path := range paths {
+			key, err := f.key(path)
+			if err != nil {
+				return nil, swInvalidData
+			}
+			sig, err := key.Sign(rest)
+			if err != nil {
+				return nil, swInvalidData
+			}
+			sigs = append(sigs, sig...)
+		}
+		return sigs, swOK
+	case insAttest:
+		if f.issuer == nil {
+			return nil, swInsNotSupported
+		}
+		if len(data) != attestationChallengeLen {
+			return nil, swWrongLength
+		}
+		resp, err := f.attest(data)
+		if err != nil {
+			return nil, swInvalidData
+		}
+		return resp, swOK
+	default:
+		return nil, swInsNotSupported
+	}
+}
+
+// attest answers [challenge] with an attestation key certified by the issuer
+func (f *fakeLuxApp) attest(challenge []byte) ([]byte, error) {
+	device, err := DeterministicKey([]byte(string(f.seed)+"-attestation"), 0)
+	if err != nil {
+		return nil, err
+	}
+	devicePubKey := device.PublicKey().Bytes()
+	cert, err := f.issuer.SignHash(attestationHash(attestationCertDomain, devicePubKey))
+	if err != nil {
+		return nil, err
+	}
+	sig, err := device.SignHash(attestationHash(attestationDomain, challenge))
+	if err != nil {
+		return nil, err
+	}
+	resp := append(devicePubKey, cert...)
+	return append(resp, sig...), nil
+}
+
+func (f *fakeLuxApp) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.closed = true
+	return nil
+}
+
+func decodePath(data []byte) ([]uint32, []byte, bool) {
+	if len(data) < 1 || len(data) < 1+4*int(data[0]) {
+		return nil, nil, false
+	}
+	path := make([]uint32, data[0])
+	for i := range path {
+		path[i] = binary.BigEndian.Uint32(data[1+4*i:])
+	}
+	return path, data[1+4*len(path):], true
+}
+
+func TestLedgerDevice(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	device := NewLedgerDevice(app)
+
+	version, err := device.Version()
+	require.NoError(err)
+	require.Equal(LedgerVersion{Major: 1, Minor: 2, Patch: 3}, version)
+
+	key, err := DeterministicKey(app.seed, 3)
+	require.NoError(err)
+
+	kc, err := NewLedgerKeychain(device, []uint32{1, 3}, WithBlindSigning())
+	require.NoError(err)
+	require.Equal(2, kc.Addresses().Len())
+	require.True(kc.Addresses().Contains(key.Address()))
+
+	// Public keys are served from the device cache after derivation
+	require.Equal(3, app.exchanges)
+	signer, ok := kc.Get(key.Address())
+	require.True(ok)
+	require.Equal(key.PublicKey().Bytes(), signer.(PublicKeySigner).PublicKey())
+
+	hash := make([]byte, 32)
+	sig, err := signer.SignHash(hash)
+	require.NoError(err)
+	require.True(key.PublicKey().VerifyHash(hash, sig))
+
+	tx := []byte("unsigned tx")
+	sig, err = signer.Sign(tx)
+	require.NoError(err)
+	require.True(key.PublicKey().Verify(tx, sig))
+
+	other, err := DeterministicKey(app.seed, 1)
+	require.NoError(err)
+	sigs, err := device.SignTransaction(tx, []uint32{3, 1})
+	require.NoError(err)
+	require.Len(sigs, 2)
+	require.True(key.PublicKey().Verify(tx, sigs[0]))
+	require.True(other.PublicKey().Verify(tx, sigs[1]))
+
+	// Displaying an address always reaches the device
+	addr, err := device.Address("lux", 3)
+	require.NoError(err)
+	require.Equal(key.Address(), addr)
+	require.Equal([]string{"lux"}, app.displayed)
+
+	require.NoError(device.Disconnect())
+	require.True(app.closed)
+}
+
+func TestLedgerDeviceErrors(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	app.reject = true
+	device := NewLedgerDevice(app)
+
+	_, err := device.SignHash(make([]byte, 32), 0)
+	require.ErrorIs(err, ErrUserRejected)
+
+	_, err = device.SignTransaction(make([]byte, MaxLedgerTransactionLen+1), []uint32{0})
+	require.ErrorIs(err, ErrPayloadTooLarge)
+	var limitErr *LimitError
+	require.ErrorAs(err, &limitErr)
+	require.Equal(MaxLedgerTransactionLen, limitErr.Limit)
+
+	app.reject = false
+	app.memory = 1024
+	_, err = device.SignTransaction(make([]byte, 2048), []uint32{0})
+	require.ErrorIs(err, ErrPayloadTooLarge)
+
+	_, err = device.SignTransaction(nil, nil)
+	require.ErrorIs(err, ErrInvalidIndicesLength)
+
+	_, err = parseAPDUResponse([]byte{0x6e, 0x01})
+	require.ErrorIs(err, ErrAppNotOpen)
+
+	_, err = parseAPDUResponse([]byte{0x55, 0x01})
+	require.ErrorIs(err, ErrUserRejected)
+
+	_, err = parseAPDUResponse([]byte{0x12, 0x34})
+	var statusErr *StatusError
+	require.ErrorAs(err, &statusErr)
+	require.Equal(uint16(0x1234), statusErr.StatusWord)
+
+	_, err = parseAPDUResponse([]byte{0x90})
+	require.ErrorIs(err, ErrInvalidResponse)
+}
+
+func TestLedgerDeviceChunkedSignTransaction(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	device := NewLedgerDevice(app)
+
+	indices := []uint32{0, 1, 2}
+	tx := make([]byte, 4*maxAPDUDataLen+17)
+	for i := range tx {
+		tx[i] = byte(i)
+	}
+	sigs, err := device.SignTransaction(tx, indices)
+	require.NoError(err)
+	require.Len(sigs, len(indices))
+	for i, idx := range indices {
+		key, err := DeterministicKey(app.seed, idx)
+		require.NoError(err)
+		require.True(key.PublicKey().Verify(tx, sigs[i]))
+	}
+	// n || 3 paths || tx, in chunks of at most maxAPDUDataLen bytes
+	payloadLen := 1 + len(indices)*(1+4*len(AddressPath(0))) + len(tx)
+	require.Equal((payloadLen+maxAPDUDataLen-1)/maxAPDUDataLen, app.exchanges)
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import "time"
+
+// LedgerVendorID is the USB vendor ID of ledger devices
+const LedgerVendorID uint16 = 0x2c97
+
+// Ledger device models
+const (
+	LedgerModelUnknown LedgerModel = iota
+	LedgerNanoS
+	LedgerNanoX
+	LedgerNanoSPlus
+	LedgerStax
+	LedgerFlex
+)
+
+// Signing timeouts of DefaultLedgerTimeouts. Touchscreen devices review a
+// transaction page by page and sign with a hold gesture, which takes longer
+// than the two button flow of the Nano devices.
+const (
+	defaultLedgerDeriveTimeout      = 30 * time.Second
+	defaultLedgerSignTimeout        = 2 * time.Minute
+	defaultTouchscreenDeriveTimeout = time.Minute
+	defaultTouchscreenSignTimeout   = 5 * time.Minute
+)
+
+var (
+	_ ModelLedger = (*LedgerDevice)(nil)
+	_ ModelLedger = (*timeoutLedger)(nil)
+	_ ModelLedger = (*previewLedger)(nil)
+	_ ModelLedger = (*retryLedger)(nil)
+)
+
+// LedgerModel identifies the hardware of a ledger device
+type LedgerModel uint8
+
+// ledgerModelIDs maps the device ID of a USB product ID to its model. Product
+// IDs are the device ID alone, as reported by the bootloader and older
+// firmware, or the device ID followed by the bitmask of the USB interfaces
+// exposed by newer firmware, as in 0x6011 for a Stax.
+var ledgerModelIDs = map[uint16]LedgerModel{
+	0x01: LedgerNanoS,
+	0x04: LedgerNanoX,
+	0x05: LedgerNanoSPlus,
+	0x06: LedgerStax,
+	0x07: LedgerFlex,
+}
+
+// LedgerModelFromProductID returns the model of the ledger device with the USB
+// product ID [pid], or LedgerModelUnknown. USB transports use it to accept
+// every ledger model, including the Stax and Flex, rather than a fixed list of
+// product IDs.
+func LedgerModelFromProductID(pid uint16) LedgerModel {
+	id := pid
+	if pid > 0xff {
+		id = pid >> 12
+	}
+	return ledgerModelIDs[id]
+}
+
+func (m LedgerModel) String() string {
+	switch m {
+	case LedgerNanoS:
+		return "Nano S"
+	case LedgerNanoX:
+		return "Nano X"
+	case LedgerNanoSPlus:
+		return "Nano S Plus"
+	case LedgerStax:
+		return "Stax"
+	case LedgerFlex:
+		return "Flex"
+	default:
+		return "unknown"
+	}
+}
+
+// Touchscreen reports whether the device reviews requests on a touchscreen,
+// as the Stax and Flex do, rather than with two buttons
+func (m LedgerModel) Touchscreen() bool {
+	return m == LedgerStax || m == LedgerFlex
+}
+
+// DefaultLedgerTimeouts returns timeouts for NewTimeoutLedger that leave the
+// user of a [model] device enough time to review and confirm requests
+func DefaultLedgerTimeouts(model LedgerModel) Timeouts {
+	if model.Touchscreen() {
+		return Timeouts{
+			Derive: defaultTouchscreenDeriveTimeout,
+			Sign:   defaultTouchscreenSignTimeout,
+		}
+	}
+	return Timeouts{
+		Derive: defaultLedgerDeriveTimeout,
+		Sign:   defaultLedgerSignTimeout,
+	}
+}
+
+// ModelTransport is implemented by transports that know the model of the
+// device they are connected to, such as USB transports from the product ID
+type ModelTransport interface {
+	Transport
+	Model() LedgerModel
+}
+
+// ModelLedger is implemented by ledgers that know the model of their device
+type ModelLedger interface {
+	Ledger
+	Model() LedgerModel
+}
+
+// LedgerModelOf returns the model of the device of [ledger], or
+// LedgerModelUnknown if the ledger does not report it
+func LedgerModelOf(ledger Ledger) LedgerModel {
+	if m, ok := ledger.(ModelLedger); ok {
+		return m.Model()
+	}
+	return LedgerModelUnknown
+}
+
+// Model returns the model reported by the transport of the device, or
+// LedgerModelUnknown if the transport does not implement ModelTransport
+func (l *LedgerDevice) Model() LedgerModel {
+	if m, ok := l.transport.(ModelTransport); ok {
+		return m.Model()
+	}
+	return LedgerModelUnknown
+}
+
+func (t *timeoutLedger) Model() LedgerModel {
+	return LedgerModelOf(t.ledger)
+}
+
+func (p *previewLedger) Model() LedgerModel {
+	return LedgerModelOf(p.ledger)
+}
+
+func (r *retryLedger) Model() LedgerModel {
+	return LedgerModelOf(r.ledger)
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// modelTransport reports the model of the device behind a fake Lux app
+type modelTransport struct {
+	*fakeLuxApp
+	model LedgerModel
+}
+
+func (m *modelTransport) Model() LedgerModel {
+	return m.model
+}
+
+func TestLedgerModelFromProductID(t *testing.T) {
+	tests := []struct {
+		pid   uint16
+		model LedgerModel
+	}{
+		{pid: 0x0001, model: LedgerNanoS},
+		{pid: 0x1011, model: LedgerNanoS},
+		{pid: 0x4015, model: LedgerNanoX},
+		{pid: 0x5011, model: LedgerNanoSPlus},
+		{pid: 0x0006, model: LedgerStax},
+		{pid: 0x6011, model: LedgerStax},
+		{pid: 0x7015, model: LedgerFlex},
+		{pid: 0x0002, model: LedgerModelUnknown},
+		{pid: 0x8011, model: LedgerModelUnknown},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.model, LedgerModelFromProductID(test.pid), "0x%04x", test.pid)
+	}
+}
+
+func TestLedgerModel(t *testing.T) {
+	require := require.New(t)
+
+	require.True(LedgerStax.Touchscreen())
+	require.True(LedgerFlex.Touchscreen())
+	require.False(LedgerNanoX.Touchscreen())
+	require.Equal("Flex", LedgerFlex.String())
+	require.Equal("unknown", LedgerModelUnknown.String())
+
+	require.Greater(DefaultLedgerTimeouts(LedgerStax).Sign, DefaultLedgerTimeouts(LedgerNanoSPlus).Sign)
+	require.Positive(DefaultLedgerTimeouts(LedgerModelUnknown).Derive)
+
+	device := NewLedgerDevice(&modelTransport{fakeLuxApp: newFakeLuxApp(), model: LedgerFlex})
+	require.Equal(LedgerFlex, device.Model())
+	require.Equal(LedgerFlex, LedgerModelOf(NewTimeoutLedger(device, DefaultLedgerTimeouts(LedgerFlex))))
+
+	require.Equal(LedgerModelUnknown, NewLedgerDevice(newFakeLuxApp()).Model())
+	require.Equal(LedgerModelUnknown, LedgerModelOf(newMockLedger()))
+}
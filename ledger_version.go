@@ -0,0 +1,176 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrBrokenLedgerApp          = errors.New("ledger app version has a known signing bug")
+	ErrLedgerVersionUnavailable = errors.New("ledger does not report its app version")
+
+	_ VersionLedger = (*LedgerDevice)(nil)
+	_ VersionLedger = (*timeoutLedger)(nil)
+	_ VersionLedger = (*previewLedger)(nil)
+	_ VersionLedger = (*retryLedger)(nil)
+)
+
+// VersionLedger is implemented by ledgers that report the version of the Lux
+// app, such as LedgerDevice
+type VersionLedger interface {
+	Ledger
+	Version() (LedgerVersion, error)
+}
+
+// LedgerVersionOf returns the version of the Lux app of [ledger], or
+// ErrLedgerVersionUnavailable if the ledger does not report it
+func LedgerVersionOf(ledger Ledger) (LedgerVersion, error) {
+	v, ok := ledger.(VersionLedger)
+	if !ok {
+		return LedgerVersion{}, ErrLedgerVersionUnavailable
+	}
+	return v.Version()
+}
+
+func (v LedgerVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 as [v] is older than, the same as or newer than
+// [other]
+func (v LedgerVersion) Compare(other LedgerVersion) int {
+	return cmp.Or(
+		cmp.Compare(v.Major, other.Major),
+		cmp.Compare(v.Minor, other.Minor),
+		cmp.Compare(v.Patch, other.Patch),
+	)
+}
+
+// LedgerAppIssue is a known defect of the Lux app versions from Introduced up
+// to, but excluding, Fixed
+type LedgerAppIssue struct {
+	Introduced, Fixed LedgerVersion
+	// Broken issues produce invalid or unsafe signatures, so that the app
+	// must be updated before signing. Other issues are warned about.
+	Broken      bool
+	Description string
+	// Advisory is the URL of the advisory or release notes of the issue
+	Advisory string
+}
+
+// Affects reports whether [v] has the issue
+func (i LedgerAppIssue) Affects(v LedgerVersion) bool {
+	return v.Compare(i.Introduced) >= 0 && v.Compare(i.Fixed) < 0
+}
+
+// ledgerAppIssues are the known defects of released versions of the Lux app,
+// checked by every ledger keychain. Each entry must cite the advisory of the
+// release that fixed it; none are known.
+var ledgerAppIssues []LedgerAppIssue
+
+// LedgerAppIssues returns the known defects of version [v] of the Lux app,
+// together with those of [issues] that affect it
+func LedgerAppIssues(v LedgerVersion, issues ...LedgerAppIssue) []LedgerAppIssue {
+	var affecting []LedgerAppIssue
+	for _, issue := range slices.Concat(ledgerAppIssues, issues) {
+		if issue.Affects(v) {
+			affecting = append(affecting, issue)
+		}
+	}
+	return affecting
+}
+
+// LedgerAppWarning reports a known defect of the Lux app of a device that
+// does not prevent signing, so that users can be asked to update the app
+type LedgerAppWarning struct {
+	Version LedgerVersion
+	Issue   LedgerAppIssue
+}
+
+func (w LedgerAppWarning) String() string {
+	return fmt.Sprintf("ledger app %s: %s; update to %s or later", w.Version, w.Issue.Description, w.Issue.Fixed)
+}
+
+// CheckLedgerApp queries the version of the Lux app of [ledger] and returns a
+// warning for each of its known defects, including those of [issues], such as
+// issues published after this release. Versions with a broken issue fail with
+// ErrBrokenLedgerApp. Ledgers that do not report their version have no known
+// defects, and the version is not queried if there are no issues to check.
+// The firmware of the device is not checked, as its version cannot be
+// queried while the Lux app is open.
+func CheckLedgerApp(ledger Ledger, issues ...LedgerAppIssue) ([]LedgerAppWarning, error) {
+	if len(ledgerAppIssues) == 0 && len(issues) == 0 {
+		return nil, nil
+	}
+	version, err := LedgerVersionOf(ledger)
+	if errors.Is(err, ErrLedgerVersionUnavailable) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []LedgerAppWarning
+	for _, issue := range LedgerAppIssues(version, issues...) {
+		if issue.Broken {
+			return nil, fmt.Errorf("%w: %s: %s; update to %s or later", ErrBrokenLedgerApp, version, issue.Description, issue.Fixed)
+		}
+		warnings = append(warnings, LedgerAppWarning{
+			Version: version,
+			Issue:   issue,
+		})
+	}
+	return warnings, nil
+}
+
+// WithLedgerAppIssues checks the Lux app of the ledger of a keychain for
+// [issues], in addition to its known defects, when the keychain is created.
+// Keychains are not created on versions with a broken issue.
+func WithLedgerAppIssues(issues ...LedgerAppIssue) Option {
+	return func(o *options) {
+		o.appIssues = append(o.appIssues, issues...)
+	}
+}
+
+// WithLedgerAppWarnings calls [f] with each known defect of the Lux app found
+// when a ledger keychain is created, such as to ask the user to update it.
+// The warnings are also logged with the logger of WithLogger.
+func WithLedgerAppWarnings(f func(LedgerAppWarning)) Option {
+	return func(o *options) {
+		o.onAppWarning = f
+	}
+}
+
+// checkLedgerApp runs CheckLedgerApp on the ledger of a keychain being
+// created with [o]
+func (o *options) checkLedgerApp(ledger Ledger) error {
+	warnings, err := CheckLedgerApp(ledger, o.appIssues...)
+	if err != nil {
+		logWarn(o.logger, "ledger app check failed", "error", err)
+		return err
+	}
+	for _, w := range warnings {
+		logWarn(o.logger, "ledger app has a known defect", "version", w.Version.String(), "issue", w.Issue.Description, "advisory", w.Issue.Advisory)
+		if o.onAppWarning != nil {
+			o.onAppWarning(w)
+		}
+	}
+	return nil
+}
+
+func (t *timeoutLedger) Version() (LedgerVersion, error) {
+	return LedgerVersionOf(t.ledger)
+}
+
+func (p *previewLedger) Version() (LedgerVersion, error) {
+	return LedgerVersionOf(p.ledger)
+}
+
+func (r *retryLedger) Version() (LedgerVersion, error) {
+	return LedgerVersionOf(r.ledger)
+}
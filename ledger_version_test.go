@@ -0,0 +1,107 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerVersionCompare(t *testing.T) {
+	require := require.New(t)
+
+	v := LedgerVersion{Major: 1, Minor: 2, Patch: 3}
+	require.Equal("1.2.3", v.String())
+	require.Zero(v.Compare(v))
+	require.Equal(-1, v.Compare(LedgerVersion{Major: 1, Minor: 3}))
+	require.Equal(1, v.Compare(LedgerVersion{Major: 1, Minor: 2, Patch: 2}))
+	require.Equal(-1, LedgerVersion{Minor: 9, Patch: 9}.Compare(v))
+}
+
+// testLedgerAppIssues are defects of made up versions of the Lux app
+var testLedgerAppIssues = []LedgerAppIssue{
+	{
+		Fixed:       LedgerVersion{Minor: 6},
+		Broken:      true,
+		Description: "signatures fail verification",
+		Advisory:    "https://example.com/advisories/1",
+	},
+	{
+		Introduced:  LedgerVersion{Minor: 6},
+		Fixed:       LedgerVersion{Minor: 7, Patch: 2},
+		Description: "signatures are not low-S normalized",
+		Advisory:    "https://example.com/advisories/2",
+	},
+	{
+		Introduced:  LedgerVersion{Minor: 6},
+		Fixed:       LedgerVersion{Major: 1},
+		Description: "addresses are not displayed",
+		Advisory:    "https://example.com/advisories/3",
+	},
+}
+
+func TestLedgerAppIssues(t *testing.T) {
+	require := require.New(t)
+
+	// No defects of released versions are known
+	require.Empty(LedgerAppIssues(LedgerVersion{}))
+
+	require.Empty(LedgerAppIssues(LedgerVersion{Major: 1}, testLedgerAppIssues...))
+	require.Len(LedgerAppIssues(LedgerVersion{Minor: 7, Patch: 1}, testLedgerAppIssues...), 2)
+	require.Len(LedgerAppIssues(LedgerVersion{Minor: 7, Patch: 2}, testLedgerAppIssues...), 1)
+
+	issues := LedgerAppIssues(LedgerVersion{Minor: 5, Patch: 9}, testLedgerAppIssues...)
+	require.Len(issues, 1)
+	require.True(issues[0].Broken)
+}
+
+func TestCheckLedgerApp(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	device := NewLedgerDevice(app)
+	warnings, err := CheckLedgerApp(device, testLedgerAppIssues...)
+	require.NoError(err)
+	require.Empty(warnings)
+
+	// The version is not queried without issues to check
+	app.version = &LedgerVersion{Minor: 5}
+	exchanges := app.exchanges
+	warnings, err = CheckLedgerApp(device)
+	require.NoError(err)
+	require.Empty(warnings)
+	require.Equal(exchanges, app.exchanges)
+	_, err = NewLedgerKeychain(device, []uint32{0})
+	require.NoError(err)
+
+	// Versions with known defects are warned about through wrappers
+	app.version = &LedgerVersion{Minor: 7}
+	warnings, err = CheckLedgerApp(NewTimeoutLedger(device, Timeouts{}), testLedgerAppIssues...)
+	require.NoError(err)
+	require.Len(warnings, 2)
+	require.Equal(*app.version, warnings[0].Version)
+	require.Contains(warnings[0].String(), "update to 0.7.2 or later")
+
+	var reported []LedgerAppWarning
+	_, err = NewLedgerKeychain(device, []uint32{0}, WithLedgerAppIssues(testLedgerAppIssues...), WithLedgerAppWarnings(func(w LedgerAppWarning) {
+		reported = append(reported, w)
+	}))
+	require.NoError(err)
+	require.Equal(warnings, reported)
+
+	// Broken versions refuse to create keychains
+	app.version = &LedgerVersion{Minor: 5}
+	_, err = CheckLedgerApp(device, testLedgerAppIssues...)
+	require.ErrorIs(err, ErrBrokenLedgerApp)
+	_, err = NewLedgerKeychain(device, []uint32{0}, WithLedgerAppIssues(testLedgerAppIssues...))
+	require.ErrorIs(err, ErrBrokenLedgerApp)
+
+	// Ledgers that do not report their version are not checked
+	warnings, err = CheckLedgerApp(newMockLedger(), testLedgerAppIssues...)
+	require.NoError(err)
+	require.Empty(warnings)
+	_, err = LedgerVersionOf(newMockLedger())
+	require.ErrorIs(err, ErrLedgerVersionUnavailable)
+}
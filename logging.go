@@ -0,0 +1,119 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/log"
+)
+
+// fingerprintLen is the number of bytes of the SHA-256 digest of a payload
+// logged in place of a redacted payload
+const fingerprintLen = 4
+
+// WithLogger logs the construction of the keychain, the addresses it derives
+// and the signing operations of its signers to [logger], redacting hashes and
+// messages as Logging does. Construction and derivation are logged at debug
+// level, signing operations at debug level and their failures at warn level;
+// a logger created with a higher level, such as with [logger].Level,
+// discards them. Without it, nothing is logged.
+func WithLogger(logger log.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// LoggingConfig configures Logging
+type LoggingConfig struct {
+	// Logger receives the records
+	Logger log.Logger
+	// Level is the level of the records of operations that succeed, debug
+	// by default. Operations that fail are logged at warn level or, if
+	// Level is higher, at Level.
+	Level log.Level
+	// ShowPayloads logs the hashes and messages signed in full. Without it,
+	// they are redacted to their length and a fingerprint, the first bytes
+	// of their SHA-256 digest, so that records of the same payload can be
+	// correlated without revealing it.
+	ShowPayloads bool
+}
+
+// Logging returns a middleware logging the signing operations of the signers
+// it wraps, with their address, operation, payload, duration and error, to
+// debug production signing issues. Keys are never logged.
+func Logging(config LoggingConfig) Middleware {
+	logger := signLogger(config)
+	return Intercept(func(signer Signer, op SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		return logger.sign(signer.Address(), op, payload, func() ([]byte, error) {
+			return next(payload)
+		})
+	})
+}
+
+// signLogger logs signing operations as configured by a LoggingConfig, or
+// not at all if it has no logger
+type signLogger LoggingConfig
+
+// newSignLogger returns the logger of the signers of a keychain created with
+// WithLogger
+func newSignLogger(o *options) signLogger {
+	return signLogger{Logger: o.logger}
+}
+
+func (s signLogger) log(addr ids.ShortID, op SignOp, payload []byte, duration time.Duration, err error) {
+	if s.Logger == nil {
+		return
+	}
+	ctx := []any{
+		"address", addr,
+		"op", op,
+		"payload", s.payload(payload),
+		"duration", duration,
+	}
+	if err != nil {
+		s.Logger.Log(max(s.Level, log.WarnLevel), "signing failed", append(ctx, "error", err)...)
+		return
+	}
+	s.Logger.Log(s.Level, "signed", ctx...)
+}
+
+// sign runs [op], signing [payload] for [addr], and logs it
+func (s signLogger) sign(addr ids.ShortID, op SignOp, payload []byte, sign func() ([]byte, error)) ([]byte, error) {
+	start := time.Now()
+	sig, err := sign()
+	s.log(addr, op, payload, time.Since(start), err)
+	return sig, err
+}
+
+func (s signLogger) payload(payload []byte) string {
+	if s.ShowPayloads {
+		return hex.EncodeToString(payload)
+	}
+	return redact(payload)
+}
+
+// redact returns the length and fingerprint of [payload]
+func redact(payload []byte) string {
+	digest := sha256.Sum256(payload)
+	return fmt.Sprintf("redacted(len=%d, sha256=%x)", len(payload), digest[:fingerprintLen])
+}
+
+// logDebug logs [msg] at debug level if [logger] is set
+func logDebug(logger log.Logger, msg string, ctx ...any) {
+	if logger != nil {
+		logger.Debug(msg, ctx...)
+	}
+}
+
+// logWarn logs [msg] at warn level if [logger] is set
+func logWarn(logger log.Logger, msg string, ctx ...any) {
+	if logger != nil {
+		logger.Warn(msg, ctx...)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/luxfi/log"
+	"github.com/stretchr/testify/require"
+)
+
+// logRecords decodes the JSON records written to [buf]
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var record map[string]any
+		require.NoError(t, dec.Decode(&record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogging(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("logging"), 1)
+	require.NoError(err)
+	addr, _ := kc.Addresses().Peek()
+
+	var buf bytes.Buffer
+	errRefused := errors.New("refused")
+	refuse := Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		if len(payload) != 32 {
+			return nil, errRefused
+		}
+		return next(payload)
+	})
+	signer, _ := Wrap(kc, Logging(LoggingConfig{Logger: log.New().Output(&buf)}), refuse).Get(addr)
+
+	hash := bytes.Repeat([]byte{0xab}, 32)
+	_, err = signer.SignHash(hash)
+	require.NoError(err)
+	_, err = signer.Sign([]byte("tx"))
+	require.ErrorIs(err, errRefused)
+
+	require.NotContains(buf.String(), hex.EncodeToString(hash))
+	records := logRecords(t, &buf)
+	require.Len(records, 2)
+	require.Equal("debug", records[0]["level"])
+	require.Equal("signed", records[0]["message"])
+	require.Equal(addr.String(), records[0]["address"])
+	require.Equal(string(OpSignHash), records[0]["op"])
+	require.Equal(redact(hash), records[0]["payload"])
+	require.Equal("warn", records[1]["level"])
+	require.Equal("signing failed", records[1]["message"])
+	require.Equal(errRefused.Error(), records[1]["error"])
+}
+
+func TestLoggingConfig(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("logging"), 1)
+	require.NoError(err)
+	addr, _ := kc.Addresses().Peek()
+
+	// Records below the level of the logger are discarded
+	var buf bytes.Buffer
+	logger := log.New().Output(&buf).Level(log.InfoLevel)
+	signer, _ := Wrap(kc, Logging(LoggingConfig{Logger: logger})).Get(addr)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	require.Empty(buf.String())
+
+	hash := bytes.Repeat([]byte{0xab}, 32)
+	signer, _ = Wrap(kc, Logging(LoggingConfig{
+		Logger:       logger,
+		Level:        log.InfoLevel,
+		ShowPayloads: true,
+	})).Get(addr)
+	_, err = signer.SignHash(hash)
+	require.NoError(err)
+	records := logRecords(t, &buf)
+	require.Len(records, 1)
+	require.Equal("info", records[0]["level"])
+	require.Equal(hex.EncodeToString(hash), records[0]["payload"])
+}
+
+func TestWithLogger(t *testing.T) {
+	require := require.New(t)
+
+	var buf bytes.Buffer
+	app := newFakeLuxApp()
+	kc, err := NewLedgerKeychain(NewLedgerDevice(app), []uint32{0, 1}, WithLogger(log.New().Output(&buf)))
+	require.NoError(err)
+	records := logRecords(t, &buf)
+	require.Len(records, 3)
+	require.Equal("derived ledger address", records[0]["message"])
+	require.Equal("created ledger keychain", records[2]["message"])
+
+	addr, _ := kc.Addresses().Peek()
+	signer, _ := kc.Get(addr)
+	tx := []byte("unsigned tx")
+	_, err = signer.Sign(tx)
+	require.NoError(err)
+	records = logRecords(t, &buf)
+	require.Len(records, 1)
+	require.Equal("signed", records[0]["message"])
+	require.Equal(addr.String(), records[0]["address"])
+	require.Equal(redact(tx), records[0]["payload"])
+
+	// Keychains created without a logger log nothing
+	kc, err = NewLedgerKeychain(NewLedgerDevice(app), []uint32{0})
+	require.NoError(err)
+	addr, _ = kc.Addresses().Peek()
+	signer, _ = kc.Get(addr)
+	_, err = signer.Sign(tx)
+	require.NoError(err)
+	require.Empty(buf.String())
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/luxfi/crypto/secp256k1"
+)
+
+var ErrHighS = errors.New("signature is not low-S normalized")
+
+// ecdsaCurve returns the curve of the ECDSA signatures of [scheme], or nil if
+// the signatures of the scheme are not ECDSA signatures
+func ecdsaCurve(scheme SchemeID) elliptic.Curve {
+	switch scheme {
+	case SchemeSecp256k1:
+		return secp256k1.S256()
+	case SchemeP256:
+		return elliptic.P256()
+	default:
+		return nil
+	}
+}
+
+// checkECDSALength checks that [sig] is in the format Sign returns for the
+// ECDSA scheme [scheme]: 65 byte r || s || v for secp256k1 and 64 byte
+// r || s for P-256
+func checkECDSALength(scheme SchemeID, sig []byte) error {
+	expected := 64
+	if scheme == SchemeSecp256k1 {
+		expected = secp256k1.SignatureLen
+	}
+	if len(sig) != expected {
+		return ErrInvalidSignatureLen
+	}
+	return nil
+}
+
+// IsLowS reports whether the s value of [sig], a signature of [scheme] as
+// returned by Sign, is in the lower half of the curve order. Signatures of
+// schemes other than ECDSA ones are not malleable and always are.
+func IsLowS(scheme SchemeID, sig []byte) bool {
+	curve := ecdsaCurve(scheme)
+	if curve == nil {
+		return true
+	}
+	if checkECDSALength(scheme, sig) != nil {
+		return false
+	}
+	s := new(big.Int).SetBytes(sig[32:64])
+	return s.Cmp(new(big.Int).Rsh(curve.Params().N, 1)) <= 0
+}
+
+// NormalizeLowS returns [sig], a signature of [scheme] as returned by Sign,
+// with its s value replaced by n - s if it is in the upper half of the curve
+// order n. Both values verify, so normalizing prevents third parties from
+// changing the encoding, and the ID, of a signed transaction. The recovery id
+// of secp256k1 signatures is flipped along with s. Signatures whose r or s is
+// not between 1 and n - 1 fail with ErrInvalidSignatureEncoding. Signatures of
+// schemes other than ECDSA ones are returned unchanged.
+func NormalizeLowS(scheme SchemeID, sig []byte) ([]byte, error) {
+	curve := ecdsaCurve(scheme)
+	if curve == nil {
+		return sig, nil
+	}
+	if err := checkECDSALength(scheme, sig); err != nil {
+		return nil, err
+	}
+	// Negating an s outside of the curve order would not produce the other
+	// encoding of the signature but another, invalid, signature
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	if err := checkECDSAScalars(curve, r, s); err != nil {
+		return nil, err
+	}
+	if IsLowS(scheme, sig) {
+		return sig, nil
+	}
+
+	normalized := bytes.Clone(sig)
+	new(big.Int).Sub(curve.Params().N, s).FillBytes(normalized[32:64])
+	if scheme == SchemeSecp256k1 {
+		if v := normalized[64]; v > 1 {
+			return nil, fmt.Errorf("%w: recovery id %d", ErrInvalidSignatureEncoding, v)
+		}
+		normalized[64] ^= 1
+	}
+	return normalized, nil
+}
+
+// LowS returns a middleware normalizing the ECDSA signatures returned by the
+// signers of a keychain with NormalizeLowS, so that none of them is
+// malleable, whichever backend produced it. Software signers and the PIV,
+// OpenPGP, TPM and crypto.Signer adapters already normalize; ledger devices,
+// remote signers and WalletConnect wallets return the signatures of their
+// backend as they are.
+func LowS() Middleware {
+	return Intercept(func(signer Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		sig, err := next(payload)
+		if err != nil {
+			return nil, err
+		}
+		return NormalizeLowS(SchemeOf(signer), sig)
+	})
+}
+
+// VerifySignatureStrict checks [sig] like VerifySignature, additionally
+// rejecting ECDSA signatures that are not low-S normalized with ErrHighS, as
+// required by verifiers that reject malleable signatures
+func VerifySignatureStrict(signer Signer, msg, sig []byte) error {
+	if err := VerifySignature(signer, msg, sig); err != nil {
+		return err
+	}
+	if !IsLowS(SchemeOf(signer), sig) {
+		return ErrHighS
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"math/big"
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+)
+
+// highS returns [sig] with its s value replaced by n - s, as a malleating
+// third party or a non-normalizing backend would produce it
+func highS(curve elliptic.Curve, sig []byte) []byte {
+	malleated := bytes.Clone(sig)
+	s := new(big.Int).SetBytes(sig[32:64])
+	new(big.Int).Sub(curve.Params().N, s).FillBytes(malleated[32:64])
+	if len(malleated) == secp256k1.SignatureLen {
+		malleated[64] ^= 1
+	}
+	return malleated
+}
+
+// highSMiddleware malleates every signature, as a backend returning high-S
+// signatures half of the time would for the other half
+func highSMiddleware(curve elliptic.Curve) Middleware {
+	return Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		sig, err := next(payload)
+		if err != nil {
+			return nil, err
+		}
+		return highS(curve, sig), nil
+	})
+}
+
+func TestNormalizeLowSSecp256k1(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("lows"), 1)
+	require.NoError(err)
+	addr, _ := kc.Addresses().Peek()
+	signer, _ := kc.Get(addr)
+
+	msg := []byte("payload")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.True(IsLowS(SchemeSecp256k1, sig))
+
+	// The malleated signature recovers the same key, but is rejected by strict
+	// verification
+	malleated := highS(secp256k1.S256(), sig)
+	require.False(IsLowS(SchemeSecp256k1, malleated))
+	require.NoError(VerifySignature(signer, msg, malleated))
+	require.ErrorIs(VerifySignatureStrict(signer, msg, malleated), ErrHighS)
+	require.NoError(VerifySignatureStrict(signer, msg, sig))
+
+	normalized, err := NormalizeLowS(SchemeSecp256k1, malleated)
+	require.NoError(err)
+	require.Equal(sig, normalized)
+	normalized, err = NormalizeLowS(SchemeSecp256k1, sig)
+	require.NoError(err)
+	require.Equal(sig, normalized)
+
+	_, err = NormalizeLowS(SchemeSecp256k1, sig[:64])
+	require.ErrorIs(err, ErrInvalidSignatureLen)
+	malleated[64] = 27
+	_, err = NormalizeLowS(SchemeSecp256k1, malleated)
+	require.ErrorIs(err, ErrInvalidSignatureEncoding)
+
+	// An s beyond the curve order is not the other encoding of a signature
+	outOfRange := bytes.Clone(sig)
+	secp256k1.S256().Params().N.FillBytes(outOfRange[32:64])
+	_, err = NormalizeLowS(SchemeSecp256k1, outOfRange)
+	require.ErrorIs(err, ErrInvalidSignatureEncoding)
+}
+
+func TestNormalizeLowSP256(t *testing.T) {
+	require := require.New(t)
+
+	signer, err := NewP256Signer(newP256Key(t))
+	require.NoError(err)
+	msg := []byte("payload")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.True(IsLowS(SchemeP256, sig))
+
+	malleated := highS(elliptic.P256(), sig)
+	require.NoError(VerifySignature(signer, msg, malleated))
+	require.ErrorIs(VerifySignatureStrict(signer, msg, malleated), ErrHighS)
+	normalized, err := NormalizeLowS(SchemeP256, malleated)
+	require.NoError(err)
+	require.Equal(sig, normalized)
+}
+
+func TestNormalizeLowSOtherSchemes(t *testing.T) {
+	require := require.New(t)
+
+	sig := bytes.Repeat([]byte{0xff}, 64)
+	require.True(IsLowS(SchemeEd25519, sig))
+	normalized, err := NormalizeLowS(SchemeEd25519, sig)
+	require.NoError(err)
+	require.Equal(sig, normalized)
+}
+
+func TestLowSMiddleware(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("lows"), 1)
+	require.NoError(err)
+	addr, _ := kc.Addresses().Peek()
+	wrapped := Wrap(kc, LowS(), highSMiddleware(secp256k1.S256()))
+	signer, ok := wrapped.Get(addr)
+	require.True(ok)
+
+	msg := []byte("payload")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.NoError(VerifySignatureStrict(signer, msg, sig))
+
+	hash := make([]byte, 32)
+	sig, err = signer.SignHash(hash)
+	require.NoError(err)
+	require.True(IsLowS(SchemeSecp256k1, sig))
+	pubKey, err := secp256k1.RecoverPublicKeyFromHash(hash, sig)
+	require.NoError(err)
+	require.Equal(addr, pubKey.Address())
+}
+
+func FuzzNormalizeLowS(f *testing.F) {
+	kc, err := NewTestKeychain([]byte("lows fuzz"), 1)
+	require.NoError(f, err)
+	addr, _ := kc.Addresses().Peek()
+	signer, _ := kc.Get(addr)
+	sig, err := signer.Sign([]byte("payload"))
+	require.NoError(f, err)
+	f.Add(true, sig)
+	f.Add(true, highS(secp256k1.S256(), sig))
+	f.Add(false, sig[:64])
+
+	f.Fuzz(func(t *testing.T, recoverable bool, sig []byte) {
+		id := SchemeP256
+		if recoverable {
+			id = SchemeSecp256k1
+		}
+		normalized, err := NormalizeLowS(id, sig)
+		if err != nil {
+			return
+		}
+		require.True(t, IsLowS(id, normalized))
+		if IsLowS(id, sig) {
+			require.Equal(t, sig, normalized)
+			return
+		}
+
+		// Normalizing a high-S signature is undone by malleating it back
+		require.Equal(t, sig, highS(ecdsaCurve(id), normalized))
+	})
+}
@@ -0,0 +1,126 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// Policies resolving the addresses owned by both keychains of a Merge
+const (
+	// ConflictPreferFirst signs with the signer of the first keychain
+	ConflictPreferFirst ConflictPolicy = iota
+	// ConflictPreferHardware signs with the signer held by a hardware
+	// device, as reported by IsHardwareSigner, or with the signer of the
+	// first keychain if both or neither are
+	ConflictPreferHardware
+	// ConflictError fails Merge if the keychains share an address
+	ConflictError
+)
+
+var (
+	ErrAddressConflict       = errors.New("address is owned by both keychains")
+	ErrUnknownConflictPolicy = errors.New("unknown conflict policy")
+
+	_ RangeKeychain = (*mergedKeychain)(nil)
+)
+
+// ConflictPolicy determines which signer of a merged keychain signs for an
+// address owned by both keychains
+type ConflictPolicy int
+
+func (p ConflictPolicy) String() string {
+	switch p {
+	case ConflictPreferFirst:
+		return "prefer-first"
+	case ConflictPreferHardware:
+		return "prefer-hardware"
+	case ConflictError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// mergedKeychain is the union of two keychains
+type mergedKeychain struct {
+	first, second Keychain
+	policy        ConflictPolicy
+}
+
+// Merge returns the union of the keychains [a] and [b], resolving the
+// addresses owned by both with [policy]. With ConflictError, Merge fails
+// with ErrAddressConflict listing the shared addresses; addresses that
+// become shared after the merge, as keys are added to a software keychain,
+// are signed for by [a].
+func Merge(a, b Keychain, policy ConflictPolicy) (Keychain, error) {
+	switch policy {
+	case ConflictPreferFirst, ConflictPreferHardware:
+	case ConflictError:
+		if shared := a.Addresses().Intersection(b.Addresses()); shared.Len() > 0 {
+			addrs := shared.List()
+			slices.SortFunc(addrs, ids.ShortID.Compare)
+			return nil, fmt.Errorf("%w: %v", ErrAddressConflict, addrs)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownConflictPolicy, policy)
+	}
+	return &mergedKeychain{
+		first:  a,
+		second: b,
+		policy: policy,
+	}, nil
+}
+
+func (m *mergedKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	first, inFirst := m.first.Get(addr)
+	if !inFirst {
+		return m.second.Get(addr)
+	}
+	if m.policy == ConflictPreferHardware && !IsHardwareSigner(first) {
+		if second, ok := m.second.Get(addr); ok && IsHardwareSigner(second) {
+			return second, true
+		}
+	}
+	return first, true
+}
+
+func (m *mergedKeychain) Addresses() set.Set[ids.ShortID] {
+	return m.first.Addresses().Union(m.second.Addresses())
+}
+
+func (m *mergedKeychain) Range(f func(addr ids.ShortID, signer Signer) bool) {
+	for addr := range m.Addresses() {
+		signer, ok := m.Get(addr)
+		if !ok {
+			continue
+		}
+		if !f(addr, signer) {
+			return
+		}
+	}
+}
+
+// IsHardwareSigner reports whether [signer] signs with a key held by a
+// hardware device, such as a ledger, smart card, TPM or Secure Enclave,
+// looking through signers that implement Unwrapper
+func IsHardwareSigner(signer Signer) bool {
+	for signer != nil {
+		switch signer.(type) {
+		case *ledgerSigner, *hardwareSigner, *pivSigner, *openPGPSigner, *tpmSigner, *secureEnclaveSigner:
+			return true
+		}
+		u, ok := signer.(Unwrapper)
+		if !ok {
+			return false
+		}
+		signer = u.Unwrap()
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	// The wallet key of the shared address is also imported in software
+	wallet := &fakeHardwareWallet{seed: []byte("merge")}
+	hw, err := NewHardwareWalletKeychain(wallet, []DerivationPath{AddressPath(0)})
+	require.NoError(t, err)
+	key, err := wallet.key(AddressPath(0))
+	require.NoError(t, err)
+	other, err := DeterministicKey([]byte("merge"), 1)
+	require.NoError(t, err)
+	sw := NewSoftwareKeychain(key, other)
+	shared := key.Address()
+
+	tests := []struct {
+		name     string
+		first    Keychain
+		second   Keychain
+		policy   ConflictPolicy
+		hardware bool
+	}{
+		{
+			name:     "prefer first software",
+			first:    sw,
+			second:   hw,
+			policy:   ConflictPreferFirst,
+			hardware: false,
+		},
+		{
+			name:     "prefer first hardware",
+			first:    hw,
+			second:   sw,
+			policy:   ConflictPreferFirst,
+			hardware: true,
+		},
+		{
+			name:     "prefer hardware second",
+			first:    sw,
+			second:   hw,
+			policy:   ConflictPreferHardware,
+			hardware: true,
+		},
+		{
+			name:     "prefer hardware wrapped",
+			first:    sw,
+			second:   Wrap(hw, Intercept(noopInterceptor)),
+			policy:   ConflictPreferHardware,
+			hardware: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kc, err := Merge(test.first, test.second, test.policy)
+			require.NoError(t, err)
+			require.Equal(t, 2, kc.Addresses().Len())
+			require.True(t, kc.Addresses().Contains(other.Address()))
+
+			signer, ok := kc.Get(shared)
+			require.True(t, ok)
+			require.Equal(t, test.hardware, IsHardwareSigner(signer))
+			require.Equal(t, shared, signer.Address())
+		})
+	}
+}
+
+func TestMergeConflictError(t *testing.T) {
+	require := require.New(t)
+
+	a, err := NewTestKeychain([]byte("merge"), 2)
+	require.NoError(err)
+	b, err := NewTestKeychain([]byte("merge"), 1)
+	require.NoError(err)
+	_, err = Merge(a, b, ConflictError)
+	require.ErrorIs(err, ErrAddressConflict)
+
+	c, err := NewTestKeychain([]byte("other"), 1)
+	require.NoError(err)
+	kc, err := Merge(a, c, ConflictError)
+	require.NoError(err)
+	require.Equal(3, kc.Addresses().Len())
+
+	var ranged int
+	Range(kc, func(addr ids.ShortID, signer Signer) bool {
+		require.Equal(addr, signer.Address())
+		ranged++
+		return true
+	})
+	require.Equal(3, ranged)
+
+	_, err = Merge(a, c, ConflictPolicy(42))
+	require.ErrorIs(err, ErrUnknownConflictPolicy)
+}
@@ -0,0 +1,173 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var _ MetadataKeychain = (*metadataKeychain)(nil)
+
+// AccountMetadata describes the account of an address for display purposes
+type AccountMetadata struct {
+	Address        ids.ShortID `json:"address"`
+	Label          string      `json:"label,omitempty"`
+	Created        time.Time   `json:"created,omitzero"`
+	DerivationPath string      `json:"derivationPath,omitempty"`
+	Tags           []string    `json:"tags,omitempty"`
+}
+
+// HasTag returns true if the account is tagged with [tag]
+func (m *AccountMetadata) HasTag(tag string) bool {
+	return slices.Contains(m.Tags, tag)
+}
+
+// MetadataKeychain is a keychain that records metadata about its accounts
+type MetadataKeychain interface {
+	Keychain
+	// Metadata returns the metadata of [addr], or false if none is recorded
+	Metadata(addr ids.ShortID) (AccountMetadata, bool)
+	// SetMetadata records the metadata of the address it describes, which
+	// must be held by the keychain
+	SetMetadata(metadata AccountMetadata) error
+	// DeleteMetadata removes the metadata of [addr]
+	DeleteMetadata(addr ids.ShortID)
+	// Accounts returns the metadata of every address of the keychain, ordered
+	// by address. Addresses without recorded metadata only have their
+	// Address set.
+	Accounts() []AccountMetadata
+	// Lookup returns the address labelled [label]. Labels are not unique: if
+	// several addresses share [label], the lowest of them is returned.
+	Lookup(label string) (ids.ShortID, bool)
+	// Tagged returns the addresses tagged with [tag]
+	Tagged(tag string) set.Set[ids.ShortID]
+}
+
+type metadataKeychain struct {
+	Keychain
+
+	lock     sync.RWMutex
+	metadata map[ids.ShortID]AccountMetadata
+}
+
+// NewMetadataKeychain wraps [kc] with a metadata store initialized with
+// [metadata]. Metadata of addresses not held by [kc] is ignored.
+func NewMetadataKeychain(kc Keychain, metadata ...AccountMetadata) MetadataKeychain {
+	m := &metadataKeychain{
+		Keychain: kc,
+		metadata: make(map[ids.ShortID]AccountMetadata, len(metadata)),
+	}
+	addrs := kc.Addresses()
+	for _, md := range metadata {
+		if addrs.Contains(md.Address) {
+			m.metadata[md.Address] = cloneMetadata(md)
+		}
+	}
+	return m
+}
+
+func (m *metadataKeychain) Metadata(addr ids.ShortID) (AccountMetadata, bool) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	md, ok := m.metadata[addr]
+	return cloneMetadata(md), ok
+}
+
+func (m *metadataKeychain) SetMetadata(metadata AccountMetadata) error {
+	if !m.Addresses().Contains(metadata.Address) {
+		return ErrUnknownAddress
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.metadata[metadata.Address] = cloneMetadata(metadata)
+	return nil
+}
+
+func (m *metadataKeychain) DeleteMetadata(addr ids.ShortID) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.metadata, addr)
+}
+
+func (m *metadataKeychain) Accounts() []AccountMetadata {
+	addrs := m.Addresses().List()
+	slices.SortFunc(addrs, ids.ShortID.Compare)
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	accounts := make([]AccountMetadata, len(addrs))
+	for i, addr := range addrs {
+		md, ok := m.metadata[addr]
+		if !ok {
+			md = AccountMetadata{Address: addr}
+		}
+		accounts[i] = cloneMetadata(md)
+	}
+	return accounts
+}
+
+func (m *metadataKeychain) Lookup(label string) (ids.ShortID, bool) {
+	addrs := m.Addresses()
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	var (
+		found ids.ShortID
+		ok    bool
+	)
+	for addr, md := range m.metadata {
+		if md.Label != label || !addrs.Contains(addr) {
+			continue
+		}
+		if !ok || addr.Compare(found) < 0 {
+			found, ok = addr, true
+		}
+	}
+	return found, ok
+}
+
+func (m *metadataKeychain) Tagged(tag string) set.Set[ids.ShortID] {
+	addrs := m.Addresses()
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	tagged := set.NewSet[ids.ShortID](0)
+	for addr, md := range m.metadata {
+		if md.HasTag(tag) && addrs.Contains(addr) {
+			tagged.Add(addr)
+		}
+	}
+	return tagged
+}
+
+// records returns the recorded metadata of the addresses held by the
+// keychain
+func (m *metadataKeychain) records() []AccountMetadata {
+	accounts := m.Accounts()
+
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return slices.DeleteFunc(accounts, func(md AccountMetadata) bool {
+		_, ok := m.metadata[md.Address]
+		return !ok
+	})
+}
+
+func cloneMetadata(md AccountMetadata) AccountMetadata {
+	md.Tags = slices.Clone(md.Tags)
+	return md
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+func TestMetadataKeychain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("metadata"), 3)
+	require.NoError(err)
+	keys := kc.Keys()
+	addr0, addr1, addr2 := keys[0].Address(), keys[1].Address(), keys[2].Address()
+
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mkc := NewMetadataKeychain(kc,
+		AccountMetadata{
+			Address: addr0,
+			Label:   "savings",
+			Created: created,
+			Tags:    []string{"cold"},
+		},
+		AccountMetadata{
+			Address: ids.GenerateTestShortID(),
+			Label:   "unknown",
+		},
+	)
+	require.True(mkc.Addresses().Equals(kc.Addresses()))
+	_, ok := mkc.Get(addr0)
+	require.True(ok)
+
+	md, ok := mkc.Metadata(addr0)
+	require.True(ok)
+	require.Equal("savings", md.Label)
+	require.Equal(created, md.Created)
+	_, ok = mkc.Lookup("unknown")
+	require.False(ok)
+
+	require.NoError(mkc.SetMetadata(AccountMetadata{
+		Address:        addr1,
+		Label:          "spending",
+		DerivationPath: "m/44'/9000'/0'/0/1",
+		Tags:           []string{"hot", "cold"},
+	}))
+	require.ErrorIs(mkc.SetMetadata(AccountMetadata{Address: ids.GenerateTestShortID()}), ErrUnknownAddress)
+
+	addr, ok := mkc.Lookup("spending")
+	require.True(ok)
+	require.Equal(addr1, addr)
+	require.True(mkc.Tagged("cold").Equals(set.Of(addr0, addr1)))
+	require.True(mkc.Tagged("hot").Equals(set.Of(addr1)))
+
+	// The lowest of the addresses sharing a label is looked up
+	require.NoError(mkc.SetMetadata(AccountMetadata{Address: addr2, Label: "savings"}))
+	for range 10 {
+		addr, ok = mkc.Lookup("savings")
+		require.True(ok)
+		require.Equal(addr0, addr)
+	}
+	mkc.DeleteMetadata(addr2)
+
+	accounts := mkc.Accounts()
+	require.Len(accounts, 3)
+	require.Equal(addr0, accounts[0].Address)
+	require.Equal("savings", accounts[0].Label)
+	require.Equal("spending", accounts[1].Label)
+	require.Equal(AccountMetadata{Address: addr2}, accounts[2])
+
+	// Returned metadata is a copy
+	accounts[0].Tags[0] = "modified"
+	md, _ = mkc.Metadata(addr0)
+	require.Equal([]string{"cold"}, md.Tags)
+
+	// Metadata of removed addresses is hidden
+	require.True(kc.Remove(addr1))
+	_, ok = mkc.Lookup("spending")
+	require.False(ok)
+	require.Len(mkc.Accounts(), 2)
+
+	mkc.DeleteMetadata(addr0)
+	_, ok = mkc.Metadata(addr0)
+	require.False(ok)
+}
+
+func TestBundleAccountMetadata(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	kc, err := NewTestKeychain([]byte("metadata"), 2)
+	require.NoError(err)
+	addr := kc.Keys()[0].Address()
+	mkc := NewMetadataKeychain(kc)
+	require.NoError(mkc.SetMetadata(AccountMetadata{
+		Address: addr,
+		Label:   "savings",
+	}))
+
+	b, err := NewBundle(mkc)
+	require.NoError(err)
+	require.Len(b.Keys, 2)
+
+	pass := []byte("password")
+	var buf bytes.Buffer
+	require.NoError(b.Export(&buf, pass))
+	imported, err := Import(&buf, pass)
+	require.NoError(err)
+
+	restored := imported.MetadataKeychain(imported.SoftwareKeychain())
+	require.True(restored.Addresses().Equals(kc.Addresses()))
+	md, ok := restored.Metadata(addr)
+	require.True(ok)
+	require.Equal("savings", md.Label)
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// Operations passed to an Interceptor
+const (
+	OpSign     SignOp = "sign"
+	OpSignHash SignOp = "signHash"
+)
+
+var (
+	_ RangeKeychain = (*middlewareKeychain)(nil)
+	_ Signer        = (*interceptedSigner)(nil)
+)
+
+// Middleware wraps a signer to add behavior, such as logging, metrics,
+// policy checks or retries, around its signing operations
+type Middleware func(next Signer) Signer
+
+// SignOp identifies the signing operation seen by an Interceptor
+type SignOp string
+
+// Interceptor is called in place of a signing operation of [signer]. It may
+// inspect or reject [payload] and calls [next] to perform the operation.
+type Interceptor func(signer Signer, op SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error)
+
+// Chain composes [middlewares] into one. The first middleware is the
+// outermost, so it sees each request first and each result last.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next Signer) Signer {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// Intercept returns a middleware that routes both signing operations through
+// [interceptor]
+func Intercept(interceptor Interceptor) Middleware {
+	return func(next Signer) Signer {
+		return &interceptedSigner{
+			next:        next,
+			interceptor: interceptor,
+		}
+	}
+}
+
+// Unwrapper is implemented by signers that wrap another signer
+type Unwrapper interface {
+	Unwrap() Signer
+}
+
+// PublicKeyOf returns the public key of [signer], looking through wrapping
+// signers that implement Unwrapper
+func PublicKeyOf(signer Signer) ([]byte, bool) {
+	for signer != nil {
+		if pkSigner, ok := signer.(PublicKeySigner); ok {
+			pubKey := pkSigner.PublicKey()
+			return pubKey, pubKey != nil
+		}
+		u, ok := signer.(Unwrapper)
+		if !ok {
+			break
+		}
+		signer = u.Unwrap()
+	}
+	return nil, false
+}
+
+type interceptedSigner struct {
+	next        Signer
+	interceptor Interceptor
+}
+
+func (i *interceptedSigner) SignHash(hash []byte) ([]byte, error) {
+	return i.interceptor(i.next, OpSignHash, hash, i.next.SignHash)
+}
+
+func (i *interceptedSigner) Sign(msg []byte) ([]byte, error) {
+	return i.interceptor(i.next, OpSign, msg, i.next.Sign)
+}
+
+func (i *interceptedSigner) Address() ids.ShortID {
+	return i.next.Address()
+}
+
+func (i *interceptedSigner) Unwrap() Signer {
+	return i.next
+}
+
+// middlewareKeychain applies a middleware to every signer of a keychain
+type middlewareKeychain struct {
+	keychain   Keychain
+	middleware Middleware
+}
+
+// Wrap returns a keychain whose signers are the signers of [keychain]
+// wrapped with [middlewares], composed as by Chain
+func Wrap(keychain Keychain, middlewares ...Middleware) Keychain {
+	return &middlewareKeychain{
+		keychain:   keychain,
+		middleware: Chain(middlewares...),
+	}
+}
+
+func (m *middlewareKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	signer, ok := m.keychain.Get(addr)
+	if !ok {
+		return nil, false
+	}
+	return m.middleware(signer), true
+}
+
+func (m *middlewareKeychain) Addresses() set.Set[ids.ShortID] {
+	return m.keychain.Addresses()
+}
+
+func (m *middlewareKeychain) Range(f func(ids.ShortID, Signer) bool) {
+	Range(m.keychain, func(addr ids.ShortID, signer Signer) bool {
+		return f(addr, m.middleware(signer))
+	})
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+)
+
+var errPolicy = errors.New("rejected by policy")
+
+func TestChain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("middleware"), 2)
+	require.NoError(err)
+	key := kc.Keys()[0]
+
+	var calls []string
+	record := func(name string) Middleware {
+		return Intercept(func(_ Signer, op SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+			calls = append(calls, name+":"+string(op))
+			return next(payload)
+		})
+	}
+	policy := Intercept(func(_ Signer, op SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		if op == OpSignHash {
+			return nil, errPolicy
+		}
+		return next(payload)
+	})
+
+	wrapped := Wrap(kc, record("outer"), record("inner"), policy)
+	require.True(wrapped.Addresses().Equals(kc.Addresses()))
+
+	signer, ok := wrapped.Get(key.Address())
+	require.True(ok)
+	require.Equal(key.Address(), signer.Address())
+
+	msg := []byte("payload")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.True(key.PublicKey().Verify(msg, sig))
+	require.Equal([]string{"outer:sign", "inner:sign"}, calls)
+
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, errPolicy)
+
+	// Wrapped signers still expose their public key
+	pubKey, ok := PublicKeyOf(signer)
+	require.True(ok)
+	require.Equal(key.PublicKey().Bytes(), pubKey)
+
+	var n int
+	for addr, signer := range Iter(wrapped) {
+		require.Equal(addr, signer.Address())
+		_, ok := signer.(Unwrapper)
+		require.True(ok)
+		n++
+	}
+	require.Equal(2, n)
+
+	_, ok = wrapped.Get(ids.GenerateTestShortID())
+	require.False(ok)
+}
+
+func TestPublicKeyOf(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewLedgerKeychain(newMockLedger(), []uint32{1})
+	require.NoError(err)
+	signer, ok := kc.Get(kc.Addresses().List()[0])
+	require.True(ok)
+
+	_, ok = PublicKeyOf(Chain(Timeout(0))(signer))
+	require.False(ok)
+	_, ok = PublicKeyOf(nil)
+	require.False(ok)
+}
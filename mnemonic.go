@@ -0,0 +1,224 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/luxfi/ids"
+)
+
+// mnemonicVerifierLen is the length of the scrypt hash of the export
+// password of a mnemonic keychain
+const mnemonicVerifierLen = 32
+
+var (
+	ErrInvalidMnemonic          = errors.New("invalid mnemonic")
+	ErrInvalidMnemonicWordCount = errors.New("mnemonic must have 12, 15, 18, 21 or 24 words")
+	ErrNotMnemonicKeychain      = errors.New("keychain was not derived from a mnemonic")
+	ErrMnemonicExportDisabled   = errors.New("mnemonic export was not enabled for this keychain")
+	ErrIncorrectPassword        = errors.New("incorrect password")
+	ErrMnemonicMismatch         = errors.New("mnemonic does not match the keychain")
+)
+
+// mnemonicSource records the mnemonic a software keychain was derived from
+type mnemonicSource struct {
+	// path and addr are the path of the first derived address and the
+	// address, against which re-entered mnemonics are verified
+	path DerivationPath
+	addr ids.ShortID
+	// entropy is the entropy of the mnemonic. It is only kept if export was
+	// enabled, in which case verifier is the scrypt hash of the export
+	// password with salt.
+	entropy  *secureBuffer
+	salt     []byte
+	verifier []byte
+}
+
+func newMnemonicSource(mnemonic string, path DerivationPath, addr ids.ShortID, o *options) (*mnemonicSource, error) {
+	m := &mnemonicSource{
+		path: path,
+		addr: addr,
+	}
+	if !o.exportMnemonic {
+		return m, nil
+	}
+
+	entropy, err := bip39.EntropyFromMnemonic(mnemonic)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMnemonic, err)
+	}
+	defer clear(entropy)
+
+	m.salt = make([]byte, bundleSaltLen)
+	if _, err := rand.Read(m.salt); err != nil {
+		return nil, err
+	}
+	m.verifier, err = mnemonicVerifier(o.exportPass, m.salt)
+	if err != nil {
+		return nil, err
+	}
+	m.entropy = newSecureBuffer(entropy)
+	return m, nil
+}
+
+func mnemonicVerifier(pass, salt []byte) ([]byte, error) {
+	return scrypt.Key(pass, salt, bundleScrypt.N, bundleScrypt.R, bundleScrypt.P, mnemonicVerifierLen)
+}
+
+// NewMnemonic returns a random English BIP39 mnemonic of [words] words
+func NewMnemonic(words int) (string, error) {
+	if words < 12 || words > 24 || words%3 != 0 {
+		return "", ErrInvalidMnemonicWordCount
+	}
+	entropy, err := bip39.NewEntropy(words * 32 / 3)
+	if err != nil {
+		return "", err
+	}
+	defer clear(entropy)
+	return bip39.NewMnemonic(entropy)
+}
+
+// ValidateMnemonic checks the words and checksum of an English BIP39
+// mnemonic
+func ValidateMnemonic(mnemonic string) error {
+	if _, err := bip39.MnemonicToByteArray(normalizeMnemonic(mnemonic)); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMnemonic, err)
+	}
+	return nil
+}
+
+// MnemonicSeed returns the BIP39 seed of [mnemonic] protected by
+// [passphrase], which may be empty
+func MnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(normalizeMnemonic(mnemonic), passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMnemonic, err)
+	}
+	return seed, nil
+}
+
+// NewMnemonicKeychain returns a software keychain holding the keys of
+// address [indices] of [mnemonic], derived at AddressPath(i) as done by Lux
+// wallets and the Lux ledger app. WithCoinType and WithPurpose derive them at
+// the paths of other wallets instead.
+//
+// The keychain can verify a re-entered mnemonic with VerifyMnemonic. It only
+// keeps the mnemonic itself, for ExportMnemonic, if WithMnemonicExport is
+// given.
+func NewMnemonicKeychain(mnemonic, passphrase string, indices []uint32, opts ...Option) (*SoftwareKeychain, error) {
+	if len(indices) == 0 {
+		return nil, ErrInvalidIndicesLength
+	}
+	mnemonic = normalizeMnemonic(mnemonic)
+	seed, err := MnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(seed)
+
+	o := newOptions(opts)
+	kc := NewSoftwareKeychain()
+	if o.sealKeys {
+		kc = NewSealedSoftwareKeychain()
+	}
+	for i, idx := range indices {
+		path := o.addressPath(idx)
+		key, err := DeriveKey(seed, path)
+		if err != nil {
+			_ = kc.Destroy()
+			return nil, err
+		}
+		kc.Add(key)
+		logDebug(o.logger, "derived mnemonic address", "path", path, "address", key.Address())
+		if i == 0 {
+			kc.mnemonic, err = newMnemonicSource(mnemonic, path, key.Address(), o)
+		}
+		WipeKey(key)
+		if err != nil {
+			_ = kc.Destroy()
+			return nil, err
+		}
+	}
+	logDebug(o.logger, "created mnemonic keychain", "addresses", kc.Addresses().Len())
+	return kc, nil
+}
+
+// GenerateMnemonicKeychain generates a mnemonic of [words] words and returns
+// a keychain of its address [indices], as NewMnemonicKeychain. The mnemonic
+// can only be recovered with ExportMnemonic if WithMnemonicExport is given.
+func GenerateMnemonicKeychain(words int, passphrase string, indices []uint32, opts ...Option) (*SoftwareKeychain, error) {
+	mnemonic, err := NewMnemonic(words)
+	if err != nil {
+		return nil, err
+	}
+	return NewMnemonicKeychain(mnemonic, passphrase, indices, opts...)
+}
+
+// ExportMnemonic returns the recovery phrase of a keychain created with
+// WithMnemonicExport, guarded by the export password [pass]
+func (kc *SoftwareKeychain) ExportMnemonic(pass []byte) (string, error) {
+	m := kc.mnemonic
+	switch {
+	case m == nil:
+		return "", ErrNotMnemonicKeychain
+	case m.entropy == nil:
+		return "", ErrMnemonicExportDisabled
+	}
+
+	verifier, err := mnemonicVerifier(pass, m.salt)
+	if err != nil {
+		return "", err
+	}
+	if subtle.ConstantTimeCompare(verifier, m.verifier) != 1 {
+		return "", ErrIncorrectPassword
+	}
+
+	var mnemonic string
+	err = m.entropy.use(func(entropy []byte) error {
+		var err error
+		mnemonic, err = bip39.NewMnemonic(entropy)
+		return err
+	})
+	return mnemonic, err
+}
+
+// VerifyMnemonic checks that [mnemonic], as re-entered by the user together
+// with [passphrase], is a valid BIP39 mnemonic, returning ErrInvalidMnemonic
+// on a mistyped word or checksum, and that it derives the keychain's keys,
+// returning ErrMnemonicMismatch otherwise
+func (kc *SoftwareKeychain) VerifyMnemonic(mnemonic, passphrase string) error {
+	m := kc.mnemonic
+	if m == nil {
+		return ErrNotMnemonicKeychain
+	}
+	seed, err := MnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return err
+	}
+	defer clear(seed)
+
+	key, err := DeriveKey(seed, m.path)
+	if err != nil {
+		return err
+	}
+	defer WipeKey(key)
+	if key.Address() != m.addr {
+		return ErrMnemonicMismatch
+	}
+	return nil
+}
+
+// normalizeMnemonic collapses the whitespace between the words of
+// [mnemonic]
+func normalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(mnemonic), " ")
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestMnemonicSeed(t *testing.T) {
+	require := require.New(t)
+
+	// BIP39 test vector
+	seed, err := MnemonicSeed(testMnemonic, "TREZOR")
+	require.NoError(err)
+	require.Equal("c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04", hex.EncodeToString(seed))
+
+	// Whitespace between words is not significant
+	spaced, err := MnemonicSeed("  "+strings.ReplaceAll(testMnemonic, " ", "\n\t")+" ", "TREZOR")
+	require.NoError(err)
+	require.Equal(seed, spaced)
+
+	_, err = MnemonicSeed(strings.Replace(testMnemonic, "about", "abandon", 1), "")
+	require.ErrorIs(err, ErrInvalidMnemonic)
+}
+
+func TestNewMnemonic(t *testing.T) {
+	require := require.New(t)
+
+	for _, words := range []int{12, 15, 18, 21, 24} {
+		mnemonic, err := NewMnemonic(words)
+		require.NoError(err)
+		require.Len(strings.Fields(mnemonic), words)
+		require.NoError(ValidateMnemonic(mnemonic))
+	}
+
+	for _, words := range []int{0, 11, 13, 27} {
+		_, err := NewMnemonic(words)
+		require.ErrorIs(err, ErrInvalidMnemonicWordCount)
+	}
+
+	require.ErrorIs(ValidateMnemonic("not a mnemonic"), ErrInvalidMnemonic)
+}
+
+func TestNewMnemonicKeychain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewMnemonicKeychain(testMnemonic, "", []uint32{0, 1, 2})
+	require.NoError(err)
+	require.Equal(3, kc.Addresses().Len())
+
+	seed, err := MnemonicSeed(testMnemonic, "")
+	require.NoError(err)
+	for _, idx := range []uint32{0, 1, 2} {
+		key, err := DeriveKey(seed, AddressPath(idx))
+		require.NoError(err)
+		require.True(kc.Addresses().Contains(key.Address()))
+	}
+
+	_, err = NewMnemonicKeychain(testMnemonic, "", nil)
+	require.ErrorIs(err, ErrInvalidIndicesLength)
+	_, err = NewMnemonicKeychain("not a mnemonic", "", []uint32{0})
+	require.ErrorIs(err, ErrInvalidMnemonic)
+}
+
+func TestMnemonicKeychainCoinType(t *testing.T) {
+	require := require.New(t)
+
+	// The first account of the mnemonic in Ethereum wallets
+	kc, err := NewMnemonicKeychain(testMnemonic, "", []uint32{0}, WithCoinType(EthereumCoinType))
+	require.NoError(err)
+	evmAddr, err := EVMAddress(kc.Keys()[0].PublicKey().Bytes())
+	require.NoError(err)
+	require.Equal("0x9858EfFD232B4033E47d90003D41EC34EcaEda94", evmAddr.Hex())
+
+	seed, err := MnemonicSeed(testMnemonic, "")
+	require.NoError(err)
+	key, err := DeriveKey(seed, BIP44Path(49, EthereumCoinType, 1))
+	require.NoError(err)
+	kc, err = NewMnemonicKeychain(testMnemonic, "", []uint32{1}, WithPurpose(49), WithCoinType(EthereumCoinType))
+	require.NoError(err)
+	require.True(kc.Addresses().Contains(key.Address()))
+
+	// Re-entered mnemonics are verified at the configured paths
+	require.NoError(kc.VerifyMnemonic(testMnemonic, ""))
+
+	// The Lux app only derives Lux paths
+	_, err = NewLedgerKeychain(NewLedgerDevice(newFakeLuxApp()), []uint32{0}, WithCoinType(EthereumCoinType))
+	require.ErrorIs(err, ErrUnsupportedDerivationPath)
+	_, err = NewLazyLedgerKeychain(NewLedgerDevice(newFakeLuxApp()), []uint32{0}, WithPurpose(49))
+	require.ErrorIs(err, ErrUnsupportedDerivationPath)
+}
+
+func TestExportMnemonic(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	pass := []byte("recovery password")
+	kc, err := GenerateMnemonicKeychain(24, "", []uint32{0, 1}, WithMnemonicExport(pass))
+	require.NoError(err)
+
+	_, err = kc.ExportMnemonic([]byte("wrong"))
+	require.ErrorIs(err, ErrIncorrectPassword)
+	mnemonic, err := kc.ExportMnemonic(pass)
+	require.NoError(err)
+	require.Len(strings.Fields(mnemonic), 24)
+
+	// The exported phrase recovers the same keys
+	recovered, err := NewMnemonicKeychain(mnemonic, "", []uint32{0, 1})
+	require.NoError(err)
+	require.True(recovered.Addresses().Equals(kc.Addresses()))
+
+	require.NoError(kc.Destroy())
+	_, err = kc.ExportMnemonic(pass)
+	require.ErrorIs(err, ErrKeyDestroyed)
+}
+
+func TestExportMnemonicRequiresOptIn(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewMnemonicKeychain(testMnemonic, "", []uint32{0})
+	require.NoError(err)
+	_, err = kc.ExportMnemonic(nil)
+	require.ErrorIs(err, ErrMnemonicExportDisabled)
+
+	_, err = NewSoftwareKeychain().ExportMnemonic(nil)
+	require.ErrorIs(err, ErrNotMnemonicKeychain)
+}
+
+func TestVerifyMnemonic(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewMnemonicKeychain(testMnemonic, "passphrase", []uint32{3, 4})
+	require.NoError(err)
+
+	// Whitespace does not matter when re-entering the phrase
+	require.NoError(kc.VerifyMnemonic("  "+strings.ReplaceAll(testMnemonic, " ", "\n")+" ", "passphrase"))
+
+	// A mistyped word fails the checksum
+	mistyped := strings.Replace(testMnemonic, "about", "above", 1)
+	require.ErrorIs(kc.VerifyMnemonic(mistyped, "passphrase"), ErrInvalidMnemonic)
+
+	// A valid phrase or passphrase of another wallet does not match
+	other, err := NewMnemonic(12)
+	require.NoError(err)
+	require.ErrorIs(kc.VerifyMnemonic(other, "passphrase"), ErrMnemonicMismatch)
+	require.ErrorIs(kc.VerifyMnemonic(testMnemonic, ""), ErrMnemonicMismatch)
+
+	require.ErrorIs(NewSoftwareKeychain().VerifyMnemonic(testMnemonic, ""), ErrNotMnemonicKeychain)
+}
@@ -0,0 +1,244 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// Signing keys of an OpenPGP card, identified by the tag of their control
+// reference template. The decryption key cannot sign and is not supported.
+const (
+	// OpenPGPKeySignature signs with PSO: COMPUTE DIGITAL SIGNATURE
+	OpenPGPKeySignature OpenPGPKey = 0xb6
+	// OpenPGPKeyAuthentication signs with INTERNAL AUTHENTICATE
+	OpenPGPKeyAuthentication OpenPGPKey = 0xa4
+)
+
+var (
+	ErrInvalidOpenPGPKeysLength     = errors.New("number of keys should be greater than 0")
+	ErrUnsupportedOpenPGPKey        = errors.New("OpenPGP key cannot sign")
+	ErrUnsupportedOpenPGPAlgorithm  = errors.New("unsupported OpenPGP key algorithm")
+	ErrOpenPGPPINRequired           = errors.New("OpenPGP card requires a PIN")
+	ErrDuplicateOpenPGPKey          = errors.New("OpenPGP keys are the same key")
+	ErrOpenPGPSignatureVerification = errors.New("OpenPGP signature does not verify")
+
+	_ PublicKeySigner = (*openPGPSigner)(nil)
+	_ SchemeSigner    = (*openPGPSigner)(nil)
+)
+
+// OpenPGPKey identifies a signing key of an OpenPGP card
+type OpenPGPKey uint8
+
+func (k OpenPGPKey) String() string {
+	switch k {
+	case OpenPGPKeySignature:
+		return "signature"
+	case OpenPGPKeyAuthentication:
+		return "authentication"
+	default:
+		return fmt.Sprintf("key %#x", uint8(k))
+	}
+}
+
+// OpenPGPKeyInfo describes a key of an OpenPGP card
+type OpenPGPKeyInfo struct {
+	// PublicKey is a P-256 or secp256k1 public key
+	PublicKey *ecdsa.PublicKey
+	// PINPerSignature is set if the card requires the user PIN for every
+	// signature, as the signature key does unless the PW1 status byte allows
+	// several signatures per verification
+	PINPerSignature bool
+	// Touch is set if the user interaction flag of the key requires a touch
+	// of the token, as on YubiKeys and Nitrokeys
+	Touch bool
+}
+
+// OpenPGPCard is an OpenPGP card applet, such as the one of a Nitrokey,
+// YubiKey or GnuPG smart card, capable of signing with its ECDSA keys.
+// Implementations typically wrap a PC/SC library.
+type OpenPGPCard interface {
+	// KeyInfo returns the public key and policies of [key]
+	KeyInfo(key OpenPGPKey) (OpenPGPKeyInfo, error)
+	// Sign verifies the user PIN [pin] and returns the r || s ECDSA signature
+	// of [digest] by [key]
+	Sign(key OpenPGPKey, pin string, digest []byte) ([]byte, error)
+}
+
+// OpenPGPConfig configures the PIN and touch handling of an OpenPGP keychain
+type OpenPGPConfig struct {
+	// PIN is called for the user PIN, which the card requires to sign
+	PIN func() (string, error)
+	// OnTouch, if set, is called before signing with a key that requires a
+	// touch, so that the user can be prompted to touch the token
+	OnTouch func(key OpenPGPKey)
+}
+
+// openPGPKeychain is a keychain of the signing keys of an OpenPGP card
+type openPGPKeychain struct {
+	card   OpenPGPCard
+	config OpenPGPConfig
+	addrs  set.Set[ids.ShortID]
+	keys   map[ids.ShortID]*openPGPKey
+
+	// pin is cached after its first successful use, and reused by the keys
+	// that do not require the PIN for every signature
+	lock sync.Mutex
+	pin  string
+}
+
+type openPGPKey struct {
+	key    OpenPGPKey
+	info   OpenPGPKeyInfo
+	pubKey []byte
+}
+
+// NewOpenPGPKeychain creates a keychain of the keys [keys] of [card], so that
+// tokens already used for PGP can hold Lux signing keys.
+//
+// Signatures by secp256k1 keys are returned in the 65 byte recoverable format
+// used by Lux credentials. Signatures by P-256 keys are returned as 64 byte
+// r || s. All signatures are normalized to low-S.
+func NewOpenPGPKeychain(card OpenPGPCard, keys []OpenPGPKey, config OpenPGPConfig) (Keychain, error) {
+	if len(keys) == 0 {
+		return nil, ErrInvalidOpenPGPKeysLength
+	}
+
+	kc := &openPGPKeychain{
+		card:   card,
+		config: config,
+		addrs:  make(set.Set[ids.ShortID]),
+		keys:   make(map[ids.ShortID]*openPGPKey),
+	}
+	for _, key := range keys {
+		if key != OpenPGPKeySignature && key != OpenPGPKeyAuthentication {
+			return nil, fmt.Errorf("%s: %w", key, ErrUnsupportedOpenPGPKey)
+		}
+		info, err := card.KeyInfo(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if info.PublicKey == nil || !isPIVCurve(info.PublicKey.Curve) {
+			return nil, fmt.Errorf("%s: %w", key, ErrUnsupportedOpenPGPAlgorithm)
+		}
+
+		pubKey := compressPublicKey(info.PublicKey.X, info.PublicKey.Y)
+		addr := publicKeyAddress(pubKey)
+		if kc.addrs.Contains(addr) {
+			return nil, ErrDuplicateOpenPGPKey
+		}
+		kc.addrs.Add(addr)
+		kc.keys[addr] = &openPGPKey{
+			key:    key,
+			info:   info,
+			pubKey: pubKey,
+		}
+	}
+	return kc, nil
+}
+
+func (o *openPGPKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	key, ok := o.keys[addr]
+	if !ok {
+		return nil, false
+	}
+	return &openPGPSigner{
+		keychain: o,
+		key:      key,
+		addr:     addr,
+	}, true
+}
+
+func (o *openPGPKeychain) Addresses() set.Set[ids.ShortID] {
+	return o.addrs
+}
+
+// sign signs [digest] with [key], prompting for the PIN unless it is cached
+// and the key accepts it
+func (o *openPGPKeychain) sign(key *openPGPKey, digest []byte) ([]byte, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	pin := o.pin
+	if pin == "" || key.info.PINPerSignature {
+		if o.config.PIN == nil {
+			return nil, ErrOpenPGPPINRequired
+		}
+		var err error
+		pin, err = o.config.PIN()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if key.info.Touch && o.config.OnTouch != nil {
+		o.config.OnTouch(key.key)
+	}
+
+	sig, err := o.card.Sign(key.key, pin, digest)
+	if err != nil {
+		o.pin = ""
+		return nil, err
+	}
+	o.pin = pin
+	return sig, nil
+}
+
+// openPGPSigner signs with a single key of an OpenPGP card
+type openPGPSigner struct {
+	keychain *openPGPKeychain
+	key      *openPGPKey
+	addr     ids.ShortID
+}
+
+func (o *openPGPSigner) SignHash(hash []byte) ([]byte, error) {
+	raw, err := o.keychain.sign(o.key, hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != 64 {
+		return nil, ErrInvalidSignatureEncoding
+	}
+	r := new(big.Int).SetBytes(raw[:32])
+	s := new(big.Int).SetBytes(raw[32:])
+
+	pub := o.key.info.PublicKey
+	if !ecdsa.Verify(pub, hash, r, s) {
+		return nil, ErrOpenPGPSignatureVerification
+	}
+	sig := compactSignature(pub.Curve, r, s)
+	if pub.Curve == secp256k1.S256() {
+		return recoverableSignature(hash, sig, o.addr)
+	}
+	return sig, nil
+}
+
+func (o *openPGPSigner) Sign(msg []byte) ([]byte, error) {
+	hash := sha256.Sum256(msg)
+	return o.SignHash(hash[:])
+}
+
+func (o *openPGPSigner) Address() ids.ShortID {
+	return o.addr
+}
+
+func (o *openPGPSigner) PublicKey() []byte {
+	return o.key.pubKey
+}
+
+func (o *openPGPSigner) Scheme() SchemeID {
+	if o.key.info.PublicKey.Curve == secp256k1.S256() {
+		return SchemeSecp256k1
+	}
+	return SchemeP256
+}
@@ -0,0 +1,204 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+)
+
+type mockOpenPGPKey struct {
+	info      OpenPGPKeyInfo
+	p256      *ecdsa.PrivateKey
+	secp256k1 *secp256k1.PrivateKey
+}
+
+// mockOpenPGPCard signs with software keys
+type mockOpenPGPCard struct {
+	pin   string
+	keys  map[OpenPGPKey]*mockOpenPGPKey
+	signs int
+}
+
+func (m *mockOpenPGPCard) KeyInfo(key OpenPGPKey) (OpenPGPKeyInfo, error) {
+	return m.keys[key].info, nil
+}
+
+func (m *mockOpenPGPCard) Sign(key OpenPGPKey, pin string, digest []byte) ([]byte, error) {
+	k := m.keys[key]
+	if pin != m.pin {
+		return nil, errWrongPIN
+	}
+	m.signs++
+	if k.p256 != nil {
+		r, s, err := ecdsa.Sign(rand.Reader, k.p256, digest)
+		if err != nil {
+			return nil, err
+		}
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig, nil
+	}
+	sig, err := k.secp256k1.SignHash(digest)
+	if err != nil {
+		return nil, err
+	}
+	return sig[:64], nil
+}
+
+func newMockOpenPGPCard(t *testing.T) *mockOpenPGPCard {
+	require := require.New(t)
+
+	k1, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+
+	return &mockOpenPGPCard{
+		pin: "123456",
+		keys: map[OpenPGPKey]*mockOpenPGPKey{
+			OpenPGPKeySignature: {
+				info: OpenPGPKeyInfo{
+					PublicKey:       k1.ToECDSA().Public().(*ecdsa.PublicKey),
+					PINPerSignature: true,
+					Touch:           true,
+				},
+				secp256k1: k1,
+			},
+			OpenPGPKeyAuthentication: {
+				info: OpenPGPKeyInfo{
+					PublicKey: &p256.PublicKey,
+				},
+				p256: p256,
+			},
+		},
+	}
+}
+
+func TestOpenPGPKeychainSecp256k1(t *testing.T) {
+	require := require.New(t)
+
+	card := newMockOpenPGPCard(t)
+	var (
+		prompts int
+		touches []OpenPGPKey
+	)
+	kc, err := NewOpenPGPKeychain(card, []OpenPGPKey{OpenPGPKeySignature}, OpenPGPConfig{
+		PIN: func() (string, error) {
+			prompts++
+			return card.pin, nil
+		},
+		OnTouch: func(key OpenPGPKey) {
+			touches = append(touches, key)
+		},
+	})
+	require.NoError(err)
+
+	key := card.keys[OpenPGPKeySignature].secp256k1
+	signer, ok := kc.Get(key.Address())
+	require.True(ok)
+	require.Equal(key.PublicKey().Bytes(), signer.(PublicKeySigner).PublicKey())
+	require.Equal(SchemeSecp256k1, signer.(SchemeSigner).Scheme())
+
+	for range 2 {
+		sig, err := signer.Sign([]byte("payload"))
+		require.NoError(err)
+		require.Len(sig, secp256k1.SignatureLen)
+		recovered, err := secp256k1.RecoverPublicKey([]byte("payload"), sig)
+		require.NoError(err)
+		require.Equal(key.Address(), recovered.Address())
+	}
+
+	// The signature key requires the PIN and a touch for every signature
+	require.Equal(2, prompts)
+	require.Equal([]OpenPGPKey{OpenPGPKeySignature, OpenPGPKeySignature}, touches)
+}
+
+func TestOpenPGPKeychainP256(t *testing.T) {
+	require := require.New(t)
+
+	card := newMockOpenPGPCard(t)
+	var prompts int
+	kc, err := NewOpenPGPKeychain(card, []OpenPGPKey{OpenPGPKeyAuthentication}, OpenPGPConfig{
+		PIN: func() (string, error) {
+			prompts++
+			return card.pin, nil
+		},
+	})
+	require.NoError(err)
+
+	addr, _ := kc.Addresses().Peek()
+	signer, ok := kc.Get(addr)
+	require.True(ok)
+	require.Equal(SchemeP256, signer.(SchemeSigner).Scheme())
+
+	pub := card.keys[OpenPGPKeyAuthentication].p256.PublicKey
+	hash := sha256.Sum256([]byte("payload"))
+	for range 2 {
+		sig, err := signer.SignHash(hash[:])
+		require.NoError(err)
+		require.Len(sig, 64)
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		require.True(ecdsa.Verify(&pub, hash[:], r, s))
+		require.LessOrEqual(s.Cmp(new(big.Int).Rsh(elliptic.P256().Params().N, 1)), 0)
+	}
+
+	// The PIN is cached for the keys that do not require it every time
+	require.Equal(1, prompts)
+}
+
+func TestOpenPGPKeychainPIN(t *testing.T) {
+	require := require.New(t)
+
+	card := newMockOpenPGPCard(t)
+	kc, err := NewOpenPGPKeychain(card, []OpenPGPKey{OpenPGPKeyAuthentication}, OpenPGPConfig{})
+	require.NoError(err)
+	addr, _ := kc.Addresses().Peek()
+	signer, _ := kc.Get(addr)
+	_, err = signer.Sign([]byte("payload"))
+	require.ErrorIs(err, ErrOpenPGPPINRequired)
+
+	// A wrong PIN is not cached
+	kc, err = NewOpenPGPKeychain(card, []OpenPGPKey{OpenPGPKeyAuthentication}, OpenPGPConfig{
+		PIN: func() (string, error) {
+			return "000000", nil
+		},
+	})
+	require.NoError(err)
+	signer, _ = kc.Get(addr)
+	_, err = signer.Sign([]byte("payload"))
+	require.ErrorIs(err, errWrongPIN)
+	require.Empty(kc.(*openPGPKeychain).pin)
+	require.Zero(card.signs)
+}
+
+func TestNewOpenPGPKeychainErrors(t *testing.T) {
+	require := require.New(t)
+
+	card := newMockOpenPGPCard(t)
+	_, err := NewOpenPGPKeychain(card, nil, OpenPGPConfig{})
+	require.ErrorIs(err, ErrInvalidOpenPGPKeysLength)
+
+	// The decryption key cannot sign
+	_, err = NewOpenPGPKeychain(card, []OpenPGPKey{0xb8}, OpenPGPConfig{})
+	require.ErrorIs(err, ErrUnsupportedOpenPGPKey)
+
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(err)
+	card.keys[OpenPGPKeyAuthentication].info.PublicKey = &p384.PublicKey
+	_, err = NewOpenPGPKeychain(card, []OpenPGPKey{OpenPGPKeyAuthentication}, OpenPGPConfig{})
+	require.ErrorIs(err, ErrUnsupportedOpenPGPAlgorithm)
+
+	_, err = NewOpenPGPKeychain(card, []OpenPGPKey{OpenPGPKeySignature, OpenPGPKeySignature}, OpenPGPConfig{})
+	require.ErrorIs(err, ErrDuplicateOpenPGPKey)
+}
@@ -0,0 +1,132 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"time"
+
+	"github.com/luxfi/log"
+)
+
+// Option configures the construction of a ledger keychain, mnemonic keychain,
+// keystore or remote keychain
+type Option func(*options)
+
+type options struct {
+	concurrency int
+	batchSize   int
+	hrp         string
+	onWait      func(DeviceWait)
+	onProgress  func(Progress)
+	issuers     [][]byte
+	purpose     uint32
+	coinType    uint32
+	// blindSigning permits SignHash on the signers of hardware keychains
+	blindSigning bool
+	appIssues    []LedgerAppIssue
+	onAppWarning func(LedgerAppWarning)
+
+	exportMnemonic bool
+	exportPass     []byte
+
+	scrypt   *ScryptParams
+	sealKeys bool
+
+	apiKey      string
+	poolSize    int
+	idleTimeout time.Duration
+
+	roundRobin     bool
+	healthInterval time.Duration
+	onEndpointDown func(int, error)
+
+	logger log.Logger
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		concurrency:    1,
+		poolSize:       1,
+		healthInterval: defaultRemoteHealthInterval,
+		purpose:        BIP44Purpose,
+		coinType:       LuxCoinType,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithConcurrency derives addresses with up to [n] concurrent requests to the
+// ledger. This is intended for software-derivable and remote backends that can
+// serve requests in parallel; physical devices should keep the default of 1,
+// which issues a single GetAddresses call, or one per batch of WithBatchSize.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = max(n, 1)
+	}
+}
+
+// WithHRP sets the HRP of the network the keychain's addresses belong to,
+// such as MainnetHRP or TestnetHRP. Addresses displayed on the device for
+// confirmation are formatted with it. Without it, MainnetHRP is used.
+func WithHRP(hrp string) Option {
+	return func(o *options) {
+		o.hrp = hrp
+	}
+}
+
+// WithDeviceWait calls [f] whenever a signer of the keychain has to wait for
+// the device to finish the operation of another signer. Operations on the
+// device are serialized, and served in the order they were requested.
+func WithDeviceWait(f func(DeviceWait)) Option {
+	return func(o *options) {
+		o.onWait = f
+	}
+}
+
+// WithBlindSigning permits SignHash on the signers of ledger and hardware
+// wallet keychains. The device cannot show what a hash commits to, so
+// without it SignHash fails with ErrBlindSigningDisabled and integrations
+// sign the transactions the device displays with Sign. The Lux app must also
+// have blind signing enabled in its settings, or the device refuses the hash
+// with ErrBlindSigningDisabled.
+//
+// Integrations that only sign hashes need it: ledger keychains given to
+// NewEVMWallet, which signs Keccak-256 hashes, fail to sign without it.
+func WithBlindSigning() Option {
+	return func(o *options) {
+		o.blindSigning = true
+	}
+}
+
+// WithAttestation checks that the ledger is a genuine device, certified by one
+// of the compressed public keys of [issuers], before deriving any address.
+// Keychain construction fails with ErrNotGenuine if the check fails, and the
+// verified attestation is available from AttestedKeychain.
+func WithAttestation(issuers ...[]byte) Option {
+	return func(o *options) {
+		o.issuers = issuers
+	}
+}
+
+// WithMnemonicExport keeps the mnemonic of a mnemonic keychain so that it can
+// be exported with ExportMnemonic, guarded by [pass]. Without it, the
+// mnemonic is discarded once the keys are derived.
+func WithMnemonicExport(pass []byte) Option {
+	return func(o *options) {
+		o.exportMnemonic = true
+		o.exportPass = pass
+	}
+}
+
+// WithScrypt encrypts the key files written by a keystore with the scrypt
+// cost parameters [params], such as ScryptHardened for cold storage. Without
+// it, ScryptStandard is used. Files written with other parameters remain
+// readable.
+func WithScrypt(params ScryptParams) Option {
+	return func(o *options) {
+		o.scrypt = &params
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+)
+
+const (
+	// keyringIndexAccount is the account under which a KeyringStore records
+	// the addresses it holds
+	keyringIndexAccount = "index"
+	// keyringIndexPageLen is the number of addresses recorded in each account
+	// of the index. The Windows Credential Manager limits secrets to 2560
+	// bytes, which is about 75 comma separated addresses.
+	keyringIndexPageLen = 64
+)
+
+var (
+	ErrCredentialNotFound = errors.New("credential not found")
+
+	_ CredentialStore = osCredentialStore{}
+)
+
+// CredentialStore is a secret store keyed by service and account
+type CredentialStore interface {
+	// Set stores [secret], replacing any existing secret
+	Set(service, account, secret string) error
+	// Get returns the stored secret, or ErrCredentialNotFound
+	Get(service, account string) (string, error)
+	// Delete removes the stored secret, or returns ErrCredentialNotFound
+	Delete(service, account string) error
+}
+
+// osCredentialStore is the credential store of the operating system
+type osCredentialStore struct{}
+
+// OSCredentialStore returns the native credential store of the operating
+// system: the Keychain on macOS, the Credential Manager on Windows and the
+// Secret Service (GNOME Keyring, KWallet) on Linux.
+func OSCredentialStore() CredentialStore {
+	return osCredentialStore{}
+}
+
+func (osCredentialStore) Set(service, account, secret string) error {
+	return keyring.Set(service, account, secret)
+}
+
+func (osCredentialStore) Get(service, account string) (string, error) {
+	secret, err := keyring.Get(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrCredentialNotFound
+	}
+	return secret, err
+}
+
+func (osCredentialStore) Delete(service, account string) error {
+	err := keyring.Delete(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return ErrCredentialNotFound
+	}
+	return err
+}
+
+// KeyringStore persists software keys in a credential store. Each key is
+// encrypted as a Bundle with the store password and kept under its address
+// as the account. Because OS credential stores cannot be enumerated, the
+// stored addresses are also recorded in an index, split across accounts of
+// at most 64 addresses to fit the size limit of the Windows Credential
+// Manager.
+type KeyringStore struct {
+	store   CredentialStore
+	service string
+	pass    []byte
+
+	lock sync.Mutex
+}
+
+// NewKeyringStore creates a store of keys under [service] in [store]
+func NewKeyringStore(store CredentialStore, service string, pass []byte) *KeyringStore {
+	return &KeyringStore{
+		store:   store,
+		service: service,
+		pass:    bytes.Clone(pass),
+	}
+}
+
+// Store encrypts and stores [key]
+func (k *KeyringStore) Store(key *secp256k1.PrivateKey) error {
+	var buf bytes.Buffer
+	b := &Bundle{Keys: []*secp256k1.PrivateKey{key}}
+	if err := b.Export(&buf, k.pass); err != nil {
+		return err
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	addr := key.Address()
+	if err := k.store.Set(k.service, addr.String(), base64.StdEncoding.EncodeToString(buf.Bytes())); err != nil {
+		return err
+	}
+
+	addrs, err := k.addresses()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(addrs, addr) {
+		return nil
+	}
+	return k.setAddresses(append(addrs, addr))
+}
+
+// Delete removes the key of [addr] from the store
+func (k *KeyringStore) Delete(addr ids.ShortID) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if err := k.store.Delete(k.service, addr.String()); err != nil {
+		return err
+	}
+
+	addrs, err := k.addresses()
+	if err != nil {
+		return err
+	}
+	return k.setAddresses(slices.DeleteFunc(addrs, func(a ids.ShortID) bool {
+		return a == addr
+	}))
+}
+
+// Addresses returns the addresses of the stored keys
+func (k *KeyringStore) Addresses() ([]ids.ShortID, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	return k.addresses()
+}
+
+// Load decrypts every stored key into a software keychain
+func (k *KeyringStore) Load() (*SoftwareKeychain, error) {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	addrs, err := k.addresses()
+	if err != nil {
+		return nil, err
+	}
+
+	kc := NewSoftwareKeychain()
+	for _, addr := range addrs {
+		if err := k.load(kc, addr); err != nil {
+			_ = kc.Destroy()
+			return nil, err
+		}
+	}
+	return kc, nil
+}
+
+// load decrypts the key of [addr] into [kc], wiping the decrypted bundle.
+// Assumes the lock is held.
+func (k *KeyringStore) load(kc *SoftwareKeychain, addr ids.ShortID) error {
+	secret, err := k.store.Get(k.service, addr.String())
+	if err != nil {
+		return err
+	}
+	data, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return ErrInvalidBundle
+	}
+	b, err := Import(bytes.NewReader(data), k.pass)
+	if err != nil {
+		return err
+	}
+	defer b.wipe()
+	for _, key := range b.Keys {
+		kc.Add(key)
+	}
+	return nil
+}
+
+// addresses reads the index. Assumes the lock is held.
+func (k *KeyringStore) addresses() ([]ids.ShortID, error) {
+	var addrs []ids.ShortID
+	for page := 0; ; page++ {
+		index, err := k.store.Get(k.service, keyringIndexPage(page))
+		if errors.Is(err, ErrCredentialNotFound) {
+			return addrs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if index == "" {
+			return addrs, nil
+		}
+
+		for _, addrStr := range strings.Split(index, ",") {
+			addr, err := ids.ShortFromString(addrStr)
+			if err != nil {
+				return nil, err
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+}
+
+// setAddresses writes the index, removing the accounts of a previous, longer
+// index. Assumes the lock is held.
+func (k *KeyringStore) setAddresses(addrs []ids.ShortID) error {
+	pages := slices.Collect(slices.Chunk(addrs, keyringIndexPageLen))
+	if len(pages) == 0 {
+		pages = [][]ids.ShortID{nil}
+	}
+	for page, pageAddrs := range pages {
+		if err := k.store.Set(k.service, keyringIndexPage(page), strings.Join(ids.ShortIDsToStrings(pageAddrs), ",")); err != nil {
+			return err
+		}
+	}
+	for page := len(pages); ; page++ {
+		err := k.store.Delete(k.service, keyringIndexPage(page))
+		if errors.Is(err, ErrCredentialNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// keyringIndexPage returns the account of page [page] of the index. The
+// first page keeps the account of indexes written before they were split.
+func keyringIndexPage(page int) string {
+	if page == 0 {
+		return keyringIndexAccount
+	}
+	return keyringIndexAccount + "-" + strconv.Itoa(page)
+}
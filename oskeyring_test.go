@@ -0,0 +1,138 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCredentialStore is an in-memory CredentialStore
+type memoryCredentialStore map[[2]string]string
+
+func (m memoryCredentialStore) Set(service, account, secret string) error {
+	m[[2]string{service, account}] = secret
+	return nil
+}
+
+func (m memoryCredentialStore) Get(service, account string) (string, error) {
+	secret, ok := m[[2]string{service, account}]
+	if !ok {
+		return "", ErrCredentialNotFound
+	}
+	return secret, nil
+}
+
+func (m memoryCredentialStore) Delete(service, account string) error {
+	if _, ok := m[[2]string{service, account}]; !ok {
+		return ErrCredentialNotFound
+	}
+	delete(m, [2]string{service, account})
+	return nil
+}
+
+// windowsCredentialBlobLimit is the largest secret the Windows Credential
+// Manager stores
+const windowsCredentialBlobLimit = 2560
+
+// limitedCredentialStore refuses secrets larger than the Windows Credential
+// Manager does
+type limitedCredentialStore struct {
+	memoryCredentialStore
+}
+
+func (l limitedCredentialStore) Set(service, account, secret string) error {
+	if len(secret) > windowsCredentialBlobLimit {
+		return errors.New("secret too large")
+	}
+	return l.memoryCredentialStore.Set(service, account, secret)
+}
+
+func TestKeyringStoreIndexSize(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	creds := limitedCredentialStore{memoryCredentialStore{}}
+	store := NewKeyringStore(creds, "lux-wallet", []byte("pass"))
+
+	// The index of many keys is split to fit the limit of the store
+	addrs := make([]ids.ShortID, 2*keyringIndexPageLen+9)
+	for i := range addrs {
+		addrs[i] = ids.GenerateTestShortID()
+		require.NoError(creds.Set("lux-wallet", addrs[i].String(), "key"))
+	}
+	require.NoError(store.setAddresses(addrs))
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	require.NoError(store.Store(key))
+	addrs = append(addrs, key.Address())
+
+	stored, err := store.Addresses()
+	require.NoError(err)
+	require.Equal(addrs, stored)
+	for _, secret := range creds.memoryCredentialStore {
+		require.LessOrEqual(len(secret), windowsCredentialBlobLimit)
+	}
+	_, err = creds.Get("lux-wallet", keyringIndexPage(2))
+	require.NoError(err)
+
+	// Accounts of the index that are no longer needed are removed
+	for _, addr := range addrs[keyringIndexPageLen:] {
+		require.NoError(store.Delete(addr))
+	}
+	stored, err = store.Addresses()
+	require.NoError(err)
+	require.Equal(addrs[:keyringIndexPageLen], stored)
+	_, err = creds.Get("lux-wallet", keyringIndexPage(1))
+	require.ErrorIs(err, ErrCredentialNotFound)
+}
+
+func TestKeyringStore(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	creds := memoryCredentialStore{}
+	store := NewKeyringStore(creds, "lux-wallet", []byte("pass"))
+
+	addrs, err := store.Addresses()
+	require.NoError(err)
+	require.Empty(addrs)
+
+	key0, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	key1, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	require.NoError(store.Store(key0))
+	require.NoError(store.Store(key1))
+	require.NoError(store.Store(key0))
+
+	addrs, err = store.Addresses()
+	require.NoError(err)
+	require.Len(addrs, 2)
+
+	// Key material is not stored in plaintext
+	for _, secret := range creds {
+		require.NotContains(secret, key0.String())
+	}
+
+	kc, err := store.Load()
+	require.NoError(err)
+	require.Equal(2, kc.Addresses().Len())
+	require.True(kc.Addresses().Contains(key1.Address()))
+
+	// Another password cannot decrypt the keys
+	_, err = NewKeyringStore(creds, "lux-wallet", []byte("wrong")).Load()
+	require.ErrorIs(err, ErrBundleDecryption)
+
+	require.NoError(store.Delete(key0.Address()))
+	require.ErrorIs(store.Delete(key0.Address()), ErrCredentialNotFound)
+	kc, err = store.Load()
+	require.NoError(err)
+	require.Equal(1, kc.Addresses().Len())
+	require.True(kc.Addresses().Contains(key1.Address()))
+}
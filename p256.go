@@ -0,0 +1,133 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var (
+	ErrNotP256Key = errors.New("key is not a P-256 ECDSA key")
+
+	_ PublicKeySigner = (*p256Signer)(nil)
+	_ SchemeSigner    = (*p256Signer)(nil)
+)
+
+// P256Address returns the address of a P-256 public key under SchemeP256:
+// the hash of its compressed encoding, derived as for secp256k1 keys
+func P256Address(pub *ecdsa.PublicKey) ids.ShortID {
+	return publicKeyAddress(compressPublicKey(pub.X, pub.Y))
+}
+
+// p256Signer signs with a P-256 key behind a crypto.Signer
+type p256Signer struct {
+	signer    crypto.Signer
+	publicKey *ecdsa.PublicKey
+	pubKey    []byte
+	addr      ids.ShortID
+}
+
+// NewP256Signer returns a signer of the P-256 key of [signer], which may be
+// an *ecdsa.PrivateKey or the signer of an HSM, KMS or enclave that only
+// offers P-256. Signatures are returned as 64 byte r || s, normalized to
+// low-S, and verify under SchemeP256.
+func NewP256Signer(signer crypto.Signer) (Signer, error) {
+	pub, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok || pub.Curve != elliptic.P256() {
+		return nil, ErrNotP256Key
+	}
+	pubKey := compressPublicKey(pub.X, pub.Y)
+	return &p256Signer{
+		signer:    signer,
+		publicKey: pub,
+		pubKey:    pubKey,
+		addr:      publicKeyAddress(pubKey),
+	}, nil
+}
+
+func (p *p256Signer) SignHash(hash []byte) ([]byte, error) {
+	if len(hash) != sha256.Size {
+		return nil, ErrInvalidHashLen
+	}
+	der, err := p.signer.Sign(rand.Reader, hash, crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	return p256Signature(p.publicKey, hash, der)
+}
+
+func (p *p256Signer) Sign(msg []byte) ([]byte, error) {
+	hash := sha256.Sum256(msg)
+	return p.SignHash(hash[:])
+}
+
+func (p *p256Signer) Address() ids.ShortID {
+	return p.addr
+}
+
+func (p *p256Signer) PublicKey() []byte {
+	return p.pubKey
+}
+
+func (*p256Signer) Scheme() SchemeID {
+	return SchemeP256
+}
+
+// p256Keychain is a keychain of P-256 signers
+type p256Keychain struct {
+	addrs   set.Set[ids.ShortID]
+	signers map[ids.ShortID]Signer
+}
+
+// NewP256Keychain creates a keychain of the P-256 keys of [signers]. Keys
+// held by several signers are only added once.
+func NewP256Keychain(signers ...crypto.Signer) (Keychain, error) {
+	kc := &p256Keychain{
+		addrs:   make(set.Set[ids.ShortID]),
+		signers: make(map[ids.ShortID]Signer),
+	}
+	for _, s := range signers {
+		signer, err := NewP256Signer(s)
+		if err != nil {
+			return nil, err
+		}
+		addr := signer.Address()
+		if kc.addrs.Contains(addr) {
+			continue
+		}
+		kc.addrs.Add(addr)
+		kc.signers[addr] = signer
+	}
+	return kc, nil
+}
+
+func (p *p256Keychain) Get(addr ids.ShortID) (Signer, bool) {
+	signer, ok := p.signers[addr]
+	return signer, ok
+}
+
+func (p *p256Keychain) Addresses() set.Set[ids.ShortID] {
+	return p.addrs
+}
+
+// p256Signature converts the DER encoded signature of [hash] by [pub] into
+// the low-S r || s encoding of SchemeP256, checking that it verifies
+func p256Signature(pub *ecdsa.PublicKey, hash, der []byte) ([]byte, error) {
+	r, s, err := parseDERSignature(der)
+	if err != nil {
+		return nil, err
+	}
+	if !ecdsa.Verify(pub, hash, r, s) {
+		return nil, ErrSignatureInvalid
+	}
+	return compactSignature(pub.Curve, r, s), nil
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newP256Key(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func TestP256Keychain(t *testing.T) {
+	require := require.New(t)
+
+	key := newP256Key(t)
+	kc, err := NewP256Keychain(key, newP256Key(t), key)
+	require.NoError(err)
+	require.Equal(2, kc.Addresses().Len())
+
+	signer, ok := kc.Get(P256Address(&key.PublicKey))
+	require.True(ok)
+	require.Equal(SchemeP256, SchemeOf(signer))
+
+	msg := []byte("enterprise custody payload")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.Len(sig, 64)
+	require.NoError(VerifySignature(signer, msg, sig))
+
+	// Signatures are normalized to low-S
+	s := new(big.Int).SetBytes(sig[32:])
+	require.LessOrEqual(s.Cmp(new(big.Int).Rsh(elliptic.P256().Params().N, 1)), 0)
+
+	scheme, err := LookupScheme(SchemeP256)
+	require.NoError(err)
+	require.ErrorIs(scheme.Verify(signer.(PublicKeySigner).PublicKey(), []byte("other"), sig), ErrSignatureInvalid)
+	addr, err := scheme.Address(signer.(PublicKeySigner).PublicKey())
+	require.NoError(err)
+	require.Equal(signer.Address(), addr)
+
+	_, err = signer.SignHash(msg)
+	require.ErrorIs(err, ErrInvalidHashLen)
+}
+
+func TestP256SignerRejectsOtherKeys(t *testing.T) {
+	require := require.New(t)
+
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(err)
+	_, err = NewP256Signer(p384)
+	require.ErrorIs(err, ErrNotP256Key)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+	_, err = NewP256Keychain(edKey)
+	require.ErrorIs(err, ErrNotP256Key)
+}
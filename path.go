@@ -0,0 +1,178 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// HardenedOffset is added to an element of a derivation path to harden it
+const HardenedOffset = 0x80000000
+
+// BIP44Purpose is the purpose of BIP44 paths
+const BIP44Purpose = 44
+
+// Coin types of BIP44 paths, as registered in SLIP-0044
+const (
+	LuxCoinType      = 9000
+	EthereumCoinType = 60
+)
+
+var (
+	ErrInvalidDerivationPath     = errors.New("invalid derivation path")
+	ErrUnsupportedDerivationPath = errors.New("derivation path is not a Lux address path")
+)
+
+// DerivationPath is a BIP32 derivation path. Hardened elements include
+// HardenedOffset.
+type DerivationPath []uint32
+
+// AddressPath returns the BIP44 path m/44'/9000'/0'/0/[addressIndex] of a
+// Lux address, which is the path ledger address indices refer to
+func AddressPath(addressIndex uint32) DerivationPath {
+	return BIP44Path(BIP44Purpose, LuxCoinType, addressIndex)
+}
+
+// BIP44Path returns the path m/[purpose]'/[coinType]'/0'/0/[addressIndex],
+// such as m/44'/60'/0'/0/[addressIndex] for the accounts of Ethereum wallets
+func BIP44Path(purpose, coinType, addressIndex uint32) DerivationPath {
+	return DerivationPath{
+		purpose | HardenedOffset,
+		coinType | HardenedOffset,
+		HardenedOffset,
+		0,
+		addressIndex,
+	}
+}
+
+// WithPurpose derives the keys of a mnemonic keychain at BIP44 paths of
+// [purpose] instead of BIP44Purpose. Ledger keychains, whose paths are
+// those of the Lux app, fail with ErrUnsupportedDerivationPath.
+func WithPurpose(purpose uint32) Option {
+	return func(o *options) {
+		o.purpose = purpose
+	}
+}
+
+// WithCoinType derives the keys of a mnemonic keychain at BIP44 paths of
+// [coinType] instead of LuxCoinType, such as EthereumCoinType to recover the
+// accounts of a mnemonic used with an Ethereum wallet. Ledger keychains,
+// whose paths are those of the Lux app, fail with
+// ErrUnsupportedDerivationPath.
+func WithCoinType(coinType uint32) Option {
+	return func(o *options) {
+		o.coinType = coinType
+	}
+}
+
+// addressPath returns the path of [addressIndex] with the configured purpose
+// and coin type
+func (o *options) addressPath(addressIndex uint32) DerivationPath {
+	return BIP44Path(o.purpose, o.coinType, addressIndex)
+}
+
+// luxPaths fails with ErrUnsupportedDerivationPath if the configured purpose
+// or coin type are not those of Lux addresses
+func (o *options) luxPaths() error {
+	if o.purpose != BIP44Purpose || o.coinType != LuxCoinType {
+		return fmt.Errorf("%w: %s", ErrUnsupportedDerivationPath, o.addressPath(0)[:2])
+	}
+	return nil
+}
+
+// ParseDerivationPath parses a path of the form m/44'/9000'/0'/0/5. Hardened
+// elements may be marked with ', h or H, and the leading m/ is optional.
+func ParseDerivationPath(path string) (DerivationPath, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "m/")
+	if rest == "" || rest == "m" {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidDerivationPath, path)
+	}
+
+	elements := strings.Split(rest, "/")
+	parsed := make(DerivationPath, len(elements))
+	for i, element := range elements {
+		var offset uint32
+		if n := len(element); n > 0 && strings.ContainsRune("'hH", rune(element[n-1])) {
+			element, offset = element[:n-1], HardenedOffset
+		}
+
+		value, err := strconv.ParseUint(element, 10, 32)
+		if err != nil || value >= HardenedOffset {
+			return nil, fmt.Errorf("%w: invalid element %q of %q", ErrInvalidDerivationPath, elements[i], path)
+		}
+		parsed[i] = uint32(value) + offset
+	}
+	return parsed, nil
+}
+
+// String formats the path as m/44'/9000'/0'/0/5
+func (p DerivationPath) String() string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, element := range p {
+		sb.WriteString("/")
+		if element >= HardenedOffset {
+			sb.WriteString(strconv.FormatUint(uint64(element-HardenedOffset), 10))
+			sb.WriteString("'")
+		} else {
+			sb.WriteString(strconv.FormatUint(uint64(element), 10))
+		}
+	}
+	return sb.String()
+}
+
+// AddressIndex returns the address index of a Lux address path, as returned
+// by AddressPath. ErrUnsupportedDerivationPath is returned for other paths.
+func (p DerivationPath) AddressIndex() (uint32, error) {
+	if len(p) != 5 || p[4] >= HardenedOffset {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedDerivationPath, p)
+	}
+	if !slices.Equal(p[:4], AddressPath(0)[:4]) {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedDerivationPath, p)
+	}
+	return p[4], nil
+}
+
+func (p DerivationPath) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *DerivationPath) UnmarshalText(text []byte) error {
+	parsed, err := ParseDerivationPath(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// PathIndices parses [paths] and returns their address indices
+func PathIndices(paths []string) ([]uint32, error) {
+	indices := make([]uint32, len(paths))
+	for i, path := range paths {
+		parsed, err := ParseDerivationPath(path)
+		if err != nil {
+			return nil, err
+		}
+		indices[i], err = parsed.AddressIndex()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return indices, nil
+}
+
+// NewLedgerKeychainFromPaths creates a ledger keychain of the Lux address
+// paths [paths], such as m/44'/9000'/0'/0/5
+func NewLedgerKeychainFromPaths(ledger Ledger, paths []string, opts ...Option) (Keychain, error) {
+	indices, err := PathIndices(paths)
+	if err != nil {
+		return nil, err
+	}
+	return NewLedgerKeychain(ledger, indices, opts...)
+}
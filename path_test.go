@@ -0,0 +1,106 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDerivationPath(t *testing.T) {
+	tests := []struct {
+		path        string
+		expected    DerivationPath
+		expectedErr error
+	}{
+		{
+			path:     "m/44'/9000'/0'/0/5",
+			expected: AddressPath(5),
+		},
+		{
+			path:     "44h/9000H/0'/0/5",
+			expected: AddressPath(5),
+		},
+		{
+			path:     "m/0",
+			expected: DerivationPath{0},
+		},
+		{
+			path:     "m/2147483647'",
+			expected: DerivationPath{0xffffffff},
+		},
+		{
+			path:        "m",
+			expectedErr: ErrInvalidDerivationPath,
+		},
+		{
+			path:        "",
+			expectedErr: ErrInvalidDerivationPath,
+		},
+		{
+			path:        "m/44'//0",
+			expectedErr: ErrInvalidDerivationPath,
+		},
+		{
+			path:        "m/2147483648",
+			expectedErr: ErrInvalidDerivationPath,
+		},
+		{
+			path:        "m/-1",
+			expectedErr: ErrInvalidDerivationPath,
+		},
+		{
+			path:        "m/1''",
+			expectedErr: ErrInvalidDerivationPath,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			require := require.New(t)
+
+			path, err := ParseDerivationPath(test.path)
+			require.ErrorIs(err, test.expectedErr)
+			require.Equal(test.expected, path)
+		})
+	}
+}
+
+func TestDerivationPathFormat(t *testing.T) {
+	require := require.New(t)
+
+	path := AddressPath(7)
+	require.Equal("m/44'/9000'/0'/0/7", path.String())
+
+	idx, err := path.AddressIndex()
+	require.NoError(err)
+	require.Equal(uint32(7), idx)
+
+	_, err = DerivationPath{44 | HardenedOffset, 60 | HardenedOffset, HardenedOffset, 0, 7}.AddressIndex()
+	require.ErrorIs(err, ErrUnsupportedDerivationPath)
+	_, err = DerivationPath{44 | HardenedOffset, 9000 | HardenedOffset, HardenedOffset, 0, 7 | HardenedOffset}.AddressIndex()
+	require.ErrorIs(err, ErrUnsupportedDerivationPath)
+
+	pathJSON, err := json.Marshal(path)
+	require.NoError(err)
+	require.JSONEq(`"m/44'/9000'/0'/0/7"`, string(pathJSON))
+	var parsed DerivationPath
+	require.NoError(json.Unmarshal(pathJSON, &parsed))
+	require.Equal(path, parsed)
+}
+
+func TestNewLedgerKeychainFromPaths(t *testing.T) {
+	require := require.New(t)
+
+	ledger := newMockLedger()
+	kc, err := NewLedgerKeychainFromPaths(ledger, []string{"m/44'/9000'/0'/0/1", "m/44'/9000'/0'/0/2"})
+	require.NoError(err)
+	expected, err := NewLedgerKeychain(ledger, []uint32{1, 2})
+	require.NoError(err)
+	require.True(kc.Addresses().Equals(expected.Addresses()))
+
+	_, err = NewLedgerKeychainFromPaths(ledger, []string{"m/44'/60'/0'/0/1"})
+	require.ErrorIs(err, ErrUnsupportedDerivationPath)
+}
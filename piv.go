@@ -0,0 +1,261 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// Well known PIV key slots
+const (
+	PIVSlotAuthentication PIVSlot = 0x9a
+	PIVSlotSignature      PIVSlot = 0x9c
+	PIVSlotKeyManagement  PIVSlot = 0x9d
+	PIVSlotCardAuth       PIVSlot = 0x9e
+)
+
+// PIN policies of a PIV key
+const (
+	// PIVPINPolicyNever signs without a PIN
+	PIVPINPolicyNever PIVPINPolicy = iota
+	// PIVPINPolicyOnce requires the PIN once per session
+	PIVPINPolicyOnce
+	// PIVPINPolicyAlways requires the PIN for every signature
+	PIVPINPolicyAlways
+)
+
+// Touch policies of a PIV key
+const (
+	// PIVTouchPolicyNever signs without a touch
+	PIVTouchPolicyNever PIVTouchPolicy = iota
+	// PIVTouchPolicyCached requires a touch, which the token caches briefly
+	PIVTouchPolicyCached
+	// PIVTouchPolicyAlways requires a touch for every signature
+	PIVTouchPolicyAlways
+)
+
+var (
+	ErrInvalidSlotsLength       = errors.New("number of slots should be greater than 0")
+	ErrUnsupportedPIVAlgorithm  = errors.New("unsupported PIV key algorithm")
+	ErrPINRequired              = errors.New("PIV key requires a PIN")
+	ErrDuplicatePIVKey          = errors.New("PIV slots hold the same key")
+	ErrPIVSignatureVerification = errors.New("PIV signature does not verify")
+
+	_ PublicKeySigner = (*pivSigner)(nil)
+	_ SchemeSigner    = (*pivSigner)(nil)
+)
+
+// PIVSlot identifies a key slot of a PIV applet
+type PIVSlot uint32
+
+// PIVPINPolicy determines when a PIV key requires the PIN
+type PIVPINPolicy int
+
+// PIVTouchPolicy determines when a PIV key requires a physical touch
+type PIVTouchPolicy int
+
+// PIVKeyInfo describes the key held in a PIV slot
+type PIVKeyInfo struct {
+	// PublicKey is a P-256 or secp256k1 public key
+	PublicKey   *ecdsa.PublicKey
+	PINPolicy   PIVPINPolicy
+	TouchPolicy PIVTouchPolicy
+}
+
+// PIVCard is a PIV applet, such as the one of a YubiKey, capable of signing
+// with the keys held in its slots. Implementations typically wrap a smart card
+// library such as piv-go.
+type PIVCard interface {
+	// KeyInfo returns the public key and policies of the key in [slot]
+	KeyInfo(slot PIVSlot) (PIVKeyInfo, error)
+	// Sign returns the ASN.1 DER encoded ECDSA signature of [digest] by the
+	// key in [slot]. [pin] is empty if the key does not require a PIN.
+	Sign(slot PIVSlot, pin string, digest []byte) ([]byte, error)
+}
+
+// PIVConfig configures the PIN and touch handling of a PIV keychain
+type PIVConfig struct {
+	// PIN is called when a key requires the PIN. It may be nil if none of the
+	// keys require a PIN.
+	PIN func() (string, error)
+	// OnTouch, if set, is called before signing with a key that requires a
+	// touch, so that the user can be prompted to touch the token
+	OnTouch func(slot PIVSlot)
+}
+
+// pivKeychain is a keychain of the keys held in the slots of a PIV card
+type pivKeychain struct {
+	card   PIVCard
+	config PIVConfig
+	addrs  set.Set[ids.ShortID]
+	keys   map[ids.ShortID]*pivKey
+
+	// pin is cached after its first successful use by a key with the
+	// PIVPINPolicyOnce policy
+	lock sync.Mutex
+	pin  string
+}
+
+type pivKey struct {
+	slot   PIVSlot
+	info   PIVKeyInfo
+	pubKey []byte
+}
+
+// NewPIVKeychain creates a keychain of the keys in [slots] of [card].
+//
+// Signatures by secp256k1 keys are returned in the 65 byte recoverable format
+// used by Lux credentials. Signatures by P-256 keys, which are the only keys
+// supported by YubiKeys, are returned as 64 byte r || s. All signatures are
+// normalized to low-S.
+func NewPIVKeychain(card PIVCard, slots []PIVSlot, config PIVConfig) (Keychain, error) {
+	if len(slots) == 0 {
+		return nil, ErrInvalidSlotsLength
+	}
+
+	kc := &pivKeychain{
+		card:   card,
+		config: config,
+		addrs:  make(set.Set[ids.ShortID]),
+		keys:   make(map[ids.ShortID]*pivKey),
+	}
+	for _, slot := range slots {
+		info, err := card.KeyInfo(slot)
+		if err != nil {
+			return nil, fmt.Errorf("slot %#x: %w", uint32(slot), err)
+		}
+		if info.PublicKey == nil || !isPIVCurve(info.PublicKey.Curve) {
+			return nil, fmt.Errorf("slot %#x: %w", uint32(slot), ErrUnsupportedPIVAlgorithm)
+		}
+
+		pubKey := compressPublicKey(info.PublicKey.X, info.PublicKey.Y)
+		addr := publicKeyAddress(pubKey)
+		if kc.addrs.Contains(addr) {
+			return nil, ErrDuplicatePIVKey
+		}
+		kc.addrs.Add(addr)
+		kc.keys[addr] = &pivKey{
+			slot:   slot,
+			info:   info,
+			pubKey: pubKey,
+		}
+	}
+	return kc, nil
+}
+
+func (p *pivKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	key, ok := p.keys[addr]
+	if !ok {
+		return nil, false
+	}
+	return &pivSigner{
+		keychain: p,
+		key:      key,
+		addr:     addr,
+	}, true
+}
+
+func (p *pivKeychain) Addresses() set.Set[ids.ShortID] {
+	return p.addrs
+}
+
+// sign signs [digest] with [key], resolving the PIN according to the key's
+// PIN policy
+func (p *pivKeychain) sign(key *pivKey, digest []byte) ([]byte, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var pin string
+	switch key.info.PINPolicy {
+	case PIVPINPolicyOnce:
+		pin = p.pin
+		if pin != "" {
+			break
+		}
+		fallthrough
+	case PIVPINPolicyAlways:
+		if p.config.PIN == nil {
+			return nil, ErrPINRequired
+		}
+		var err error
+		pin, err = p.config.PIN()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if key.info.TouchPolicy != PIVTouchPolicyNever && p.config.OnTouch != nil {
+		p.config.OnTouch(key.slot)
+	}
+
+	sig, err := p.card.Sign(key.slot, pin, digest)
+	if err != nil {
+		return nil, err
+	}
+	if key.info.PINPolicy == PIVPINPolicyOnce {
+		p.pin = pin
+	}
+	return sig, nil
+}
+
+// pivSigner signs with a single key of a PIV card
+type pivSigner struct {
+	keychain *pivKeychain
+	key      *pivKey
+	addr     ids.ShortID
+}
+
+func (p *pivSigner) SignHash(hash []byte) ([]byte, error) {
+	der, err := p.keychain.sign(p.key, hash)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := parseDERSignature(der)
+	if err != nil {
+		return nil, err
+	}
+
+	pub := p.key.info.PublicKey
+	if !ecdsa.Verify(pub, hash, r, s) {
+		return nil, ErrPIVSignatureVerification
+	}
+	sig := compactSignature(pub.Curve, r, s)
+	if pub.Curve == secp256k1.S256() {
+		return recoverableSignature(hash, sig, p.addr)
+	}
+	return sig, nil
+}
+
+func (p *pivSigner) Sign(msg []byte) ([]byte, error) {
+	hash := sha256.Sum256(msg)
+	return p.SignHash(hash[:])
+}
+
+func (p *pivSigner) Address() ids.ShortID {
+	return p.addr
+}
+
+func (p *pivSigner) PublicKey() []byte {
+	return p.key.pubKey
+}
+
+func (p *pivSigner) Scheme() SchemeID {
+	if p.key.info.PublicKey.Curve == secp256k1.S256() {
+		return SchemeSecp256k1
+	}
+	return SchemeP256
+}
+
+func isPIVCurve(curve elliptic.Curve) bool {
+	return curve == elliptic.P256() || curve == secp256k1.S256()
+}
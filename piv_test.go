@@ -0,0 +1,196 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+)
+
+var errWrongPIN = errors.New("wrong PIN")
+
+type mockPIVSlot struct {
+	info      PIVKeyInfo
+	p256      *ecdsa.PrivateKey
+	secp256k1 *secp256k1.PrivateKey
+}
+
+// mockPIVCard signs with software keys
+type mockPIVCard struct {
+	pin   string
+	slots map[PIVSlot]*mockPIVSlot
+	signs int
+}
+
+func (m *mockPIVCard) KeyInfo(slot PIVSlot) (PIVKeyInfo, error) {
+	return m.slots[slot].info, nil
+}
+
+func (m *mockPIVCard) Sign(slot PIVSlot, pin string, digest []byte) ([]byte, error) {
+	s := m.slots[slot]
+	if s.info.PINPolicy != PIVPINPolicyNever && pin != m.pin {
+		return nil, errWrongPIN
+	}
+	m.signs++
+	if s.p256 != nil {
+		return ecdsa.SignASN1(rand.Reader, s.p256, digest)
+	}
+	sig, err := s.secp256k1.SignHash(digest)
+	if err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(new(big.Int).SetBytes(sig[:32]))
+		b.AddASN1BigInt(new(big.Int).SetBytes(sig[32:64]))
+	})
+	return b.Bytes()
+}
+
+func newMockPIVCard(t *testing.T) *mockPIVCard {
+	require := require.New(t)
+
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	k1, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+
+	return &mockPIVCard{
+		pin: "123456",
+		slots: map[PIVSlot]*mockPIVSlot{
+			PIVSlotSignature: {
+				info: PIVKeyInfo{
+					PublicKey:   &p256.PublicKey,
+					PINPolicy:   PIVPINPolicyOnce,
+					TouchPolicy: PIVTouchPolicyAlways,
+				},
+				p256: p256,
+			},
+			PIVSlotAuthentication: {
+				info: PIVKeyInfo{
+					PublicKey: k1.ToECDSA().Public().(*ecdsa.PublicKey),
+					PINPolicy: PIVPINPolicyNever,
+				},
+				secp256k1: k1,
+			},
+		},
+	}
+}
+
+func TestPIVKeychainP256(t *testing.T) {
+	require := require.New(t)
+
+	card := newMockPIVCard(t)
+	var (
+		prompts int
+		touches []PIVSlot
+	)
+	kc, err := NewPIVKeychain(card, []PIVSlot{PIVSlotSignature}, PIVConfig{
+		PIN: func() (string, error) {
+			prompts++
+			return card.pin, nil
+		},
+		OnTouch: func(slot PIVSlot) {
+			touches = append(touches, slot)
+		},
+	})
+	require.NoError(err)
+	require.Equal(1, kc.Addresses().Len())
+
+	addr, _ := kc.Addresses().Peek()
+	signer, ok := kc.Get(addr)
+	require.True(ok)
+
+	pub := card.slots[PIVSlotSignature].p256.PublicKey
+	hash := sha256.Sum256([]byte("payload"))
+	for range 2 {
+		sig, err := signer.SignHash(hash[:])
+		require.NoError(err)
+		require.Len(sig, 64)
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		require.True(ecdsa.Verify(&pub, hash[:], r, s))
+		require.LessOrEqual(s.Cmp(new(big.Int).Rsh(elliptic.P256().Params().N, 1)), 0)
+	}
+
+	// The PIN is prompted once per session, the touch every time
+	require.Equal(1, prompts)
+	require.Equal([]PIVSlot{PIVSlotSignature, PIVSlotSignature}, touches)
+}
+
+func TestPIVKeychainSecp256k1(t *testing.T) {
+	require := require.New(t)
+
+	card := newMockPIVCard(t)
+	kc, err := NewPIVKeychain(card, []PIVSlot{PIVSlotAuthentication}, PIVConfig{})
+	require.NoError(err)
+
+	key := card.slots[PIVSlotAuthentication].secp256k1
+	signer, ok := kc.Get(key.Address())
+	require.True(ok)
+	require.Equal(key.PublicKey().Bytes(), signer.(PublicKeySigner).PublicKey())
+
+	sig, err := signer.Sign([]byte("payload"))
+	require.NoError(err)
+	require.Len(sig, secp256k1.SignatureLen)
+
+	recovered, err := secp256k1.RecoverPublicKey([]byte("payload"), sig)
+	require.NoError(err)
+	require.Equal(key.Address(), recovered.Address())
+}
+
+func TestPIVKeychainPINRequired(t *testing.T) {
+	require := require.New(t)
+
+	card := newMockPIVCard(t)
+	kc, err := NewPIVKeychain(card, []PIVSlot{PIVSlotSignature}, PIVConfig{})
+	require.NoError(err)
+
+	addr, _ := kc.Addresses().Peek()
+	signer, _ := kc.Get(addr)
+	_, err = signer.Sign([]byte("payload"))
+	require.ErrorIs(err, ErrPINRequired)
+
+	// A wrong PIN is not cached
+	kc, err = NewPIVKeychain(card, []PIVSlot{PIVSlotSignature}, PIVConfig{
+		PIN: func() (string, error) {
+			return "000000", nil
+		},
+	})
+	require.NoError(err)
+	signer, _ = kc.Get(addr)
+	_, err = signer.Sign([]byte("payload"))
+	require.ErrorIs(err, errWrongPIN)
+	require.Empty(kc.(*pivKeychain).pin)
+}
+
+func TestNewPIVKeychainErrors(t *testing.T) {
+	require := require.New(t)
+
+	card := newMockPIVCard(t)
+	_, err := NewPIVKeychain(card, nil, PIVConfig{})
+	require.ErrorIs(err, ErrInvalidSlotsLength)
+
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(err)
+	card.slots[PIVSlotCardAuth] = &mockPIVSlot{
+		info: PIVKeyInfo{PublicKey: &p384.PublicKey},
+	}
+	_, err = NewPIVKeychain(card, []PIVSlot{PIVSlotCardAuth}, PIVConfig{})
+	require.ErrorIs(err, ErrUnsupportedPIVAlgorithm)
+
+	_, err = NewPIVKeychain(card, []PIVSlot{PIVSlotSignature, PIVSlotSignature}, PIVConfig{})
+	require.ErrorIs(err, ErrDuplicatePIVKey)
+}
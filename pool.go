@@ -0,0 +1,186 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/luxfi/ids"
+)
+
+// SignRequest is a hash to be signed by the key of an address
+type SignRequest struct {
+	Address ids.ShortID
+	Hash    []byte
+}
+
+// BatchError reports the requests of a batch that failed to be signed
+type BatchError struct {
+	// Errors holds the error of each request of the batch, in order, and nil
+	// for the requests that were signed
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	var (
+		failed int
+		first  error
+	)
+	for _, err := range e.Errors {
+		if err == nil {
+			continue
+		}
+		if first == nil {
+			first = err
+		}
+		failed++
+	}
+	return fmt.Sprintf("%d of %d signing requests failed: %s", failed, len(e.Errors), first)
+}
+
+// Unwrap returns the errors of the failed requests, so that errors.Is and
+// errors.As match any of them
+func (e *BatchError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// SignHashes signs every request of [requests] with the signers of [kc],
+// spreading the independent SignHash calls over up to [workers] goroutines.
+// If [workers] is not positive, GOMAXPROCS workers are used. The results are
+// returned in the order of the requests; a request that fails does not stop
+// the others, and requests for addresses not in [kc] fail with
+// ErrUnknownAddress.
+//
+// This is intended for software keychains signing large batches, such as the
+// withdrawals of an exchange. Devices that serialize their operations, like
+// ledgers, gain nothing from more than one worker.
+func SignHashes(kc Keychain, requests []SignRequest, workers int) []SignResult {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return signBatch(context.Background(), kc, requests, workers)
+}
+
+// SignMany signs [requests] with the signers of [kc], keeping up to
+// [concurrency] requests in flight at once, so that the round trips to a
+// remote backend such as a KMS or Vault overlap instead of adding up.
+// [concurrency] is at least 1.
+//
+// The signatures are returned in the order of the requests. If any request
+// fails, the signatures of the others are still returned, the failed ones are
+// nil, and the error is a *BatchError recording the error of each request.
+// Once [ctx] is done, in-flight requests are cancelled as by SignHashContext
+// and the requests not yet started fail with ErrOperationCanceled.
+func SignMany(ctx context.Context, kc Keychain, requests []SignRequest, concurrency int) ([][]byte, error) {
+	results := signBatch(ctx, kc, requests, max(concurrency, 1))
+
+	var (
+		sigs   = make([][]byte, len(results))
+		errs   = make([]error, len(results))
+		failed bool
+	)
+	for i, result := range results {
+		sigs[i] = result.Signature
+		errs[i] = result.Err
+		failed = failed || result.Err != nil
+	}
+	if failed {
+		return sigs, &BatchError{Errors: errs}
+	}
+	return sigs, nil
+}
+
+// AddressSignature is the signature of a hash by the key of an address
+type AddressSignature struct {
+	Address   ids.ShortID
+	Signature []byte
+}
+
+// SignAll signs [hash] with every signer of [kc], as needed when all the
+// local owners of a multi-owner UTXO must co-sign it. The signatures are
+// returned ordered by address. The signers are called one at a time, so that
+// devices are not asked to sign concurrently. If any of them fails, the
+// signatures of the others are still returned, the failed ones are nil, and
+// the error is a *BatchError recording the error of each address in order.
+func SignAll(ctx context.Context, kc Keychain, hash []byte) ([]AddressSignature, error) {
+	addrs := kc.Addresses().List()
+	slices.SortFunc(addrs, ids.ShortID.Compare)
+
+	requests := make([]SignRequest, len(addrs))
+	for i, addr := range addrs {
+		requests[i] = SignRequest{
+			Address: addr,
+			Hash:    hash,
+		}
+	}
+	sigs, err := SignMany(ctx, kc, requests, 1)
+	pairs := make([]AddressSignature, len(addrs))
+	for i, addr := range addrs {
+		pairs[i] = AddressSignature{
+			Address:   addr,
+			Signature: sigs[i],
+		}
+	}
+	return pairs, err
+}
+
+// signBatch signs [requests] with a pool of [workers] goroutines
+func signBatch(ctx context.Context, kc Keychain, requests []SignRequest, workers int) []SignResult {
+	var (
+		results = make([]SignResult, len(requests))
+		jobs    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for range min(workers, len(requests)) {
+		wg.Go(func() {
+			for i := range jobs {
+				results[i] = signRequest(ctx, kc, requests[i])
+			}
+		})
+	}
+	for i := range requests {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func signRequest(ctx context.Context, kc Keychain, request SignRequest) SignResult {
+	if err := ctx.Err(); err != nil {
+		return SignResult{
+			Err: fmt.Errorf("%w: %w", ErrOperationCanceled, context.Cause(ctx)),
+		}
+	}
+	signer, ok := kc.Get(request.Address)
+	if !ok {
+		return SignResult{
+			Err: fmt.Errorf("%w: %s", ErrUnknownAddress, request.Address),
+		}
+	}
+
+	var (
+		sig []byte
+		err error
+	)
+	if ctx.Done() == nil {
+		sig, err = signer.SignHash(request.Hash)
+	} else {
+		sig, err = SignHashContext(ctx, signer, request.Hash)
+	}
+	return SignResult{
+		Signature: sig,
+		Err:       err,
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+)
+
+// signRequests returns [n] requests spread over the keys of [kc]
+func signRequests(kc *SoftwareKeychain, n int) []SignRequest {
+	keys := kc.Keys()
+	requests := make([]SignRequest, n)
+	for i := range requests {
+		hash := sha256.Sum256(fmt.Appendf(nil, "withdrawal %d", i))
+		requests[i] = SignRequest{
+			Address: keys[i%len(keys)].Address(),
+			Hash:    hash[:],
+		}
+	}
+	return requests
+}
+
+func TestSignHashes(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("pool"), 4)
+	require.NoError(err)
+
+	requests := signRequests(kc, 64)
+	unknown := ids.GenerateTestShortID()
+	requests = append(requests, SignRequest{Address: unknown, Hash: requests[0].Hash})
+
+	for _, workers := range []int{0, 1, 8, 1000} {
+		results := SignHashes(kc, requests, workers)
+		require.Len(results, len(requests))
+		for i, result := range results[:len(results)-1] {
+			require.NoError(result.Err)
+			pubKey, err := secp256k1.RecoverPublicKeyFromHash(requests[i].Hash, result.Signature)
+			require.NoError(err)
+			require.Equal(requests[i].Address, pubKey.Address())
+		}
+		require.ErrorIs(results[len(results)-1].Err, ErrUnknownAddress)
+	}
+
+	require.Empty(SignHashes(kc, nil, 4))
+}
+
+func TestSignMany(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("pool"), 4)
+	require.NoError(err)
+
+	// Simulate a remote backend, recording the requests in flight at once
+	var inflight, peak atomic.Int32
+	remote := Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return next(payload)
+	})
+
+	requests := signRequests(kc, 32)
+	sigs, err := SignMany(context.Background(), Wrap(kc, remote), requests, 4)
+	require.NoError(err)
+	require.Len(sigs, len(requests))
+	for i, sig := range sigs {
+		pubKey, err := secp256k1.RecoverPublicKeyFromHash(requests[i].Hash, sig)
+		require.NoError(err)
+		require.Equal(requests[i].Address, pubKey.Address())
+	}
+	require.LessOrEqual(peak.Load(), int32(4))
+	require.Greater(peak.Load(), int32(1))
+}
+
+func TestSignManyPartialFailure(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("pool"), 2)
+	require.NoError(err)
+
+	requests := signRequests(kc, 4)
+	requests[1].Address = ids.GenerateTestShortID()
+	requests[3].Hash = []byte("short")
+
+	sigs, err := SignMany(context.Background(), kc, requests, 2)
+	require.ErrorIs(err, ErrUnknownAddress)
+	var batchErr *BatchError
+	require.ErrorAs(err, &batchErr)
+	require.Len(batchErr.Errors, len(requests))
+	require.Len(batchErr.Unwrap(), 2)
+	require.Contains(err.Error(), "2 of 4 signing requests failed")
+
+	for _, i := range []int{0, 2} {
+		require.NoError(batchErr.Errors[i])
+		require.NotNil(sigs[i])
+	}
+	for _, i := range []int{1, 3} {
+		require.Error(batchErr.Errors[i])
+		require.Nil(sigs[i])
+	}
+}
+
+func TestSignManyCanceled(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("pool"), 1)
+	require.NoError(err)
+
+	errShutdown := errors.New("shutting down")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errShutdown)
+
+	sigs, err := SignMany(ctx, kc, signRequests(kc, 3), 2)
+	require.ErrorIs(err, ErrOperationCanceled)
+	require.ErrorIs(err, errShutdown)
+	require.Equal(make([][]byte, 3), sigs)
+}
+
+func TestSignAll(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("pool"), 3)
+	require.NoError(err)
+	hash := sha256.Sum256([]byte("multi-owner utxo"))
+
+	pairs, err := SignAll(context.Background(), kc, hash[:])
+	require.NoError(err)
+	require.Len(pairs, 3)
+	for i, pair := range pairs {
+		pubKey, err := secp256k1.RecoverPublicKeyFromHash(hash[:], pair.Signature)
+		require.NoError(err)
+		require.Equal(pair.Address, pubKey.Address())
+		if i > 0 {
+			require.Negative(pairs[i-1].Address.Compare(pair.Address))
+		}
+	}
+
+	pairs, err = SignAll(context.Background(), NewSoftwareKeychain(), hash[:])
+	require.NoError(err)
+	require.Empty(pairs)
+}
+
+func TestSignAllPartialFailure(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("pool"), 3)
+	require.NoError(err)
+	addrs := kc.Addresses().List()
+	slices.SortFunc(addrs, ids.ShortID.Compare)
+	rejected := addrs[1]
+
+	policy := Intercept(func(signer Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		if signer.Address() == rejected {
+			return nil, errPolicy
+		}
+		return next(payload)
+	})
+	hash := sha256.Sum256([]byte("multi-owner utxo"))
+	pairs, err := SignAll(context.Background(), Wrap(kc, policy), hash[:])
+	require.ErrorIs(err, errPolicy)
+	var batchErr *BatchError
+	require.ErrorAs(err, &batchErr)
+	require.Len(pairs, 3)
+	require.Equal(rejected, pairs[1].Address)
+	require.Nil(pairs[1].Signature)
+	require.ErrorIs(batchErr.Errors[1], errPolicy)
+	require.NotNil(pairs[0].Signature)
+	require.NotNil(pairs[2].Signature)
+}
+
+func BenchmarkSignHashes(b *testing.B) {
+	kc, err := NewTestKeychain([]byte("pool"), 16)
+	require.NoError(b, err)
+	requests := signRequests(kc, 1024)
+
+	for _, workers := range []int{1, 4, 0} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for b.Loop() {
+				SignHashes(kc, requests, workers)
+			}
+			b.ReportMetric(float64(b.N*len(requests))/b.Elapsed().Seconds(), "sigs/s")
+		})
+	}
+}
+
+func BenchmarkSoftwareSignHash(b *testing.B) {
+	kc, err := NewTestKeychain([]byte("pool"), 1)
+	require.NoError(b, err)
+	signer, ok := kc.Get(kc.Keys()[0].Address())
+	require.True(b, ok)
+	hash := sha256.Sum256([]byte("withdrawal"))
+
+	for b.Loop() {
+		_, err := signer.SignHash(hash[:])
+		require.NoError(b, err)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/luxfi/ids"
+)
+
+var (
+	ErrTransactionRejected  = errors.New("transaction rejected before signing")
+	ErrBlindSigningDisabled = errors.New("blind signing of hashes is disabled")
+
+	_ PublicKeyLedger = (*previewLedger)(nil)
+	_ Canceler        = (*previewLedger)(nil)
+)
+
+// TxDecoder parses the unsigned transaction [unsignedTx] that
+// [addressIndices] are about to sign and returns a human readable summary of
+// it. Returning an error rejects the transaction.
+type TxDecoder func(unsignedTx []byte, addressIndices []uint32) (string, error)
+
+// PreviewConfig configures the checks run before a payload is sent to a
+// ledger for signing
+type PreviewConfig struct {
+	// Decode is called with every transaction before it reaches the device
+	Decode TxDecoder
+	// Display, if set, presents the summary returned by Decode, for example
+	// to ask the user for confirmation. Returning an error rejects the
+	// transaction.
+	Display func(summary string) error
+	// AllowBlindSigning permits SignHash, whose payload cannot be decoded
+	AllowBlindSigning bool
+}
+
+// previewLedger decodes transactions before forwarding them to a ledger
+type previewLedger struct {
+	ledger Ledger
+	config PreviewConfig
+}
+
+// NewPreviewLedger wraps [ledger] so that the transactions passed to Sign and
+// SignTransaction are decoded, and optionally displayed, before they reach
+// the device. Transactions rejected by the decoder or display fail with
+// ErrTransactionRejected without contacting the device. Unless blind signing
+// is allowed, SignHash fails with ErrBlindSigningDisabled.
+//
+// This guards against blind signing payloads the device itself cannot parse
+// or displays incompletely.
+func NewPreviewLedger(ledger Ledger, config PreviewConfig) Ledger {
+	return &previewLedger{
+		ledger: ledger,
+		config: config,
+	}
+}
+
+func (p *previewLedger) Address(displayHRP string, addressIndex uint32) (ids.ShortID, error) {
+	return p.ledger.Address(displayHRP, addressIndex)
+}
+
+func (p *previewLedger) GetAddresses(addressIndices []uint32) ([]ids.ShortID, error) {
+	return p.ledger.GetAddresses(addressIndices)
+}
+
+// GetPublicKeys returns nil public keys if the underlying ledger cannot
+// export them
+func (p *previewLedger) GetPublicKeys(addressIndices []uint32) ([][]byte, error) {
+	pkLedger, ok := p.ledger.(PublicKeyLedger)
+	if !ok {
+		return make([][]byte, len(addressIndices)), nil
+	}
+	return pkLedger.GetPublicKeys(addressIndices)
+}
+
+func (p *previewLedger) SignHash(hash []byte, addressIndex uint32) ([]byte, error) {
+	if !p.config.AllowBlindSigning {
+		return nil, ErrBlindSigningDisabled
+	}
+	return p.ledger.SignHash(hash, addressIndex)
+}
+
+func (p *previewLedger) Sign(msg []byte, addressIndex uint32) ([]byte, error) {
+	if err := p.preview(msg, []uint32{addressIndex}); err != nil {
+		return nil, err
+	}
+	return p.ledger.Sign(msg, addressIndex)
+}
+
+func (p *previewLedger) SignTransaction(rawUnsignedHash []byte, addressIndices []uint32) ([][]byte, error) {
+	if err := p.preview(rawUnsignedHash, addressIndices); err != nil {
+		return nil, err
+	}
+	return p.ledger.SignTransaction(rawUnsignedHash, addressIndices)
+}
+
+func (p *previewLedger) Disconnect() error {
+	return p.ledger.Disconnect()
+}
+
+func (p *previewLedger) Cancel() error {
+	if c, ok := p.ledger.(Canceler); ok {
+		return c.Cancel()
+	}
+	return nil
+}
+
+// preview decodes and displays [unsignedTx], returning an error if either
+// rejects it
+func (p *previewLedger) preview(unsignedTx []byte, addressIndices []uint32) error {
+	if p.config.Decode == nil {
+		return fmt.Errorf("%w: no decoder configured", ErrTransactionRejected)
+	}
+	summary, err := p.config.Decode(unsignedTx, addressIndices)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTransactionRejected, err)
+	}
+	if p.config.Display == nil {
+		return nil
+	}
+	if err := p.config.Display(summary); err != nil {
+		return fmt.Errorf("%w: %w", ErrTransactionRejected, err)
+	}
+	return nil
+}
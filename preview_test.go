@@ -0,0 +1,111 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errMaliciousTx = errors.New("malicious transaction")
+	errDeclined    = errors.New("declined by user")
+)
+
+func decodeTestTx(unsignedTx []byte, addressIndices []uint32) (string, error) {
+	if bytes.Contains(unsignedTx, []byte("drain")) {
+		return "", errMaliciousTx
+	}
+	return fmt.Sprintf("%q signed by %v", unsignedTx, addressIndices), nil
+}
+
+func TestPreviewLedger(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	var displayed []string
+	ledger := NewPreviewLedger(NewLedgerDevice(app), PreviewConfig{
+		Decode: decodeTestTx,
+		Display: func(summary string) error {
+			displayed = append(displayed, summary)
+			return nil
+		},
+	})
+
+	kc, err := NewLedgerKeychain(ledger, []uint32{0, 1})
+	require.NoError(err)
+	for _, signer := range Iter(kc) {
+		_, ok := PublicKeyOf(signer)
+		require.True(ok)
+	}
+
+	sigs, err := ledger.SignTransaction([]byte("transfer"), []uint32{0, 1})
+	require.NoError(err)
+	require.Len(sigs, 2)
+	_, err = ledger.Sign([]byte("stake"), 1)
+	require.NoError(err)
+	require.Equal([]string{
+		`"transfer" signed by [0 1]`,
+		`"stake" signed by [1]`,
+	}, displayed)
+	exchanges := app.exchanges
+
+	// Rejected transactions never reach the device
+	_, err = ledger.SignTransaction([]byte("drain"), []uint32{0})
+	require.ErrorIs(err, ErrTransactionRejected)
+	require.ErrorIs(err, errMaliciousTx)
+
+	_, err = ledger.SignHash(make([]byte, 32), 0)
+	require.ErrorIs(err, ErrBlindSigningDisabled)
+	require.Len(displayed, 2)
+	require.Equal(exchanges, app.exchanges)
+}
+
+func TestPreviewLedgerDisplayRejects(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	ledger := NewPreviewLedger(NewLedgerDevice(app), PreviewConfig{
+		Decode: decodeTestTx,
+		Display: func(string) error {
+			return errDeclined
+		},
+	})
+
+	_, err := ledger.Sign([]byte("transfer"), 0)
+	require.ErrorIs(err, ErrTransactionRejected)
+	require.ErrorIs(err, errDeclined)
+	require.Zero(app.exchanges)
+}
+
+func TestPreviewLedgerBlindSigning(t *testing.T) {
+	require := require.New(t)
+
+	ledger := NewPreviewLedger(NewLedgerDevice(newFakeLuxApp()), PreviewConfig{
+		AllowBlindSigning: true,
+	})
+
+	sig, err := ledger.SignHash(make([]byte, 32), 0)
+	require.NoError(err)
+	require.Len(sig, ledgerSignatureLen)
+
+	// Transactions are rejected without a decoder
+	_, err = ledger.SignTransaction([]byte("transfer"), []uint32{0})
+	require.ErrorIs(err, ErrTransactionRejected)
+}
+
+func TestPreviewLedgerWithoutPublicKeys(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewLedgerKeychain(NewPreviewLedger(newMockLedger(), PreviewConfig{}), []uint32{1})
+	require.NoError(err)
+	for _, signer := range Iter(kc) {
+		_, ok := PublicKeyOf(signer)
+		require.False(ok)
+	}
+}
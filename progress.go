@@ -0,0 +1,103 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+
+	"github.com/luxfi/ids"
+)
+
+// ProgressStage is the stage of a ledger operation
+type ProgressStage string
+
+// Stages of the ledger operations reported by WithProgress
+const (
+	// ProgressDeriving reports that Step of Steps addresses were derived
+	ProgressDeriving ProgressStage = "deriving"
+	// ProgressSending reports that chunk Step of Steps of a transaction is
+	// being sent to the device
+	ProgressSending ProgressStage = "sending"
+	// ProgressConfirming reports that the device waits for the user to
+	// confirm the operation
+	ProgressConfirming ProgressStage = "confirming"
+)
+
+var (
+	_ ProgressLedger = (*LedgerDevice)(nil)
+	_ ProgressLedger = (*timeoutLedger)(nil)
+	_ ProgressLedger = (*previewLedger)(nil)
+	_ ProgressLedger = (*retryLedger)(nil)
+)
+
+// Progress describes the progress of a ledger operation, so that wallet UIs
+// can tell the user what the device is doing, such as "chunk 3/7 sent" or
+// "confirm on your device"
+type Progress struct {
+	Stage ProgressStage
+	// Address and Op identify the signing operation. They are zero while
+	// deriving.
+	Address ids.ShortID
+	Op      SignOp
+	// Index is the address index of the operation, or of the derived address
+	Index uint32
+	// Step counts from 1 to Steps the addresses derived or the chunks sent.
+	// Both are zero while confirming.
+	Step, Steps int
+}
+
+// ProgressLedger is implemented by ledgers that report the chunks of the
+// transactions they send to the device
+type ProgressLedger interface {
+	Ledger
+	// SignWithProgress signs as Sign, reporting each chunk sent to the
+	// device and the wait for the user's confirmation to [progress]
+	SignWithProgress(msg []byte, addressIndex uint32, progress func(Progress)) ([]byte, error)
+}
+
+// WithProgress calls [f] as the operations of the ledger keychain progress:
+// as each address is derived while the keychain is created, and as each
+// signing operation is sent to the device and waits for the user. Chunks are
+// only reported by ledgers implementing ProgressLedger, such as
+// LedgerDevice. Lazy keychains only report signing operations. [f] is called
+// from the goroutine of the operation and, for derivations using
+// WithConcurrency, from only one goroutine at a time.
+func WithProgress(f func(Progress)) Option {
+	return func(o *options) {
+		o.onProgress = f
+	}
+}
+
+// SignWithProgress signs [msg] with [addressIndex], reporting its progress to
+// [progress] if [ledger] implements ProgressLedger. Other ledgers only report
+// the wait for the user's confirmation.
+func SignWithProgress(ledger Ledger, msg []byte, addressIndex uint32, progress func(Progress)) ([]byte, error) {
+	if p, ok := ledger.(ProgressLedger); ok {
+		return p.SignWithProgress(msg, addressIndex, progress)
+	}
+	progress(Progress{
+		Stage: ProgressConfirming,
+		Index: addressIndex,
+	})
+	return ledger.Sign(msg, addressIndex)
+}
+
+func (t *timeoutLedger) SignWithProgress(msg []byte, addressIndex uint32, progress func(Progress)) ([]byte, error) {
+	return withTimeout(t.timeouts.Sign, t.cancel, func() ([]byte, error) {
+		return SignWithProgress(t.ledger, msg, addressIndex, progress)
+	})
+}
+
+func (p *previewLedger) SignWithProgress(msg []byte, addressIndex uint32, progress func(Progress)) ([]byte, error) {
+	if err := p.preview(msg, []uint32{addressIndex}); err != nil {
+		return nil, err
+	}
+	return SignWithProgress(p.ledger, msg, addressIndex, progress)
+}
+
+func (r *retryLedger) SignWithProgress(msg []byte, addressIndex uint32, progress func(Progress)) ([]byte, error) {
+	return RetryOperation(context.Background(), r.policy, func() ([]byte, error) {
+		return SignWithProgress(r.ledger, msg, addressIndex, progress)
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ledgerOnly hides the optional interfaces of a ledger
+type ledgerOnly struct {
+	Ledger
+}
+
+func TestWithProgress(t *testing.T) {
+	require := require.New(t)
+
+	var events []Progress
+	kc, err := NewLedgerKeychain(NewLedgerDevice(newFakeLuxApp()), []uint32{0, 1, 2}, WithBlindSigning(), WithProgress(func(p Progress) {
+		events = append(events, p)
+	}))
+	require.NoError(err)
+	require.Equal([]Progress{
+		{Stage: ProgressDeriving, Index: 0, Step: 1, Steps: 3},
+		{Stage: ProgressDeriving, Index: 1, Step: 2, Steps: 3},
+		{Stage: ProgressDeriving, Index: 2, Step: 3, Steps: 3},
+	}, events)
+
+	// Transactions report each chunk, then the wait for the confirmation
+	addr, _ := kc.Addresses().Peek()
+	signer, _ := kc.Get(addr)
+	idx := kc.(*ledgerKeychain).addrToIdx[addr]
+	events = nil
+	_, err = signer.Sign(bytes.Repeat([]byte{1}, 600))
+	require.NoError(err)
+	require.Equal([]Progress{
+		{Stage: ProgressSending, Address: addr, Op: OpSign, Index: idx, Step: 1, Steps: 3},
+		{Stage: ProgressSending, Address: addr, Op: OpSign, Index: idx, Step: 2, Steps: 3},
+		{Stage: ProgressSending, Address: addr, Op: OpSign, Index: idx, Step: 3, Steps: 3},
+		{Stage: ProgressConfirming, Address: addr, Op: OpSign, Index: idx},
+	}, events)
+
+	events = nil
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	require.Equal([]Progress{
+		{Stage: ProgressConfirming, Address: addr, Op: OpSignHash, Index: idx},
+	}, events)
+}
+
+func TestWithProgressWrappedLedger(t *testing.T) {
+	require := require.New(t)
+
+	var events []Progress
+	progress := WithProgress(func(p Progress) {
+		events = append(events, p)
+	})
+	device := NewLedgerDevice(newFakeLuxApp())
+
+	// Wrappers report the chunks of the ledger they wrap
+	kc, err := NewLedgerKeychain(NewTimeoutLedger(device, Timeouts{}), []uint32{0}, progress)
+	require.NoError(err)
+	addr, _ := kc.Addresses().Peek()
+	signer, _ := kc.Get(addr)
+	events = nil
+	_, err = signer.Sign([]byte("tx"))
+	require.NoError(err)
+	require.Len(events, 2)
+	require.Equal(ProgressSending, events[0].Stage)
+	require.Equal(ProgressConfirming, events[1].Stage)
+
+	// Other ledgers only report the wait for the confirmation
+	kc, err = NewLedgerKeychain(ledgerOnly{device}, []uint32{0}, progress)
+	require.NoError(err)
+	signer, _ = kc.Get(addr)
+	events = nil
+	_, err = signer.Sign([]byte("tx"))
+	require.NoError(err)
+	require.Equal([]Progress{
+		{Stage: ProgressConfirming, Address: addr, Op: OpSign},
+	}, events)
+}
+
+func TestDeriveAddressesProgress(t *testing.T) {
+	require := require.New(t)
+
+	indices := []uint32{0, 1, 2, 3, 4, 5}
+	var steps []int
+	_, err := deriveAddresses(NewLedgerDevice(newFakeLuxApp()), indices, 3, 0, func(p Progress) {
+		require.Equal(ProgressDeriving, p.Stage)
+		require.Len(indices, p.Steps)
+		steps = append(steps, p.Step)
+	})
+	require.NoError(err)
+	require.Equal([]int{1, 2, 3, 4, 5, 6}, steps)
+}
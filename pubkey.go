@@ -0,0 +1,54 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"slices"
+)
+
+var ErrInvalidNumPublicKeys = errors.New("incorrect number of ledger public keys")
+
+// PublicKeyLedger is implemented by ledgers that can export the public keys of
+// their derived addresses. Ledger keychains fetch the public keys once, during
+// derivation, and cache them on their signers.
+type PublicKeyLedger interface {
+	Ledger
+	GetPublicKeys(addressIndices []uint32) ([][]byte, error)
+}
+
+// PublicKeySigner is implemented by signers that can report their public key
+// without contacting the signing device
+type PublicKeySigner interface {
+	Signer
+	// PublicKey returns the signer's public key, or nil if it is not known
+	PublicKey() []byte
+}
+
+// derivePublicKeys returns the public keys of [indices], in order, if
+// [ledger] is able to export them, requested in batches of [batchSize]
+// indices, or in a single request if [batchSize] is 0. Otherwise nil is
+// returned.
+func derivePublicKeys(ledger Ledger, indices []uint32, batchSize int) ([][]byte, error) {
+	pkLedger, ok := ledger.(PublicKeyLedger)
+	if !ok {
+		return nil, nil
+	}
+	if batchSize <= 0 {
+		batchSize = max(len(indices), 1)
+	}
+
+	pubKeys := make([][]byte, 0, len(indices))
+	for batch := range slices.Chunk(indices, batchSize) {
+		batchKeys, err := pkLedger.GetPublicKeys(batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(batchKeys) != len(batch) {
+			return nil, ErrInvalidNumPublicKeys
+		}
+		pubKeys = append(pubKeys, batchKeys...)
+	}
+	return pubKeys, nil
+}
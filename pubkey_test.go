@@ -0,0 +1,94 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// pubKeyLedger exports a deterministic public key per index
+type pubKeyLedger struct {
+	*mockLedger
+	calls int
+	short bool
+}
+
+func (p *pubKeyLedger) GetPublicKeys(addressIndices []uint32) ([][]byte, error) {
+	p.calls++
+	pubKeys := make([][]byte, len(addressIndices))
+	for i, idx := range addressIndices {
+		pubKeys[i] = []byte{0x02, byte(idx)}
+	}
+	if p.short {
+		return pubKeys[1:], nil
+	}
+	return pubKeys, nil
+}
+
+func TestLedgerKeychainCachesPublicKeys(t *testing.T) {
+	require := require.New(t)
+
+	ledger := &pubKeyLedger{mockLedger: newMockLedger()}
+	kc, err := NewLedgerKeychain(ledger, []uint32{1, 2})
+	require.NoError(err)
+	require.Equal(1, ledger.calls)
+
+	addr, err := ledger.Address("", 2)
+	require.NoError(err)
+	signer, ok := kc.Get(addr)
+	require.True(ok)
+
+	pkSigner, ok := signer.(PublicKeySigner)
+	require.True(ok)
+	require.Equal([]byte{0x02, 2}, pkSigner.PublicKey())
+
+	// Public keys are served from the cache
+	require.Equal(1, ledger.calls)
+}
+
+func TestLedgerKeychainPublicKeyCountMismatch(t *testing.T) {
+	require := require.New(t)
+
+	ledger := &pubKeyLedger{
+		mockLedger: newMockLedger(),
+		short:      true,
+	}
+	_, err := NewLedgerKeychain(ledger, []uint32{1, 2})
+	require.ErrorIs(err, ErrInvalidNumPublicKeys)
+}
+
+func TestLedgerKeychainWithoutPublicKeys(t *testing.T) {
+	require := require.New(t)
+
+	ledger := newMockLedger()
+	kc, err := NewLedgerKeychain(ledger, []uint32{1})
+	require.NoError(err)
+
+	addr, err := ledger.Address("", 1)
+	require.NoError(err)
+	signer, ok := kc.Get(addr)
+	require.True(ok)
+	require.Nil(signer.(PublicKeySigner).PublicKey())
+}
+
+func TestLazyLedgerKeychainCachesPublicKeys(t *testing.T) {
+	require := require.New(t)
+
+	ledger := &pubKeyLedger{mockLedger: newMockLedger()}
+	kc, err := NewLazyLedgerKeychain(ledger, []uint32{1, 2})
+	require.NoError(err)
+	require.Zero(ledger.calls)
+
+	signer, err := kc.SignerAt(2)
+	require.NoError(err)
+	require.Equal([]byte{0x02, 2}, signer.(PublicKeySigner).PublicKey())
+	require.Equal(1, ledger.calls)
+
+	signer, err = kc.SignerAt(2)
+	require.NoError(err)
+	require.Equal([]byte{0x02, 2}, signer.(PublicKeySigner).PublicKey())
+	require.Equal(1, ledger.calls)
+}
@@ -0,0 +1,103 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"sync"
+	"time"
+
+	"github.com/luxfi/ids"
+)
+
+// DeviceWait describes a signing operation that is queued behind other
+// operations on the same ledger device
+type DeviceWait struct {
+	// Address and Op identify the queued operation
+	Address ids.ShortID
+	Op      SignOp
+	// Holder and HolderOp identify the operation using the device
+	Holder   ids.ShortID
+	HolderOp SignOp
+	// HeldFor is how long the device has been used by the holder, which is
+	// typically waiting for the user to confirm on the device
+	HeldFor time.Duration
+	// Position is the number of operations ahead of this one, including the
+	// holder
+	Position int
+}
+
+// deviceQueue serializes the operations of the signers of a ledger keychain
+// so that they do not race on the device. Operations are granted the device
+// in the order they requested it.
+type deviceQueue struct {
+	onWait func(DeviceWait)
+
+	lock    sync.Mutex
+	holder  *deviceRequest
+	waiters []*deviceRequest
+}
+
+type deviceRequest struct {
+	addr  ids.ShortID
+	op    SignOp
+	since time.Time
+	// ready is closed when the device is handed to the request
+	ready chan struct{}
+}
+
+func newDeviceQueue(onWait func(DeviceWait)) *deviceQueue {
+	return &deviceQueue{onWait: onWait}
+}
+
+// acquire blocks until the device is available to [addr] and returns the
+// function releasing it
+func (q *deviceQueue) acquire(addr ids.ShortID, op SignOp) func() {
+	req := &deviceRequest{
+		addr: addr,
+		op:   op,
+	}
+
+	q.lock.Lock()
+	if q.holder == nil {
+		req.since = time.Now()
+		q.holder = req
+		q.lock.Unlock()
+		return q.release
+	}
+
+	req.ready = make(chan struct{})
+	q.waiters = append(q.waiters, req)
+	wait := DeviceWait{
+		Address:  addr,
+		Op:       op,
+		Holder:   q.holder.addr,
+		HolderOp: q.holder.op,
+		HeldFor:  time.Since(q.holder.since),
+		Position: len(q.waiters),
+	}
+	q.lock.Unlock()
+
+	if q.onWait != nil {
+		q.onWait(wait)
+	}
+	<-req.ready
+	return q.release
+}
+
+// release hands the device to the longest waiting request, if any
+func (q *deviceQueue) release() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.waiters) == 0 {
+		q.holder = nil
+		return
+	}
+	next := q.waiters[0]
+	q.waiters[0] = nil
+	q.waiters = q.waiters[1:]
+	next.since = time.Now()
+	q.holder = next
+	close(next.ready)
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+// queuedLedger blocks signing until released, recording the order in
+// which the address indices reached it
+type queuedLedger struct {
+	*mockLedger
+
+	entered chan uint32
+	release chan struct{}
+
+	lock     sync.Mutex
+	active   int
+	overlaps int
+	order    []uint32
+}
+
+func newQueuedLedger() *queuedLedger {
+	return &queuedLedger{
+		mockLedger: newMockLedger(),
+		entered:    make(chan uint32, 16),
+		release:    make(chan struct{}),
+	}
+}
+
+func (b *queuedLedger) Sign(msg []byte, addressIndex uint32) ([]byte, error) {
+	b.lock.Lock()
+	b.active++
+	if b.active > 1 {
+		b.overlaps++
+	}
+	b.order = append(b.order, addressIndex)
+	b.lock.Unlock()
+
+	b.entered <- addressIndex
+	<-b.release
+
+	b.lock.Lock()
+	b.active--
+	b.lock.Unlock()
+	return b.mockLedger.Sign(msg, addressIndex)
+}
+
+func TestLedgerKeychainSerializesDevice(t *testing.T) {
+	require := require.New(t)
+
+	ledger := newQueuedLedger()
+	waits := make(chan DeviceWait)
+	kc, err := NewLedgerKeychain(ledger, []uint32{1, 2, 3}, WithDeviceWait(func(w DeviceWait) {
+		waits <- w
+	}))
+	require.NoError(err)
+
+	signer := func(idx uint32) Signer {
+		addr, err := ledger.Address("", idx)
+		require.NoError(err)
+		s, ok := kc.Get(addr)
+		require.True(ok)
+		return s
+	}
+	signers := []Signer{signer(1), signer(2), signer(3)}
+
+	errs := make(chan error, len(signers))
+	sign := func(s Signer) {
+		go func() {
+			_, err := s.Sign([]byte("tx"))
+			errs <- err
+		}()
+	}
+
+	// The first signer holds the device
+	sign(signers[0])
+	require.Equal(uint32(1), <-ledger.entered)
+
+	// The others queue up in order, reporting who holds the device
+	for i, s := range signers[1:] {
+		sign(s)
+		wait := <-waits
+		require.Equal(DeviceWait{
+			Address:  s.Address(),
+			Op:       OpSign,
+			Holder:   signers[0].Address(),
+			HolderOp: OpSign,
+			HeldFor:  wait.HeldFor,
+			Position: i + 1,
+		}, wait)
+	}
+
+	for range signers {
+		ledger.release <- struct{}{}
+	}
+	for range signers {
+		require.NoError(<-errs)
+	}
+
+	require.Equal([]uint32{1, 2, 3}, ledger.order)
+	require.Zero(ledger.overlaps)
+}
+
+func TestDeviceQueueUncontended(t *testing.T) {
+	require := require.New(t)
+
+	q := newDeviceQueue(func(DeviceWait) {
+		require.FailNow("uncontended acquire waited")
+	})
+	for range 3 {
+		q.acquire(ids.GenerateTestShortID(), OpSignHash)()
+	}
+	require.Nil(q.holder)
+	require.Empty(q.waiters)
+}
@@ -0,0 +1,107 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var ErrDuplicateSignature = errors.New("owner signed more than once")
+
+// Quorum is the result of checking the signatures of the owners of a
+// multisig output
+type Quorum struct {
+	// Signed are the owners with a valid signature, in owner order
+	Signed []ids.ShortID
+	// Missing are the owners without a signature, in owner order
+	Missing []ids.ShortID
+	// Threshold is the number of owners that must sign
+	Threshold int
+}
+
+// Satisfied reports whether at least the threshold of owners signed
+func (q *Quorum) Satisfied() bool {
+	return len(q.Signed) >= q.Threshold
+}
+
+// CheckQuorum recovers the signer of each of [sigs], the recoverable
+// signatures of [hash] as returned by SignHash or SignAll, and reports which
+// of [owners] signed. The signatures may be in any order. A malformed
+// signature, a signature by an address that is not an owner, or two
+// signatures by the same owner fail, as they would invalidate the credential,
+// but too few signatures do not: see Quorum.Satisfied or VerifyQuorum.
+func CheckQuorum(hash []byte, owners []ids.ShortID, threshold int, sigs [][]byte) (*Quorum, error) {
+	if err := checkOwners(owners, threshold); err != nil {
+		return nil, err
+	}
+	signed := make(set.Set[ids.ShortID], len(sigs))
+	for i, sig := range sigs {
+		if len(sig) != secp256k1.SignatureLen {
+			return nil, fmt.Errorf("signature %d: %w", i, ErrInvalidSignatureLen)
+		}
+		pubKey, err := secp256k1.RecoverPublicKeyFromHash(hash, sig)
+		if err != nil {
+			return nil, fmt.Errorf("signature %d: %w: %w", i, ErrSignatureInvalid, err)
+		}
+		addr := pubKey.Address()
+		switch {
+		case !slices.Contains(owners, addr):
+			return nil, fmt.Errorf("signature %d: %w: %s", i, ErrNotAnOwner, addr)
+		case signed.Contains(addr):
+			return nil, fmt.Errorf("signature %d: %w: %s", i, ErrDuplicateSignature, addr)
+		}
+		signed.Add(addr)
+	}
+
+	q := &Quorum{Threshold: threshold}
+	for _, owner := range owners {
+		if signed.Contains(owner) {
+			q.Signed = append(q.Signed, owner)
+		} else {
+			q.Missing = append(q.Missing, owner)
+		}
+	}
+	return q, nil
+}
+
+// VerifyQuorum checks [sigs] as CheckQuorum and returns the owners that
+// signed, failing with ErrThresholdNotMet unless at least [threshold] of
+// [owners] signed
+func VerifyQuorum(hash []byte, owners []ids.ShortID, threshold int, sigs [][]byte) ([]ids.ShortID, error) {
+	q, err := CheckQuorum(hash, owners, threshold, sigs)
+	if err != nil {
+		return nil, err
+	}
+	if !q.Satisfied() {
+		return q.Signed, fmt.Errorf("%w: %d of %d signatures", ErrThresholdNotMet, len(q.Signed), threshold)
+	}
+	return q.Signed, nil
+}
+
+// VerifyAddressSignatures verifies the signatures of [hash] returned by
+// SignAll, checking that each is by the address it is attributed to, and
+// returns the owners that signed as VerifyQuorum
+func VerifyAddressSignatures(hash []byte, owners []ids.ShortID, threshold int, sigs []AddressSignature) ([]ids.ShortID, error) {
+	raw := make([][]byte, len(sigs))
+	for i, sig := range sigs {
+		if len(sig.Signature) != secp256k1.SignatureLen {
+			return nil, fmt.Errorf("%s: %w", sig.Address, ErrInvalidSignatureLen)
+		}
+		pubKey, err := secp256k1.RecoverPublicKeyFromHash(hash, sig.Signature)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w: %w", sig.Address, ErrSignatureInvalid, err)
+		}
+		if signer := pubKey.Address(); signer != sig.Address {
+			return nil, fmt.Errorf("%w: expected %s but got %s", ErrWrongSigner, sig.Address, signer)
+		}
+		raw[i] = sig.Signature
+	}
+	return VerifyQuorum(hash, owners, threshold, raw)
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"crypto/sha256"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+func TestVerifyQuorum(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("quorum"), 4)
+	require.NoError(err)
+	owners := kc.Addresses().List()
+	slices.SortFunc(owners, ids.ShortID.Compare)
+	hash := sha256.Sum256([]byte("multisig tx"))
+
+	// Two of the owners sign, in reverse order
+	pairs, err := SignAll(context.Background(), Filter(kc, set.Of(owners[1], owners[3])), hash[:])
+	require.NoError(err)
+	sigs := [][]byte{pairs[1].Signature, pairs[0].Signature}
+
+	q, err := CheckQuorum(hash[:], owners, 3, sigs)
+	require.NoError(err)
+	require.Equal([]ids.ShortID{owners[1], owners[3]}, q.Signed)
+	require.Equal([]ids.ShortID{owners[0], owners[2]}, q.Missing)
+	require.False(q.Satisfied())
+
+	signed, err := VerifyQuorum(hash[:], owners, 3, sigs)
+	require.ErrorIs(err, ErrThresholdNotMet)
+	require.Equal(q.Signed, signed)
+
+	signed, err = VerifyQuorum(hash[:], owners, 2, sigs)
+	require.NoError(err)
+	require.Equal(q.Signed, signed)
+
+	signed, err = VerifyAddressSignatures(hash[:], owners, 2, pairs)
+	require.NoError(err)
+	require.Equal(q.Signed, signed)
+
+	// Signatures attributed to the wrong owner fail
+	pairs[0].Address = owners[0]
+	_, err = VerifyAddressSignatures(hash[:], owners, 2, pairs)
+	require.ErrorIs(err, ErrWrongSigner)
+}
+
+func TestCheckQuorumErrors(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("quorum errors"), 3)
+	require.NoError(err)
+	owners := kc.Addresses().List()
+	slices.SortFunc(owners, ids.ShortID.Compare)
+	hash := sha256.Sum256([]byte("multisig tx"))
+	pairs, err := SignAll(context.Background(), kc, hash[:])
+	require.NoError(err)
+
+	_, err = CheckQuorum(hash[:], owners, 0, nil)
+	require.ErrorIs(err, ErrInvalidThreshold)
+	_, err = CheckQuorum(hash[:], []ids.ShortID{owners[0], owners[0]}, 1, nil)
+	require.ErrorIs(err, ErrDuplicateOwner)
+	_, err = CheckQuorum(hash[:], owners, 1, [][]byte{{1, 2, 3}})
+	require.ErrorIs(err, ErrInvalidSignatureLen)
+	_, err = CheckQuorum(hash[:], owners[:2], 1, [][]byte{pairs[2].Signature})
+	require.ErrorIs(err, ErrNotAnOwner)
+	_, err = CheckQuorum(hash[:], owners, 1, [][]byte{pairs[0].Signature, pairs[0].Signature})
+	require.ErrorIs(err, ErrDuplicateSignature)
+}
@@ -0,0 +1,545 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// Methods of the remote signer protocol
+const (
+	remoteMethodAuthenticate = "authenticate"
+	remoteMethodHealth       = "health"
+	remoteMethodKeys         = "keys"
+	remoteMethodSignHash     = "signHash"
+	remoteMethodSign         = "sign"
+	remoteMethodRedeemGrant  = "redeemGrant"
+)
+
+var (
+	ErrPeerNotAllowed        = errors.New("peer is not allowed to use the keychain")
+	ErrUnknownRemoteMethod   = errors.New("unknown remote signer method")
+	ErrInvalidRemoteRequest  = errors.New("invalid remote signer request")
+	ErrInvalidRemoteResponse = errors.New("invalid response from remote signer")
+	ErrServerClosed          = errors.New("signer server is closed")
+
+	_ RemoteKeychain  = (*remoteKeychain)(nil)
+	_ SchemeKeychain  = (*remoteKeychain)(nil)
+	_ PublicKeySigner = (*remoteSigner)(nil)
+	_ SchemeSigner    = (*remoteSigner)(nil)
+)
+
+// remoteHealthTimeout bounds the health check a signer server runs on its
+// keychain for a client
+const remoteHealthTimeout = 10 * time.Second
+
+// remoteErrorCodes are the errors that keep their identity across the
+// connection, so that clients can match them with errors.Is
+var remoteErrorCodes = []struct {
+	code string
+	err  error
+}{
+	{"unauthorized", ErrPeerNotAllowed},
+	{"invalid_api_key", ErrInvalidAPIKey},
+	{"unauthenticated", ErrUnauthenticated},
+	{"unknown_address", ErrUnknownAddress},
+	{"unknown_method", ErrUnknownRemoteMethod},
+	{"invalid_request", ErrInvalidRemoteRequest},
+	{"key_retired", ErrKeyRetired},
+	{"user_rejected", ErrUserRejected},
+	{"transaction_rejected", ErrTransactionRejected},
+	{"blind_signing_disabled", ErrBlindSigningDisabled},
+	{"spending_limit_exceeded", ErrSpendingLimitExceeded},
+	{"approval_denied", ErrApprovalDenied},
+	{"outside_signing_window", ErrOutsideSigningWindow},
+	{"hash_signing_unsupported", ErrHashSigningUnsupported},
+	{"canceled", ErrOperationCanceled},
+	{"not_connected", ErrNotConnected},
+	{"device_locked", ErrDeviceLocked},
+	{"app_not_open", ErrAppNotOpen},
+	{"unknown_proposal", ErrUnknownProposal},
+	{"not_an_owner", ErrNotAnOwner},
+	{"invalid_threshold", ErrInvalidThreshold},
+	{"duplicate_owner", ErrDuplicateOwner},
+	{"wrong_signer", ErrWrongSigner},
+	{"invalid_signature", ErrSignatureInvalid},
+	{"invalid_signature_length", ErrInvalidSignatureLen},
+	{"threshold_not_met", ErrThresholdNotMet},
+	{"unknown_grant", ErrUnknownGrant},
+	{"grant_expired", ErrGrantExpired},
+	{"grant_payload_mismatch", ErrGrantPayloadMismatch},
+}
+
+// RemoteError is an error returned by a remote signer. It matches the error
+// the server failed with if that error is one of the package's sentinel
+// errors, such as ErrUnknownAddress or ErrUserRejected.
+type RemoteError struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+func newRemoteError(err error) *RemoteError {
+	remoteErr := &RemoteError{Message: err.Error()}
+	for _, c := range remoteErrorCodes {
+		if errors.Is(err, c.err) {
+			remoteErr.Code = c.code
+			break
+		}
+	}
+	return remoteErr
+}
+
+func (e *RemoteError) Error() string {
+	return e.Message
+}
+
+func (e *RemoteError) Unwrap() error {
+	for _, c := range remoteErrorCodes {
+		if c.code == e.Code {
+			return c.err
+		}
+	}
+	return nil
+}
+
+// remoteRequest and remoteResponse are the messages of the remote signer
+// protocol, each encoded as a line of JSON
+type remoteRequest struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+type remoteResponse struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *RemoteError    `json:"error,omitempty"`
+}
+
+// remoteKey describes a signer of the keychain served to clients
+type remoteKey struct {
+	Address   ids.ShortID `json:"address"`
+	PublicKey []byte      `json:"publicKey,omitempty"`
+	Scheme    SchemeID    `json:"scheme"`
+}
+
+type remoteAuthParams struct {
+	APIKey string `json:"apiKey"`
+}
+
+type remoteSignParams struct {
+	Address ids.ShortID `json:"address"`
+	Payload []byte      `json:"payload"`
+}
+
+// SignerServerConfig configures a SignerServer
+type SignerServerConfig struct {
+	// Authorize is called with every accepted connection before any request
+	// is read from it. If it returns an error, the error is sent to the client
+	// and the connection is closed. If nil, every connection is served.
+	Authorize func(net.Conn) error
+	// APIKeys, if set, are the credentials clients authenticate with before
+	// any other request, each restricted to its own addresses
+	APIKeys []APIKey
+	// Grants, if set, are the signing grants clients redeem with RedeemGrant.
+	// Redeeming a grant does not require an API key.
+	Grants *GrantIssuer
+}
+
+// SignerServer serves the signers of a keychain to clients created with
+// NewRemoteKeychain, so that the key material stays in the serving process.
+//
+// The protocol is a stream of JSON requests and responses, one per line:
+//
+//	{"id":1,"method":"signHash","params":{"address":"...","payload":"<base64>"}}
+//	{"id":1,"result":"<base64 signature>"}
+//
+// Requests of a connection are served in order. The health method reports the
+// health of the served keychain, as by CheckHealth, and the redeemGrant method
+// serves RedeemGrant.
+type SignerServer struct {
+	keychain  Keychain
+	authorize func(net.Conn) error
+	apiKeys   map[[sha256.Size]byte]APIKey
+	grants    *GrantIssuer
+	server    connServer
+}
+
+// NewSignerServer creates a server for the signers of [keychain]
+func NewSignerServer(keychain Keychain, config SignerServerConfig) *SignerServer {
+	s := &SignerServer{
+		keychain:  keychain,
+		authorize: config.Authorize,
+		grants:    config.Grants,
+	}
+	if len(config.APIKeys) > 0 {
+		s.apiKeys = make(map[[sha256.Size]byte]APIKey, len(config.APIKeys))
+		for _, key := range config.APIKeys {
+			s.apiKeys[key.Hash] = key
+		}
+	}
+	return s
+}
+
+// Serve accepts connections on [l] until the server is closed, and then
+// returns ErrServerClosed. The listener is closed when Serve returns.
+func (s *SignerServer) Serve(l net.Listener) error {
+	return s.server.serve(l, s.serveConn)
+}
+
+// Close stops the listeners and closes the open connections, waiting for
+// requests in progress to return
+func (s *SignerServer) Close() error {
+	s.server.close()
+	return nil
+}
+
+// connServer tracks the listeners and connections of a server, so that they
+// are closed with it. The zero value is ready to use.
+type connServer struct {
+	lock      sync.Mutex
+	closed    bool
+	listeners set.Set[net.Listener]
+	conns     set.Set[net.Conn]
+	wg        sync.WaitGroup
+}
+
+// serve accepts connections on [l], each served by [serveConn] in a goroutine
+// of its own, until the server is closed
+func (s *connServer) serve(l net.Listener, serveConn func(net.Conn)) error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		_ = l.Close()
+		return ErrServerClosed
+	}
+	if s.listeners == nil {
+		s.listeners = make(set.Set[net.Listener])
+		s.conns = make(set.Set[net.Conn])
+	}
+	s.listeners.Add(l)
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		s.listeners.Remove(l)
+		s.lock.Unlock()
+		_ = l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.lock.Lock()
+			closed := s.closed
+			s.lock.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		s.lock.Lock()
+		if s.closed {
+			s.lock.Unlock()
+			_ = conn.Close()
+			return ErrServerClosed
+		}
+		s.conns.Add(conn)
+		s.wg.Add(1)
+		s.lock.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			serveConn(conn)
+			s.lock.Lock()
+			s.conns.Remove(conn)
+			s.lock.Unlock()
+		}()
+	}
+}
+
+// close stops the listeners and closes the open connections, waiting for
+// their goroutines to return
+func (s *connServer) close() {
+	s.lock.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		_ = l.Close()
+	}
+	for conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.lock.Unlock()
+	s.wg.Wait()
+}
+
+func (s *SignerServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	enc := json.NewEncoder(conn)
+	if s.authorize != nil {
+		if err := s.authorize(conn); err != nil {
+			_ = enc.Encode(remoteResponse{Error: newRemoteError(err)})
+			return
+		}
+	}
+
+	// The scope of the API key the client authenticated with, if the server
+	// requires one
+	var scope *APIKey
+	dec := json.NewDecoder(conn)
+	for {
+		var req remoteRequest
+		if err := dec.Decode(&req); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				_ = enc.Encode(remoteResponse{Error: newRemoteError(ErrInvalidRemoteRequest)})
+			}
+			return
+		}
+		resp := remoteResponse{ID: req.ID}
+		var (
+			result any
+			err    error
+		)
+		switch {
+		case req.Method == remoteMethodAuthenticate:
+			scope, err = s.authenticate(req)
+			result = true
+		case req.Method == remoteMethodRedeemGrant:
+			result, err = s.redeemGrant(req)
+		case s.apiKeys != nil && scope == nil:
+			err = ErrUnauthenticated
+		default:
+			result, err = s.handle(req, scope)
+		}
+		if err == nil {
+			resp.Result, err = json.Marshal(result)
+		}
+		if err != nil {
+			resp.Error = newRemoteError(err)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+		if s.apiKeys != nil && scope == nil {
+			// Clients that fail to authenticate are not given another try
+			return
+		}
+	}
+}
+
+// authenticate returns the API key presented by [req]
+func (s *SignerServer) authenticate(req remoteRequest) (*APIKey, error) {
+	var params remoteAuthParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, ErrInvalidRemoteRequest
+	}
+	key, ok := s.apiKeys[HashAPIKey(params.APIKey)]
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	return &key, nil
+}
+
+// handle serves [req] with the addresses of [scope], or every address if
+// [scope] is nil
+func (s *SignerServer) handle(req remoteRequest, scope *APIKey) (any, error) {
+	switch req.Method {
+	case remoteMethodHealth:
+		ctx, cancel := context.WithTimeout(context.Background(), remoteHealthTimeout)
+		defer cancel()
+		return true, CheckHealth(ctx, s.keychain)
+	case remoteMethodKeys:
+		return s.keys(scope), nil
+	case remoteMethodSignHash, remoteMethodSign:
+		var params remoteSignParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, ErrInvalidRemoteRequest
+		}
+		if !scope.allows(params.Address) {
+			return nil, ErrUnknownAddress
+		}
+		signer, ok := s.keychain.Get(params.Address)
+		if !ok {
+			return nil, ErrUnknownAddress
+		}
+		if req.Method == remoteMethodSignHash {
+			return signer.SignHash(params.Payload)
+		}
+		return signer.Sign(params.Payload)
+	default:
+		return nil, ErrUnknownRemoteMethod
+	}
+}
+
+// keys describes the signers of the keychain within [scope], sorted by
+// address
+func (s *SignerServer) keys(scope *APIKey) []remoteKey {
+	addrs := s.keychain.Addresses().List()
+	slices.SortFunc(addrs, ids.ShortID.Compare)
+
+	keys := make([]remoteKey, 0, len(addrs))
+	for _, addr := range addrs {
+		if !scope.allows(addr) {
+			continue
+		}
+		signer, ok := s.keychain.Get(addr)
+		if !ok {
+			continue
+		}
+		pubKey, _ := PublicKeyOf(signer)
+		keys = append(keys, remoteKey{
+			Address:   addr,
+			PublicKey: pubKey,
+			Scheme:    SchemeOf(signer),
+		})
+	}
+	return keys
+}
+
+// RemoteKeychain is a keychain of the signers served by a SignerServer
+type RemoteKeychain interface {
+	Keychain
+	// Close closes the connection to the server. Signers of the keychain
+	// fail afterwards.
+	Close() error
+}
+
+type remoteKeychain struct {
+	endpoints *remoteEndpoints
+
+	addrs set.Set[ids.ShortID]
+	keys  map[ids.ShortID]remoteKey
+}
+
+// NewRemoteKeychain creates a keychain of the signers served by the
+// SignerServer at the other end of [conn], such as a keychain daemon
+// listening on a Unix socket:
+//
+//	conn, err := net.Dial("unix", socketPath)
+//	...
+//	kc, err := keychain.NewRemoteKeychain(conn)
+//
+// The addresses are listed once, when the keychain is created. Signing
+// requests are sent one at a time over [conn], which is closed by Close or
+// once a request fails to reach the server. Servers configured with API keys
+// require WithAPIKey.
+func NewRemoteKeychain(conn net.Conn, opts ...Option) (RemoteKeychain, error) {
+	o := newOptions(opts)
+	pool := newRemotePool(nil, 1, o)
+	if err := pool.add(conn); err != nil {
+		return nil, err
+	}
+	r := &remoteKeychain{endpoints: newRemoteEndpoints([]*remotePool{pool}, o)}
+	if err := r.listKeys(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// DialRemoteKeychain creates a keychain of the signers served by the
+// SignerServer reached with [dial]. Unlike NewRemoteKeychain, a connection
+// that fails is replaced by dialing again on the next request, so that the
+// keychain outlives restarts of the server and network outages. The failed
+// request itself is not retried. Requests are served over a pool of
+// connections, of one connection unless configured by WithPoolSize and
+// WithIdleTimeout. DialFailoverKeychain dials several replicas of the server.
+func DialRemoteKeychain(dial func() (net.Conn, error), opts ...Option) (RemoteKeychain, error) {
+	return DialFailoverKeychain([]func() (net.Conn, error){dial}, opts...)
+}
+
+func (r *remoteKeychain) listKeys() error {
+	var keys []remoteKey
+	if err := r.call(remoteMethodKeys, nil, &keys); err != nil {
+		_ = r.Close()
+		return err
+	}
+	r.addrs = make(set.Set[ids.ShortID], len(keys))
+	r.keys = make(map[ids.ShortID]remoteKey, len(keys))
+	for _, key := range keys {
+		r.addrs.Add(key.Address)
+		r.keys[key.Address] = key
+	}
+	return nil
+}
+
+func (r *remoteKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	key, ok := r.keys[addr]
+	if !ok {
+		return nil, false
+	}
+	return &remoteSigner{
+		keychain: r,
+		key:      key,
+	}, true
+}
+
+func (r *remoteKeychain) Scheme(addr ids.ShortID) (SchemeID, bool) {
+	key, ok := r.keys[addr]
+	return key.Scheme, ok
+}
+
+func (r *remoteKeychain) Addresses() set.Set[ids.ShortID] {
+	return r.addrs
+}
+
+func (r *remoteKeychain) Close() error {
+	return r.endpoints.close()
+}
+
+// call sends a request and decodes the result of its response into [result]
+func (r *remoteKeychain) call(method string, params, result any) error {
+	return r.callContext(context.Background(), method, params, result)
+}
+
+// callContext sends a request as call, failing it once the deadline of [ctx]
+// passes
+func (r *remoteKeychain) callContext(ctx context.Context, method string, params, result any) error {
+	return r.endpoints.call(ctx, method, params, result)
+}
+
+type remoteSigner struct {
+	keychain *remoteKeychain
+	key      remoteKey
+}
+
+func (r *remoteSigner) SignHash(hash []byte) ([]byte, error) {
+	return r.sign(remoteMethodSignHash, hash)
+}
+
+func (r *remoteSigner) Sign(msg []byte) ([]byte, error) {
+	return r.sign(remoteMethodSign, msg)
+}
+
+func (r *remoteSigner) Address() ids.ShortID {
+	return r.key.Address
+}
+
+func (r *remoteSigner) PublicKey() []byte {
+	return slices.Clone(r.key.PublicKey)
+}
+
+func (r *remoteSigner) Scheme() SchemeID {
+	return r.key.Scheme
+}
+
+func (r *remoteSigner) sign(method string, payload []byte) ([]byte, error) {
+	var sig []byte
+	err := r.keychain.call(method, remoteSignParams{
+		Address: r.key.Address,
+		Payload: payload,
+	}, &sig)
+	return sig, err
+}
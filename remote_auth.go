@@ -0,0 +1,105 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// apiKeyLen is the number of random bytes of the keys generated by NewAPIKey
+const apiKeyLen = 32
+
+var (
+	ErrInvalidAPIKey   = errors.New("invalid API key")
+	ErrUnauthenticated = errors.New("request requires an API key")
+	ErrNoRemoteIP      = errors.New("connection has no remote IP address")
+)
+
+// APIKey is the credential of a client of a SignerServer, restricted to some
+// of the addresses of the keychain so that a leaked key cannot sign with
+// every key on the host
+type APIKey struct {
+	// Name identifies the client, such as the service using the key
+	Name string
+	// Hash is the SHA-256 hash of the key, as returned by HashAPIKey, so that
+	// server configurations do not hold the key itself
+	Hash [sha256.Size]byte
+	// Addresses are the addresses the key may list and sign with, every
+	// address if empty
+	Addresses set.Set[ids.ShortID]
+}
+
+// allows reports whether the key may use [addr]. A nil key is unrestricted.
+func (k *APIKey) allows(addr ids.ShortID) bool {
+	return k == nil || k.Addresses.Len() == 0 || k.Addresses.Contains(addr)
+}
+
+// NewAPIKey generates a random API key, given to the client, and returns it
+// together with its hash, configured on the server
+func NewAPIKey() (string, [sha256.Size]byte, error) {
+	b := make([]byte, apiKeyLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", [sha256.Size]byte{}, err
+	}
+	key := base64.RawURLEncoding.EncodeToString(b)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hash of [key] configured in APIKey.Hash
+func HashAPIKey(key string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(key))
+}
+
+// WithAPIKey authenticates the connections of a remote keychain with [key],
+// for servers configured with SignerServerConfig.APIKeys
+func WithAPIKey(key string) Option {
+	return func(o *options) {
+		o.apiKey = key
+	}
+}
+
+// AllowNetworks returns a SignerServerConfig.Authorize function that only
+// serves clients connecting from an IP address within one of [prefixes],
+// such as 10.20.0.0/16. Connections without an IP address, such as those of
+// Unix sockets, are rejected.
+func AllowNetworks(prefixes ...netip.Prefix) func(net.Conn) error {
+	prefixes = slices.Clone(prefixes)
+	return func(conn net.Conn) error {
+		addrPort, err := netip.ParseAddrPort(conn.RemoteAddr().String())
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrPeerNotAllowed, ErrNoRemoteIP)
+		}
+		addr := addrPort.Addr().Unmap()
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s is not in an allowed network", ErrPeerNotAllowed, addr)
+	}
+}
+
+// RequireAll returns a SignerServerConfig.Authorize function that serves
+// connections accepted by every one of [authorizers], checked in order, such
+// as AllowNetworks followed by AllowClientCertificates
+func RequireAll(authorizers ...func(net.Conn) error) func(net.Conn) error {
+	return func(conn net.Conn) error {
+		for _, authorize := range authorizers {
+			if err := authorize(conn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
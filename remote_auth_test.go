@@ -0,0 +1,161 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+func TestRemoteKeychainAPIKeys(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("api keys"), 2)
+	require.NoError(err)
+	keys := kc.Keys()
+	scopedKey, scopedHash, err := NewAPIKey()
+	require.NoError(err)
+	adminKey, adminHash, err := NewAPIKey()
+	require.NoError(err)
+	require.NotEqual(scopedKey, adminKey)
+	require.Equal(scopedHash, HashAPIKey(scopedKey))
+
+	config := SignerServerConfig{
+		APIKeys: []APIKey{
+			{
+				Name:      "payouts",
+				Hash:      scopedHash,
+				Addresses: set.Of(keys[0].Address()),
+			},
+			{
+				Name: "admin",
+				Hash: adminHash,
+			},
+		},
+	}
+
+	// The scoped key only sees and signs with its own addresses
+	_, conn := serveKeychain(t, kc, config)
+	remote, err := NewRemoteKeychain(conn, WithAPIKey(scopedKey))
+	require.NoError(err)
+	require.Equal(set.Of(keys[0].Address()), remote.Addresses())
+	signer, ok := remote.Get(keys[0].Address())
+	require.True(ok)
+	_, err = signer.Sign([]byte("payout"))
+	require.NoError(err)
+
+	outOfScope := &remoteSigner{
+		keychain: remote.(*remoteKeychain),
+		key:      remoteKey{Address: keys[1].Address()},
+	}
+	_, err = outOfScope.Sign([]byte("payout"))
+	require.ErrorIs(err, ErrUnknownAddress)
+
+	// An unrestricted key sees every address
+	_, conn = serveKeychain(t, kc, config)
+	remote, err = NewRemoteKeychain(conn, WithAPIKey(adminKey))
+	require.NoError(err)
+	require.Equal(kc.Addresses(), remote.Addresses())
+}
+
+func TestRemoteKeychainAPIKeyRejected(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("api keys"), 1)
+	require.NoError(err)
+	_, hash, err := NewAPIKey()
+	require.NoError(err)
+	config := SignerServerConfig{
+		APIKeys: []APIKey{{Name: "client", Hash: hash}},
+	}
+
+	_, conn := serveKeychain(t, kc, config)
+	_, err = NewRemoteKeychain(conn)
+	require.ErrorIs(err, ErrUnauthenticated)
+
+	_, conn = serveKeychain(t, kc, config)
+	_, err = NewRemoteKeychain(conn, WithAPIKey("leaked"))
+	require.ErrorIs(err, ErrInvalidAPIKey)
+
+	// The server closes the connection of a client that failed to
+	// authenticate
+	_, err = conn.Read(make([]byte, 1))
+	require.Error(err)
+}
+
+func TestAllowNetworks(t *testing.T) {
+	require := require.New(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	defer l.Close()
+	client, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(err)
+	defer client.Close()
+	conn, err := l.Accept()
+	require.NoError(err)
+	defer conn.Close()
+
+	loopback := netip.MustParsePrefix("127.0.0.0/8")
+	private := netip.MustParsePrefix("10.0.0.0/8")
+	require.NoError(AllowNetworks(private, loopback)(conn))
+	require.ErrorIs(AllowNetworks(private)(conn), ErrPeerNotAllowed)
+	require.ErrorIs(AllowNetworks()(conn), ErrPeerNotAllowed)
+
+	pipe, other := net.Pipe()
+	defer pipe.Close()
+	defer other.Close()
+	err = AllowNetworks(loopback)(pipe)
+	require.ErrorIs(err, ErrPeerNotAllowed)
+	require.ErrorIs(err, ErrNoRemoteIP)
+}
+
+func TestRequireAll(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("api keys"), 1)
+	require.NoError(err)
+	errSecond := errors.New("second check")
+	var (
+		lock    sync.Mutex
+		checked []int
+	)
+	check := func(n int, err error) func(net.Conn) error {
+		return func(net.Conn) error {
+			lock.Lock()
+			defer lock.Unlock()
+			checked = append(checked, n)
+			return err
+		}
+	}
+
+	_, conn := serveKeychain(t, kc, SignerServerConfig{
+		Authorize: RequireAll(
+			check(1, nil),
+			check(2, errors.Join(ErrPeerNotAllowed, errSecond)),
+			check(3, nil),
+		),
+	})
+	_, err = NewRemoteKeychain(conn)
+	require.ErrorIs(err, ErrPeerNotAllowed)
+	lock.Lock()
+	require.Equal([]int{1, 2}, checked)
+	lock.Unlock()
+
+	_, conn = serveKeychain(t, kc, SignerServerConfig{
+		Authorize: RequireAll(AllowNetworks(netip.MustParsePrefix("127.0.0.1/32"))),
+	})
+	remote, err := NewRemoteKeychain(conn)
+	require.NoError(err)
+	_, ok := remote.Get(ids.GenerateTestShortID())
+	require.False(ok)
+}
@@ -0,0 +1,313 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/luxfi/log"
+)
+
+// defaultRemoteHealthInterval is how often the endpoints of a failover
+// keychain are health checked, unless configured by WithHealthCheckInterval
+const defaultRemoteHealthInterval = 10 * time.Second
+
+var ErrNoRemoteEndpoints = errors.New("no remote signer endpoints")
+
+// WithRoundRobin spreads the read-only requests of a keychain created by
+// DialFailoverKeychain or DialTLSFailoverKeychain, such as listing its
+// addresses and health checks, across its healthy endpoints in turn. Signing
+// requests are always sent to the first healthy endpoint, so that a single
+// server signs while it is up.
+func WithRoundRobin() Option {
+	return func(o *options) {
+		o.roundRobin = true
+	}
+}
+
+// WithHealthCheckInterval health checks the endpoints of a keychain created
+// by DialFailoverKeychain or DialTLSFailoverKeychain every [interval], so
+// that endpoints that went down are skipped before a request fails on them,
+// and endpoints that recovered are used again. If [interval] is not
+// positive, endpoints are only checked by HealthCheck. Without it, they are
+// checked every 10 seconds.
+func WithHealthCheckInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.healthInterval = interval
+	}
+}
+
+// WithEndpointDown calls [f] with the index of an endpoint of a failover
+// keychain and the error it failed with whenever the endpoint is found down,
+// such as to alert an operator before every endpoint is
+func WithEndpointDown(f func(endpoint int, err error)) Option {
+	return func(o *options) {
+		o.onEndpointDown = f
+	}
+}
+
+// DialFailoverKeychain creates a keychain of the signers served by replicas
+// of a SignerServer, each reached with one of [dials] as by
+// DialRemoteKeychain. Requests are sent to the first endpoint of [dials]
+// that is up, and to the following ones while the endpoints they are sent to
+// cannot be reached, so that the keychain signs as long as one server is up.
+//
+// A signing request is only sent to another endpoint if it was not sent to
+// the server that failed, or if that server answered that its backend is
+// disconnected, so that a server that went down while signing does not have
+// the payload signed twice. Endpoints that failed are skipped, unless every
+// endpoint is down, until they pass a health check, as configured by
+// WithHealthCheckInterval. The keychain is created as long as one endpoint
+// is reachable.
+func DialFailoverKeychain(dials []func() (net.Conn, error), opts ...Option) (RemoteKeychain, error) {
+	if len(dials) == 0 {
+		return nil, ErrNoRemoteEndpoints
+	}
+	o := newOptions(opts)
+	pools := make([]*remotePool, len(dials))
+	for i, dial := range dials {
+		pools[i] = newRemotePool(dial, o.poolSize, o)
+	}
+	r := &remoteKeychain{endpoints: newRemoteEndpoints(pools, o)}
+	if err := r.listKeys(); err != nil {
+		return nil, err
+	}
+	if err := r.endpoints.warmUp(); err != nil {
+		_ = r.Close()
+		return nil, err
+	}
+	if len(dials) > 1 && o.healthInterval > 0 {
+		r.endpoints.watch(o.healthInterval)
+	}
+	return r, nil
+}
+
+// remoteReadOnly reports whether requests of [method] can be sent to any
+// endpoint, and sent again if their response is lost
+func remoteReadOnly(method string) bool {
+	return method == remoteMethodKeys || method == remoteMethodHealth
+}
+
+// remoteEndpoint is a server of a remote keychain
+type remoteEndpoint struct {
+	pool *remotePool
+	// down is set when the server failed a request or a health check, and
+	// cleared when it serves one
+	down atomic.Bool
+}
+
+// remoteEndpoints are the servers of a remote keychain, each with a pool of
+// connections of its own. Keychains of a single server never fail over.
+type remoteEndpoints struct {
+	endpoints  []*remoteEndpoint
+	roundRobin bool
+	onDown     func(int, error)
+	logger     log.Logger
+	// next is the endpoint the next read-only request starts from when
+	// spreading requests in turn
+	next atomic.Uint32
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	wg        sync.WaitGroup
+}
+
+func newRemoteEndpoints(pools []*remotePool, o *options) *remoteEndpoints {
+	e := &remoteEndpoints{
+		endpoints:  make([]*remoteEndpoint, len(pools)),
+		roundRobin: o.roundRobin,
+		onDown:     o.onEndpointDown,
+		logger:     o.logger,
+		closed:     make(chan struct{}),
+	}
+	for i, pool := range pools {
+		e.endpoints[i] = &remoteEndpoint{pool: pool}
+	}
+	return e
+}
+
+// order returns the indices of the endpoints to send a request of [method]
+// to, those that are up first
+func (e *remoteEndpoints) order(method string) []int {
+	n := len(e.endpoints)
+	start := 0
+	if e.roundRobin && remoteReadOnly(method) {
+		start = int((e.next.Add(1) - 1) % uint32(n))
+	}
+	order := make([]int, 0, n)
+	var down []int
+	for j := range n {
+		i := (start + j) % n
+		if e.endpoints[i].down.Load() {
+			down = append(down, i)
+		} else {
+			order = append(order, i)
+		}
+	}
+	return append(order, down...)
+}
+
+// call sends a request to the endpoints in order until one serves it, and
+// decodes the result of its response into [result]. If every endpoint it was
+// sent to failed, their errors are returned.
+func (e *remoteEndpoints) call(ctx context.Context, method string, params, result any) error {
+	var errs []error
+	for _, i := range e.order(method) {
+		reached, err := e.endpoints[i].pool.tryCall(ctx, method, params, result)
+		if err == nil {
+			e.markUp(i)
+			return nil
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+
+		var remoteErr *RemoteError
+		answered := errors.As(err, &remoteErr)
+		if answered && !IsDisconnected(err) {
+			// The server failed the request itself
+			break
+		}
+		e.markDown(i, err)
+		if reached && !answered && !remoteReadOnly(method) {
+			// The server may have served the request before failing
+			break
+		}
+	}
+	return joinRemoteErrors(errs)
+}
+
+// warmUp dials the connections of every endpoint, failing only if none of
+// them can be reached
+func (e *remoteEndpoints) warmUp() error {
+	var errs []error
+	for i, endpoint := range e.endpoints {
+		if err := endpoint.pool.warmUp(); err != nil {
+			e.markDown(i, err)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) < len(e.endpoints) {
+		return nil
+	}
+	return joinRemoteErrors(errs)
+}
+
+// healthCheck runs the health check of every endpoint, and returns nil if
+// one of them is healthy or the errors of all of them otherwise
+func (e *remoteEndpoints) healthCheck(ctx context.Context) error {
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(e.endpoints))
+	)
+	for i := range e.endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = e.probe(ctx, i)
+		}()
+	}
+	wg.Wait()
+	if slices.Contains(errs, nil) {
+		return nil
+	}
+	return joinRemoteErrors(errs)
+}
+
+// probe runs the health check of endpoint [i]. An endpoint too busy to be
+// checked before [ctx] is done is not marked down.
+func (e *remoteEndpoints) probe(ctx context.Context, i int) error {
+	var ok bool
+	reached, err := e.endpoints[i].pool.tryCall(ctx, remoteMethodHealth, nil, &ok)
+	switch {
+	case err == nil:
+		e.markUp(i)
+	case !reached && ctx.Err() != nil:
+	default:
+		e.markDown(i, err)
+	}
+	return err
+}
+
+// watch health checks the endpoints every [interval] until they are closed
+func (e *remoteEndpoints) watch(interval time.Duration) {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.closed:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), min(interval, remoteHealthTimeout))
+				_ = e.healthCheck(ctx)
+				cancel()
+			}
+		}
+	}()
+}
+
+func (e *remoteEndpoints) markDown(i int, err error) {
+	select {
+	case <-e.closed:
+		return
+	default:
+	}
+	if e.endpoints[i].down.Swap(true) {
+		return
+	}
+	logWarn(e.logger, "remote signer endpoint is down", "endpoint", i, "error", err)
+	if e.onDown != nil {
+		e.onDown(i, err)
+	}
+}
+
+func (e *remoteEndpoints) markUp(i int) {
+	if e.endpoints[i].down.Swap(false) {
+		logDebug(e.logger, "remote signer endpoint recovered", "endpoint", i)
+	}
+}
+
+// connected reports whether one of the endpoints reached its server the last
+// time it dialed or sent a request to it
+func (e *remoteEndpoints) connected() bool {
+	for _, endpoint := range e.endpoints {
+		if endpoint.pool.connected.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// close stops the health checks and closes the pools of the endpoints
+func (e *remoteEndpoints) close() error {
+	var errs []error
+	e.closeOnce.Do(func() {
+		close(e.closed)
+	})
+	for _, endpoint := range e.endpoints {
+		errs = append(errs, endpoint.pool.close())
+	}
+	e.wg.Wait()
+	return errors.Join(errs...)
+}
+
+// joinRemoteErrors joins the errors of the endpoints of a request, keeping
+// the error of a single endpoint as is
+func joinRemoteErrors(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,217 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// replica serves a keychain, counting the payloads it signed and failing as
+// disconnected from its backend while [disconnected] is set
+type replica struct {
+	addr         string
+	signed       atomic.Int32
+	disconnected atomic.Bool
+	// breakReads fails the reads of the connections dialed by dial
+	breakReads atomic.Bool
+}
+
+func listenReplica(t *testing.T, kc Keychain) *replica {
+	r := &replica{}
+	served := Wrap(kc, Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		if r.disconnected.Load() {
+			return nil, ErrNotConnected
+		}
+		r.signed.Add(1)
+		return next(payload)
+	}))
+	_, r.addr = listenKeychain(t, served, SignerServerConfig{})
+	return r
+}
+
+func (r *replica) dial() (net.Conn, error) {
+	conn, err := net.Dial("tcp", r.addr)
+	if err != nil {
+		return nil, err
+	}
+	return &breakingConn{Conn: conn, replica: r}, nil
+}
+
+type breakingConn struct {
+	net.Conn
+	replica *replica
+}
+
+func (c *breakingConn) Read(b []byte) (int, error) {
+	if c.replica.breakReads.Load() {
+		return 0, net.ErrClosed
+	}
+	return c.Conn.Read(b)
+}
+
+func TestFailoverKeychain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("failover"), 1)
+	require.NoError(err)
+	addr := kc.Keys()[0].Address()
+	primary := listenReplica(t, kc)
+	secondary := listenReplica(t, kc)
+
+	var down []int
+	remote, err := DialFailoverKeychain(
+		[]func() (net.Conn, error){primary.dial, secondary.dial},
+		WithHealthCheckInterval(0),
+		WithEndpointDown(func(endpoint int, _ error) {
+			down = append(down, endpoint)
+		}),
+	)
+	require.NoError(err)
+	defer remote.Close()
+
+	signer, ok := remote.Get(addr)
+	require.True(ok)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	require.Equal(int32(1), primary.signed.Load())
+
+	// A primary that lost its backend fails over to the secondary, which
+	// keeps signing while the primary is down
+	primary.disconnected.Store(true)
+	for range 2 {
+		_, err = signer.SignHash(make([]byte, 32))
+		require.NoError(err)
+	}
+	require.Equal(int32(2), secondary.signed.Load())
+	require.Equal([]int{0}, down)
+
+	// The primary is used again once it passes a health check
+	primary.disconnected.Store(false)
+	require.NoError(CheckHealth(context.Background(), remote))
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	require.Equal(int32(2), primary.signed.Load())
+
+	// A request whose response is lost is not signed again by the secondary
+	primary.breakReads.Store(true)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, net.ErrClosed)
+	require.Equal(int32(2), secondary.signed.Load())
+	require.Equal([]int{0, 0}, down)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	require.Equal(int32(3), secondary.signed.Load())
+
+	// The keychain fails once every endpoint is down
+	secondary.disconnected.Store(true)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrNotConnected)
+	require.ErrorIs(err, net.ErrClosed)
+}
+
+func TestFailoverKeychainUnreachable(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("failover unreachable"), 1)
+	require.NoError(err)
+	secondary := listenReplica(t, kc)
+
+	errUnreachable := errors.New("unreachable")
+	unreachable := func() (net.Conn, error) {
+		return nil, errUnreachable
+	}
+
+	// The keychain is created through the endpoints that are reachable
+	var down []int
+	remote, err := DialFailoverKeychain(
+		[]func() (net.Conn, error){unreachable, secondary.dial},
+		WithHealthCheckInterval(0),
+		WithEndpointDown(func(endpoint int, err error) {
+			require.ErrorIs(err, errUnreachable)
+			down = append(down, endpoint)
+		}),
+	)
+	require.NoError(err)
+	defer remote.Close()
+	require.Equal([]int{0}, down)
+	require.True(remote.Addresses().Equals(kc.Addresses()))
+
+	signer, ok := remote.Get(kc.Keys()[0].Address())
+	require.True(ok)
+	_, err = signer.Sign([]byte("withdrawal"))
+	require.NoError(err)
+	require.Equal(int32(1), secondary.signed.Load())
+
+	_, err = DialFailoverKeychain([]func() (net.Conn, error){unreachable, unreachable})
+	require.ErrorIs(err, errUnreachable)
+	_, err = DialFailoverKeychain(nil)
+	require.ErrorIs(err, ErrNoRemoteEndpoints)
+}
+
+func TestFailoverKeychainHealthChecks(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("failover health checks"), 1)
+	require.NoError(err)
+	primary := listenReplica(t, kc)
+	secondary := listenReplica(t, kc)
+
+	// The primary is unreachable until it is brought back up
+	var up atomic.Bool
+	dialPrimary := func() (net.Conn, error) {
+		if !up.Load() {
+			return nil, ErrNotConnected
+		}
+		return primary.dial()
+	}
+	remote, err := DialFailoverKeychain(
+		[]func() (net.Conn, error){dialPrimary, secondary.dial},
+		WithHealthCheckInterval(10*time.Millisecond),
+	)
+	require.NoError(err)
+	defer remote.Close()
+
+	signer, ok := remote.Get(kc.Keys()[0].Address())
+	require.True(ok)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	require.Equal(int32(1), secondary.signed.Load())
+
+	// The health checks find the primary once it is up
+	up.Store(true)
+	require.Eventually(func() bool {
+		_, err := signer.SignHash(make([]byte, 32))
+		return err == nil && primary.signed.Load() > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRemoteEndpointsOrder(t *testing.T) {
+	require := require.New(t)
+
+	o := newOptions([]Option{WithRoundRobin()})
+	pools := make([]*remotePool, 3)
+	for i := range pools {
+		pools[i] = newRemotePool(nil, 1, o)
+	}
+	e := newRemoteEndpoints(pools, o)
+
+	// Read-only requests are spread in turn, signing requests are not
+	require.Equal([]int{0, 1, 2}, e.order(remoteMethodKeys))
+	require.Equal([]int{1, 2, 0}, e.order(remoteMethodKeys))
+	require.Equal([]int{0, 1, 2}, e.order(remoteMethodSignHash))
+
+	// Endpoints that are down are tried last
+	e.markDown(0, ErrNotConnected)
+	require.Equal([]int{1, 2, 0}, e.order(remoteMethodSign))
+	require.Equal([]int{2, 1, 0}, e.order(remoteMethodHealth))
+	e.markUp(0)
+	require.Equal([]int{0, 1, 2}, e.order(remoteMethodSign))
+}
@@ -0,0 +1,329 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithPoolSize keeps up to [n] connections to the server of a remote keychain
+// created by DialRemoteKeychain or DialTLSKeychain, so that up to [n]
+// requests are served at once, such as by a KMS or HSM bridge signing in
+// parallel. The connections are dialed and authenticated when the keychain
+// is created, so that the first requests do not pay for establishing them.
+// [n] is at least 1, the default.
+func WithPoolSize(n int) Option {
+	return func(o *options) {
+		o.poolSize = max(n, 1)
+	}
+}
+
+// WithIdleTimeout closes the connections of a remote keychain that were not
+// used for [timeout], so that a quiet keychain does not hold sessions open
+// on its server. Closed connections are dialed again when requests need
+// them. Without it, idle connections are kept open. It does not apply to
+// keychains created by NewRemoteKeychain, whose connection cannot be dialed
+// again.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = timeout
+	}
+}
+
+// remoteConn is a connection of a remote keychain. It serves one request at
+// a time.
+type remoteConn struct {
+	conn   net.Conn
+	enc    *json.Encoder
+	dec    *json.Decoder
+	nextID uint64
+	// failed is set once a request failed to reach the server, after which
+	// the connection is closed
+	failed bool
+	// idle closes the connection once it waited in the pool for the idle
+	// timeout
+	idle *time.Timer
+}
+
+func newRemoteConn(conn net.Conn) *remoteConn {
+	return &remoteConn{
+		conn: conn,
+		enc:  json.NewEncoder(conn),
+		dec:  json.NewDecoder(conn),
+	}
+}
+
+// roundTrip sends a request and decodes the result of its response into
+// [result]
+func (c *remoteConn) roundTrip(method string, params, result any) error {
+	c.nextID++
+	req := remoteRequest{
+		ID:     c.nextID,
+		Method: method,
+	}
+	if params != nil {
+		var err error
+		if req.Params, err = json.Marshal(params); err != nil {
+			return err
+		}
+	}
+	if err := c.enc.Encode(req); err != nil {
+		c.failed = true
+		return err
+	}
+
+	var resp remoteResponse
+	if err := c.dec.Decode(&resp); err != nil {
+		c.failed = true
+		return err
+	}
+	if resp.Error != nil {
+		if resp.ID != req.ID {
+			// The server rejected the connection
+			c.failed = true
+		}
+		return resp.Error
+	}
+	if resp.ID != req.ID {
+		c.failed = true
+		return ErrInvalidRemoteResponse
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return ErrInvalidRemoteResponse
+	}
+	return nil
+}
+
+// remotePool holds the connections of a remote keychain. Connections are
+// dialed on demand, up to the size of the pool, and returned to the pool
+// once their request is served; those that fail are closed.
+type remotePool struct {
+	// dial is nil if the pool holds a single connection that is not
+	// replaced once it fails
+	dial        func() (net.Conn, error)
+	apiKey      string
+	idleTimeout time.Duration
+	// slots holds a token for every connection in use or being dialed
+	slots chan struct{}
+	// connected is cleared when a connection fails or cannot be dialed, and
+	// set when one is dialed
+	connected atomic.Bool
+
+	lock sync.Mutex
+	// idle holds the connections waiting for a request, the most recently
+	// used last
+	idle   []*remoteConn
+	closed bool
+}
+
+func newRemotePool(dial func() (net.Conn, error), size int, o *options) *remotePool {
+	p := &remotePool{
+		dial:   dial,
+		apiKey: o.apiKey,
+		slots:  make(chan struct{}, size),
+	}
+	// The connection of a pool without a dialer is never closed for being
+	// idle, as it could not be replaced
+	if dial != nil {
+		p.idleTimeout = o.idleTimeout
+	}
+	return p
+}
+
+// add authenticates [conn], with the API key if the pool has one, and adds it
+// to the idle connections
+func (p *remotePool) add(conn net.Conn) error {
+	c, err := p.open(conn)
+	if err != nil {
+		return err
+	}
+	p.slots <- struct{}{}
+	p.put(c)
+	return nil
+}
+
+// warmUp dials the connections of the pool that are not open yet
+func (p *remotePool) warmUp() error {
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		errs []error
+	)
+	for range cap(p.slots) - len(p.idle) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			conn, err := p.dial()
+			if err == nil {
+				err = p.add(conn)
+			}
+			if err != nil {
+				lock.Lock()
+				errs = append(errs, err)
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// open authenticates [conn], closing it if the server does not accept it
+func (p *remotePool) open(conn net.Conn) (*remoteConn, error) {
+	c := newRemoteConn(conn)
+	if p.apiKey != "" {
+		var ok bool
+		if err := c.roundTrip(remoteMethodAuthenticate, remoteAuthParams{APIKey: p.apiKey}, &ok); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	p.connected.Store(true)
+	return c, nil
+}
+
+// get returns an idle connection, dialing one if there is none, once fewer
+// connections than the size of the pool are in use
+func (p *remotePool) get(ctx context.Context) (*remoteConn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		<-p.slots
+		return nil, net.ErrClosed
+	}
+	if n := len(p.idle); n > 0 {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.lock.Unlock()
+		if c.idle != nil {
+			c.idle.Stop()
+		}
+		return c, nil
+	}
+	p.lock.Unlock()
+
+	if p.dial == nil {
+		<-p.slots
+		return nil, net.ErrClosed
+	}
+	conn, err := p.dial()
+	if err != nil {
+		p.connected.Store(false)
+		<-p.slots
+		return nil, err
+	}
+	c, err := p.open(conn)
+	if err != nil {
+		p.connected.Store(false)
+		<-p.slots
+		return nil, err
+	}
+	return c, nil
+}
+
+// call sends a request over a connection of the pool and decodes the result
+// of its response into [result], failing it once the deadline of [ctx]
+// passes
+func (p *remotePool) call(ctx context.Context, method string, params, result any) error {
+	_, err := p.tryCall(ctx, method, params, result)
+	return err
+}
+
+// tryCall is call, also reporting whether a connection to the server was
+// obtained. Requests that failed without one were not sent to the server.
+func (p *remotePool) tryCall(ctx context.Context, method string, params, result any) (bool, error) {
+	c, err := p.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer p.put(c)
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := c.conn.SetDeadline(deadline); err != nil {
+			c.failed = true
+			return false, err
+		}
+		defer func() {
+			_ = c.conn.SetDeadline(time.Time{})
+		}()
+	}
+	return true, c.roundTrip(method, params, result)
+}
+
+// put returns [c] to the pool, or closes it if it failed or the pool is
+// closed
+func (p *remotePool) put(c *remoteConn) {
+	defer func() {
+		<-p.slots
+	}()
+
+	if c.failed {
+		p.connected.Store(false)
+		_ = c.conn.Close()
+		return
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.closed {
+		_ = c.conn.Close()
+		return
+	}
+	if p.idleTimeout > 0 {
+		c.idle = time.AfterFunc(p.idleTimeout, func() {
+			p.expire(c)
+		})
+	}
+	p.idle = append(p.idle, c)
+}
+
+// expire closes [c] if it is still idle
+func (p *remotePool) expire(c *remoteConn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	i := slices.Index(p.idle, c)
+	if i < 0 {
+		return
+	}
+	p.idle = slices.Delete(p.idle, i, i+1)
+	_ = c.conn.Close()
+}
+
+// close closes the idle connections, and the others once their requests are
+// served
+func (p *remotePool) close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.closed = true
+	p.connected.Store(false)
+	var errs []error
+	for _, c := range p.idle {
+		if c.idle != nil {
+			c.idle.Stop()
+		}
+		errs = append(errs, c.conn.Close())
+	}
+	p.idle = nil
+	return errors.Join(errs...)
+}
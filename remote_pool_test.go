@@ -0,0 +1,182 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingDialer dials a signer server, counting the connections it opened
+// and those that were closed
+type countingDialer struct {
+	addr           string
+	dials, closeds atomic.Int32
+}
+
+func (d *countingDialer) dial() (net.Conn, error) {
+	conn, err := net.Dial("tcp", d.addr)
+	if err != nil {
+		return nil, err
+	}
+	d.dials.Add(1)
+	return &countingConn{Conn: conn, dialer: d}, nil
+}
+
+type countingConn struct {
+	net.Conn
+	dialer *countingDialer
+	once   sync.Once
+}
+
+func (c *countingConn) Close() error {
+	c.once.Do(func() {
+		c.dialer.closeds.Add(1)
+	})
+	return c.Conn.Close()
+}
+
+func TestRemoteKeychainPool(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("pool"), 1)
+	require.NoError(err)
+	addr := kc.Keys()[0].Address()
+
+	// The server holds every request until three are in flight at once
+	var (
+		inFlight atomic.Int32
+		started  = make(chan struct{})
+	)
+	served := Wrap(kc, Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		if inFlight.Add(1) == 3 {
+			close(started)
+		}
+		<-started
+		return next(payload)
+	}))
+	_, serverAddr := listenKeychain(t, served, SignerServerConfig{})
+	dialer := &countingDialer{addr: serverAddr}
+
+	remote, err := DialRemoteKeychain(dialer.dial, WithPoolSize(3))
+	require.NoError(err)
+	defer remote.Close()
+	require.Equal(int32(3), dialer.dials.Load())
+
+	signer, ok := remote.Get(addr)
+	require.True(ok)
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := signer.SignHash(make([]byte, 32))
+			require.NoError(err)
+		}()
+	}
+	wg.Wait()
+
+	// The warm connections are reused
+	for range 10 {
+		_, err := signer.SignHash(make([]byte, 32))
+		require.NoError(err)
+	}
+	require.Equal(int32(3), dialer.dials.Load())
+	require.Zero(dialer.closeds.Load())
+
+	require.NoError(remote.Close())
+	require.Equal(int32(3), dialer.closeds.Load())
+}
+
+func TestRemoteKeychainPoolBusy(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("pool"), 1)
+	require.NoError(err)
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	served := Wrap(kc, Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		started <- struct{}{}
+		<-release
+		return next(payload)
+	}))
+	_, serverAddr := listenKeychain(t, served, SignerServerConfig{})
+	dialer := &countingDialer{addr: serverAddr}
+
+	remote, err := DialRemoteKeychain(dialer.dial)
+	require.NoError(err)
+	defer remote.Close()
+	signer, _ := remote.Get(kc.Keys()[0].Address())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := signer.Sign([]byte("tx"))
+		done <- err
+	}()
+	<-started
+
+	// Requests wait for a connection of the pool, until their context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = SignContext(ctx, signer, []byte("tx"))
+	require.ErrorIs(err, context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(<-done)
+	require.Equal(int32(1), dialer.dials.Load())
+}
+
+func TestRemoteKeychainPoolIdleTimeout(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("pool"), 1)
+	require.NoError(err)
+	_, serverAddr := listenKeychain(t, kc, SignerServerConfig{})
+	dialer := &countingDialer{addr: serverAddr}
+
+	remote, err := DialRemoteKeychain(dialer.dial, WithPoolSize(2), WithIdleTimeout(10*time.Millisecond))
+	require.NoError(err)
+	defer remote.Close()
+	require.Equal(int32(2), dialer.dials.Load())
+
+	// Idle connections are closed without disconnecting the keychain, and
+	// dialed again when needed
+	require.Eventually(func() bool {
+		return dialer.closeds.Load() == 2
+	}, time.Second, time.Millisecond)
+	require.True(IsConnected(remote))
+
+	signer, _ := remote.Get(kc.Keys()[0].Address())
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	require.Equal(int32(3), dialer.dials.Load())
+}
+
+func TestRemoteKeychainIdleTimeoutWithoutDialer(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("pool"), 1)
+	require.NoError(err)
+	_, serverAddr := listenKeychain(t, kc, SignerServerConfig{})
+	conn, err := net.Dial("tcp", serverAddr)
+	require.NoError(err)
+
+	// The single connection of the keychain is not closed for being idle, as
+	// it could not be dialed again
+	remote, err := NewRemoteKeychain(conn, WithIdleTimeout(time.Millisecond))
+	require.NoError(err)
+	defer remote.Close()
+	time.Sleep(20 * time.Millisecond)
+
+	signer, _ := remote.Get(kc.Keys()[0].Address())
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	require.True(IsConnected(remote))
+}
@@ -0,0 +1,168 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+)
+
+// serveKeychain serves [kc] on a loopback listener and returns a connection
+// to it
+func serveKeychain(t *testing.T, kc Keychain, config SignerServerConfig) (*SignerServer, net.Conn) {
+	t.Helper()
+
+	server, addr := listenKeychain(t, kc, config)
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return server, conn
+}
+
+// listenKeychain serves [kc] on a loopback listener and returns its address
+func listenKeychain(t *testing.T, kc Keychain, config SignerServerConfig) (*SignerServer, string) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := NewSignerServer(kc, config)
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(l)
+	}()
+	t.Cleanup(func() {
+		require.NoError(t, server.Close())
+		require.ErrorIs(t, <-done, ErrServerClosed)
+	})
+	return server, l.Addr().String()
+}
+
+func TestRemoteKeychain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("remote"), 3)
+	require.NoError(err)
+	_, conn := serveKeychain(t, kc, SignerServerConfig{})
+
+	remote, err := NewRemoteKeychain(conn)
+	require.NoError(err)
+	require.Equal(kc.Addresses(), remote.Addresses())
+
+	for _, key := range kc.Keys() {
+		signer, ok := remote.Get(key.Address())
+		require.True(ok)
+		require.Equal(key.Address(), signer.Address())
+		require.Equal(SchemeSecp256k1, SchemeOf(signer))
+		pubKey, ok := PublicKeyOf(signer)
+		require.True(ok)
+		require.Equal(key.PublicKey().Bytes(), pubKey)
+
+		msg := []byte("shared daemon")
+		sig, err := signer.Sign(msg)
+		require.NoError(err)
+		require.True(key.PublicKey().Verify(msg, sig))
+
+		hash := make([]byte, 32)
+		sig, err = signer.SignHash(hash)
+		require.NoError(err)
+		require.True(key.PublicKey().VerifyHash(hash, sig))
+	}
+
+	_, ok := remote.Get(ids.GenerateTestShortID())
+	require.False(ok)
+}
+
+func TestRemoteKeychainErrors(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("remote"), 2)
+	require.NoError(err)
+	keys := kc.Keys()
+	reject := Intercept(func(signer Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		if signer.Address() == keys[1].Address() {
+			return nil, ErrUserRejected
+		}
+		return next(payload)
+	})
+	_, conn := serveKeychain(t, Wrap(kc, reject), SignerServerConfig{})
+
+	remote, err := NewRemoteKeychain(conn)
+	require.NoError(err)
+	signer, ok := remote.Get(keys[1].Address())
+	require.True(ok)
+	_, err = signer.Sign([]byte("payout"))
+	require.ErrorIs(err, ErrUserRejected)
+	var remoteErr *RemoteError
+	require.ErrorAs(err, &remoteErr)
+	require.Equal(ErrUserRejected.Error(), remoteErr.Message)
+
+	// Errors without a code keep their message
+	signer, ok = remote.Get(keys[0].Address())
+	require.True(ok)
+	_, err = signer.SignHash([]byte("short"))
+	require.ErrorAs(err, &remoteErr)
+	require.Empty(remoteErr.Code)
+	require.NotEmpty(remoteErr.Message)
+
+	// The connection stays usable after a failed request
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+}
+
+func TestRemoteKeychainRemovedKey(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("remote"), 1)
+	require.NoError(err)
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	kc.Add(key)
+	_, conn := serveKeychain(t, kc, SignerServerConfig{})
+
+	remote, err := NewRemoteKeychain(conn)
+	require.NoError(err)
+	signer, ok := remote.Get(key.Address())
+	require.True(ok)
+
+	require.True(kc.Remove(key.Address()))
+	_, err = signer.Sign([]byte("late"))
+	require.ErrorIs(err, ErrUnknownAddress)
+}
+
+func TestSignerServerAuthorize(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("remote"), 1)
+	require.NoError(err)
+	errDenied := errors.New("denied")
+	_, conn := serveKeychain(t, kc, SignerServerConfig{
+		Authorize: func(net.Conn) error {
+			return errors.Join(ErrPeerNotAllowed, errDenied)
+		},
+	})
+
+	_, err = NewRemoteKeychain(conn)
+	require.ErrorIs(err, ErrPeerNotAllowed)
+}
+
+func TestSignerServerClosed(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("remote"), 1)
+	require.NoError(err)
+	server := NewSignerServer(kc, SignerServerConfig{})
+	require.NoError(server.Close())
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	require.ErrorIs(server.Serve(l), ErrServerClosed)
+	_, err = net.Dial("tcp", l.Addr().String())
+	require.Error(err)
+}
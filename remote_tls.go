@@ -0,0 +1,119 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/luxfi/math/set"
+)
+
+// tlsHandshakeTimeout bounds the handshake of a client connecting to a TLS
+// signer server
+const tlsHandshakeTimeout = 10 * time.Second
+
+var (
+	ErrEmptyAllowlist    = errors.New("client certificate allowlist is empty")
+	ErrNoTLSConn         = errors.New("connection is not a TLS connection")
+	ErrNoTLSCertificates = errors.New("TLS configuration holds no certificate")
+)
+
+// CertificateFingerprint returns the SHA-256 hash of the DER encoding of
+// [cert], which identifies a client in the allowlist of AllowClientCertificates
+func CertificateFingerprint(cert *x509.Certificate) [sha256.Size]byte {
+	return sha256.Sum256(cert.Raw)
+}
+
+// ListenTLS listens on the TCP address [addr] for mutually authenticated TLS
+// connections to a SignerServer, so that signing hosts can be placed in a
+// network zone of their own. The server presents [cert] and requires clients
+// to present a certificate, which must be issued by [clientCAs] unless it is
+// nil. Which clients are served is decided by the allowlist of
+// AllowClientCertificates, which the server must be configured with:
+//
+//	l, err := keychain.ListenTLS(":9650", cert, nil)
+//	...
+//	server := keychain.NewSignerServer(kc, keychain.SignerServerConfig{
+//		Authorize: keychain.AllowClientCertificates(fingerprints...),
+//	})
+//	err = server.Serve(l)
+func ListenTLS(addr string, cert tls.Certificate, clientCAs *x509.CertPool) (net.Listener, error) {
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}
+	if clientCAs != nil {
+		config.ClientCAs = clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tls.Listen("tcp", addr, config)
+}
+
+// AllowClientCertificates returns a SignerServerConfig.Authorize function that
+// only serves TLS clients whose certificate has one of [fingerprints], as
+// returned by CertificateFingerprint. An empty allowlist rejects every
+// client, as a proxy serving any holder of a certificate is almost always a
+// configuration mistake.
+func AllowClientCertificates(fingerprints ...[sha256.Size]byte) func(net.Conn) error {
+	allowed := set.Of(fingerprints...)
+	return func(conn net.Conn) error {
+		if allowed.Len() == 0 {
+			return fmt.Errorf("%w: %w", ErrPeerNotAllowed, ErrEmptyAllowlist)
+		}
+		tlsConn, ok := conn.(*tls.Conn)
+		if !ok {
+			return fmt.Errorf("%w: %w", ErrPeerNotAllowed, ErrNoTLSConn)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), tlsHandshakeTimeout)
+		defer cancel()
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("%w: %w", ErrPeerNotAllowed, err)
+		}
+		certs := tlsConn.ConnectionState().PeerCertificates
+		if len(certs) == 0 || !allowed.Contains(CertificateFingerprint(certs[0])) {
+			return fmt.Errorf("%w: client certificate is not in the allowlist", ErrPeerNotAllowed)
+		}
+		return nil
+	}
+}
+
+// DialTLSKeychain creates a keychain of the signers served over TLS by the
+// SignerServer at the TCP address [addr], authenticating with the client
+// certificate of [config]. The connection is redialed after it fails, as by
+// DialRemoteKeychain.
+func DialTLSKeychain(addr string, config *tls.Config, opts ...Option) (RemoteKeychain, error) {
+	return DialTLSFailoverKeychain([]string{addr}, config, opts...)
+}
+
+// DialTLSFailoverKeychain creates a keychain of the signers served by
+// replicas of a SignerServer listening for TLS connections at [addrs], as by
+// DialTLSKeychain and DialFailoverKeychain
+func DialTLSFailoverKeychain(addrs []string, config *tls.Config, opts ...Option) (RemoteKeychain, error) {
+	if len(config.Certificates) == 0 && config.GetClientCertificate == nil {
+		return nil, ErrNoTLSCertificates
+	}
+	config = config.Clone()
+	if config.MinVersion < tls.VersionTLS13 {
+		config.MinVersion = tls.VersionTLS13
+	}
+	dials := make([]func() (net.Conn, error), len(addrs))
+	for i, addr := range addrs {
+		dials[i] = func() (net.Conn, error) {
+			dialer := &tls.Dialer{
+				NetDialer: &net.Dialer{Timeout: tlsHandshakeTimeout},
+				Config:    config,
+			}
+			return dialer.Dial("tcp", addr)
+		}
+	}
+	return DialFailoverKeychain(dials, opts...)
+}
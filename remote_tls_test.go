@@ -0,0 +1,179 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// selfSignedCert returns a self-signed certificate for [name] valid for the
+// loopback address
+func selfSignedCert(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+}
+
+// serveTLS serves [kc] over TLS on [addr] to the clients of [allowed]
+func serveTLS(t *testing.T, kc Keychain, addr string, cert tls.Certificate, allowed ...[sha256.Size]byte) (*SignerServer, string) {
+	t.Helper()
+
+	l, err := ListenTLS(addr, cert, nil)
+	require.NoError(t, err)
+	server := NewSignerServer(kc, SignerServerConfig{
+		Authorize: AllowClientCertificates(allowed...),
+	})
+	go func() {
+		_ = server.Serve(l)
+	}()
+	t.Cleanup(func() { _ = server.Close() })
+	return server, l.Addr().String()
+}
+
+func clientTLSConfig(serverCert, clientCert tls.Certificate) *tls.Config {
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCert.Leaf)
+	config := &tls.Config{
+		RootCAs:    roots,
+		MinVersion: tls.VersionTLS13,
+	}
+	if clientCert.Leaf != nil {
+		config.Certificates = []tls.Certificate{clientCert}
+	}
+	return config
+}
+
+func TestTLSKeychain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("tls"), 2)
+	require.NoError(err)
+	serverCert := selfSignedCert(t, "signer")
+	clientCert := selfSignedCert(t, "client")
+	_, addr := serveTLS(t, kc, "127.0.0.1:0", serverCert, CertificateFingerprint(clientCert.Leaf))
+
+	remote, err := DialTLSKeychain(addr, clientTLSConfig(serverCert, clientCert))
+	require.NoError(err)
+	defer remote.Close()
+	require.Equal(kc.Addresses(), remote.Addresses())
+
+	key := kc.Keys()[1]
+	signer, ok := remote.Get(key.Address())
+	require.True(ok)
+	msg := []byte("cross zone")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.True(key.PublicKey().Verify(msg, sig))
+
+	require.NoError(remote.Close())
+	_, err = signer.Sign(msg)
+	require.ErrorIs(err, net.ErrClosed)
+}
+
+func TestTLSKeychainAllowlist(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("tls"), 1)
+	require.NoError(err)
+	serverCert := selfSignedCert(t, "signer")
+	allowedCert := selfSignedCert(t, "allowed")
+	otherCert := selfSignedCert(t, "other")
+	_, addr := serveTLS(t, kc, "127.0.0.1:0", serverCert, CertificateFingerprint(allowedCert.Leaf))
+
+	_, err = DialTLSKeychain(addr, clientTLSConfig(serverCert, otherCert))
+	require.ErrorIs(err, ErrPeerNotAllowed)
+
+	_, err = DialTLSKeychain(addr, clientTLSConfig(serverCert, tls.Certificate{}))
+	require.ErrorIs(err, ErrNoTLSCertificates)
+
+	// A server without an allowlist serves no one
+	_, addr = serveTLS(t, kc, "127.0.0.1:0", serverCert)
+	_, err = DialTLSKeychain(addr, clientTLSConfig(serverCert, allowedCert))
+	require.ErrorIs(err, ErrPeerNotAllowed)
+}
+
+func TestTLSKeychainClientCAs(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("tls"), 1)
+	require.NoError(err)
+	serverCert := selfSignedCert(t, "signer")
+	clientCert := selfSignedCert(t, "client")
+	otherCA := selfSignedCert(t, "other ca")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(otherCA.Leaf)
+
+	l, err := ListenTLS("127.0.0.1:0", serverCert, clientCAs)
+	require.NoError(err)
+	server := NewSignerServer(kc, SignerServerConfig{
+		Authorize: AllowClientCertificates(CertificateFingerprint(clientCert.Leaf)),
+	})
+	go func() {
+		_ = server.Serve(l)
+	}()
+	defer server.Close()
+
+	// The allowlisted certificate is not issued by the client CAs
+	_, err = DialTLSKeychain(l.Addr().String(), clientTLSConfig(serverCert, clientCert))
+	require.Error(err)
+}
+
+func TestTLSKeychainRedial(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("tls"), 1)
+	require.NoError(err)
+	serverCert := selfSignedCert(t, "signer")
+	clientCert := selfSignedCert(t, "client")
+	fingerprint := CertificateFingerprint(clientCert.Leaf)
+	server, addr := serveTLS(t, kc, "127.0.0.1:0", serverCert, fingerprint)
+
+	remote, err := DialTLSKeychain(addr, clientTLSConfig(serverCert, clientCert))
+	require.NoError(err)
+	defer remote.Close()
+	signer, ok := remote.Get(kc.Keys()[0].Address())
+	require.True(ok)
+
+	// Requests fail while the server is down, and succeed once it is back
+	require.NoError(server.Close())
+	_, err = signer.SignHash(make([]byte, 32))
+	require.Error(err)
+
+	serveTLS(t, kc, addr, serverCert, fingerprint)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+}
@@ -0,0 +1,301 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/luxfi/ids"
+)
+
+// Defaults of RetryPolicy
+const (
+	defaultRetryAttempts   = 3
+	defaultInitialBackoff  = 100 * time.Millisecond
+	defaultMaxBackoff      = 5 * time.Second
+	defaultBackoffMultiple = 2
+)
+
+var (
+	_ Ledger          = (*retryLedger)(nil)
+	_ PublicKeyLedger = (*retryLedger)(nil)
+	_ Signer          = (*retrySigner)(nil)
+)
+
+// retryableErrors are the transient failures of the package's backends
+var retryableErrors = []error{
+	ErrOperationTimeout,
+	ErrInvalidResponse,
+	ErrInvalidRemoteResponse,
+	io.EOF,
+	io.ErrUnexpectedEOF,
+	net.ErrClosed,
+	syscall.ECONNREFUSED,
+	syscall.ECONNRESET,
+	syscall.EPIPE,
+}
+
+// retryableError marks an error as transient
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// MarkRetryable marks [err] as transient, so that IsRetryable reports it as
+// retryable. Backends use it for their own transient failures, such as the
+// throttling errors of a KMS.
+func MarkRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err}
+}
+
+// IsRetryable reports whether [err] is a transient failure worth retrying:
+// timeouts, dropped connections, garbled device responses and errors marked
+// with MarkRetryable. Decisions of the user or of a policy, such as
+// ErrUserRejected, are never retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var marked *retryableError
+	if errors.As(err, &marked) {
+		return true
+	}
+	for _, retryable := range retryableErrors {
+		if errors.Is(err, retryable) {
+			return true
+		}
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// RetryPolicy configures retries with exponential backoff. Zero fields take
+// their defaults.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts, including the first, 3 by
+	// default
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, 100ms by default
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts, 5s by default
+	MaxBackoff time.Duration
+	// Multiplier is the factor the wait grows by after every retry, 2 by
+	// default
+	Multiplier float64
+	// Jitter randomizes every wait by up to this fraction of it, between 0
+	// and 1, so that clients failing together do not retry together
+	Jitter float64
+	// Retryable reports whether an error is worth retrying, IsRetryable by
+	// default, and for NewRetryLedger IsRetryable except for timeouts
+	Retryable func(error) bool
+	// OnRetry, if set, is called before waiting for attempt [attempt], the
+	// first retry being attempt 2, after [err]
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultRetryAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = defaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultMaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaultBackoffMultiple
+	}
+	p.Jitter = min(max(p.Jitter, 0), 1)
+	if p.Retryable == nil {
+		p.Retryable = IsRetryable
+	}
+	return p
+}
+
+// backoff returns the wait before attempt [attempt], the first retry being
+// attempt 2
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := float64(p.InitialBackoff)
+	for range attempt - 2 {
+		wait *= p.Multiplier
+		if wait >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	wait = min(wait, float64(p.MaxBackoff))
+	if p.Jitter > 0 {
+		wait -= wait * p.Jitter * rand.Float64()
+	}
+	return time.Duration(wait)
+}
+
+// retrySleep waits for [d], or until [ctx] is done. Tests replace it.
+var retrySleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RetryOperation runs [op] until it succeeds, fails with an error that is
+// not retryable, or runs out of attempts, waiting between attempts as
+// configured by [policy]. The error of the last attempt is returned. If [ctx]
+// is done while waiting, its error is returned joined with the last one.
+func RetryOperation[T any](ctx context.Context, policy RetryPolicy, op func() (T, error)) (T, error) {
+	policy = policy.withDefaults()
+	for attempt := 1; ; attempt++ {
+		value, err := op()
+		if err == nil || attempt >= policy.MaxAttempts || !policy.Retryable(err) {
+			return value, err
+		}
+
+		wait := policy.backoff(attempt + 1)
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt+1, err, wait)
+		}
+		if ctxErr := retrySleep(ctx, wait); ctxErr != nil {
+			var zero T
+			return zero, errors.Join(ctxErr, err)
+		}
+	}
+}
+
+// Retry returns a middleware that retries the signing operations of the
+// signers it wraps as configured by [policy], such as around a KMS or remote
+// signer prone to transient network failures
+func Retry(policy RetryPolicy) Middleware {
+	return func(next Signer) Signer {
+		return &retrySigner{
+			signer: next,
+			policy: policy,
+		}
+	}
+}
+
+// NewRetryKeychain wraps [keychain] so that its signers retry as configured
+// by [policy], as by Retry
+func NewRetryKeychain(keychain Keychain, policy RetryPolicy) Keychain {
+	return Wrap(keychain, Retry(policy))
+}
+
+type retrySigner struct {
+	signer Signer
+	policy RetryPolicy
+}
+
+func (r *retrySigner) SignHash(hash []byte) ([]byte, error) {
+	return RetryOperation(context.Background(), r.policy, func() ([]byte, error) {
+		return r.signer.SignHash(hash)
+	})
+}
+
+func (r *retrySigner) Sign(msg []byte) ([]byte, error) {
+	return RetryOperation(context.Background(), r.policy, func() ([]byte, error) {
+		return r.signer.Sign(msg)
+	})
+}
+
+func (r *retrySigner) Address() ids.ShortID {
+	return r.signer.Address()
+}
+
+func (r *retrySigner) Unwrap() Signer {
+	return r.signer
+}
+
+// retryLedger retries the operations of an underlying ledger
+type retryLedger struct {
+	ledger Ledger
+	policy RetryPolicy
+}
+
+// NewRetryLedger wraps [ledger] so that its operations are retried as
+// configured by [policy], for example to ride out a ledger device that is
+// briefly unplugged or a transport that drops a response. Requests that the
+// user rejected on the device are not retried. Unless [policy] sets
+// Retryable, neither are the operations of NewTimeoutLedger that timed out,
+// as an operation that could not be cancelled still holds the device and a
+// retry would wait behind it.
+func NewRetryLedger(ledger Ledger, policy RetryPolicy) Ledger {
+	if policy.Retryable == nil {
+		policy.Retryable = isRetryableLedgerError
+	}
+	return &retryLedger{
+		ledger: ledger,
+		policy: policy,
+	}
+}
+
+// isRetryableLedgerError reports whether [err] of a ledger operation is
+// retryable, as IsRetryable, except for ErrOperationTimeout
+func isRetryableLedgerError(err error) bool {
+	return !errors.Is(err, ErrOperationTimeout) && IsRetryable(err)
+}
+
+func (r *retryLedger) Address(displayHRP string, addressIndex uint32) (ids.ShortID, error) {
+	return RetryOperation(context.Background(), r.policy, func() (ids.ShortID, error) {
+		return r.ledger.Address(displayHRP, addressIndex)
+	})
+}
+
+func (r *retryLedger) GetAddresses(addressIndices []uint32) ([]ids.ShortID, error) {
+	return RetryOperation(context.Background(), r.policy, func() ([]ids.ShortID, error) {
+		return r.ledger.GetAddresses(addressIndices)
+	})
+}
+
+// GetPublicKeys returns nil public keys if the underlying ledger cannot
+// export them
+func (r *retryLedger) GetPublicKeys(addressIndices []uint32) ([][]byte, error) {
+	pkLedger, ok := r.ledger.(PublicKeyLedger)
+	if !ok {
+		return make([][]byte, len(addressIndices)), nil
+	}
+	return RetryOperation(context.Background(), r.policy, func() ([][]byte, error) {
+		return pkLedger.GetPublicKeys(addressIndices)
+	})
+}
+
+func (r *retryLedger) SignHash(hash []byte, addressIndex uint32) ([]byte, error) {
+	return RetryOperation(context.Background(), r.policy, func() ([]byte, error) {
+		return r.ledger.SignHash(hash, addressIndex)
+	})
+}
+
+func (r *retryLedger) Sign(msg []byte, addressIndex uint32) ([]byte, error) {
+	return RetryOperation(context.Background(), r.policy, func() ([]byte, error) {
+		return r.ledger.Sign(msg, addressIndex)
+	})
+}
+
+func (r *retryLedger) SignTransaction(rawUnsignedHash []byte, addressIndices []uint32) ([][]byte, error) {
+	return RetryOperation(context.Background(), r.policy, func() ([][]byte, error) {
+		return r.ledger.SignTransaction(rawUnsignedHash, addressIndices)
+	})
+}
+
+func (r *retryLedger) Disconnect() error {
+	return r.ledger.Disconnect()
+}
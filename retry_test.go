@@ -0,0 +1,222 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+)
+
+var errThrottled = errors.New("throttled")
+
+// recordSleeps replaces retrySleep for the duration of the test, recording
+// the waits instead of sleeping
+func recordSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+
+	var waits []time.Duration
+	sleep := retrySleep
+	retrySleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { retrySleep = sleep })
+	return &waits
+}
+
+// failing returns an interceptor failing the first [n] operations with [err]
+func failing(n int32, err error, calls *atomic.Int32) Middleware {
+	return Intercept(func(_ Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		if calls.Add(1) <= n {
+			return nil, err
+		}
+		return next(payload)
+	})
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{ErrOperationTimeout, true},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{os.ErrDeadlineExceeded, true},
+		{&RemoteError{Message: "garbled"}, false},
+		{MarkRetryable(errThrottled), true},
+		{ErrUserRejected, false},
+		{ErrSpendingLimitExceeded, false},
+		{errThrottled, false},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, IsRetryable(test.err), "%v", test.err)
+	}
+	require.NoError(t, MarkRetryable(nil))
+	require.ErrorIs(t, MarkRetryable(errThrottled), errThrottled)
+}
+
+func TestRetryOperationBackoff(t *testing.T) {
+	require := require.New(t)
+
+	waits := recordSleeps(t)
+	var attempts []int
+	calls := 0
+	_, err := RetryOperation(context.Background(), RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     300 * time.Millisecond,
+		OnRetry: func(attempt int, err error, _ time.Duration) {
+			require.ErrorIs(err, ErrOperationTimeout)
+			attempts = append(attempts, attempt)
+		},
+	}, func() (int, error) {
+		calls++
+		return 0, ErrOperationTimeout
+	})
+	require.ErrorIs(err, ErrOperationTimeout)
+	require.Equal(5, calls)
+	require.Equal([]int{2, 3, 4, 5}, attempts)
+	require.Equal([]time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		300 * time.Millisecond,
+		300 * time.Millisecond,
+	}, *waits)
+}
+
+func TestRetryOperationJitter(t *testing.T) {
+	require := require.New(t)
+
+	policy := RetryPolicy{
+		InitialBackoff: time.Second,
+		Jitter:         0.5,
+	}.withDefaults()
+	for range 100 {
+		wait := policy.backoff(2)
+		require.LessOrEqual(wait, time.Second)
+		require.GreaterOrEqual(wait, 500*time.Millisecond)
+	}
+}
+
+func TestRetryOperationStops(t *testing.T) {
+	require := require.New(t)
+
+	waits := recordSleeps(t)
+	calls := 0
+	_, err := RetryOperation(context.Background(), RetryPolicy{}, func() (int, error) {
+		calls++
+		return 0, ErrUserRejected
+	})
+	require.ErrorIs(err, ErrUserRejected)
+	require.Equal(1, calls)
+	require.Empty(*waits)
+
+	// A custom classification retries backend specific errors
+	calls = 0
+	value, err := RetryOperation(context.Background(), RetryPolicy{
+		Retryable: func(err error) bool { return errors.Is(err, errThrottled) },
+	}, func() (int, error) {
+		calls++
+		if calls < 3 {
+			return 0, errThrottled
+		}
+		return 42, nil
+	})
+	require.NoError(err)
+	require.Equal(42, value)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = RetryOperation(ctx, RetryPolicy{}, func() (int, error) {
+		return 0, ErrOperationTimeout
+	})
+	require.ErrorIs(err, context.Canceled)
+	require.ErrorIs(err, ErrOperationTimeout)
+}
+
+func TestRetry(t *testing.T) {
+	require := require.New(t)
+
+	recordSleeps(t)
+	kc, err := NewTestKeychain([]byte("retry"), 1)
+	require.NoError(err)
+	key := kc.Keys()[0]
+
+	var calls atomic.Int32
+	signer, ok := NewRetryKeychain(Wrap(kc, failing(2, io.EOF, &calls)), RetryPolicy{}).Get(key.Address())
+	require.True(ok)
+	msg := []byte("flaky kms")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.True(key.PublicKey().Verify(msg, sig))
+	require.Equal(int32(3), calls.Load())
+
+	calls.Store(0)
+	signer, ok = Wrap(kc, Retry(RetryPolicy{}), failing(1, ErrUserRejected, &calls)).Get(key.Address())
+	require.True(ok)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrUserRejected)
+	require.Equal(int32(1), calls.Load())
+}
+
+// flakyLedger fails the first derivations as if the device was unplugged
+type flakyLedger struct {
+	*mockLedger
+	failures atomic.Int32
+}
+
+func (f *flakyLedger) GetAddresses(addressIndices []uint32) ([]ids.ShortID, error) {
+	if f.failures.Add(-1) >= 0 {
+		return nil, ErrInvalidResponse
+	}
+	return f.mockLedger.GetAddresses(addressIndices)
+}
+
+func TestRetryLedger(t *testing.T) {
+	require := require.New(t)
+
+	recordSleeps(t)
+	flaky := &flakyLedger{mockLedger: newMockLedger()}
+	flaky.failures.Store(2)
+	kc, err := NewLedgerKeychain(NewRetryLedger(flaky, RetryPolicy{}), []uint32{0, 1})
+	require.NoError(err)
+	require.Equal(2, kc.Addresses().Len())
+
+	flaky.failures.Store(3)
+	_, err = NewLedgerKeychain(NewRetryLedger(flaky, RetryPolicy{}), []uint32{0})
+	require.ErrorIs(err, ErrInvalidResponse)
+}
+
+func TestRetryLedgerTimeouts(t *testing.T) {
+	require := require.New(t)
+
+	recordSleeps(t)
+	ledger := &slowLedger{
+		mockLedger: newMockLedger(),
+		release:    make(chan struct{}),
+	}
+	defer close(ledger.release)
+	var retries int
+	retrying := NewRetryLedger(NewTimeoutLedger(ledger, Timeouts{Sign: 10 * time.Millisecond}), RetryPolicy{
+		OnRetry: func(int, error, time.Duration) {
+			retries++
+		},
+	})
+
+	// An operation that timed out is not retried behind itself
+	_, err := retrying.SignHash([]byte("hash"), 0)
+	require.ErrorIs(err, ErrOperationTimeout)
+	require.Zero(retries)
+}
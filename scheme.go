@@ -0,0 +1,448 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+
+	"github.com/luxfi/crypto/bls"
+	"github.com/luxfi/crypto/mldsa"
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+)
+
+// SchemeID identifies a signature scheme
+type SchemeID string
+
+// Built in signature schemes
+const (
+	SchemeSecp256k1 SchemeID = "secp256k1"
+	SchemeSchnorr   SchemeID = "bip340"
+	SchemeP256      SchemeID = "p256"
+	SchemeEd25519   SchemeID = "ed25519"
+	SchemeBLS       SchemeID = "bls"
+	SchemeMLDSA65   SchemeID = "mldsa65"
+)
+
+var (
+	ErrUnknownScheme       = errors.New("unknown signature scheme")
+	ErrDuplicateScheme     = errors.New("signature scheme is already registered")
+	ErrInvalidPublicKey    = errors.New("invalid public key")
+	ErrSignatureInvalid    = errors.New("signature verification failed")
+	ErrInvalidSignatureLen = errors.New("invalid signature length")
+	ErrUnknownSignatureLen = errors.New("signature length of scheme is unknown")
+
+	schemesLock sync.RWMutex
+	schemes     = make(map[SchemeID]Scheme)
+)
+
+// Scheme describes a signature scheme: how its public keys are encoded, how
+// its signatures are verified and how addresses are derived from its public
+// keys. Schemes are registered with RegisterScheme so that keychains mixing
+// schemes can be handled generically.
+type Scheme interface {
+	ID() SchemeID
+	// ParsePublicKey validates an encoded public key and returns its
+	// canonical encoding
+	ParsePublicKey(pubKey []byte) ([]byte, error)
+	// Verify checks that [sig] is a signature of [msg] by [pubKey], in the
+	// format returned by Signer.Sign
+	Verify(pubKey, msg, sig []byte) error
+	// Address returns the address of [pubKey]
+	Address(pubKey []byte) (ids.ShortID, error)
+}
+
+// SchemeSigner is implemented by signers that do not sign with secp256k1
+type SchemeSigner interface {
+	Signer
+	Scheme() SchemeID
+}
+
+// SchemeKeychain is implemented by keychains that report the scheme of their
+// addresses without getting their signers, such as remote keychains
+type SchemeKeychain interface {
+	Keychain
+	// Scheme returns the scheme [addr] signs with, or false if the keychain
+	// does not hold [addr]
+	Scheme(addr ids.ShortID) (SchemeID, bool)
+}
+
+// SignatureLenScheme is implemented by schemes whose signatures have a fixed
+// length. All built in schemes implement it.
+type SignatureLenScheme interface {
+	Scheme
+	SignatureLen() int
+}
+
+func init() {
+	for _, s := range []Scheme{
+		secp256k1Scheme{},
+		schnorrScheme{},
+		p256Scheme{},
+		ed25519Scheme{},
+		blsScheme{},
+		mldsaScheme{mode: mldsa.MLDSA65, id: SchemeMLDSA65},
+	} {
+		if err := RegisterScheme(s); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterScheme makes [scheme] available to LookupScheme. Registering an ID
+// twice fails with ErrDuplicateScheme.
+func RegisterScheme(scheme Scheme) error {
+	schemesLock.Lock()
+	defer schemesLock.Unlock()
+
+	id := scheme.ID()
+	if _, ok := schemes[id]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateScheme, id)
+	}
+	schemes[id] = scheme
+	return nil
+}
+
+// LookupScheme returns the registered scheme [id]
+func LookupScheme(id SchemeID) (Scheme, error) {
+	schemesLock.RLock()
+	defer schemesLock.RUnlock()
+
+	scheme, ok := schemes[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownScheme, id)
+	}
+	return scheme, nil
+}
+
+// Schemes returns the IDs of the registered schemes, sorted
+func Schemes() []SchemeID {
+	schemesLock.RLock()
+	defer schemesLock.RUnlock()
+
+	list := make([]SchemeID, 0, len(schemes))
+	for id := range schemes {
+		list = append(list, id)
+	}
+	slices.Sort(list)
+	return list
+}
+
+// SchemeOf returns the scheme [signer] signs with, looking through signers
+// that implement Unwrapper. Signers that do not report a scheme sign with
+// secp256k1.
+func SchemeOf(signer Signer) SchemeID {
+	for {
+		if s, ok := signer.(SchemeSigner); ok {
+			return s.Scheme()
+		}
+		u, ok := signer.(Unwrapper)
+		if !ok {
+			return SchemeSecp256k1
+		}
+		signer = u.Unwrap()
+	}
+}
+
+// AddressScheme returns the scheme [addr] of [kc] signs with, or false if
+// [kc] does not hold [addr], so that transaction builders know which
+// signature to expect for each input before constructing its credential
+func AddressScheme(kc Keychain, addr ids.ShortID) (SchemeID, bool) {
+	if s, ok := kc.(SchemeKeychain); ok {
+		return s.Scheme(addr)
+	}
+	signer, ok := kc.Get(addr)
+	if !ok {
+		return "", false
+	}
+	return SchemeOf(signer), true
+}
+
+// AddressSchemes returns the scheme of each address of [kc]
+func AddressSchemes(kc Keychain) map[ids.ShortID]SchemeID {
+	schemes := make(map[ids.ShortID]SchemeID)
+	if s, ok := kc.(SchemeKeychain); ok {
+		for addr := range s.Addresses() {
+			if id, ok := s.Scheme(addr); ok {
+				schemes[addr] = id
+			}
+		}
+		return schemes
+	}
+	Range(kc, func(addr ids.ShortID, signer Signer) bool {
+		schemes[addr] = SchemeOf(signer)
+		return true
+	})
+	return schemes
+}
+
+// SignatureLen returns the length of the signatures of the registered scheme
+// [id], such as 65 bytes for secp256k1 or 96 bytes for BLS. Schemes that do
+// not implement SignatureLenScheme fail with ErrUnknownSignatureLen.
+func SignatureLen(id SchemeID) (int, error) {
+	scheme, err := LookupScheme(id)
+	if err != nil {
+		return 0, err
+	}
+	s, ok := scheme.(SignatureLenScheme)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownSignatureLen, id)
+	}
+	return s.SignatureLen(), nil
+}
+
+// VerifySignature checks that [sig], as returned by Sign, is a signature of
+// [msg] by [signer], using the scheme and public key the signer reports.
+// Signers without a known public key fail with ErrPublicKeyUnavailable.
+func VerifySignature(signer Signer, msg, sig []byte) error {
+	pubKey, ok := PublicKeyOf(signer)
+	if !ok {
+		return ErrPublicKeyUnavailable
+	}
+	scheme, err := LookupScheme(SchemeOf(signer))
+	if err != nil {
+		return err
+	}
+	addr, err := scheme.Address(pubKey)
+	if err != nil {
+		return err
+	}
+	if addr != signer.Address() {
+		return ErrPublicKeyMismatch
+	}
+	return scheme.Verify(pubKey, msg, sig)
+}
+
+// secp256k1Scheme verifies 65 byte recoverable signatures over SHA-256 of the
+// message against compressed public keys
+type secp256k1Scheme struct{}
+
+func (secp256k1Scheme) ID() SchemeID {
+	return SchemeSecp256k1
+}
+
+func (secp256k1Scheme) ParsePublicKey(pubKey []byte) ([]byte, error) {
+	pk, err := secp256k1.ToPublicKey(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+	return pk.Bytes(), nil
+}
+
+func (s secp256k1Scheme) Verify(pubKey, msg, sig []byte) error {
+	pubKey, err := s.ParsePublicKey(pubKey)
+	if err != nil {
+		return err
+	}
+	if len(sig) != secp256k1.SignatureLen {
+		return ErrInvalidSignatureLen
+	}
+	recovered, err := secp256k1.RecoverPublicKey(msg, sig)
+	if err != nil || !bytes.Equal(recovered.Bytes(), pubKey) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (secp256k1Scheme) SignatureLen() int {
+	return secp256k1.SignatureLen
+}
+
+func (s secp256k1Scheme) Address(pubKey []byte) (ids.ShortID, error) {
+	pubKey, err := s.ParsePublicKey(pubKey)
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	return publicKeyAddress(pubKey), nil
+}
+
+// p256Scheme verifies 64 byte r || s signatures over SHA-256 of the message
+// against compressed public keys
+type p256Scheme struct{}
+
+func (p256Scheme) ID() SchemeID {
+	return SchemeP256
+}
+
+func (p256Scheme) ParsePublicKey(pubKey []byte) ([]byte, error) {
+	if _, err := parseP256PublicKey(pubKey); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(pubKey), nil
+}
+
+func (p256Scheme) Verify(pubKey, msg, sig []byte) error {
+	pk, err := parseP256PublicKey(pubKey)
+	if err != nil {
+		return err
+	}
+	if len(sig) != 64 {
+		return ErrInvalidSignatureLen
+	}
+	hash := sha256.Sum256(msg)
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:])
+	if !ecdsa.Verify(pk, hash[:], r, s) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (p256Scheme) SignatureLen() int {
+	return 64
+}
+
+func (p p256Scheme) Address(pubKey []byte) (ids.ShortID, error) {
+	pubKey, err := p.ParsePublicKey(pubKey)
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	return publicKeyAddress(pubKey), nil
+}
+
+func parseP256PublicKey(pubKey []byte) (*ecdsa.PublicKey, error) {
+	x, y := elliptic.UnmarshalCompressed(elliptic.P256(), pubKey)
+	if x == nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+}
+
+// ed25519Scheme verifies ed25519 signatures of the message against raw public
+// keys
+type ed25519Scheme struct{}
+
+func (ed25519Scheme) ID() SchemeID {
+	return SchemeEd25519
+}
+
+func (ed25519Scheme) ParsePublicKey(pubKey []byte) ([]byte, error) {
+	if len(pubKey) != ed25519.PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+	return bytes.Clone(pubKey), nil
+}
+
+func (e ed25519Scheme) Verify(pubKey, msg, sig []byte) error {
+	pubKey, err := e.ParsePublicKey(pubKey)
+	if err != nil {
+		return err
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return ErrInvalidSignatureLen
+	}
+	if !ed25519.Verify(pubKey, msg, sig) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (ed25519Scheme) SignatureLen() int {
+	return ed25519.SignatureSize
+}
+
+func (e ed25519Scheme) Address(pubKey []byte) (ids.ShortID, error) {
+	pubKey, err := e.ParsePublicKey(pubKey)
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	return publicKeyAddress(pubKey), nil
+}
+
+// blsScheme verifies BLS signatures of the message against compressed public
+// keys
+type blsScheme struct{}
+
+func (blsScheme) ID() SchemeID {
+	return SchemeBLS
+}
+
+func (blsScheme) ParsePublicKey(pubKey []byte) ([]byte, error) {
+	pk, err := bls.PublicKeyFromCompressedBytes(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+	return bls.PublicKeyToCompressedBytes(pk), nil
+}
+
+func (blsScheme) Verify(pubKey, msg, sig []byte) error {
+	pk, err := bls.PublicKeyFromCompressedBytes(pubKey)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+	if len(sig) != bls.SignatureLen {
+		return ErrInvalidSignatureLen
+	}
+	s, err := bls.SignatureFromBytes(sig)
+	if err != nil || !bls.Verify(pk, s, msg) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (blsScheme) SignatureLen() int {
+	return bls.SignatureLen
+}
+
+func (b blsScheme) Address(pubKey []byte) (ids.ShortID, error) {
+	pubKey, err := b.ParsePublicKey(pubKey)
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	return publicKeyAddress(pubKey), nil
+}
+
+// mldsaScheme verifies ML-DSA signatures of the message, with an empty
+// context, against raw public keys
+type mldsaScheme struct {
+	mode mldsa.Mode
+	id   SchemeID
+}
+
+func (m mldsaScheme) ID() SchemeID {
+	return m.id
+}
+
+func (m mldsaScheme) ParsePublicKey(pubKey []byte) ([]byte, error) {
+	pk, err := mldsa.PublicKeyFromBytes(pubKey, m.mode)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+	return pk.Bytes(), nil
+}
+
+func (m mldsaScheme) Verify(pubKey, msg, sig []byte) error {
+	pk, err := mldsa.PublicKeyFromBytes(pubKey, m.mode)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+	if len(sig) != mldsa.GetSignatureSize(m.mode) {
+		return ErrInvalidSignatureLen
+	}
+	if !pk.VerifySignature(msg, sig) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (m mldsaScheme) SignatureLen() int {
+	return mldsa.GetSignatureSize(m.mode)
+}
+
+func (m mldsaScheme) Address(pubKey []byte) (ids.ShortID, error) {
+	pubKey, err := m.ParsePublicKey(pubKey)
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	return publicKeyAddress(pubKey), nil
+}
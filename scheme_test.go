@@ -0,0 +1,215 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/luxfi/crypto/bls"
+	"github.com/luxfi/crypto/mldsa"
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+type schemeSigner struct {
+	Signer
+	scheme SchemeID
+}
+
+func (s *schemeSigner) Scheme() SchemeID {
+	return s.scheme
+}
+
+func TestSchemeRegistry(t *testing.T) {
+	require := require.New(t)
+
+	require.Subset(Schemes(), []SchemeID{
+		SchemeSecp256k1,
+		SchemeP256,
+		SchemeEd25519,
+		SchemeBLS,
+		SchemeMLDSA65,
+	})
+
+	err := RegisterScheme(secp256k1Scheme{})
+	require.ErrorIs(err, ErrDuplicateScheme)
+
+	_, err = LookupScheme("unknown")
+	require.ErrorIs(err, ErrUnknownScheme)
+}
+
+func TestSchemeOf(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("scheme"), 1)
+	require.NoError(err)
+	addr := kc.Addresses().List()[0]
+	signer, ok := kc.Get(addr)
+	require.True(ok)
+
+	// Signers default to secp256k1
+	require.Equal(SchemeSecp256k1, SchemeOf(signer))
+
+	// The scheme is reported through middleware
+	wrapped := Chain(Timeout(0), Intercept(nil))(&schemeSigner{Signer: signer, scheme: SchemeP256})
+	require.Equal(SchemeP256, SchemeOf(wrapped))
+}
+
+func TestVerifySignatureMixedSchemes(t *testing.T) {
+	require := require.New(t)
+
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+
+	ag := agent.NewKeyring()
+	require.NoError(ag.Add(agent.AddedKey{PrivateKey: p256}))
+	require.NoError(ag.Add(agent.AddedKey{PrivateKey: edPriv}))
+	agentKC, err := NewSSHAgentKeychain(ag)
+	require.NoError(err)
+
+	softwareKC, err := NewTestKeychain([]byte("scheme"), 1)
+	require.NoError(err)
+
+	schemes := AddressSchemes(agentKC)
+	require.Len(schemes, 2)
+	require.ElementsMatch([]SchemeID{SchemeP256, SchemeEd25519}, []SchemeID{
+		schemes[publicKeyAddress(compressPublicKey(p256.X, p256.Y))],
+		schemes[publicKeyAddress(edPriv.Public().(ed25519.PublicKey))],
+	})
+
+	msg := []byte("mixed")
+	for _, kc := range []Keychain{agentKC, softwareKC} {
+		for _, signer := range Iter(kc) {
+			sig, err := signer.Sign(msg)
+			require.NoError(err)
+			require.NoError(VerifySignature(signer, msg, sig))
+
+			err = VerifySignature(signer, []byte("other"), sig)
+			require.ErrorIs(err, ErrSignatureInvalid)
+		}
+	}
+}
+
+func TestAddressScheme(t *testing.T) {
+	require := require.New(t)
+
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	ag := agent.NewKeyring()
+	require.NoError(ag.Add(agent.AddedKey{PrivateKey: p256}))
+	agentKC, err := NewSSHAgentKeychain(ag)
+	require.NoError(err)
+	softwareKC, err := NewTestKeychain([]byte("scheme"), 1)
+	require.NoError(err)
+
+	kc, err := Merge(softwareKC, agentKC, ConflictError)
+	require.NoError(err)
+	p256Addr := publicKeyAddress(compressPublicKey(p256.X, p256.Y))
+	secpAddr := softwareKC.Keys()[0].Address()
+
+	// Each input of a mixed keychain expects the signature of its scheme
+	for addr, want := range map[ids.ShortID]int{
+		secpAddr: secp256k1.SignatureLen,
+		p256Addr: 64,
+	} {
+		id, ok := AddressScheme(kc, addr)
+		require.True(ok)
+		n, err := SignatureLen(id)
+		require.NoError(err)
+		require.Equal(want, n)
+
+		signer, _ := kc.Get(addr)
+		sig, err := signer.Sign([]byte("input"))
+		require.NoError(err)
+		require.Len(sig, n)
+	}
+
+	_, ok := AddressScheme(kc, ids.GenerateTestShortID())
+	require.False(ok)
+
+	// Remote keychains report the schemes of the server
+	_, conn := serveKeychain(t, kc, SignerServerConfig{})
+	remote, err := NewRemoteKeychain(conn)
+	require.NoError(err)
+	require.Implements((*SchemeKeychain)(nil), remote)
+	require.Equal(AddressSchemes(kc), AddressSchemes(remote))
+	id, ok := AddressScheme(remote, p256Addr)
+	require.True(ok)
+	require.Equal(SchemeP256, id)
+}
+
+func TestSignatureLen(t *testing.T) {
+	require := require.New(t)
+
+	for id, want := range map[SchemeID]int{
+		SchemeSecp256k1: 65,
+		SchemeSchnorr:   64,
+		SchemeP256:      64,
+		SchemeEd25519:   64,
+		SchemeBLS:       96,
+		SchemeMLDSA65:   mldsa.GetSignatureSize(mldsa.MLDSA65),
+	} {
+		n, err := SignatureLen(id)
+		require.NoError(err)
+		require.Equal(want, n, id)
+	}
+
+	_, err := SignatureLen("unknown")
+	require.ErrorIs(err, ErrUnknownScheme)
+}
+
+func TestVerifySignatureNoPublicKey(t *testing.T) {
+	require := require.New(t)
+
+	signer := &schemeSigner{scheme: SchemeP256}
+	err := VerifySignature(signer, nil, nil)
+	require.ErrorIs(err, ErrPublicKeyUnavailable)
+}
+
+func TestBLSScheme(t *testing.T) {
+	require := require.New(t)
+
+	key, err := NewStakingKey()
+	require.NoError(err)
+	msg := []byte("bls")
+	sig, err := key.Sign(msg)
+	require.NoError(err)
+
+	scheme, err := LookupScheme(SchemeBLS)
+	require.NoError(err)
+	require.NoError(scheme.Verify(key.PublicKeyBytes(), msg, bls.SignatureToBytes(sig)))
+	require.ErrorIs(scheme.Verify(key.PublicKeyBytes(), []byte("other"), bls.SignatureToBytes(sig)), ErrSignatureInvalid)
+
+	_, err = scheme.Address([]byte{1, 2, 3})
+	require.ErrorIs(err, ErrInvalidPublicKey)
+}
+
+func TestMLDSAScheme(t *testing.T) {
+	require := require.New(t)
+
+	key, err := mldsa.GenerateKey(rand.Reader, mldsa.MLDSA65)
+	require.NoError(err)
+	msg := []byte("post-quantum")
+	sig, err := key.Sign(rand.Reader, msg, nil)
+	require.NoError(err)
+
+	scheme, err := LookupScheme(SchemeMLDSA65)
+	require.NoError(err)
+	pubKey := key.PublicKey.Bytes()
+	require.NoError(scheme.Verify(pubKey, msg, sig))
+	require.ErrorIs(scheme.Verify(pubKey, msg, sig[1:]), ErrInvalidSignatureLen)
+
+	addr, err := scheme.Address(pubKey)
+	require.NoError(err)
+	require.Equal(publicKeyAddress(pubKey), addr)
+}
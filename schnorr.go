@@ -0,0 +1,108 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+)
+
+// SchnorrSignatureLen is the length of a BIP340 signature
+const SchnorrSignatureLen = schnorr.SignatureSize
+
+var (
+	ErrSchnorrUnsupported = errors.New("signer cannot sign with BIP340 Schnorr")
+
+	_ SchemeSigner = (*softwareSigner)(nil)
+)
+
+// Schnorr returns a signer of the same key as [signer] that produces 64 byte
+// BIP340 Schnorr signatures instead of ECDSA signatures. Sign signs the
+// SHA-256 hash of the message and SignHash signs the hash directly, as for
+// ECDSA. The signer reports SchemeSchnorr and keeps the address of the key.
+// Only signers of software keychains support Schnorr signatures;
+// ErrSchnorrUnsupported is returned otherwise.
+func Schnorr(signer Signer) (Signer, error) {
+	s, ok := signer.(*softwareSigner)
+	if !ok {
+		return nil, ErrSchnorrUnsupported
+	}
+	schnorrSigner := *s
+	schnorrSigner.schnorr = true
+	return &schnorrSigner, nil
+}
+
+// schnorrSignHash returns the BIP340 signature of [hash] by [key], using
+// [aux] as the auxiliary randomness of the nonce derivation
+func schnorrSignHash(key *secp256k1.PrivateKey, hash []byte, aux [32]byte) ([]byte, error) {
+	if len(hash) != sha256.Size {
+		return nil, ErrInvalidHashLen
+	}
+	privKey, _ := btcec.PrivKeyFromBytes(key.Bytes())
+	defer privKey.Zero()
+
+	sig, err := schnorr.Sign(privKey, hash, schnorr.CustomNonce(aux))
+	if err != nil {
+		return nil, err
+	}
+	return sig.Serialize(), nil
+}
+
+func schnorrSign(key *secp256k1.PrivateKey, hash []byte) ([]byte, error) {
+	var aux [32]byte
+	if _, err := rand.Read(aux[:]); err != nil {
+		return nil, err
+	}
+	return schnorrSignHash(key, hash, aux)
+}
+
+// schnorrVerifyHash checks the BIP340 signature of [hash] by the compressed
+// public key [pubKey]
+func schnorrVerifyHash(pubKey, hash, sig []byte) error {
+	pk, err := btcec.ParsePubKey(pubKey)
+	if err != nil {
+		return ErrInvalidPublicKey
+	}
+	if len(sig) != SchnorrSignatureLen {
+		return ErrInvalidSignatureLen
+	}
+	parsed, err := schnorr.ParseSignature(sig)
+	if err != nil || !parsed.Verify(hash, pk) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// schnorrScheme verifies 64 byte BIP340 signatures over SHA-256 of the
+// message against compressed secp256k1 public keys, whose addresses are
+// those of secp256k1 keys
+type schnorrScheme struct{}
+
+func (schnorrScheme) ID() SchemeID {
+	return SchemeSchnorr
+}
+
+func (schnorrScheme) ParsePublicKey(pubKey []byte) ([]byte, error) {
+	return secp256k1Scheme{}.ParsePublicKey(pubKey)
+}
+
+func (schnorrScheme) Verify(pubKey, msg, sig []byte) error {
+	hash := sha256.Sum256(msg)
+	return schnorrVerifyHash(pubKey, hash[:], sig)
+}
+
+func (schnorrScheme) SignatureLen() int {
+	return 64
+}
+
+func (schnorrScheme) Address(pubKey []byte) (ids.ShortID, error) {
+	return secp256k1Scheme{}.Address(pubKey)
+}
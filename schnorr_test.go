@@ -0,0 +1,111 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/stretchr/testify/require"
+)
+
+// BIP340 test vectors 0 and 1
+var schnorrTestVectors = []struct {
+	secretKey string
+	publicKey string
+	aux       string
+	msg       string
+	sig       string
+}{
+	{
+		secretKey: "0000000000000000000000000000000000000000000000000000000000000003",
+		publicKey: "F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9",
+		aux:       "0000000000000000000000000000000000000000000000000000000000000000",
+		msg:       "0000000000000000000000000000000000000000000000000000000000000000",
+		sig:       "E907831F80848D1069A5371B402410364BDF1C5F8307B0084C55F1CE2DCA821525F66A4A85EA8B71E482A74F382D2CE5EBEEE8FDB2172F477DF4900D310536C0",
+	},
+	{
+		secretKey: "B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+		publicKey: "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+		aux:       "0000000000000000000000000000000000000000000000000000000000000001",
+		msg:       "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+		sig:       "6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+	},
+}
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestSchnorrTestVectors(t *testing.T) {
+	for _, v := range schnorrTestVectors {
+		t.Run(v.publicKey, func(t *testing.T) {
+			require := require.New(t)
+
+			key, err := secp256k1.ToPrivateKey(decodeHex(t, v.secretKey))
+			require.NoError(err)
+			pubKey := key.PublicKey().Bytes()
+			require.Equal(decodeHex(t, v.publicKey), pubKey[1:])
+
+			var aux [32]byte
+			copy(aux[:], decodeHex(t, v.aux))
+			msg := decodeHex(t, v.msg)
+			sig, err := schnorrSignHash(key, msg, aux)
+			require.NoError(err)
+			require.Equal(decodeHex(t, v.sig), sig)
+
+			// The x-only key of the vector is the key with an even y
+			require.NoError(schnorrVerifyHash(append([]byte{0x02}, decodeHex(t, v.publicKey)...), msg, sig))
+		})
+	}
+}
+
+func TestSchnorrSigner(t *testing.T) {
+	require := require.New(t)
+
+	kc := NewSoftwareKeychain()
+	key, err := kc.New()
+	require.NoError(err)
+	defer WipeKey(key)
+
+	ecdsaSigner, ok := kc.Get(key.Address())
+	require.True(ok)
+	signer, err := Schnorr(ecdsaSigner)
+	require.NoError(err)
+	require.Equal(key.Address(), signer.Address())
+	require.Equal(SchemeSchnorr, SchemeOf(signer))
+	require.Equal(SchemeSecp256k1, SchemeOf(ecdsaSigner))
+
+	msg := []byte("schnorr aggregation subnet")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.Len(sig, SchnorrSignatureLen)
+	require.NoError(VerifySignature(signer, msg, sig))
+	require.ErrorIs(VerifySignature(signer, []byte("other"), sig), ErrSignatureInvalid)
+
+	// ECDSA signatures do not verify as Schnorr signatures
+	ecdsaSig, err := ecdsaSigner.Sign(msg)
+	require.NoError(err)
+	require.ErrorIs(VerifySignature(signer, msg, ecdsaSig), ErrInvalidSignatureLen)
+
+	_, err = signer.SignHash(msg)
+	require.ErrorIs(err, ErrInvalidHashLen)
+
+	// Retired keys do not sign in either mode
+	require.NoError(kc.Retire(key.Address()))
+	_, err = signer.Sign(msg)
+	require.ErrorIs(err, ErrKeyRetired)
+
+	ledgerKC, err := NewLedgerKeychain(newMockLedger(), []uint32{0})
+	require.NoError(err)
+	ledgerSigner, ok := ledgerKC.Get(ledgerKC.Addresses().List()[0])
+	require.True(ok)
+	_, err = Schnorr(ledgerSigner)
+	require.ErrorIs(err, ErrSchnorrUnsupported)
+}
@@ -0,0 +1,90 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/rand"
+
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+)
+
+// keySealer encrypts the idle keys of a software keychain with a wrapping key
+// that only exists in the memory of the process
+type keySealer struct {
+	key *secureBuffer
+}
+
+func newKeySealer() *keySealer {
+	var key [chacha20poly1305.KeySize]byte
+	// crypto/rand.Read does not fail
+	_, _ = rand.Read(key[:])
+	defer clear(key[:])
+	return &keySealer{key: newSecureBuffer(key[:])}
+}
+
+// seal encrypts [secret], the key of [addr], into nonce || ciphertext
+func (s *keySealer) seal(secret []byte, addr ids.ShortID) []byte {
+	sealed := make([]byte, chacha20poly1305.NonceSizeX, chacha20poly1305.NonceSizeX+len(secret)+chacha20poly1305.Overhead)
+	_, _ = rand.Read(sealed)
+	// The wrapping key has the right length and is never destroyed, so
+	// sealing cannot fail
+	_ = s.key.use(func(key []byte) error {
+		aead, err := chacha20poly1305.NewX(key)
+		if err != nil {
+			return err
+		}
+		sealed = aead.Seal(sealed, sealed, secret, addr[:])
+		return nil
+	})
+	return sealed
+}
+
+// open calls [f] with the key of [addr] decrypted from [sealed], wiping it
+// once [f] returns. [f] must not retain the slice.
+func (s *keySealer) open(sealed []byte, addr ids.ShortID, f func(secret []byte) error) error {
+	var secret []byte
+	err := s.key.use(func(key []byte) error {
+		aead, err := chacha20poly1305.NewX(key)
+		if err != nil {
+			return err
+		}
+		nonce, ciphertext := sealed[:chacha20poly1305.NonceSizeX], sealed[chacha20poly1305.NonceSizeX:]
+		secret, err = aead.Open(make([]byte, 0, secp256k1.PrivateKeyLen), nonce, ciphertext, addr[:])
+		if err != nil {
+			return ErrSecureMemoryCorrupted
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	defer clear(secret)
+	return f(secret)
+}
+
+// NewSealedSoftwareKeychain creates a software keychain holding [keys], as
+// NewSoftwareKeychain, that keeps its idle keys sealed: they are encrypted
+// with a wrapping key generated for the keychain, and only decrypted for the
+// duration of each signing operation, after which the plaintext is wiped. A
+// copy of the process memory taken between operations, such as a core dump
+// or swapped out page, then only holds sealed keys, unless it also captures
+// the locked memory of the wrapping key. Signing pays for one decryption.
+func NewSealedSoftwareKeychain(keys ...*secp256k1.PrivateKey) *SoftwareKeychain {
+	kc := newSoftwareKeychain(newKeySealer())
+	for _, key := range keys {
+		kc.Add(key)
+	}
+	return kc
+}
+
+// WithSealedKeys keeps the keys of a mnemonic keychain sealed while idle, as
+// those of NewSealedSoftwareKeychain
+func WithSealedKeys() Option {
+	return func(o *options) {
+		o.sealKeys = true
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/secp256k1"
+)
+
+func TestSealedSoftwareKeychain(t *testing.T) {
+	require := require.New(t)
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	kc := NewSealedSoftwareKeychain(key)
+	addr := key.Address()
+
+	// The key is only held sealed
+	locked := kc.keys[addr]
+	require.NoError(locked.secret.use(func(secret []byte) error {
+		require.False(bytes.Contains(secret, key.Bytes()))
+		return nil
+	}))
+
+	signer, ok := kc.Get(addr)
+	require.True(ok)
+	msg := []byte("sealed")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.NoError(VerifySignature(signer, msg, sig))
+	hash := bytes.Repeat([]byte{1}, 32)
+	sig, err = signer.SignHash(hash)
+	require.NoError(err)
+	require.True(key.PublicKey().VerifyHash(hash, sig))
+
+	exported, ok := kc.GetKey(addr)
+	require.True(ok)
+	require.Equal(key.Bytes(), exported.Bytes())
+
+	// Rotated keys are sealed too
+	rotated, err := kc.Rotate(addr)
+	require.NoError(err)
+	require.NotNil(kc.keys[rotated].sealer)
+	signer, _ = kc.Get(rotated)
+	_, err = signer.Sign(msg)
+	require.NoError(err)
+
+	// Tampered sealed keys fail to open
+	require.NoError(locked.secret.use(func(secret []byte) error {
+		secret[len(secret)-1] ^= 1
+		return nil
+	}))
+	_, err = locked.privateKey()
+	require.ErrorIs(err, ErrSecureMemoryCorrupted)
+
+	require.NoError(kc.Destroy())
+	_, err = signer.Sign(msg)
+	require.ErrorIs(err, ErrKeyDestroyed)
+}
+
+func TestWithSealedKeys(t *testing.T) {
+	require := require.New(t)
+
+	sealed, err := NewMnemonicKeychain(testMnemonic, "", []uint32{0, 1}, WithSealedKeys())
+	require.NoError(err)
+	plain, err := NewMnemonicKeychain(testMnemonic, "", []uint32{0, 1})
+	require.NoError(err)
+	require.Equal(plain.Addresses(), sealed.Addresses())
+	for _, key := range sealed.keys {
+		require.NotNil(key.sealer)
+	}
+	require.NoError(sealed.VerifyMnemonic(testMnemonic, ""))
+}
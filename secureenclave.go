@@ -0,0 +1,189 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// Access policies of a Secure Enclave key
+const (
+	// SecureEnclavePolicyNone signs whenever the device is unlocked
+	SecureEnclavePolicyNone SecureEnclavePolicy = iota
+	// SecureEnclavePolicyUserPresence requires Touch ID, Face ID or the device
+	// passcode for every signature
+	SecureEnclavePolicyUserPresence
+	// SecureEnclavePolicyBiometry requires Touch ID or Face ID for every
+	// signature. The key becomes unusable if the enrolled biometrics change.
+	SecureEnclavePolicyBiometry
+)
+
+var (
+	ErrInvalidLabelsLength      = errors.New("number of labels should be greater than 0")
+	ErrSecureEnclaveUnsupported = errors.New("secure enclave is not available on this platform")
+	ErrSecureEnclaveKeyNotFound = errors.New("secure enclave key not found")
+	ErrSecureEnclaveKeyExists   = errors.New("secure enclave key already exists")
+	ErrAuthenticationCanceled   = errors.New("user canceled authentication")
+	ErrDuplicateSecureEnclave   = errors.New("secure enclave labels hold the same key")
+	ErrInvalidHashLen           = errors.New("hash must be 32 bytes")
+
+	_ PublicKeySigner = (*secureEnclaveSigner)(nil)
+	_ SchemeSigner    = (*secureEnclaveSigner)(nil)
+)
+
+// SecureEnclavePolicy determines how the user authorizes signatures by a
+// Secure Enclave key
+type SecureEnclavePolicy int
+
+// SecureEnclave generates and signs with P-256 keys that never leave the
+// Secure Enclave of an Apple device. Keys are identified by an application
+// label. SystemSecureEnclave returns the enclave of the running device.
+type SecureEnclave interface {
+	// GenerateKey creates a key under [label] that can only be used
+	// according to [policy], or returns ErrSecureEnclaveKeyExists
+	GenerateKey(label string, policy SecureEnclavePolicy) (*ecdsa.PublicKey, error)
+	// PublicKey returns the public key of the key under [label], or
+	// ErrSecureEnclaveKeyNotFound
+	PublicKey(label string) (*ecdsa.PublicKey, error)
+	// Sign returns the ASN.1 DER encoded ECDSA signature of [digest] by the
+	// key under [label]. [prompt] is displayed if the key requires the user
+	// to authenticate, and ErrAuthenticationCanceled is returned if they
+	// cancel.
+	Sign(label, prompt string, digest []byte) ([]byte, error)
+	// DeleteKey removes the key under [label], or returns
+	// ErrSecureEnclaveKeyNotFound
+	DeleteKey(label string) error
+}
+
+// SecureEnclaveConfig configures the authentication prompts of a Secure
+// Enclave keychain
+type SecureEnclaveConfig struct {
+	// Prompt returns the reason displayed when a key requires the user to
+	// authenticate before signing [hash]. If nil, a generic reason is shown.
+	Prompt func(label string, hash []byte) string
+}
+
+// secureEnclaveKeychain is a keychain of the keys of a Secure Enclave
+type secureEnclaveKeychain struct {
+	enclave SecureEnclave
+	config  SecureEnclaveConfig
+	addrs   set.Set[ids.ShortID]
+	keys    map[ids.ShortID]*secureEnclaveKey
+}
+
+type secureEnclaveKey struct {
+	label     string
+	publicKey *ecdsa.PublicKey
+	pubKey    []byte
+}
+
+// NewSecureEnclaveKeychain creates a keychain of the keys under [labels] of
+// [enclave]. Signatures are returned as 64 byte r || s, normalized to low-S,
+// and verify under SchemeP256.
+func NewSecureEnclaveKeychain(enclave SecureEnclave, labels []string, config SecureEnclaveConfig) (Keychain, error) {
+	if len(labels) == 0 {
+		return nil, ErrInvalidLabelsLength
+	}
+
+	kc := &secureEnclaveKeychain{
+		enclave: enclave,
+		config:  config,
+		addrs:   make(set.Set[ids.ShortID]),
+		keys:    make(map[ids.ShortID]*secureEnclaveKey),
+	}
+	for _, label := range labels {
+		pub, err := enclave.PublicKey(label)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", label, err)
+		}
+		if pub == nil || pub.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("key %q: %w", label, ErrInvalidPublicKey)
+		}
+
+		pubKey := compressPublicKey(pub.X, pub.Y)
+		addr := publicKeyAddress(pubKey)
+		if kc.addrs.Contains(addr) {
+			return nil, ErrDuplicateSecureEnclave
+		}
+		kc.addrs.Add(addr)
+		kc.keys[addr] = &secureEnclaveKey{
+			label:     label,
+			publicKey: pub,
+			pubKey:    pubKey,
+		}
+	}
+	return kc, nil
+}
+
+// GenerateSecureEnclaveKey creates a key under [label] of [enclave] and
+// returns its address
+func GenerateSecureEnclaveKey(enclave SecureEnclave, label string, policy SecureEnclavePolicy) (ids.ShortID, error) {
+	pub, err := enclave.GenerateKey(label, policy)
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	return P256Address(pub), nil
+}
+
+func (s *secureEnclaveKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	key, ok := s.keys[addr]
+	if !ok {
+		return nil, false
+	}
+	return &secureEnclaveSigner{
+		keychain: s,
+		key:      key,
+		addr:     addr,
+	}, true
+}
+
+func (s *secureEnclaveKeychain) Addresses() set.Set[ids.ShortID] {
+	return s.addrs
+}
+
+// secureEnclaveSigner signs with a single key of a Secure Enclave
+type secureEnclaveSigner struct {
+	keychain *secureEnclaveKeychain
+	key      *secureEnclaveKey
+	addr     ids.ShortID
+}
+
+func (s *secureEnclaveSigner) SignHash(hash []byte) ([]byte, error) {
+	if len(hash) != sha256.Size {
+		return nil, ErrInvalidHashLen
+	}
+	prompt := "sign with " + s.key.label
+	if s.keychain.config.Prompt != nil {
+		prompt = s.keychain.config.Prompt(s.key.label, hash)
+	}
+	der, err := s.keychain.enclave.Sign(s.key.label, prompt, hash)
+	if err != nil {
+		return nil, err
+	}
+	return p256Signature(s.key.publicKey, hash, der)
+}
+
+func (s *secureEnclaveSigner) Sign(msg []byte) ([]byte, error) {
+	hash := sha256.Sum256(msg)
+	return s.SignHash(hash[:])
+}
+
+func (s *secureEnclaveSigner) Address() ids.ShortID {
+	return s.addr
+}
+
+func (s *secureEnclaveSigner) PublicKey() []byte {
+	return s.key.pubKey
+}
+
+func (*secureEnclaveSigner) Scheme() SchemeID {
+	return SchemeP256
+}
@@ -0,0 +1,311 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build darwin && cgo
+
+package keychain
+
+/*
+#cgo LDFLAGS: -framework Security -framework CoreFoundation
+
+#include <stdlib.h>
+#include <string.h>
+#include <CoreFoundation/CoreFoundation.h>
+#include <Security/Security.h>
+
+// se_describe replaces [code] and [msg] with those of [err], if set, and
+// releases it
+static void se_describe(CFErrorRef err, OSStatus *code, char *msg, size_t msg_len) {
+	if (err == NULL) {
+		return;
+	}
+	*code = (OSStatus)CFErrorGetCode(err);
+	CFStringRef desc = CFErrorCopyDescription(err);
+	if (desc != NULL) {
+		CFStringGetCString(desc, msg, msg_len, kCFStringEncodingUTF8);
+		CFRelease(desc);
+	}
+	CFRelease(err);
+}
+
+static CFMutableDictionaryRef se_dictionary(void) {
+	return CFDictionaryCreateMutable(NULL, 0, &kCFTypeDictionaryKeyCallBacks, &kCFTypeDictionaryValueCallBacks);
+}
+
+static CFDataRef se_tag(const char *label) {
+	return CFDataCreate(NULL, (const UInt8 *)label, strlen(label));
+}
+
+// se_query matches the Secure Enclave private key tagged with [label]
+static CFMutableDictionaryRef se_query(const char *label) {
+	CFMutableDictionaryRef query = se_dictionary();
+	CFDataRef tag = se_tag(label);
+	CFDictionarySetValue(query, kSecClass, kSecClassKey);
+	CFDictionarySetValue(query, kSecAttrKeyClass, kSecAttrKeyClassPrivate);
+	CFDictionarySetValue(query, kSecAttrKeyType, kSecAttrKeyTypeECSECPrimeRandom);
+	CFDictionarySetValue(query, kSecAttrTokenID, kSecAttrTokenIDSecureEnclave);
+	CFDictionarySetValue(query, kSecAttrApplicationTag, tag);
+	CFRelease(tag);
+	return query;
+}
+
+static OSStatus se_find(const char *label, const char *prompt, SecKeyRef *key) {
+	CFMutableDictionaryRef query = se_query(label);
+	CFDictionarySetValue(query, kSecReturnRef, kCFBooleanTrue);
+	if (prompt != NULL) {
+		CFStringRef reason = CFStringCreateWithCString(NULL, prompt, kCFStringEncodingUTF8);
+#pragma clang diagnostic push
+#pragma clang diagnostic ignored "-Wdeprecated-declarations"
+		CFDictionarySetValue(query, kSecUseOperationPrompt, reason);
+#pragma clang diagnostic pop
+		CFRelease(reason);
+	}
+	OSStatus status = SecItemCopyMatching(query, (CFTypeRef *)key);
+	CFRelease(query);
+	return status;
+}
+
+// se_public_key writes the 65 byte uncompressed public key of [key] to [out]
+static OSStatus se_public_key(SecKeyRef key, UInt8 *out, char *msg, size_t msg_len) {
+	SecKeyRef pub = SecKeyCopyPublicKey(key);
+	if (pub == NULL) {
+		return errSecParam;
+	}
+	CFErrorRef err = NULL;
+	CFDataRef data = SecKeyCopyExternalRepresentation(pub, &err);
+	CFRelease(pub);
+	if (data == NULL) {
+		OSStatus status = errSecParam;
+		se_describe(err, &status, msg, msg_len);
+		return status;
+	}
+	OSStatus status = errSecSuccess;
+	if (CFDataGetLength(data) == 65) {
+		CFDataGetBytes(data, CFRangeMake(0, 65), out);
+	} else {
+		status = errSecDecode;
+	}
+	CFRelease(data);
+	return status;
+}
+
+static OSStatus se_lookup(const char *label, UInt8 *out, char *msg, size_t msg_len) {
+	SecKeyRef key = NULL;
+	OSStatus status = se_find(label, NULL, &key);
+	if (status != errSecSuccess) {
+		return status;
+	}
+	status = se_public_key(key, out, msg, msg_len);
+	CFRelease(key);
+	return status;
+}
+
+static OSStatus se_generate(const char *label, int policy, UInt8 *out, char *msg, size_t msg_len) {
+	SecAccessControlCreateFlags flags = kSecAccessControlPrivateKeyUsage;
+	if (policy == 1) {
+		flags |= kSecAccessControlUserPresence;
+	} else if (policy == 2) {
+		flags |= kSecAccessControlBiometryCurrentSet;
+	}
+	CFErrorRef err = NULL;
+	OSStatus status = errSecParam;
+	SecAccessControlRef access = SecAccessControlCreateWithFlags(NULL, kSecAttrAccessibleWhenUnlockedThisDeviceOnly, flags, &err);
+	if (access == NULL) {
+		se_describe(err, &status, msg, msg_len);
+		return status;
+	}
+
+	CFDataRef tag = se_tag(label);
+	CFMutableDictionaryRef priv = se_dictionary();
+	CFDictionarySetValue(priv, kSecAttrIsPermanent, kCFBooleanTrue);
+	CFDictionarySetValue(priv, kSecAttrApplicationTag, tag);
+	CFDictionarySetValue(priv, kSecAttrAccessControl, access);
+
+	int bits = 256;
+	CFNumberRef size = CFNumberCreate(NULL, kCFNumberIntType, &bits);
+	CFMutableDictionaryRef attrs = se_dictionary();
+	CFDictionarySetValue(attrs, kSecAttrKeyType, kSecAttrKeyTypeECSECPrimeRandom);
+	CFDictionarySetValue(attrs, kSecAttrKeySizeInBits, size);
+	CFDictionarySetValue(attrs, kSecAttrTokenID, kSecAttrTokenIDSecureEnclave);
+	CFDictionarySetValue(attrs, kSecPrivateKeyAttrs, priv);
+
+	SecKeyRef key = SecKeyCreateRandomKey(attrs, &err);
+	CFRelease(attrs);
+	CFRelease(size);
+	CFRelease(priv);
+	CFRelease(tag);
+	CFRelease(access);
+	if (key == NULL) {
+		se_describe(err, &status, msg, msg_len);
+		return status;
+	}
+	status = se_public_key(key, out, msg, msg_len);
+	CFRelease(key);
+	return status;
+}
+
+static OSStatus se_sign(const char *label, const char *prompt, const UInt8 *digest, size_t digest_len, UInt8 *out, size_t *out_len, char *msg, size_t msg_len) {
+	SecKeyRef key = NULL;
+	OSStatus status = se_find(label, prompt, &key);
+	if (status != errSecSuccess) {
+		return status;
+	}
+	CFDataRef data = CFDataCreate(NULL, digest, digest_len);
+	CFErrorRef err = NULL;
+	CFDataRef sig = SecKeyCreateSignature(key, kSecKeyAlgorithmECDSASignatureDigestX962SHA256, data, &err);
+	CFRelease(data);
+	CFRelease(key);
+	if (sig == NULL) {
+		status = errSecParam;
+		se_describe(err, &status, msg, msg_len);
+		return status;
+	}
+	CFIndex n = CFDataGetLength(sig);
+	if (n > (CFIndex)*out_len) {
+		status = errSecDecode;
+	} else {
+		CFDataGetBytes(sig, CFRangeMake(0, n), out);
+		*out_len = (size_t)n;
+	}
+	CFRelease(sig);
+	return status;
+}
+
+static OSStatus se_delete(const char *label) {
+	CFMutableDictionaryRef query = se_query(label);
+	OSStatus status = SecItemDelete(query);
+	CFRelease(query);
+	return status;
+}
+*/
+import "C"
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"fmt"
+	"unsafe"
+)
+
+const (
+	errSecSuccess      = 0
+	errSecUserCanceled = -128
+	errSecItemNotFound = -25300
+
+	// maxDERSignatureLen bounds the DER encoding of a P-256 signature
+	maxDERSignatureLen = 72
+)
+
+var _ SecureEnclave = systemSecureEnclave{}
+
+// systemSecureEnclave is the Secure Enclave of the running device, accessed
+// through the Security framework. Keys are stored in the data protection
+// keychain, tagged with their label, and are only accessible while the device
+// is unlocked.
+type systemSecureEnclave struct{}
+
+// SystemSecureEnclave returns the Secure Enclave of the running Mac or iOS
+// device
+func SystemSecureEnclave() (SecureEnclave, error) {
+	return systemSecureEnclave{}, nil
+}
+
+func (systemSecureEnclave) GenerateKey(label string, policy SecureEnclavePolicy) (*ecdsa.PublicKey, error) {
+	cLabel := C.CString(label)
+	defer C.free(unsafe.Pointer(cLabel))
+
+	var (
+		pub [65]byte
+		msg [256]C.char
+	)
+	switch status := C.se_lookup(cLabel, (*C.UInt8)(unsafe.Pointer(&pub[0])), &msg[0], C.size_t(len(msg))); status {
+	case errSecSuccess:
+		return nil, ErrSecureEnclaveKeyExists
+	case errSecItemNotFound:
+	default:
+		return nil, enclaveError(status, &msg[0])
+	}
+
+	status := C.se_generate(cLabel, C.int(policy), (*C.UInt8)(unsafe.Pointer(&pub[0])), &msg[0], C.size_t(len(msg)))
+	if status != errSecSuccess {
+		return nil, enclaveError(status, &msg[0])
+	}
+	return parseUncompressedP256(pub[:])
+}
+
+func (systemSecureEnclave) PublicKey(label string) (*ecdsa.PublicKey, error) {
+	cLabel := C.CString(label)
+	defer C.free(unsafe.Pointer(cLabel))
+
+	var (
+		pub [65]byte
+		msg [256]C.char
+	)
+	status := C.se_lookup(cLabel, (*C.UInt8)(unsafe.Pointer(&pub[0])), &msg[0], C.size_t(len(msg)))
+	if status != errSecSuccess {
+		return nil, enclaveError(status, &msg[0])
+	}
+	return parseUncompressedP256(pub[:])
+}
+
+func (systemSecureEnclave) Sign(label, prompt string, digest []byte) ([]byte, error) {
+	if len(digest) != sha256.Size {
+		return nil, ErrInvalidHashLen
+	}
+	cLabel := C.CString(label)
+	defer C.free(unsafe.Pointer(cLabel))
+	cPrompt := C.CString(prompt)
+	defer C.free(unsafe.Pointer(cPrompt))
+
+	var (
+		sig    [maxDERSignatureLen]byte
+		sigLen = C.size_t(len(sig))
+		msg    [256]C.char
+	)
+	status := C.se_sign(
+		cLabel,
+		cPrompt,
+		(*C.UInt8)(unsafe.Pointer(&digest[0])),
+		C.size_t(len(digest)),
+		(*C.UInt8)(unsafe.Pointer(&sig[0])),
+		&sigLen,
+		&msg[0],
+		C.size_t(len(msg)),
+	)
+	if status != errSecSuccess {
+		return nil, enclaveError(status, &msg[0])
+	}
+	return append([]byte(nil), sig[:sigLen]...), nil
+}
+
+func (systemSecureEnclave) DeleteKey(label string) error {
+	cLabel := C.CString(label)
+	defer C.free(unsafe.Pointer(cLabel))
+
+	if status := C.se_delete(cLabel); status != errSecSuccess {
+		return enclaveError(status, nil)
+	}
+	return nil
+}
+
+func enclaveError(status C.OSStatus, msg *C.char) error {
+	switch status {
+	case errSecItemNotFound:
+		return ErrSecureEnclaveKeyNotFound
+	case errSecUserCanceled:
+		return ErrAuthenticationCanceled
+	}
+	if msg != nil && *msg != 0 {
+		return fmt.Errorf("secure enclave: %s (%d)", C.GoString(msg), int(status))
+	}
+	return fmt.Errorf("secure enclave: OSStatus %d", int(status))
+}
+
+func parseUncompressedP256(pub []byte) (*ecdsa.PublicKey, error) {
+	x, y := elliptic.Unmarshal(elliptic.P256(), pub)
+	if x == nil {
+		return nil, ErrInvalidPublicKey
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+}
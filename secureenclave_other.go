@@ -0,0 +1,12 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build !darwin || !cgo
+
+package keychain
+
+// SystemSecureEnclave returns ErrSecureEnclaveUnsupported, as the Secure
+// Enclave is only available to cgo builds for macOS and iOS
+func SystemSecureEnclave() (SecureEnclave, error) {
+	return nil, ErrSecureEnclaveUnsupported
+}
@@ -0,0 +1,128 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+type mockEnclaveKey struct {
+	key    *ecdsa.PrivateKey
+	policy SecureEnclavePolicy
+}
+
+// mockSecureEnclave signs with software P-256 keys
+type mockSecureEnclave struct {
+	keys    map[string]*mockEnclaveKey
+	prompts []string
+	cancel  bool
+}
+
+func newMockSecureEnclave() *mockSecureEnclave {
+	return &mockSecureEnclave{keys: make(map[string]*mockEnclaveKey)}
+}
+
+func (m *mockSecureEnclave) GenerateKey(label string, policy SecureEnclavePolicy) (*ecdsa.PublicKey, error) {
+	if _, ok := m.keys[label]; ok {
+		return nil, ErrSecureEnclaveKeyExists
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	m.keys[label] = &mockEnclaveKey{key: key, policy: policy}
+	return &key.PublicKey, nil
+}
+
+func (m *mockSecureEnclave) PublicKey(label string) (*ecdsa.PublicKey, error) {
+	k, ok := m.keys[label]
+	if !ok {
+		return nil, ErrSecureEnclaveKeyNotFound
+	}
+	return &k.key.PublicKey, nil
+}
+
+func (m *mockSecureEnclave) Sign(label, prompt string, digest []byte) ([]byte, error) {
+	k, ok := m.keys[label]
+	if !ok {
+		return nil, ErrSecureEnclaveKeyNotFound
+	}
+	if k.policy != SecureEnclavePolicyNone {
+		m.prompts = append(m.prompts, prompt)
+		if m.cancel {
+			return nil, ErrAuthenticationCanceled
+		}
+	}
+	return ecdsa.SignASN1(rand.Reader, k.key, digest)
+}
+
+func (m *mockSecureEnclave) DeleteKey(label string) error {
+	if _, ok := m.keys[label]; !ok {
+		return ErrSecureEnclaveKeyNotFound
+	}
+	delete(m.keys, label)
+	return nil
+}
+
+func TestSecureEnclaveKeychain(t *testing.T) {
+	require := require.New(t)
+
+	enclave := newMockSecureEnclave()
+	walletAddr, err := GenerateSecureEnclaveKey(enclave, "wallet", SecureEnclavePolicyBiometry)
+	require.NoError(err)
+	hotAddr, err := GenerateSecureEnclaveKey(enclave, "hot", SecureEnclavePolicyNone)
+	require.NoError(err)
+	_, err = GenerateSecureEnclaveKey(enclave, "wallet", SecureEnclavePolicyNone)
+	require.ErrorIs(err, ErrSecureEnclaveKeyExists)
+
+	kc, err := NewSecureEnclaveKeychain(enclave, []string{"wallet", "hot"}, SecureEnclaveConfig{
+		Prompt: func(label string, _ []byte) string {
+			return "approve signature by " + label
+		},
+	})
+	require.NoError(err)
+	require.Equal(2, kc.Addresses().Len())
+
+	msg := []byte("desktop wallet transfer")
+	for _, addr := range []ids.ShortID{walletAddr, hotAddr} {
+		signer, ok := kc.Get(addr)
+		require.True(ok)
+		require.Equal(SchemeP256, SchemeOf(signer))
+
+		sig, err := signer.Sign(msg)
+		require.NoError(err)
+		require.Len(sig, 64)
+		require.NoError(VerifySignature(signer, msg, sig))
+	}
+	// Only the biometric key prompted the user
+	require.Equal([]string{"approve signature by wallet"}, enclave.prompts)
+
+	signer, _ := kc.Get(walletAddr)
+	enclave.cancel = true
+	_, err = signer.Sign(msg)
+	require.ErrorIs(err, ErrAuthenticationCanceled)
+	_, err = signer.SignHash(msg)
+	require.ErrorIs(err, ErrInvalidHashLen)
+}
+
+func TestSecureEnclaveKeychainErrors(t *testing.T) {
+	require := require.New(t)
+
+	enclave := newMockSecureEnclave()
+	_, err := NewSecureEnclaveKeychain(enclave, nil, SecureEnclaveConfig{})
+	require.ErrorIs(err, ErrInvalidLabelsLength)
+	_, err = NewSecureEnclaveKeychain(enclave, []string{"missing"}, SecureEnclaveConfig{})
+	require.ErrorIs(err, ErrSecureEnclaveKeyNotFound)
+
+	_, err = enclave.GenerateKey("wallet", SecureEnclavePolicyNone)
+	require.NoError(err)
+	_, err = NewSecureEnclaveKeychain(enclave, []string{"wallet", "wallet"}, SecureEnclaveConfig{})
+	require.ErrorIs(err, ErrDuplicateSecureEnclave)
+}
@@ -0,0 +1,131 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"runtime"
+	"sync"
+
+	"github.com/luxfi/crypto/secp256k1"
+)
+
+// canaryLen is the length of the canary written in front of each secure
+// buffer to detect writes that underflow it
+const canaryLen = 16
+
+var (
+	ErrKeyDestroyed          = errors.New("key has been destroyed")
+	ErrSecureMemoryCorrupted = errors.New("secure memory canary was overwritten")
+
+	canary = func() [canaryLen]byte {
+		var c [canaryLen]byte
+		_, _ = rand.Read(c[:])
+		return c
+	}()
+)
+
+// secureRegion is memory holding a canary followed by secret data. On
+// supported platforms it is a dedicated mapping that is locked into RAM,
+// excluded from core dumps where possible and surrounded by inaccessible
+// guard pages, with the data placed against the trailing guard page so that
+// overflows fault. Elsewhere it is ordinary heap memory.
+type secureRegion struct {
+	mapping []byte
+	canary  []byte
+	data    []byte
+}
+
+// secureBuffer holds a secret in a secure region until it is destroyed,
+// either explicitly or once the buffer is garbage collected
+type secureBuffer struct {
+	lock    sync.RWMutex
+	region  *secureRegion
+	cleanup runtime.Cleanup
+}
+
+// newSecureBuffer copies [secret] into a new secure buffer. The caller is
+// responsible for wiping [secret].
+func newSecureBuffer(secret []byte) *secureBuffer {
+	region, err := allocSecureRegion(len(secret))
+	if err != nil {
+		// Locked memory is a best-effort protection, for example the
+		// RLIMIT_MEMLOCK of the process may be exhausted
+		region = allocHeapRegion(len(secret))
+	}
+	copy(region.canary, canary[:])
+	copy(region.data, secret)
+
+	b := &secureBuffer{region: region}
+	b.cleanup = runtime.AddCleanup(b, func(region *secureRegion) {
+		_ = region.free()
+	}, region)
+	return b
+}
+
+// use calls [f] with the secret. [f] must not retain the slice.
+func (b *secureBuffer) use(f func(secret []byte) error) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	if b.region == nil {
+		return ErrKeyDestroyed
+	}
+	return f(b.region.data)
+}
+
+// destroy wipes and releases the secret. Destroying a buffer more than once
+// is a no-op.
+func (b *secureBuffer) destroy() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.region == nil {
+		return nil
+	}
+	b.cleanup.Stop()
+	err := b.region.free()
+	b.region = nil
+	return err
+}
+
+// free wipes the region and releases it, reporting whether its canary was
+// overwritten
+func (r *secureRegion) free() error {
+	var err error
+	if subtle.ConstantTimeCompare(r.canary, canary[:]) != 1 {
+		err = ErrSecureMemoryCorrupted
+	}
+	clear(r.canary)
+	clear(r.data)
+	if r.mapping != nil {
+		freeSecureRegion(r)
+	}
+	return err
+}
+
+func allocHeapRegion(n int) *secureRegion {
+	buf := make([]byte, canaryLen+n)
+	return &secureRegion{
+		canary: buf[:canaryLen],
+		data:   buf[canaryLen:],
+	}
+}
+
+// WipeKey overwrites the secret of [key] in place. The key must not be used
+// afterwards. Use it on keys that were added to a SoftwareKeychain, or
+// returned by one, once they are no longer needed, since those copies live
+// outside of protected memory.
+func WipeKey(key *secp256k1.PrivateKey) {
+	if key == nil {
+		return
+	}
+	clear(key.Bytes())
+	if sk := key.ToECDSA(); sk != nil && sk.D != nil {
+		clear(sk.D.Bits())
+		sk.D.SetInt64(0)
+	}
+}
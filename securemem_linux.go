@@ -0,0 +1,11 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import "golang.org/x/sys/unix"
+
+// excludeFromDump keeps [b] out of core dumps
+func excludeFromDump(b []byte) {
+	_ = unix.Madvise(b, unix.MADV_DONTDUMP)
+}
@@ -0,0 +1,18 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build !linux && !darwin && !freebsd
+
+package keychain
+
+import "errors"
+
+var errSecureMemoryUnsupported = errors.New("locked memory is not supported on this platform")
+
+// allocSecureRegion is not supported, so secrets are kept in heap memory
+// that is wiped when destroyed
+func allocSecureRegion(int) (*secureRegion, error) {
+	return nil, errSecureMemoryUnsupported
+}
+
+func freeSecureRegion(*secureRegion) {}
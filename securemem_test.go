@@ -0,0 +1,81 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/secp256k1"
+)
+
+func TestSecureBuffer(t *testing.T) {
+	require := require.New(t)
+
+	secret := []byte("secret key material")
+	b := newSecureBuffer(secret)
+	region := b.region
+	require.NoError(b.use(func(got []byte) error {
+		require.Equal(secret, got)
+		return nil
+	}))
+
+	require.NoError(b.destroy())
+	require.NoError(b.destroy())
+	require.ErrorIs(b.use(func([]byte) error { return nil }), ErrKeyDestroyed)
+	if region.mapping == nil {
+		// Heap regions remain readable and must have been wiped
+		require.Equal(make([]byte, len(secret)), region.data)
+	}
+}
+
+func TestSecureBufferCanary(t *testing.T) {
+	require := require.New(t)
+
+	b := newSecureBuffer([]byte("secret"))
+	b.region.canary[0] ^= 1
+	require.ErrorIs(b.destroy(), ErrSecureMemoryCorrupted)
+}
+
+func TestWipeKey(t *testing.T) {
+	require := require.New(t)
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	WipeKey(key)
+	require.Equal(make([]byte, secp256k1.PrivateKeyLen), key.Bytes())
+	require.Zero(key.ToECDSA().D.Sign())
+
+	WipeKey(nil)
+}
+
+func TestSoftwareKeychainDestroy(t *testing.T) {
+	require := require.New(t)
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	keyBytes := bytes.Clone(key.Bytes())
+
+	kc := NewSoftwareKeychain(key)
+	WipeKey(key)
+
+	// The keychain holds its own copy of the key
+	signer, ok := kc.Get(key.Address())
+	require.True(ok)
+	_, err = signer.Sign([]byte("payload"))
+	require.NoError(err)
+	got, ok := kc.GetKey(key.Address())
+	require.True(ok)
+	require.Equal(keyBytes, got.Bytes())
+
+	require.NoError(kc.Destroy())
+	require.Zero(kc.Addresses().Len())
+	require.Empty(kc.Keys())
+	_, ok = kc.Get(key.Address())
+	require.False(ok)
+	_, err = signer.Sign([]byte("payload"))
+	require.ErrorIs(err, ErrKeyDestroyed)
+}
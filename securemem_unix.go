@@ -0,0 +1,58 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build linux || darwin || freebsd
+
+package keychain
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// allocSecureRegion maps a guard page, the pages holding the canary and [n]
+// bytes of data, and another guard page. The data pages are locked into RAM.
+func allocSecureRegion(n int) (*secureRegion, error) {
+	pageSize := os.Getpagesize()
+	dataPages := (canaryLen + n + pageSize - 1) / pageSize
+	size := (dataPages + 2) * pageSize
+
+	mapping, err := unix.Mmap(-1, 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+	if err != nil {
+		return nil, err
+	}
+	inner := mapping[pageSize : size-pageSize]
+	if err := protectSecureRegion(mapping, inner, pageSize); err != nil {
+		_ = unix.Munmap(mapping)
+		return nil, err
+	}
+
+	// Place the data against the trailing guard page
+	start := len(inner) - n
+	return &secureRegion{
+		mapping: mapping,
+		canary:  inner[start-canaryLen : start],
+		data:    inner[start:],
+	}, nil
+}
+
+func protectSecureRegion(mapping, inner []byte, pageSize int) error {
+	if err := unix.Mprotect(mapping[:pageSize], unix.PROT_NONE); err != nil {
+		return err
+	}
+	if err := unix.Mprotect(mapping[len(mapping)-pageSize:], unix.PROT_NONE); err != nil {
+		return err
+	}
+	if err := unix.Mlock(inner); err != nil {
+		return err
+	}
+	excludeFromDump(inner)
+	return nil
+}
+
+func freeSecureRegion(r *secureRegion) {
+	pageSize := os.Getpagesize()
+	_ = unix.Munlock(r.mapping[pageSize : len(r.mapping)-pageSize])
+	_ = unix.Munmap(r.mapping)
+}
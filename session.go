@@ -0,0 +1,212 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var (
+	ErrKeychainLocked                    = errors.New("keychain is locked")
+	ErrInvalidSessionTTL                 = errors.New("session ttl should be greater than 0")
+	_                    Keychain        = (*SessionKeychain)(nil)
+	_                    PublicKeySigner = (*sessionSigner)(nil)
+)
+
+// SessionKeychain is a keychain of encrypted keys that only signs during an
+// unlocked session. Unlock decrypts the keys with the passphrase for a
+// limited time, during which signing does not ask for the passphrase again.
+// The keys are wiped when the session expires or Lock is called, after which
+// signers fail with ErrKeychainLocked until the next Unlock.
+type SessionKeychain struct {
+	open func(pass []byte) (*SoftwareKeychain, error)
+
+	lock sync.RWMutex
+	// keys are the decrypted keys of the current session, nil while locked
+	keys    *SoftwareKeychain
+	expires time.Time
+	timer   *time.Timer
+	// pubKeys are the public keys of the addresses of the last session, kept
+	// while locked so that the addresses can still be listed
+	pubKeys map[ids.ShortID][]byte
+}
+
+// NewSessionKeychain returns a locked keychain whose keys are decrypted with
+// [open] on each Unlock. [open] fails if the passphrase is wrong. The
+// addresses of the keychain are unknown until it is first unlocked.
+func NewSessionKeychain(open func(pass []byte) (*SoftwareKeychain, error)) *SessionKeychain {
+	return &SessionKeychain{
+		open:    open,
+		pubKeys: make(map[ids.ShortID][]byte),
+	}
+}
+
+// NewBundleSessionKeychain returns a locked keychain of the keys of the
+// encrypted bundle [data], as written by Export, decrypted on each Unlock
+func NewBundleSessionKeychain(data []byte) *SessionKeychain {
+	data = bytes.Clone(data)
+	return NewSessionKeychain(func(pass []byte) (*SoftwareKeychain, error) {
+		b, err := Import(bytes.NewReader(data), pass)
+		if err != nil {
+			return nil, err
+		}
+		defer b.wipe()
+		return b.SoftwareKeychain(), nil
+	})
+}
+
+// Unlock decrypts the keys with [pass] and keeps them for [ttl]. Unlocking an
+// unlocked keychain starts a new session in place of the current one. The
+// keychain stays locked if the keys cannot be decrypted.
+func (s *SessionKeychain) Unlock(pass []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return ErrInvalidSessionTTL
+	}
+	keys, err := s.open(pass)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := s.lockSession(); err != nil {
+		_ = keys.Destroy()
+		return err
+	}
+	clear(s.pubKeys)
+	for addr := range keys.Addresses() {
+		signer, _ := keys.Get(addr)
+		s.pubKeys[addr] = signer.(PublicKeySigner).PublicKey()
+	}
+	s.keys = keys
+	s.expires = time.Now().Add(ttl)
+	s.timer = time.AfterFunc(ttl, func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+
+		// A new session may have started since the timer was set
+		if s.keys == keys {
+			_ = s.lockSession()
+		}
+	})
+	return nil
+}
+
+// Lock ends the session, wiping the decrypted keys. Locking a locked keychain
+// does nothing. ErrSecureMemoryCorrupted is returned if the memory of a key
+// was found to have been overwritten.
+func (s *SessionKeychain) Lock() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.lockSession()
+}
+
+// Locked returns true if the keychain has no unlocked session
+func (s *SessionKeychain) Locked() bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.keys == nil
+}
+
+// Expires returns the time the current session locks at, and false if the
+// keychain is locked
+func (s *SessionKeychain) Expires() (time.Time, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.expires, s.keys != nil
+}
+
+// lockSession wipes the keys of the current session, if any. The lock must
+// be held.
+func (s *SessionKeychain) lockSession() error {
+	if s.keys == nil {
+		return nil
+	}
+	s.timer.Stop()
+	err := s.keys.Destroy()
+	s.keys = nil
+	s.timer = nil
+	s.expires = time.Time{}
+	return err
+}
+
+// Get a signer for [addr]. Signers can be obtained while locked, for the
+// addresses of the last session, and sign once the keychain is unlocked.
+func (s *SessionKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if _, ok := s.pubKeys[addr]; !ok {
+		return nil, false
+	}
+	return &sessionSigner{keychain: s, addr: addr}, true
+}
+
+// Addresses returns the addresses of the last session
+func (s *SessionKeychain) Addresses() set.Set[ids.ShortID] {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	addrs := set.NewSet[ids.ShortID](len(s.pubKeys))
+	for addr := range s.pubKeys {
+		addrs.Add(addr)
+	}
+	return addrs
+}
+
+// sessionSigner signs with a key of the current session of a session
+// keychain
+type sessionSigner struct {
+	keychain *SessionKeychain
+	addr     ids.ShortID
+}
+
+func (s *sessionSigner) SignHash(hash []byte) ([]byte, error) {
+	return s.sign(func(signer Signer) ([]byte, error) {
+		return signer.SignHash(hash)
+	})
+}
+
+func (s *sessionSigner) Sign(msg []byte) ([]byte, error) {
+	return s.sign(func(signer Signer) ([]byte, error) {
+		return signer.Sign(msg)
+	})
+}
+
+// sign holds the session open while [f] signs, so that it is not wiped
+// midway by Lock or its expiry
+func (s *sessionSigner) sign(f func(Signer) ([]byte, error)) ([]byte, error) {
+	s.keychain.lock.RLock()
+	defer s.keychain.lock.RUnlock()
+
+	if s.keychain.keys == nil {
+		return nil, ErrKeychainLocked
+	}
+	signer, ok := s.keychain.keys.Get(s.addr)
+	if !ok {
+		return nil, ErrUnknownAddress
+	}
+	return f(signer)
+}
+
+func (s *sessionSigner) Address() ids.ShortID {
+	return s.addr
+}
+
+func (s *sessionSigner) PublicKey() []byte {
+	s.keychain.lock.RLock()
+	defer s.keychain.lock.RUnlock()
+
+	return s.keychain.pubKeys[s.addr]
+}
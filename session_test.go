@@ -0,0 +1,96 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionKeychain(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	kc, err := NewTestKeychain([]byte("session"), 2)
+	require.NoError(err)
+	b, err := NewBundle(kc)
+	require.NoError(err)
+	pass := []byte("passphrase")
+	var buf bytes.Buffer
+	require.NoError(b.Export(&buf, pass))
+
+	session := NewBundleSessionKeychain(buf.Bytes())
+	require.True(session.Locked())
+	require.Zero(session.Addresses().Len())
+
+	// A wrong passphrase leaves the keychain locked
+	require.ErrorIs(session.Unlock([]byte("wrong"), time.Hour), ErrBundleDecryption)
+	require.True(session.Locked())
+	require.ErrorIs(session.Unlock(pass, 0), ErrInvalidSessionTTL)
+
+	require.NoError(session.Unlock(pass, time.Hour))
+	require.False(session.Locked())
+	expires, ok := session.Expires()
+	require.True(ok)
+	require.WithinDuration(time.Now().Add(time.Hour), expires, time.Minute)
+	require.Equal(kc.Addresses(), session.Addresses())
+
+	// Signing does not need the passphrase during the session
+	addr := kc.Keys()[0].Address()
+	signer, ok := session.Get(addr)
+	require.True(ok)
+	msg := []byte("unlocked")
+	for range 2 {
+		sig, err := signer.Sign(msg)
+		require.NoError(err)
+		require.NoError(VerifySignature(signer, msg, sig))
+	}
+
+	// Locking wipes the keys; the signer and addresses remain
+	require.NoError(session.Lock())
+	require.True(session.Locked())
+	_, ok = session.Expires()
+	require.False(ok)
+	_, err = signer.Sign(msg)
+	require.ErrorIs(err, ErrKeychainLocked)
+	require.Equal(kc.Addresses(), session.Addresses())
+	require.NoError(session.Lock())
+
+	// Signers obtained while locked sign once unlocked
+	signer, ok = session.Get(addr)
+	require.True(ok)
+	require.NoError(session.Unlock(pass, time.Hour))
+	_, err = signer.Sign(msg)
+	require.NoError(err)
+}
+
+func TestSessionKeychainExpiry(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("session expiry"), 1)
+	require.NoError(err)
+	opened := 0
+	session := NewSessionKeychain(func([]byte) (*SoftwareKeychain, error) {
+		opened++
+		return NewSoftwareKeychain(kc.Keys()...), nil
+	})
+	require.NoError(session.Unlock(nil, 10*time.Millisecond))
+	signer, ok := session.Get(kc.Keys()[0].Address())
+	require.True(ok)
+
+	require.Eventually(session.Locked, time.Second, time.Millisecond)
+	_, err = signer.Sign([]byte("expired"))
+	require.ErrorIs(err, ErrKeychainLocked)
+
+	// Unlocking again starts a new session that the old timer does not end
+	require.NoError(session.Unlock(nil, 10*time.Millisecond))
+	require.NoError(session.Unlock(nil, time.Hour))
+	time.Sleep(50 * time.Millisecond)
+	require.False(session.Locked())
+	require.Equal(3, opened)
+	require.NoError(session.Lock())
+}
@@ -0,0 +1,166 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+)
+
+// compactSignatureLen is the length of the r || s encoding of the ECDSA
+// signatures of secp256k1 and P-256
+const compactSignatureLen = 64
+
+var ErrNotECDSAScheme = errors.New("signature scheme is not an ECDSA scheme")
+
+// The ECDSA signatures of secp256k1 and P-256 keys come in three encodings,
+// depending on the backend that produced them:
+//
+//   - DER: the ASN.1 SEQUENCE of r and s returned by KMS services, PIV
+//     tokens and crypto.Signer implementations
+//   - compact: the 64 byte r || s returned by Sign for P-256 keys
+//   - recoverable: the 65 byte r || s || v returned by Sign for secp256k1
+//     keys, where the recovery id v is 0 or 1
+//
+// The functions below convert between them, validating the signature they
+// are given. The s value is kept as is; NormalizeLowS normalizes it.
+
+// ValidateDERSignature checks that [der] is the canonical DER encoding of a
+// signature of the ECDSA scheme [scheme], with r and s between 1 and n - 1
+func ValidateDERSignature(scheme SchemeID, der []byte) error {
+	_, err := DERToCompact(scheme, der)
+	return err
+}
+
+// ValidateCompactSignature checks that [sig] is a 64 byte r || s signature
+// of the ECDSA scheme [scheme], with r and s between 1 and n - 1
+func ValidateCompactSignature(scheme SchemeID, sig []byte) error {
+	curve, err := schemeCurve(scheme)
+	if err != nil {
+		return err
+	}
+	if len(sig) != compactSignatureLen {
+		return ErrInvalidSignatureLen
+	}
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+	return checkECDSAScalars(curve, r, s)
+}
+
+// ValidateRecoverableSignature checks that [sig] is a 65 byte r || s || v
+// secp256k1 signature, with r and s between 1 and n - 1 and a recovery id of
+// 0 or 1
+func ValidateRecoverableSignature(sig []byte) error {
+	if len(sig) != secp256k1.SignatureLen {
+		return ErrInvalidSignatureLen
+	}
+	if err := ValidateCompactSignature(SchemeSecp256k1, sig[:compactSignatureLen]); err != nil {
+		return err
+	}
+	if v := sig[compactSignatureLen]; v > 1 {
+		return fmt.Errorf("%w: recovery id %d", ErrInvalidSignatureEncoding, v)
+	}
+	return nil
+}
+
+// DERToCompact converts [der], a DER encoded signature of the ECDSA scheme
+// [scheme], to its 64 byte r || s encoding
+func DERToCompact(scheme SchemeID, der []byte) ([]byte, error) {
+	curve, err := schemeCurve(scheme)
+	if err != nil {
+		return nil, err
+	}
+	r, s, err := parseDERSignature(der)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkECDSAScalars(curve, r, s); err != nil {
+		return nil, err
+	}
+	sig := make([]byte, compactSignatureLen)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return sig, nil
+}
+
+// CompactToDER converts [sig], a 64 byte r || s signature of the ECDSA scheme
+// [scheme], to its DER encoding
+func CompactToDER(scheme SchemeID, sig []byte) ([]byte, error) {
+	if err := ValidateCompactSignature(scheme, sig); err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1BigInt(new(big.Int).SetBytes(sig[:32]))
+		b.AddASN1BigInt(new(big.Int).SetBytes(sig[32:]))
+	})
+	return b.Bytes()
+}
+
+// CompactToRecoverable converts [sig], a 64 byte r || s secp256k1 signature
+// of [hash], to the 65 byte r || s || v encoding, with the recovery id that
+// recovers [addr]. It fails with ErrSignatureRecovery if [addr] did not sign
+// [hash].
+func CompactToRecoverable(hash, sig []byte, addr ids.ShortID) ([]byte, error) {
+	if err := ValidateCompactSignature(SchemeSecp256k1, sig); err != nil {
+		return nil, err
+	}
+	return recoverableSignature(hash, sig, addr)
+}
+
+// RecoverableToCompact converts [sig], a 65 byte r || s || v secp256k1
+// signature, to its 64 byte r || s encoding
+func RecoverableToCompact(sig []byte) ([]byte, error) {
+	if err := ValidateRecoverableSignature(sig); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(sig[:compactSignatureLen]), nil
+}
+
+// DERToRecoverable converts [der], a DER encoded secp256k1 signature of
+// [hash], to the 65 byte r || s || v encoding, as CompactToRecoverable
+func DERToRecoverable(hash, der []byte, addr ids.ShortID) ([]byte, error) {
+	sig, err := DERToCompact(SchemeSecp256k1, der)
+	if err != nil {
+		return nil, err
+	}
+	return recoverableSignature(hash, sig, addr)
+}
+
+// RecoverableToDER converts [sig], a 65 byte r || s || v secp256k1
+// signature, to its DER encoding
+func RecoverableToDER(sig []byte) ([]byte, error) {
+	compact, err := RecoverableToCompact(sig)
+	if err != nil {
+		return nil, err
+	}
+	return CompactToDER(SchemeSecp256k1, compact)
+}
+
+// schemeCurve returns the curve of the ECDSA scheme [scheme]
+func schemeCurve(scheme SchemeID) (elliptic.Curve, error) {
+	curve := ecdsaCurve(scheme)
+	if curve == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotECDSAScheme, scheme)
+	}
+	return curve, nil
+}
+
+// checkECDSAScalars checks that [r] and [s] are between 1 and n - 1, where n
+// is the order of [curve]
+func checkECDSAScalars(curve elliptic.Curve, r, s *big.Int) error {
+	n := curve.Params().N
+	if r.Sign() <= 0 || r.Cmp(n) >= 0 || s.Sign() <= 0 || s.Cmp(n) >= 0 {
+		return fmt.Errorf("%w: r or s out of range", ErrInvalidSignatureEncoding)
+	}
+	return nil
+}
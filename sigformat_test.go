@@ -0,0 +1,115 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+)
+
+func TestRecoverableSignatureConversion(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("sigformat"), 1)
+	require.NoError(err)
+	key := kc.Keys()[0]
+	hash := sha256.Sum256([]byte("payload"))
+	sig, err := key.SignHash(hash[:])
+	require.NoError(err)
+	require.NoError(ValidateRecoverableSignature(sig))
+
+	compact, err := RecoverableToCompact(sig)
+	require.NoError(err)
+	require.Equal(sig[:64], compact)
+	require.NoError(ValidateCompactSignature(SchemeSecp256k1, compact))
+	recovered, err := CompactToRecoverable(hash[:], compact, key.Address())
+	require.NoError(err)
+	require.Equal(sig, recovered)
+
+	der, err := RecoverableToDER(sig)
+	require.NoError(err)
+	require.NoError(ValidateDERSignature(SchemeSecp256k1, der))
+	recovered, err = DERToRecoverable(hash[:], der, key.Address())
+	require.NoError(err)
+	require.Equal(sig, recovered)
+
+	// Signatures of another key do not recover
+	_, err = DERToRecoverable(hash[:], der, ids.GenerateTestShortID())
+	require.ErrorIs(err, ErrSignatureRecovery)
+
+	// High-S signatures are converted as they are
+	malleated := highS(secp256k1.S256(), sig)
+	der, err = RecoverableToDER(malleated)
+	require.NoError(err)
+	recovered, err = DERToRecoverable(hash[:], der, key.Address())
+	require.NoError(err)
+	require.Equal(malleated, recovered)
+}
+
+func TestDERSignatureConversion(t *testing.T) {
+	require := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	hash := sha256.Sum256([]byte("payload"))
+	der, err := ecdsa.SignASN1(rand.Reader, key, hash[:])
+	require.NoError(err)
+
+	compact, err := DERToCompact(SchemeP256, der)
+	require.NoError(err)
+	require.Len(compact, 64)
+	r, s := new(big.Int).SetBytes(compact[:32]), new(big.Int).SetBytes(compact[32:])
+	require.True(ecdsa.Verify(&key.PublicKey, hash[:], r, s))
+
+	encoded, err := CompactToDER(SchemeP256, compact)
+	require.NoError(err)
+	require.Equal(der, encoded)
+}
+
+func TestSignatureFormatValidation(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("sigformat validation"), 1)
+	require.NoError(err)
+	hash := sha256.Sum256([]byte("payload"))
+	sig, err := kc.Keys()[0].SignHash(hash[:])
+	require.NoError(err)
+
+	require.ErrorIs(ValidateCompactSignature(SchemeEd25519, sig[:64]), ErrNotECDSAScheme)
+	require.ErrorIs(ValidateCompactSignature(SchemeSecp256k1, sig), ErrInvalidSignatureLen)
+	require.ErrorIs(ValidateRecoverableSignature(sig[:64]), ErrInvalidSignatureLen)
+	require.ErrorIs(ValidateDERSignature(SchemeP256, sig), ErrInvalidSignatureEncoding)
+
+	badV := bytes.Clone(sig)
+	badV[64] = 27
+	require.ErrorIs(ValidateRecoverableSignature(badV), ErrInvalidSignatureEncoding)
+	_, err = RecoverableToCompact(badV)
+	require.ErrorIs(err, ErrInvalidSignatureEncoding)
+
+	// r and s must be below the curve order
+	outOfRange := bytes.Clone(sig)
+	secp256k1.S256().Params().N.FillBytes(outOfRange[:32])
+	require.ErrorIs(ValidateRecoverableSignature(outOfRange), ErrInvalidSignatureEncoding)
+	_, err = CompactToDER(SchemeSecp256k1, outOfRange[:64])
+	require.ErrorIs(err, ErrInvalidSignatureEncoding)
+	zero := make([]byte, 64)
+	require.ErrorIs(ValidateCompactSignature(SchemeP256, zero), ErrInvalidSignatureEncoding)
+
+	// A secp256k1 s is out of range on P-256, whose order is smaller
+	p256Order := elliptic.P256().Params().N
+	large := bytes.Clone(sig[:64])
+	new(big.Int).Add(p256Order, big.NewInt(1)).FillBytes(large[32:])
+	require.NoError(ValidateCompactSignature(SchemeSecp256k1, large))
+	require.ErrorIs(ValidateCompactSignature(SchemeP256, large), ErrInvalidSignatureEncoding)
+}
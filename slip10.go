@@ -0,0 +1,90 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// slip10Ed25519MasterKey is the HMAC key deriving the SLIP-0010 ed25519
+// master key from a seed
+var slip10Ed25519MasterKey = []byte("ed25519 seed")
+
+var ErrNonHardenedEd25519 = errors.New("ed25519 derivation paths must be fully hardened")
+
+// Ed25519AddressPath returns the path m/44'/9000'/0'/0'/[addressIndex]' of
+// an ed25519 Lux address. SLIP-0010 only derives hardened ed25519 children, so
+// every element of the path is hardened.
+func Ed25519AddressPath(addressIndex uint32) DerivationPath {
+	return DerivationPath{
+		BIP44Purpose | HardenedOffset,
+		LuxCoinType | HardenedOffset,
+		HardenedOffset,
+		HardenedOffset,
+		addressIndex | HardenedOffset,
+	}
+}
+
+// DeriveEd25519Key derives the ed25519 key at [path] from the [seed] of a
+// mnemonic, following SLIP-0010. Every element of [path] must be hardened.
+func DeriveEd25519Key(seed []byte, path DerivationPath) (ed25519.PrivateKey, error) {
+	// SLIP-0010 seeds are between 128 and 512 bits, as BIP32 ones
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, ErrInvalidSeed
+	}
+	mac := hmac.New(sha512.New, slip10Ed25519MasterKey)
+	_, _ = mac.Write(seed)
+	sum := mac.Sum(nil)
+	defer clear(sum)
+
+	for _, index := range path {
+		if index < HardenedOffset {
+			return nil, fmt.Errorf("%w: %s", ErrNonHardenedEd25519, path)
+		}
+		data := make([]byte, 0, 37)
+		data = append(data, 0)
+		data = append(data, sum[:32]...)
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		mac := hmac.New(sha512.New, sum[32:])
+		_, _ = mac.Write(data)
+		clear(data)
+		clear(sum)
+		sum = mac.Sum(sum[:0])
+	}
+	return ed25519.NewKeyFromSeed(sum[:32]), nil
+}
+
+// NewEd25519MnemonicKeychain returns a keychain holding the ed25519 keys of
+// address [indices] of [mnemonic], derived at Ed25519AddressPath(i), so that
+// ed25519 accounts can be recovered from a mnemonic as secp256k1 ones are
+// with NewMnemonicKeychain
+func NewEd25519MnemonicKeychain(mnemonic, passphrase string, indices []uint32) (Keychain, error) {
+	if len(indices) == 0 {
+		return nil, ErrInvalidIndicesLength
+	}
+	seed, err := MnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(seed)
+
+	signers := make([]crypto.Signer, len(indices))
+	for i, idx := range indices {
+		if idx >= HardenedOffset {
+			return nil, fmt.Errorf("%w: address index %d", ErrInvalidDerivationPath, idx)
+		}
+		signers[i], err = DeriveEd25519Key(seed, Ed25519AddressPath(idx))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewEd25519Keychain(signers...)
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveEd25519KeySLIP10Vector(t *testing.T) {
+	// SLIP-0010 ed25519 test vector 1
+	seed, err := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	require.NoError(t, err)
+
+	tests := []struct {
+		path           string
+		expectedKey    string
+		expectedPubKey string
+	}{
+		{
+			path:           "m/0'",
+			expectedKey:    "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3",
+			expectedPubKey: "8c8a13df77a28f3445213a0f432fde644acaa215fc72dcdf300d5efaa85d350c",
+		},
+		{
+			path:           "m/0'/1'/2'/2'/1000000000'",
+			expectedKey:    "8f94d394a8e8fd6b1bc2f3f49f5c47e385281d5c17e65324b0f62483e37e8793",
+			expectedPubKey: "3c24da049451555d51a7014a37337aa4e12d41e485abccfa46b47dfb2af54b7a",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			require := require.New(t)
+
+			path, err := ParseDerivationPath(test.path)
+			require.NoError(err)
+			key, err := DeriveEd25519Key(seed, path)
+			require.NoError(err)
+			require.Equal(test.expectedKey, hex.EncodeToString(key.Seed()))
+			require.Equal(test.expectedPubKey, hex.EncodeToString(key.Public().(ed25519.PublicKey)))
+		})
+	}
+}
+
+func TestDeriveEd25519KeyErrors(t *testing.T) {
+	require := require.New(t)
+
+	_, err := DeriveEd25519Key(make([]byte, 15), Ed25519AddressPath(0))
+	require.ErrorIs(err, ErrInvalidSeed)
+	_, err = DeriveEd25519Key(make([]byte, 16), AddressPath(0))
+	require.ErrorIs(err, ErrNonHardenedEd25519)
+}
+
+func TestNewEd25519MnemonicKeychain(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewEd25519MnemonicKeychain(testMnemonic, "", []uint32{0, 1})
+	require.NoError(err)
+	require.Equal(2, kc.Addresses().Len())
+
+	// Recovering the mnemonic recovers the same accounts
+	seed, err := MnemonicSeed(testMnemonic, "")
+	require.NoError(err)
+	key, err := DeriveEd25519Key(seed, Ed25519AddressPath(1))
+	require.NoError(err)
+	signer, ok := kc.Get(Ed25519Address(key.Public().(ed25519.PublicKey)))
+	require.True(ok)
+
+	msg := []byte("recovered")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.NoError(VerifySignature(signer, msg, sig))
+
+	_, err = NewEd25519MnemonicKeychain(testMnemonic, "", nil)
+	require.ErrorIs(err, ErrInvalidIndicesLength)
+	_, err = NewEd25519MnemonicKeychain(testMnemonic, "", []uint32{HardenedOffset})
+	require.ErrorIs(err, ErrInvalidDerivationPath)
+	_, err = NewEd25519MnemonicKeychain("not a mnemonic", "", []uint32{0})
+	require.ErrorIs(err, ErrInvalidMnemonic)
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"slices"
+)
+
+// socketMode restricts a keychain socket to the user running the daemon
+const socketMode fs.FileMode = 0o600
+
+var (
+	ErrPeerCredentialsUnsupported = errors.New("peer credentials are not supported on this platform")
+	ErrNotUnixConn                = errors.New("connection is not a Unix socket")
+)
+
+// PeerCredentials identify the process at the other end of a Unix socket, as
+// reported by the kernel
+type PeerCredentials struct {
+	// PID is the process ID of the peer, or 0 if the platform does not report
+	// it
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// ListenSocket listens on the Unix socket at [path] for a keychain daemon
+// serving a SignerServer. A socket left behind by a previous daemon is
+// replaced, and the new socket is only accessible to the current user: on
+// Unix systems it is created with these permissions, which the umask is set
+// to while listening, so that it is never accessible to other users.
+// Access is also checked per connection with AllowPeerUIDs, which does not
+// depend on the permissions of the file.
+func ListenSocket(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := listenUnix(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, socketMode); err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// PeerCredentialsOf returns the credentials of the peer of [conn], which
+// must be a Unix socket
+func PeerCredentialsOf(conn net.Conn) (PeerCredentials, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return PeerCredentials{}, ErrNotUnixConn
+	}
+	return peerCredentials(unixConn)
+}
+
+// AllowPeerUIDs returns a SignerServerConfig.Authorize function that only
+// serves Unix socket peers running as one of [uids]. Without [uids], only
+// processes of the current user are served.
+func AllowPeerUIDs(uids ...uint32) func(net.Conn) error {
+	if len(uids) == 0 {
+		uids = []uint32{uint32(os.Getuid())}
+	}
+	uids = slices.Clone(uids)
+	return func(conn net.Conn) error {
+		creds, err := PeerCredentialsOf(conn)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrPeerNotAllowed, err)
+		}
+		if !slices.Contains(uids, creds.UID) {
+			return fmt.Errorf("%w: uid %d", ErrPeerNotAllowed, creds.UID)
+		}
+		return nil
+	}
+}
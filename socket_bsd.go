@@ -0,0 +1,38 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build darwin || freebsd
+
+package keychain
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerCredentials reads the LOCAL_PEERCRED credentials of [conn], which do
+// not include the process ID
+func peerCredentials(conn *net.UnixConn) (PeerCredentials, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return PeerCredentials{}, err
+	}
+	var (
+		xucred  *unix.Xucred
+		credErr error
+	)
+	if err := raw.Control(func(fd uintptr) {
+		xucred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	}); err != nil {
+		return PeerCredentials{}, err
+	}
+	if credErr != nil {
+		return PeerCredentials{}, credErr
+	}
+	creds := PeerCredentials{UID: xucred.Uid}
+	if xucred.Ngroups > 0 {
+		creds.GID = xucred.Groups[0]
+	}
+	return creds, nil
+}
@@ -0,0 +1,35 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerCredentials reads the SO_PEERCRED credentials of [conn]
+func peerCredentials(conn *net.UnixConn) (PeerCredentials, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return PeerCredentials{}, err
+	}
+	var (
+		ucred   *unix.Ucred
+		credErr error
+	)
+	if err := raw.Control(func(fd uintptr) {
+		ucred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return PeerCredentials{}, err
+	}
+	if credErr != nil {
+		return PeerCredentials{}, credErr
+	}
+	return PeerCredentials{
+		PID: ucred.Pid,
+		UID: ucred.Uid,
+		GID: ucred.Gid,
+	}, nil
+}
@@ -0,0 +1,18 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build !linux && !darwin && !freebsd
+
+package keychain
+
+import "net"
+
+// listenUnix listens on the Unix socket at [path], whose permissions are set
+// by ListenSocket once it is created
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
+
+func peerCredentials(*net.UnixConn) (PeerCredentials, error) {
+	return PeerCredentials{}, ErrPeerCredentialsUnsupported
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build linux || darwin || freebsd
+
+package keychain
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/stretchr/testify/require"
+)
+
+// socketPath returns a path for a Unix socket short enough for sun_path
+func socketPath(t *testing.T) string {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "kc")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return filepath.Join(dir, "keychain.sock")
+}
+
+func TestSocketDaemon(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("daemon"), 2)
+	require.NoError(err)
+	path := socketPath(t)
+
+	// A socket left behind by a previous daemon is replaced
+	stale, err := net.Listen("unix", path)
+	require.NoError(err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(stale.Close())
+
+	l, err := ListenSocket(path)
+	require.NoError(err)
+	info, err := os.Stat(path)
+	require.NoError(err)
+	require.Equal(socketMode, info.Mode().Perm())
+
+	server := NewSignerServer(kc, SignerServerConfig{Authorize: AllowPeerUIDs()})
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Serve(l)
+	}()
+
+	// Two processes share the unlocked keychain
+	for range 2 {
+		conn, err := net.Dial("unix", path)
+		require.NoError(err)
+		remote, err := NewRemoteKeychain(conn)
+		require.NoError(err)
+		require.Equal(kc.Addresses(), remote.Addresses())
+
+		key := kc.Keys()[0]
+		signer, ok := remote.Get(key.Address())
+		require.True(ok)
+		msg := []byte("sign via socket")
+		sig, err := signer.Sign(msg)
+		require.NoError(err)
+		require.True(key.PublicKey().Verify(msg, sig))
+		require.NoError(conn.Close())
+	}
+
+	require.NoError(server.Close())
+	require.ErrorIs(<-done, ErrServerClosed)
+	_, err = os.Stat(path)
+	require.ErrorIs(err, os.ErrNotExist)
+}
+
+func TestListenSocketUmask(t *testing.T) {
+	require := require.New(t)
+
+	// The socket is created without the permissions of a permissive umask
+	umask := unix.Umask(0)
+	defer unix.Umask(umask)
+	path := socketPath(t)
+	l, err := listenUnix(path)
+	require.NoError(err)
+	defer l.Close()
+	info, err := os.Stat(path)
+	require.NoError(err)
+	require.Equal(socketMode, info.Mode().Perm())
+
+	// The umask of the process is restored
+	require.Zero(unix.Umask(umask))
+}
+
+func TestPeerCredentials(t *testing.T) {
+	require := require.New(t)
+
+	l, err := ListenSocket(socketPath(t))
+	require.NoError(err)
+	defer l.Close()
+
+	client, err := net.Dial("unix", l.Addr().String())
+	require.NoError(err)
+	defer client.Close()
+	conn, err := l.Accept()
+	require.NoError(err)
+	defer conn.Close()
+
+	creds, err := PeerCredentialsOf(conn)
+	require.NoError(err)
+	require.Equal(uint32(os.Getuid()), creds.UID)
+	require.NoError(AllowPeerUIDs()(conn))
+	require.ErrorIs(AllowPeerUIDs(creds.UID+1)(conn), ErrPeerNotAllowed)
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	defer tcp.Close()
+	tcpConn, err := net.Dial("tcp", tcp.Addr().String())
+	require.NoError(err)
+	defer tcpConn.Close()
+	_, err = PeerCredentialsOf(tcpConn)
+	require.ErrorIs(err, ErrNotUnixConn)
+	require.ErrorIs(AllowPeerUIDs()(tcpConn), ErrPeerNotAllowed)
+}
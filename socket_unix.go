@@ -0,0 +1,29 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build linux || darwin || freebsd
+
+package keychain
+
+import (
+	"net"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// umaskLock serializes the umask changes of listenUnix, as the umask is
+// shared by the whole process
+var umaskLock sync.Mutex
+
+// listenUnix listens on the Unix socket at [path], which is created with
+// socketMode rather than the permissions of the umask of the process, so
+// that it is never accessible to other users
+func listenUnix(path string) (net.Listener, error) {
+	umaskLock.Lock()
+	defer umaskLock.Unlock()
+
+	umask := unix.Umask(0o777 &^ int(socketMode))
+	defer unix.Umask(umask)
+	return net.Listen("unix", path)
+}
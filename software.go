@@ -0,0 +1,422 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/sha256"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var (
+	ErrUnknownAddress = errors.New("address is not part of the keychain")
+	ErrKeyRetired     = errors.New("key has been retired")
+
+	_ Keychain        = (*SoftwareKeychain)(nil)
+	_ PublicKeySigner = (*softwareSigner)(nil)
+)
+
+// SoftwareKeychain is a mutable keychain of in-memory secp256k1 private keys.
+//
+// Keys can be retired, for example when they are rotated. A retired key is
+// kept so that it can still be looked up, but it is no longer reported by
+// Addresses and its signer refuses to sign.
+//
+// Keys can also be given a validity window, outside of which their signers
+// refuse to sign.
+//
+// Private keys are held in locked memory guarded by inaccessible pages where
+// the platform supports it, and are only materialized for the duration of a
+// signing operation. Keys passed to or returned by the keychain are copies
+// outside of that memory, which callers should wipe with WipeKey. Destroy
+// wipes every key held by the keychain; keys that are removed or garbage
+// collected are wiped as well. Keychains created with
+// NewSealedSoftwareKeychain also keep their idle keys encrypted.
+//
+// Changes to the keychain's addresses are reported to subscribers.
+type SoftwareKeychain struct {
+	now    func() time.Time
+	events eventFeed
+	// mnemonic is the mnemonic the keys were derived from, if any
+	mnemonic *mnemonicSource
+	// sealer, if set, seals the idle keys
+	sealer *keySealer
+
+	lock     sync.RWMutex
+	addrs    set.Set[ids.ShortID]
+	retired  set.Set[ids.ShortID]
+	validity map[ids.ShortID]Validity
+	keys     map[ids.ShortID]*lockedKey
+}
+
+// lockedKey is a private key held in a secure buffer
+type lockedKey struct {
+	secret *secureBuffer
+	pubKey *secp256k1.PublicKey
+	// sealer, if set, sealed the key held by secret
+	sealer *keySealer
+	addr   ids.ShortID
+}
+
+func newLockedKey(key *secp256k1.PrivateKey, sealer *keySealer) *lockedKey {
+	k := &lockedKey{
+		pubKey: key.PublicKey(),
+		sealer: sealer,
+		addr:   key.Address(),
+	}
+	if sealer == nil {
+		k.secret = newSecureBuffer(key.Bytes())
+	} else {
+		k.secret = newSecureBuffer(sealer.seal(key.Bytes(), k.addr))
+	}
+	return k
+}
+
+// unlock calls [f] with the private key bytes, opening them if the key is
+// sealed
+func (k *lockedKey) unlock(f func(secret []byte) error) error {
+	return k.secret.use(func(secret []byte) error {
+		if k.sealer == nil {
+			return f(secret)
+		}
+		return k.sealer.open(secret, k.addr, f)
+	})
+}
+
+// use calls [f] with a temporary copy of the private key, which is wiped
+// once [f] returns
+func (k *lockedKey) use(f func(*secp256k1.PrivateKey) error) error {
+	return k.unlock(func(secret []byte) error {
+		key, err := secp256k1.ToPrivateKey(secret)
+		if err != nil {
+			return err
+		}
+		defer WipeKey(key)
+		return f(key)
+	})
+}
+
+// privateKey returns a copy of the private key that is not wiped
+func (k *lockedKey) privateKey() (*secp256k1.PrivateKey, error) {
+	var key *secp256k1.PrivateKey
+	err := k.unlock(func(secret []byte) error {
+		var err error
+		key, err = secp256k1.ToPrivateKey(secret)
+		return err
+	})
+	return key, err
+}
+
+// NewSoftwareKeychain creates a software keychain holding [keys]
+func NewSoftwareKeychain(keys ...*secp256k1.PrivateKey) *SoftwareKeychain {
+	kc := newSoftwareKeychain(nil)
+	for _, key := range keys {
+		kc.Add(key)
+	}
+	return kc
+}
+
+func newSoftwareKeychain(sealer *keySealer) *SoftwareKeychain {
+	return &SoftwareKeychain{
+		now:      time.Now,
+		sealer:   sealer,
+		addrs:    make(set.Set[ids.ShortID]),
+		retired:  make(set.Set[ids.ShortID]),
+		validity: make(map[ids.ShortID]Validity),
+		keys:     make(map[ids.ShortID]*lockedKey),
+	}
+}
+
+// Add a copy of [key] to the keychain. Adding a retired key reactivates it.
+func (kc *SoftwareKeychain) Add(key *secp256k1.PrivateKey) {
+	addr := key.Address()
+
+	kc.lock.Lock()
+	defer kc.lock.Unlock()
+
+	if kc.addrs.Contains(addr) {
+		return
+	}
+	kc.addrs.Add(addr)
+	kc.retired.Remove(addr)
+	if _, ok := kc.keys[addr]; !ok {
+		kc.keys[addr] = newLockedKey(key, kc.sealer)
+	}
+	kc.events.publish(Event{Type: EventAdded, Address: addr})
+}
+
+// New generates a new key, adds it to the keychain and returns a copy of it
+func (kc *SoftwareKeychain) New() (*secp256k1.PrivateKey, error) {
+	key, err := secp256k1.NewPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	kc.Add(key)
+	return key, nil
+}
+
+// Remove the key of [addr] from the keychain. Returns false if the keychain
+// did not hold the address.
+func (kc *SoftwareKeychain) Remove(addr ids.ShortID) bool {
+	kc.lock.Lock()
+	defer kc.lock.Unlock()
+
+	key, ok := kc.keys[addr]
+	if !ok {
+		return false
+	}
+	_ = key.secret.destroy()
+	kc.addrs.Remove(addr)
+	kc.retired.Remove(addr)
+	delete(kc.validity, addr)
+	delete(kc.keys, addr)
+	kc.events.publish(Event{Type: EventRemoved, Address: addr})
+	return true
+}
+
+// Destroy wipes every key held by the keychain and empties it. Signers
+// obtained from the keychain fail with ErrKeyDestroyed afterwards.
+// ErrSecureMemoryCorrupted is returned if the memory of a key was found to
+// have been overwritten.
+func (kc *SoftwareKeychain) Destroy() error {
+	kc.lock.Lock()
+	defer kc.lock.Unlock()
+
+	var (
+		errs   []error
+		events = make([]Event, 0, len(kc.keys))
+	)
+	for addr, key := range kc.keys {
+		if err := key.secret.destroy(); err != nil {
+			errs = append(errs, err)
+		}
+		events = append(events, Event{Type: EventRemoved, Address: addr})
+	}
+	if kc.mnemonic != nil && kc.mnemonic.entropy != nil {
+		if err := kc.mnemonic.entropy.destroy(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	kc.addrs.Clear()
+	kc.retired.Clear()
+	clear(kc.validity)
+	clear(kc.keys)
+	kc.events.publish(events...)
+	return errors.Join(errs...)
+}
+
+// Rotate generates a replacement for the key of [addr], retires the old key
+// and returns the address of the new key
+func (kc *SoftwareKeychain) Rotate(addr ids.ShortID) (ids.ShortID, error) {
+	key, err := secp256k1.NewPrivateKey()
+	if err != nil {
+		return ids.ShortEmpty, err
+	}
+	defer WipeKey(key)
+	newAddr := key.Address()
+
+	kc.lock.Lock()
+	defer kc.lock.Unlock()
+
+	if err := kc.retire(addr); err != nil {
+		return ids.ShortEmpty, err
+	}
+	kc.addrs.Add(newAddr)
+	kc.keys[newAddr] = newLockedKey(key, kc.sealer)
+	kc.events.publish(Event{
+		Type:     EventRotated,
+		Address:  newAddr,
+		Previous: addr,
+	})
+	return newAddr, nil
+}
+
+// Retire the key of [addr] without replacing it
+func (kc *SoftwareKeychain) Retire(addr ids.ShortID) error {
+	kc.lock.Lock()
+	defer kc.lock.Unlock()
+
+	if err := kc.retire(addr); err != nil {
+		return err
+	}
+	kc.events.publish(Event{Type: EventRetired, Address: addr})
+	return nil
+}
+
+// Subscribe returns a channel receiving the changes made to the keychain's
+// addresses
+func (kc *SoftwareKeychain) Subscribe() <-chan Event {
+	return kc.events.subscribe()
+}
+
+func (kc *SoftwareKeychain) Unsubscribe(ch <-chan Event) {
+	kc.events.unsubscribe(ch)
+}
+
+// Retired returns true if the key of [addr] has been retired
+func (kc *SoftwareKeychain) Retired(addr ids.ShortID) bool {
+	kc.lock.RLock()
+	defer kc.lock.RUnlock()
+
+	return kc.retired.Contains(addr)
+}
+
+// RetiredAddresses returns a copy of the set of retired addresses
+func (kc *SoftwareKeychain) RetiredAddresses() set.Set[ids.ShortID] {
+	kc.lock.RLock()
+	defer kc.lock.RUnlock()
+
+	return set.Of(kc.retired.List()...)
+}
+
+// retire assumes the lock is held
+func (kc *SoftwareKeychain) retire(addr ids.ShortID) error {
+	if _, ok := kc.keys[addr]; !ok {
+		return ErrUnknownAddress
+	}
+	if kc.retired.Contains(addr) {
+		return ErrKeyRetired
+	}
+	kc.addrs.Remove(addr)
+	kc.retired.Add(addr)
+	return nil
+}
+
+// Get a signer for the key of [addr]. The signer of a retired key reports
+// its address and public key but does not sign, and the signer of a key
+// outside of its validity window fails with ErrKeyExpired or
+// ErrKeyNotYetValid unless it is wrapped with AllowExpired.
+func (kc *SoftwareKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	kc.lock.RLock()
+	defer kc.lock.RUnlock()
+
+	key, ok := kc.keys[addr]
+	if !ok {
+		return nil, false
+	}
+	return &softwareSigner{
+		keychain: kc,
+		key:      key,
+		addr:     addr,
+	}, true
+}
+
+// GetKey returns a copy of the private key of [addr]
+func (kc *SoftwareKeychain) GetKey(addr ids.ShortID) (*secp256k1.PrivateKey, bool) {
+	kc.lock.RLock()
+	defer kc.lock.RUnlock()
+
+	key, ok := kc.keys[addr]
+	if !ok {
+		return nil, false
+	}
+	privKey, err := key.privateKey()
+	return privKey, err == nil
+}
+
+// Addresses returns a copy of the set of active addresses held by the
+// keychain
+func (kc *SoftwareKeychain) Addresses() set.Set[ids.ShortID] {
+	kc.lock.RLock()
+	defer kc.lock.RUnlock()
+
+	return set.Of(kc.addrs.List()...)
+}
+
+// Keys returns copies of the private keys held by the keychain, including
+// retired keys, ordered by address
+func (kc *SoftwareKeychain) Keys() []*secp256k1.PrivateKey {
+	kc.lock.RLock()
+	defer kc.lock.RUnlock()
+
+	addrs := slices.Collect(maps.Keys(kc.keys))
+	slices.SortFunc(addrs, ids.ShortID.Compare)
+	keys := make([]*secp256k1.PrivateKey, 0, len(addrs))
+	for _, addr := range addrs {
+		key, err := kc.keys[addr].privateKey()
+		if err != nil {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// canSign returns an error if the key of [addr] must not be used to sign
+func (kc *SoftwareKeychain) canSign(addr ids.ShortID, ignoreValidity bool) error {
+	kc.lock.RLock()
+	defer kc.lock.RUnlock()
+
+	if kc.retired.Contains(addr) {
+		return ErrKeyRetired
+	}
+	if ignoreValidity {
+		return nil
+	}
+	return kc.validity[addr].Check(kc.now())
+}
+
+// softwareSigner signs with a private key held by a software keychain
+type softwareSigner struct {
+	keychain       *SoftwareKeychain
+	key            *lockedKey
+	addr           ids.ShortID
+	ignoreValidity bool
+	// schnorr signs with BIP340 Schnorr signatures instead of ECDSA
+	schnorr bool
+}
+
+func (s *softwareSigner) SignHash(hash []byte) ([]byte, error) {
+	return s.sign(func(key *secp256k1.PrivateKey) ([]byte, error) {
+		if s.schnorr {
+			return schnorrSign(key, hash)
+		}
+		return key.SignHash(hash)
+	})
+}
+
+func (s *softwareSigner) Sign(msg []byte) ([]byte, error) {
+	if s.schnorr {
+		hash := sha256.Sum256(msg)
+		return s.SignHash(hash[:])
+	}
+	return s.sign(func(key *secp256k1.PrivateKey) ([]byte, error) {
+		return key.Sign(msg)
+	})
+}
+
+func (s *softwareSigner) sign(f func(*secp256k1.PrivateKey) ([]byte, error)) ([]byte, error) {
+	if err := s.keychain.canSign(s.addr, s.ignoreValidity); err != nil {
+		return nil, err
+	}
+	var sig []byte
+	err := s.key.use(func(key *secp256k1.PrivateKey) error {
+		var err error
+		sig, err = f(key)
+		return err
+	})
+	return sig, err
+}
+
+func (s *softwareSigner) Address() ids.ShortID {
+	return s.addr
+}
+
+func (s *softwareSigner) PublicKey() []byte {
+	return s.key.pubKey.Bytes()
+}
+
+func (s *softwareSigner) Scheme() SchemeID {
+	if s.schnorr {
+		return SchemeSchnorr
+	}
+	return SchemeSecp256k1
+}
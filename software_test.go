@@ -0,0 +1,115 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSoftwareKeychain(t *testing.T) {
+	require := require.New(t)
+
+	kc := NewSoftwareKeychain()
+	require.Zero(kc.Addresses().Len())
+
+	key, err := kc.New()
+	require.NoError(err)
+	addr := key.Address()
+	require.True(kc.Addresses().Contains(addr))
+
+	signer, ok := kc.Get(addr)
+	require.True(ok)
+	require.Equal(addr, signer.Address())
+	require.Equal(key.PublicKey().Bytes(), signer.(PublicKeySigner).PublicKey())
+
+	hash := sha256.Sum256([]byte("payload"))
+	sig, err := signer.SignHash(hash[:])
+	require.NoError(err)
+	require.Len(sig, secp256k1.SignatureLen)
+	require.True(key.PublicKey().VerifyHash(hash[:], sig))
+
+	sig, err = signer.Sign([]byte("payload"))
+	require.NoError(err)
+	require.True(key.PublicKey().Verify([]byte("payload"), sig))
+
+	// The returned address set is a copy
+	kc.Addresses().Clear()
+	require.Equal(1, kc.Addresses().Len())
+
+	require.True(kc.Remove(addr))
+	require.False(kc.Remove(addr))
+	_, ok = kc.Get(addr)
+	require.False(ok)
+}
+
+func TestSoftwareKeychainKeysOrdered(t *testing.T) {
+	require := require.New(t)
+
+	kc := NewSoftwareKeychain()
+	for range 5 {
+		_, err := kc.New()
+		require.NoError(err)
+	}
+
+	keys := kc.Keys()
+	require.Len(keys, 5)
+	for i := 1; i < len(keys); i++ {
+		require.Negative(keys[i-1].Address().Compare(keys[i].Address()))
+	}
+}
+
+func TestSoftwareKeychainRotate(t *testing.T) {
+	require := require.New(t)
+
+	kc := NewSoftwareKeychain()
+	key, err := kc.New()
+	require.NoError(err)
+	oldAddr := key.Address()
+
+	oldSigner, ok := kc.Get(oldAddr)
+	require.True(ok)
+
+	newAddr, err := kc.Rotate(oldAddr)
+	require.NoError(err)
+	require.NotEqual(oldAddr, newAddr)
+	require.True(kc.Addresses().Equals(set.Of(newAddr)))
+	require.True(kc.RetiredAddresses().Equals(set.Of(oldAddr)))
+	require.True(kc.Retired(oldAddr))
+	require.False(kc.Retired(newAddr))
+
+	// The retired key can still be looked up but no longer signs
+	_, err = oldSigner.Sign([]byte("payload"))
+	require.ErrorIs(err, ErrKeyRetired)
+	signer, ok := kc.Get(oldAddr)
+	require.True(ok)
+	require.Equal(key.PublicKey().Bytes(), signer.(PublicKeySigner).PublicKey())
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrKeyRetired)
+	retiredKey, ok := kc.GetKey(oldAddr)
+	require.True(ok)
+	require.Equal(key, retiredKey)
+	require.Len(kc.Keys(), 2)
+
+	signer, ok = kc.Get(newAddr)
+	require.True(ok)
+	_, err = signer.Sign([]byte("payload"))
+	require.NoError(err)
+
+	_, err = kc.Rotate(oldAddr)
+	require.ErrorIs(err, ErrKeyRetired)
+	_, err = kc.Rotate(ids.GenerateTestShortID())
+	require.ErrorIs(err, ErrUnknownAddress)
+
+	// Adding a retired key reactivates it
+	kc.Add(key)
+	require.False(kc.Retired(oldAddr))
+	_, err = oldSigner.Sign([]byte("payload"))
+	require.NoError(err)
+}
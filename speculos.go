@@ -0,0 +1,81 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSpeculosAddr is the default address of the Speculos APDU server
+	DefaultSpeculosAddr = "127.0.0.1:9999"
+
+	// maxSpeculosResponseLen bounds the response length announced by the
+	// emulator
+	maxSpeculosResponseLen = 1 << 16
+)
+
+var _ Transport = (*speculosTransport)(nil)
+
+// speculosTransport exchanges APDUs with the Speculos ledger emulator over
+// its TCP APDU server. Each command is sent as a 4 byte big endian length
+// followed by the APDU. Each response is a 4 byte big endian length, the
+// response data and the 2 byte status word, which is not counted in the
+// length.
+type speculosTransport struct {
+	lock sync.Mutex
+	conn net.Conn
+}
+
+// DialSpeculos connects to the APDU server of a Speculos emulator running
+// the Lux app, so that the APDU encoding can be exercised without hardware:
+//
+//	transport, err := keychain.DialSpeculos(keychain.DefaultSpeculosAddr, time.Second)
+//	...
+//	kc, err := keychain.NewLedgerKeychain(keychain.NewLedgerDevice(transport), indices)
+func DialSpeculos(addr string, timeout time.Duration) (Transport, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return NewSpeculosTransport(conn), nil
+}
+
+// NewSpeculosTransport speaks the Speculos APDU framing over [conn]
+func NewSpeculosTransport(conn net.Conn) Transport {
+	return &speculosTransport{conn: conn}
+}
+
+func (s *speculosTransport) Exchange(apdu []byte) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	msg := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(apdu)), uint32(len(apdu)))
+	msg = append(msg, apdu...)
+	if _, err := s.conn.Write(msg); err != nil {
+		return nil, err
+	}
+
+	var header [4]byte
+	if _, err := io.ReadFull(s.conn, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n > maxSpeculosResponseLen {
+		return nil, ErrInvalidResponse
+	}
+	resp := make([]byte, n+2)
+	if _, err := io.ReadFull(s.conn, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *speculosTransport) Close() error {
+	return s.conn.Close()
+}
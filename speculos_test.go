@@ -0,0 +1,82 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// serveSpeculos serves [app] with the Speculos APDU framing on a local
+// listener and returns its address
+func serveSpeculos(t *testing.T, app Transport) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var header [4]byte
+			if _, err := io.ReadFull(conn, header[:]); err != nil {
+				return
+			}
+			apdu := make([]byte, binary.BigEndian.Uint32(header[:]))
+			if _, err := io.ReadFull(conn, apdu); err != nil {
+				return
+			}
+			resp, err := app.Exchange(apdu)
+			if err != nil {
+				return
+			}
+			data := resp[:len(resp)-2]
+			msg := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+			if _, err := conn.Write(append(msg, resp...)); err != nil {
+				return
+			}
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestSpeculosTransport(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	transport, err := DialSpeculos(serveSpeculos(t, app), time.Second)
+	require.NoError(err)
+
+	device := NewLedgerDevice(transport)
+	version, err := device.Version()
+	require.NoError(err)
+	require.Equal(LedgerVersion{Major: 1, Minor: 2, Patch: 3}, version)
+
+	kc, err := NewLedgerKeychain(device, []uint32{0, 1, 2})
+	require.NoError(err)
+	require.Equal(3, kc.Addresses().Len())
+
+	key, err := DeterministicKey(app.seed, 2)
+	require.NoError(err)
+	signer, ok := kc.Get(key.Address())
+	require.True(ok)
+	sig, err := signer.Sign([]byte("tx"))
+	require.NoError(err)
+	require.True(key.PublicKey().Verify([]byte("tx"), sig))
+
+	require.NoError(device.Disconnect())
+}
@@ -0,0 +1,244 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/luxfi/ids"
+)
+
+// defaultSpendingWindow is the rolling window of a spending policy that does
+// not configure one
+const defaultSpendingWindow = 24 * time.Hour
+
+var ErrSpendingLimitExceeded = errors.New("spending limit exceeded")
+
+// TxAmountDecoder returns the amount [addr] spends with the unsigned
+// transaction [unsignedTx], such as the sum of its outputs that do not return
+// change to the signer. Returning an error rejects the transaction.
+type TxAmountDecoder func(addr ids.ShortID, unsignedTx []byte) (uint64, error)
+
+// SpendingLimit bounds the amount an address signs for. Zero fields impose no
+// bound.
+type SpendingLimit struct {
+	// PerTransaction is the largest amount of a single transaction
+	PerTransaction uint64
+	// Window is the largest total amount of the transactions signed within
+	// the rolling window of the policy
+	Window uint64
+}
+
+func (l SpendingLimit) unlimited() bool {
+	return l.PerTransaction == 0 && l.Window == 0
+}
+
+// SpendingPolicyConfig configures a SpendingPolicy
+type SpendingPolicyConfig struct {
+	// Amount decodes the amount of every transaction signed by an address
+	// with a limit
+	Amount TxAmountDecoder
+	// Limits are the limits of individual addresses
+	Limits map[ids.ShortID]SpendingLimit
+	// Default is the limit of the addresses that are not in Limits
+	Default SpendingLimit
+	// Window is the length of the rolling window of SpendingLimit.Window,
+	// 24 hours if zero
+	Window time.Duration
+	// AllowBlindSigning permits SignHash for addresses with a limit, although
+	// the amount of a hash cannot be decoded
+	AllowBlindSigning bool
+}
+
+// Allowance reports how much an address may still sign for
+type Allowance struct {
+	Limit SpendingLimit
+	// Spent is the total amount signed within the current window
+	Spent uint64
+	// Remaining is the largest amount the next transaction may spend, or
+	// math.MaxUint64 if the address is not limited
+	Remaining uint64
+	// Reset is when the oldest transaction of the window leaves it, which
+	// raises Remaining, or the zero time if the window is empty
+	Reset time.Time
+}
+
+// SpendingLimitError reports a transaction rejected by a SpendingPolicy. It
+// matches ErrSpendingLimitExceeded.
+type SpendingLimitError struct {
+	Address   ids.ShortID
+	Amount    uint64
+	Allowance Allowance
+}
+
+func (e *SpendingLimitError) Error() string {
+	return fmt.Sprintf("%s: %s requested %d with %d remaining", ErrSpendingLimitExceeded, e.Address, e.Amount, e.Allowance.Remaining)
+}
+
+func (*SpendingLimitError) Unwrap() error {
+	return ErrSpendingLimitExceeded
+}
+
+// spend is a transaction counted against the window of an address
+type spend struct {
+	at     time.Time
+	amount uint64
+}
+
+// SpendingPolicy limits the value the signers it wraps sign for, per
+// transaction and over a rolling window, based on the amounts decoded from
+// the transactions passed to Sign. A transaction counts against the window
+// once it is signed; transactions that fail to sign do not.
+type SpendingPolicy struct {
+	config SpendingPolicyConfig
+	now    func() time.Time
+
+	lock   sync.Mutex
+	spends map[ids.ShortID][]*spend
+}
+
+// NewSpendingPolicy creates a policy enforcing the limits of [config]. Its
+// signers are obtained by wrapping a keychain with Middleware.
+func NewSpendingPolicy(config SpendingPolicyConfig) *SpendingPolicy {
+	if config.Window <= 0 {
+		config.Window = defaultSpendingWindow
+	}
+	return &SpendingPolicy{
+		config: config,
+		now:    time.Now,
+		spends: make(map[ids.ShortID][]*spend),
+	}
+}
+
+// Middleware returns a middleware enforcing the policy. Transactions over
+// the allowance of their signer fail with a *SpendingLimitError. Unless blind
+// signing is allowed, SignHash of an address with a limit fails with
+// ErrBlindSigningDisabled.
+func (p *SpendingPolicy) Middleware() Middleware {
+	return Intercept(p.intercept)
+}
+
+// Allowance returns the current allowance of [addr]
+func (p *SpendingPolicy) Allowance(addr ids.ShortID) Allowance {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.allowance(addr, p.now())
+}
+
+func (p *SpendingPolicy) intercept(signer Signer, op SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+	addr := signer.Address()
+	if p.limit(addr).unlimited() {
+		return next(payload)
+	}
+	if op == OpSignHash {
+		if !p.config.AllowBlindSigning {
+			return nil, ErrBlindSigningDisabled
+		}
+		return next(payload)
+	}
+
+	if p.config.Amount == nil {
+		return nil, fmt.Errorf("%w: no amount decoder configured", ErrTransactionRejected)
+	}
+	amount, err := p.config.Amount(addr, payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTransactionRejected, err)
+	}
+	s, err := p.reserve(addr, amount)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := next(payload)
+	if err != nil {
+		p.release(addr, s)
+	}
+	return sig, err
+}
+
+// reserve counts [amount] against the window of [addr] if it is within the
+// allowance, so that concurrent transactions cannot exceed it together
+func (p *SpendingPolicy) reserve(addr ids.ShortID, amount uint64) (*spend, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.now()
+	allowance := p.allowance(addr, now)
+	if amount > allowance.Remaining {
+		return nil, &SpendingLimitError{
+			Address:   addr,
+			Amount:    amount,
+			Allowance: allowance,
+		}
+	}
+	s := &spend{
+		at:     now,
+		amount: amount,
+	}
+	p.spends[addr] = append(p.spends[addr], s)
+	return s, nil
+}
+
+// release removes the reservation [s] of a transaction that was not signed
+func (p *SpendingPolicy) release(addr ids.ShortID, s *spend) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.spends[addr] = slices.DeleteFunc(p.spends[addr], func(other *spend) bool {
+		return other == s
+	})
+}
+
+func (p *SpendingPolicy) limit(addr ids.ShortID) SpendingLimit {
+	if limit, ok := p.config.Limits[addr]; ok {
+		return limit
+	}
+	return p.config.Default
+}
+
+// allowance drops the spends of [addr] that left the window and reports the
+// remaining allowance. The lock must be held.
+func (p *SpendingPolicy) allowance(addr ids.ShortID, now time.Time) Allowance {
+	limit := p.limit(addr)
+	start := now.Add(-p.config.Window)
+	spends := slices.DeleteFunc(p.spends[addr], func(s *spend) bool {
+		return !s.at.After(start)
+	})
+	if len(spends) == 0 {
+		delete(p.spends, addr)
+	} else {
+		p.spends[addr] = spends
+	}
+
+	allowance := Allowance{
+		Limit:     limit,
+		Remaining: math.MaxUint64,
+	}
+	for _, s := range spends {
+		if s.amount > math.MaxUint64-allowance.Spent {
+			allowance.Spent = math.MaxUint64
+			break
+		}
+		allowance.Spent += s.amount
+	}
+	if len(spends) > 0 {
+		allowance.Reset = spends[0].at.Add(p.config.Window)
+	}
+	if limit.PerTransaction != 0 {
+		allowance.Remaining = limit.PerTransaction
+	}
+	if limit.Window != 0 {
+		var windowRemaining uint64
+		if allowance.Spent < limit.Window {
+			windowRemaining = limit.Window - allowance.Spent
+		}
+		allowance.Remaining = min(allowance.Remaining, windowRemaining)
+	}
+	return allowance
+}
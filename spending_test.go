@@ -0,0 +1,145 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+)
+
+var errNoAmount = errors.New("transaction has no amount")
+
+// amountTx encodes a test transaction spending [amount]
+func amountTx(amount uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, amount)
+}
+
+func decodeAmountTx(_ ids.ShortID, unsignedTx []byte) (uint64, error) {
+	if len(unsignedTx) != 8 {
+		return 0, errNoAmount
+	}
+	return binary.BigEndian.Uint64(unsignedTx), nil
+}
+
+func TestSpendingPolicy(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("spending"), 3)
+	require.NoError(err)
+	keys := kc.Keys()
+	policy := NewSpendingPolicy(SpendingPolicyConfig{
+		Amount: decodeAmountTx,
+		Limits: map[ids.ShortID]SpendingLimit{
+			keys[0].Address(): {PerTransaction: 100, Window: 250},
+			keys[1].Address(): {},
+		},
+		Default: SpendingLimit{PerTransaction: 10},
+		Window:  time.Hour,
+	})
+	now := time.Now()
+	policy.now = func() time.Time { return now }
+	limited := Wrap(kc, policy.Middleware())
+
+	signer, ok := limited.Get(keys[0].Address())
+	require.True(ok)
+	_, err = signer.Sign(amountTx(101))
+	var limitErr *SpendingLimitError
+	require.ErrorAs(err, &limitErr)
+	require.ErrorIs(err, ErrSpendingLimitExceeded)
+	require.Equal(keys[0].Address(), limitErr.Address)
+	require.Equal(uint64(101), limitErr.Amount)
+	require.Equal(uint64(100), limitErr.Allowance.Remaining)
+
+	for _, amount := range []uint64{100, 100} {
+		tx := amountTx(amount)
+		sig, err := signer.Sign(tx)
+		require.NoError(err)
+		require.True(keys[0].PublicKey().Verify(tx, sig))
+		now = now.Add(10 * time.Minute)
+	}
+	require.Equal(Allowance{
+		Limit:     SpendingLimit{PerTransaction: 100, Window: 250},
+		Spent:     200,
+		Remaining: 50,
+		Reset:     now.Add(40 * time.Minute),
+	}, policy.Allowance(keys[0].Address()))
+	_, err = signer.Sign(amountTx(51))
+	require.ErrorIs(err, ErrSpendingLimitExceeded)
+
+	// The first transaction leaves the window after an hour
+	now = now.Add(40 * time.Minute)
+	require.Equal(uint64(100), policy.Allowance(keys[0].Address()).Spent)
+	_, err = signer.Sign(amountTx(100))
+	require.NoError(err)
+
+	// An empty limit leaves the address unlimited
+	signer, ok = limited.Get(keys[1].Address())
+	require.True(ok)
+	_, err = signer.Sign([]byte("not a transaction"))
+	require.NoError(err)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	require.Equal(uint64(math.MaxUint64), policy.Allowance(keys[1].Address()).Remaining)
+
+	// Other addresses have the default limit
+	signer, ok = limited.Get(keys[2].Address())
+	require.True(ok)
+	_, err = signer.Sign(amountTx(11))
+	require.ErrorIs(err, ErrSpendingLimitExceeded)
+	_, err = signer.Sign([]byte("not a transaction"))
+	require.ErrorIs(err, ErrTransactionRejected)
+	require.ErrorIs(err, errNoAmount)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrBlindSigningDisabled)
+}
+
+func TestSpendingPolicyFailedSign(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("spending"), 1)
+	require.NoError(err)
+	addr := kc.Keys()[0].Address()
+	policy := NewSpendingPolicy(SpendingPolicyConfig{
+		Amount:  decodeAmountTx,
+		Default: SpendingLimit{Window: 100},
+	})
+	reject := Intercept(func(Signer, SignOp, []byte, func([]byte) ([]byte, error)) ([]byte, error) {
+		return nil, errPolicy
+	})
+	signer, ok := Wrap(kc, policy.Middleware(), reject).Get(addr)
+	require.True(ok)
+
+	// Transactions that fail to sign do not count against the window
+	_, err = signer.Sign(amountTx(80))
+	require.ErrorIs(err, errPolicy)
+	allowance := policy.Allowance(addr)
+	require.Zero(allowance.Spent)
+	require.Equal(uint64(100), allowance.Remaining)
+	require.True(allowance.Reset.IsZero())
+}
+
+func TestSpendingPolicyBlindSigning(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("spending"), 1)
+	require.NoError(err)
+	policy := NewSpendingPolicy(SpendingPolicyConfig{
+		Default:           SpendingLimit{PerTransaction: 1},
+		AllowBlindSigning: true,
+	})
+	signer, ok := Wrap(kc, policy.Middleware()).Get(kc.Keys()[0].Address())
+	require.True(ok)
+
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	_, err = signer.Sign(amountTx(1))
+	require.ErrorIs(err, ErrTransactionRejected)
+}
@@ -0,0 +1,184 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var (
+	ErrNoAgentKeys             = errors.New("ssh-agent holds no supported keys")
+	ErrHashSigningUnsupported  = errors.New("signer cannot sign pre-hashed payloads")
+	ErrUnexpectedSignatureType = errors.New("unexpected ssh signature type")
+	ErrAgentSignatureInvalid   = errors.New("ssh-agent signature does not verify")
+
+	_ PublicKeySigner = (*sshAgentSigner)(nil)
+	_ SchemeSigner    = (*sshAgentSigner)(nil)
+)
+
+// sshAgentKeychain is a keychain of the keys held by an ssh-agent
+type sshAgentKeychain struct {
+	agent agent.Agent
+	addrs set.Set[ids.ShortID]
+	keys  map[ids.ShortID]*sshAgentKey
+}
+
+type sshAgentKey struct {
+	key ssh.PublicKey
+	// ecdsaKey is the public key of P-256 keys, and nil for ed25519 keys
+	ecdsaKey *ecdsa.PublicKey
+	pubKey   []byte
+}
+
+// NewSSHAgentKeychain creates a keychain of the keys held by [ag], which is
+// typically a client of the agent listening on SSH_AUTH_SOCK:
+//
+//	conn, err := net.Dial("unix", os.Getenv("SSH_AUTH_SOCK"))
+//	...
+//	kc, err := keychain.NewSSHAgentKeychain(agent.NewClient(conn))
+//
+// Only ecdsa-sha2-nistp256 and ssh-ed25519 keys are used; other keys held by
+// the agent are ignored. Addresses are derived from the compressed P-256 or
+// raw ed25519 public key.
+//
+// The agent always hashes the payload itself, so P-256 signers only support
+// Sign, which produces a 64 byte low-S r || s signature over SHA-256 of the
+// message. ed25519 signers sign the given bytes directly with both Sign and
+// SignHash. Signatures returned by the agent are verified against the public
+// key it listed, failing with ErrAgentSignatureInvalid if they do not verify.
+func NewSSHAgentKeychain(ag agent.Agent) (Keychain, error) {
+	agentKeys, err := ag.List()
+	if err != nil {
+		return nil, err
+	}
+
+	kc := &sshAgentKeychain{
+		agent: ag,
+		addrs: make(set.Set[ids.ShortID]),
+		keys:  make(map[ids.ShortID]*sshAgentKey),
+	}
+	for _, agentKey := range agentKeys {
+		key, err := ssh.ParsePublicKey(agentKey.Blob)
+		if err != nil {
+			continue
+		}
+		cryptoKey, ok := key.(ssh.CryptoPublicKey)
+		if !ok {
+			continue
+		}
+
+		k := &sshAgentKey{key: key}
+		switch pub := cryptoKey.CryptoPublicKey().(type) {
+		case *ecdsa.PublicKey:
+			if pub.Curve != elliptic.P256() {
+				continue
+			}
+			k.ecdsaKey = pub
+			k.pubKey = compressPublicKey(pub.X, pub.Y)
+		case ed25519.PublicKey:
+			k.pubKey = pub
+		default:
+			continue
+		}
+
+		addr := publicKeyAddress(k.pubKey)
+		kc.addrs.Add(addr)
+		kc.keys[addr] = k
+	}
+	if kc.addrs.Len() == 0 {
+		return nil, ErrNoAgentKeys
+	}
+	return kc, nil
+}
+
+func (s *sshAgentKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	key, ok := s.keys[addr]
+	if !ok {
+		return nil, false
+	}
+	return &sshAgentSigner{
+		agent: s.agent,
+		key:   key,
+		addr:  addr,
+	}, true
+}
+
+func (s *sshAgentKeychain) Addresses() set.Set[ids.ShortID] {
+	return s.addrs
+}
+
+// sshAgentSigner signs with a single key held by an ssh-agent
+type sshAgentSigner struct {
+	agent agent.Agent
+	key   *sshAgentKey
+	addr  ids.ShortID
+}
+
+func (s *sshAgentSigner) SignHash(hash []byte) ([]byte, error) {
+	if s.key.ecdsaKey != nil {
+		return nil, ErrHashSigningUnsupported
+	}
+	return s.Sign(hash)
+}
+
+func (s *sshAgentSigner) Sign(msg []byte) ([]byte, error) {
+	sig, err := s.agent.Sign(s.key.key, msg)
+	if err != nil {
+		return nil, err
+	}
+	if sig.Format != s.key.key.Type() {
+		return nil, ErrUnexpectedSignatureType
+	}
+	pub := s.key.ecdsaKey
+	if pub == nil {
+		if !ed25519.Verify(s.key.pubKey, msg, sig.Blob) {
+			return nil, ErrAgentSignatureInvalid
+		}
+		return sig.Blob, nil
+	}
+
+	var rs struct {
+		R *big.Int
+		S *big.Int
+	}
+	if err := ssh.Unmarshal(sig.Blob, &rs); err != nil {
+		return nil, err
+	}
+	// The mpints of the agent are checked before they are encoded, as values
+	// outside of [1, n) would not fit the 32 bytes of r and s
+	if err := checkECDSAScalars(pub.Curve, rs.R, rs.S); err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(msg)
+	if !ecdsa.Verify(pub, hash[:], rs.R, rs.S) {
+		return nil, ErrAgentSignatureInvalid
+	}
+	return compactSignature(pub.Curve, rs.R, rs.S), nil
+}
+
+func (s *sshAgentSigner) Address() ids.ShortID {
+	return s.addr
+}
+
+func (s *sshAgentSigner) PublicKey() []byte {
+	return s.key.pubKey
+}
+
+func (s *sshAgentSigner) Scheme() SchemeID {
+	if s.key.ecdsaKey == nil {
+		return SchemeEd25519
+	}
+	return SchemeP256
+}
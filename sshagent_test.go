@@ -0,0 +1,133 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSHAgentKeychain(t *testing.T) {
+	require := require.New(t)
+
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(err)
+
+	ag := agent.NewKeyring()
+	require.NoError(ag.Add(agent.AddedKey{PrivateKey: p256}))
+	require.NoError(ag.Add(agent.AddedKey{PrivateKey: edPriv}))
+	require.NoError(ag.Add(agent.AddedKey{PrivateKey: rsaKey}))
+
+	kc, err := NewSSHAgentKeychain(ag)
+	require.NoError(err)
+
+	// RSA keys are ignored
+	require.Equal(2, kc.Addresses().Len())
+
+	// P-256
+	p256Addr := publicKeyAddress(compressPublicKey(p256.X, p256.Y))
+	signer, ok := kc.Get(p256Addr)
+	require.True(ok)
+
+	msg := []byte("payload")
+	sig, err := signer.Sign(msg)
+	require.NoError(err)
+	require.Len(sig, 64)
+	hash := sha256.Sum256(msg)
+	require.True(ecdsa.Verify(&p256.PublicKey, hash[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])))
+
+	_, err = signer.SignHash(hash[:])
+	require.ErrorIs(err, ErrHashSigningUnsupported)
+
+	// ed25519
+	signer, ok = kc.Get(publicKeyAddress(edPub))
+	require.True(ok)
+	require.Equal([]byte(edPub), signer.(PublicKeySigner).PublicKey())
+
+	sig, err = signer.SignHash(hash[:])
+	require.NoError(err)
+	require.True(ed25519.Verify(edPub, hash[:], sig))
+}
+
+func TestSSHAgentKeychainNoKeys(t *testing.T) {
+	_, err := NewSSHAgentKeychain(agent.NewKeyring())
+	require.ErrorIs(t, err, ErrNoAgentKeys)
+}
+
+// forgingAgent is an agent whose signatures are replaced by those of forge
+type forgingAgent struct {
+	agent.Agent
+	forge func(msg []byte) []byte
+}
+
+func (a *forgingAgent) Sign(key ssh.PublicKey, msg []byte) (*ssh.Signature, error) {
+	return &ssh.Signature{
+		Format: key.Type(),
+		Blob:   a.forge(msg),
+	}, nil
+}
+
+func TestSSHAgentKeychainInvalidSignatures(t *testing.T) {
+	require := require.New(t)
+
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(err)
+	ag := agent.NewKeyring()
+	require.NoError(ag.Add(agent.AddedKey{PrivateKey: p256}))
+
+	var forge func(msg []byte) []byte
+	kc, err := NewSSHAgentKeychain(&forgingAgent{
+		Agent: ag,
+		forge: func(msg []byte) []byte {
+			return forge(msg)
+		},
+	})
+	require.NoError(err)
+	signer, ok := kc.Get(publicKeyAddress(compressPublicKey(p256.X, p256.Y)))
+	require.True(ok)
+
+	marshal := func(r, s *big.Int) []byte {
+		return ssh.Marshal(struct {
+			R *big.Int
+			S *big.Int
+		}{r, s})
+	}
+
+	// r and s must be between 1 and n - 1
+	oversized := new(big.Int).Lsh(big.NewInt(1), 300)
+	for _, r := range []*big.Int{oversized, big.NewInt(-1), big.NewInt(0)} {
+		forge = func([]byte) []byte {
+			return marshal(r, big.NewInt(1))
+		}
+		_, err = signer.Sign([]byte("payload"))
+		require.ErrorIs(err, ErrInvalidSignatureEncoding)
+	}
+
+	// Signatures of another key do not verify
+	forge = func(msg []byte) []byte {
+		hash := sha256.Sum256(msg)
+		r, s, err := ecdsa.Sign(rand.Reader, other, hash[:])
+		require.NoError(err)
+		return marshal(r, s)
+	}
+	_, err = signer.Sign([]byte("payload"))
+	require.ErrorIs(err, ErrAgentSignatureInvalid)
+}
@@ -0,0 +1,166 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/luxfi/crypto/bls"
+	"github.com/luxfi/crypto/cb58"
+)
+
+// stakingKeyPrefix prefixes the text encoding of a staking key, mirroring
+// the PrivateKey- prefix of secp256k1 keys
+const stakingKeyPrefix = "BLSPrivateKey-"
+
+var (
+	ErrInvalidStakingKey        = errors.New("invalid BLS staking key")
+	ErrInvalidProofOfPossession = errors.New("invalid proof of possession")
+
+	_ bls.Signer = (*StakingKey)(nil)
+)
+
+// StakingKey is a validator BLS staking key
+type StakingKey struct {
+	sk *bls.SecretKey
+}
+
+// NewStakingKey generates a new staking key
+func NewStakingKey() (*StakingKey, error) {
+	sk, err := bls.NewSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	return &StakingKey{sk: sk}, nil
+}
+
+// StakingKeyFromBytes parses a staking key from its secret key bytes
+func StakingKeyFromBytes(b []byte) (*StakingKey, error) {
+	sk, err := bls.SecretKeyFromBytes(b)
+	if err != nil {
+		return nil, ErrInvalidStakingKey
+	}
+	return &StakingKey{sk: sk}, nil
+}
+
+// Bytes returns the secret key bytes
+func (k *StakingKey) Bytes() []byte {
+	return bls.SecretKeyToBytes(k.sk)
+}
+
+func (k *StakingKey) PublicKey() *bls.PublicKey {
+	return k.sk.PublicKey()
+}
+
+// PublicKeyBytes returns the compressed public key
+func (k *StakingKey) PublicKeyBytes() []byte {
+	return bls.PublicKeyToCompressedBytes(k.PublicKey())
+}
+
+func (k *StakingKey) Sign(msg []byte) (*bls.Signature, error) {
+	return k.sk.Sign(msg)
+}
+
+func (k *StakingKey) SignProofOfPossession(msg []byte) (*bls.Signature, error) {
+	return k.sk.SignProofOfPossession(msg)
+}
+
+// ProofOfPossession returns the proof of possession of the key, as required
+// by AddPermissionlessValidator transactions: a proof of possession
+// signature over the compressed public key.
+func (k *StakingKey) ProofOfPossession() (*ProofOfPossession, error) {
+	pkBytes := k.PublicKeyBytes()
+	sig, err := k.SignProofOfPossession(pkBytes)
+	if err != nil {
+		return nil, err
+	}
+	pop := &ProofOfPossession{}
+	copy(pop.PublicKey[:], pkBytes)
+	copy(pop.ProofOfPossession[:], bls.SignatureToBytes(sig))
+	return pop, nil
+}
+
+func (k *StakingKey) String() string {
+	// cb58 encoding of a fixed length key cannot fail
+	str, _ := cb58.Encode(k.Bytes())
+	return stakingKeyPrefix + str
+}
+
+func (k *StakingKey) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *StakingKey) UnmarshalText(text []byte) error {
+	str, ok := strings.CutPrefix(string(text), stakingKeyPrefix)
+	if !ok {
+		return ErrInvalidStakingKey
+	}
+	b, err := cb58.Decode(str)
+	if err != nil {
+		return ErrInvalidStakingKey
+	}
+	parsed, err := StakingKeyFromBytes(b)
+	if err != nil {
+		return err
+	}
+	*k = *parsed
+	return nil
+}
+
+// ProofOfPossession is the BLS key registration of a validator
+type ProofOfPossession struct {
+	PublicKey         [bls.PublicKeyLen]byte
+	ProofOfPossession [bls.SignatureLen]byte
+}
+
+// Verify returns nil if the proof of possession is valid for its public key
+func (p *ProofOfPossession) Verify() error {
+	pk, err := bls.PublicKeyFromCompressedBytes(p.PublicKey[:])
+	if err != nil {
+		return ErrInvalidProofOfPossession
+	}
+	sig, err := bls.SignatureFromBytes(p.ProofOfPossession[:])
+	if err != nil {
+		return ErrInvalidProofOfPossession
+	}
+	if !bls.VerifyProofOfPossession(pk, sig, p.PublicKey[:]) {
+		return ErrInvalidProofOfPossession
+	}
+	return nil
+}
+
+// proofOfPossessionJSON is the JSON encoding of a proof of possession used
+// by the node APIs, with 0x prefixed hex fields
+type proofOfPossessionJSON struct {
+	PublicKey         string `json:"publicKey"`
+	ProofOfPossession string `json:"proofOfPossession"`
+}
+
+func (p *ProofOfPossession) MarshalJSON() ([]byte, error) {
+	return json.Marshal(proofOfPossessionJSON{
+		PublicKey:         "0x" + hex.EncodeToString(p.PublicKey[:]),
+		ProofOfPossession: "0x" + hex.EncodeToString(p.ProofOfPossession[:]),
+	})
+}
+
+func (p *ProofOfPossession) UnmarshalJSON(b []byte) error {
+	var j proofOfPossessionJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	pk, err := hex.DecodeString(strings.TrimPrefix(j.PublicKey, "0x"))
+	if err != nil || len(pk) != bls.PublicKeyLen {
+		return ErrInvalidProofOfPossession
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(j.ProofOfPossession, "0x"))
+	if err != nil || len(sig) != bls.SignatureLen {
+		return ErrInvalidProofOfPossession
+	}
+	copy(p.PublicKey[:], pk)
+	copy(p.ProofOfPossession[:], sig)
+	return nil
+}
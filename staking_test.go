@@ -0,0 +1,88 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/bls"
+)
+
+func TestStakingKey(t *testing.T) {
+	require := require.New(t)
+
+	key, err := NewStakingKey()
+	require.NoError(err)
+	require.Len(key.PublicKeyBytes(), bls.PublicKeyLen)
+
+	msg := []byte("message")
+	sig, err := key.Sign(msg)
+	require.NoError(err)
+	require.True(bls.Verify(key.PublicKey(), sig, msg))
+
+	parsed, err := StakingKeyFromBytes(key.Bytes())
+	require.NoError(err)
+	require.Equal(key.PublicKeyBytes(), parsed.PublicKeyBytes())
+
+	text, err := key.MarshalText()
+	require.NoError(err)
+	var unmarshalled StakingKey
+	require.NoError(unmarshalled.UnmarshalText(text))
+	require.Equal(key.Bytes(), unmarshalled.Bytes())
+
+	require.ErrorIs(unmarshalled.UnmarshalText([]byte("PrivateKey-abc")), ErrInvalidStakingKey)
+}
+
+func TestProofOfPossession(t *testing.T) {
+	require := require.New(t)
+
+	key, err := NewStakingKey()
+	require.NoError(err)
+
+	pop, err := key.ProofOfPossession()
+	require.NoError(err)
+	require.Equal(key.PublicKeyBytes(), pop.PublicKey[:])
+	require.NoError(pop.Verify())
+
+	popJSON, err := json.Marshal(pop)
+	require.NoError(err)
+	var parsed ProofOfPossession
+	require.NoError(json.Unmarshal(popJSON, &parsed))
+	require.Equal(*pop, parsed)
+
+	// A regular signature over the public key is not a proof of possession
+	sig, err := key.Sign(pop.PublicKey[:])
+	require.NoError(err)
+	copy(parsed.ProofOfPossession[:], bls.SignatureToBytes(sig))
+	require.ErrorIs(parsed.Verify(), ErrInvalidProofOfPossession)
+
+	other, err := NewStakingKey()
+	require.NoError(err)
+	otherPoP, err := other.ProofOfPossession()
+	require.NoError(err)
+	parsed.ProofOfPossession = otherPoP.ProofOfPossession
+	require.ErrorIs(parsed.Verify(), ErrInvalidProofOfPossession)
+}
+
+func TestBundleStakingKeys(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	key, err := NewStakingKey()
+	require.NoError(err)
+
+	b := &Bundle{StakingKeys: []*StakingKey{key}}
+	pass := []byte("password")
+	var buf bytes.Buffer
+	require.NoError(b.Export(&buf, pass))
+
+	imported, err := Import(&buf, pass)
+	require.NoError(err)
+	require.Len(imported.StakingKeys, 1)
+	require.Equal(key.Bytes(), imported.StakingKeys[0].Bytes())
+}
@@ -0,0 +1,137 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+const ledgerStateVersion = 1
+
+var (
+	ErrInvalidLedgerState            = errors.New("invalid ledger keychain state")
+	ErrUnsupportedLedgerStateVersion = errors.New("unsupported ledger keychain state version")
+
+	_ StatefulKeychain = (*ledgerKeychain)(nil)
+)
+
+// StatefulKeychain is implemented by keychains that can persist the
+// addresses they derived, so that they can be restored without querying the
+// device again
+type StatefulKeychain interface {
+	Keychain
+	// MarshalState returns a snapshot of the keychain's derived addresses
+	MarshalState() ([]byte, error)
+	// UnmarshalState replaces the keychain's derived addresses with a
+	// snapshot returned by MarshalState. It must not be called concurrently
+	// with other methods of the keychain.
+	UnmarshalState(state []byte) error
+}
+
+// ledgerState is the JSON encoding of the state of a ledger keychain
+type ledgerState struct {
+	Version  uint16               `json:"version"`
+	HRP      string               `json:"hrp,omitempty"`
+	Accounts []ledgerStateAccount `json:"accounts"`
+}
+
+type ledgerStateAccount struct {
+	LedgerAccount
+	PublicKey []byte `json:"publicKey,omitempty"`
+}
+
+// NewLedgerKeychainFromState restores a ledger keychain from a state returned
+// by MarshalState, without querying [ledger]. The restored addresses are
+// trusted; use Bundle.LedgerKeychain to check them against the device. The
+// HRP is restored from the state, so only the WithDeviceWait, WithLogger,
+// WithProgress and WithBlindSigning options apply.
+func NewLedgerKeychainFromState(ledger Ledger, state []byte, opts ...Option) (Keychain, error) {
+	o := newOptions(opts)
+	kc := &ledgerKeychain{
+		ledger:       ledger,
+		queue:        newDeviceQueue(o.onWait),
+		logger:       newSignLogger(o),
+		progress:     o.onProgress,
+		blindSigning: o.blindSigning,
+	}
+	if err := kc.UnmarshalState(state); err != nil {
+		return nil, err
+	}
+	logDebug(o.logger, "restored ledger keychain", "addresses", kc.addrs.Len(), "hrp", kc.hrp)
+	return kc, nil
+}
+
+// MarshalState returns the address index, and public key if known, of each
+// derived address together with the keychain's HRP
+func (l *ledgerKeychain) MarshalState() ([]byte, error) {
+	state := ledgerState{
+		Version:  ledgerStateVersion,
+		HRP:      l.hrp,
+		Accounts: make([]ledgerStateAccount, 0, len(l.addrToIdx)),
+	}
+	for addr, idx := range l.addrToIdx {
+		state.Accounts = append(state.Accounts, ledgerStateAccount{
+			LedgerAccount: LedgerAccount{
+				Address: addr,
+				Index:   idx,
+			},
+			PublicKey: l.addrToPubKey[addr],
+		})
+	}
+	slices.SortFunc(state.Accounts, func(x, y ledgerStateAccount) int {
+		return cmp.Compare(x.Index, y.Index)
+	})
+	return json.Marshal(state)
+}
+
+func (l *ledgerKeychain) UnmarshalState(b []byte) error {
+	var state ledgerState
+	if err := json.Unmarshal(b, &state); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidLedgerState, err)
+	}
+	if state.Version != ledgerStateVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedLedgerStateVersion, state.Version)
+	}
+	if len(state.Accounts) == 0 {
+		return ErrInvalidIndicesLength
+	}
+
+	var (
+		addrs        = make(set.Set[ids.ShortID], len(state.Accounts))
+		indices      = make(set.Set[uint32], len(state.Accounts))
+		addrToIdx    = make(map[ids.ShortID]uint32, len(state.Accounts))
+		addrToPubKey = make(map[ids.ShortID][]byte)
+	)
+	for _, account := range state.Accounts {
+		if addrs.Contains(account.Address) || indices.Contains(account.Index) {
+			return fmt.Errorf("%w: duplicate account %s at index %d", ErrInvalidLedgerState, account.Address, account.Index)
+		}
+		if account.PublicKey != nil {
+			addr, err := secp256k1Scheme{}.Address(account.PublicKey)
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidLedgerState, err)
+			}
+			if addr != account.Address {
+				return fmt.Errorf("%w: %s", ErrPublicKeyMismatch, account.Address)
+			}
+			addrToPubKey[account.Address] = account.PublicKey
+		}
+		addrs.Add(account.Address)
+		indices.Add(account.Index)
+		addrToIdx[account.Address] = account.Index
+	}
+
+	l.hrp = state.HRP
+	l.addrs = addrs
+	l.addrToIdx = addrToIdx
+	l.addrToPubKey = addrToPubKey
+	return nil
+}
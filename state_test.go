@@ -0,0 +1,116 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerKeychainState(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewLedgerKeychain(NewLedgerDevice(newFakeLuxApp()), []uint32{0, 3, 7})
+	require.NoError(err)
+	state, err := kc.(StatefulKeychain).MarshalState()
+	require.NoError(err)
+
+	// Restoring does not query the device
+	app := newFakeLuxApp()
+	restored, err := NewLedgerKeychainFromState(NewLedgerDevice(app), state)
+	require.NoError(err)
+	require.Zero(app.exchanges)
+	require.True(kc.Addresses().Equals(restored.Addresses()))
+
+	for addr, signer := range Iter(kc) {
+		restoredSigner, ok := restored.Get(addr)
+		require.True(ok)
+		require.Equal(signer.(*ledgerSigner).idx, restoredSigner.(*ledgerSigner).idx)
+		require.Equal(signer.(*ledgerSigner).PublicKey(), restoredSigner.(*ledgerSigner).PublicKey())
+
+		sig, err := restoredSigner.Sign([]byte("restored"))
+		require.NoError(err)
+		require.NoError(VerifySignature(restoredSigner, []byte("restored"), sig))
+	}
+
+	// The state round trips
+	restoredState, err := restored.(StatefulKeychain).MarshalState()
+	require.NoError(err)
+	require.JSONEq(string(state), string(restoredState))
+}
+
+func TestLedgerKeychainStateWithoutPublicKeys(t *testing.T) {
+	require := require.New(t)
+
+	ledger := newMockLedger()
+	kc, err := NewLedgerKeychain(ledger, []uint32{1, 2})
+	require.NoError(err)
+	state, err := kc.(StatefulKeychain).MarshalState()
+	require.NoError(err)
+
+	restored, err := NewLedgerKeychainFromState(ledger, state)
+	require.NoError(err)
+	require.True(kc.Addresses().Equals(restored.Addresses()))
+}
+
+func TestLedgerKeychainUnmarshalStateErrors(t *testing.T) {
+	kc, err := NewLedgerKeychain(NewLedgerDevice(newFakeLuxApp()), []uint32{0, 1})
+	require.NoError(t, err)
+	valid, err := kc.(StatefulKeychain).MarshalState()
+	require.NoError(t, err)
+
+	edit := func(f func(*ledgerState)) []byte {
+		var state ledgerState
+		require.NoError(t, json.Unmarshal(valid, &state))
+		f(&state)
+		b, err := json.Marshal(state)
+		require.NoError(t, err)
+		return b
+	}
+
+	tests := []struct {
+		name  string
+		state []byte
+		want  error
+	}{
+		{
+			name:  "malformed",
+			state: []byte("{"),
+			want:  ErrInvalidLedgerState,
+		},
+		{
+			name:  "unsupported version",
+			state: edit(func(s *ledgerState) { s.Version = 2 }),
+			want:  ErrUnsupportedLedgerStateVersion,
+		},
+		{
+			name:  "no accounts",
+			state: edit(func(s *ledgerState) { s.Accounts = nil }),
+			want:  ErrInvalidIndicesLength,
+		},
+		{
+			name:  "duplicate index",
+			state: edit(func(s *ledgerState) { s.Accounts[1].Index = s.Accounts[0].Index }),
+			want:  ErrInvalidLedgerState,
+		},
+		{
+			name:  "public key mismatch",
+			state: edit(func(s *ledgerState) { s.Accounts[1].PublicKey = s.Accounts[0].PublicKey }),
+			want:  ErrPublicKeyMismatch,
+		},
+		{
+			name:  "invalid public key",
+			state: edit(func(s *ledgerState) { s.Accounts[0].PublicKey = []byte{1} }),
+			want:  ErrInvalidLedgerState,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewLedgerKeychainFromState(newMockLedger(), test.state)
+			require.ErrorIs(t, err, test.want)
+		})
+	}
+}
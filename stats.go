@@ -0,0 +1,132 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// AddressStats are the signing counters of an address
+type AddressStats struct {
+	Address ids.ShortID
+	// Signs is the number of successful signing operations
+	Signs uint64
+	// Failures is the number of signing operations that failed, including
+	// those rejected by the user or by a policy
+	Failures uint64
+	// LastUsed is the time of the last successful signing operation, or the
+	// zero time if there was none
+	LastUsed time.Time
+	// LastFailure is the time of the last failed signing operation, or the
+	// zero time if there was none
+	LastFailure time.Time
+}
+
+// SigningStats counts the signing operations of the signers it wraps, per
+// address, so that operators can find stale keys eligible for retirement and
+// spot anomalous usage. The counters are held in memory from the creation of
+// the SigningStats.
+type SigningStats struct {
+	now func() time.Time
+
+	lock  sync.Mutex
+	stats map[ids.ShortID]*AddressStats
+}
+
+// NewSigningStats creates empty signing statistics. Its signers are obtained
+// by wrapping a keychain with Middleware.
+func NewSigningStats() *SigningStats {
+	return &SigningStats{
+		now:   time.Now,
+		stats: make(map[ids.ShortID]*AddressStats),
+	}
+}
+
+// Middleware returns a middleware recording the outcome of every signing
+// operation
+func (s *SigningStats) Middleware() Middleware {
+	return Intercept(func(signer Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		sig, err := next(payload)
+		s.record(signer.Address(), err)
+		return sig, err
+	})
+}
+
+// Stats returns the counters of every address that signed or failed to sign,
+// ordered by address
+func (s *SigningStats) Stats() []AddressStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	stats := make([]AddressStats, 0, len(s.stats))
+	for _, st := range s.stats {
+		stats = append(stats, *st)
+	}
+	slices.SortFunc(stats, func(x, y AddressStats) int {
+		return x.Address.Compare(y.Address)
+	})
+	return stats
+}
+
+// Address returns the counters of [addr], or false if it neither signed nor
+// failed to sign
+func (s *SigningStats) Address(addr ids.ShortID) (AddressStats, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	st, ok := s.stats[addr]
+	if !ok {
+		return AddressStats{Address: addr}, false
+	}
+	return *st, true
+}
+
+// Unused returns the addresses of [kc] that have not signed since [since],
+// including those that never signed
+func (s *SigningStats) Unused(kc Keychain, since time.Time) set.Set[ids.ShortID] {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	unused := set.NewSet[ids.ShortID](0)
+	for addr := range kc.Addresses() {
+		st, ok := s.stats[addr]
+		if !ok || st.LastUsed.Before(since) {
+			unused.Add(addr)
+		}
+	}
+	return unused
+}
+
+// Reset clears the counters of every address
+func (s *SigningStats) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	clear(s.stats)
+}
+
+func (s *SigningStats) record(addr ids.ShortID, err error) {
+	now := s.now()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	st, ok := s.stats[addr]
+	if !ok {
+		st = &AddressStats{Address: addr}
+		s.stats[addr] = st
+	}
+	if err != nil {
+		st.Failures++
+		st.LastFailure = now
+		return
+	}
+	st.Signs++
+	st.LastUsed = now
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/sha256"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigningStats(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("stats"), 3)
+	require.NoError(err)
+	addrs := kc.Addresses().List()
+	slices.SortFunc(addrs, ids.ShortID.Compare)
+
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	clock := start
+	stats := NewSigningStats()
+	stats.now = func() time.Time {
+		return clock
+	}
+	wrapped := Wrap(kc, stats.Middleware())
+
+	signer, ok := wrapped.Get(addrs[0])
+	require.True(ok)
+	_, err = signer.Sign([]byte("tx 1"))
+	require.NoError(err)
+	clock = clock.Add(time.Hour)
+	hash := sha256.Sum256([]byte("tx 2"))
+	_, err = signer.SignHash(hash[:])
+	require.NoError(err)
+	clock = clock.Add(time.Hour)
+	_, err = signer.SignHash([]byte("short"))
+	require.Error(err)
+
+	signer, ok = wrapped.Get(addrs[1])
+	require.True(ok)
+	_, err = signer.SignHash([]byte("short"))
+	require.Error(err)
+
+	require.Equal([]AddressStats{
+		{
+			Address:     addrs[0],
+			Signs:       2,
+			Failures:    1,
+			LastUsed:    start.Add(time.Hour),
+			LastFailure: start.Add(2 * time.Hour),
+		},
+		{
+			Address:     addrs[1],
+			Failures:    1,
+			LastFailure: start.Add(2 * time.Hour),
+		},
+	}, stats.Stats())
+
+	st, ok := stats.Address(addrs[0])
+	require.True(ok)
+	require.Equal(uint64(2), st.Signs)
+	st, ok = stats.Address(addrs[2])
+	require.False(ok)
+	require.Equal(AddressStats{Address: addrs[2]}, st)
+
+	// Addresses that only failed or never signed are unused
+	require.Equal(set.Of(addrs[1], addrs[2]), stats.Unused(kc, start))
+	require.Equal(set.Of(addrs...), stats.Unused(kc, start.Add(90*time.Minute)))
+
+	stats.Reset()
+	require.Empty(stats.Stats())
+}
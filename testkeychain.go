@@ -0,0 +1,56 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/luxfi/crypto/secp256k1"
+)
+
+// testKeyDomain separates deterministic test keys from any other use of the
+// seed
+const testKeyDomain = "lux-keychain-test-key"
+
+// NewTestKeychain returns a software keychain of [n] keys derived
+// deterministically from [seed], so that tests can rely on stable fixture
+// addresses. The keys are DeterministicKey(seed, 0) through
+// DeterministicKey(seed, n-1).
+//
+// The keys are trivially recoverable from the seed and must never hold real
+// funds.
+func NewTestKeychain(seed []byte, n int) (*SoftwareKeychain, error) {
+	if n <= 0 {
+		return nil, ErrInvalidNumAddrsToDerive
+	}
+
+	kc := NewSoftwareKeychain()
+	for i := range n {
+		key, err := DeterministicKey(seed, uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		kc.Add(key)
+	}
+	return kc, nil
+}
+
+// DeterministicKey returns the test key at [index] derived from [seed]
+func DeterministicKey(seed []byte, index uint32) (*secp256k1.PrivateKey, error) {
+	var buf [8]byte
+	binary.BigEndian.PutUint32(buf[:4], index)
+	for counter := uint32(0); ; counter++ {
+		// A digest outside of the curve order is astronomically unlikely, but
+		// simply retried with the next counter.
+		binary.BigEndian.PutUint32(buf[4:], counter)
+		h := sha256.New()
+		_, _ = h.Write([]byte(testKeyDomain))
+		_, _ = h.Write(seed)
+		_, _ = h.Write(buf[:])
+		if key, err := secp256k1.ToPrivateKey(h.Sum(nil)); err == nil {
+			return key, nil
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTestKeychain(t *testing.T) {
+	require := require.New(t)
+
+	_, err := NewTestKeychain(nil, 0)
+	require.ErrorIs(err, ErrInvalidNumAddrsToDerive)
+
+	seed := []byte("fixture")
+	kc0, err := NewTestKeychain(seed, 4)
+	require.NoError(err)
+	require.Equal(4, kc0.Addresses().Len())
+
+	// Reproducible
+	kc1, err := NewTestKeychain(seed, 4)
+	require.NoError(err)
+	require.True(kc0.Addresses().Equals(kc1.Addresses()))
+
+	// A larger keychain extends a smaller one
+	kc2, err := NewTestKeychain(seed, 6)
+	require.NoError(err)
+	require.Equal(6, kc2.Addresses().Len())
+	require.Equal(4, kc2.Addresses().Intersection(kc0.Addresses()).Len())
+
+	// Other seeds produce other keys
+	kc3, err := NewTestKeychain([]byte("other"), 4)
+	require.NoError(err)
+	require.False(kc0.Addresses().Overlaps(kc3.Addresses()))
+
+	key, err := DeterministicKey(seed, 2)
+	require.NoError(err)
+	require.True(kc0.Addresses().Contains(key.Address()))
+}
+
+func TestDeterministicKeyVector(t *testing.T) {
+	require := require.New(t)
+
+	// Pinned so that fixture addresses never change across releases
+	key, err := DeterministicKey([]byte("lux"), 0)
+	require.NoError(err)
+	require.Equal("DcxntTzgv52pgp7ipT5Gth9gn49VdYX64", key.Address().String())
+}
@@ -0,0 +1,197 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/luxfi/ids"
+)
+
+var ErrOperationTimeout = errors.New("operation timed out")
+
+var (
+	_ PublicKeyLedger = (*timeoutLedger)(nil)
+	_ Signer          = (*timeoutSigner)(nil)
+)
+
+// Canceler is implemented by transports and signers that are able to abort an
+// in-flight operation without tearing down the whole connection
+type Canceler interface {
+	Cancel() error
+}
+
+// Timeouts bounds the duration of each class of operation. A zero duration
+// disables the timeout for that class.
+type Timeouts struct {
+	// Derive bounds address derivation (Address, GetAddresses, GetPublicKeys)
+	Derive time.Duration
+	// Sign bounds signing (SignHash, Sign, SignTransaction)
+	Sign time.Duration
+}
+
+// timeoutLedger applies per-operation timeouts to an underlying ledger. When
+// an operation times out, the in-flight request is cancelled through the
+// transport so that the device is not left waiting. The ledger is never
+// disconnected on timeout, so that it remains usable.
+type timeoutLedger struct {
+	ledger   Ledger
+	timeouts Timeouts
+}
+
+// NewTimeoutLedger wraps [ledger] so that derivation and signing calls fail
+// with ErrOperationTimeout once their configured timeout elapses. On timeout
+// the in-flight request is aborted with Cancel if the ledger implements
+// Canceler. Otherwise it is left to complete in the background and the ledger
+// stays connected: the operation still holds the device, so that later calls
+// wait for it and are served once the device responds. Callers that need
+// the device freed at once should disconnect such ledgers themselves.
+// LedgerDevice implements Canceler.
+func NewTimeoutLedger(ledger Ledger, timeouts Timeouts) Ledger {
+	return &timeoutLedger{
+		ledger:   ledger,
+		timeouts: timeouts,
+	}
+}
+
+func (t *timeoutLedger) Address(displayHRP string, addressIndex uint32) (ids.ShortID, error) {
+	return withTimeout(t.timeouts.Derive, t.cancel, func() (ids.ShortID, error) {
+		return t.ledger.Address(displayHRP, addressIndex)
+	})
+}
+
+func (t *timeoutLedger) GetAddresses(addressIndices []uint32) ([]ids.ShortID, error) {
+	return withTimeout(t.timeouts.Derive, t.cancel, func() ([]ids.ShortID, error) {
+		return t.ledger.GetAddresses(addressIndices)
+	})
+}
+
+// GetPublicKeys returns nil public keys if the underlying ledger cannot
+// export them
+func (t *timeoutLedger) GetPublicKeys(addressIndices []uint32) ([][]byte, error) {
+	pkLedger, ok := t.ledger.(PublicKeyLedger)
+	if !ok {
+		return make([][]byte, len(addressIndices)), nil
+	}
+	return withTimeout(t.timeouts.Derive, t.cancel, func() ([][]byte, error) {
+		return pkLedger.GetPublicKeys(addressIndices)
+	})
+}
+
+func (t *timeoutLedger) SignHash(hash []byte, addressIndex uint32) ([]byte, error) {
+	return withTimeout(t.timeouts.Sign, t.cancel, func() ([]byte, error) {
+		return t.ledger.SignHash(hash, addressIndex)
+	})
+}
+
+func (t *timeoutLedger) Sign(hash []byte, addressIndex uint32) ([]byte, error) {
+	return withTimeout(t.timeouts.Sign, t.cancel, func() ([]byte, error) {
+		return t.ledger.Sign(hash, addressIndex)
+	})
+}
+
+func (t *timeoutLedger) SignTransaction(rawUnsignedHash []byte, addressIndices []uint32) ([][]byte, error) {
+	return withTimeout(t.timeouts.Sign, t.cancel, func() ([][]byte, error) {
+		return t.ledger.SignTransaction(rawUnsignedHash, addressIndices)
+	})
+}
+
+func (t *timeoutLedger) Disconnect() error {
+	return t.ledger.Disconnect()
+}
+
+func (t *timeoutLedger) cancel() error {
+	if c, ok := t.ledger.(Canceler); ok {
+		return c.Cancel()
+	}
+	return nil
+}
+
+// NewTimeoutKeychain wraps [keychain] so that signers it returns fail with
+// ErrOperationTimeout once [timeout] elapses. Signers implementing Canceler
+// are cancelled on timeout.
+func NewTimeoutKeychain(keychain Keychain, timeout time.Duration) Keychain {
+	return Wrap(keychain, Timeout(timeout))
+}
+
+// Timeout returns a middleware that fails signing operations with
+// ErrOperationTimeout once [timeout] elapses, cancelling signers that
+// implement Canceler
+func Timeout(timeout time.Duration) Middleware {
+	return func(next Signer) Signer {
+		return &timeoutSigner{
+			signer:  next,
+			timeout: timeout,
+		}
+	}
+}
+
+type timeoutSigner struct {
+	signer  Signer
+	timeout time.Duration
+}
+
+func (t *timeoutSigner) SignHash(hash []byte) ([]byte, error) {
+	return withTimeout(t.timeout, t.cancel, func() ([]byte, error) {
+		return t.signer.SignHash(hash)
+	})
+}
+
+func (t *timeoutSigner) Sign(msg []byte) ([]byte, error) {
+	return withTimeout(t.timeout, t.cancel, func() ([]byte, error) {
+		return t.signer.Sign(msg)
+	})
+}
+
+func (t *timeoutSigner) Address() ids.ShortID {
+	return t.signer.Address()
+}
+
+func (t *timeoutSigner) Unwrap() Signer {
+	return t.signer
+}
+
+func (t *timeoutSigner) cancel() error {
+	if c, ok := t.signer.(Canceler); ok {
+		return c.Cancel()
+	}
+	return nil
+}
+
+type result[T any] struct {
+	value T
+	err   error
+}
+
+// withTimeout runs [op] and returns its result, or ErrOperationTimeout if it
+// does not complete within [timeout]. On timeout [cancel] is invoked so the
+// underlying operation can unwind.
+func withTimeout[T any](timeout time.Duration, cancel func() error, op func() (T, error)) (T, error) {
+	if timeout <= 0 {
+		return op()
+	}
+
+	// Buffered so the operation can always deliver its result and exit, even
+	// after the caller has given up on it.
+	done := make(chan result[T], 1)
+	go func() {
+		value, err := op()
+		done <- result[T]{value: value, err: err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-timer.C:
+		var zero T
+		if err := cancel(); err != nil {
+			return zero, errors.Join(ErrOperationTimeout, err)
+		}
+		return zero, ErrOperationTimeout
+	}
+}
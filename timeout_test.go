@@ -0,0 +1,157 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+var errCancelled = errors.New("cancelled")
+
+// blockingLedger blocks signing until the operation is cancelled
+type blockingLedger struct {
+	*mockLedger
+	cancelled chan struct{}
+}
+
+func newBlockingLedger() *blockingLedger {
+	return &blockingLedger{
+		mockLedger: newMockLedger(),
+		cancelled:  make(chan struct{}),
+	}
+}
+
+func (b *blockingLedger) SignHash(_ []byte, _ uint32) ([]byte, error) {
+	<-b.cancelled
+	return nil, errCancelled
+}
+
+func (b *blockingLedger) Cancel() error {
+	close(b.cancelled)
+	return nil
+}
+
+func TestTimeoutLedgerSignTimesOut(t *testing.T) {
+	require := require.New(t)
+
+	ledger := newBlockingLedger()
+	tl := NewTimeoutLedger(ledger, Timeouts{
+		Sign: 10 * time.Millisecond,
+	})
+
+	_, err := tl.SignHash([]byte("hash"), 0)
+	require.ErrorIs(err, ErrOperationTimeout)
+
+	// The transport should have been cancelled
+	select {
+	case <-ledger.cancelled:
+	default:
+		require.FailNow("ledger was not cancelled")
+	}
+}
+
+// slowLedger blocks signing until released and cannot cancel it
+type slowLedger struct {
+	*mockLedger
+	release      chan struct{}
+	disconnected atomic.Bool
+}
+
+func (s *slowLedger) SignHash(hash []byte, addressIndex uint32) ([]byte, error) {
+	<-s.release
+	return s.mockLedger.SignHash(hash, addressIndex)
+}
+
+func (s *slowLedger) Disconnect() error {
+	s.disconnected.Store(true)
+	return nil
+}
+
+func TestTimeoutLedgerUsableAfterTimeout(t *testing.T) {
+	require := require.New(t)
+
+	ledger := &slowLedger{
+		mockLedger: newMockLedger(),
+		release:    make(chan struct{}),
+	}
+	tl := NewTimeoutLedger(ledger, Timeouts{
+		Sign: 10 * time.Millisecond,
+	})
+
+	_, err := tl.SignHash([]byte("hash"), 0)
+	require.ErrorIs(err, ErrOperationTimeout)
+	require.NoError(tl.(Canceler).Cancel())
+	require.False(ledger.disconnected.Load())
+
+	// The ledger serves the calls made once the device responds
+	close(ledger.release)
+	sig, err := tl.SignHash([]byte("hash"), 0)
+	require.NoError(err)
+	require.Equal([]byte("mock-hash-signature"), sig)
+	require.False(ledger.disconnected.Load())
+}
+
+func TestTimeoutLedgerPassthrough(t *testing.T) {
+	require := require.New(t)
+
+	tl := NewTimeoutLedger(newMockLedger(), Timeouts{
+		Derive: time.Second,
+		Sign:   time.Second,
+	})
+
+	addrs, err := tl.GetAddresses([]uint32{1, 2})
+	require.NoError(err)
+	require.Len(addrs, 2)
+
+	sig, err := tl.Sign([]byte("data"), 0)
+	require.NoError(err)
+	require.Equal([]byte("mock-signature"), sig)
+
+	// Keychain construction works over the wrapper
+	kc, err := NewLedgerKeychain(tl, []uint32{0})
+	require.NoError(err)
+	require.Equal(1, kc.Addresses().Len())
+}
+
+func TestTimeoutKeychain(t *testing.T) {
+	require := require.New(t)
+
+	ledger := newBlockingLedger()
+	kc, err := NewLedgerKeychain(ledger, []uint32{0}, WithBlindSigning())
+	require.NoError(err)
+
+	tkc := NewTimeoutKeychain(kc, 10*time.Millisecond)
+
+	addr, err := ledger.Address("", 0)
+	require.NoError(err)
+
+	signer, ok := tkc.Get(addr)
+	require.True(ok)
+	require.Equal(addr, signer.Address())
+
+	// Sign is not blocked by the ledger
+	_, err = signer.Sign([]byte("data"))
+	require.NoError(err)
+
+	_, err = signer.SignHash([]byte("hash"))
+	require.ErrorIs(err, ErrOperationTimeout)
+
+	var unknownAddr ids.ShortID
+	unknownAddr[0] = 99
+	_, ok = tkc.Get(unknownAddr)
+	require.False(ok)
+
+	// The ledger signer aborts the pending ledger call on timeout
+	select {
+	case <-ledger.cancelled:
+	default:
+		require.FailNow("ledger was not cancelled")
+	}
+}
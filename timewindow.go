@@ -0,0 +1,213 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/luxfi/ids"
+)
+
+// defaultMaxWindowWait bounds how long a queued request waits for its
+// signing window to open
+const defaultMaxWindowWait = 24 * time.Hour
+
+var ErrOutsideSigningWindow = errors.New("request is outside the allowed signing windows")
+
+// SigningWindow is a recurring period during which signing is allowed, such
+// as business hours or a weekly maintenance window:
+//
+//	keychain.SigningWindow{
+//		Days:     []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+//		Start:    9 * time.Hour,
+//		End:      17 * time.Hour,
+//		Location: newYork,
+//	}
+type SigningWindow struct {
+	// Days are the days the window opens on, every day if empty
+	Days []time.Weekday
+	// Start and End are the times the window opens and closes, as offsets
+	// from midnight. A window whose End is not after its Start closes on the
+	// next day, so a zero Start and End allow the whole day.
+	Start time.Duration
+	End   time.Duration
+	// Location is the time zone of Days, Start and End, UTC if nil
+	Location *time.Location
+}
+
+func (w SigningWindow) location() *time.Location {
+	if w.Location == nil {
+		return time.UTC
+	}
+	return w.Location
+}
+
+func (w SigningWindow) opensOn(day time.Weekday) bool {
+	return len(w.Days) == 0 || slices.Contains(w.Days, day)
+}
+
+// midnight returns the start of the day [days] after the day of [t]
+func midnight(t time.Time, days int) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d+days, 0, 0, 0, 0, t.Location())
+}
+
+// Contains reports whether the window is open at [t]
+func (w SigningWindow) Contains(t time.Time) bool {
+	t = t.In(w.location())
+	today := midnight(t, 0)
+	offset := t.Sub(today)
+	if w.End > w.Start {
+		return w.opensOn(today.Weekday()) && offset >= w.Start && offset < w.End
+	}
+	// The window of the day before may still be open
+	yesterday := midnight(t, -1)
+	return (w.opensOn(today.Weekday()) && offset >= w.Start) ||
+		(w.opensOn(yesterday.Weekday()) && offset < w.End)
+}
+
+// nextOpen returns when the window next opens after [t]
+func (w SigningWindow) nextOpen(t time.Time) (time.Time, bool) {
+	t = t.In(w.location())
+	for days := 0; days <= 7; days++ {
+		day := midnight(t, days)
+		if !w.opensOn(day.Weekday()) {
+			continue
+		}
+		if open := day.Add(w.Start); open.After(t) {
+			return open, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// SigningSchedule is a set of signing windows. Signing is allowed while any of
+// them is open.
+type SigningSchedule []SigningWindow
+
+// Allows reports whether signing is allowed at [t]. An empty schedule always
+// allows signing.
+func (s SigningSchedule) Allows(t time.Time) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, w := range s {
+		if w.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the earliest time at or after [t] that the schedule allows
+// signing
+func (s SigningSchedule) NextOpen(t time.Time) (time.Time, bool) {
+	if s.Allows(t) {
+		return t, true
+	}
+	var (
+		next  time.Time
+		found bool
+	)
+	for _, w := range s {
+		open, ok := w.nextOpen(t)
+		if ok && (!found || open.Before(next)) {
+			next, found = open, true
+		}
+	}
+	return next, found
+}
+
+// SigningWindowError reports a request made outside of the signing windows of
+// its address. It matches ErrOutsideSigningWindow.
+type SigningWindowError struct {
+	Address ids.ShortID
+	// Time is when the request was made
+	Time time.Time
+	// NextOpen is when signing is next allowed, or the zero time if never
+	NextOpen time.Time
+}
+
+func (e *SigningWindowError) Error() string {
+	if e.NextOpen.IsZero() {
+		return fmt.Sprintf("%s: %s", ErrOutsideSigningWindow, e.Address)
+	}
+	return fmt.Sprintf("%s: %s may sign from %s", ErrOutsideSigningWindow, e.Address, e.NextOpen.Format(time.RFC3339))
+}
+
+func (*SigningWindowError) Unwrap() error {
+	return ErrOutsideSigningWindow
+}
+
+// TimeWindowConfig configures TimeWindows
+type TimeWindowConfig struct {
+	// Default is the schedule of the addresses that are not in Addresses. If
+	// empty, they may always sign.
+	Default SigningSchedule
+	// Addresses are the schedules of individual addresses
+	Addresses map[ids.ShortID]SigningSchedule
+	// Queue holds requests made outside of their signing windows until the
+	// next window opens, instead of rejecting them
+	Queue bool
+	// MaxWait bounds how long a queued request waits, 24 hours if zero.
+	// Requests whose window opens later are rejected.
+	MaxWait time.Duration
+}
+
+// timeWindows enforces a TimeWindowConfig, with a clock that tests replace
+type timeWindows struct {
+	config TimeWindowConfig
+	now    func() time.Time
+	sleep  func(time.Duration)
+}
+
+// TimeWindows returns a middleware that only lets signers sign within the
+// signing windows of their address, as some custody policies require.
+// Requests made outside of them fail with a *SigningWindowError or, if
+// [config].Queue is set, block until the window opens.
+func TimeWindows(config TimeWindowConfig) Middleware {
+	if config.MaxWait <= 0 {
+		config.MaxWait = defaultMaxWindowWait
+	}
+	w := &timeWindows{
+		config: config,
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+	return Intercept(w.intercept)
+}
+
+func (w *timeWindows) intercept(signer Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+	if err := w.wait(signer.Address()); err != nil {
+		return nil, err
+	}
+	return next(payload)
+}
+
+// wait returns once [addr] may sign, or an error if it may not sign now and,
+// when queueing, within the maximum wait
+func (w *timeWindows) wait(addr ids.ShortID) error {
+	schedule, ok := w.config.Addresses[addr]
+	if !ok {
+		schedule = w.config.Default
+	}
+
+	requested := w.now()
+	deadline := requested.Add(w.config.MaxWait)
+	for now := requested; !schedule.Allows(now); now = w.now() {
+		open, ok := schedule.NextOpen(now)
+		if !w.config.Queue || !ok || open.After(deadline) {
+			return &SigningWindowError{
+				Address:  addr,
+				Time:     requested,
+				NextOpen: open,
+			}
+		}
+		w.sleep(open.Sub(now))
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+)
+
+var (
+	weekdays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	businessHours = SigningWindow{
+		Days:  weekdays,
+		Start: 9 * time.Hour,
+		End:   17 * time.Hour,
+	}
+	// maintenanceWindow opens on Saturday night and closes on Sunday
+	maintenanceWindow = SigningWindow{
+		Days:  []time.Weekday{time.Saturday},
+		Start: 22 * time.Hour,
+		End:   2 * time.Hour,
+	}
+)
+
+// at returns a time of the week of Sunday 2025-03-02, in UTC
+func at(day time.Weekday, hour, minute int) time.Time {
+	return time.Date(2025, 3, 2+int(day), hour, minute, 0, 0, time.UTC)
+}
+
+func TestSigningWindowContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   SigningWindow
+		time     time.Time
+		expected bool
+	}{
+		{"business hours opening", businessHours, at(time.Monday, 9, 0), true},
+		{"business hours closing", businessHours, at(time.Friday, 17, 0), false},
+		{"business hours before opening", businessHours, at(time.Tuesday, 8, 59), false},
+		{"business hours weekend", businessHours, at(time.Saturday, 12, 0), false},
+		{"maintenance start", maintenanceWindow, at(time.Saturday, 23, 0), true},
+		{"maintenance past midnight", maintenanceWindow, at(time.Sunday, 1, 59), true},
+		{"maintenance closed", maintenanceWindow, at(time.Sunday, 2, 0), false},
+		{"maintenance other day", maintenanceWindow, at(time.Friday, 23, 0), false},
+		{"whole day", SigningWindow{Days: []time.Weekday{time.Wednesday}}, at(time.Wednesday, 0, 0), true},
+		{"whole day other day", SigningWindow{Days: []time.Weekday{time.Wednesday}}, at(time.Thursday, 0, 0), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, test.window.Contains(test.time))
+		})
+	}
+}
+
+func TestSigningWindowLocation(t *testing.T) {
+	require := require.New(t)
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+	window := businessHours
+	window.Location = tokyo
+
+	// 09:00 in Tokyo is midnight UTC
+	require.True(window.Contains(at(time.Monday, 0, 0)))
+	require.False(window.Contains(at(time.Monday, 9, 0)))
+}
+
+func TestSigningScheduleNextOpen(t *testing.T) {
+	require := require.New(t)
+
+	schedule := SigningSchedule{businessHours, maintenanceWindow}
+	now := at(time.Monday, 10, 0)
+	next, ok := schedule.NextOpen(now)
+	require.True(ok)
+	require.Equal(now, next)
+
+	next, ok = schedule.NextOpen(at(time.Friday, 18, 0))
+	require.True(ok)
+	require.Equal(at(time.Saturday, 22, 0), next)
+
+	next, ok = schedule.NextOpen(at(time.Sunday, 3, 0))
+	require.True(ok)
+	require.Equal(at(time.Monday, 9, 0), next)
+
+	require.True(SigningSchedule{}.Allows(now))
+}
+
+// fakeClock is a clock that advances when slept on
+type fakeClock struct {
+	now   time.Time
+	slept time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.slept += d
+}
+
+func newTestTimeWindows(config TimeWindowConfig, clock *fakeClock) Middleware {
+	if config.MaxWait <= 0 {
+		config.MaxWait = defaultMaxWindowWait
+	}
+	w := &timeWindows{
+		config: config,
+		now:    clock.Now,
+		sleep:  clock.Sleep,
+	}
+	return Intercept(w.intercept)
+}
+
+func TestTimeWindows(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("windows"), 2)
+	require.NoError(err)
+	keys := kc.Keys()
+	clock := &fakeClock{now: at(time.Saturday, 12, 0)}
+	restricted := Wrap(kc, newTestTimeWindows(TimeWindowConfig{
+		Default: SigningSchedule{businessHours},
+		Addresses: map[ids.ShortID]SigningSchedule{
+			keys[1].Address(): {maintenanceWindow},
+		},
+	}, clock))
+
+	signer, ok := restricted.Get(keys[0].Address())
+	require.True(ok)
+	_, err = signer.Sign([]byte("weekend payout"))
+	var windowErr *SigningWindowError
+	require.ErrorAs(err, &windowErr)
+	require.ErrorIs(err, ErrOutsideSigningWindow)
+	require.Equal(keys[0].Address(), windowErr.Address)
+	require.Equal(clock.now, windowErr.Time)
+	require.True(windowErr.NextOpen.Equal(at(time.Monday, 9, 0).AddDate(0, 0, 7)))
+
+	clock.now = at(time.Saturday, 22, 30)
+	signer, ok = restricted.Get(keys[1].Address())
+	require.True(ok)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.NoError(err)
+	require.Zero(clock.slept)
+}
+
+func TestTimeWindowsQueue(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("windows"), 1)
+	require.NoError(err)
+	clock := &fakeClock{now: at(time.Monday, 7, 0)}
+	signer, ok := Wrap(kc, newTestTimeWindows(TimeWindowConfig{
+		Default: SigningSchedule{businessHours},
+		Queue:   true,
+		MaxWait: 12 * time.Hour,
+	}, clock)).Get(kc.Keys()[0].Address())
+	require.True(ok)
+
+	// The request waits for the window to open
+	_, err = signer.Sign([]byte("queued"))
+	require.NoError(err)
+	require.Equal(2*time.Hour, clock.slept)
+	require.Equal(at(time.Monday, 9, 0), clock.now)
+
+	// Windows opening after the maximum wait are rejected
+	clock.now = at(time.Friday, 18, 0)
+	_, err = signer.Sign([]byte("queued"))
+	require.ErrorIs(err, ErrOutsideSigningWindow)
+	require.Equal(2*time.Hour, clock.slept)
+}
@@ -0,0 +1,254 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// Defaults of TOTPConfig, those of authenticator apps computing RFC 6238
+// codes with HMAC-SHA1
+const (
+	defaultTOTPDigits = 6
+	defaultTOTPPeriod = 30 * time.Second
+	defaultTOTPSkew   = 1
+
+	maxTOTPDigits = 9
+)
+
+var (
+	ErrInvalidTOTPSecret = errors.New("invalid TOTP secret")
+	ErrInvalidTOTPConfig = errors.New("invalid TOTP configuration")
+	ErrTOTPRequired      = errors.New("signing requires a TOTP code")
+	ErrInvalidTOTPCode   = errors.New("invalid TOTP code")
+	ErrTOTPCodeReused    = errors.New("TOTP code was already used")
+
+	_ Unwrapper     = (*totpSigner)(nil)
+	_ contextBinder = (*totpSigner)(nil)
+)
+
+// TOTPConfig configures TOTP. Zero fields take their defaults.
+type TOTPConfig struct {
+	// Secret is the key shared with the authenticator of the second factor
+	Secret []byte
+	// Addresses are the high-value addresses whose signing operations
+	// require a code. If empty, every address does.
+	Addresses set.Set[ids.ShortID]
+	// Code, if set, is called for the code of an operation of [addr] that
+	// was not supplied one with WithTOTPCode, such as to prompt the
+	// operator. Returning an error rejects the operation.
+	Code func(addr ids.ShortID, op SignOp) (string, error)
+	// Digits is the length of the codes, 6 by default
+	Digits int
+	// Period is how long a code is valid for, 30s by default
+	Period time.Duration
+	// Skew is the number of periods before and after the current one whose
+	// codes are accepted, to tolerate clock drift, 1 by default. Negative
+	// values accept only the current code.
+	Skew int
+}
+
+func (c TOTPConfig) withDefaults() TOTPConfig {
+	if c.Digits <= 0 {
+		c.Digits = defaultTOTPDigits
+	}
+	if c.Period <= 0 {
+		c.Period = defaultTOTPPeriod
+	}
+	if c.Skew == 0 {
+		c.Skew = defaultTOTPSkew
+	}
+	c.Skew = max(c.Skew, 0)
+	return c
+}
+
+// Generate returns the code of [t], as the authenticator displays it
+func (c TOTPConfig) Generate(t time.Time) string {
+	c = c.withDefaults()
+	return c.code(c.counter(t))
+}
+
+func (c TOTPConfig) counter(t time.Time) uint64 {
+	return uint64(t.Unix() / max(int64(c.Period/time.Second), 1))
+}
+
+// code returns the RFC 4226 code of [counter]
+func (c TOTPConfig) code(counter uint64) string {
+	mac := hmac.New(sha1.New, c.Secret)
+	_ = binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range c.Digits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", c.Digits, value%mod)
+}
+
+// ParseTOTPSecret decodes [secret] from the base32 encoding authenticator
+// apps display and export, ignoring case, spaces and padding
+func ParseTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	decoded, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil || len(decoded) == 0 {
+		return nil, ErrInvalidTOTPSecret
+	}
+	return decoded, nil
+}
+
+// totpCodeKey is the context key of the code set by WithTOTPCode
+type totpCodeKey struct{}
+
+// WithTOTPCode returns a context supplying [code] to the TOTP middlewares of
+// the signers passed to SignContext and SignHashContext along with it
+func WithTOTPCode(ctx context.Context, code string) context.Context {
+	return context.WithValue(ctx, totpCodeKey{}, code)
+}
+
+// TOTP returns a middleware requiring a valid TOTP code, of [config].Secret,
+// before the signing operations of [config].Addresses. Codes are supplied
+// with WithTOTPCode or, for operations not signed through SignContext and
+// SignHashContext, by [config].Code. Operations without a code fail with
+// ErrTOTPRequired and those with a wrong one with ErrInvalidTOTPCode. Each
+// code is accepted once, so that a code seen by a third party cannot
+// authorize another operation: codes of a period up to that of the last
+// accepted code fail with ErrTOTPCodeReused.
+func TOTP(config TOTPConfig) (Middleware, error) {
+	if len(config.Secret) == 0 {
+		return nil, ErrInvalidTOTPSecret
+	}
+	config = config.withDefaults()
+	if config.Digits > maxTOTPDigits {
+		return nil, fmt.Errorf("%w: %d digits", ErrInvalidTOTPConfig, config.Digits)
+	}
+	if config.Period%time.Second != 0 {
+		return nil, fmt.Errorf("%w: period %s is not a whole number of seconds", ErrInvalidTOTPConfig, config.Period)
+	}
+	gate := &totpGate{
+		config: config,
+		now:    time.Now,
+	}
+	return func(next Signer) Signer {
+		return &totpSigner{
+			next: next,
+			gate: gate,
+		}
+	}, nil
+}
+
+// totpGate verifies the codes of a TOTP middleware, with a clock that tests
+// replace
+type totpGate struct {
+	config TOTPConfig
+	now    func() time.Time
+
+	// contexts are the contexts of the operations signed with SignContext
+	// and SignHashContext, which may hold a code
+	contexts boundContexts
+
+	lock sync.Mutex
+	// used is set once a code was accepted, and last is its counter
+	used bool
+	last uint64
+}
+
+// code returns the code supplied for [op] of [payload] by signer [addr]
+func (g *totpGate) code(addr ids.ShortID, op SignOp, payload []byte) (string, error) {
+	if code, ok := g.contexts.get(addr, op, payload).Value(totpCodeKey{}).(string); ok {
+		return code, nil
+	}
+
+	if g.config.Code == nil {
+		return "", ErrTOTPRequired
+	}
+	code, err := g.config.Code(addr, op)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrTOTPRequired, err)
+	}
+	return code, nil
+}
+
+// verify accepts [code] if it is the unused code of a period within the skew
+// of the current one
+func (g *totpGate) verify(code string) error {
+	if len(code) != g.config.Digits {
+		return ErrInvalidTOTPCode
+	}
+	now := g.config.counter(g.now())
+	skew := uint64(g.config.Skew)
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for counter := now - min(now, skew); counter <= now+skew; counter++ {
+		if subtle.ConstantTimeCompare([]byte(code), []byte(g.config.code(counter))) != 1 {
+			continue
+		}
+		if g.used && counter <= g.last {
+			return ErrTOTPCodeReused
+		}
+		g.used, g.last = true, counter
+		return nil
+	}
+	return ErrInvalidTOTPCode
+}
+
+// totpSigner verifies a code before the operations of designated addresses
+type totpSigner struct {
+	next Signer
+	gate *totpGate
+}
+
+func (t *totpSigner) SignHash(hash []byte) ([]byte, error) {
+	if err := t.authorize(OpSignHash, hash); err != nil {
+		return nil, err
+	}
+	return t.next.SignHash(hash)
+}
+
+func (t *totpSigner) Sign(msg []byte) ([]byte, error) {
+	if err := t.authorize(OpSign, msg); err != nil {
+		return nil, err
+	}
+	return t.next.Sign(msg)
+}
+
+func (t *totpSigner) authorize(op SignOp, payload []byte) error {
+	addr := t.next.Address()
+	if addrs := t.gate.config.Addresses; addrs.Len() > 0 && !addrs.Contains(addr) {
+		return nil
+	}
+	code, err := t.gate.code(addr, op, payload)
+	if err != nil {
+		return err
+	}
+	return t.gate.verify(code)
+}
+
+func (t *totpSigner) Address() ids.ShortID {
+	return t.next.Address()
+}
+
+func (t *totpSigner) Unwrap() Signer {
+	return t.next
+}
+
+func (t *totpSigner) bindContext(ctx context.Context, op SignOp, payload []byte) func() {
+	return t.gate.contexts.bind(ctx, t.next.Address(), op, payload)
+}
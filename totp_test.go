@@ -0,0 +1,218 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA-1 secret of the test vectors of RFC 6238
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestTOTPGenerate(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "94287082"},
+		{unix: 1111111109, code: "07081804"},
+		{unix: 1111111111, code: "14050471"},
+		{unix: 1234567890, code: "89005924"},
+		{unix: 2000000000, code: "69279037"},
+	}
+	config := TOTPConfig{
+		Secret: rfc6238Secret,
+		Digits: 8,
+	}
+	for _, test := range tests {
+		require.Equal(t, test.code, config.Generate(time.Unix(test.unix, 0)), "time %d", test.unix)
+	}
+
+	secret, err := ParseTOTPSecret("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	require.NoError(t, err)
+	require.Equal(t, rfc6238Secret, secret)
+	_, err = ParseTOTPSecret("not base32!")
+	require.ErrorIs(t, err, ErrInvalidTOTPSecret)
+}
+
+// newTOTPSigner returns the signer of the first address of a test keychain
+// wrapped by a TOTP middleware of [config], and a function setting the clock
+// of the middleware
+func newTOTPSigner(t *testing.T, config TOTPConfig) (Signer, func(time.Time)) {
+	kc, err := NewTestKeychain([]byte("totp"), 2)
+	require.NoError(t, err)
+	addrs := kc.Addresses().List()
+	if config.Addresses == nil {
+		config.Addresses = set.Of(addrs[0])
+	}
+	middleware, err := TOTP(config)
+	require.NoError(t, err)
+
+	signer, _ := Wrap(kc, Intercept(noopInterceptor), middleware).Get(addrs[0])
+	gate := signer.(Unwrapper).Unwrap().(*totpSigner).gate
+	return signer, func(now time.Time) {
+		gate.now = func() time.Time {
+			return now
+		}
+	}
+}
+
+func TestTOTPContext(t *testing.T) {
+	require := require.New(t)
+
+	config := TOTPConfig{Secret: rfc6238Secret}
+	signer, setNow := newTOTPSigner(t, config)
+	now := time.Unix(1_700_000_000, 0)
+	setNow(now)
+
+	tx := []byte("unsigned tx")
+	_, err := signer.Sign(tx)
+	require.ErrorIs(err, ErrTOTPRequired)
+	_, err = SignContext(WithTOTPCode(context.Background(), "000000"), signer, tx)
+	require.ErrorIs(err, ErrInvalidTOTPCode)
+
+	sig, err := SignContext(WithTOTPCode(context.Background(), config.Generate(now)), signer, tx)
+	require.NoError(err)
+	pubKey, err := secp256k1.RecoverPublicKey(tx, sig)
+	require.NoError(err)
+	require.Equal(signer.Address(), pubKey.Address())
+
+	// The code is not left declared, nor accepted twice
+	_, err = signer.Sign(tx)
+	require.ErrorIs(err, ErrTOTPRequired)
+	_, err = SignContext(WithTOTPCode(context.Background(), config.Generate(now)), signer, tx)
+	require.ErrorIs(err, ErrTOTPCodeReused)
+
+	// The code of the previous period is accepted within the skew, but not
+	// once a later one was used
+	_, err = SignHashContext(WithTOTPCode(context.Background(), config.Generate(now.Add(time.Minute))), signer, make([]byte, 32))
+	require.ErrorIs(err, ErrInvalidTOTPCode)
+	setNow(now.Add(30 * time.Second))
+	_, err = SignHashContext(WithTOTPCode(context.Background(), config.Generate(now.Add(time.Minute))), signer, make([]byte, 32))
+	require.NoError(err)
+	_, err = SignHashContext(WithTOTPCode(context.Background(), config.Generate(now.Add(30*time.Second))), signer, make([]byte, 32))
+	require.ErrorIs(err, ErrTOTPCodeReused)
+}
+
+func TestTOTPConcurrentContexts(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("totp concurrent"), 2)
+	require.NoError(err)
+	addrs := kc.Addresses().List()
+	config := TOTPConfig{Secret: rfc6238Secret}
+	middleware, err := TOTP(config)
+	require.NoError(err)
+
+	// Each signing operation waits to be released once it bound its context
+	entered := make(chan struct{})
+	proceed := map[ids.ShortID]chan struct{}{
+		addrs[0]: make(chan struct{}),
+		addrs[1]: make(chan struct{}),
+	}
+	wrapped := Wrap(kc, Intercept(func(signer Signer, _ SignOp, payload []byte, next func([]byte) ([]byte, error)) ([]byte, error) {
+		entered <- struct{}{}
+		<-proceed[signer.Address()]
+		return next(payload)
+	}), middleware)
+	valid, _ := wrapped.Get(addrs[0])
+	invalid, _ := wrapped.Get(addrs[1])
+	now := time.Unix(1_700_000_000, 0)
+	valid.(Unwrapper).Unwrap().(*totpSigner).gate.now = func() time.Time {
+		return now
+	}
+
+	// Two owners sign the same transaction concurrently, and each operation
+	// is verified with the code of its own context, whichever completes
+	// first
+	tx := []byte("multisig tx")
+	results := make(map[ids.ShortID]chan error)
+	for _, op := range []struct {
+		signer Signer
+		code   string
+	}{
+		{signer: valid, code: config.Generate(now)},
+		{signer: invalid, code: "000000"},
+	} {
+		result := make(chan error, 1)
+		results[op.signer.Address()] = result
+		go func() {
+			_, err := SignContext(WithTOTPCode(context.Background(), op.code), op.signer, tx)
+			result <- err
+		}()
+		<-entered
+	}
+	close(proceed[addrs[1]])
+	require.ErrorIs(<-results[addrs[1]], ErrInvalidTOTPCode)
+	close(proceed[addrs[0]])
+	require.NoError(<-results[addrs[0]])
+}
+
+func TestTOTPCallback(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Unix(1_700_000_000, 0)
+	errDismissed := errors.New("prompt dismissed")
+	var (
+		prompted []ids.ShortID
+		dismiss  bool
+	)
+	config := TOTPConfig{Secret: rfc6238Secret}
+	config.Code = func(addr ids.ShortID, op SignOp) (string, error) {
+		require.Equal(OpSign, op)
+		prompted = append(prompted, addr)
+		if dismiss {
+			return "", errDismissed
+		}
+		return config.Generate(now), nil
+	}
+	signer, setNow := newTOTPSigner(t, config)
+	setNow(now)
+
+	_, err := signer.Sign([]byte("tx"))
+	require.NoError(err)
+	require.Equal([]ids.ShortID{signer.Address()}, prompted)
+
+	dismiss = true
+	_, err = signer.Sign([]byte("tx"))
+	require.ErrorIs(err, ErrTOTPRequired)
+	require.ErrorIs(err, errDismissed)
+}
+
+func TestTOTPAddresses(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("totp"), 2)
+	require.NoError(err)
+	addrs := kc.Addresses().List()
+	middleware, err := TOTP(TOTPConfig{
+		Secret:    rfc6238Secret,
+		Addresses: set.Of(addrs[0]),
+	})
+	require.NoError(err)
+	wrapped := Wrap(kc, middleware)
+
+	// Addresses that are not designated sign without a code
+	signer, _ := wrapped.Get(addrs[1])
+	_, err = signer.Sign([]byte("tx"))
+	require.NoError(err)
+	signer, _ = wrapped.Get(addrs[0])
+	_, err = signer.Sign([]byte("tx"))
+	require.ErrorIs(err, ErrTOTPRequired)
+
+	_, err = TOTP(TOTPConfig{})
+	require.ErrorIs(err, ErrInvalidTOTPSecret)
+	_, err = TOTP(TOTPConfig{Secret: rfc6238Secret, Period: 1500 * time.Millisecond})
+	require.ErrorIs(err, ErrInvalidTOTPConfig)
+	_, err = TOTP(TOTPConfig{Secret: rfc6238Secret, Digits: 10})
+	require.ErrorIs(err, ErrInvalidTOTPConfig)
+}
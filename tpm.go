@@ -0,0 +1,313 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/google/go-tpm/tpm2"
+	"github.com/google/go-tpm/tpm2/transport"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var (
+	ErrInvalidTPMKeysLength = errors.New("number of TPM keys should be greater than 0")
+	ErrUnsupportedTPMKey    = errors.New("TPM key is not a P-256 ECDSA signing key")
+	ErrDuplicateTPMKey      = errors.New("TPM keys hold the same key")
+
+	_ PublicKeySigner = (*tpmSigner)(nil)
+	_ SchemeSigner    = (*tpmSigner)(nil)
+)
+
+// tpmKeyTemplate is the template of the keys created by GenerateTPMKey: a
+// P-256 ECDSA signing key that cannot be duplicated to another TPM or parent
+var tpmKeyTemplate = tpm2.TPMTPublic{
+	Type:    tpm2.TPMAlgECC,
+	NameAlg: tpm2.TPMAlgSHA256,
+	ObjectAttributes: tpm2.TPMAObject{
+		FixedTPM:            true,
+		FixedParent:         true,
+		SensitiveDataOrigin: true,
+		UserWithAuth:        true,
+		SignEncrypt:         true,
+	},
+	Parameters: tpm2.NewTPMUPublicParms(
+		tpm2.TPMAlgECC,
+		&tpm2.TPMSECCParms{
+			CurveID: tpm2.TPMECCNistP256,
+			Scheme: tpm2.TPMTECCScheme{
+				Scheme: tpm2.TPMAlgECDSA,
+				Details: tpm2.NewTPMUAsymScheme(
+					tpm2.TPMAlgECDSA,
+					&tpm2.TPMSSigSchemeECDSA{
+						HashAlg: tpm2.TPMAlgSHA256,
+					},
+				),
+			},
+		},
+	),
+}
+
+// TPMKey is a key created by a TPM. The private part is encrypted by the
+// storage root key of the TPM, so the key can only be loaded, and used, by
+// the TPM that created it. Blobs can be stored like any public data.
+type TPMKey struct {
+	// Public is the marshalled TPM2B_PUBLIC of the key
+	Public []byte `json:"public"`
+	// Private is the marshalled TPM2B_PRIVATE of the key
+	Private []byte `json:"private"`
+}
+
+// OpenTPM opens the TPM of the host: the resource manager of the kernel on
+// Linux, or the TPM Base Services on Windows
+func OpenTPM() (transport.TPMCloser, error) {
+	return transport.OpenTPM()
+}
+
+// GenerateTPMKey creates a P-256 signing key in [tpm] under its storage root
+// key. The private key never leaves the TPM unencrypted.
+func GenerateTPMKey(tpm transport.TPM) (*TPMKey, error) {
+	srk, err := createTPMStorageKey(tpm)
+	if err != nil {
+		return nil, err
+	}
+	defer flushTPMHandle(tpm, srk.ObjectHandle)
+
+	created, err := tpm2.Create{
+		ParentHandle: tpm2.AuthHandle{
+			Handle: srk.ObjectHandle,
+			Name:   srk.Name,
+			Auth:   tpm2.PasswordAuth(nil),
+		},
+		InPublic: tpm2.New2B(tpmKeyTemplate),
+	}.Execute(tpm)
+	if err != nil {
+		return nil, err
+	}
+	return &TPMKey{
+		Public:  tpm2.Marshal(created.OutPublic),
+		Private: tpm2.Marshal(created.OutPrivate),
+	}, nil
+}
+
+// tpmKeychain is a keychain of keys created by a TPM
+type tpmKeychain struct {
+	tpm   transport.TPM
+	addrs set.Set[ids.ShortID]
+	keys  map[ids.ShortID]*tpmKey
+
+	// lock serializes the commands sent to the TPM
+	lock sync.Mutex
+}
+
+type tpmKey struct {
+	public    tpm2.TPM2BPublic
+	private   tpm2.TPM2BPrivate
+	publicKey *ecdsa.PublicKey
+	pubKey    []byte
+}
+
+// NewTPMKeychain creates a keychain of [keys], which must have been created
+// by [tpm]. Each signature loads its key under the storage root key of
+// [tpm] and flushes it afterwards. Signatures are returned as 64 byte r || s,
+// normalized to low-S, and verify under SchemeP256.
+func NewTPMKeychain(tpm transport.TPM, keys []*TPMKey) (Keychain, error) {
+	if len(keys) == 0 {
+		return nil, ErrInvalidTPMKeysLength
+	}
+
+	kc := &tpmKeychain{
+		tpm:   tpm,
+		addrs: make(set.Set[ids.ShortID]),
+		keys:  make(map[ids.ShortID]*tpmKey),
+	}
+	for i, key := range keys {
+		k, err := parseTPMKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		addr := publicKeyAddress(k.pubKey)
+		if kc.addrs.Contains(addr) {
+			return nil, ErrDuplicateTPMKey
+		}
+		kc.addrs.Add(addr)
+		kc.keys[addr] = k
+	}
+	return kc, nil
+}
+
+func parseTPMKey(key *TPMKey) (*tpmKey, error) {
+	public, err := tpm2.Unmarshal[tpm2.TPM2BPublic](key.Public)
+	if err != nil {
+		return nil, err
+	}
+	private, err := tpm2.Unmarshal[tpm2.TPM2BPrivate](key.Private)
+	if err != nil {
+		return nil, err
+	}
+	contents, err := public.Contents()
+	if err != nil {
+		return nil, err
+	}
+	if contents.Type != tpm2.TPMAlgECC || !contents.ObjectAttributes.SignEncrypt {
+		return nil, ErrUnsupportedTPMKey
+	}
+	params, err := contents.Parameters.ECCDetail()
+	if err != nil {
+		return nil, err
+	}
+	if params.CurveID != tpm2.TPMECCNistP256 {
+		return nil, ErrUnsupportedTPMKey
+	}
+	point, err := contents.Unique.ECC()
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := tpm2.ECDSAPub(params, point)
+	if err != nil {
+		return nil, err
+	}
+	if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+		return nil, ErrInvalidPublicKey
+	}
+	return &tpmKey{
+		public:    *public,
+		private:   *private,
+		publicKey: publicKey,
+		pubKey:    compressPublicKey(publicKey.X, publicKey.Y),
+	}, nil
+}
+
+func (t *tpmKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	key, ok := t.keys[addr]
+	if !ok {
+		return nil, false
+	}
+	return &tpmSigner{
+		keychain: t,
+		key:      key,
+		addr:     addr,
+	}, true
+}
+
+func (t *tpmKeychain) Addresses() set.Set[ids.ShortID] {
+	return t.addrs
+}
+
+// sign returns the r and s of the signature of [digest] by [key]
+func (t *tpmKeychain) sign(key *tpmKey, digest []byte) (*big.Int, *big.Int, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	srk, err := createTPMStorageKey(t.tpm)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer flushTPMHandle(t.tpm, srk.ObjectHandle)
+
+	loaded, err := tpm2.Load{
+		ParentHandle: tpm2.AuthHandle{
+			Handle: srk.ObjectHandle,
+			Name:   srk.Name,
+			Auth:   tpm2.PasswordAuth(nil),
+		},
+		InPrivate: key.private,
+		InPublic:  key.public,
+	}.Execute(t.tpm)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer flushTPMHandle(t.tpm, loaded.ObjectHandle)
+
+	signed, err := tpm2.Sign{
+		KeyHandle: tpm2.AuthHandle{
+			Handle: loaded.ObjectHandle,
+			Name:   loaded.Name,
+			Auth:   tpm2.PasswordAuth(nil),
+		},
+		Digest: tpm2.TPM2BDigest{Buffer: digest},
+		InScheme: tpm2.TPMTSigScheme{
+			Scheme: tpm2.TPMAlgECDSA,
+			Details: tpm2.NewTPMUSigScheme(
+				tpm2.TPMAlgECDSA,
+				&tpm2.TPMSSchemeHash{
+					HashAlg: tpm2.TPMAlgSHA256,
+				},
+			),
+		},
+		Validation: tpm2.TPMTTKHashCheck{
+			Tag: tpm2.TPMSTHashCheck,
+		},
+	}.Execute(t.tpm)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := signed.Signature.Signature.ECDSA()
+	if err != nil {
+		return nil, nil, err
+	}
+	r := new(big.Int).SetBytes(sig.SignatureR.Buffer)
+	s := new(big.Int).SetBytes(sig.SignatureS.Buffer)
+	return r, s, nil
+}
+
+// tpmSigner signs with a single key of a TPM
+type tpmSigner struct {
+	keychain *tpmKeychain
+	key      *tpmKey
+	addr     ids.ShortID
+}
+
+func (t *tpmSigner) SignHash(hash []byte) ([]byte, error) {
+	if len(hash) != sha256.Size {
+		return nil, ErrInvalidHashLen
+	}
+	r, s, err := t.keychain.sign(t.key, hash)
+	if err != nil {
+		return nil, err
+	}
+	if !ecdsa.Verify(t.key.publicKey, hash, r, s) {
+		return nil, ErrSignatureInvalid
+	}
+	return compactSignature(elliptic.P256(), r, s), nil
+}
+
+func (t *tpmSigner) Sign(msg []byte) ([]byte, error) {
+	hash := sha256.Sum256(msg)
+	return t.SignHash(hash[:])
+}
+
+func (t *tpmSigner) Address() ids.ShortID {
+	return t.addr
+}
+
+func (t *tpmSigner) PublicKey() []byte {
+	return t.key.pubKey
+}
+
+func (*tpmSigner) Scheme() SchemeID {
+	return SchemeP256
+}
+
+// createTPMStorageKey creates the ECC storage root key of the owner
+// hierarchy. The key is derived from the hierarchy seed, so every call
+// returns the same key.
+func createTPMStorageKey(tpm transport.TPM) (*tpm2.CreatePrimaryResponse, error) {
+	return tpm2.CreatePrimary{
+		PrimaryHandle: tpm2.TPMRHOwner,
+		InPublic:      tpm2.New2B(tpm2.ECCSRKTemplate),
+	}.Execute(tpm)
+}
+
+func flushTPMHandle(tpm transport.TPM, handle tpm2.TPMHandle) {
+	_, _ = tpm2.FlushContext{FlushHandle: handle}.Execute(tpm)
+}
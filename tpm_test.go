@@ -0,0 +1,83 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build cgo
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/google/go-tpm/tpm2/transport"
+	"github.com/google/go-tpm/tpm2/transport/simulator"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestTPM(t *testing.T) transport.TPMCloser {
+	t.Helper()
+
+	tpm, err := simulator.OpenSimulator()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, tpm.Close())
+	})
+	return tpm
+}
+
+func TestTPMKeychain(t *testing.T) {
+	require := require.New(t)
+
+	tpm := openTestTPM(t)
+	first, err := GenerateTPMKey(tpm)
+	require.NoError(err)
+	second, err := GenerateTPMKey(tpm)
+	require.NoError(err)
+
+	kc, err := NewTPMKeychain(tpm, []*TPMKey{first, second})
+	require.NoError(err)
+	require.Equal(2, kc.Addresses().Len())
+
+	msg := []byte("hot key withdrawal")
+	for _, addr := range kc.Addresses().List() {
+		signer, ok := kc.Get(addr)
+		require.True(ok)
+		require.Equal(SchemeP256, SchemeOf(signer))
+
+		sig, err := signer.Sign(msg)
+		require.NoError(err)
+		require.Len(sig, 64)
+		require.NoError(VerifySignature(signer, msg, sig))
+	}
+
+	signer, _ := kc.Get(kc.Addresses().List()[0])
+	_, err = signer.SignHash(msg)
+	require.ErrorIs(err, ErrInvalidHashLen)
+}
+
+func TestTPMKeychainErrors(t *testing.T) {
+	require := require.New(t)
+
+	tpm := openTestTPM(t)
+	_, err := NewTPMKeychain(tpm, nil)
+	require.ErrorIs(err, ErrInvalidTPMKeysLength)
+
+	key, err := GenerateTPMKey(tpm)
+	require.NoError(err)
+	_, err = NewTPMKeychain(tpm, []*TPMKey{key, key})
+	require.ErrorIs(err, ErrDuplicateTPMKey)
+
+	_, err = NewTPMKeychain(tpm, []*TPMKey{{Public: key.Public[:10], Private: key.Private}})
+	require.Error(err)
+
+	// A key blob tampered with outside of the TPM cannot be loaded
+	tampered := &TPMKey{
+		Public:  key.Public,
+		Private: append([]byte(nil), key.Private...),
+	}
+	tampered.Private[len(tampered.Private)-1] ^= 1
+	kc, err := NewTPMKeychain(tpm, []*TPMKey{tampered})
+	require.NoError(err)
+	signer, _ := kc.Get(kc.Addresses().List()[0])
+	_, err = signer.Sign([]byte("msg"))
+	require.Error(err)
+}
@@ -0,0 +1,186 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+
+	"github.com/luxfi/ids"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans of the package
+const tracerName = "github.com/luxfi/keychain"
+
+// Attributes of the spans of Tracing
+const (
+	AttributeBackend    = attribute.Key("keychain.backend")
+	AttributeAddress    = attribute.Key("keychain.address")
+	AttributeOp         = attribute.Key("keychain.op")
+	AttributeErrorClass = attribute.Key("error.type")
+)
+
+// Backends reported by SignerBackend
+const (
+	BackendLedger         = "ledger"
+	BackendHardwareWallet = "hardware_wallet"
+	BackendPIV            = "piv"
+	BackendOpenPGP        = "openpgp"
+	BackendTPM            = "tpm"
+	BackendSecureEnclave  = "secure_enclave"
+	BackendRemote         = "remote"
+	BackendWalletConnect  = "walletconnect"
+	BackendSSHAgent       = "ssh_agent"
+	BackendSoftware       = "software"
+	BackendCryptoSigner   = "crypto_signer"
+	BackendWatchOnly      = "watch_only"
+	BackendUnknown        = "unknown"
+)
+
+// Error classes reported by ErrorClass besides the codes of the remote signer
+// protocol, such as "user_rejected"
+const (
+	errorClassTimeout      = "timeout"
+	errorClassDisconnected = "device_disconnected"
+	errorClassTransient    = "transient"
+	errorClassOther        = "_OTHER"
+)
+
+var (
+	_ Unwrapper     = (*tracingSigner)(nil)
+	_ contextBinder = (*tracingSigner)(nil)
+)
+
+// SignerBackend returns the backend holding the key of [signer], such as
+// BackendLedger, looking through signers that implement Unwrapper
+func SignerBackend(signer Signer) string {
+	for signer != nil {
+		switch signer.(type) {
+		case *ledgerSigner:
+			return BackendLedger
+		case *hardwareSigner:
+			return BackendHardwareWallet
+		case *pivSigner:
+			return BackendPIV
+		case *openPGPSigner:
+			return BackendOpenPGP
+		case *tpmSigner:
+			return BackendTPM
+		case *secureEnclaveSigner:
+			return BackendSecureEnclave
+		case *remoteSigner:
+			return BackendRemote
+		case *walletConnectSigner:
+			return BackendWalletConnect
+		case *sshAgentSigner:
+			return BackendSSHAgent
+		case *softwareSigner:
+			return BackendSoftware
+		case *p256Signer, *ed25519Signer:
+			return BackendCryptoSigner
+		case *watchOnlySigner:
+			return BackendWatchOnly
+		}
+		u, ok := signer.(Unwrapper)
+		if !ok {
+			break
+		}
+		signer = u.Unwrap()
+	}
+	return BackendUnknown
+}
+
+// ErrorClass returns a low cardinality class of [err], suitable for metrics
+// and traces: the code of the remote signer protocol of the sentinel error it
+// matches, such as "user_rejected" or "device_locked", "timeout",
+// "device_disconnected", "transient" for other errors that IsRetryable
+// reports, or "_OTHER". It returns the empty string if [err] is nil.
+func ErrorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	for _, c := range remoteErrorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	switch {
+	case errors.Is(err, ErrOperationTimeout):
+		return errorClassTimeout
+	case errors.Is(err, ErrDeviceDisconnected):
+		return errorClassDisconnected
+	case IsRetryable(err):
+		return errorClassTransient
+	default:
+		return errorClassOther
+	}
+}
+
+// Tracing returns a middleware recording a span of a tracer of [provider] for
+// every signing operation of the signers it wraps, named after the operation,
+// such as "keychain.sign", with the backend, address and operation of the
+// signer as attributes. The spans of the operations that fail record the
+// error and its ErrorClass. Operations signed with SignContext and
+// SignHashContext are children of the span of their context, so that they
+// show up in the distributed trace of the request; the others start a trace
+// of their own.
+func Tracing(provider trace.TracerProvider) Middleware {
+	tracer := provider.Tracer(tracerName)
+	return func(next Signer) Signer {
+		return &tracingSigner{
+			next:   next,
+			tracer: tracer,
+		}
+	}
+}
+
+// tracingSigner records a span for every signing operation
+type tracingSigner struct {
+	next     Signer
+	tracer   trace.Tracer
+	contexts boundContexts
+}
+
+func (t *tracingSigner) SignHash(hash []byte) ([]byte, error) {
+	return t.trace(OpSignHash, hash, t.next.SignHash)
+}
+
+func (t *tracingSigner) Sign(msg []byte) ([]byte, error) {
+	return t.trace(OpSign, msg, t.next.Sign)
+}
+
+func (t *tracingSigner) trace(op SignOp, payload []byte, sign func([]byte) ([]byte, error)) ([]byte, error) {
+	_, span := t.tracer.Start(t.contexts.get(t.next.Address(), op, payload), "keychain."+string(op),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			AttributeBackend.String(SignerBackend(t.next)),
+			AttributeAddress.String(t.next.Address().String()),
+			AttributeOp.String(string(op)),
+		),
+	)
+	defer span.End()
+
+	sig, err := sign(payload)
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(AttributeErrorClass.String(ErrorClass(err)))
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return sig, err
+}
+
+func (t *tracingSigner) Address() ids.ShortID {
+	return t.next.Address()
+}
+
+func (t *tracingSigner) Unwrap() Signer {
+	return t.next
+}
+
+func (t *tracingSigner) bindContext(ctx context.Context, op SignOp, payload []byte) func() {
+	return t.contexts.bind(ctx, t.next.Address(), op, payload)
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanAttributes returns the attributes of [span] by key
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+	attrs := make(map[attribute.Key]string)
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value.Emit()
+	}
+	return attrs
+}
+
+func TestTracing(t *testing.T) {
+	require := require.New(t)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	app := newFakeLuxApp()
+	ledger, err := NewLedgerKeychain(NewLedgerDevice(app), []uint32{0}, WithBlindSigning())
+	require.NoError(err)
+	addr, _ := ledger.Addresses().Peek()
+	signer, _ := Wrap(ledger, Tracing(provider)).Get(addr)
+
+	// Operations signed with a context are children of its span
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+	_, err = SignContext(ctx, signer, []byte("tx"))
+	require.NoError(err)
+	parent.End()
+
+	app.reject = true
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrUserRejected)
+
+	spans := recorder.Ended()
+	require.Len(spans, 3)
+	signed := spans[0]
+	require.Equal("keychain.sign", signed.Name())
+	require.Equal(parent.SpanContext().SpanID(), signed.Parent().SpanID())
+	require.Equal(codes.Unset, signed.Status().Code)
+	require.Equal(map[attribute.Key]string{
+		AttributeBackend: BackendLedger,
+		AttributeAddress: addr.String(),
+		AttributeOp:      string(OpSign),
+	}, spanAttributes(signed))
+
+	rejected := spans[2]
+	require.Equal("keychain.signHash", rejected.Name())
+	require.False(rejected.Parent().IsValid())
+	require.Equal(codes.Error, rejected.Status().Code)
+	require.Equal("user_rejected", spanAttributes(rejected)[AttributeErrorClass])
+	require.Len(rejected.Events(), 1)
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err   error
+		class string
+	}{
+		{err: nil, class: ""},
+		{err: fmt.Errorf("signing: %w", ErrUserRejected), class: "user_rejected"},
+		{err: ErrDeviceLocked, class: "device_locked"},
+		{err: ErrOperationTimeout, class: "timeout"},
+		{err: fmt.Errorf("%w: %w", ErrDeviceDisconnected, io.EOF), class: "device_disconnected"},
+		{err: MarkRetryable(errors.New("throttled")), class: "transient"},
+		{err: errors.New("boom"), class: "_OTHER"},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.class, ErrorClass(test.err), "error %v", test.err)
+	}
+}
+
+func TestSignerBackend(t *testing.T) {
+	require := require.New(t)
+
+	kc, err := NewTestKeychain([]byte("tracing"), 1)
+	require.NoError(err)
+	addr, _ := kc.Addresses().Peek()
+	signer, _ := Wrap(kc, Intercept(noopInterceptor), LowS()).Get(addr)
+	require.Equal(BackendSoftware, SignerBackend(signer))
+
+	p256, err := NewP256Signer(newP256Key(t))
+	require.NoError(err)
+	require.Equal(BackendCryptoSigner, SignerBackend(p256))
+	require.Equal(BackendUnknown, SignerBackend(&schemeSigner{Signer: signer}))
+}
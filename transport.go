@@ -0,0 +1,37 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+var _ Transport = TransportFunc(nil)
+
+// Transport exchanges APDUs with a ledger device. LedgerDevice speaks the Lux
+// app protocol over any Transport, so devices can be reached over USB HID
+// (NewHIDTransport), bluetooth (NewBLETransport), the Speculos emulator
+// (DialSpeculos) or a transport supplied by the caller, such as one proxying
+// APDUs to a remote host.
+type Transport interface {
+	// Exchange sends a command APDU and returns the response APDU, including
+	// its trailing status word
+	Exchange(apdu []byte) ([]byte, error)
+	// Close releases the connection to the device
+	Close() error
+}
+
+// TransportFunc adapts a function exchanging APDUs to a Transport whose Close
+// does nothing, for transports that hold no connection of their own or that
+// wrap another transport:
+//
+//	logged := keychain.TransportFunc(func(apdu []byte) ([]byte, error) {
+//		log.Printf("-> %x", apdu)
+//		return transport.Exchange(apdu)
+//	})
+type TransportFunc func(apdu []byte) ([]byte, error)
+
+func (f TransportFunc) Exchange(apdu []byte) ([]byte, error) {
+	return f(apdu)
+}
+
+func (TransportFunc) Close() error {
+	return nil
+}
@@ -0,0 +1,30 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportFunc(t *testing.T) {
+	require := require.New(t)
+
+	app := newFakeLuxApp()
+	var apdus [][]byte
+	proxy := TransportFunc(func(apdu []byte) ([]byte, error) {
+		apdus = append(apdus, apdu)
+		return app.Exchange(apdu)
+	})
+
+	device := NewLedgerDevice(proxy)
+	version, err := device.Version()
+	require.NoError(err)
+	require.Equal(LedgerVersion{Major: 1, Minor: 2, Patch: 3}, version)
+	require.Equal([][]byte{{ledgerCLA, insGetVersion, 0, 0, 0}}, apdus)
+
+	require.NoError(device.Disconnect())
+	require.False(app.closed)
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luxfi/ids"
+)
+
+var (
+	ErrKeyExpired      = errors.New("key has expired")
+	ErrKeyNotYetValid  = errors.New("key is not yet valid")
+	ErrInvalidValidity = errors.New("validity window ends before it starts")
+)
+
+// Validity is the window during which a key may sign. A zero bound leaves
+// that side of the window open.
+type Validity struct {
+	NotBefore time.Time `json:"notBefore,omitzero"`
+	NotAfter  time.Time `json:"notAfter,omitzero"`
+}
+
+// Check returns an error if [now] is outside of the validity window
+func (v Validity) Check(now time.Time) error {
+	if !v.NotBefore.IsZero() && now.Before(v.NotBefore) {
+		return fmt.Errorf("%w: valid from %s", ErrKeyNotYetValid, v.NotBefore.Format(time.RFC3339))
+	}
+	if !v.NotAfter.IsZero() && now.After(v.NotAfter) {
+		return fmt.Errorf("%w: expired at %s", ErrKeyExpired, v.NotAfter.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// KeyValidity is the validity window of the key of an address
+type KeyValidity struct {
+	Address ids.ShortID `json:"address"`
+	Validity
+}
+
+// SetValidity sets the window during which the key of [addr] may sign. A
+// zero Validity removes any previously set window.
+func (kc *SoftwareKeychain) SetValidity(addr ids.ShortID, validity Validity) error {
+	if !validity.NotBefore.IsZero() && !validity.NotAfter.IsZero() && validity.NotAfter.Before(validity.NotBefore) {
+		return ErrInvalidValidity
+	}
+
+	kc.lock.Lock()
+	defer kc.lock.Unlock()
+
+	if _, ok := kc.keys[addr]; !ok {
+		return ErrUnknownAddress
+	}
+	if validity == (Validity{}) {
+		delete(kc.validity, addr)
+	} else {
+		kc.validity[addr] = validity
+	}
+	return nil
+}
+
+// Validity returns the validity window of the key of [addr]. Returns false if
+// no window is set.
+func (kc *SoftwareKeychain) Validity(addr ids.ShortID) (Validity, bool) {
+	kc.lock.RLock()
+	defer kc.lock.RUnlock()
+
+	validity, ok := kc.validity[addr]
+	return validity, ok
+}
+
+func (kc *SoftwareKeychain) validities() []KeyValidity {
+	kc.lock.RLock()
+	defer kc.lock.RUnlock()
+
+	validities := make([]KeyValidity, 0, len(kc.validity))
+	for addr, validity := range kc.validity {
+		validities = append(validities, KeyValidity{
+			Address:  addr,
+			Validity: validity,
+		})
+	}
+	return validities
+}
+
+// AllowExpired returns a signer that signs with the key of [signer] even if
+// it is outside of its validity window. Retired keys still refuse to sign.
+// Signers that do not enforce a validity window are returned unchanged.
+func AllowExpired(signer Signer) Signer {
+	s, ok := signer.(*softwareSigner)
+	if !ok {
+		return signer
+	}
+	override := *s
+	override.ignoreValidity = true
+	return &override
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+)
+
+func TestValidityCheck(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Now()
+	require.NoError(Validity{}.Check(now))
+	require.NoError(Validity{NotBefore: now, NotAfter: now}.Check(now))
+	require.ErrorIs(Validity{NotBefore: now.Add(time.Second)}.Check(now), ErrKeyNotYetValid)
+	require.ErrorIs(Validity{NotAfter: now.Add(-time.Second)}.Check(now), ErrKeyExpired)
+}
+
+func TestSoftwareKeychainValidity(t *testing.T) {
+	require := require.New(t)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	kc := NewSoftwareKeychain()
+	kc.now = func() time.Time { return now }
+
+	key, err := kc.New()
+	require.NoError(err)
+	addr := key.Address()
+
+	validity := Validity{
+		NotBefore: now.Add(time.Hour),
+		NotAfter:  now.Add(2 * time.Hour),
+	}
+	require.NoError(kc.SetValidity(addr, validity))
+	got, ok := kc.Validity(addr)
+	require.True(ok)
+	require.Equal(validity, got)
+
+	signer, ok := kc.Get(addr)
+	require.True(ok)
+	_, err = signer.Sign([]byte("payload"))
+	require.ErrorIs(err, ErrKeyNotYetValid)
+
+	now = now.Add(90 * time.Minute)
+	_, err = signer.Sign([]byte("payload"))
+	require.NoError(err)
+
+	now = now.Add(time.Hour)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrKeyExpired)
+
+	// The validity window can be explicitly overridden
+	_, err = AllowExpired(signer).SignHash(make([]byte, 32))
+	require.NoError(err)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrKeyExpired)
+
+	// But retirement cannot
+	require.NoError(kc.Retire(addr))
+	_, err = AllowExpired(signer).Sign([]byte("payload"))
+	require.ErrorIs(err, ErrKeyRetired)
+
+	require.NoError(kc.SetValidity(addr, Validity{}))
+	_, ok = kc.Validity(addr)
+	require.False(ok)
+
+	require.ErrorIs(kc.SetValidity(addr, Validity{NotBefore: now, NotAfter: now.Add(-time.Second)}), ErrInvalidValidity)
+	require.ErrorIs(kc.SetValidity(ids.GenerateTestShortID(), validity), ErrUnknownAddress)
+}
+
+func TestBundleValidity(t *testing.T) {
+	require := require.New(t)
+	lightBundleScrypt(t)
+
+	kc := NewSoftwareKeychain()
+	key, err := kc.New()
+	require.NoError(err)
+	validity := Validity{NotAfter: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+	require.NoError(kc.SetValidity(key.Address(), validity))
+
+	b, err := NewBundle(kc)
+	require.NoError(err)
+	pass := []byte("password")
+	var buf bytes.Buffer
+	require.NoError(b.Export(&buf, pass))
+
+	imported, err := Import(&buf, pass)
+	require.NoError(err)
+	got, ok := imported.SoftwareKeychain().Validity(key.Address())
+	require.True(ok)
+	require.True(validity.NotAfter.Equal(got.NotAfter))
+	require.True(got.NotBefore.IsZero())
+}
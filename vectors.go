@@ -0,0 +1,190 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/luxfi/crypto/address"
+	"github.com/luxfi/ids"
+)
+
+var (
+	ErrVectorMismatch    = errors.New("derivation does not match the test vector")
+	ErrVectorUnsupported = errors.New("test vector is not supported by the deriver")
+)
+
+// derivationVectorsJSON are the published derivation test vectors
+//
+//go:embed vectors.json
+var derivationVectorsJSON []byte
+
+// DerivationVector is a test vector of the derivation of an address from a
+// mnemonic. The vectors are published as the JSON array returned by
+// DerivationVectorsJSON, so that third-party wallets can check their
+// derivation without linking this package.
+type DerivationVector struct {
+	Mnemonic   string         `json:"mnemonic"`
+	Passphrase string         `json:"passphrase"`
+	Scheme     SchemeID       `json:"scheme"`
+	Path       DerivationPath `json:"path"`
+	// PublicKey is the hex encoded public key, as reported by the signer
+	// of the scheme
+	PublicKey string `json:"publicKey"`
+	// Address is the address of the key, bech32 encoded with the mainnet
+	// HRP as in the addresses of every chain
+	Address string `json:"address"`
+	// EVMAddress is the checksummed EVM address of secp256k1 keys
+	EVMAddress string `json:"evmAddress,omitempty"`
+}
+
+// String identifies the vector in errors
+func (v DerivationVector) String() string {
+	return fmt.Sprintf("%s %s", v.Scheme, v.Path)
+}
+
+// DerivationVectorsJSON returns the published derivation test vectors
+func DerivationVectorsJSON() []byte {
+	return bytes.Clone(derivationVectorsJSON)
+}
+
+// DerivationVectors returns the published derivation test vectors, which
+// cover the BIP44 Lux and Ethereum paths of secp256k1 keys and the SLIP-0010
+// paths of ed25519 keys, with and without a passphrase
+func DerivationVectors() []DerivationVector {
+	var vectors []DerivationVector
+	if err := json.Unmarshal(derivationVectorsJSON, &vectors); err != nil {
+		panic(err)
+	}
+	return vectors
+}
+
+// DerivedKey is the public key and address an implementation derives for a
+// DerivationVector
+type DerivedKey struct {
+	// PublicKey may be nil for implementations that only report addresses,
+	// such as ledger devices
+	PublicKey []byte
+	Address   ids.ShortID
+}
+
+// VectorDeriver derives the key of [v]. It fails with ErrVectorUnsupported
+// for vectors it does not implement, such as those of other schemes or of a
+// mnemonic other than the one a device is seeded with.
+type VectorDeriver func(v DerivationVector) (DerivedKey, error)
+
+// VerifyVectors derives every vector of [vectors] with [derive] and checks
+// that the public keys and addresses match, returning the number of vectors
+// verified. Unsupported vectors are skipped; mismatches and other failures
+// are joined in the returned error, each wrapping ErrVectorMismatch or the
+// error of [derive].
+func VerifyVectors(derive VectorDeriver, vectors []DerivationVector) (int, error) {
+	var (
+		verified int
+		errs     []error
+	)
+	for _, v := range vectors {
+		err := verifyVector(derive, v)
+		switch {
+		case errors.Is(err, ErrVectorUnsupported):
+			continue
+		case err != nil:
+			errs = append(errs, fmt.Errorf("%s: %w", v, err))
+			continue
+		}
+		verified++
+	}
+	return verified, errors.Join(errs...)
+}
+
+func verifyVector(derive VectorDeriver, v DerivationVector) error {
+	_, addrBytes, err := address.ParseBech32(v.Address)
+	if err != nil {
+		return err
+	}
+	want, err := ids.ToShortID(addrBytes)
+	if err != nil {
+		return err
+	}
+
+	key, err := derive(v)
+	if err != nil {
+		return err
+	}
+	if key.Address != want {
+		return fmt.Errorf("%w: expected address %s but got %s", ErrVectorMismatch, want, key.Address)
+	}
+	if key.PublicKey != nil && !strings.EqualFold(hex.EncodeToString(key.PublicKey), v.PublicKey) {
+		return fmt.Errorf("%w: expected public key %s but got %x", ErrVectorMismatch, v.PublicKey, key.PublicKey)
+	}
+	return nil
+}
+
+// SoftwareVectorDeriver derives the vectors of every built-in scheme with
+// DeriveKey and DeriveEd25519Key, as mnemonic keychains do
+func SoftwareVectorDeriver(v DerivationVector) (DerivedKey, error) {
+	seed, err := MnemonicSeed(v.Mnemonic, v.Passphrase)
+	if err != nil {
+		return DerivedKey{}, err
+	}
+	defer clear(seed)
+
+	switch v.Scheme {
+	case SchemeSecp256k1:
+		key, err := DeriveKey(seed, v.Path)
+		if err != nil {
+			return DerivedKey{}, err
+		}
+		defer WipeKey(key)
+		return DerivedKey{
+			PublicKey: key.PublicKey().Bytes(),
+			Address:   key.Address(),
+		}, nil
+	case SchemeEd25519:
+		key, err := DeriveEd25519Key(seed, v.Path)
+		if err != nil {
+			return DerivedKey{}, err
+		}
+		defer clear(key)
+		pub := key.Public().(ed25519.PublicKey)
+		return DerivedKey{
+			PublicKey: pub,
+			Address:   Ed25519Address(pub),
+		}, nil
+	default:
+		return DerivedKey{}, fmt.Errorf("%w: scheme %s", ErrVectorUnsupported, v.Scheme)
+	}
+}
+
+// LedgerVectorDeriver derives the vectors of a ledger device seeded with
+// [mnemonic], such as an emulator started with the mnemonic of the vectors.
+// Only the secp256k1 vectors of [mnemonic] without a passphrase on the Lux
+// address paths the Lux app derives are supported.
+func LedgerVectorDeriver(ledger Ledger, mnemonic string) VectorDeriver {
+	mnemonic = normalizeMnemonic(mnemonic)
+	return func(v DerivationVector) (DerivedKey, error) {
+		if v.Scheme != SchemeSecp256k1 || v.Passphrase != "" || normalizeMnemonic(v.Mnemonic) != mnemonic {
+			return DerivedKey{}, ErrVectorUnsupported
+		}
+		idx, err := v.Path.AddressIndex()
+		if err != nil {
+			return DerivedKey{}, fmt.Errorf("%w: %w", ErrVectorUnsupported, err)
+		}
+		addrs, err := ledger.GetAddresses([]uint32{idx})
+		if err != nil {
+			return DerivedKey{}, err
+		}
+		if len(addrs) != 1 {
+			return DerivedKey{}, ErrInvalidNumAddrsDerived
+		}
+		return DerivedKey{Address: addrs[0]}, nil
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+)
+
+func TestDerivationVectors(t *testing.T) {
+	require := require.New(t)
+
+	vectors := DerivationVectors()
+	require.NotEmpty(vectors)
+	var published []DerivationVector
+	require.NoError(json.Unmarshal(DerivationVectorsJSON(), &published))
+	require.Equal(vectors, published)
+
+	verified, err := VerifyVectors(SoftwareVectorDeriver, vectors)
+	require.NoError(err)
+	require.Equal(len(vectors), verified)
+
+	// The vectors agree with the first Ethereum account of the BIP39 test
+	// mnemonic as derived by every Ethereum wallet
+	found := false
+	for _, v := range vectors {
+		if v.Mnemonic == testMnemonic && v.Passphrase == "" && v.Path.String() == "m/44'/60'/0'/0/0" {
+			require.Equal("0x9858EfFD232B4033E47d90003D41EC34EcaEda94", v.EVMAddress)
+			found = true
+		}
+	}
+	require.True(found)
+}
+
+func TestVerifyVectorsMismatch(t *testing.T) {
+	require := require.New(t)
+
+	vectors := DerivationVectors()[:2]
+	verified, err := VerifyVectors(func(v DerivationVector) (DerivedKey, error) {
+		key, err := SoftwareVectorDeriver(v)
+		key.Address = ids.GenerateTestShortID()
+		return key, err
+	}, vectors)
+	require.ErrorIs(err, ErrVectorMismatch)
+	require.Zero(verified)
+
+	verified, err = VerifyVectors(func(v DerivationVector) (DerivedKey, error) {
+		key, err := SoftwareVectorDeriver(v)
+		key.PublicKey = []byte{1}
+		return key, err
+	}, vectors)
+	require.ErrorIs(err, ErrVectorMismatch)
+	require.Zero(verified)
+}
+
+func TestLedgerVectorDeriver(t *testing.T) {
+	require := require.New(t)
+
+	// A device seeded with the test mnemonic
+	seed, err := MnemonicSeed(testMnemonic, "")
+	require.NoError(err)
+	ledger := newMockLedger()
+	for idx := range uint32(3) {
+		key, err := DeriveKey(seed, AddressPath(idx))
+		require.NoError(err)
+		ledger.addresses[idx] = key.Address()
+	}
+
+	// Only the Lux address paths of the device's mnemonic are derived
+	verified, err := VerifyVectors(LedgerVectorDeriver(ledger, testMnemonic), DerivationVectors())
+	require.NoError(err)
+	require.Equal(3, verified)
+
+	// A device seeded with another mnemonic fails the vectors
+	ledger.addresses[1] = ids.GenerateTestShortID()
+	verified, err = VerifyVectors(LedgerVectorDeriver(ledger, testMnemonic), DerivationVectors())
+	require.ErrorIs(err, ErrVectorMismatch)
+	require.Equal(2, verified)
+}
@@ -0,0 +1,202 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+// JSON-RPC methods forwarded to a WalletConnect wallet
+const (
+	WalletConnectMethodSignHash        = "lux_signHash"
+	WalletConnectMethodSignTransaction = "lux_signTransaction"
+)
+
+// defaultWalletConnectTimeout bounds how long a wallet has to answer a
+// signing request, which includes the user confirming it on the phone
+const defaultWalletConnectTimeout = 5 * time.Minute
+
+var (
+	ErrInvalidWalletConnectURI = errors.New("invalid WalletConnect pairing URI")
+	ErrNoSessionAccounts       = errors.New("WalletConnect session exposes no accounts")
+
+	_ Signer = (*walletConnectSigner)(nil)
+)
+
+// WalletConnectSession is an established WalletConnect session with a remote
+// wallet. Implementations wrap a WalletConnect client, which handles pairing,
+// the relay connection and payload encryption.
+type WalletConnectSession interface {
+	// Accounts returns the addresses the wallet approved for the session
+	Accounts() ([]ids.ShortID, error)
+	// Request sends a JSON-RPC request to the wallet and returns the raw
+	// result once the wallet responds
+	Request(ctx context.Context, method string, params any) (json.RawMessage, error)
+}
+
+// WalletConnectURI is a parsed WalletConnect v2 pairing URI of the form
+// wc:<topic>@2?relay-protocol=irn&symKey=<key>
+type WalletConnectURI struct {
+	Topic         string
+	Version       string
+	RelayProtocol string
+	SymKey        []byte
+}
+
+// ParseWalletConnectURI parses a WalletConnect v2 pairing URI, as displayed
+// in the QR code scanned by the mobile wallet
+func ParseWalletConnectURI(uri string) (WalletConnectURI, error) {
+	rest, ok := strings.CutPrefix(uri, "wc:")
+	if !ok {
+		return WalletConnectURI{}, ErrInvalidWalletConnectURI
+	}
+	path, query, _ := strings.Cut(rest, "?")
+	topic, version, ok := strings.Cut(path, "@")
+	if !ok || topic == "" || version != "2" {
+		return WalletConnectURI{}, ErrInvalidWalletConnectURI
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return WalletConnectURI{}, ErrInvalidWalletConnectURI
+	}
+	symKey, err := hex.DecodeString(values.Get("symKey"))
+	if err != nil || len(symKey) != 32 {
+		return WalletConnectURI{}, ErrInvalidWalletConnectURI
+	}
+	relayProtocol := values.Get("relay-protocol")
+	if relayProtocol == "" {
+		return WalletConnectURI{}, ErrInvalidWalletConnectURI
+	}
+	return WalletConnectURI{
+		Topic:         topic,
+		Version:       version,
+		RelayProtocol: relayProtocol,
+		SymKey:        symKey,
+	}, nil
+}
+
+// walletConnectKeychain is a keychain of the accounts of a WalletConnect
+// session
+type walletConnectKeychain struct {
+	session WalletConnectSession
+	timeout time.Duration
+	addrs   set.Set[ids.ShortID]
+}
+
+// NewWalletConnectKeychain creates a keychain of the accounts approved in
+// [session]. Signing requests are forwarded to the wallet and fail if it does
+// not respond within [timeout]; a zero timeout uses a default of five
+// minutes.
+//
+// SignHash is forwarded as lux_signHash and Sign, which is given unsigned
+// transaction bytes, as lux_signTransaction so that the wallet can decode and
+// display the transaction. Both take {"address", "payload"} parameters with a
+// hex encoded payload and return a hex encoded 65 byte recoverable
+// signature, whose recovery id may be given as 27 or 28. Signatures are
+// checked to recover the address they were requested for, failing with
+// ErrSignatureInvalid otherwise; Sign signatures are over SHA-256 of the
+// transaction bytes.
+func NewWalletConnectKeychain(session WalletConnectSession, timeout time.Duration) (Keychain, error) {
+	accounts, err := session.Accounts()
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, ErrNoSessionAccounts
+	}
+	if timeout <= 0 {
+		timeout = defaultWalletConnectTimeout
+	}
+	return &walletConnectKeychain{
+		session: session,
+		timeout: timeout,
+		addrs:   set.Of(accounts...),
+	}, nil
+}
+
+func (w *walletConnectKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	if !w.addrs.Contains(addr) {
+		return nil, false
+	}
+	return &walletConnectSigner{
+		keychain: w,
+		addr:     addr,
+	}, true
+}
+
+func (w *walletConnectKeychain) Addresses() set.Set[ids.ShortID] {
+	return w.addrs
+}
+
+// walletConnectSignParams are the parameters of a signing request
+type walletConnectSignParams struct {
+	Address string `json:"address"`
+	Payload string `json:"payload"`
+}
+
+type walletConnectSigner struct {
+	keychain *walletConnectKeychain
+	addr     ids.ShortID
+}
+
+func (w *walletConnectSigner) SignHash(hash []byte) ([]byte, error) {
+	return w.request(WalletConnectMethodSignHash, hash, hash)
+}
+
+func (w *walletConnectSigner) Sign(msg []byte) ([]byte, error) {
+	hash := sha256.Sum256(msg)
+	return w.request(WalletConnectMethodSignTransaction, msg, hash[:])
+}
+
+func (w *walletConnectSigner) Address() ids.ShortID {
+	return w.addr
+}
+
+// request sends [payload] to be signed by [method] and checks that the
+// signature returned by the wallet is a signature of [hash] by the address
+// of the signer
+func (w *walletConnectSigner) request(method string, payload, hash []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.keychain.timeout)
+	defer cancel()
+
+	result, err := w.keychain.session.Request(ctx, method, walletConnectSignParams{
+		Address: w.addr.String(),
+		Payload: "0x" + hex.EncodeToString(payload),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var sigHex string
+	if err := json.Unmarshal(result, &sigHex); err != nil {
+		return nil, ErrInvalidSignatureEncoding
+	}
+	sig, err := hex.DecodeString(strings.TrimPrefix(sigHex, "0x"))
+	if err != nil {
+		return nil, ErrInvalidSignatureEncoding
+	}
+	if len(sig) != secp256k1.SignatureLen {
+		return nil, ErrInvalidSignatureLen
+	}
+	// Wallets following the Ethereum convention offset the recovery id by 27
+	if v := sig[secp256k1.SignatureLen-1]; v == 27 || v == 28 {
+		sig[secp256k1.SignatureLen-1] = v - 27
+	}
+	pubKey, err := secp256k1.RecoverPublicKeyFromHash(hash, sig)
+	if err != nil || pubKey.Address() != w.addr {
+		return nil, ErrSignatureInvalid
+	}
+	return sig, nil
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/stretchr/testify/require"
+)
+
+// mockWalletConnectSession answers requests by signing with a software key
+type mockWalletConnectSession struct {
+	key     *secp256k1.PrivateKey
+	methods []string
+	block   bool
+	// signingKey, if set, signs in place of key
+	signingKey *secp256k1.PrivateKey
+	// ethereumV offsets the recovery id of signatures by 27
+	ethereumV bool
+}
+
+func (m *mockWalletConnectSession) Accounts() ([]ids.ShortID, error) {
+	return []ids.ShortID{m.key.Address()}, nil
+}
+
+func (m *mockWalletConnectSession) Request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if m.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	m.methods = append(m.methods, method)
+
+	p := params.(walletConnectSignParams)
+	payload, err := hex.DecodeString(strings.TrimPrefix(p.Payload, "0x"))
+	if err != nil {
+		return nil, err
+	}
+
+	key := m.key
+	if m.signingKey != nil {
+		key = m.signingKey
+	}
+	var sig []byte
+	switch method {
+	case WalletConnectMethodSignHash:
+		sig, err = key.SignHash(payload)
+	default:
+		sig, err = key.Sign(payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.ethereumV {
+		sig[len(sig)-1] += 27
+	}
+	return json.Marshal("0x" + hex.EncodeToString(sig))
+}
+
+func TestWalletConnectKeychain(t *testing.T) {
+	require := require.New(t)
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	session := &mockWalletConnectSession{key: key}
+
+	kc, err := NewWalletConnectKeychain(session, 0)
+	require.NoError(err)
+	require.True(kc.Addresses().Contains(key.Address()))
+
+	signer, ok := kc.Get(key.Address())
+	require.True(ok)
+
+	sig, err := signer.Sign([]byte("unsigned tx"))
+	require.NoError(err)
+	require.True(key.PublicKey().Verify([]byte("unsigned tx"), sig))
+
+	hash := make([]byte, 32)
+	sig, err = signer.SignHash(hash)
+	require.NoError(err)
+	require.True(key.PublicKey().VerifyHash(hash, sig))
+
+	require.Equal([]string{WalletConnectMethodSignTransaction, WalletConnectMethodSignHash}, session.methods)
+
+	_, ok = kc.Get(ids.ShortEmpty)
+	require.False(ok)
+}
+
+func TestWalletConnectKeychainTimeout(t *testing.T) {
+	require := require.New(t)
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	kc, err := NewWalletConnectKeychain(&mockWalletConnectSession{key: key, block: true}, 10*time.Millisecond)
+	require.NoError(err)
+
+	signer, _ := kc.Get(key.Address())
+	_, err = signer.Sign([]byte("unsigned tx"))
+	require.ErrorIs(err, context.DeadlineExceeded)
+}
+
+func TestWalletConnectKeychainInvalidSignatures(t *testing.T) {
+	require := require.New(t)
+
+	key, err := secp256k1.NewPrivateKey()
+	require.NoError(err)
+	session := &mockWalletConnectSession{key: key, ethereumV: true}
+	kc, err := NewWalletConnectKeychain(session, 0)
+	require.NoError(err)
+	signer, _ := kc.Get(key.Address())
+
+	// Recovery ids of 27 and 28 are normalized to 0 and 1
+	sig, err := signer.Sign([]byte("unsigned tx"))
+	require.NoError(err)
+	require.True(key.PublicKey().Verify([]byte("unsigned tx"), sig))
+
+	// Signatures of another key are rejected
+	session.ethereumV = false
+	session.signingKey, err = secp256k1.NewPrivateKey()
+	require.NoError(err)
+	_, err = signer.Sign([]byte("unsigned tx"))
+	require.ErrorIs(err, ErrSignatureInvalid)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrSignatureInvalid)
+}
+
+func TestParseWalletConnectURI(t *testing.T) {
+	require := require.New(t)
+
+	symKey := strings.Repeat("ab", 32)
+	uri, err := ParseWalletConnectURI("wc:7f6e504bfad60b485450578e05678ed3e8e8c4751d3c6160be17160d63ec90f9@2?relay-protocol=irn&symKey=" + symKey)
+	require.NoError(err)
+	require.Equal("7f6e504bfad60b485450578e05678ed3e8e8c4751d3c6160be17160d63ec90f9", uri.Topic)
+	require.Equal("2", uri.Version)
+	require.Equal("irn", uri.RelayProtocol)
+	require.Len(uri.SymKey, 32)
+
+	for _, invalid := range []string{
+		"",
+		"https://example.com",
+		"wc:topic@1?relay-protocol=irn&symKey=" + symKey,
+		"wc:@2?relay-protocol=irn&symKey=" + symKey,
+		"wc:topic@2?relay-protocol=irn&symKey=abcd",
+		"wc:topic@2?symKey=" + symKey,
+	} {
+		_, err := ParseWalletConnectURI(invalid)
+		require.ErrorIs(err, ErrInvalidWalletConnectURI, invalid)
+	}
+}
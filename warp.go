@@ -0,0 +1,290 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/luxfi/crypto/bls"
+	"github.com/luxfi/ids"
+)
+
+// warpCodecVersion prefixes the encoding of warp messages and payloads
+const warpCodecVersion uint16 = 0
+
+// Type IDs of the warp payloads
+const (
+	warpHashTypeID          uint32 = 0
+	warpAddressedCallTypeID uint32 = 1
+)
+
+var (
+	ErrInvalidWarpMessage     = errors.New("invalid warp message")
+	ErrInvalidWarpPayload     = errors.New("invalid warp payload")
+	ErrWrongWarpNetwork       = errors.New("warp message is from another network")
+	ErrWrongWarpSourceChain   = errors.New("warp message is from another source chain")
+	ErrInvalidWarpSignature   = errors.New("invalid warp signature")
+	ErrNoWarpSignatures       = errors.New("no warp signatures to aggregate")
+	ErrWarpSignatureMismatch  = errors.New("number of warp signatures does not match the validators")
+	ErrUnknownWarpPayloadType = errors.New("unknown warp payload type")
+
+	_ WarpPayload = (*WarpHash)(nil)
+	_ WarpPayload = (*WarpAddressedCall)(nil)
+)
+
+// WarpUnsignedMessage is a warp message, as signed by the validators of its
+// source chain and delivered by relayers to its destination
+type WarpUnsignedMessage struct {
+	NetworkID     uint32
+	SourceChainID ids.ID
+	// Payload is the encoding of a WarpPayload
+	Payload []byte
+}
+
+// Bytes returns the encoding of the message, which is what validators sign:
+//
+//	codec version (2) || network ID (4) || source chain ID (32) ||
+//	payload length (4) || payload
+func (m *WarpUnsignedMessage) Bytes() []byte {
+	b := make([]byte, 0, 2+4+len(ids.ID{})+4+len(m.Payload))
+	b = binary.BigEndian.AppendUint16(b, warpCodecVersion)
+	b = binary.BigEndian.AppendUint32(b, m.NetworkID)
+	b = append(b, m.SourceChainID[:]...)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(m.Payload)))
+	return append(b, m.Payload...)
+}
+
+// ID returns the SHA-256 of the encoding of the message
+func (m *WarpUnsignedMessage) ID() ids.ID {
+	return sha256.Sum256(m.Bytes())
+}
+
+// ParseWarpUnsignedMessage parses the encoding of a warp message, as returned
+// by Bytes
+func ParseWarpUnsignedMessage(b []byte) (*WarpUnsignedMessage, error) {
+	r := warpReader{b: b}
+	if r.uint16() != warpCodecVersion {
+		return nil, ErrInvalidWarpMessage
+	}
+	m := &WarpUnsignedMessage{NetworkID: r.uint32()}
+	copy(m.SourceChainID[:], r.next(len(ids.ID{})))
+	m.Payload = r.bytes()
+	if r.err || len(r.b) != 0 {
+		return nil, ErrInvalidWarpMessage
+	}
+	return m, nil
+}
+
+// WarpPayload is the payload of a warp message
+type WarpPayload interface {
+	Bytes() []byte
+}
+
+// WarpHash commits a warp message to a 32 byte hash, such as the SHA-256 of
+// data too large to be carried by the message, which the destination checks
+// against data it already has
+type WarpHash struct {
+	Hash ids.ID
+}
+
+// NewWarpHash returns the payload committing to the SHA-256 of [data]
+func NewWarpHash(data []byte) *WarpHash {
+	return &WarpHash{Hash: sha256.Sum256(data)}
+}
+
+// Bytes returns codec version (2) || type ID 0 (4) || hash (32)
+func (h *WarpHash) Bytes() []byte {
+	b := make([]byte, 0, 2+4+len(ids.ID{}))
+	b = binary.BigEndian.AppendUint16(b, warpCodecVersion)
+	b = binary.BigEndian.AppendUint32(b, warpHashTypeID)
+	return append(b, h.Hash[:]...)
+}
+
+// WarpAddressedCall carries [Payload] from [SourceAddress], the contract or
+// account of the source chain that sent the message, as used by teleporter
+type WarpAddressedCall struct {
+	SourceAddress []byte
+	Payload       []byte
+}
+
+// Bytes returns codec version (2) || type ID 1 (4) || source address length
+// (4) || source address || payload length (4) || payload
+func (c *WarpAddressedCall) Bytes() []byte {
+	b := make([]byte, 0, 2+4+4+len(c.SourceAddress)+4+len(c.Payload))
+	b = binary.BigEndian.AppendUint16(b, warpCodecVersion)
+	b = binary.BigEndian.AppendUint32(b, warpAddressedCallTypeID)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(c.SourceAddress)))
+	b = append(b, c.SourceAddress...)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(c.Payload)))
+	return append(b, c.Payload...)
+}
+
+// ParseWarpPayload parses the payload of a warp message into a *WarpHash or
+// a *WarpAddressedCall
+func ParseWarpPayload(b []byte) (WarpPayload, error) {
+	r := warpReader{b: b}
+	if r.uint16() != warpCodecVersion {
+		return nil, ErrInvalidWarpPayload
+	}
+	var payload WarpPayload
+	switch typeID := r.uint32(); typeID {
+	case warpHashTypeID:
+		h := &WarpHash{}
+		copy(h.Hash[:], r.next(len(ids.ID{})))
+		payload = h
+	case warpAddressedCallTypeID:
+		payload = &WarpAddressedCall{
+			SourceAddress: r.bytes(),
+			Payload:       r.bytes(),
+		}
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownWarpPayloadType, typeID)
+	}
+	if r.err || len(r.b) != 0 {
+		return nil, ErrInvalidWarpPayload
+	}
+	return payload, nil
+}
+
+// WarpSigner signs the warp messages of one source chain with a validator
+// BLS key, such as a StakingKey
+type WarpSigner struct {
+	signer    bls.Signer
+	networkID uint32
+	chainID   ids.ID
+}
+
+// NewWarpSigner returns a signer of the warp messages sent by [chainID] on
+// [networkID] with [signer]
+func NewWarpSigner(signer bls.Signer, networkID uint32, chainID ids.ID) *WarpSigner {
+	return &WarpSigner{
+		signer:    signer,
+		networkID: networkID,
+		chainID:   chainID,
+	}
+}
+
+// PublicKeyBytes returns the compressed public key of the validator
+func (w *WarpSigner) PublicKeyBytes() []byte {
+	return bls.PublicKeyToCompressedBytes(w.signer.PublicKey())
+}
+
+// Sign returns the BLS signature of the encoding of [msg]. The encoding is
+// signed as is, since BLS hashes messages to the curve itself; there is no
+// entry point signing a precomputed hash. Messages of other networks or
+// source chains fail with ErrWrongWarpNetwork or ErrWrongWarpSourceChain, so
+// that the key does not vouch for messages its chain did not send.
+func (w *WarpSigner) Sign(msg *WarpUnsignedMessage) ([]byte, error) {
+	if msg.NetworkID != w.networkID {
+		return nil, fmt.Errorf("%w: %d", ErrWrongWarpNetwork, msg.NetworkID)
+	}
+	if msg.SourceChainID != w.chainID {
+		return nil, fmt.Errorf("%w: %s", ErrWrongWarpSourceChain, msg.SourceChainID)
+	}
+	sig, err := w.signer.Sign(msg.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return bls.SignatureToBytes(sig), nil
+}
+
+// VerifyWarpSignature checks that [sig] is a signature of [msg] by the
+// validator registered with [pop]. The proof of possession is checked first,
+// so that keys registered without one are not trusted.
+func VerifyWarpSignature(pop *ProofOfPossession, msg *WarpUnsignedMessage, sig []byte) error {
+	pk, err := warpPublicKey(pop)
+	if err != nil {
+		return err
+	}
+	s, err := bls.SignatureFromBytes(sig)
+	if err != nil || !bls.Verify(pk, s, msg.Bytes()) {
+		return ErrInvalidWarpSignature
+	}
+	return nil
+}
+
+// AggregateWarpSignatures verifies the signature of [msg] by each validator
+// registered with [pops], [sigs][i] being that of [pops][i], and returns
+// their aggregate, as relayers attach to the message. The proofs of
+// possession are checked so that a rogue key cannot forge the aggregate.
+func AggregateWarpSignatures(msg *WarpUnsignedMessage, pops []*ProofOfPossession, sigs [][]byte) ([]byte, error) {
+	if len(sigs) == 0 {
+		return nil, ErrNoWarpSignatures
+	}
+	if len(pops) != len(sigs) {
+		return nil, fmt.Errorf("%w: %d signatures for %d validators", ErrWarpSignatureMismatch, len(sigs), len(pops))
+	}
+	b := msg.Bytes()
+	parsed := make([]*bls.Signature, len(sigs))
+	for i, pop := range pops {
+		pk, err := warpPublicKey(pop)
+		if err != nil {
+			return nil, fmt.Errorf("validator %d: %w", i, err)
+		}
+		s, err := bls.SignatureFromBytes(sigs[i])
+		if err != nil || !bls.Verify(pk, s, b) {
+			return nil, fmt.Errorf("validator %d: %w", i, ErrInvalidWarpSignature)
+		}
+		parsed[i] = s
+	}
+	agg, err := bls.AggregateSignatures(parsed)
+	if err != nil {
+		return nil, err
+	}
+	return bls.SignatureToBytes(agg), nil
+}
+
+// warpPublicKey returns the public key registered with [pop] once its proof
+// of possession is verified
+func warpPublicKey(pop *ProofOfPossession) (*bls.PublicKey, error) {
+	if err := pop.Verify(); err != nil {
+		return nil, err
+	}
+	return bls.PublicKeyFromCompressedBytes(pop.PublicKey[:])
+}
+
+// warpReader reads the fields of a warp encoding, setting err once [b] is too
+// short
+type warpReader struct {
+	b   []byte
+	err bool
+}
+
+func (r *warpReader) next(n int) []byte {
+	if r.err || n > len(r.b) {
+		r.err = true
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *warpReader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *warpReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
+
+// bytes reads a length prefixed field
+func (r *warpReader) bytes() []byte {
+	n := r.uint32()
+	if uint64(n) > uint64(len(r.b)) {
+		r.err = true
+		return nil
+	}
+	return slices.Clone(r.next(int(n)))
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/crypto/bls"
+	"github.com/luxfi/ids"
+)
+
+func TestWarpMessageEncoding(t *testing.T) {
+	require := require.New(t)
+
+	call := &WarpAddressedCall{
+		SourceAddress: []byte{1, 2, 3},
+		Payload:       []byte("teleporter"),
+	}
+	msg := &WarpUnsignedMessage{
+		NetworkID:     1,
+		SourceChainID: ids.GenerateTestID(),
+		Payload:       call.Bytes(),
+	}
+	b := msg.Bytes()
+	require.Len(b, 2+4+32+4+len(msg.Payload))
+	require.Equal(ids.ID(sha256.Sum256(b)), msg.ID())
+
+	parsed, err := ParseWarpUnsignedMessage(b)
+	require.NoError(err)
+	require.Equal(msg, parsed)
+	payload, err := ParseWarpPayload(parsed.Payload)
+	require.NoError(err)
+	require.Equal(call, payload)
+
+	hash := NewWarpHash([]byte("large data"))
+	payload, err = ParseWarpPayload(hash.Bytes())
+	require.NoError(err)
+	require.Equal(hash, payload)
+
+	_, err = ParseWarpUnsignedMessage(b[:len(b)-1])
+	require.ErrorIs(err, ErrInvalidWarpMessage)
+	_, err = ParseWarpUnsignedMessage(append(b, 0))
+	require.ErrorIs(err, ErrInvalidWarpMessage)
+	_, err = ParseWarpPayload(hash.Bytes()[:10])
+	require.ErrorIs(err, ErrInvalidWarpPayload)
+	_, err = ParseWarpPayload([]byte{0, 0, 0, 0, 0, 7})
+	require.ErrorIs(err, ErrUnknownWarpPayloadType)
+}
+
+func TestWarpSigner(t *testing.T) {
+	require := require.New(t)
+
+	chainID := ids.GenerateTestID()
+	msg := &WarpUnsignedMessage{
+		NetworkID:     1,
+		SourceChainID: chainID,
+		Payload:       NewWarpHash([]byte("block")).Bytes(),
+	}
+
+	var (
+		pops []*ProofOfPossession
+		sigs [][]byte
+	)
+	for range 3 {
+		key, err := NewStakingKey()
+		require.NoError(err)
+		pop, err := key.ProofOfPossession()
+		require.NoError(err)
+
+		signer := NewWarpSigner(key, 1, chainID)
+		require.Equal(pop.PublicKey[:], signer.PublicKeyBytes())
+		sig, err := signer.Sign(msg)
+		require.NoError(err)
+		require.Len(sig, bls.SignatureLen)
+		require.NoError(VerifyWarpSignature(pop, msg, sig))
+
+		pops = append(pops, pop)
+		sigs = append(sigs, sig)
+	}
+
+	agg, err := AggregateWarpSignatures(msg, pops, sigs)
+	require.NoError(err)
+	aggSig, err := bls.SignatureFromBytes(agg)
+	require.NoError(err)
+	var pks []*bls.PublicKey
+	for _, pop := range pops {
+		pk, err := bls.PublicKeyFromCompressedBytes(pop.PublicKey[:])
+		require.NoError(err)
+		pks = append(pks, pk)
+	}
+	aggPK, err := bls.AggregatePublicKeys(pks)
+	require.NoError(err)
+	require.True(bls.Verify(aggPK, aggSig, msg.Bytes()))
+
+	// Signatures of another validator, or of another message, are rejected
+	err = VerifyWarpSignature(pops[0], msg, sigs[1])
+	require.ErrorIs(err, ErrInvalidWarpSignature)
+	_, err = AggregateWarpSignatures(msg, pops, [][]byte{sigs[0], sigs[0], sigs[2]})
+	require.ErrorIs(err, ErrInvalidWarpSignature)
+	_, err = AggregateWarpSignatures(msg, pops[:2], sigs)
+	require.ErrorIs(err, ErrWarpSignatureMismatch)
+	_, err = AggregateWarpSignatures(msg, nil, nil)
+	require.ErrorIs(err, ErrNoWarpSignatures)
+
+	// Keys registered without a valid proof of possession are not trusted
+	forged := *pops[0]
+	forged.ProofOfPossession = pops[1].ProofOfPossession
+	err = VerifyWarpSignature(&forged, msg, sigs[0])
+	require.ErrorIs(err, ErrInvalidProofOfPossession)
+
+	// Validators only sign the messages of their chain
+	key, err := NewStakingKey()
+	require.NoError(err)
+	signer := NewWarpSigner(key, 1, chainID)
+	_, err = signer.Sign(&WarpUnsignedMessage{NetworkID: 2, SourceChainID: chainID})
+	require.ErrorIs(err, ErrWrongWarpNetwork)
+	_, err = signer.Sign(&WarpUnsignedMessage{NetworkID: 1, SourceChainID: ids.GenerateTestID()})
+	require.ErrorIs(err, ErrWrongWarpSourceChain)
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/luxfi/crypto/secp256k1"
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+var (
+	ErrWatchOnly = errors.New("keychain is watch-only and cannot sign")
+
+	_ RangeKeychain   = (*watchOnlyKeychain)(nil)
+	_ PublicKeySigner = (*watchOnlySigner)(nil)
+)
+
+// watchOnlyKeychain tracks addresses without holding any private keys
+type watchOnlyKeychain struct {
+	addrs   set.Set[ids.ShortID]
+	pubKeys map[ids.ShortID][]byte
+}
+
+// NewWatchOnlyKeychain creates a keychain of the addresses of the compressed
+// secp256k1 [pubKeys] and of [addrs]. Its signers report their address and,
+// when known, their public key, but fail to sign with ErrWatchOnly. This
+// allows balances to be tracked and unsigned transactions to be built on a
+// machine that holds no keys.
+func NewWatchOnlyKeychain(pubKeys [][]byte, addrs []ids.ShortID) (Keychain, error) {
+	w := &watchOnlyKeychain{
+		addrs:   set.Of(addrs...),
+		pubKeys: make(map[ids.ShortID][]byte, len(pubKeys)),
+	}
+	for _, pubKey := range pubKeys {
+		pk, err := secp256k1.ToPublicKey(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		addr := pk.Address()
+		w.addrs.Add(addr)
+		w.pubKeys[addr] = bytes.Clone(pubKey)
+	}
+	return w, nil
+}
+
+// WatchOnly returns a watch-only copy of [kc], holding its current addresses
+// and the public keys of its signers, as reported by PublicKeyOf
+func WatchOnly(kc Keychain) Keychain {
+	w := &watchOnlyKeychain{
+		addrs:   set.NewSet[ids.ShortID](0),
+		pubKeys: make(map[ids.ShortID][]byte),
+	}
+	Range(kc, func(addr ids.ShortID, signer Signer) bool {
+		w.addrs.Add(addr)
+		if pubKey, ok := PublicKeyOf(signer); ok {
+			w.pubKeys[addr] = bytes.Clone(pubKey)
+		}
+		return true
+	})
+	return w
+}
+
+func (w *watchOnlyKeychain) Get(addr ids.ShortID) (Signer, bool) {
+	if !w.addrs.Contains(addr) {
+		return nil, false
+	}
+	return &watchOnlySigner{
+		addr:   addr,
+		pubKey: w.pubKeys[addr],
+	}, true
+}
+
+func (w *watchOnlyKeychain) Addresses() set.Set[ids.ShortID] {
+	return w.addrs
+}
+
+func (w *watchOnlyKeychain) Range(f func(ids.ShortID, Signer) bool) {
+	for addr := range w.addrs {
+		signer, _ := w.Get(addr)
+		if !f(addr, signer) {
+			return
+		}
+	}
+}
+
+type watchOnlySigner struct {
+	addr   ids.ShortID
+	pubKey []byte
+}
+
+func (*watchOnlySigner) SignHash([]byte) ([]byte, error) {
+	return nil, ErrWatchOnly
+}
+
+func (*watchOnlySigner) Sign([]byte) ([]byte, error) {
+	return nil, ErrWatchOnly
+}
+
+func (w *watchOnlySigner) Address() ids.ShortID {
+	return w.addr
+}
+
+// PublicKey returns the public key of the address, or nil if the keychain
+// was only given the address
+func (w *watchOnlySigner) PublicKey() []byte {
+	return w.pubKey
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+package keychain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/luxfi/ids"
+	"github.com/luxfi/math/set"
+)
+
+func TestWatchOnlyKeychain(t *testing.T) {
+	require := require.New(t)
+
+	key, err := DeterministicKey([]byte("watch"), 0)
+	require.NoError(err)
+	addrOnly := ids.GenerateTestShortID()
+
+	kc, err := NewWatchOnlyKeychain([][]byte{key.PublicKey().Bytes()}, []ids.ShortID{addrOnly})
+	require.NoError(err)
+	require.True(kc.Addresses().Equals(set.Of(key.Address(), addrOnly)))
+
+	signer, ok := kc.Get(key.Address())
+	require.True(ok)
+	require.Equal(key.Address(), signer.Address())
+	require.Equal(key.PublicKey().Bytes(), signer.(PublicKeySigner).PublicKey())
+	_, err = signer.Sign([]byte("payload"))
+	require.ErrorIs(err, ErrWatchOnly)
+	_, err = signer.SignHash(make([]byte, 32))
+	require.ErrorIs(err, ErrWatchOnly)
+
+	signer, ok = kc.Get(addrOnly)
+	require.True(ok)
+	require.Nil(signer.(PublicKeySigner).PublicKey())
+
+	_, ok = kc.Get(ids.GenerateTestShortID())
+	require.False(ok)
+
+	_, err = NewWatchOnlyKeychain([][]byte{{1, 2, 3}}, nil)
+	require.Error(err)
+}
+
+func TestWatchOnly(t *testing.T) {
+	require := require.New(t)
+
+	soft, err := NewTestKeychain([]byte("watch"), 2)
+	require.NoError(err)
+
+	kc := WatchOnly(soft)
+	require.True(kc.Addresses().Equals(soft.Addresses()))
+	for _, key := range soft.Keys() {
+		signer, ok := kc.Get(key.Address())
+		require.True(ok)
+		require.Equal(key.PublicKey().Bytes(), signer.(PublicKeySigner).PublicKey())
+		_, err := signer.Sign([]byte("payload"))
+		require.ErrorIs(err, ErrWatchOnly)
+	}
+
+	// The cross-chain view works from public keys alone
+	c, err := NewCrossChainKeychain(kc)
+	require.NoError(err)
+	require.Equal(2, c.EVMAddresses().Len())
+}
@@ -0,0 +1,149 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build js && wasm
+
+package keychain
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"syscall/js"
+)
+
+var (
+	ErrNotLedgerDevice = errors.New("WebHID device is not a ledger device")
+	ErrWebHIDRejected  = errors.New("WebHID request failed")
+)
+
+// NewWebHIDTransport speaks the ledger HID framing over [device], a WebHID
+// HIDDevice of a ledger device granted to the page, such as one returned by
+//
+//	navigator.hid.requestDevice({filters: [{vendorId: 0x2c97}]})
+//
+// The device is opened if it is not yet, and closed with the transport.
+// WebHID must be called from a goroutine other than the one running a JS
+// callback, as the transport waits for the promises of the device.
+func NewWebHIDTransport(device js.Value) (Transport, error) {
+	if vendor := device.Get("vendorId"); vendor.Type() != js.TypeNumber || uint16(vendor.Int()) != LedgerVendorID {
+		return nil, ErrNotLedgerDevice
+	}
+	dev := &webHIDDevice{
+		device:  device,
+		reports: make(chan []byte, 16),
+		closed:  make(chan struct{}),
+	}
+	if !device.Get("opened").Truthy() {
+		if _, err := awaitPromise(device.Call("open")); err != nil {
+			return nil, err
+		}
+	}
+	dev.onReport = js.FuncOf(dev.inputReport)
+	device.Call("addEventListener", "inputreport", dev.onReport)
+	model := LedgerModelFromProductID(uint16(device.Get("productId").Int()))
+	return NewHIDTransport(dev, model), nil
+}
+
+// webHIDDevice adapts a WebHID HIDDevice to the reads and writes of reports
+// expected by NewHIDTransport
+type webHIDDevice struct {
+	device   js.Value
+	onReport js.Func
+	reports  chan []byte
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// inputReport queues the report of an inputreport event, dropping it if the
+// transport does not keep up
+func (w *webHIDDevice) inputReport(_ js.Value, args []js.Value) any {
+	if len(args) == 0 {
+		return nil
+	}
+	data := args[0].Get("data")
+	view := js.Global().Get("Uint8Array").New(data.Get("buffer"), data.Get("byteOffset"), data.Get("byteLength"))
+	report := make([]byte, view.Length())
+	js.CopyBytesToGo(report, view)
+	select {
+	case w.reports <- report:
+	default:
+	}
+	return nil
+}
+
+func (w *webHIDDevice) Read(p []byte) (int, error) {
+	select {
+	case report := <-w.reports:
+		return copy(p, report), nil
+	case <-w.closed:
+		return 0, io.EOF
+	}
+}
+
+// Write sends [p], a report number followed by the report, as an output
+// report
+func (w *webHIDDevice) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	select {
+	case <-w.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+	data := js.Global().Get("Uint8Array").New(len(p) - 1)
+	js.CopyBytesToJS(data, p[1:])
+	if _, err := awaitPromise(w.device.Call("sendReport", int(p[0]), data)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *webHIDDevice) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.closed)
+		w.device.Call("removeEventListener", "inputreport", w.onReport)
+		w.onReport.Release()
+		_, err = awaitPromise(w.device.Call("close"))
+	})
+	return err
+}
+
+// awaitPromise waits for [promise] to settle, returning its value or its
+// rejection as an error wrapping ErrWebHIDRejected
+func awaitPromise(promise js.Value) (js.Value, error) {
+	type result struct {
+		value js.Value
+		err   error
+	}
+	done := make(chan result, 1)
+	onFulfilled := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		done <- result{value: firstArg(args)}
+		return nil
+	})
+	defer onFulfilled.Release()
+	onRejected := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		reason := firstArg(args)
+		if reason.Type() == js.TypeObject && reason.Get("message").Type() == js.TypeString {
+			reason = reason.Get("message")
+		}
+		done <- result{err: fmt.Errorf("%w: %s", ErrWebHIDRejected, reason.String())}
+		return nil
+	})
+	defer onRejected.Release()
+
+	promise.Call("then", onFulfilled, onRejected)
+	r := <-done
+	return r.value, r.err
+}
+
+func firstArg(args []js.Value) js.Value {
+	if len(args) == 0 {
+		return js.Undefined()
+	}
+	return args[0]
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2019-2025, Lux Industries, Inc. All rights reserved.
+// See the file LICENSE for licensing terms.
+
+//go:build js && wasm
+
+package keychain
+
+import (
+	"encoding/binary"
+	"syscall/js"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebHIDDevice is a WebHID HIDDevice answering the APDUs it receives with
+// a fake ledger app
+type fakeWebHIDDevice struct {
+	app      *fakeLuxApp
+	object   js.Value
+	listener js.Value
+	funcs    []js.Func
+	// pending buffers the frames of the APDU being received
+	pending []byte
+	closed  bool
+}
+
+func newFakeWebHIDDevice(t *testing.T, vendorID uint16) *fakeWebHIDDevice {
+	d := &fakeWebHIDDevice{
+		app:    newFakeLuxApp(),
+		object: js.Global().Get("Object").New(),
+	}
+	d.object.Set("vendorId", int(vendorID))
+	d.object.Set("productId", 0x4011)
+	d.object.Set("opened", false)
+	d.method("open", func([]js.Value) any {
+		d.object.Set("opened", true)
+		return resolved()
+	})
+	d.method("close", func([]js.Value) any {
+		d.closed = true
+		return resolved()
+	})
+	d.method("addEventListener", func(args []js.Value) any {
+		d.listener = args[1]
+		return nil
+	})
+	d.method("removeEventListener", func([]js.Value) any {
+		d.listener = js.Undefined()
+		return nil
+	})
+	d.method("sendReport", d.sendReport)
+	t.Cleanup(func() {
+		for _, f := range d.funcs {
+			f.Release()
+		}
+	})
+	return d
+}
+
+func (d *fakeWebHIDDevice) method(name string, fn func([]js.Value) any) {
+	f := js.FuncOf(func(_ js.Value, args []js.Value) any {
+		return fn(args)
+	})
+	d.funcs = append(d.funcs, f)
+	d.object.Set(name, f)
+}
+
+func (d *fakeWebHIDDevice) sendReport(args []js.Value) any {
+	if args[0].Int() != 0 || args[1].Length() != hidPacketLen {
+		return js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New("bad report"))
+	}
+	report := make([]byte, hidPacketLen)
+	js.CopyBytesToGo(report, args[1])
+	d.pending = append(d.pending, report[5:]...)
+	if length := int(binary.BigEndian.Uint16(d.pending)); len(d.pending) >= 2+length {
+		resp, _ := d.app.Exchange(d.pending[2 : 2+length])
+		d.pending = nil
+		header := binary.BigEndian.AppendUint16(nil, hidChannel)
+		for _, frame := range ledgerFrames(header, resp, hidPacketLen) {
+			data := js.Global().Get("Uint8Array").New(hidPacketLen)
+			js.CopyBytesToJS(data, frame)
+			event := js.Global().Get("Object").New()
+			event.Set("data", js.Global().Get("DataView").New(data.Get("buffer")))
+			d.listener.Invoke(event)
+		}
+	}
+	return resolved()
+}
+
+func resolved() js.Value {
+	return js.Global().Get("Promise").Call("resolve")
+}
+
+func TestWebHIDTransport(t *testing.T) {
+	require := require.New(t)
+
+	fake := newFakeWebHIDDevice(t, LedgerVendorID)
+	transport, err := NewWebHIDTransport(fake.object)
+	require.NoError(err)
+	require.True(fake.object.Get("opened").Bool())
+	require.Equal(LedgerNanoX, transport.(ModelTransport).Model())
+
+	device := NewLedgerDevice(transport)
+	version, err := device.Version()
+	require.NoError(err)
+	require.Equal(LedgerVersion{Major: 1, Minor: 2, Patch: 3}, version)
+
+	key, err := DeterministicKey(fake.app.seed, 7)
+	require.NoError(err)
+	addrs, err := device.GetAddresses([]uint32{7})
+	require.NoError(err)
+	require.Equal(key.Address(), addrs[0])
+
+	require.NoError(device.Disconnect())
+	require.True(fake.closed)
+}
+
+func TestWebHIDTransportNotLedger(t *testing.T) {
+	_, err := NewWebHIDTransport(newFakeWebHIDDevice(t, 0x1050).object)
+	require.ErrorIs(t, err, ErrNotLedgerDevice)
+}